@@ -29,6 +29,10 @@ const (
 	ActivityPipelineTaskStatementUpdate ActivityType = "bb.pipeline.task.statement.update"
 	// ActivityPipelineTaskEarliestAllowedTimeUpdate is the type for updating pipeline task the earliest allowed time.
 	ActivityPipelineTaskEarliestAllowedTimeUpdate ActivityType = "bb.pipeline.task.general.earliest-allowed-time.update"
+	// ActivityPipelineRolloutHalt is the type for halting the rollout of pipeline stage on failures.
+	ActivityPipelineRolloutHalt ActivityType = "bb.pipeline.rollout.halt"
+	// ActivityPipelineTaskMaintenanceWindowOverride is the type for overriding the maintenance windows of pipeline task.
+	ActivityPipelineTaskMaintenanceWindowOverride ActivityType = "bb.pipeline.task.general.maintenance-window.override"
 
 	// Member related.
 
@@ -150,6 +154,28 @@ type ActivityPipelineTaskEarliestAllowedTimeUpdatePayload struct {
 	TaskName  string `json:"taskName"`
 }
 
+// ActivityPipelineTaskMaintenanceWindowOverridePayload is the API message payloads for overriding the maintenance windows of pipeline task.
+type ActivityPipelineTaskMaintenanceWindowOverridePayload struct {
+	TaskID int `json:"taskId"`
+	// Reason is why the task was not allowed to start when it's overridden.
+	Reason string `json:"reason"`
+	// Used by inbox to display info without paying the join cost
+	IssueName string `json:"issueName"`
+	TaskName  string `json:"taskName"`
+}
+
+// ActivityPipelineRolloutHaltPayload is the API message payloads for halting the rollout of pipeline stage.
+type ActivityPipelineRolloutHaltPayload struct {
+	StageID         int    `json:"stageId"`
+	StageName       string `json:"stageName"`
+	FailureCount    int    `json:"failureCount"`
+	MaxFailureCount int    `json:"maxFailureCount"`
+	// CanceledTaskCount is the number of tasks not started yet which are canceled by the halt.
+	CanceledTaskCount int `json:"canceledTaskCount"`
+	// Used by inbox to display info without paying the join cost
+	IssueName string `json:"issueName"`
+}
+
 // ActivityMemberCreatePayload is the API message payloads for creating members.
 type ActivityMemberCreatePayload struct {
 	PrincipalID    int          `json:"principalId"`
@@ -12,6 +12,8 @@ type ServerInfo struct {
 	Port           string `json:"port"`
 	ExternalURL    string `json:"externalUrl"`
 	NeedAdminSetup bool   `json:"needAdminSetup"`
+	// Leader is whether the server is the leader running the background runners among the replicas.
+	Leader bool `json:"leader"`
 	// Rand may be based on the server start time, thus exposing startedTs to the client may cause security issues (e.g. jwt key is based on Rand).
 	// StartedTs   int64  `json:"startedTs"`
 }
@@ -2,6 +2,8 @@ package api
 
 import (
 	"encoding/json"
+
+	"github.com/bytebase/bytebase/plugin/parser/differ"
 )
 
 // AnomalyType is the type of a task.
@@ -20,6 +22,10 @@ const (
 	AnomalyDatabaseConnection AnomalyType = "bb.anomaly.database.connection"
 	// AnomalyDatabaseSchemaDrift is the anomaly type for database schema drifts.
 	AnomalyDatabaseSchemaDrift AnomalyType = "bb.anomaly.database.schema.drift"
+	// AnomalyDatabaseSizeGrowth is the anomaly type for abnormal database size growth.
+	AnomalyDatabaseSizeGrowth AnomalyType = "bb.anomaly.database.size.growth"
+	// AnomalyDatabaseMigrationChecksumMismatch is the anomaly type for applied migrations diverging from their recorded checksums.
+	AnomalyDatabaseMigrationChecksumMismatch AnomalyType = "bb.anomaly.database.migration.checksum-mismatch"
 )
 
 // AnomalySeverity is the severity of anomaly.
@@ -39,8 +45,12 @@ func AnomalySeverityFromType(anomalyType AnomalyType) AnomalySeverity {
 	switch anomalyType {
 	case AnomalyDatabaseBackupPolicyViolation:
 		return AnomalySeverityMedium
+	case AnomalyDatabaseSizeGrowth:
+		return AnomalySeverityMedium
 	case AnomalyDatabaseBackupMissing:
 		return AnomalySeverityHigh
+	case AnomalyDatabaseMigrationChecksumMismatch:
+		return AnomalySeverityHigh
 	case AnomalyInstanceConnection:
 	case AnomalyInstanceMigrationSchema:
 	case AnomalyDatabaseConnection:
@@ -84,6 +94,51 @@ type AnomalyDatabaseSchemaDriftPayload struct {
 	Expect string `json:"expect,omitempty"`
 	// The actual schema dumped from the database
 	Actual string `json:"actual,omitempty"`
+	// The structured changes made to the expected schema in the actual schema
+	ChangeList []*differ.SchemaChange `json:"changeList,omitempty"`
+	// The DDL statements to reconcile the actual schema with the expected schema
+	Reconciliation string `json:"reconciliation,omitempty"`
+}
+
+// AnomalyDatabaseSizeGrowthPayload is the API message for database size growth payloads.
+type AnomalyDatabaseSizeGrowthPayload struct {
+	// The data and index size of the database in bytes
+	Size int64 `json:"size,omitempty"`
+	// The size of the previous day
+	PreviousSize int64 `json:"previousSize,omitempty"`
+	// The growth ratio compared with the previous day
+	GrowthRatio float64 `json:"growthRatio,omitempty"`
+	// The thresholds violated, 0 if not violated
+	MaxSize             int64   `json:"maxSize,omitempty"`
+	MaxDailyGrowthRatio float64 `json:"maxDailyGrowthRatio,omitempty"`
+}
+
+// MigrationChecksumMismatchSource is the source where a migration checksum mismatch is found.
+type MigrationChecksumMismatchSource string
+
+const (
+	// MigrationChecksumMismatchSourceHistory is the source for the statement stored in the migration history.
+	MigrationChecksumMismatchSourceHistory MigrationChecksumMismatchSource = "HISTORY"
+	// MigrationChecksumMismatchSourceVCS is the source for the migration file in the VCS repository.
+	MigrationChecksumMismatchSourceVCS MigrationChecksumMismatchSource = "VCS"
+)
+
+// MigrationChecksumMismatch is the API message for an applied migration diverging from its recorded checksum.
+type MigrationChecksumMismatch struct {
+	MigrationHistoryID int                             `json:"migrationHistoryId,omitempty"`
+	Version            string                          `json:"version,omitempty"`
+	Source             MigrationChecksumMismatchSource `json:"source,omitempty"`
+	// The migration file path in the VCS repository, only set for the VCS source
+	FilePath string `json:"filePath,omitempty"`
+	// The checksum recorded when the migration was applied
+	Expect string `json:"expect,omitempty"`
+	// The checksum of the current statement or file content
+	Actual string `json:"actual,omitempty"`
+}
+
+// AnomalyDatabaseMigrationChecksumMismatchPayload is the API message for migration checksum mismatch payloads.
+type AnomalyDatabaseMigrationChecksumMismatchPayload struct {
+	MismatchList []*MigrationChecksumMismatch `json:"mismatchList,omitempty"`
 }
 
 // Anomaly is the API message for an anomaly.
@@ -129,6 +184,7 @@ type AnomalyUpsert struct {
 // AnomalyFind is the API message for finding anomalies.
 type AnomalyFind struct {
 	// Standard fields
+	ID        *int
 	RowStatus *RowStatus
 
 	// Related fields
@@ -42,6 +42,36 @@ type Deployment struct {
 // DeploymentSpec is the API message for deployment specification.
 type DeploymentSpec struct {
 	Selector *LabelSelector `json:"selector"`
+	// Rollout is the canary rollout controls of the matched databases. Nil means rolling out all databases at once.
+	Rollout *DeploymentRollout `json:"rollout,omitempty"`
+}
+
+// DeploymentRollout is the API message for rolling out the databases of a deployment in batches.
+type DeploymentRollout struct {
+	// BatchSize is the number of databases in each batch.
+	BatchSize int `json:"batchSize,omitempty"`
+	// BatchPercentage is the percentage of databases in each batch, which is used if BatchSize is not set.
+	// All databases are in one batch if neither is set.
+	BatchPercentage int `json:"batchPercentage,omitempty"`
+	// PauseSeconds is the pause between the end of a batch and the start of the next batch.
+	PauseSeconds int `json:"pauseSeconds,omitempty"`
+	// MaxFailureCount is the maximum number of failed databases. The rest of the rollout halts once it's exceeded.
+	MaxFailureCount int `json:"maxFailureCount"`
+}
+
+// GetBatchSize returns the number of databases in each batch given the total number of databases.
+func (r *DeploymentRollout) GetBatchSize(total int) int {
+	if r.BatchSize > 0 {
+		return r.BatchSize
+	}
+	if r.BatchPercentage > 0 {
+		size := (total*r.BatchPercentage + 99) / 100
+		if size < 1 {
+			return 1
+		}
+		return size
+	}
+	return total
 }
 
 // LabelSelector is the API message for label selector.
@@ -51,15 +81,18 @@ type LabelSelector struct {
 }
 
 // OperatorType is the type of label selector requirement operator.
-// Valid operators are In, Exists.
-// Note: NotIn and DoesNotExist are not supported initially.
+// Valid operators are In, NotIn, Exists and DoesNotExist, which have the same semantics as Kubernetes label selectors.
 type OperatorType string
 
 const (
 	// InOperatorType is the operator type for In.
 	InOperatorType OperatorType = "In"
+	// NotInOperatorType is the operator type for NotIn, which also matches the databases without the label.
+	NotInOperatorType OperatorType = "NotIn"
 	// ExistsOperatorType is the operator type for Exists.
 	ExistsOperatorType OperatorType = "Exists"
+	// DoesNotExistOperatorType is the operator type for DoesNotExist.
+	DoesNotExistOperatorType OperatorType = "DoesNotExist"
 )
 
 // LabelSelectorRequirement is the API message for label selector.
@@ -74,6 +107,19 @@ type LabelSelectorRequirement struct {
 	Values []string `json:"values"`
 }
 
+// DeploymentPreview is the API message for previewing the databases matched by a deployment.
+type DeploymentPreview struct {
+	Name         string                       `json:"name"`
+	DatabaseList []*DeploymentPreviewDatabase `json:"databaseList"`
+}
+
+// DeploymentPreviewDatabase is the API message for a database matched by a deployment.
+type DeploymentPreviewDatabase struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	InstanceName string `json:"instanceName"`
+}
+
 // DeploymentConfigFind is the find request for deployment configs.
 type DeploymentConfigFind struct {
 	ID *int
@@ -114,11 +160,11 @@ func ValidateAndGetDeploymentSchedule(payload string) (*DeploymentSchedule, erro
 		hasEnv := false
 		for _, e := range d.Spec.Selector.MatchExpressions {
 			switch e.Operator {
-			case InOperatorType:
+			case InOperatorType, NotInOperatorType:
 				if len(e.Values) == 0 {
 					return nil, common.Errorf(common.Invalid, "expression key %q with %q operator should have at least one value", e.Key, e.Operator)
 				}
-			case ExistsOperatorType:
+			case ExistsOperatorType, DoesNotExistOperatorType:
 				if len(e.Values) > 0 {
 					return nil, common.Errorf(common.Invalid, "expression key %q with %q operator shouldn't have values", e.Key, e.Operator)
 				}
@@ -135,6 +181,17 @@ func ValidateAndGetDeploymentSchedule(payload string) (*DeploymentSchedule, erro
 		if !hasEnv {
 			return nil, common.Errorf(common.Invalid, "deployment should contain %q label", EnvironmentKeyName)
 		}
+		if r := d.Spec.Rollout; r != nil {
+			if r.BatchSize < 0 || r.BatchPercentage < 0 || r.PauseSeconds < 0 || r.MaxFailureCount < 0 {
+				return nil, common.Errorf(common.Invalid, "deployment %q rollout should not have negative values", d.Name)
+			}
+			if r.BatchPercentage > 100 {
+				return nil, common.Errorf(common.Invalid, "deployment %q rollout batch percentage should not exceed 100", d.Name)
+			}
+			if r.BatchSize > 0 && r.BatchPercentage > 0 {
+				return nil, common.Errorf(common.Invalid, "deployment %q rollout should set either batch size or batch percentage", d.Name)
+			}
+		}
 	}
 	return schedule, nil
 }
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"github.com/bytebase/bytebase/plugin/advisor/index"
+)
+
+// IndexAdviceSource is the source of the queries analyzed by the index advisor.
+type IndexAdviceSource string
+
+const (
+	// IndexAdviceSourceSlowQuery is the source for the slow queries collected from the instance.
+	IndexAdviceSourceSlowQuery IndexAdviceSource = "SLOW_QUERY"
+	// IndexAdviceSourceSQLEditor is the source for the queries executed in the SQL editor.
+	IndexAdviceSourceSQLEditor IndexAdviceSource = "SQL_EDITOR"
+)
+
+// IndexAdvice is the API message for the index advice of a database.
+type IndexAdvice struct {
+	DatabaseID int `jsonapi:"primary,indexAdvice"`
+
+	// Domain specific fields
+	// RecommendationList is the list of recommended indexes ranked by the estimated benefit.
+	RecommendationList []*index.Recommendation `jsonapi:"attr,recommendationList"`
+	// RedundantIndexList is the list of existing indexes covered by other indexes.
+	RedundantIndexList []*index.RedundantIndex `jsonapi:"attr,redundantIndexList"`
+}
+
+// IndexAdviceIssueCreate is the API message for creating a schema update issue applying the index advice.
+type IndexAdviceIssueCreate struct {
+	// Domain specific fields
+	// Statement is the statement to create or drop the indexes.
+	Statement string `jsonapi:"attr,statement"`
+}
@@ -152,6 +152,7 @@ type MigrationHistory struct {
 	SchemaPrev            string             `jsonapi:"attr,schemaPrev"`
 	ExecutionDurationNs   int64              `jsonapi:"attr,executionDurationNs"`
 	// This is a string instead of int as the issue id may come from other issue tracking system in the future
-	IssueID string `jsonapi:"attr,issueId"`
-	Payload string `jsonapi:"attr,payload"`
+	IssueID  string `jsonapi:"attr,issueId"`
+	Payload  string `jsonapi:"attr,payload"`
+	Checksum string `jsonapi:"attr,checksum"`
 }
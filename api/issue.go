@@ -15,6 +15,10 @@ const (
 	IssueDone IssueStatus = "DONE"
 	// IssueCanceled is the issue status for CANCELED.
 	IssueCanceled IssueStatus = "CANCELED"
+	// IssueDraft is the issue status for DRAFT.
+	// A draft issue is created from an unmerged VCS pull request. Its task checks run, but its pipeline is not
+	// scheduled until the issue is activated to OPEN.
+	IssueDraft IssueStatus = "DRAFT"
 )
 
 // IssueType is the type of an issue.
@@ -121,6 +125,26 @@ type IssueCreate struct {
 
 	// ValidateOnly validates the request and previews the review, but does not actually post it.
 	ValidateOnly bool `jsonapi:"attr,validateOnly"`
+	// Draft creates the issue in the DRAFT status, which is only set internally for issues created from VCS pull requests.
+	Draft bool
+}
+
+// IssuePayload is the payload of an issue.
+type IssuePayload struct {
+	// PullRequest is the VCS pull request which the issue is created from.
+	PullRequest *IssuePullRequestPayload `json:"pullRequest,omitempty"`
+}
+
+// IssuePullRequestPayload is the VCS pull request information of an issue.
+type IssuePullRequestPayload struct {
+	// RepositoryID is the ID of Bytebase's own repository resource.
+	RepositoryID int `json:"repositoryId"`
+	// PullRequestID is the pull request ID from the external VCS system.
+	PullRequestID string `json:"pullRequestId"`
+	URL           string `json:"url"`
+	Title         string `json:"title"`
+	// FileList is the list of the migration files in the pull request which the issue is created from.
+	FileList []string `json:"fileList"`
 }
 
 // CreateDatabaseContext is the issue create context for creating a database.
@@ -154,6 +178,9 @@ type MigrationDetail struct {
 	// DatabaseName is the name of databases, mutually exclusive to DatabaseID.
 	// This should be set when a project is in tenant mode, and ProjectID is derived from IssueCreate.
 	DatabaseName string `json:"databaseName"`
+	// EnvironmentIDList limits the databases found by DatabaseName to the environments.
+	// Empty means all environments. It is set by the branch mapping of the VCS repository.
+	EnvironmentIDList []int `json:"environmentIdList,omitempty"`
 	// Statement is the statement to update database schema.
 	Statement string `json:"statement"`
 	// EarliestAllowedTs the earliest execution time of the change at system local Unix timestamp in seconds.
@@ -161,6 +188,12 @@ type MigrationDetail struct {
 	// SchemaVersion is parsed from VCS file name.
 	// It is automatically generated in the UI workflow.
 	SchemaVersion string `json:"schemaVersion"`
+	// RepeatableName is the VCS file path of a REPEATABLE type of migration.
+	RepeatableName string `json:"repeatableName,omitempty"`
+	// DataUpdateChunk executes a DATA type of migration in primary key ranged chunks if set.
+	DataUpdateChunk *DataUpdateChunk `json:"dataUpdateChunk,omitempty"`
+	// RowSnapshot snapshots the rows changed by a DATA type of migration for Postgres if set.
+	RowSnapshot bool `json:"rowSnapshot,omitempty"`
 }
 
 // MigrationContext is the issue create context for database migration such as Migrate, Data.
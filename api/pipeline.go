@@ -14,6 +14,8 @@ const (
 	PipelineDone PipelineStatus = "DONE"
 	// PipelineCanceled is the pipeline status for CANCELED.
 	PipelineCanceled PipelineStatus = "CANCELED"
+	// PipelineDraft is the pipeline status for DRAFT.
+	PipelineDraft PipelineStatus = "DRAFT"
 )
 
 // Pipeline is the API message for pipelines.
@@ -47,6 +49,8 @@ type PipelineCreate struct {
 
 	// Domain specific fields
 	Name string `jsonapi:"attr,name"`
+	// Status is set internally and defaults to OPEN.
+	Status PipelineStatus
 }
 
 // PipelineFind is the API message for finding pipelines.
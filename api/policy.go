@@ -2,6 +2,8 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -35,6 +37,12 @@ const (
 	PolicyTypeSQLReview PolicyType = "bb.policy.sql-review"
 	// PolicyTypeEnvironmentTier is the tier of an environment.
 	PolicyTypeEnvironmentTier PolicyType = "bb.policy.environment-tier"
+	// PolicyTypeTaskRetry is the task retry policy type.
+	PolicyTypeTaskRetry PolicyType = "bb.policy.task-retry"
+	// PolicyTypeTaskConcurrency is the task concurrency policy type.
+	PolicyTypeTaskConcurrency PolicyType = "bb.policy.task-concurrency"
+	// PolicyTypeMaintenanceWindow is the maintenance window policy type.
+	PolicyTypeMaintenanceWindow PolicyType = "bb.policy.maintenance-window"
 
 	// PipelineApprovalValueManualNever means the pipeline will automatically be approved without user intervention.
 	PipelineApprovalValueManualNever PipelineApprovalValue = "MANUAL_APPROVAL_NEVER"
@@ -62,10 +70,21 @@ const (
 var (
 	// PolicyTypes is a set of all policy types.
 	PolicyTypes = map[PolicyType]bool{
-		PolicyTypePipelineApproval: true,
-		PolicyTypeBackupPlan:       true,
-		PolicyTypeSQLReview:        true,
-		PolicyTypeEnvironmentTier:  true,
+		PolicyTypePipelineApproval:  true,
+		PolicyTypeBackupPlan:        true,
+		PolicyTypeSQLReview:         true,
+		PolicyTypeEnvironmentTier:   true,
+		PolicyTypeTaskRetry:         true,
+		PolicyTypeTaskConcurrency:   true,
+		PolicyTypeMaintenanceWindow: true,
+	}
+
+	// TaskRetryPolicyTaskTypes is the set of task types which can be retried automatically.
+	TaskRetryPolicyTaskTypes = map[TaskType]bool{
+		TaskDatabaseSchemaUpdate:    true,
+		TaskDatabaseSchemaUpdateSDL: true,
+		TaskDatabaseDataUpdate:      true,
+		TaskDatabaseBackup:          true,
 	}
 )
 
@@ -229,6 +248,242 @@ func UnmarshalEnvironmentTierPolicy(payload string) (*EnvironmentTierPolicy, err
 	return &p, nil
 }
 
+// TaskRetryPolicy is the policy configuration for automatically retrying the tasks failed with transient errors,
+// e.g. a dropped connection or a lock wait timeout.
+type TaskRetryPolicy struct {
+	RuleList []TaskRetryRule `json:"ruleList"`
+}
+
+// TaskRetryRule is the retry configuration for a task type.
+type TaskRetryRule struct {
+	TaskType TaskType `json:"taskType"`
+	// MaxAttempts is the maximum number of runs including the first one.
+	MaxAttempts int `json:"maxAttempts"`
+	// InitialBackoffSeconds is the backoff before the first retry, the backoff doubles for each following retry.
+	InitialBackoffSeconds int `json:"initialBackoffSeconds"`
+	// MaxBackoffSeconds caps the backoff, 0 means no cap.
+	MaxBackoffSeconds int `json:"maxBackoffSeconds"`
+}
+
+func (p *TaskRetryPolicy) String() (string, error) {
+	s, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	return string(s), nil
+}
+
+// GetRule returns the retry rule for the task type, or nil if the task type is not retried.
+func (p *TaskRetryPolicy) GetRule(taskType TaskType) *TaskRetryRule {
+	for i, rule := range p.RuleList {
+		if rule.TaskType == taskType {
+			return &p.RuleList[i]
+		}
+	}
+	return nil
+}
+
+// GetBackoff returns the backoff before the given retry attempt, which starts from 1.
+func (r *TaskRetryRule) GetBackoff(attempt int) time.Duration {
+	backoff := time.Duration(r.InitialBackoffSeconds) * time.Second
+	maxBackoff := time.Duration(r.MaxBackoffSeconds) * time.Second
+	for i := 1; i < attempt; i++ {
+		backoff *= 2
+		if maxBackoff > 0 && backoff >= maxBackoff {
+			break
+		}
+	}
+	if maxBackoff > 0 && backoff > maxBackoff {
+		return maxBackoff
+	}
+	return backoff
+}
+
+// UnmarshalTaskRetryPolicy will unmarshal payload to task retry policy.
+func UnmarshalTaskRetryPolicy(payload string) (*TaskRetryPolicy, error) {
+	var p TaskRetryPolicy
+	if err := json.Unmarshal([]byte(payload), &p); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal task retry policy %q", payload)
+	}
+	return &p, nil
+}
+
+// TaskConcurrencyPolicy is the policy configuration for limiting the number of tasks running at the same time.
+// The limits are enforced by the task scheduler, and zero means no limit.
+type TaskConcurrencyPolicy struct {
+	// MaxConcurrentTasks is the maximum number of tasks running in the environment at the same time.
+	MaxConcurrentTasks int `json:"maxConcurrentTasks"`
+	// InstanceMaxConcurrentTasks is the maximum number of tasks running on each instance of the environment at the same time.
+	InstanceMaxConcurrentTasks int `json:"instanceMaxConcurrentTasks"`
+}
+
+func (p *TaskConcurrencyPolicy) String() (string, error) {
+	s, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	return string(s), nil
+}
+
+// UnmarshalTaskConcurrencyPolicy will unmarshal payload to task concurrency policy.
+func UnmarshalTaskConcurrencyPolicy(payload string) (*TaskConcurrencyPolicy, error) {
+	var p TaskConcurrencyPolicy
+	if err := json.Unmarshal([]byte(payload), &p); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal task concurrency policy %q", payload)
+	}
+	return &p, nil
+}
+
+// MaintenanceWindowPolicy is the policy configuration for when the tasks are allowed to start.
+// The tasks can only start within one of the maintenance windows, or at any time if there is no window,
+// and never during a change freeze.
+type MaintenanceWindowPolicy struct {
+	WindowList []MaintenanceWindow `json:"windowList"`
+	FreezeList []ChangeFreeze      `json:"freezeList"`
+}
+
+// MaintenanceWindow is a recurring window in UTC, e.g. 02:00-04:00 on weekdays.
+type MaintenanceWindow struct {
+	// DayOfWeekList is the days of the week when the window starts, 0 is Sunday. Empty means every day.
+	DayOfWeekList []time.Weekday `json:"dayOfWeekList"`
+	// StartTime and EndTime are in the "HH:MM" format.
+	// The window ends on the next day if EndTime is not after StartTime, e.g. 22:00-02:00.
+	StartTime string `json:"startTime"`
+	EndTime   string `json:"endTime"`
+}
+
+// ChangeFreeze is a one-off period when no task is allowed to start, e.g. a holiday code freeze.
+type ChangeFreeze struct {
+	Title   string `json:"title"`
+	StartTs int64  `json:"startTs"`
+	EndTs   int64  `json:"endTs"`
+}
+
+func (p *MaintenanceWindowPolicy) String() (string, error) {
+	s, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	return string(s), nil
+}
+
+// Check returns whether a task is allowed to start at t, and the reason if not.
+func (p *MaintenanceWindowPolicy) Check(t time.Time) (bool, string) {
+	if freeze := p.getFreeze(t); freeze != nil {
+		return false, fmt.Sprintf("In change freeze %q until %s", freeze.Title, time.Unix(freeze.EndTs, 0).UTC().Format(time.RFC3339))
+	}
+	if len(p.WindowList) == 0 {
+		return true, ""
+	}
+	for _, window := range p.WindowList {
+		// The window containing t starts on the same day or the day before.
+		for _, day := range []time.Time{t, t.AddDate(0, 0, -1)} {
+			start, end, ok := window.getRange(day)
+			if ok && !t.Before(start) && t.Before(end) {
+				return true, ""
+			}
+		}
+	}
+	return false, "Outside the maintenance windows"
+}
+
+// GetNextAllowedTime returns the earliest time from t when a task is allowed to start.
+// It returns the zero time if there is no such time, e.g. none of the windows is valid.
+func (p *MaintenanceWindowPolicy) GetNextAllowedTime(t time.Time) time.Time {
+	// The next allowed time is either the end of a change freeze or the start of a maintenance window.
+	// Each jump either passes a change freeze or reaches a window start, which is allowed unless it's in a change freeze.
+	for i := 0; i <= 2*len(p.FreezeList)+1; i++ {
+		if ok, _ := p.Check(t); ok {
+			return t
+		}
+		if freeze := p.getFreeze(t); freeze != nil {
+			t = time.Unix(freeze.EndTs, 0)
+			continue
+		}
+		t = p.getNextWindowStart(t)
+		if t.IsZero() {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// getFreeze returns the change freeze containing t, and nil if there is none.
+func (p *MaintenanceWindowPolicy) getFreeze(t time.Time) *ChangeFreeze {
+	for i, freeze := range p.FreezeList {
+		if freeze.StartTs <= t.Unix() && t.Unix() < freeze.EndTs {
+			return &p.FreezeList[i]
+		}
+	}
+	return nil
+}
+
+// getNextWindowStart returns the earliest start of the maintenance windows after t, and the zero time if there is none.
+func (p *MaintenanceWindowPolicy) getNextWindowStart(t time.Time) time.Time {
+	var next time.Time
+	for _, window := range p.WindowList {
+		// A weekly window starts within a week.
+		for day := 0; day <= 7; day++ {
+			start, _, ok := window.getRange(t.AddDate(0, 0, day))
+			if ok && start.After(t) {
+				if next.IsZero() || start.Before(next) {
+					next = start
+				}
+				break
+			}
+		}
+	}
+	return next
+}
+
+// getRange returns the range of the window starting on the UTC day of t, and false if the window doesn't start on that day.
+func (w *MaintenanceWindow) getRange(t time.Time) (time.Time, time.Time, bool) {
+	t = t.UTC()
+	if len(w.DayOfWeekList) > 0 {
+		found := false
+		for _, day := range w.DayOfWeekList {
+			if day == t.Weekday() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return time.Time{}, time.Time{}, false
+		}
+	}
+	startMinute, err := parseMaintenanceWindowTime(w.StartTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	endMinute, err := parseMaintenanceWindowTime(w.EndTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	if endMinute <= startMinute {
+		endMinute += 24 * 60
+	}
+	date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return date.Add(time.Duration(startMinute) * time.Minute), date.Add(time.Duration(endMinute) * time.Minute), true
+}
+
+// parseMaintenanceWindowTime parses the "HH:MM" time to the minutes of the day.
+func parseMaintenanceWindowTime(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid maintenance window time %q, should be in the HH:MM format", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// UnmarshalMaintenanceWindowPolicy will unmarshal payload to maintenance window policy.
+func UnmarshalMaintenanceWindowPolicy(payload string) (*MaintenanceWindowPolicy, error) {
+	var p MaintenanceWindowPolicy
+	if err := json.Unmarshal([]byte(payload), &p); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal maintenance window policy %q", payload)
+	}
+	return &p, nil
+}
+
 // ValidatePolicy will validate the policy type and payload values.
 func ValidatePolicy(pType PolicyType, payload string) error {
 	if !PolicyTypes[pType] {
@@ -283,6 +538,66 @@ func ValidatePolicy(pType PolicyType, payload string) error {
 		if p.EnvironmentTier != EnvironmentTierValueProtected && p.EnvironmentTier != EnvironmentTierValueUnprotected {
 			return errors.Errorf("invalid environment tier value %q", p.EnvironmentTier)
 		}
+	case PolicyTypeTaskRetry:
+		p, err := UnmarshalTaskRetryPolicy(payload)
+		if err != nil {
+			return err
+		}
+		taskTypeSeen := make(map[TaskType]bool)
+		for _, rule := range p.RuleList {
+			if !TaskRetryPolicyTaskTypes[rule.TaskType] {
+				return errors.Errorf("invalid task retry rule task type %q", rule.TaskType)
+			}
+			if taskTypeSeen[rule.TaskType] {
+				return errors.Errorf("duplicate task retry rule task type %q", rule.TaskType)
+			}
+			taskTypeSeen[rule.TaskType] = true
+			if rule.MaxAttempts < 1 {
+				return errors.Errorf("invalid max attempts %d for task type %q, should be at least 1", rule.MaxAttempts, rule.TaskType)
+			}
+			if rule.InitialBackoffSeconds < 0 || rule.MaxBackoffSeconds < 0 {
+				return errors.Errorf("invalid backoff for task type %q, should not be negative", rule.TaskType)
+			}
+		}
+	case PolicyTypeTaskConcurrency:
+		p, err := UnmarshalTaskConcurrencyPolicy(payload)
+		if err != nil {
+			return err
+		}
+		if p.MaxConcurrentTasks < 0 || p.InstanceMaxConcurrentTasks < 0 {
+			return errors.Errorf("invalid task concurrency limit %q, should not be negative", payload)
+		}
+	case PolicyTypeMaintenanceWindow:
+		p, err := UnmarshalMaintenanceWindowPolicy(payload)
+		if err != nil {
+			return err
+		}
+		for _, window := range p.WindowList {
+			for _, day := range window.DayOfWeekList {
+				if day < time.Sunday || day > time.Saturday {
+					return errors.Errorf("invalid maintenance window day of week %d", day)
+				}
+			}
+			startMinute, err := parseMaintenanceWindowTime(window.StartTime)
+			if err != nil {
+				return err
+			}
+			endMinute, err := parseMaintenanceWindowTime(window.EndTime)
+			if err != nil {
+				return err
+			}
+			if startMinute == endMinute {
+				return errors.Errorf("invalid maintenance window %s-%s, should not be empty", window.StartTime, window.EndTime)
+			}
+		}
+		for _, freeze := range p.FreezeList {
+			if freeze.Title == "" {
+				return errors.Errorf("change freeze title should not be empty")
+			}
+			if freeze.EndTs <= freeze.StartTs {
+				return errors.Errorf("invalid change freeze %q, should end after it starts", freeze.Title)
+			}
+		}
 	}
 	return nil
 }
@@ -309,6 +624,20 @@ func GetDefaultPolicy(pType PolicyType) (string, error) {
 			EnvironmentTier: EnvironmentTierValueUnprotected,
 		}
 		return policy.String()
+	case PolicyTypeTaskRetry:
+		policy := TaskRetryPolicy{
+			RuleList: []TaskRetryRule{},
+		}
+		return policy.String()
+	case PolicyTypeTaskConcurrency:
+		policy := TaskConcurrencyPolicy{}
+		return policy.String()
+	case PolicyTypeMaintenanceWindow:
+		policy := MaintenanceWindowPolicy{
+			WindowList: []MaintenanceWindow{},
+			FreezeList: []ChangeFreeze{},
+		}
+		return policy.String()
 	}
 	return "", nil
 }
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenanceWindowPolicyGetNextAllowedTime(t *testing.T) {
+	date := func(month time.Month, day, hour int) time.Time {
+		year := 2022
+		if month == time.January {
+			year = 2023
+		}
+		return time.Date(year, month, day, hour, 0, 0, 0, time.UTC)
+	}
+	weekdays := []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+	holiday := ChangeFreeze{Title: "Holiday", StartTs: date(time.December, 23, 0).Unix(), EndTs: date(time.January, 3, 0).Unix()}
+
+	tests := []struct {
+		name   string
+		policy MaintenanceWindowPolicy
+		t      time.Time
+		want   time.Time
+	}{
+		{
+			name:   "no window",
+			policy: MaintenanceWindowPolicy{},
+			t:      date(time.October, 31, 10),
+			want:   date(time.October, 31, 10),
+		},
+		{
+			name: "in window",
+			policy: MaintenanceWindowPolicy{
+				WindowList: []MaintenanceWindow{{DayOfWeekList: weekdays, StartTime: "02:00", EndTime: "04:00"}},
+			},
+			t:    date(time.October, 31, 3),
+			want: date(time.October, 31, 3),
+		},
+		{
+			name: "before window",
+			policy: MaintenanceWindowPolicy{
+				WindowList: []MaintenanceWindow{{DayOfWeekList: weekdays, StartTime: "02:00", EndTime: "04:00"}},
+			},
+			t:    date(time.October, 31, 1),
+			want: date(time.October, 31, 2),
+		},
+		{
+			name: "after the last window of the week",
+			policy: MaintenanceWindowPolicy{
+				WindowList: []MaintenanceWindow{{DayOfWeekList: weekdays, StartTime: "02:00", EndTime: "04:00"}},
+			},
+			t:    date(time.November, 4, 5),
+			want: date(time.November, 7, 2),
+		},
+		{
+			name: "window crossing midnight",
+			policy: MaintenanceWindowPolicy{
+				WindowList: []MaintenanceWindow{{DayOfWeekList: []time.Weekday{time.Saturday}, StartTime: "22:00", EndTime: "02:00"}},
+			},
+			t:    date(time.November, 6, 1),
+			want: date(time.November, 6, 1),
+		},
+		{
+			name: "in change freeze without window",
+			policy: MaintenanceWindowPolicy{
+				FreezeList: []ChangeFreeze{holiday},
+			},
+			t:    date(time.December, 26, 3),
+			want: date(time.January, 3, 0),
+		},
+		{
+			name: "in change freeze and window",
+			policy: MaintenanceWindowPolicy{
+				WindowList: []MaintenanceWindow{{DayOfWeekList: weekdays, StartTime: "02:00", EndTime: "04:00"}},
+				FreezeList: []ChangeFreeze{holiday},
+			},
+			t:    date(time.December, 22, 5),
+			want: date(time.January, 3, 2),
+		},
+	}
+
+	for _, test := range tests {
+		got := test.policy.GetNextAllowedTime(test.t)
+		require.Equal(t, test.want.Unix(), got.Unix(), test.name)
+		allowed, _ := test.policy.Check(test.t)
+		require.Equal(t, test.want.Equal(test.t), allowed, test.name)
+	}
+}
@@ -171,6 +171,16 @@ var (
 		EnvironmentToken:  false,
 		"{{DESCRIPTION}}": false,
 	}
+	repeatableFilePathTemplateTokens = map[string]bool{
+		DBNameToken:       true,
+		EnvironmentToken:  false,
+		"{{DESCRIPTION}}": false,
+	}
+	tenantWildcardRepeatableFilePathTemplateTokens = map[string]bool{
+		DBNameToken:       false,
+		EnvironmentToken:  false,
+		"{{DESCRIPTION}}": false,
+	}
 	schemaPathTemplateTokens = map[string]bool{
 		DBNameToken:      true,
 		EnvironmentToken: false,
@@ -218,6 +228,42 @@ func ValidateRepositoryFilePathTemplate(filePathTemplate string, tenantMode Proj
 	return nil
 }
 
+// ValidateRepositoryRepeatableFilePathTemplate validates the repository repeatable file path template.
+func ValidateRepositoryRepeatableFilePathTemplate(filePathTemplate string, tenantMode ProjectTenantMode, dbNameTemplate string) error {
+	if filePathTemplate == "" {
+		return nil
+	}
+	tokens, _ := common.ParseTemplateTokens(filePathTemplate)
+	tokenMap := make(map[string]bool)
+	for _, token := range tokens {
+		tokenMap[token] = true
+	}
+	if tenantMode == TenantModeTenant {
+		if _, ok := tokenMap[EnvironmentToken]; ok {
+			return &common.Error{Code: common.Invalid, Err: errors.Errorf("%q is not allowed in the template for projects in tenant mode", EnvironmentToken)}
+		}
+	}
+
+	filePathTemplateTokens := repeatableFilePathTemplateTokens
+	// Skip checking {{DB_NAME}} if it's a tenant project with empty database name template (i.e .wildcard).
+	if tenantMode == TenantModeTenant && dbNameTemplate == "" {
+		filePathTemplateTokens = tenantWildcardRepeatableFilePathTemplateTokens
+	}
+	for token, required := range filePathTemplateTokens {
+		if required {
+			if _, ok := tokenMap[token]; !ok {
+				return errors.Errorf("missing %s in repeatable file path template", token)
+			}
+		}
+	}
+	for token := range tokenMap {
+		if _, ok := filePathTemplateTokens[token]; !ok {
+			return errors.Errorf("unknown token %s in repeatable file path template", token)
+		}
+	}
+	return nil
+}
+
 // ValidateRepositorySchemaPathTemplate validates the repository schema path template.
 func ValidateRepositorySchemaPathTemplate(schemaPathTemplate string, tenantMode ProjectTenantMode) error {
 	if schemaPathTemplate == "" {
@@ -17,6 +17,8 @@ const (
 	ProjectRoleProviderGitLabSelfHost ProjectRoleProvider = "GITLAB_SELF_HOST"
 	// ProjectRoleProviderGitHubCom indicates the role provider is the GitHub.com.
 	ProjectRoleProviderGitHubCom ProjectRoleProvider = "GITHUB_COM"
+	// ProjectRoleProviderGitea indicates the role provider is the Gitea.
+	ProjectRoleProviderGitea ProjectRoleProvider = "GITEA"
 )
 
 // ProjectRoleProviderPayload is the payload for role provider.
@@ -153,6 +153,57 @@ func TestValidateRepositorySchemaPathTemplate(t *testing.T) {
 	}
 }
 
+func TestValidateRepositoryRepeatableFilePathTemplate(t *testing.T) {
+	tests := []struct {
+		name           string
+		template       string
+		tenantMode     ProjectTenantMode
+		dbNameTemplate string
+		errPart        string
+	}{
+		{
+			"Empty",
+			"",
+			TenantModeDisabled,
+			"",
+			"",
+		}, {
+			"OK",
+			"repeatable/{{ENV_NAME}}/{{DB_NAME}}##{{DESCRIPTION}}.sql",
+			TenantModeDisabled,
+			"",
+			"",
+		}, {
+			"Missing {{DB_NAME}}",
+			"repeatable/{{DESCRIPTION}}.sql",
+			TenantModeDisabled,
+			"",
+			"missing {{DB_NAME}}",
+		}, {
+			"Tenant mode wildcard",
+			"repeatable/{{DESCRIPTION}}.sql",
+			TenantModeTenant,
+			"",
+			"",
+		}, {
+			"UnknownToken",
+			"repeatable/{{DB_NAME}}##{{VERSION}}.sql",
+			TenantModeDisabled,
+			"",
+			"unknown token {{VERSION}}",
+		},
+	}
+
+	for _, test := range tests {
+		err := ValidateRepositoryRepeatableFilePathTemplate(test.template, test.tenantMode, test.dbNameTemplate)
+		if test.errPart == "" {
+			require.NoError(t, err)
+		} else {
+			require.Contains(t, err.Error(), test.errPart)
+		}
+	}
+}
+
 func TestValidateProjectDBNameTemplate(t *testing.T) {
 	tests := []struct {
 		name     string
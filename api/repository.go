@@ -23,13 +23,19 @@ type Repository struct {
 	Project   *Project `jsonapi:"relation,project"`
 
 	// Domain specific fields
-	Name          string `jsonapi:"attr,name"`
-	FullPath      string `jsonapi:"attr,fullPath"`
-	WebURL        string `jsonapi:"attr,webUrl"`
-	BranchFilter  string `jsonapi:"attr,branchFilter"`
-	BaseDirectory string `jsonapi:"attr,baseDirectory"`
+	Name         string `jsonapi:"attr,name"`
+	FullPath     string `jsonapi:"attr,fullPath"`
+	WebURL       string `jsonapi:"attr,webUrl"`
+	BranchFilter string `jsonapi:"attr,branchFilter"`
+	// BranchMapping maps the branches to the environments whose databases are changed by the pushes.
+	// If set, it decides the branches we are interested instead of the BranchFilter.
+	BranchMapping BranchMappingSetting `jsonapi:"attr,branchMapping"`
+	BaseDirectory string               `jsonapi:"attr,baseDirectory"`
 	// The file path template for matching the committed migration script.
 	FilePathTemplate string `jsonapi:"attr,filePathTemplate"`
+	// The dedicated file path template for matching the committed repeatable migration script.
+	// The repeatable migration is re-applied whenever the file content changes.
+	RepeatableFilePathTemplate string `jsonapi:"attr,repeatableFilePathTemplate"`
 	// The file path template for storing the latest schema auto-generated by Bytebase after migration.
 	// If empty, then Bytebase won't auto generate it.
 	SchemaPathTemplate string `jsonapi:"attr,schemaPathTemplate"`
@@ -68,15 +74,17 @@ type RepositoryCreate struct {
 	ProjectID int
 
 	// Domain specific fields
-	Name               string `jsonapi:"attr,name"`
-	FullPath           string `jsonapi:"attr,fullPath"`
-	WebURL             string `jsonapi:"attr,webUrl"`
-	BranchFilter       string `jsonapi:"attr,branchFilter"`
-	BaseDirectory      string `jsonapi:"attr,baseDirectory"`
-	FilePathTemplate   string `jsonapi:"attr,filePathTemplate"`
-	SchemaPathTemplate string `jsonapi:"attr,schemaPathTemplate"`
-	SheetPathTemplate  string `jsonapi:"attr,sheetPathTemplate"`
-	ExternalID         string `jsonapi:"attr,externalId"`
+	Name                       string               `jsonapi:"attr,name"`
+	FullPath                   string               `jsonapi:"attr,fullPath"`
+	WebURL                     string               `jsonapi:"attr,webUrl"`
+	BranchFilter               string               `jsonapi:"attr,branchFilter"`
+	BranchMapping              BranchMappingSetting `jsonapi:"attr,branchMapping"`
+	BaseDirectory              string               `jsonapi:"attr,baseDirectory"`
+	FilePathTemplate           string               `jsonapi:"attr,filePathTemplate"`
+	RepeatableFilePathTemplate string               `jsonapi:"attr,repeatableFilePathTemplate"`
+	SchemaPathTemplate         string               `jsonapi:"attr,schemaPathTemplate"`
+	SheetPathTemplate          string               `jsonapi:"attr,sheetPathTemplate"`
+	ExternalID                 string               `jsonapi:"attr,externalId"`
 	// Token belonged by the user linking the project to the VCS repository. We store this token together
 	// with the refresh token in the new repository record so we can use it to call VCS API on
 	// behalf of that user to perform tasks such as webhook CRUD later.
@@ -121,15 +129,17 @@ type RepositoryPatch struct {
 	UpdaterID int
 
 	// Domain specific fields
-	BranchFilter       *string `jsonapi:"attr,branchFilter"`
-	BaseDirectory      *string `jsonapi:"attr,baseDirectory"`
-	FilePathTemplate   *string `jsonapi:"attr,filePathTemplate"`
-	SchemaPathTemplate *string `jsonapi:"attr,schemaPathTemplate"`
-	SheetPathTemplate  *string `jsonapi:"attr,sheetPathTemplate"`
-	EnableSQLReviewCI  *bool   `jsonapi:"attr,enableSQLReviewCI"`
-	AccessToken        *string
-	ExpiresTs          *int64
-	RefreshToken       *string
+	BranchFilter               *string               `jsonapi:"attr,branchFilter"`
+	BranchMapping              *BranchMappingSetting `jsonapi:"attr,branchMapping"`
+	BaseDirectory              *string               `jsonapi:"attr,baseDirectory"`
+	FilePathTemplate           *string               `jsonapi:"attr,filePathTemplate"`
+	RepeatableFilePathTemplate *string               `jsonapi:"attr,repeatableFilePathTemplate"`
+	SchemaPathTemplate         *string               `jsonapi:"attr,schemaPathTemplate"`
+	SheetPathTemplate          *string               `jsonapi:"attr,sheetPathTemplate"`
+	EnableSQLReviewCI          *bool                 `jsonapi:"attr,enableSQLReviewCI"`
+	AccessToken                *string
+	ExpiresTs                  *int64
+	RefreshToken               *string
 }
 
 // RepositoryDelete is the API message for deleting a repository.
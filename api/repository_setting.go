@@ -0,0 +1,82 @@
+package api
+
+import (
+	"encoding/json"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// BranchMapping maps the branches matching the branch filter to a set of environments.
+type BranchMapping struct {
+	// BranchFilter is the pattern of the branch name. Wildcard is supported, e.g. "release/*".
+	BranchFilter string `json:"branchFilter" jsonapi:"attr,branchFilter"`
+	// EnvironmentIDList is the list of environments whose databases are changed by the
+	// migration scripts pushed to the matching branches.
+	EnvironmentIDList []int `json:"environmentIdList" jsonapi:"attr,environmentIdList"`
+}
+
+// BranchMappingSetting is the setting of the branch mapping of a repository.
+// An empty mapping list means that the pushes to the branch filter of the
+// repository drive all environments.
+type BranchMappingSetting struct {
+	MappingList []*BranchMapping `json:"mappingList" jsonapi:"attr,mappingList"`
+}
+
+// Scan implements database/sql Scanner interface, converts JSONB to BranchMappingSetting struct.
+func (s *BranchMappingSetting) Scan(src interface{}) error {
+	if bs, ok := src.([]byte); ok {
+		if string(bs) == "{}" {
+			// handle '{}', return default values
+			*s = BranchMappingSetting{}
+			return nil
+		}
+		return json.Unmarshal(bs, s)
+	}
+	return errors.New("failed to scan branch_mapping")
+}
+
+// Enabled returns true if the repository routes the pushes by the branch mapping.
+func (s *BranchMappingSetting) Enabled() bool {
+	return len(s.MappingList) > 0
+}
+
+// Match returns the first mapping whose branch filter matches the branch, or nil
+// if there is no such mapping.
+func (s *BranchMappingSetting) Match(branch string) (*BranchMapping, error) {
+	for _, mapping := range s.MappingList {
+		ok, err := filepath.Match(mapping.BranchFilter, branch)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to match branch filter %q", mapping.BranchFilter)
+		}
+		if ok {
+			return mapping, nil
+		}
+	}
+	return nil, nil
+}
+
+// Validate validates the branch mapping setting.
+// An environment can only belong to one mapping, so that the migration versions
+// of a database are always ordered by the pushes to the branches of a single mapping.
+func (s *BranchMappingSetting) Validate() error {
+	environmentMapping := make(map[int]string)
+	for _, mapping := range s.MappingList {
+		if mapping.BranchFilter == "" {
+			return errors.New("branch filter of the branch mapping cannot be empty")
+		}
+		if _, err := filepath.Match(mapping.BranchFilter, ""); err != nil {
+			return errors.Wrapf(err, "invalid branch filter %q", mapping.BranchFilter)
+		}
+		if len(mapping.EnvironmentIDList) == 0 {
+			return errors.Errorf("branch mapping %q should have at least one environment", mapping.BranchFilter)
+		}
+		for _, environmentID := range mapping.EnvironmentIDList {
+			if branchFilter, ok := environmentMapping[environmentID]; ok {
+				return errors.Errorf("environment %d is mapped by both branch %q and %q", environmentID, branchFilter, mapping.BranchFilter)
+			}
+			environmentMapping[environmentID] = mapping.BranchFilter
+		}
+	}
+	return nil
+}
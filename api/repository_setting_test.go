@@ -0,0 +1,88 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBranchMappingSettingMatch(t *testing.T) {
+	setting := BranchMappingSetting{
+		MappingList: []*BranchMapping{
+			{BranchFilter: "develop", EnvironmentIDList: []int{101, 102}},
+			{BranchFilter: "main", EnvironmentIDList: []int{103}},
+			{BranchFilter: "release/*", EnvironmentIDList: []int{104}},
+		},
+	}
+	tests := []struct {
+		branch string
+		want   *BranchMapping
+	}{
+		{"develop", setting.MappingList[0]},
+		{"main", setting.MappingList[1]},
+		{"release/1.0", setting.MappingList[2]},
+		{"feature/foo", nil},
+	}
+
+	require.True(t, setting.Enabled())
+	for _, test := range tests {
+		got, err := setting.Match(test.branch)
+		require.NoError(t, err)
+		require.Equal(t, test.want, got, test.branch)
+	}
+}
+
+func TestBranchMappingSettingValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		mappingList []*BranchMapping
+		wantErr     bool
+	}{
+		{
+			name: "valid",
+			mappingList: []*BranchMapping{
+				{BranchFilter: "develop", EnvironmentIDList: []int{101}},
+				{BranchFilter: "main", EnvironmentIDList: []int{102}},
+			},
+		},
+		{
+			name: "empty branch filter",
+			mappingList: []*BranchMapping{
+				{BranchFilter: "", EnvironmentIDList: []int{101}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "bad branch filter",
+			mappingList: []*BranchMapping{
+				{BranchFilter: "release/[", EnvironmentIDList: []int{101}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "no environment",
+			mappingList: []*BranchMapping{
+				{BranchFilter: "develop"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "environment mapped twice",
+			mappingList: []*BranchMapping{
+				{BranchFilter: "develop", EnvironmentIDList: []int{101}},
+				{BranchFilter: "main", EnvironmentIDList: []int{101, 102}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		setting := BranchMappingSetting{MappingList: test.mappingList}
+		err := setting.Validate()
+		if test.wantErr {
+			require.Error(t, err, test.name)
+		} else {
+			require.NoError(t, err, test.name)
+		}
+	}
+}
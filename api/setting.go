@@ -20,6 +20,8 @@ const (
 	SettingEnterpriseTrial SettingName = "bb.enterprise.trial"
 	// SettingAppIM is the setting name for IM applications.
 	SettingAppIM SettingName = "bb.app.im"
+	// SettingAnomalyDatabaseSize is the setting name for the database size anomaly thresholds.
+	SettingAnomalyDatabaseSize SettingName = "bb.anomaly.database-size"
 )
 
 // IMType is the type of IM.
@@ -87,3 +89,12 @@ type SettingAppIMValue struct {
 		ApprovalCode string
 	} `json:"externalApproval"`
 }
+
+// SettingAnomalyDatabaseSizeValue is the setting value of SettingAnomalyDatabaseSize type setting.
+type SettingAnomalyDatabaseSizeValue struct {
+	// MaxSize is the maximum data and index size of a database in bytes, 0 means no limit.
+	MaxSize int64 `json:"maxSize"`
+	// MaxDailyGrowthRatio is the maximum size growth ratio of a database compared with the previous day,
+	// e.g. 0.5 means the size can grow by 50% a day, 0 means no limit.
+	MaxDailyGrowthRatio float64 `json:"maxDailyGrowthRatio"`
+}
@@ -26,6 +26,14 @@ const (
 	SheetFromGitLabSelfHost SheetSource = "GITLAB_SELF_HOST"
 	// SheetFromGitHubCom is the sheet synced from github.com.
 	SheetFromGitHubCom SheetSource = "GITHUB_COM"
+	// SheetFromBitbucketCloud is the sheet synced from Bitbucket Cloud.
+	SheetFromBitbucketCloud SheetSource = "BITBUCKET_CLOUD"
+	// SheetFromBitbucketServer is the sheet synced from Bitbucket Server.
+	SheetFromBitbucketServer SheetSource = "BITBUCKET_SERVER"
+	// SheetFromGitea is the sheet synced from Gitea.
+	SheetFromGitea SheetSource = "GITEA"
+	// SheetFromAzureDevOps is the sheet synced from Azure DevOps.
+	SheetFromAzureDevOps SheetSource = "AZURE_DEVOPS"
 )
 
 // SheetType is the type of sheet.
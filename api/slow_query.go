@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+
+	"github.com/bytebase/bytebase/plugin/advisor"
+)
+
+// SlowQueryPayload is the API message for slow query payloads.
+type SlowQueryPayload struct {
+	// AdviceList is the SQL review violations found in the query.
+	AdviceList []advisor.Advice `json:"adviceList,omitempty"`
+	// ReviewError is the error reviewing the query, e.g. the query fails to parse.
+	ReviewError string `json:"reviewError,omitempty"`
+}
+
+// SlowQuery is the API message for the statistics of a normalized query collected from the instance.
+// The statistics are cumulative since the engine last reset them.
+type SlowQuery struct {
+	ID int `jsonapi:"primary,slowQuery"`
+
+	// Standard fields
+	CreatedTs int64 `jsonapi:"attr,createdTs"`
+	UpdatedTs int64 `jsonapi:"attr,updatedTs"`
+
+	// Related fields
+	InstanceID int `jsonapi:"attr,instanceId"`
+	DatabaseID int `jsonapi:"attr,databaseId"`
+
+	// Domain specific fields
+	Fingerprint     string `jsonapi:"attr,fingerprint"`
+	NormalizedQuery string `jsonapi:"attr,normalizedQuery"`
+	SampleQuery     string `jsonapi:"attr,sampleQuery"`
+	Calls           int64  `jsonapi:"attr,calls"`
+	TotalLatencyNs  int64  `jsonapi:"attr,totalLatencyNs"`
+	MaxLatencyNs    int64  `jsonapi:"attr,maxLatencyNs"`
+	RowsExamined    int64  `jsonapi:"attr,rowsExamined"`
+	RowsReturned    int64  `jsonapi:"attr,rowsReturned"`
+	Payload         string `jsonapi:"attr,payload"`
+}
+
+// SlowQueryUpsert is the API message for upserting a slow query.
+type SlowQueryUpsert struct {
+	// Related fields
+	InstanceID int
+	DatabaseID int
+
+	// Domain specific fields
+	Fingerprint     string
+	NormalizedQuery string
+	SampleQuery     string
+	Calls           int64
+	TotalLatencyNs  int64
+	MaxLatencyNs    int64
+	RowsExamined    int64
+	RowsReturned    int64
+	Payload         string
+}
+
+// SlowQueryFind is the API message for finding slow queries.
+// The result is ordered by the total latency in descending order.
+type SlowQueryFind struct {
+	// Related fields
+	InstanceID *int
+	DatabaseID *int
+
+	// Limit is the maximum number of slow queries returned.
+	Limit *int
+}
+
+func (find *SlowQueryFind) String() string {
+	str, err := json.Marshal(*find)
+	if err != nil {
+		return err.Error()
+	}
+	return string(str)
+}
@@ -24,7 +24,17 @@ type Stage struct {
 	TaskList      []*Task      `jsonapi:"relation,task"`
 
 	// Domain specific fields
-	Name string `jsonapi:"attr,name"`
+	Name    string `jsonapi:"attr,name"`
+	Payload string `jsonapi:"attr,payload"`
+}
+
+// StagePayload is the payload of a stage.
+type StagePayload struct {
+	// Rollout is copied from the deployment of the tenant project when creating the stage.
+	Rollout *DeploymentRollout `json:"rollout,omitempty"`
+	// RolloutHaltTaskRunIDList is the IDs of the failed task runs counted when the rollout halted last time.
+	// The rollout resumed by the user only halts again on new failures.
+	RolloutHaltTaskRunIDList []int `json:"rolloutHaltTaskRunIdList,omitempty"`
 }
 
 // StageCreate is the API message for creating a stage.
@@ -40,7 +50,8 @@ type StageCreate struct {
 	TaskIndexDAGList []TaskIndexDAG `jsonapi:"attr,taskDAGList"`
 
 	// Domain specific fields
-	Name string `jsonapi:"attr,name"`
+	Name    string `jsonapi:"attr,name"`
+	Payload string
 }
 
 // StageFind is the API message for finding stages.
@@ -51,6 +62,18 @@ type StageFind struct {
 	PipelineID *int
 }
 
+// StagePatch is the API message for patching a stage.
+type StagePatch struct {
+	ID int
+
+	// Standard fields
+	// Value is assigned from the jwt subject field passed by the client.
+	UpdaterID int
+
+	// Domain specific fields
+	Payload *string
+}
+
 // StageAllTaskStatusPatch is the API message for patching task status for all tasks in a stage.
 type StageAllTaskStatusPatch struct {
 	ID int
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"encoding/json"
+)
+
+// TableSizeHistoryGranularity is the granularity of the table size history.
+type TableSizeHistoryGranularity string
+
+const (
+	// TableSizeHistoryGranularityHour keeps the latest snapshot in each hour.
+	TableSizeHistoryGranularityHour TableSizeHistoryGranularity = "HOUR"
+	// TableSizeHistoryGranularityDay keeps the latest snapshot in each day.
+	TableSizeHistoryGranularityDay TableSizeHistoryGranularity = "DAY"
+)
+
+// Seconds returns the length of the bucket period in seconds.
+func (g TableSizeHistoryGranularity) Seconds() int64 {
+	switch g {
+	case TableSizeHistoryGranularityHour:
+		return 60 * 60
+	case TableSizeHistoryGranularityDay:
+		return 24 * 60 * 60
+	}
+	return 0
+}
+
+// TableSizeHistory is the API message for a table size snapshot.
+type TableSizeHistory struct {
+	ID int `jsonapi:"primary,tableSizeHistory"`
+
+	// Related fields
+	DatabaseID int `jsonapi:"attr,databaseId"`
+
+	// Domain specific fields
+	// TableName is empty for the snapshot of the whole database.
+	TableName   string                      `jsonapi:"attr,tableName"`
+	Granularity TableSizeHistoryGranularity `jsonapi:"attr,granularity"`
+	// Ts is the start of the bucket period.
+	Ts        int64 `jsonapi:"attr,ts"`
+	RowCount  int64 `jsonapi:"attr,rowCount"`
+	DataSize  int64 `jsonapi:"attr,dataSize"`
+	IndexSize int64 `jsonapi:"attr,indexSize"`
+}
+
+// TableSizeHistoryFind is the API message for finding table size history.
+// The result is ordered by ts in ascending order.
+type TableSizeHistoryFind struct {
+	// Related fields
+	DatabaseID *int
+
+	// Domain specific fields
+	// TableName is the name of the table, nil to sum up the sizes of all tables in the database.
+	TableName   *string
+	Granularity TableSizeHistoryGranularity
+	// SinceTs is the earliest bucket start time to find.
+	SinceTs *int64
+}
+
+func (find *TableSizeHistoryFind) String() string {
+	str, err := json.Marshal(*find)
+	if err != nil {
+		return err.Error()
+	}
+	return string(str)
+}
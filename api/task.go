@@ -3,6 +3,8 @@ package api
 import (
 	"encoding/json"
 
+	"github.com/pkg/errors"
+
 	"github.com/bytebase/bytebase/common"
 	"github.com/bytebase/bytebase/plugin/vcs"
 )
@@ -109,6 +111,9 @@ type TaskDatabaseSchemaUpdatePayload struct {
 	Statement     string         `json:"statement,omitempty"`
 	SchemaVersion string         `json:"schemaVersion,omitempty"`
 	VCSPushEvent  *vcs.PushEvent `json:"pushEvent,omitempty"`
+	// RepeatableName is the VCS file path of the repeatable migration.
+	// It's set if the task re-applies a repeatable migration whenever the file content changes.
+	RepeatableName string `json:"repeatableName,omitempty"`
 }
 
 // TaskDatabaseSchemaUpdateSDLPayload is the task payload for database schema update (SDL).
@@ -152,6 +157,59 @@ type TaskDatabaseDataUpdatePayload struct {
 	BinlogFileEnd   string `json:"binlogFileEnd,omitempty"`
 	BinlogPosStart  int64  `json:"binlogPosStart,omitempty"`
 	BinlogPosEnd    int64  `json:"binlogPosEnd,omitempty"`
+
+	// Chunked execution related.
+
+	// Chunk is set to execute the statement in primary key ranged chunks instead of a single transaction.
+	Chunk *DataUpdateChunk `json:"chunk,omitempty"`
+	// ChunkCursor records the chunks executed by the previous attempts, so that a rerun resumes after them.
+	ChunkCursor *DataUpdateChunkCursor `json:"chunkCursor,omitempty"`
+
+	// Postgres row snapshot related.
+
+	// RowSnapshot is set to snapshot the rows changed by each UPDATE or DELETE statement into a table before the change.
+	RowSnapshot bool `json:"rowSnapshot,omitempty"`
+	// RowSnapshotTableList is the qualified names of the snapshot tables, which are created in the same database.
+	RowSnapshotTableList []string `json:"rowSnapshotTableList,omitempty"`
+	// CompensatingStatement restores the changed rows from the snapshot tables, which can be applied by a follow-up issue.
+	CompensatingStatement string `json:"compensatingStatement,omitempty"`
+}
+
+// DataUpdateChunk is the option to execute a single-table UPDATE or DELETE statement with a WHERE clause in chunks.
+// The statement is rewritten to cover a range of the primary key in each chunk, and each chunk is committed in
+// its own transaction, so that the locks are held briefly and the replicas can keep up.
+type DataUpdateChunk struct {
+	// ChunkSize is the number of rows in the primary key range of a chunk.
+	ChunkSize int `json:"chunkSize"`
+	// PauseMilliseconds is the pause between chunks.
+	PauseMilliseconds int `json:"pauseMilliseconds,omitempty"`
+	// MaxReplicaLagSeconds throttles the execution while the replica lag exceeds it. 0 means no throttling.
+	// For MySQL, the lag is checked on the read-only data source with the host override, which points to the replica.
+	MaxReplicaLagSeconds int `json:"maxReplicaLagSeconds,omitempty"`
+}
+
+// Validate validates the chunk option.
+func (c *DataUpdateChunk) Validate() error {
+	if c.ChunkSize <= 0 {
+		return errors.Errorf("chunk size should be positive, got %d", c.ChunkSize)
+	}
+	if c.PauseMilliseconds < 0 {
+		return errors.Errorf("pause between chunks should not be negative, got %d", c.PauseMilliseconds)
+	}
+	if c.MaxReplicaLagSeconds < 0 {
+		return errors.Errorf("max replica lag should not be negative, got %d", c.MaxReplicaLagSeconds)
+	}
+	return nil
+}
+
+// DataUpdateChunkCursor is the position of the chunked execution.
+type DataUpdateChunkCursor struct {
+	// LastKey is the upper bound of the primary key range of the last executed chunk.
+	LastKey string `json:"lastKey"`
+	// ExecutedChunk is the number of executed chunks.
+	ExecutedChunk int `json:"executedChunk"`
+	// AffectedRows is the number of rows affected by the executed chunks.
+	AffectedRows int64 `json:"affectedRows"`
 }
 
 // TaskDatabaseBackupPayload is the task payload for database backup.
@@ -192,8 +250,14 @@ type Task struct {
 	// BlockedBy is an array of Task ID.
 	// We use string here to workaround jsonapi limitations. https://github.com/google/jsonapi/issues/209
 	BlockedBy []string `jsonapi:"attr,blockedBy"`
-	// Progress is loaded from the task scheduler in memory, NOT from the database
+	// Progress is loaded from the task scheduler in memory, or from the running task run on the replicas which aren't the leader
 	Progress Progress `jsonapi:"attr,progress"`
+	// Queue is loaded from the task scheduler in memory, or from the running task run on the replicas which aren't the leader
+	Queue TaskQueue `jsonapi:"attr,queue"`
+	// MaintenanceWindowOverride allows the task to start outside the maintenance windows and change freezes.
+	MaintenanceWindowOverride bool `jsonapi:"attr,maintenanceWindowOverride"`
+	// MaintenanceWindow is computed from the maintenance window policy of the environment, NOT from the database
+	MaintenanceWindow TaskMaintenanceWindow `jsonapi:"attr,maintenanceWindow"`
 }
 
 // Progress is a generalized struct which can track the progress of a task.
@@ -205,12 +269,51 @@ type Progress struct {
 	// CreatedTs is when the task starts
 	CreatedTs int64 `json:"createdTs"`
 	// UpdatedTs is when the progress gets updated most recently
+	// The elapsed time of the task is UpdatedTs - CreatedTs.
 	UpdatedTs int64 `json:"updatedTs"`
+	// TotalStatement is the number of statements split by the parser, and ExecutedStatement is the number of them executed.
+	TotalStatement    int `json:"totalStatement,omitempty"`
+	ExecutedStatement int `json:"executedStatement,omitempty"`
+	// CurrentStatement is the statement being executed, truncated if it's too long.
+	CurrentStatement string `json:"currentStatement,omitempty"`
+	// ETASeconds is the estimated seconds to complete the task, e.g. the gh-ost ETA of copying rows.
+	// 0 means unknown.
+	ETASeconds int64 `json:"etaSeconds,omitempty"`
 	// Payload is reserved for the future
 	// Might be something like {comment:"postponing due to network lag"}
 	Payload string `json:"payload"`
 }
 
+// ProgressPayload is the payload of the task progress.
+type ProgressPayload struct {
+	// Comment is the note on the progress, e.g. the execution is throttled due to the replica lag.
+	Comment string `json:"comment,omitempty"`
+}
+
+// TaskQueue is the queue status of a running task which is waiting for its turn to be executed.
+type TaskQueue struct {
+	// Position is the 1-based position of the task in the queue of its instance, 0 means the task is not queued.
+	Position int `json:"position"`
+	// Reason is why the task is waiting.
+	Reason string `json:"reason"`
+}
+
+// TaskMaintenanceWindow is the maintenance window status of a task which is not allowed to start now.
+type TaskMaintenanceWindow struct {
+	// NextAllowedTs is the earliest time when the task is allowed to start, 0 means unknown.
+	NextAllowedTs int64 `json:"nextAllowedTs"`
+	// Reason is why the task is not allowed to start now, empty means the task is allowed to start now.
+	Reason string `json:"reason"`
+}
+
+// TaskProgressEvent is the server-sent event message for the task progress.
+type TaskProgressEvent struct {
+	TaskID   int        `json:"taskId"`
+	Status   TaskStatus `json:"status"`
+	Progress Progress   `json:"progress"`
+	Queue    TaskQueue  `json:"queue"`
+}
+
 // TaskCreate is the API message for creating a task.
 type TaskCreate struct {
 	// Standard fields
@@ -278,6 +381,14 @@ type TaskPatch struct {
 	Statement         *string `jsonapi:"attr,statement"`
 	Payload           *string
 	EarliestAllowedTs *int64 `jsonapi:"attr,earliestAllowedTs"`
+	// MaintenanceWindowOverride is only set by the owner through the audited override API.
+	MaintenanceWindowOverride *bool
+}
+
+// TaskMaintenanceWindowOverride is the API message for overriding the maintenance windows and change freezes of a task.
+type TaskMaintenanceWindowOverride struct {
+	// Comment is the required justification of the override, which is recorded in the activity.
+	Comment string `jsonapi:"attr,comment"`
 }
 
 // TaskStatusPatch is the API message for patching a task status.
@@ -27,6 +27,10 @@ type TaskRunResultPayload struct {
 	Detail      string `json:"detail,omitempty"`
 	MigrationID int64  `json:"migrationId,omitempty"`
 	Version     string `json:"version,omitempty"`
+	// RetryAttempt is the attempt number of the failed run which is retried automatically, 0 if not retried.
+	RetryAttempt int `json:"retryAttempt,omitempty"`
+	// RetryTs is the earliest time to run the retry.
+	RetryTs int64 `json:"retryTs,omitempty"`
 }
 
 // TaskRun is the API message for a task run.
@@ -52,6 +56,12 @@ type TaskRun struct {
 	Comment string        `jsonapi:"attr,comment"`
 	Result  string        `jsonapi:"attr,result"`
 	Payload string        `jsonapi:"attr,payload"`
+	// StartedTs is when the task executor starts to run the task run, 0 means the task run is still queued.
+	StartedTs int64 `jsonapi:"attr,startedTs"`
+	// Progress is the live progress reported by the task executor, in the format of Progress.
+	Progress string `jsonapi:"attr,progress"`
+	// Queue is the queue status of the task run waiting for its turn, in the format of TaskQueue.
+	Queue string `jsonapi:"attr,queue"`
 }
 
 // TaskRunCreate is the API message for creating a task run.
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"encoding/json"
+
+	"github.com/bytebase/bytebase/plugin/vcs"
+)
+
+// WebhookDeliveryStatus is the processing status of an inbound VCS webhook delivery.
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookDeliveryProcessing is the status of the delivery being processed.
+	WebhookDeliveryProcessing WebhookDeliveryStatus = "PROCESSING"
+	// WebhookDeliverySucceeded is the status of the delivery processed successfully.
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "SUCCEEDED"
+	// WebhookDeliveryFailed is the status of the delivery failed to process.
+	WebhookDeliveryFailed WebhookDeliveryStatus = "FAILED"
+)
+
+// WebhookDeliveryVerification is the verification result of the signature or the
+// secret token of an inbound VCS webhook delivery.
+type WebhookDeliveryVerification string
+
+const (
+	// WebhookDeliveryVerificationNone means the delivery is not verified, e.g. the
+	// ping events and the events ignored before verification.
+	WebhookDeliveryVerificationNone WebhookDeliveryVerification = "NONE"
+	// WebhookDeliveryVerificationPassed means the delivery is verified by at least one repository.
+	WebhookDeliveryVerificationPassed WebhookDeliveryVerification = "PASSED"
+	// WebhookDeliveryVerificationFailed means the delivery fails the verification of all repositories.
+	WebhookDeliveryVerificationFailed WebhookDeliveryVerification = "FAILED"
+)
+
+// WebhookDelivery is the API message for an inbound VCS webhook delivery.
+type WebhookDelivery struct {
+	ID int `jsonapi:"primary,webhookDelivery"`
+
+	// Standard fields
+	CreatedTs int64 `jsonapi:"attr,createdTs"`
+	UpdatedTs int64 `jsonapi:"attr,updatedTs"`
+
+	// Related fields
+	WebhookEndpointID string `jsonapi:"attr,webhookEndpointId"`
+
+	// Domain specific fields
+	VCSType vcs.Type `jsonapi:"attr,vcsType"`
+	// DeliveryID is the unique ID of the delivery assigned by the VCS provider,
+	// e.g. the X-GitHub-Delivery header. It's the SHA256 of the payload if the
+	// VCS provider doesn't assign one.
+	DeliveryID string `jsonapi:"attr,deliveryId"`
+	Event      string `jsonapi:"attr,event"`
+	// Headers is the JSON encoded map of the request headers.
+	Headers      string                      `jsonapi:"attr,headers"`
+	Payload      string                      `jsonapi:"attr,payload"`
+	Verification WebhookDeliveryVerification `jsonapi:"attr,verification"`
+	Status       WebhookDeliveryStatus       `jsonapi:"attr,status"`
+	// ResponseCode and Result are the HTTP status code and the message responded
+	// to the VCS provider for the last processing.
+	ResponseCode int    `jsonapi:"attr,responseCode"`
+	Result       string `jsonapi:"attr,result"`
+	// DuplicateCount is the number of the redeliveries deduplicated.
+	DuplicateCount int `jsonapi:"attr,duplicateCount"`
+	// ReplayCount is the number of the replays requested through the API.
+	ReplayCount int `jsonapi:"attr,replayCount"`
+}
+
+// WebhookDeliveryCreate is the API message for creating a webhook delivery.
+type WebhookDeliveryCreate struct {
+	// Related fields
+	WebhookEndpointID string
+
+	// Domain specific fields
+	VCSType    vcs.Type
+	DeliveryID string
+	Event      string
+	Headers    string
+	Payload    string
+}
+
+// WebhookDeliveryFind is the API message for finding webhook deliveries.
+// The result is ordered by the creation time in descending order.
+type WebhookDeliveryFind struct {
+	ID *int
+
+	// Related fields
+	WebhookEndpointID *string
+
+	// Domain specific fields
+	DeliveryID *string
+
+	// Limit is the maximum number of webhook deliveries returned.
+	Limit *int
+}
+
+func (find *WebhookDeliveryFind) String() string {
+	str, err := json.Marshal(*find)
+	if err != nil {
+		return err.Error()
+	}
+	return string(str)
+}
+
+// WebhookDeliveryPatch is the API message for patching a webhook delivery.
+type WebhookDeliveryPatch struct {
+	ID int
+
+	// Domain specific fields
+	Verification *WebhookDeliveryVerification
+	Status       *WebhookDeliveryStatus
+	ResponseCode *int
+	Result       *string
+	// IncreaseDuplicateCount increases the duplicate count by one.
+	IncreaseDuplicateCount bool
+	// IncreaseReplayCount increases the replay count by one.
+	IncreaseReplayCount bool
+}
@@ -36,6 +36,7 @@ func getBaseProfile() server.Profile {
 		GitCommit:            gitcommit,
 		PgURL:                flags.pgURL,
 		DisableMetric:        flags.disableMetric,
+		OTLPEndpoint:         flags.otlpEndpoint,
 		BackupStorageBackend: backupStorageBackend,
 		BackupRegion:         flags.backupRegion,
 		BackupBucket:         flags.backupBucket,
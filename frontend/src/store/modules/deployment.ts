@@ -10,6 +10,7 @@ import {
   unknown,
   UNKNOWN_ID,
   DeploymentConfigPatch,
+  DeploymentPreview,
 } from "@/types";
 import { getPrincipalFromIncludedList } from "./principal";
 
@@ -115,5 +116,29 @@ export const useDeploymentStore = defineStore("deployment", {
       });
       return updatedDeploymentConfig;
     },
+    async previewDeploymentConfigByProjectId({
+      projectId,
+      deploymentConfigPatch,
+      databaseName,
+    }: {
+      projectId: ProjectId;
+      deploymentConfigPatch: DeploymentConfigPatch;
+      databaseName?: string;
+    }) {
+      const params = databaseName ? { databaseName } : {};
+      const previewList: DeploymentPreview[] = (
+        await axios.post(
+          `/api/project/${projectId}/deployment/preview`,
+          {
+            data: {
+              type: "deploymentConfigPatch",
+              attributes: deploymentConfigPatch,
+            },
+          },
+          { params }
+        )
+      ).data;
+      return previewList;
+    },
   },
 });
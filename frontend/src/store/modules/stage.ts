@@ -52,12 +52,15 @@ function convertPartial(
     }
   }
 
+  const payload = JSON.parse((stage.attributes.payload as string) || "{}");
+
   const result: Omit<Stage, "pipeline"> = {
     ...(stage.attributes as Omit<
       Stage,
-      "id" | "database" | "taskList" | "creator" | "updater"
+      "id" | "database" | "taskList" | "creator" | "updater" | "payload"
     >),
     id: parseInt(stage.id),
+    payload,
     creator: getPrincipalFromIncludedList(
       stage.relationships!.creator.data,
       includedList
@@ -37,11 +37,17 @@ function convertTaskRun(
   const payload = taskRun.attributes.payload
     ? JSON.parse((taskRun.attributes.payload as string) || "{}")
     : {};
+  const progress = taskRun.attributes.progress
+    ? JSON.parse((taskRun.attributes.progress as string) || "{}")
+    : {};
+  const queue = taskRun.attributes.queue
+    ? JSON.parse((taskRun.attributes.queue as string) || "{}")
+    : {};
 
   return {
     ...(taskRun.attributes as Omit<
       TaskRun,
-      "id" | "result" | "payload" | "creator" | "updater"
+      "id" | "result" | "payload" | "progress" | "queue" | "creator" | "updater"
     >),
     id: parseInt(taskRun.id),
     creator: getPrincipalFromIncludedList(
@@ -54,6 +60,8 @@ function convertTaskRun(
     ),
     result,
     payload,
+    progress,
+    queue,
   };
 }
 
@@ -5,6 +5,7 @@ import {
   DatabaseId,
   InstanceId,
   PrincipalId,
+  StageId,
   TaskId,
 } from "./id";
 import { IssueStatus } from "./issue";
@@ -23,7 +24,9 @@ export type IssueActivityType =
   | "bb.pipeline.task.status.update"
   | "bb.pipeline.task.file.commit"
   | "bb.pipeline.task.statement.update"
-  | "bb.pipeline.task.general.earliest-allowed-time.update";
+  | "bb.pipeline.task.general.earliest-allowed-time.update"
+  | "bb.pipeline.task.general.maintenance-window.override"
+  | "bb.pipeline.rollout.halt";
 
 export type MemberActivityType =
   | "bb.member.create"
@@ -67,6 +70,10 @@ export function activityName(type: ActivityType): string {
       return t("activity.type.pipeline-task-statement-update");
     case "bb.pipeline.task.general.earliest-allowed-time.update":
       return t("activity.type.pipeline-task-earliest-allowed-time-update");
+    case "bb.pipeline.task.general.maintenance-window.override":
+      return t("activity.type.pipeline-task-maintenance-window-override");
+    case "bb.pipeline.rollout.halt":
+      return t("activity.type.pipeline-rollout-halt");
     case "bb.member.create":
       return t("activity.type.member-create");
     case "bb.member.role.update":
@@ -148,6 +155,22 @@ export type ActivityTaskEarliestAllowedTimeUpdatePayload = {
   taskName: string;
 };
 
+export type ActivityTaskMaintenanceWindowOverridePayload = {
+  taskId: TaskId;
+  reason: string;
+  issueName: string;
+  taskName: string;
+};
+
+export type ActivityPipelineRolloutHaltPayload = {
+  stageId: StageId;
+  stageName: string;
+  failureCount: number;
+  maxFailureCount: number;
+  canceledTaskCount: number;
+  issueName: string;
+};
+
 export type ActivityMemberCreatePayload = {
   principalId: PrincipalId;
   principalName: string;
@@ -202,6 +225,8 @@ export type ActionPayloadType =
   | ActivityTaskFileCommitPayload
   | ActivityTaskStatementUpdatePayload
   | ActivityTaskEarliestAllowedTimeUpdatePayload
+  | ActivityTaskMaintenanceWindowOverridePayload
+  | ActivityPipelineRolloutHaltPayload
   | ActivityMemberCreatePayload
   | ActivityMemberRoleUpdatePayload
   | ActivityMemberActivateDeactivatePayload
@@ -6,5 +6,6 @@ export type ServerInfo = {
   demoName: string;
   externalUrl: string;
   needAdminSetup: boolean;
+  leader: boolean;
   startedTs: number;
 };
@@ -16,7 +16,8 @@ export type AnomalyType =
   | "bb.anomaly.database.backup.policy-violation"
   | "bb.anomaly.database.backup.missing"
   | "bb.anomaly.database.connection"
-  | "bb.anomaly.database.schema.drift";
+  | "bb.anomaly.database.schema.drift"
+  | "bb.anomaly.database.migration.checksum-mismatch";
 
 export type AnomalyInstanceConnectionPayload = {
   detail: string;
@@ -43,11 +44,25 @@ export type AnomalyDatabaseSchemaDriftPayload = {
   actual: string;
 };
 
+export type MigrationChecksumMismatch = {
+  migrationHistoryId: number;
+  version: string;
+  source: "HISTORY" | "VCS";
+  filePath?: string;
+  expect: string;
+  actual: string;
+};
+
+export type AnomalyDatabaseMigrationChecksumMismatchPayload = {
+  mismatchList: MigrationChecksumMismatch[];
+};
+
 export type AnomalyPayload =
   | AnomalyDatabaseBackupPolicyViolationPayload
   | AnomalyDatabaseBackupMissingPayload
   | AnomalyDatabaseConnectionPayload
-  | AnomalyDatabaseSchemaDriftPayload;
+  | AnomalyDatabaseSchemaDriftPayload
+  | AnomalyDatabaseMigrationChecksumMismatchPayload;
 
 export type AnomalySeverity = "MEDIUM" | "HIGH" | "CRITICAL";
 
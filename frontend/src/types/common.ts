@@ -366,6 +366,7 @@ const makeUnknown = (type: ResourceType) => {
     name: "<<Unknown stage>>",
     environment: UNKNOWN_ENVIRONMENT,
     taskList: [],
+    payload: {},
   };
 
   const UNKNOWN_TASK_PROGRESS: TaskProgress = {
@@ -393,6 +394,9 @@ const makeUnknown = (type: ResourceType) => {
     taskCheckRunList: [],
     blockedBy: [],
     progress: { ...UNKNOWN_TASK_PROGRESS },
+    queue: { position: 0, reason: "" },
+    maintenanceWindowOverride: false,
+    maintenanceWindow: { nextAllowedTs: 0, reason: "" },
   };
 
   const UNKNOWN_ACTIVITY: Activity = {
@@ -794,6 +798,7 @@ const makeEmpty = (type: ResourceType) => {
     name: "",
     environment: EMPTY_ENVIRONMENT,
     taskList: [],
+    payload: {},
   };
 
   const EMPTY_TASK_PROGRESS: TaskProgress = {
@@ -821,6 +826,9 @@ const makeEmpty = (type: ResourceType) => {
     earliestAllowedTs: 0,
     blockedBy: [],
     progress: { ...EMPTY_TASK_PROGRESS },
+    queue: { position: 0, reason: "" },
+    maintenanceWindowOverride: false,
+    maintenanceWindow: { nextAllowedTs: 0, reason: "" },
   };
 
   const EMPTY_ACTIVITY: Activity = {
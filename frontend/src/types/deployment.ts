@@ -1,5 +1,5 @@
 import { Project } from ".";
-import { DeploymentConfigId } from "./id";
+import { DatabaseId, DeploymentConfigId } from "./id";
 import { LabelKeyType, LabelValueType } from "./label";
 import { Principal } from "./principal";
 
@@ -34,6 +34,17 @@ export type Deployment = {
 
 export type DeploymentSpec = {
   selector: LabelSelector;
+  // Canary rollout controls, roll out all databases at once if not set.
+  rollout?: DeploymentRollout;
+};
+
+export type DeploymentRollout = {
+  batchSize?: number;
+  // Used if batchSize is not set.
+  batchPercentage?: number;
+  pauseSeconds?: number;
+  // The rest of the rollout halts once the failed databases exceed it.
+  maxFailureCount: number;
 };
 
 export type LabelSelector = {
@@ -46,4 +57,15 @@ export type LabelSelectorRequirement = {
   values: LabelValueType[];
 };
 
-export type OperatorType = "In" | "Exists";
+export type OperatorType = "In" | "NotIn" | "Exists" | "DoesNotExist";
+
+export type DeploymentPreview = {
+  name: string;
+  databaseList: DeploymentPreviewDatabase[];
+};
+
+export type DeploymentPreviewDatabase = {
+  id: DatabaseId;
+  name: string;
+  instanceName: string;
+};
@@ -59,6 +59,8 @@ export type VCSId = IdType;
 
 export type RepositoryId = IdType;
 
+export type WebhookDeliveryId = IdType;
+
 export type MigrationHistoryId = IdType;
 
 export type BackupId = IdType;
@@ -35,6 +35,7 @@ export * from "./table";
 export * from "./tableIndex";
 export * from "./vcs";
 export * from "./view";
+export * from "./webhookDelivery";
 export * from "./db_extension";
 export * from "./label";
 export * from "./deployment";
@@ -103,7 +103,12 @@ export type InstanceMigration = {
 
 export type MigrationSource = "UI" | "VCS" | "LIBRARY";
 
-export type MigrationType = "BASELINE" | "MIGRATE" | "BRANCH" | "DATA";
+export type MigrationType =
+  | "BASELINE"
+  | "MIGRATE"
+  | "BRANCH"
+  | "DATA"
+  | "REPEATABLE";
 
 export type MigrationStatus = "PENDING" | "DONE" | "FAILED";
 
@@ -130,4 +135,5 @@ export type MigrationHistory = {
   executionDurationNs: number;
   issueId: number;
   payload?: MigrationHistoryPayload;
+  checksum: string;
 };
@@ -6,7 +6,7 @@ import {
   PrincipalId,
   ProjectId,
 } from "./id";
-import { Pipeline, PipelineCreate } from "./pipeline";
+import { DataUpdateChunk, Pipeline, PipelineCreate } from "./pipeline";
 import { Principal } from "./principal";
 import { Project } from "./project";
 import { MigrationType } from "./instance";
@@ -28,7 +28,7 @@ export type IssueType =
   | IssueTypeDatabase
   | IssueTypeDataSource;
 
-export type IssueStatus = "OPEN" | "DONE" | "CANCELED";
+export type IssueStatus = "DRAFT" | "OPEN" | "DONE" | "CANCELED";
 
 export type CreateDatabaseContext = {
   instanceId: InstanceId;
@@ -49,6 +49,10 @@ export type MigrationDetail = {
   databaseName: string;
   statement: string;
   earliestAllowedTs: number;
+  // Execute a DATA type of migration in primary key ranged chunks
+  dataUpdateChunk?: DataUpdateChunk;
+  // Snapshot the rows changed by a DATA type of migration, Postgres only
+  rowSnapshot?: boolean;
 };
 
 export type UpdateSchemaGhostDetail = MigrationDetail & {
@@ -197,6 +201,7 @@ export const CREATOR_APPLICABLE_ACTION_LIST: Map<
   IssueStatus,
   IssueStatusTransitionType[]
 > = new Map([
+  ["DRAFT", ["CANCEL"]],
   ["OPEN", ["CANCEL"]],
   ["DONE", ["REOPEN"]],
   ["CANCELED", ["REOPEN"]],
@@ -206,6 +211,7 @@ export const ASSIGNEE_APPLICABLE_ACTION_LIST: Map<
   IssueStatus,
   IssueStatusTransitionType[]
 > = new Map([
+  ["DRAFT", ["CANCEL"]],
   ["OPEN", ["RESOLVE", "CANCEL"]],
   ["DONE", ["REOPEN"]],
   ["CANCELED", ["REOPEN"]],
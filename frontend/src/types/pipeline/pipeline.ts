@@ -22,7 +22,7 @@ import { Stage, StageCreate } from "./stage";
     Change prod database schema
 */
 // Pipeline
-export type PipelineStatus = "OPEN" | "DONE" | "CANCELED";
+export type PipelineStatus = "DRAFT" | "OPEN" | "DONE" | "CANCELED";
 
 export type Pipeline = {
   id: PipelineId;
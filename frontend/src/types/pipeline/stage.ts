@@ -1,7 +1,8 @@
 // The database belongs to an instance which in turns belongs to an environment.
 
+import { DeploymentRollout } from "../deployment";
 import { Environment } from "../environment";
-import { EnvironmentId, StageId } from "../id";
+import { EnvironmentId, StageId, TaskRunId } from "../id";
 import { Principal } from "../principal";
 import { Pipeline } from "./pipeline";
 import { Task, TaskCreate, TaskStatus } from "./task";
@@ -23,6 +24,14 @@ export type Stage = {
 
   // Domain specific fields
   name: string;
+  payload: StagePayload;
+};
+
+export type StagePayload = {
+  // Copied from the deployment of the tenant project when creating the stage.
+  rollout?: DeploymentRollout;
+  // The IDs of the failed task runs counted when the rollout halted last time.
+  rolloutHaltTaskRunIdList?: TaskRunId[];
 };
 
 export type StageCreate = {
@@ -94,9 +94,30 @@ export type TaskDatabasePITRDeletePayload = {
   // more input and output parameters in the future
 };
 
+export type DataUpdateChunk = {
+  // Rows in the primary key range of a chunk
+  chunkSize: number;
+  pauseMilliseconds?: number;
+  // 0 means no throttling
+  maxReplicaLagSeconds?: number;
+};
+
+export type DataUpdateChunkCursor = {
+  lastKey: string;
+  executedChunk: number;
+  affectedRows: number;
+};
+
 export type TaskDatabaseDataUpdatePayload = {
   statement: string;
   pushEvent?: VCSPushEvent;
+  chunk?: DataUpdateChunk;
+  chunkCursor?: DataUpdateChunkCursor;
+  // Postgres only, snapshot the changed rows before the change
+  rowSnapshot?: boolean;
+  rowSnapshotTableList?: string[];
+  // Restores the changed rows from the snapshot tables
+  compensatingStatement?: string;
 };
 
 export type TaskDatabaseRestorePayload = {
@@ -128,6 +149,25 @@ export type TaskProgress = {
   createdTs: number;
   updatedTs: number;
   payload?: TaskProgressPayload; // JSON encoded
+  // Statements split by the parser
+  totalStatement?: number;
+  executedStatement?: number;
+  currentStatement?: string;
+  // Estimated seconds to complete, e.g. the gh-ost ETA
+  etaSeconds?: number;
+};
+
+export type TaskQueue = {
+  // 1-based position in the queue of the instance, 0 means not queued.
+  position: number;
+  reason: string;
+};
+
+export type TaskMaintenanceWindow = {
+  // When the task is allowed to start, 0 means unknown.
+  nextAllowedTs: number;
+  // Empty means the task is allowed to start now.
+  reason: string;
 };
 
 export type Task = {
@@ -160,6 +200,11 @@ export type Task = {
 
   // Task progress
   progress: TaskProgress;
+  // Queue status when the running task is waiting for its turn
+  queue: TaskQueue;
+  // Break-glass override of the maintenance windows by the owner
+  maintenanceWindowOverride: boolean;
+  maintenanceWindow: TaskMaintenanceWindow;
 };
 
 export type TaskCreate = {
@@ -217,6 +262,9 @@ export type TaskRun = {
   comment: string;
   result: TaskRunResultPayload;
   payload?: TaskPayload;
+  startedTs: number;
+  progress?: TaskProgress;
+  queue?: TaskQueue;
 };
 
 export type TaskCheckRunStatus = "RUNNING" | "DONE" | "FAILED" | "CANCELED";
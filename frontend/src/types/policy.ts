@@ -7,13 +7,17 @@ import {
   RuleType,
   RuleLevel,
   SubsetOf,
+  TaskType,
 } from ".";
 
 export type PolicyType =
   | "bb.policy.pipeline-approval"
   | "bb.policy.backup-plan"
   | "bb.policy.sql-review"
-  | "bb.policy.environment-tier";
+  | "bb.policy.environment-tier"
+  | "bb.policy.task-retry"
+  | "bb.policy.task-concurrency"
+  | "bb.policy.maintenance-window";
 
 export type PipelineApprovalPolicyValue =
   | "MANUAL_APPROVAL_NEVER"
@@ -53,6 +57,52 @@ export type SQLReviewPolicyPayload = {
   }[];
 };
 
+// TaskRetryPolicyPayload is the payload for task retry policy in the backend.
+export type TaskRetryPolicyPayload = {
+  ruleList: {
+    taskType: SubsetOf<
+      TaskType,
+      | "bb.task.database.schema.update"
+      | "bb.task.database.schema.update-sdl"
+      | "bb.task.database.data.update"
+      | "bb.task.database.backup"
+    >;
+    maxAttempts: number;
+    initialBackoffSeconds: number;
+    maxBackoffSeconds: number;
+  }[];
+};
+
+// TaskConcurrencyPolicyPayload is the payload for task concurrency policy in the backend.
+// Zero means no limit.
+export type TaskConcurrencyPolicyPayload = {
+  maxConcurrentTasks: number;
+  instanceMaxConcurrentTasks: number;
+};
+
+// MaintenanceWindow is a recurring window in UTC.
+// The window ends on the next day if endTime is not after startTime.
+export type MaintenanceWindow = {
+  // 0 is Sunday, empty means every day.
+  dayOfWeekList: number[];
+  // In the "HH:MM" format.
+  startTime: string;
+  endTime: string;
+};
+
+export type ChangeFreeze = {
+  title: string;
+  startTs: number;
+  endTs: number;
+};
+
+// MaintenanceWindowPolicyPayload is the payload for maintenance window policy in the backend.
+// Tasks can start at any time if windowList is empty.
+export type MaintenanceWindowPolicyPayload = {
+  windowList: MaintenanceWindow[];
+  freezeList: ChangeFreeze[];
+};
+
 export type AssigneeGroupValue = "WORKSPACE_OWNER_OR_DBA" | "PROJECT_OWNER";
 
 export const DefaultAssigneeGroup: AssigneeGroupValue =
@@ -72,7 +122,10 @@ export type PolicyPayload =
   | PipelineApprovalPolicyPayload
   | BackupPlanPolicyPayload
   | SQLReviewPolicyPayload
-  | EnvironmentTierPolicyPayload;
+  | EnvironmentTierPolicyPayload
+  | TaskRetryPolicyPayload
+  | TaskConcurrencyPolicyPayload
+  | MaintenanceWindowPolicyPayload;
 
 export type Policy = {
   id: PolicyId;
@@ -72,6 +72,11 @@ export const PROJECT_HOOK_ACTIVITY_ITEM_LIST: () => ProjectWebhookActivityItem[]
       label: t("project.webhook.activity-item.issue-task-status-change.label"),
       activity: "bb.pipeline.task.status.update",
     },
+    {
+      title: t("project.webhook.activity-item.issue-rollout-halt.title"),
+      label: t("project.webhook.activity-item.issue-rollout-halt.label"),
+      activity: "bb.pipeline.rollout.halt",
+    },
     {
       title: t("project.webhook.activity-item.issue-info-change.title"),
       label: t("project.webhook.activity-item.issue-info-change.label"),
@@ -1,5 +1,5 @@
 import isEmpty from "lodash-es/isEmpty";
-import { RepositoryId, VCSId } from "./id";
+import { EnvironmentId, RepositoryId, VCSId } from "./id";
 import { Principal } from "./principal";
 import { Project } from "./project";
 import { VCS } from "./vcs";
@@ -26,7 +26,11 @@ export type Repository = {
   webUrl: string;
   baseDirectory: string;
   branchFilter: string;
+  // If set, the branch mapping decides the branches instead of the branchFilter.
+  branchMapping?: BranchMappingSetting;
   filePathTemplate: string;
+  // Optional dedicated template for the repeatable migration files.
+  repeatableFilePathTemplate?: string;
   schemaPathTemplate: string;
   sheetPathTemplate: string;
   enableSQLReviewCI: boolean;
@@ -35,6 +39,16 @@ export type Repository = {
   externalId: string;
 };
 
+export type BranchMapping = {
+  // e.g. release/*
+  branchFilter: string;
+  environmentIdList: EnvironmentId[];
+};
+
+export type BranchMappingSetting = {
+  mappingList: BranchMapping[];
+};
+
 export type SQLReviewCISetup = {
   pullRequestURL: string;
 };
@@ -61,7 +75,9 @@ export type RepositoryCreate = {
 export type RepositoryPatch = {
   baseDirectory?: string;
   branchFilter?: string;
+  branchMapping?: BranchMappingSetting;
   filePathTemplate?: string;
+  repeatableFilePathTemplate?: string;
   schemaPathTemplate?: string;
   sheetPathTemplate?: string;
   enableSQLReviewCI?: boolean;
@@ -0,0 +1,32 @@
+import { WebhookDeliveryId } from "./id";
+import { VCSType } from "./vcs";
+
+export type WebhookDeliveryStatus = "PROCESSING" | "SUCCEEDED" | "FAILED";
+
+export type WebhookDeliveryVerification = "NONE" | "PASSED" | "FAILED";
+
+export type WebhookDelivery = {
+  id: WebhookDeliveryId;
+
+  // Standard fields
+  createdTs: number;
+  updatedTs: number;
+
+  // Related fields
+  webhookEndpointId: string;
+
+  // Domain specific fields
+  vcsType: VCSType;
+  // The unique ID assigned by the VCS provider, or the SHA256 of the payload.
+  deliveryId: string;
+  event: string;
+  // JSON encoded request headers with the secret token redacted.
+  headers: string;
+  payload: string;
+  verification: WebhookDeliveryVerification;
+  status: WebhookDeliveryStatus;
+  responseCode: number;
+  result: string;
+  duplicateCount: number;
+  replayCount: number;
+};
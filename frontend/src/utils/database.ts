@@ -61,13 +61,15 @@ export function sortDatabaseList(
 }
 
 const MIN_GHOST_SUPPORT_MYSQL_VERSION = "5.7.0";
+// Postgres versions are not semver, e.g. 14.2, so we compare the major version only.
+const MIN_ONLINE_MIGRATION_SUPPORT_POSTGRES_MAJOR_VERSION = 10;
 
 export function allowGhostMigration(databaseList: Database[]): boolean {
   const groupByEnvironment = groupBy(
     databaseList,
     (db) => db.instance.environment.id
   );
-  // Multiple tasks in one stage is not supported by gh-ost now.
+  // Multiple tasks in one stage is not supported by online migration now.
   for (const environmentId in groupByEnvironment) {
     const databaseListInStage = groupByEnvironment[environmentId];
     if (databaseListInStage.length > 1) {
@@ -76,6 +78,12 @@ export function allowGhostMigration(databaseList: Database[]): boolean {
   }
 
   return databaseList.every((db) => {
+    if (db.instance.engine === "POSTGRES") {
+      return (
+        parseInt(db.instance.engineVersion, 10) >=
+        MIN_ONLINE_MIGRATION_SUPPORT_POSTGRES_MAJOR_VERSION
+      );
+    }
     return (
       db.instance.engine === "MYSQL" &&
       semverCompare(
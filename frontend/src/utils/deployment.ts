@@ -71,7 +71,10 @@ export const validateDeploymentSpec = (
     if (!rule.key) {
       return "deployment-config.error.key-required";
     }
-    if (rule.operator === "In" && rule.values.length === 0) {
+    if (
+      (rule.operator === "In" || rule.operator === "NotIn") &&
+      rule.values.length === 0
+    ) {
       return "deployment-config.error.values-required";
     }
   }
@@ -0,0 +1,513 @@
+// Package index implements the index advisor recommending indexes for the queries
+// and finding the redundant indexes in the database.
+package index
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/bytebase/bytebase/plugin/advisor/catalog"
+	"github.com/bytebase/bytebase/plugin/db"
+)
+
+const (
+	// maxIndexColumnCount is the maximum number of columns in a recommended index.
+	maxIndexColumnCount = 4
+	// maxMySQLIndexNameLength is the identifier length limit of MySQL.
+	maxMySQLIndexNameLength = 64
+	// maxPostgreSQLIndexNameLength is the identifier length limit of PostgreSQL in bytes.
+	maxPostgreSQLIndexNameLength = 63
+	// defaultPostgreSQLSchema is the schema used for the tables without schema qualifier in PostgreSQL.
+	defaultPostgreSQLSchema = "public"
+)
+
+// Query is the query analyzed by the index advisor.
+type Query struct {
+	// Statement is the query statement.
+	Statement string
+	// Calls is the number of times the query has been executed.
+	Calls int64
+	// FullScanTableList is the list of tables scanned sequentially in the query plan.
+	// Nil means the query plan is unknown, e.g. the statement contains parameter placeholders.
+	FullScanTableList []string
+}
+
+// Recommendation is the index recommended by the index advisor.
+type Recommendation struct {
+	Schema     string   `json:"schema"`
+	Table      string   `json:"table"`
+	Name       string   `json:"name"`
+	ColumnList []string `json:"columnList"`
+	// Statement is the statement to create the index.
+	Statement string `json:"statement"`
+	// Benefit is the estimated number of rows saved from scanning.
+	Benefit int64 `json:"benefit"`
+	// QueryList is the list of queries benefiting from the index.
+	QueryList []string `json:"queryList"`
+}
+
+// RedundantIndex is the index covered by another index on the same table.
+type RedundantIndex struct {
+	Schema string `json:"schema"`
+	Table  string `json:"table"`
+	Name   string `json:"name"`
+	// CoveredBy is the name of the index whose leading columns are the same as the redundant index.
+	CoveredBy string `json:"coveredBy"`
+	// Duplicate is true if both indexes have exactly the same columns.
+	Duplicate bool `json:"duplicate"`
+	// Statement is the statement to drop the index.
+	Statement string `json:"statement"`
+}
+
+// predicateType is the type of the column reference.
+type predicateType int
+
+const (
+	// predicateTypeEqual is the column compared by equality, e.g. a = 1, a IN (1, 2) and a IS NULL.
+	predicateTypeEqual predicateType = iota
+	// predicateTypeJoin is the column compared by equality with another column, e.g. a.id = b.a_id.
+	predicateTypeJoin
+	// predicateTypeRange is the column compared by range, e.g. a > 1, a BETWEEN 1 AND 2 and a LIKE 'abc%'.
+	predicateTypeRange
+	// predicateTypeOrder is the column used in ORDER BY.
+	predicateTypeOrder
+)
+
+// tableReference is a table referenced in the query.
+type tableReference struct {
+	schema string
+	name   string
+	alias  string
+}
+
+// columnReference is a column referenced in the query.
+type columnReference struct {
+	// table is the table name or alias qualifying the column, empty if the column is not qualified.
+	table  string
+	column string
+	tp     predicateType
+}
+
+// queryAccess is the tables and columns accessed by a query.
+type queryAccess struct {
+	tableList  []*tableReference
+	columnList []*columnReference
+}
+
+// Recommend recommends the indexes for the query list ranked by the estimated benefit.
+// The queries that cannot be parsed are skipped.
+func Recommend(dbType db.Type, database *catalog.Database, queryList []*Query) ([]*Recommendation, error) {
+	recommendationMap := make(map[string]*Recommendation)
+	for _, query := range queryList {
+		access, err := extractQueryAccess(dbType, query.Statement)
+		if err != nil {
+			return nil, err
+		}
+		if access == nil {
+			continue
+		}
+		for _, candidate := range buildCandidateList(dbType, database, access) {
+			if query.FullScanTableList != nil && !isFullScan(query.FullScanTableList, candidate) {
+				continue
+			}
+			if isCoveredByIndex(candidate.table, candidate.columnList) {
+				continue
+			}
+			calls := query.Calls
+			if calls <= 0 {
+				calls = 1
+			}
+			rowCount := candidate.table.RowCount
+			if rowCount <= 0 {
+				rowCount = 1
+			}
+
+			key := fmt.Sprintf("%s.%s(%s)", candidate.schema, candidate.table.Name, strings.Join(candidate.columnList, ","))
+			recommendation, ok := recommendationMap[key]
+			if !ok {
+				name := getIndexName(dbType, candidate.table.Name, candidate.columnList)
+				recommendation = &Recommendation{
+					Schema:     candidate.schema,
+					Table:      candidate.table.Name,
+					Name:       name,
+					ColumnList: candidate.columnList,
+					Statement:  getCreateIndexStatement(dbType, candidate.schema, candidate.table.Name, name, candidate.columnList),
+				}
+				recommendationMap[key] = recommendation
+			}
+			recommendation.Benefit += calls * rowCount
+			recommendation.QueryList = append(recommendation.QueryList, query.Statement)
+		}
+	}
+
+	return mergeRecommendation(recommendationMap), nil
+}
+
+// FindRedundantIndex finds the indexes whose columns are the leading columns of another index on the same table.
+// The primary and unique indexes are never redundant because they enforce constraints.
+func FindRedundantIndex(dbType db.Type, database *catalog.Database) []*RedundantIndex {
+	var result []*RedundantIndex
+	for _, schema := range database.SchemaList {
+		for _, table := range schema.TableList {
+			for _, index := range table.IndexList {
+				if index.Primary || index.Unique || !isBTreeIndex(index) {
+					continue
+				}
+				expressionList := normalizeExpressionList(index.ExpressionList)
+				for _, other := range table.IndexList {
+					if other == index || !isBTreeIndex(other) {
+						continue
+					}
+					otherExpressionList := normalizeExpressionList(other.ExpressionList)
+					if !isPrefix(expressionList, otherExpressionList) {
+						continue
+					}
+					duplicate := len(expressionList) == len(otherExpressionList)
+					// For duplicate non-unique indexes, only report the one with the greater name.
+					if duplicate && !other.Primary && !other.Unique && index.Name < other.Name {
+						continue
+					}
+					result = append(result, &RedundantIndex{
+						Schema:    schema.Name,
+						Table:     table.Name,
+						Name:      index.Name,
+						CoveredBy: other.Name,
+						Duplicate: duplicate,
+						Statement: getDropIndexStatement(dbType, schema.Name, table.Name, index.Name),
+					})
+					break
+				}
+			}
+		}
+	}
+	return result
+}
+
+// extractQueryAccess extracts the tables and columns accessed by the statement.
+// It returns nil if the statement cannot be parsed or is not a single SELECT, UPDATE or DELETE statement.
+func extractQueryAccess(dbType db.Type, statement string) (*queryAccess, error) {
+	switch dbType {
+	case db.MySQL, db.TiDB:
+		return extractMySQLQueryAccess(statement), nil
+	case db.Postgres:
+		return extractPostgreSQLQueryAccess(statement), nil
+	}
+	return nil, errors.Errorf("index advisor is not supported for engine %s", dbType)
+}
+
+// candidate is a candidate index.
+type candidate struct {
+	schema string
+	table  *catalog.Table
+	// aliasList is the list of aliases of the table in the query.
+	aliasList  []string
+	columnList []string
+}
+
+// buildCandidateList builds the candidate index for each table accessed by the query.
+// The columns are ordered as the equality columns followed by the first range column.
+// The join columns are used as the equality columns only if the table has no other equality columns,
+// and the ORDER BY columns are used only if the table has neither equality nor range columns.
+func buildCandidateList(dbType db.Type, database *catalog.Database, access *queryAccess) []*candidate {
+	type tableColumns struct {
+		schema    string
+		table     *catalog.Table
+		aliasList []string
+		// columnMap is the column list for each predicate type.
+		columnMap map[predicateType][]string
+	}
+	var tableColumnsList []*tableColumns
+	aliasMap := make(map[string]*tableColumns)
+	for _, tableRef := range access.tableList {
+		schema, table := findTable(dbType, database, tableRef)
+		if table == nil {
+			continue
+		}
+		var value *tableColumns
+		for _, tableColumns := range tableColumnsList {
+			if tableColumns.table == table {
+				value = tableColumns
+			}
+		}
+		if value == nil {
+			value = &tableColumns{
+				schema:    schema,
+				table:     table,
+				columnMap: make(map[predicateType][]string),
+			}
+			tableColumnsList = append(tableColumnsList, value)
+		}
+		aliasMap[tableRef.name] = value
+		if tableRef.alias != "" {
+			aliasMap[tableRef.alias] = value
+			value.aliasList = append(value.aliasList, tableRef.alias)
+		}
+	}
+
+	for _, columnRef := range access.columnList {
+		var value *tableColumns
+		if columnRef.table != "" {
+			value = aliasMap[columnRef.table]
+		} else {
+			// Resolve the unqualified column only if exactly one table has it.
+			for _, tableColumns := range tableColumnsList {
+				if hasColumn(tableColumns.table, columnRef.column) {
+					if value != nil && value != tableColumns {
+						value = nil
+						break
+					}
+					value = tableColumns
+				}
+			}
+		}
+		if value == nil {
+			continue
+		}
+		// The index is created on the column name in the schema, which may differ from the query in case.
+		column, ok := getColumnName(value.table, columnRef.column)
+		if !ok {
+			continue
+		}
+		if !containsString(value.columnMap[columnRef.tp], column) {
+			value.columnMap[columnRef.tp] = append(value.columnMap[columnRef.tp], column)
+		}
+	}
+
+	var result []*candidate
+	for _, tableColumns := range tableColumnsList {
+		var columnList []string
+		equalList := tableColumns.columnMap[predicateTypeEqual]
+		if len(equalList) == 0 {
+			equalList = tableColumns.columnMap[predicateTypeJoin]
+		}
+		for _, column := range equalList {
+			if len(columnList) < maxIndexColumnCount {
+				columnList = append(columnList, column)
+			}
+		}
+		for _, column := range tableColumns.columnMap[predicateTypeRange] {
+			if !containsString(columnList, column) && len(columnList) < maxIndexColumnCount {
+				columnList = append(columnList, column)
+				break
+			}
+		}
+		if len(columnList) == 0 {
+			for _, column := range tableColumns.columnMap[predicateTypeOrder] {
+				if len(columnList) < maxIndexColumnCount {
+					columnList = append(columnList, column)
+				}
+			}
+		}
+		if len(columnList) == 0 {
+			continue
+		}
+		result = append(result, &candidate{
+			schema:     tableColumns.schema,
+			table:      tableColumns.table,
+			aliasList:  tableColumns.aliasList,
+			columnList: columnList,
+		})
+	}
+	return result
+}
+
+// mergeRecommendation folds the recommendation into the one on the same table whose leading columns are the same,
+// and sorts the recommendations by the benefit in descending order.
+func mergeRecommendation(recommendationMap map[string]*Recommendation) []*Recommendation {
+	var list []*Recommendation
+	for _, recommendation := range recommendationMap {
+		list = append(list, recommendation)
+	}
+	// Longer indexes come first so that the shorter ones are folded into them.
+	sort.Slice(list, func(i, j int) bool {
+		if len(list[i].ColumnList) != len(list[j].ColumnList) {
+			return len(list[i].ColumnList) > len(list[j].ColumnList)
+		}
+		return list[i].Name < list[j].Name
+	})
+
+	var result []*Recommendation
+	for _, recommendation := range list {
+		merged := false
+		for _, existing := range result {
+			if existing.Schema == recommendation.Schema && existing.Table == recommendation.Table && isPrefix(recommendation.ColumnList, existing.ColumnList) {
+				existing.Benefit += recommendation.Benefit
+				existing.QueryList = append(existing.QueryList, recommendation.QueryList...)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			result = append(result, recommendation)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Benefit > result[j].Benefit
+	})
+	return result
+}
+
+// findTable finds the table referenced in the query in the catalog.
+// For PostgreSQL, the table without schema qualifier is looked up in the public schema first.
+func findTable(dbType db.Type, database *catalog.Database, tableRef *tableReference) (string, *catalog.Table) {
+	var found *catalog.Table
+	foundSchema := ""
+	for _, schema := range database.SchemaList {
+		if tableRef.schema != "" && schema.Name != tableRef.schema {
+			continue
+		}
+		for _, table := range schema.TableList {
+			if !strings.EqualFold(table.Name, tableRef.name) {
+				continue
+			}
+			if dbType == db.Postgres && tableRef.schema == "" && schema.Name == defaultPostgreSQLSchema {
+				return schema.Name, table
+			}
+			if found != nil {
+				// Ambiguous table.
+				return "", nil
+			}
+			found, foundSchema = table, schema.Name
+		}
+	}
+	return foundSchema, found
+}
+
+// isCoveredByIndex returns true if the columns are the leading columns of an existing index.
+func isCoveredByIndex(table *catalog.Table, columnList []string) bool {
+	for _, index := range table.IndexList {
+		if !isBTreeIndex(index) {
+			continue
+		}
+		if isPrefix(columnList, normalizeExpressionList(index.ExpressionList)) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBTreeIndex returns true if the index supports equality and range lookups on its leading columns.
+func isBTreeIndex(index *catalog.Index) bool {
+	return index.Type == "" || strings.EqualFold(index.Type, "btree")
+}
+
+func normalizeExpressionList(expressionList []string) []string {
+	var result []string
+	for _, expression := range expressionList {
+		expression = strings.TrimSpace(expression)
+		upper := strings.ToUpper(expression)
+		if strings.HasSuffix(upper, " DESC") || strings.HasSuffix(upper, " ASC") {
+			expression = strings.TrimSpace(expression[:strings.LastIndex(expression, " ")])
+		}
+		result = append(result, strings.Trim(expression, "`\""))
+	}
+	return result
+}
+
+// isPrefix returns true if a is the prefix of b, ignoring case.
+func isPrefix(a, b []string) bool {
+	if len(a) == 0 || len(a) > len(b) {
+		return false
+	}
+	for i := range a {
+		if !strings.EqualFold(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasColumn(table *catalog.Table, column string) bool {
+	_, ok := getColumnName(table, column)
+	return ok
+}
+
+// getColumnName returns the name of the column in the table, ignoring case.
+// The column is returned as is if the catalog doesn't have the column list because the schema has not been synced.
+func getColumnName(table *catalog.Table, column string) (string, bool) {
+	if len(table.ColumnList) == 0 {
+		return column, true
+	}
+	for _, c := range table.ColumnList {
+		if strings.EqualFold(c.Name, column) {
+			return c.Name, true
+		}
+	}
+	return "", false
+}
+
+// isFullScan returns true if the candidate table is in the full scan table list.
+// The query plan may refer to the table by its alias.
+func isFullScan(fullScanTableList []string, candidate *candidate) bool {
+	for _, table := range fullScanTableList {
+		if strings.EqualFold(table, candidate.table.Name) {
+			return true
+		}
+		for _, alias := range candidate.aliasList {
+			if strings.EqualFold(table, alias) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// getIndexName returns the name of the index, which is truncated to the identifier length limit of the engine.
+// The limit is in bytes, and the name is truncated on the character boundary.
+func getIndexName(dbType db.Type, table string, columnList []string) string {
+	maxLength := maxMySQLIndexNameLength
+	if dbType == db.Postgres {
+		maxLength = maxPostgreSQLIndexNameLength
+	}
+	name := fmt.Sprintf("idx_%s_%s", table, strings.Join(columnList, "_"))
+	if len(name) <= maxLength {
+		return name
+	}
+	length := 0
+	for i := range name {
+		if i > maxLength {
+			break
+		}
+		length = i
+	}
+	return name[:length]
+}
+
+// quoteIdentifier quotes the identifier with the identifier quote character of the engine.
+func quoteIdentifier(dbType db.Type, identifier string) string {
+	if dbType == db.Postgres {
+		return fmt.Sprintf(`"%s"`, strings.ReplaceAll(identifier, `"`, `""`))
+	}
+	return fmt.Sprintf("`%s`", strings.ReplaceAll(identifier, "`", "``"))
+}
+
+func getCreateIndexStatement(dbType db.Type, schema, table, name string, columnList []string) string {
+	var quotedList []string
+	for _, column := range columnList {
+		quotedList = append(quotedList, quoteIdentifier(dbType, column))
+	}
+	if dbType == db.Postgres {
+		return fmt.Sprintf("CREATE INDEX %s ON %s.%s (%s);", quoteIdentifier(dbType, name), quoteIdentifier(dbType, schema), quoteIdentifier(dbType, table), strings.Join(quotedList, ", "))
+	}
+	return fmt.Sprintf("CREATE INDEX %s ON %s (%s);", quoteIdentifier(dbType, name), quoteIdentifier(dbType, table), strings.Join(quotedList, ", "))
+}
+
+func getDropIndexStatement(dbType db.Type, schema, table, name string) string {
+	if dbType == db.Postgres {
+		return fmt.Sprintf("DROP INDEX %s.%s;", quoteIdentifier(dbType, schema), quoteIdentifier(dbType, name))
+	}
+	return fmt.Sprintf("DROP INDEX %s ON %s;", quoteIdentifier(dbType, name), quoteIdentifier(dbType, table))
+}
@@ -0,0 +1,202 @@
+package index
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bytebase/bytebase/plugin/advisor/catalog"
+	"github.com/bytebase/bytebase/plugin/db"
+)
+
+func newTestDatabase(schemaName string) *catalog.Database {
+	return &catalog.Database{
+		Name: "test",
+		SchemaList: []*catalog.Schema{
+			{
+				Name: schemaName,
+				TableList: []*catalog.Table{
+					{
+						Name:     "user",
+						RowCount: 1000,
+						ColumnList: []*catalog.Column{
+							{Name: "id"},
+							{Name: "name"},
+							{Name: "email"},
+							{Name: "created_ts"},
+						},
+						IndexList: []*catalog.Index{
+							{Name: "PRIMARY", ExpressionList: []string{"id"}, Type: "BTREE", Unique: true, Primary: true},
+							{Name: "idx_user_name", ExpressionList: []string{"name"}, Type: "BTREE"},
+							{Name: "idx_user_name_email", ExpressionList: []string{"name", "email"}, Type: "BTREE"},
+						},
+					},
+					{
+						Name:     "post",
+						RowCount: 100,
+						ColumnList: []*catalog.Column{
+							{Name: "id"},
+							{Name: "user_id"},
+							{Name: "title"},
+							{Name: "created_ts"},
+						},
+						IndexList: []*catalog.Index{
+							{Name: "PRIMARY", ExpressionList: []string{"id"}, Type: "BTREE", Unique: true, Primary: true},
+							{Name: "idx_post_title_1", ExpressionList: []string{"title"}, Type: "BTREE"},
+							{Name: "idx_post_title_2", ExpressionList: []string{"title"}, Type: "BTREE"},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestRecommendMySQL(t *testing.T) {
+	database := newTestDatabase("")
+	queryList := []*Query{
+		{
+			// MySQL refers to the table by its alias in the query plan.
+			Statement:         "SELECT * FROM post p JOIN user u ON p.user_id = u.id WHERE u.email = 'a@b.com' AND p.created_ts > 100",
+			Calls:             10,
+			FullScanTableList: []string{"p", "u"},
+		},
+		{
+			// Covered by idx_user_name_email.
+			Statement: "SELECT * FROM user WHERE name = 'a' AND email = 'b'",
+			Calls:     100,
+		},
+		{
+			Statement:         "SELECT * FROM post WHERE user_id = ?",
+			Calls:             1,
+			FullScanTableList: []string{"post"},
+		},
+		{
+			// The plan does not scan the table sequentially.
+			Statement:         "SELECT * FROM user WHERE created_ts > 1",
+			Calls:             1,
+			FullScanTableList: []string{},
+		},
+		{
+			// Truncated digest text.
+			Statement: "SELECT * FROM user WHERE email IN (...",
+			Calls:     1,
+		},
+	}
+
+	recommendationList, err := Recommend(db.MySQL, database, queryList)
+	require.NoError(t, err)
+	require.Len(t, recommendationList, 2)
+
+	require.Equal(t, "user", recommendationList[0].Table)
+	require.Equal(t, []string{"email"}, recommendationList[0].ColumnList)
+	require.Equal(t, int64(10*1000), recommendationList[0].Benefit)
+	require.Equal(t, "CREATE INDEX `idx_user_email` ON `user` (`email`);", recommendationList[0].Statement)
+
+	// The recommendation on (user_id) is folded into the one on (user_id, created_ts).
+	require.Equal(t, "post", recommendationList[1].Table)
+	require.Equal(t, []string{"user_id", "created_ts"}, recommendationList[1].ColumnList)
+	require.Equal(t, int64(10*100+1*100), recommendationList[1].Benefit)
+	require.Len(t, recommendationList[1].QueryList, 2)
+}
+
+func TestRecommendPostgreSQL(t *testing.T) {
+	database := newTestDatabase("public")
+	queryList := []*Query{
+		{
+			Statement: `SELECT * FROM "user" WHERE email LIKE 'abc%' ORDER BY created_ts`,
+			Calls:     3,
+		},
+		{
+			// Leading wildcard cannot use the index.
+			Statement: `SELECT * FROM post WHERE title LIKE '%abc'`,
+			Calls:     3,
+		},
+		{
+			Statement: `UPDATE public.post SET title = $1 WHERE user_id = $2 AND created_ts IS NULL`,
+			Calls:     5,
+		},
+	}
+
+	recommendationList, err := Recommend(db.Postgres, database, queryList)
+	require.NoError(t, err)
+	require.Len(t, recommendationList, 2)
+
+	require.Equal(t, []string{"email"}, recommendationList[0].ColumnList)
+	require.Equal(t, `CREATE INDEX "idx_user_email" ON "public"."user" ("email");`, recommendationList[0].Statement)
+	require.Equal(t, []string{"user_id", "created_ts"}, recommendationList[1].ColumnList)
+}
+
+func TestFindRedundantIndex(t *testing.T) {
+	database := newTestDatabase("")
+	redundantIndexList := FindRedundantIndex(db.MySQL, database)
+	require.Equal(t, []*RedundantIndex{
+		{
+			Table:     "user",
+			Name:      "idx_user_name",
+			CoveredBy: "idx_user_name_email",
+			Statement: "DROP INDEX `idx_user_name` ON `user`;",
+		},
+		{
+			Table:     "post",
+			Name:      "idx_post_title_2",
+			CoveredBy: "idx_post_title_1",
+			Duplicate: true,
+			Statement: "DROP INDEX `idx_post_title_2` ON `post`;",
+		},
+	}, redundantIndexList)
+}
+
+func TestRecommendMixedCaseAndNonASCII(t *testing.T) {
+	// The table name takes 60 bytes in UTF-8.
+	table := strings.Repeat("订单", 10)
+	newDatabase := func(schemaName string) *catalog.Database {
+		return &catalog.Database{
+			Name: "test",
+			SchemaList: []*catalog.Schema{
+				{
+					Name: schemaName,
+					TableList: []*catalog.Table{
+						{
+							Name:     table,
+							RowCount: 100,
+							ColumnList: []*catalog.Column{
+								{Name: "id"},
+								{Name: "userId"},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	recommendationList, err := Recommend(db.Postgres, newDatabase("public"), []*Query{
+		{Statement: fmt.Sprintf(`SELECT * FROM "%s" WHERE "USERID" = 1`, table), Calls: 1},
+	})
+	require.NoError(t, err)
+	require.Len(t, recommendationList, 1)
+	require.Equal(t, []string{"userId"}, recommendationList[0].ColumnList)
+	// The name is truncated to 63 bytes on the character boundary.
+	name := "idx_" + strings.Repeat("订单", 9) + "订"
+	require.Equal(t, name, recommendationList[0].Name)
+	require.Equal(t, fmt.Sprintf(`CREATE INDEX "%s" ON "public"."%s" ("userId");`, name, table), recommendationList[0].Statement)
+
+	recommendationList, err = Recommend(db.MySQL, newDatabase(""), []*Query{
+		{Statement: fmt.Sprintf("SELECT * FROM `%s` WHERE USERID = 1", table), Calls: 1},
+	})
+	require.NoError(t, err)
+	require.Len(t, recommendationList, 1)
+	require.Equal(t, []string{"userId"}, recommendationList[0].ColumnList)
+	// The name is truncated to 64 bytes.
+	name = "idx_" + table
+	require.Equal(t, name, recommendationList[0].Name)
+	require.Equal(t, fmt.Sprintf("CREATE INDEX `%s` ON `%s` (`userId`);", name, table), recommendationList[0].Statement)
+}
+
+func TestGetCreateIndexStatement(t *testing.T) {
+	require.Equal(t, `CREATE INDEX "idx_a""b" ON "public"."a""b" ("c""d");`, getCreateIndexStatement(db.Postgres, "public", `a"b`, `idx_a"b`, []string{`c"d`}))
+	require.Equal(t, "CREATE INDEX `idx_a``b` ON `a``b` (`c``d`);", getCreateIndexStatement(db.MySQL, "", "a`b", "idx_a`b", []string{"c`d"}))
+}
@@ -0,0 +1,139 @@
+package index
+
+import (
+	"strings"
+
+	tidbparser "github.com/pingcap/tidb/parser"
+	"github.com/pingcap/tidb/parser/ast"
+	"github.com/pingcap/tidb/parser/opcode"
+
+	// Register the value expression driver for the parser.
+	_ "github.com/pingcap/tidb/types/parser_driver"
+)
+
+var (
+	_ ast.Visitor = (*mysqlAccessExtractor)(nil)
+)
+
+// extractMySQLQueryAccess extracts the tables and columns accessed by a MySQL statement.
+func extractMySQLQueryAccess(statement string) *queryAccess {
+	p := tidbparser.New()
+	p.EnableWindowFunc(true)
+	nodeList, _, err := p.Parse(statement, "", "")
+	if err != nil || len(nodeList) != 1 {
+		return nil
+	}
+	switch nodeList[0].(type) {
+	case *ast.SelectStmt, *ast.SetOprStmt, *ast.UpdateStmt, *ast.DeleteStmt:
+	default:
+		return nil
+	}
+
+	extractor := &mysqlAccessExtractor{
+		access: &queryAccess{},
+	}
+	nodeList[0].Accept(extractor)
+	return extractor.access
+}
+
+type mysqlAccessExtractor struct {
+	access *queryAccess
+}
+
+// Enter implements the ast.Visitor interface.
+func (e *mysqlAccessExtractor) Enter(in ast.Node) (ast.Node, bool) {
+	switch node := in.(type) {
+	case *ast.TableSource:
+		if table, ok := node.Source.(*ast.TableName); ok {
+			e.access.tableList = append(e.access.tableList, &tableReference{
+				schema: table.Schema.O,
+				name:   table.Name.O,
+				alias:  node.AsName.O,
+			})
+		}
+	case *ast.BinaryOperationExpr:
+		switch node.Op {
+		case opcode.EQ, opcode.NullEQ:
+			tp := predicateTypeEqual
+			if isMySQLColumn(node.L) && isMySQLColumn(node.R) {
+				tp = predicateTypeJoin
+			}
+			e.addColumn(node.L, tp)
+			e.addColumn(node.R, tp)
+		case opcode.LT, opcode.LE, opcode.GT, opcode.GE:
+			e.addColumn(node.L, predicateTypeRange)
+			e.addColumn(node.R, predicateTypeRange)
+		}
+	case *ast.PatternInExpr:
+		if !node.Not {
+			e.addColumn(node.Expr, predicateTypeEqual)
+		}
+	case *ast.IsNullExpr:
+		if !node.Not {
+			e.addColumn(node.Expr, predicateTypeEqual)
+		}
+	case *ast.BetweenExpr:
+		if !node.Not {
+			e.addColumn(node.Expr, predicateTypeRange)
+		}
+	case *ast.PatternLikeExpr:
+		if !node.Not && !hasMySQLLeadingWildcard(node.Pattern) {
+			e.addColumn(node.Expr, predicateTypeRange)
+		}
+	case *ast.OrderByClause:
+		for _, item := range node.Items {
+			e.addColumn(item.Expr, predicateTypeOrder)
+		}
+	}
+	return in, false
+}
+
+// Leave implements the ast.Visitor interface.
+func (*mysqlAccessExtractor) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
+
+func (e *mysqlAccessExtractor) addColumn(expr ast.ExprNode, tp predicateType) {
+	column := getMySQLColumn(expr)
+	if column == nil {
+		return
+	}
+	e.access.columnList = append(e.access.columnList, &columnReference{
+		table:  column.Name.Table.O,
+		column: column.Name.Name.O,
+		tp:     tp,
+	})
+}
+
+func isMySQLColumn(expr ast.ExprNode) bool {
+	return getMySQLColumn(expr) != nil
+}
+
+func getMySQLColumn(expr ast.ExprNode) *ast.ColumnNameExpr {
+	for {
+		parentheses, ok := expr.(*ast.ParenthesesExpr)
+		if !ok {
+			break
+		}
+		expr = parentheses.Expr
+	}
+	column, ok := expr.(*ast.ColumnNameExpr)
+	if !ok {
+		return nil
+	}
+	return column
+}
+
+// hasMySQLLeadingWildcard returns true if the LIKE pattern is not a constant prefix, e.g. '%abc'.
+func hasMySQLLeadingWildcard(pattern ast.ExprNode) bool {
+	value, ok := pattern.(ast.ValueExpr)
+	if !ok {
+		// Parameter marker or expression, whose value is unknown.
+		return true
+	}
+	s, ok := value.GetValue().(string)
+	if !ok {
+		return true
+	}
+	return s == "" || strings.HasPrefix(s, "%") || strings.HasPrefix(s, "_")
+}
@@ -0,0 +1,166 @@
+package index
+
+import (
+	"strings"
+
+	pgquery "github.com/pganalyze/pg_query_go/v2"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	pgOperatorLike = "~~"
+)
+
+// extractPostgreSQLQueryAccess extracts the tables and columns accessed by a PostgreSQL statement.
+func extractPostgreSQLQueryAccess(statement string) *queryAccess {
+	result, err := pgquery.Parse(statement)
+	if err != nil || len(result.Stmts) != 1 {
+		return nil
+	}
+	stmt := result.Stmts[0].Stmt
+	switch stmt.Node.(type) {
+	case *pgquery.Node_SelectStmt, *pgquery.Node_UpdateStmt, *pgquery.Node_DeleteStmt:
+	default:
+		return nil
+	}
+
+	access := &queryAccess{}
+	walkPostgreSQLNode(stmt.ProtoReflect(), func(message proto.Message) {
+		switch node := message.(type) {
+		case *pgquery.RangeVar:
+			tableRef := &tableReference{
+				schema: node.Schemaname,
+				name:   node.Relname,
+			}
+			if node.Alias != nil {
+				tableRef.alias = node.Alias.Aliasname
+			}
+			access.tableList = append(access.tableList, tableRef)
+		case *pgquery.A_Expr:
+			switch node.Kind {
+			case pgquery.A_Expr_Kind_AEXPR_OP:
+				switch getPostgreSQLOperator(node) {
+				case "=":
+					tp := predicateTypeEqual
+					if getPostgreSQLColumn(node.Lexpr) != nil && getPostgreSQLColumn(node.Rexpr) != nil {
+						tp = predicateTypeJoin
+					}
+					access.addPostgreSQLColumn(node.Lexpr, tp)
+					access.addPostgreSQLColumn(node.Rexpr, tp)
+				case "<", "<=", ">", ">=":
+					access.addPostgreSQLColumn(node.Lexpr, predicateTypeRange)
+					access.addPostgreSQLColumn(node.Rexpr, predicateTypeRange)
+				}
+			case pgquery.A_Expr_Kind_AEXPR_IN:
+				if getPostgreSQLOperator(node) == "=" {
+					access.addPostgreSQLColumn(node.Lexpr, predicateTypeEqual)
+				}
+			case pgquery.A_Expr_Kind_AEXPR_BETWEEN, pgquery.A_Expr_Kind_AEXPR_BETWEEN_SYM:
+				access.addPostgreSQLColumn(node.Lexpr, predicateTypeRange)
+			case pgquery.A_Expr_Kind_AEXPR_LIKE:
+				if getPostgreSQLOperator(node) == pgOperatorLike && !hasPostgreSQLLeadingWildcard(node.Rexpr) {
+					access.addPostgreSQLColumn(node.Lexpr, predicateTypeRange)
+				}
+			}
+		case *pgquery.NullTest:
+			if node.Nulltesttype == pgquery.NullTestType_IS_NULL {
+				access.addPostgreSQLColumn(node.Arg, predicateTypeEqual)
+			}
+		case *pgquery.SortBy:
+			access.addPostgreSQLColumn(node.Node, predicateTypeOrder)
+		}
+	})
+	return access
+}
+
+// walkPostgreSQLNode visits the message and all its descendant messages in depth-first order.
+func walkPostgreSQLNode(message protoreflect.Message, visit func(proto.Message)) {
+	visit(message.Interface())
+	message.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.Kind() != protoreflect.MessageKind {
+			return true
+		}
+		if fd.IsList() {
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				walkPostgreSQLNode(list.Get(i).Message(), visit)
+			}
+			return true
+		}
+		if !fd.IsMap() {
+			walkPostgreSQLNode(v.Message(), visit)
+		}
+		return true
+	})
+}
+
+func (access *queryAccess) addPostgreSQLColumn(node *pgquery.Node, tp predicateType) {
+	columnRef := getPostgreSQLColumn(node)
+	if columnRef == nil {
+		return
+	}
+	var nameList []string
+	for _, field := range columnRef.Fields {
+		name, ok := field.Node.(*pgquery.Node_String_)
+		if !ok {
+			// Skip the star.
+			return
+		}
+		nameList = append(nameList, name.String_.Str)
+	}
+	if len(nameList) == 0 {
+		return
+	}
+	column := &columnReference{
+		column: nameList[len(nameList)-1],
+		tp:     tp,
+	}
+	if len(nameList) > 1 {
+		column.table = nameList[len(nameList)-2]
+	}
+	access.columnList = append(access.columnList, column)
+}
+
+func getPostgreSQLColumn(node *pgquery.Node) *pgquery.ColumnRef {
+	if node == nil {
+		return nil
+	}
+	if typeCast, ok := node.Node.(*pgquery.Node_TypeCast); ok {
+		node = typeCast.TypeCast.Arg
+	}
+	columnRef, ok := node.Node.(*pgquery.Node_ColumnRef)
+	if !ok {
+		return nil
+	}
+	return columnRef.ColumnRef
+}
+
+func getPostgreSQLOperator(expr *pgquery.A_Expr) string {
+	if len(expr.Name) != 1 {
+		return ""
+	}
+	name, ok := expr.Name[0].Node.(*pgquery.Node_String_)
+	if !ok {
+		return ""
+	}
+	return name.String_.Str
+}
+
+// hasPostgreSQLLeadingWildcard returns true if the LIKE pattern is not a constant prefix, e.g. '%abc'.
+func hasPostgreSQLLeadingWildcard(node *pgquery.Node) bool {
+	if node == nil {
+		return true
+	}
+	constant, ok := node.Node.(*pgquery.Node_AConst)
+	if !ok || constant.AConst.Val == nil {
+		// Parameter or expression, whose value is unknown.
+		return true
+	}
+	value, ok := constant.AConst.Val.Node.(*pgquery.Node_String_)
+	if !ok {
+		return true
+	}
+	s := value.String_.Str
+	return s == "" || strings.HasPrefix(s, "%") || strings.HasPrefix(s, "_")
+}
@@ -0,0 +1,45 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bytebase/bytebase/plugin/advisor"
+	"github.com/bytebase/bytebase/plugin/advisor/db"
+)
+
+func TestQueryReviewCheck(t *testing.T) {
+	tests := []struct {
+		statement string
+		want      []advisor.Code
+	}{
+		{
+			statement: "SELECT a, b FROM t WHERE a = 1",
+			want:      nil,
+		},
+		{
+			statement: "SELECT * FROM t WHERE a LIKE '%abc'",
+			want:      []advisor.Code{advisor.StatementLeadingWildcardLike, advisor.StatementSelectAll},
+		},
+		{
+			statement: "DELETE FROM t",
+			want:      []advisor.Code{advisor.StatementNoWhere},
+		},
+		{
+			// The digest text may be truncated by the engine.
+			statement: "SELECT a FROM t WHERE a IN (1, 2, ...",
+			want:      nil,
+		},
+	}
+
+	for _, test := range tests {
+		adviceList, err := advisor.QueryReviewCheck(db.MySQL, test.statement)
+		require.NoError(t, err)
+		var codeList []advisor.Code
+		for _, advice := range adviceList {
+			codeList = append(codeList, advice.Code)
+		}
+		require.Equal(t, test.want, codeList, test.statement)
+	}
+}
@@ -0,0 +1,50 @@
+package advisor
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/bytebase/bytebase/plugin/advisor/db"
+)
+
+// queryReviewRuleTypeList is the list of SQL review rules applicable to the queries collected from the engines.
+var queryReviewRuleTypeList = []SQLReviewRuleType{
+	SchemaRuleStatementNoLeadingWildcardLike,
+	SchemaRuleStatementNoSelectAll,
+	SchemaRuleStatementRequireWhere,
+}
+
+// QueryReviewCheck checks a query collected from the engine against the SQL review rules applicable to queries.
+// It only returns the violations, and returns nothing if the query cannot be parsed, e.g. the query text is truncated.
+func QueryReviewCheck(dbType db.Type, statement string) ([]Advice, error) {
+	var result []Advice
+	for _, ruleType := range queryReviewRuleTypeList {
+		advisorType, err := getAdvisorTypeByRule(ruleType, dbType)
+		if err != nil {
+			return nil, err
+		}
+		adviceList, err := Check(
+			dbType,
+			advisorType,
+			Context{
+				Rule: &SQLReviewRule{
+					Type:  ruleType,
+					Level: SchemaRuleLevelWarning,
+				},
+			},
+			statement,
+		)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to check query with rule %q", ruleType)
+		}
+		for _, advice := range adviceList {
+			if advice.Code == StatementSyntaxError {
+				return nil, nil
+			}
+			if advice.Status == Success {
+				continue
+			}
+			result = append(result, advice)
+		}
+	}
+	return result, nil
+}
@@ -10,12 +10,14 @@ import (
 
 	clickhouse "github.com/ClickHouse/clickhouse-go/v2"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 
 	"github.com/bytebase/bytebase/common"
 	"github.com/bytebase/bytebase/common/log"
 	"github.com/bytebase/bytebase/plugin/db"
 	"github.com/bytebase/bytebase/plugin/db/util"
+	"github.com/bytebase/bytebase/plugin/tracing"
 )
 
 var (
@@ -116,6 +118,9 @@ func (driver *Driver) getVersion(ctx context.Context) (string, error) {
 
 // Execute executes a SQL statement.
 func (driver *Driver) Execute(ctx context.Context, statement string, _ bool) error {
+	ctx, span := tracing.Start(ctx, "Driver.Execute", attribute.String("db.system", string(driver.dbType)))
+	defer span.End()
+
 	tx, err := driver.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
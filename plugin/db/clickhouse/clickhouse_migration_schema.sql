@@ -40,6 +40,8 @@ CREATE TABLE bytebase.migration_history (
     schema_prev TEXT NOT NULL,
     execution_duration_ns BIGINT NOT NULL,
     issue_id TEXT NOT NULL,
-    payload TEXT NOT NULL
+    payload TEXT NOT NULL,
+    -- Record the checksum of the migration statement to detect tampering with the applied migration.
+    checksum TEXT NOT NULL DEFAULT ''
 ) ENGINE = MergeTree()
 PRIMARY KEY id;
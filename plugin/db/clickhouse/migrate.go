@@ -24,6 +24,18 @@ var (
 	_ util.MigrationExecutor = (*Driver)(nil)
 )
 
+const (
+	// migrationChecksumColumnQuery returns a row if the checksum column exists in the migration history table.
+	migrationChecksumColumnQuery = `
+		SELECT
+			1
+		FROM system.columns
+		WHERE database = 'bytebase' AND table = 'migration_history' AND name = 'checksum'
+	`
+	// addMigrationChecksumColumnStmt adds the checksum column to the migration history table set up by the earlier releases.
+	addMigrationChecksumColumnStmt = "ALTER TABLE bytebase.migration_history ADD COLUMN checksum TEXT NOT NULL DEFAULT ''"
+)
+
 // NeedsSetupMigration returns whether it needs to setup migration.
 func (driver *Driver) NeedsSetupMigration(ctx context.Context) (bool, error) {
 	const query = `
@@ -61,7 +73,8 @@ func (driver *Driver) SetupMigrationIfNeeded(ctx context.Context) error {
 		)
 	}
 
-	return nil
+	// Upgrade the migration schema set up by the earlier releases.
+	return util.UpgradeMigrationSchemaIfNeeded(ctx, driver.db, migrationChecksumColumnQuery, addMigrationChecksumColumnStmt)
 }
 
 // FindLargestVersionSinceBaseline will find the largest version since last baseline or branch.
@@ -72,7 +85,7 @@ func (driver Driver) FindLargestVersionSinceBaseline(ctx context.Context, tx *sq
 	}
 	const getLargestVersionSinceLastBaselineQuery = `
 		SELECT MAX(version) FROM bytebase.migration_history
-		WHERE namespace = $1 AND sequence >= $2
+		WHERE namespace = $1 AND sequence >= $2 AND type <> '` + string(db.Repeatable) + `'
 	`
 	var version sql.NullString
 	if err := tx.QueryRowContext(ctx, getLargestVersionSinceLastBaselineQuery,
@@ -135,9 +148,10 @@ func (Driver) InsertPendingHistory(ctx context.Context, tx *sql.Tx, sequence int
 		schema_prev,
 		execution_duration_ns,
 		issue_id,
-		payload
+		payload,
+		checksum
 	)
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
 	`
 	var insertedID int64
 	maxIDQuery := "SELECT MAX(id)+1 FROM bytebase.migration_history"
@@ -178,6 +192,7 @@ func (Driver) InsertPendingHistory(ctx context.Context, tx *sql.Tx, sequence int
 		0,
 		m.IssueID,
 		m.Payload,
+		db.Checksum(statement),
 	)
 	if err != nil {
 		return int64(0), util.FormatErrorWithQuery(err, insertHistoryQuery)
@@ -222,6 +237,10 @@ func (driver *Driver) ExecuteMigration(ctx context.Context, m *db.MigrationInfo,
 
 // FindMigrationHistoryList finds the migration history.
 func (driver *Driver) FindMigrationHistoryList(ctx context.Context, find *db.MigrationHistoryFind) ([]*db.MigrationHistory, error) {
+	checksumColumn, err := util.GetMigrationHistoryChecksumColumn(ctx, driver, db.BytebaseDatabase, migrationChecksumColumnQuery)
+	if err != nil {
+		return nil, err
+	}
 	baseQuery := `
 	SELECT
 		id,
@@ -242,7 +261,8 @@ func (driver *Driver) FindMigrationHistoryList(ctx context.Context, find *db.Mig
 		schema_prev,
 		execution_duration_ns,
 		issue_id,
-		payload
+		payload,
+		` + checksumColumn + `
 		FROM bytebase.migration_history `
 	paramNames, params := []string{}, []interface{}{}
 	if v := find.ID; v != nil {
@@ -262,6 +282,12 @@ func (driver *Driver) FindMigrationHistoryList(ctx context.Context, find *db.Mig
 	if v := find.Source; v != nil {
 		paramNames, params = append(paramNames, "source"), append(params, *v)
 	}
+	if v := find.Type; v != nil {
+		paramNames, params = append(paramNames, "type"), append(params, *v)
+	}
+	if v := find.ExcludeType; v != nil {
+		paramNames, params = append(paramNames, "type <> ?"), append(params, *v)
+	}
 	var query = baseQuery +
 		db.FormatParamNameInNumberedPosition(paramNames) +
 		`ORDER BY id DESC`
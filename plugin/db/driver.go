@@ -3,16 +3,24 @@ package db
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
 	"fmt"
 	"io"
+	"net"
 	"path"
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
 
+	"github.com/bytebase/bytebase/plugin/metric/prometheus"
+	"github.com/bytebase/bytebase/plugin/tracing"
 	"github.com/bytebase/bytebase/plugin/vcs"
 )
 
@@ -153,6 +161,8 @@ type Schema struct {
 var (
 	driversMu sync.RWMutex
 	drivers   = make(map[Type]driverFunc)
+	// retryableErrorFuncs is the mapping from the database type to the function telling whether an error is transient.
+	retryableErrorFuncs = make(map[Type]func(error) bool)
 )
 
 // DriverConfig is the driver configuration.
@@ -198,6 +208,10 @@ const (
 	// Data is the migration type for DATA.
 	// Used for DML change.
 	Data MigrationType = "DATA"
+	// Repeatable is the migration type for REPEATABLE.
+	// Used for views, functions and procedures that are re-applied whenever the file content changes.
+	// Repeatable migrations are not versioned, so they don't take part in the migration version ordering.
+	Repeatable MigrationType = "REPEATABLE"
 )
 
 // MigrationStatus is the status of migration.
@@ -215,6 +229,8 @@ const (
 // MigrationInfoPayload is the API message for migration info payload.
 type MigrationInfoPayload struct {
 	VCSPushEvent *vcs.PushEvent `json:"pushEvent,omitempty"`
+	// RepeatableName is the file path identifying the repeatable migration.
+	RepeatableName string `json:"repeatableName,omitempty"`
 }
 
 // MigrationInfo is the API message for migration info.
@@ -245,6 +261,9 @@ type MigrationInfo struct {
 	// This applies to BASELINE and MIGRATE types of migrations because most of these migrations are retry-able.
 	// We don't use force option for DATA type of migrations yet till there's customer needs.
 	Force bool
+	// ExecuteFunc executes the migration on the connection to the database instead of Driver.Execute if set,
+	// e.g. to execute a data update in chunks. The migration history is recorded the same way.
+	ExecuteFunc func(ctx context.Context, sqlDB *sql.DB) error
 }
 
 // placeholderRegexp is the regexp for placeholder.
@@ -256,6 +275,20 @@ const placeholderRegexp = `[^\\/?%*:|"<>]+`
 // Both filePath and filePathTemplate are the full file path (including the base directory) of the repository.
 // It returns (nil, nil) if it doesn't look like a migration file path.
 func ParseMigrationInfo(filePath, filePathTemplate string, allowOmitDatabaseName bool) (*MigrationInfo, error) {
+	return parseMigrationInfo(filePath, filePathTemplate, allowOmitDatabaseName, false /* repeatable */)
+}
+
+// ParseRepeatableMigrationInfo matches filePath against the dedicated repeatable migration filePathTemplate.
+// The {{VERSION}} and {{TYPE}} placeholders are not required, and the migration type is always REPEATABLE.
+// It returns (nil, nil) if it doesn't look like a repeatable migration file path.
+func ParseRepeatableMigrationInfo(filePath, filePathTemplate string, allowOmitDatabaseName bool) (*MigrationInfo, error) {
+	if filePathTemplate == "" {
+		return nil, nil
+	}
+	return parseMigrationInfo(filePath, filePathTemplate, allowOmitDatabaseName, true /* repeatable */)
+}
+
+func parseMigrationInfo(filePath, filePathTemplate string, allowOmitDatabaseName bool, repeatable bool) (*MigrationInfo, error) {
 	placeholderList := []string{
 		"ENV_NAME",
 		"VERSION",
@@ -286,6 +319,9 @@ func ParseMigrationInfo(filePath, filePathTemplate string, allowOmitDatabaseName
 		Source: VCS,
 		Type:   Migrate,
 	}
+	if repeatable {
+		mi.Type = Repeatable
+	}
 	matchList := myRegex.FindStringSubmatch(filePath)
 	for _, placeholder := range placeholderList {
 		index := myRegex.SubexpIndex(placeholder)
@@ -299,6 +335,9 @@ func ParseMigrationInfo(filePath, filePathTemplate string, allowOmitDatabaseName
 				mi.Namespace = matchList[index]
 				mi.Database = matchList[index]
 			case "TYPE":
+				if repeatable {
+					continue
+				}
 				switch matchList[index] {
 				case "data":
 					mi.Type = Data
@@ -308,8 +347,10 @@ func ParseMigrationInfo(filePath, filePathTemplate string, allowOmitDatabaseName
 					mi.Type = Migrate
 				case "ddl":
 					mi.Type = Migrate
+				case "repeatable":
+					mi.Type = Repeatable
 				default:
-					return nil, errors.Errorf("file path %q contains invalid migration type %q, must be 'migrate'('ddl') or 'data'('dml')", filePath, matchList[index])
+					return nil, errors.Errorf("file path %q contains invalid migration type %q, must be 'migrate'('ddl'), 'data'('dml') or 'repeatable'", filePath, matchList[index])
 				}
 			case "DESCRIPTION":
 				mi.Description = matchList[index]
@@ -317,7 +358,8 @@ func ParseMigrationInfo(filePath, filePathTemplate string, allowOmitDatabaseName
 		}
 	}
 
-	if mi.Version == "" {
+	// The version of a repeatable migration is decided when it's applied, so it's not required in the file path.
+	if mi.Version == "" && mi.Type != Repeatable {
 		return nil, errors.Errorf("file path %q does not contain {{VERSION}}, configured file path template %q", filePath, filePathTemplate)
 	}
 	if mi.Namespace == "" && !allowOmitDatabaseName {
@@ -330,6 +372,8 @@ func ParseMigrationInfo(filePath, filePathTemplate string, allowOmitDatabaseName
 			mi.Description = fmt.Sprintf("Create %s baseline", mi.Database)
 		case Data:
 			mi.Description = fmt.Sprintf("Create %s data change", mi.Database)
+		case Repeatable:
+			mi.Description = fmt.Sprintf("Apply %s repeatable migration", mi.Database)
 		default:
 			mi.Description = fmt.Sprintf("Create %s schema migration", mi.Database)
 		}
@@ -343,6 +387,13 @@ func ParseMigrationInfo(filePath, filePathTemplate string, allowOmitDatabaseName
 	return mi, nil
 }
 
+// Checksum returns the hex encoded SHA256 checksum of the migration statement.
+// The leading and trailing white spaces are ignored.
+func Checksum(statement string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(statement)))
+	return hex.EncodeToString(sum[:])
+}
+
 // ParseSchemaFileInfo attempts to parse the given schema file path to extract
 // the schema file info.
 // It returns (nil, nil) if it doesn't look like a schema file path.
@@ -409,6 +460,7 @@ type MigrationHistory struct {
 	ExecutionDurationNs   int64
 	IssueID               string
 	Payload               string
+	Checksum              string
 	UseSemanticVersion    bool
 	SemanticVersionSuffix string
 }
@@ -420,10 +472,35 @@ type MigrationHistoryFind struct {
 	Database *string
 	Source   *MigrationSource
 	Version  *string
+	Type     *MigrationType
+	// ExcludeType filters out the migration histories of the type, e.g. the repeatable
+	// migrations which don't take part in the schema version.
+	ExcludeType *MigrationType
 	// If specified, then it will only fetch "Limit" most recent migration histories
 	Limit *int
 }
 
+// QueryStatistics is the statistics of a normalized query collected from the engine,
+// e.g. performance_schema digests on MySQL and pg_stat_statements on Postgres.
+// The statistics are cumulative since the engine last reset them.
+type QueryStatistics struct {
+	// Database is the database the query runs against.
+	Database string
+	// Fingerprint identifies the normalized query, e.g. the digest on MySQL and the queryid on Postgres.
+	Fingerprint string
+	// NormalizedQuery is the query text with literals replaced by placeholders.
+	NormalizedQuery string
+	// SampleQuery is a sample of the actual query text, empty if the engine doesn't provide one.
+	SampleQuery string
+
+	Calls          int64
+	TotalLatencyNs int64
+	MaxLatencyNs   int64
+	// RowsExamined is 0 if the engine doesn't provide it.
+	RowsExamined int64
+	RowsReturned int64
+}
+
 // ConnectionConfig is the configuration for connections.
 type ConnectionConfig struct {
 	Host      string
@@ -504,6 +581,64 @@ func Register(dbType Type, f driverFunc) {
 	drivers[dbType] = f
 }
 
+// RegisterRetryableErrorFunc makes the function telling whether an error is transient available by the provided type.
+// If RegisterRetryableErrorFunc is called twice with the same name or if f is nil, it panics.
+func RegisterRetryableErrorFunc(dbType Type, f func(error) bool) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	if f == nil {
+		panic("db: RegisterRetryableErrorFunc is nil")
+	}
+	if _, dup := retryableErrorFuncs[dbType]; dup {
+		panic("db: RegisterRetryableErrorFunc called twice for driver " + dbType)
+	}
+	retryableErrorFuncs[dbType] = f
+}
+
+// IsRetryableError returns whether the error is transient so that the operation can be retried, e.g. a dropped
+// connection, a lock wait timeout or a deadlock.
+func IsRetryableError(dbType Type, err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	driversMu.RLock()
+	f, ok := retryableErrorFuncs[dbType]
+	driversMu.RUnlock()
+	return ok && f(err)
+}
+
+// ExecuteProgress is the progress of executing a multi-statement SQL reported by the driver.
+type ExecuteProgress struct {
+	// TotalStatement is the number of statements split by the parser.
+	TotalStatement int
+	// ExecutedStatement is the number of statements executed.
+	ExecutedStatement int
+	// CurrentStatement is the statement being executed, empty after all statements are executed.
+	CurrentStatement string
+}
+
+type executeProgressReporterKey struct{}
+
+// WithExecuteProgressReporter returns a copy of ctx carrying the progress reporter.
+// The drivers supporting the progress report call the reporter in Execute before executing each statement, and after
+// executing all statements.
+func WithExecuteProgressReporter(ctx context.Context, reporter func(ExecuteProgress)) context.Context {
+	return context.WithValue(ctx, executeProgressReporterKey{}, reporter)
+}
+
+// GetExecuteProgressReporter returns the progress reporter carried by ctx, or nil if there is none.
+func GetExecuteProgressReporter(ctx context.Context) func(ExecuteProgress) {
+	reporter, _ := ctx.Value(executeProgressReporterKey{}).(func(ExecuteProgress))
+	return reporter
+}
+
 // Open opens a database specified by its database driver type and connection config without verifying the connection.
 func Open(ctx context.Context, dbType Type, driverConfig DriverConfig, connectionConfig ConnectionConfig, connCtx ConnectionContext) (Driver, error) {
 	driversMu.RLock()
@@ -513,7 +648,14 @@ func Open(ctx context.Context, dbType Type, driverConfig DriverConfig, connectio
 		return nil, errors.Errorf("db: unknown driver %v", dbType)
 	}
 
+	ctx, span := tracing.Start(ctx, "db.Open",
+		attribute.String("db.system", string(dbType)),
+		attribute.String("db.instance", connCtx.InstanceName),
+	)
+	start := time.Now()
 	driver, err := f(driverConfig).Open(ctx, dbType, connectionConfig, connCtx)
+	prometheus.ObserveSince(prometheus.DriverOpenDuration, start, string(dbType), string(prometheus.OutcomeFromError(err)))
+	tracing.End(span, err)
 	if err != nil {
 		return nil, err
 	}
@@ -543,7 +685,11 @@ func FormatParamNameInNumberedPosition(paramNames []string) string {
 	var parts []string
 	for i, param := range paramNames {
 		idx := fmt.Sprintf("$%d", i+1)
-		param = param + "=" + idx
+		if strings.Contains(param, "?") {
+			param = strings.Replace(param, "?", idx, 1)
+		} else {
+			param = param + "=" + idx
+		}
 		parts = append(parts, param)
 	}
 	return fmt.Sprintf("WHERE %s ", strings.Join(parts, " AND "))
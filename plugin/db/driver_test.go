@@ -229,6 +229,37 @@ func TestParseMigrationInfo(t *testing.T) {
 			},
 			wantErr: "",
 		},
+		{
+			filePath:         "db1##001foo##repeatable##create_view",
+			filePathTemplate: "{{DB_NAME}}##{{VERSION}}##{{TYPE}}##{{DESCRIPTION}}",
+			want: &MigrationInfo{
+				Version:     "001foo",
+				Namespace:   "db1",
+				Database:    "db1",
+				Source:      VCS,
+				Type:        Repeatable,
+				Description: "Create view",
+			},
+			wantErr: "",
+		},
+		{
+			filePath:         "db1##repeatable",
+			filePathTemplate: "{{DB_NAME}}##{{TYPE}}",
+			want: &MigrationInfo{
+				Namespace:   "db1",
+				Database:    "db1",
+				Source:      VCS,
+				Type:        Repeatable,
+				Description: "Apply db1 repeatable migration",
+			},
+			wantErr: "",
+		},
+		{
+			filePath:         "db1##ddl",
+			filePathTemplate: "{{DB_NAME}}##{{TYPE}}",
+			want:             nil,
+			wantErr:          "does not contain {{VERSION}}",
+		},
 	}
 	for _, tc := range tests {
 		t.Run(tc.filePath, func(t *testing.T) {
@@ -249,6 +280,49 @@ func TestParseMigrationInfo(t *testing.T) {
 	}
 }
 
+func TestParseRepeatableMigrationInfo(t *testing.T) {
+	tests := []struct {
+		filePath         string
+		filePathTemplate string
+		want             *MigrationInfo
+	}{
+		{
+			filePath:         "bytebase/repeatable/db1##create_view.sql",
+			filePathTemplate: "bytebase/repeatable/{{DB_NAME}}##{{DESCRIPTION}}.sql",
+			want: &MigrationInfo{
+				Namespace:   "db1",
+				Database:    "db1",
+				Source:      VCS,
+				Type:        Repeatable,
+				Description: "Create view",
+			},
+		},
+		{
+			filePath:         "bytebase/db1##001##create_view.sql",
+			filePathTemplate: "bytebase/repeatable/{{DB_NAME}}##{{DESCRIPTION}}.sql",
+			want:             nil,
+		},
+		{
+			filePath:         "bytebase/repeatable/db1##create_view.sql",
+			filePathTemplate: "",
+			want:             nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.filePath, func(t *testing.T) {
+			mi, err := ParseRepeatableMigrationInfo(test.filePath, test.filePathTemplate, false)
+			require.NoError(t, err)
+			require.Equal(t, test.want, mi)
+		})
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	require.Equal(t, Checksum("CREATE VIEW v AS SELECT 1;"), Checksum("\nCREATE VIEW v AS SELECT 1;\n"))
+	require.NotEqual(t, Checksum("CREATE VIEW v AS SELECT 1;"), Checksum("CREATE VIEW v AS SELECT 2;"))
+}
+
 func TestParseSchemaFileInfo(t *testing.T) {
 	tests := []struct {
 		name               string
@@ -316,3 +390,8 @@ func TestParseSchemaFileInfo(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatParamNameInNumberedPosition(t *testing.T) {
+	assert.Equal(t, "", FormatParamNameInNumberedPosition(nil))
+	assert.Equal(t, "WHERE namespace=$1 AND type <> $2 ", FormatParamNameInNumberedPosition([]string{"namespace", "type <> ?"}))
+}
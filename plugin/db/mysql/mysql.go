@@ -2,7 +2,6 @@
 package mysql
 
 import (
-	"bytes"
 	"context"
 	"database/sql"
 	"fmt"
@@ -11,24 +10,58 @@ import (
 
 	"github.com/go-sql-driver/mysql"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/multierr"
 
 	"github.com/bytebase/bytebase/common"
 	"github.com/bytebase/bytebase/plugin/db"
 	"github.com/bytebase/bytebase/plugin/db/util"
 	bbparser "github.com/bytebase/bytebase/plugin/parser"
+	"github.com/bytebase/bytebase/plugin/tracing"
 )
 
 var (
 	baseTableType = "BASE TABLE"
 	viewTableType = "VIEW"
 
+	// retryableErrorNumbers is the set of the transient MySQL and TiDB error numbers.
+	retryableErrorNumbers = map[uint16]bool{
+		// ER_CON_COUNT_ERROR: Too many connections.
+		1040: true,
+		// ER_SERVER_SHUTDOWN: Server shutdown in progress.
+		1053: true,
+		// ER_LOCK_WAIT_TIMEOUT: Lock wait timeout exceeded.
+		1205: true,
+		// ER_LOCK_DEADLOCK: Deadlock found when trying to get lock.
+		1213: true,
+		// TiDB ErrTxnRetryable: the transaction can be retried.
+		8022: true,
+		// TiDB ErrInfoSchemaChanged: the information schema is changed during the execution.
+		8028: true,
+		// TiDB ErrWriteConflict: write conflict.
+		9007: true,
+	}
+
 	_ db.Driver = (*Driver)(nil)
 )
 
 func init() {
 	db.Register(db.MySQL, newDriver)
 	db.Register(db.TiDB, newDriver)
+	db.RegisterRetryableErrorFunc(db.MySQL, isRetryableError)
+	db.RegisterRetryableErrorFunc(db.TiDB, isRetryableError)
+}
+
+// isRetryableError returns whether the error is a transient error such as a lock wait timeout or a dropped connection.
+func isRetryableError(err error) bool {
+	if errors.Is(err, mysql.ErrInvalidConn) {
+		return true
+	}
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return retryableErrorNumbers[mysqlErr.Number]
+	}
+	return false
 }
 
 // Driver is the MySQL driver.
@@ -164,26 +197,40 @@ func (driver *Driver) getVersion(ctx context.Context) (string, error) {
 
 // Execute executes a SQL statement.
 func (driver *Driver) Execute(ctx context.Context, statement string, _ bool) error {
-	var buf bytes.Buffer
-	if err := transformDelimiter(&buf, statement); err != nil {
+	ctx, span := tracing.Start(ctx, "Driver.Execute", attribute.String("db.system", string(driver.dbType)))
+	defer span.End()
+
+	statements, err := splitAndTransformDelimiter(statement)
+	if err != nil {
 		return err
 	}
-	transformedStatement := buf.String()
 	tx, err := driver.migrationConn.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	_, err = tx.ExecContext(ctx, transformedStatement)
-
-	if err == nil {
-		if err := tx.Commit(); err != nil {
-			return err
+	if reporter := db.GetExecuteProgressReporter(ctx); reporter != nil {
+		// Execute the statements one by one to report which statement is being executed.
+		for i, stmt := range statements {
+			reporter(db.ExecuteProgress{
+				TotalStatement:    len(statements),
+				ExecutedStatement: i,
+				CurrentStatement:  stmt,
+			})
+			if _, err := tx.ExecContext(ctx, stmt); err != nil {
+				return err
+			}
 		}
+		reporter(db.ExecuteProgress{
+			TotalStatement:    len(statements),
+			ExecutedStatement: len(statements),
+		})
+	} else if _, err := tx.ExecContext(ctx, strings.Join(statements, "")); err != nil {
+		return err
 	}
 
-	return err
+	return tx.Commit()
 }
 
 // GetMigrationConnID gets the ID of the connection executing migrations.
@@ -202,17 +249,37 @@ func (driver *Driver) Query(ctx context.Context, statement string, limit int, re
 
 // transformDelimiter transform the delimiter to the MySQL default delimiter.
 func transformDelimiter(out io.Writer, statement string) error {
-	statements, err := bbparser.SplitMultiSQL(bbparser.MySQL, statement)
+	statements, err := splitAndTransformDelimiter(statement)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range statements {
+		if _, err = out.Write([]byte(stmt)); err != nil {
+			return errors.Wrapf(err, "failed to write SQL statement")
+		}
+	}
+	return nil
+}
+
+// splitAndTransformDelimiter splits the statement and transforms the delimiter of each statement to the MySQL default delimiter.
+// The DELIMITER statements and the empty statements, e.g. a trailing comment, are dropped.
+func splitAndTransformDelimiter(statement string) ([]string, error) {
+	list, err := bbparser.SplitMultiSQL(bbparser.MySQL, statement)
 	if err != nil {
-		return errors.Wrapf(err, "failed to split SQL statements")
+		return nil, errors.Wrapf(err, "failed to split SQL statements")
 	}
+	var statements []string
 	delimiter := `;`
-	for _, singleSQL := range statements {
+	for _, singleSQL := range list {
 		stmt := singleSQL.Text
+		// MySQL rejects executing a statement with only comments with "Query was empty" (Error 1065).
+		if isEmptyStatement(stmt) {
+			continue
+		}
 		if bbparser.IsDelimiter(stmt) {
 			delimiter, err = bbparser.ExtractDelimiter(stmt)
 			if err != nil {
-				return errors.Wrapf(err, "failed to extract delimiter")
+				return nil, errors.Wrapf(err, "failed to extract delimiter")
 			}
 			continue
 		}
@@ -220,9 +287,34 @@ func transformDelimiter(out io.Writer, statement string) error {
 			// Trim delimiter
 			stmt = fmt.Sprintf("%s;", stmt[:len(stmt)-len(delimiter)])
 		}
-		if _, err = out.Write([]byte(stmt)); err != nil {
-			return errors.Wrapf(err, "failed to write SQL statement")
+		statements = append(statements, stmt)
+	}
+	return statements, nil
+}
+
+// isEmptyStatement returns whether the statement consists of only whitespaces, semicolons and comments.
+// The executable comments, e.g. /*!40101 SET NAMES utf8 */, are not empty.
+func isEmptyStatement(stmt string) bool {
+	for {
+		stmt = strings.TrimLeft(stmt, " \t\r\n;")
+		switch {
+		case stmt == "":
+			return true
+		case strings.HasPrefix(stmt, "#"), strings.HasPrefix(stmt, "-- "), strings.HasPrefix(stmt, "--\t"), strings.HasPrefix(stmt, "--\r"), strings.HasPrefix(stmt, "--\n"), stmt == "--":
+			i := strings.Index(stmt, "\n")
+			if i < 0 {
+				return true
+			}
+			stmt = stmt[i+1:]
+		case strings.HasPrefix(stmt, "/*") && !strings.HasPrefix(stmt, "/*!"):
+			i := strings.Index(stmt[2:], "*/")
+			if i < 0 {
+				// Leave the unterminated comment to MySQL to report the error.
+				return false
+			}
+			stmt = stmt[i+4:]
+		default:
+			return false
 		}
 	}
-	return nil
 }
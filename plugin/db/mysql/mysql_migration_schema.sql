@@ -40,7 +40,9 @@ CREATE TABLE bytebase.migration_history (
     schema_prev MEDIUMTEXT NOT NULL,
     execution_duration_ns BIGINT NOT NULL,
     issue_id TEXT NOT NULL,
-    payload TEXT NOT NULL
+    payload TEXT NOT NULL,
+    -- Record the checksum of the migration statement to detect tampering with the applied migration.
+    checksum VARCHAR(64) NOT NULL DEFAULT ''
 );
 
 CREATE UNIQUE INDEX bytebase_idx_unique_migration_history_namespace_sequence ON bytebase.migration_history (namespace(256), sequence);
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"testing"
 
+	"github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 )
 
@@ -76,6 +78,13 @@ func TestTransformDelimiter(t *testing.T) {
 				DELETE FROM film_text WHERE film_id = old.film_id;
 			  END ;`,
 		},
+		{
+			statement: `
+			CREATE TABLE t1(id INT PRIMARY KEY);
+			-- The trailing comment is dropped.
+			`,
+			want: "CREATE TABLE t1(id INT PRIMARY KEY);",
+		},
 	}
 	a := require.New(t)
 	for _, test := range tests {
@@ -85,3 +94,76 @@ func TestTransformDelimiter(t *testing.T) {
 		a.Equal(test.want, buf.String())
 	}
 }
+
+func TestIsEmptyStatement(t *testing.T) {
+	tests := []struct {
+		statement string
+		want      bool
+	}{
+		{
+			statement: "",
+			want:      true,
+		},
+		{
+			statement: "\n\t;\n",
+			want:      true,
+		},
+		{
+			statement: "-- comment\n# comment\n/* comment */",
+			want:      true,
+		},
+		{
+			statement: "-- comment\nSELECT 1;",
+			want:      false,
+		},
+		{
+			// "--" without a following whitespace isn't a comment in MySQL.
+			statement: "--1;",
+			want:      false,
+		},
+		{
+			statement: "/*!40101 SET NAMES utf8 */;",
+			want:      false,
+		},
+		{
+			statement: "/* unterminated comment",
+			want:      false,
+		},
+	}
+	a := require.New(t)
+	for _, test := range tests {
+		a.Equal(test.want, isEmptyStatement(test.statement), test.statement)
+	}
+}
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{
+			err:  &mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded; try restarting transaction"},
+			want: true,
+		},
+		{
+			err:  errors.Wrapf(&mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}, "failed to execute"),
+			want: true,
+		},
+		{
+			err:  mysql.ErrInvalidConn,
+			want: true,
+		},
+		{
+			err:  &mysql.MySQLError{Number: 1064, Message: "You have an error in your SQL syntax"},
+			want: false,
+		},
+		{
+			err:  errors.New("table already exists"),
+			want: false,
+		},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.want, isRetryableError(test.err))
+	}
+}
@@ -0,0 +1,93 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/blang/semver/v4"
+
+	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/plugin/db/util"
+)
+
+// QUERY_SAMPLE_TEXT is only available since MySQL 8.0.3.
+var querySampleTextMinVersion = semver.MustParse("8.0.3")
+
+// GetQueryStatistics returns the statistics of the top limit queries ordered by total latency from
+// performance_schema.events_statements_summary_by_digest.
+func (driver *Driver) GetQueryStatistics(ctx context.Context, limit int) ([]*db.QueryStatistics, error) {
+	excludedDatabaseList := []string{
+		// Skip our internal "bytebase" database
+		fmt.Sprintf("'%s'", db.BytebaseDatabase),
+	}
+	// Skip all system databases
+	for k := range systemDatabases {
+		excludedDatabaseList = append(excludedDatabaseList, fmt.Sprintf("'%s'", k))
+	}
+
+	sampleColumn := "''"
+	if driver.dbType == db.MySQL {
+		version, err := driver.getVersion(ctx)
+		if err != nil {
+			return nil, err
+		}
+		v, err := semver.ParseTolerant(version)
+		if err != nil {
+			return nil, err
+		}
+		if v.GE(querySampleTextMinVersion) {
+			sampleColumn = "IFNULL(QUERY_SAMPLE_TEXT, '')"
+		}
+	}
+
+	// The timers are in picoseconds.
+	query := fmt.Sprintf(`
+		SELECT
+			SCHEMA_NAME,
+			DIGEST,
+			DIGEST_TEXT,
+			%s,
+			COUNT_STAR,
+			SUM_TIMER_WAIT DIV 1000,
+			MAX_TIMER_WAIT DIV 1000,
+			SUM_ROWS_EXAMINED,
+			SUM_ROWS_SENT
+		FROM performance_schema.events_statements_summary_by_digest
+		WHERE SCHEMA_NAME IS NOT NULL AND DIGEST IS NOT NULL AND DIGEST_TEXT IS NOT NULL
+			AND LOWER(SCHEMA_NAME) NOT IN (%s)
+		ORDER BY SUM_TIMER_WAIT DESC
+		LIMIT %d`,
+		sampleColumn,
+		strings.Join(excludedDatabaseList, ", "),
+		limit,
+	)
+	rows, err := driver.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, util.FormatErrorWithQuery(err, query)
+	}
+	defer rows.Close()
+
+	var statisticsList []*db.QueryStatistics
+	for rows.Next() {
+		var statistics db.QueryStatistics
+		if err := rows.Scan(
+			&statistics.Database,
+			&statistics.Fingerprint,
+			&statistics.NormalizedQuery,
+			&statistics.SampleQuery,
+			&statistics.Calls,
+			&statistics.TotalLatencyNs,
+			&statistics.MaxLatencyNs,
+			&statistics.RowsExamined,
+			&statistics.RowsReturned,
+		); err != nil {
+			return nil, err
+		}
+		statisticsList = append(statisticsList, &statistics)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, util.FormatErrorWithQuery(err, query)
+	}
+	return statisticsList, nil
+}
@@ -23,6 +23,18 @@ var (
 	_ util.MigrationExecutor = (*Driver)(nil)
 )
 
+const (
+	// migrationChecksumColumnQuery returns a row if the checksum column exists in the migration history table.
+	migrationChecksumColumnQuery = `
+		SELECT
+		    1
+		FROM information_schema.columns
+		WHERE table_name = 'migration_history' AND column_name = 'checksum'
+	`
+	// addMigrationChecksumColumnStmt adds the checksum column to the migration history table set up by the earlier releases.
+	addMigrationChecksumColumnStmt = "ALTER TABLE migration_history ADD COLUMN checksum TEXT NOT NULL DEFAULT ''"
+)
+
 // NeedsSetupMigration returns whether it needs to setup migration.
 func (driver *Driver) NeedsSetupMigration(ctx context.Context) (bool, error) {
 	// Don't use `bytebase` when user gives database instead of instance.
@@ -111,7 +123,8 @@ func (driver *Driver) SetupMigrationIfNeeded(ctx context.Context) error {
 		)
 	}
 
-	return nil
+	// Upgrade the migration schema set up by the earlier releases.
+	return util.UpgradeMigrationSchemaIfNeeded(ctx, driver.db, migrationChecksumColumnQuery, addMigrationChecksumColumnStmt)
 }
 
 // FindLargestVersionSinceBaseline will find the largest version since last baseline or branch.
@@ -122,7 +135,7 @@ func (driver Driver) FindLargestVersionSinceBaseline(ctx context.Context, tx *sq
 	}
 	const getLargestVersionSinceLastBaselineQuery = `
 		SELECT MAX(version) FROM migration_history
-		WHERE namespace = $1 AND sequence >= $2
+		WHERE namespace = $1 AND sequence >= $2 AND type <> '` + string(db.Repeatable) + `'
 	`
 	var version sql.NullString
 	if err := tx.QueryRowContext(ctx, getLargestVersionSinceLastBaselineQuery,
@@ -184,9 +197,10 @@ func (Driver) InsertPendingHistory(ctx context.Context, tx *sql.Tx, sequence int
 		schema_prev,
 		execution_duration_ns,
 		issue_id,
-		payload
+		payload,
+		checksum
 	)
-	VALUES ($1, EXTRACT(epoch from NOW()), $2, EXTRACT(epoch from NOW()), $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, 0, $14, $15)
+	VALUES ($1, EXTRACT(epoch from NOW()), $2, EXTRACT(epoch from NOW()), $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, 0, $14, $15, $16)
 	RETURNING id
 	`
 	var insertedID int64
@@ -206,6 +220,7 @@ func (Driver) InsertPendingHistory(ctx context.Context, tx *sql.Tx, sequence int
 		prevSchema,
 		m.IssueID,
 		m.Payload,
+		db.Checksum(statement),
 	).Scan(&insertedID); err != nil {
 		return 0, err
 	}
@@ -251,6 +266,14 @@ func (driver *Driver) ExecuteMigration(ctx context.Context, m *db.MigrationInfo,
 
 // FindMigrationHistoryList finds the migration history.
 func (driver *Driver) FindMigrationHistoryList(ctx context.Context, find *db.MigrationHistoryFind) ([]*db.MigrationHistory, error) {
+	database := db.BytebaseDatabase
+	if driver.strictUseDb() {
+		database = driver.strictDatabase
+	}
+	checksumColumn, err := util.GetMigrationHistoryChecksumColumn(ctx, driver, database, migrationChecksumColumnQuery)
+	if err != nil {
+		return nil, err
+	}
 	baseQuery := `
 	SELECT
 		id,
@@ -271,7 +294,8 @@ func (driver *Driver) FindMigrationHistoryList(ctx context.Context, find *db.Mig
 		schema_prev,
 		execution_duration_ns,
 		issue_id,
-		payload
+		payload,
+		` + checksumColumn + `
 		FROM migration_history `
 	paramNames, params := []string{}, []interface{}{}
 	if v := find.ID; v != nil {
@@ -291,17 +315,18 @@ func (driver *Driver) FindMigrationHistoryList(ctx context.Context, find *db.Mig
 	if v := find.Source; v != nil {
 		paramNames, params = append(paramNames, "source"), append(params, *v)
 	}
+	if v := find.Type; v != nil {
+		paramNames, params = append(paramNames, "type"), append(params, *v)
+	}
+	if v := find.ExcludeType; v != nil {
+		paramNames, params = append(paramNames, "type <> ?"), append(params, *v)
+	}
 	var query = baseQuery +
 		db.FormatParamNameInNumberedPosition(paramNames) +
 		`ORDER BY id DESC`
 	if v := find.Limit; v != nil {
 		query += fmt.Sprintf(" LIMIT %d", *v)
 	}
-
-	database := db.BytebaseDatabase
-	if driver.strictUseDb() {
-		database = driver.strictDatabase
-	}
 	return util.FindMigrationHistoryList(ctx, query, params, driver, database)
 }
 
@@ -7,15 +7,18 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/jackc/pgconn"
 	// Import pg driver.
 	// init() in pgx/v4/stdlib will register it's pgx driver.
 	_ "github.com/jackc/pgx/v4/stdlib"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/bytebase/bytebase/common"
 	"github.com/bytebase/bytebase/plugin/db"
 	"github.com/bytebase/bytebase/plugin/db/util"
 	"github.com/bytebase/bytebase/plugin/parser"
+	"github.com/bytebase/bytebase/plugin/tracing"
 )
 
 var (
@@ -39,11 +42,38 @@ var (
 	// driverName is the driver name that our driver dependence register, now is "pgx".
 	driverName = "pgx"
 
+	// retryableErrorCodes is the set of the transient Postgres SQLSTATE codes.
+	retryableErrorCodes = map[string]bool{
+		// serialization_failure
+		"40001": true,
+		// deadlock_detected
+		"40P01": true,
+		// lock_not_available
+		"55P03": true,
+		// too_many_connections
+		"53300": true,
+		// admin_shutdown
+		"57P01": true,
+		// cannot_connect_now
+		"57P03": true,
+	}
+
 	_ db.Driver = (*Driver)(nil)
 )
 
 func init() {
 	db.Register(db.Postgres, newDriver)
+	db.RegisterRetryableErrorFunc(db.Postgres, isRetryableError)
+}
+
+// isRetryableError returns whether the error is a transient error such as a serialization failure or a dropped connection.
+func isRetryableError(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// Class 08 is the connection exception.
+		return retryableErrorCodes[pgErr.Code] || strings.HasPrefix(pgErr.Code, "08")
+	}
+	return pgconn.SafeToRetry(err)
 }
 
 // Driver is the Postgres driver.
@@ -220,6 +250,9 @@ func (driver *Driver) getVersion(ctx context.Context) (string, error) {
 
 // Execute executes a SQL statement.
 func (driver *Driver) Execute(ctx context.Context, statement string, createDatabase bool) error {
+	ctx, span := tracing.Start(ctx, "Driver.Execute", attribute.String("db.system", string(db.Postgres)))
+	defer span.End()
+
 	owner, err := driver.GetCurrentDatabaseOwner()
 	if err != nil {
 		return err
@@ -314,7 +347,23 @@ func (driver *Driver) Execute(ctx context.Context, statement string, createDatab
 		return err
 	}
 
-	if _, err := tx.ExecContext(ctx, strings.Join(remainingStmts, "\n")); err != nil {
+	if reporter := db.GetExecuteProgressReporter(ctx); reporter != nil {
+		// Execute the statements one by one to report which statement is being executed.
+		for i, stmt := range remainingStmts {
+			reporter(db.ExecuteProgress{
+				TotalStatement:    len(remainingStmts),
+				ExecutedStatement: i,
+				CurrentStatement:  stmt,
+			})
+			if _, err := tx.ExecContext(ctx, stmt); err != nil {
+				return err
+			}
+		}
+		reporter(db.ExecuteProgress{
+			TotalStatement:    len(remainingStmts),
+			ExecutedStatement: len(remainingStmts),
+		})
+	} else if _, err := tx.ExecContext(ctx, strings.Join(remainingStmts, "\n")); err != nil {
 		return err
 	}
 
@@ -3,6 +3,8 @@ package pg
 import (
 	"testing"
 
+	"github.com/jackc/pgconn"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 )
 
@@ -49,3 +51,35 @@ func TestGetDatabaseInCreateDatabaseStatement(t *testing.T) {
 		require.Equal(t, test.want, got)
 	}
 }
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{
+			err:  &pgconn.PgError{Code: "40001", Message: "could not serialize access due to concurrent update"},
+			want: true,
+		},
+		{
+			err:  errors.Wrapf(&pgconn.PgError{Code: "55P03", Message: "canceling statement due to lock timeout"}, "failed to execute"),
+			want: true,
+		},
+		{
+			err:  &pgconn.PgError{Code: "08006", Message: "connection failure"},
+			want: true,
+		},
+		{
+			err:  &pgconn.PgError{Code: "42601", Message: "syntax error"},
+			want: false,
+		},
+		{
+			err:  errors.New("relation already exists"),
+			want: false,
+		},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.want, isRetryableError(test.err))
+	}
+}
@@ -0,0 +1,90 @@
+package pg
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/plugin/db/util"
+)
+
+// pg_stat_statements renames total_time and max_time to total_exec_time and max_exec_time since Postgres 13.
+const pgStatStatementsExecTimeMinVersionNum = 130000
+
+// GetQueryStatistics returns the statistics of the top limit queries ordered by total latency from pg_stat_statements.
+// It requires the pg_stat_statements extension to be created in the connected database.
+func (driver *Driver) GetQueryStatistics(ctx context.Context, limit int) ([]*db.QueryStatistics, error) {
+	var exists bool
+	if err := driver.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_stat_statements')").Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.Errorf("pg_stat_statements extension is not installed in database %q", driver.databaseName)
+	}
+
+	var versionNum int
+	if err := driver.db.QueryRowContext(ctx, "SELECT current_setting('server_version_num')::int").Scan(&versionNum); err != nil {
+		return nil, err
+	}
+	totalTimeColumn, maxTimeColumn := "total_time", "max_time"
+	if versionNum >= pgStatStatementsExecTimeMinVersionNum {
+		totalTimeColumn, maxTimeColumn = "total_exec_time", "max_exec_time"
+	}
+
+	var excludedList []string
+	for name := range excludedDatabaseList {
+		excludedList = append(excludedList, fmt.Sprintf("'%s'", name))
+	}
+	// pg_stat_statements has a row per user and top-level flag of the same query, so the rows are aggregated by the
+	// database and the query ID. The times are in milliseconds.
+	query := fmt.Sprintf(`
+		SELECT
+			pg_database.datname,
+			pg_stat_statements.queryid::text,
+			MIN(pg_stat_statements.query),
+			SUM(pg_stat_statements.calls)::bigint,
+			(SUM(pg_stat_statements.%s) * 1000000)::bigint,
+			(MAX(pg_stat_statements.%s) * 1000000)::bigint,
+			SUM(pg_stat_statements.rows)::bigint
+		FROM pg_stat_statements
+		JOIN pg_database ON pg_database.oid = pg_stat_statements.dbid
+		WHERE pg_stat_statements.queryid IS NOT NULL AND pg_database.datname NOT IN (%s)
+		GROUP BY pg_database.datname, pg_stat_statements.queryid
+		ORDER BY SUM(pg_stat_statements.%s) DESC
+		LIMIT %d`,
+		totalTimeColumn,
+		maxTimeColumn,
+		strings.Join(excludedList, ", "),
+		totalTimeColumn,
+		limit,
+	)
+	rows, err := driver.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, util.FormatErrorWithQuery(err, query)
+	}
+	defer rows.Close()
+
+	var statisticsList []*db.QueryStatistics
+	for rows.Next() {
+		var statistics db.QueryStatistics
+		if err := rows.Scan(
+			&statistics.Database,
+			&statistics.Fingerprint,
+			&statistics.NormalizedQuery,
+			&statistics.Calls,
+			&statistics.TotalLatencyNs,
+			&statistics.MaxLatencyNs,
+			&statistics.RowsReturned,
+		); err != nil {
+			return nil, err
+		}
+		statisticsList = append(statisticsList, &statistics)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, util.FormatErrorWithQuery(err, query)
+	}
+	return statisticsList, nil
+}
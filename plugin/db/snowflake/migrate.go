@@ -23,6 +23,18 @@ var (
 	_ util.MigrationExecutor = (*Driver)(nil)
 )
 
+const (
+	// migrationChecksumColumnQuery returns a row if the checksum column exists in the migration history table.
+	migrationChecksumColumnQuery = `
+		SELECT
+		    1
+		FROM BYTEBASE.INFORMATION_SCHEMA.COLUMNS
+		WHERE TABLE_SCHEMA='PUBLIC' AND TABLE_NAME = 'MIGRATION_HISTORY' AND COLUMN_NAME = 'CHECKSUM'
+	`
+	// addMigrationChecksumColumnStmt adds the checksum column to the migration history table set up by the earlier releases.
+	addMigrationChecksumColumnStmt = "ALTER TABLE bytebase.public.migration_history ADD COLUMN checksum TEXT NOT NULL DEFAULT ''"
+)
+
 // NeedsSetupMigration returns whether it needs to setup migration.
 func (driver *Driver) NeedsSetupMigration(ctx context.Context) (bool, error) {
 	exist, err := driver.hasBytebaseDatabase(ctx)
@@ -69,7 +81,8 @@ func (driver *Driver) SetupMigrationIfNeeded(ctx context.Context) error {
 		)
 	}
 
-	return nil
+	// Upgrade the migration schema set up by the earlier releases.
+	return util.UpgradeMigrationSchemaIfNeeded(ctx, driver.db, migrationChecksumColumnQuery, addMigrationChecksumColumnStmt)
 }
 
 // FindLargestVersionSinceBaseline will find the largest version since last baseline or branch.
@@ -80,7 +93,7 @@ func (driver Driver) FindLargestVersionSinceBaseline(ctx context.Context, tx *sq
 	}
 	const getLargestVersionSinceLastBaselineQuery = `
 		SELECT MAX(version) FROM bytebase.public.migration_history
-		WHERE namespace = ? AND sequence >= ?
+		WHERE namespace = ? AND sequence >= ? AND type <> '` + string(db.Repeatable) + `'
 	`
 	var version sql.NullString
 	if err := tx.QueryRowContext(ctx, getLargestVersionSinceLastBaselineQuery,
@@ -142,9 +155,10 @@ func (Driver) InsertPendingHistory(ctx context.Context, tx *sql.Tx, sequence int
 			schema_prev,
 			execution_duration_ns,
 			issue_id,
-			payload
+			payload,
+			checksum
 		)
-		VALUES (?, DATE_PART(EPOCH_SECOND, CURRENT_TIMESTAMP()), ?, DATE_PART(EPOCH_SECOND, CURRENT_TIMESTAMP()), ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 0, ?, ?)
+		VALUES (?, DATE_PART(EPOCH_SECOND, CURRENT_TIMESTAMP()), ?, DATE_PART(EPOCH_SECOND, CURRENT_TIMESTAMP()), ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 0, ?, ?, ?)
 	`
 	var insertedID int64
 	maxIDQuery := "SELECT MAX(id)+1 FROM bytebase.public.migration_history"
@@ -186,6 +200,7 @@ func (Driver) InsertPendingHistory(ctx context.Context, tx *sql.Tx, sequence int
 		prevSchema,
 		m.IssueID,
 		m.Payload,
+		db.Checksum(statement),
 	)
 	if err != nil {
 		return int64(0), util.FormatErrorWithQuery(err, insertHistoryQuery)
@@ -232,6 +247,10 @@ func (driver *Driver) ExecuteMigration(ctx context.Context, m *db.MigrationInfo,
 
 // FindMigrationHistoryList finds the migration history.
 func (driver *Driver) FindMigrationHistoryList(ctx context.Context, find *db.MigrationHistoryFind) ([]*db.MigrationHistory, error) {
+	checksumColumn, err := util.GetMigrationHistoryChecksumColumn(ctx, driver, bytebaseDatabase, migrationChecksumColumnQuery)
+	if err != nil {
+		return nil, err
+	}
 	baseQuery := `
 	SELECT
 		id,
@@ -252,7 +271,8 @@ func (driver *Driver) FindMigrationHistoryList(ctx context.Context, find *db.Mig
 		schema_prev,
 		execution_duration_ns,
 		issue_id,
-		payload
+		payload,
+		` + checksumColumn + `
 		FROM bytebase.public.migration_history `
 	paramNames, params := []string{}, []interface{}{}
 	if v := find.ID; v != nil {
@@ -272,6 +292,12 @@ func (driver *Driver) FindMigrationHistoryList(ctx context.Context, find *db.Mig
 	if v := find.Source; v != nil {
 		paramNames, params = append(paramNames, "source"), append(params, *v)
 	}
+	if v := find.Type; v != nil {
+		paramNames, params = append(paramNames, "type"), append(params, *v)
+	}
+	if v := find.ExcludeType; v != nil {
+		paramNames, params = append(paramNames, "type <> ?"), append(params, *v)
+	}
 	var query = baseQuery +
 		db.FormatParamNameInQuestionMark(paramNames) +
 		`ORDER BY id DESC`
@@ -8,11 +8,13 @@ import (
 	"strings"
 
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/bytebase/bytebase/common"
 	"github.com/bytebase/bytebase/common/log"
 	"github.com/bytebase/bytebase/plugin/db"
 	"github.com/bytebase/bytebase/plugin/db/util"
+	"github.com/bytebase/bytebase/plugin/tracing"
 
 	snow "github.com/snowflakedb/gosnowflake"
 	"go.uber.org/zap"
@@ -218,6 +220,9 @@ func getDatabasesTxn(ctx context.Context, tx *sql.Tx) ([]string, error) {
 
 // Execute executes a SQL statement.
 func (driver *Driver) Execute(ctx context.Context, statement string, _ bool) error {
+	ctx, span := tracing.Start(ctx, "Driver.Execute", attribute.String("db.system", string(db.Snowflake)))
+	defer span.End()
+
 	count := 0
 	f := func(stmt string) error {
 		count++
@@ -40,5 +40,7 @@ CREATE TABLE bytebase.public.migration_history (
     schema_prev TEXT NOT NULL,
     execution_duration_ns BIGINT NOT NULL,
     issue_id TEXT NOT NULL,
-    payload TEXT NOT NULL
+    payload TEXT NOT NULL,
+    -- Record the checksum of the migration statement to detect tampering with the applied migration.
+    checksum TEXT NOT NULL DEFAULT ''
 );
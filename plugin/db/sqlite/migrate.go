@@ -24,6 +24,18 @@ var (
 	_ util.MigrationExecutor = (*Driver)(nil)
 )
 
+const (
+	// migrationChecksumColumnQuery returns a row if the checksum column exists in the migration history table.
+	migrationChecksumColumnQuery = `
+		SELECT
+		    1
+		FROM pragma_table_info('bytebase_migration_history')
+		WHERE name = 'checksum'
+	`
+	// addMigrationChecksumColumnStmt adds the checksum column to the migration history table set up by the earlier releases.
+	addMigrationChecksumColumnStmt = "ALTER TABLE bytebase_migration_history ADD COLUMN checksum TEXT NOT NULL DEFAULT ''"
+)
+
 // NeedsSetupMigration returns whether it needs to setup migration.
 func (driver *Driver) NeedsSetupMigration(ctx context.Context) (bool, error) {
 	exist, err := driver.hasBytebaseDatabase()
@@ -82,7 +94,8 @@ func (driver *Driver) SetupMigrationIfNeeded(ctx context.Context) error {
 		)
 	}
 
-	return nil
+	// Upgrade the migration schema set up by the earlier releases.
+	return util.UpgradeMigrationSchemaIfNeeded(ctx, driver.db, migrationChecksumColumnQuery, addMigrationChecksumColumnStmt)
 }
 
 // FindLargestVersionSinceBaseline will find the largest version since last baseline or branch.
@@ -93,7 +106,7 @@ func (driver Driver) FindLargestVersionSinceBaseline(ctx context.Context, tx *sq
 	}
 	const getLargestVersionSinceLastBaselineQuery = `
 		SELECT MAX(version) FROM bytebase_migration_history
-		WHERE namespace = ? AND sequence >= ?
+		WHERE namespace = ? AND sequence >= ? AND type <> '` + string(db.Repeatable) + `'
 	`
 	var version sql.NullString
 	if err := tx.QueryRowContext(ctx, getLargestVersionSinceLastBaselineQuery,
@@ -156,9 +169,10 @@ func (Driver) InsertPendingHistory(ctx context.Context, tx *sql.Tx, sequence int
 		schema_prev,
 		execution_duration_ns,
 		issue_id,
-		payload
+		payload,
+		checksum
 	)
-	VALUES (?, strftime('%s', 'now'), ?, strftime('%s', 'now'), ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 0, ?, ?)
+	VALUES (?, strftime('%s', 'now'), ?, strftime('%s', 'now'), ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 0, ?, ?, ?)
 	`
 	res, err := tx.ExecContext(ctx, insertHistoryQuery,
 		m.Creator,
@@ -176,6 +190,7 @@ func (Driver) InsertPendingHistory(ctx context.Context, tx *sql.Tx, sequence int
 		prevSchema,
 		m.IssueID,
 		m.Payload,
+		db.Checksum(statement),
 	)
 	if err != nil {
 		return int64(0), util.FormatErrorWithQuery(err, insertHistoryQuery)
@@ -224,6 +239,10 @@ func (driver *Driver) ExecuteMigration(ctx context.Context, m *db.MigrationInfo,
 
 // FindMigrationHistoryList finds the migration history.
 func (driver *Driver) FindMigrationHistoryList(ctx context.Context, find *db.MigrationHistoryFind) ([]*db.MigrationHistory, error) {
+	checksumColumn, err := util.GetMigrationHistoryChecksumColumn(ctx, driver, bytebaseDatabase, migrationChecksumColumnQuery)
+	if err != nil {
+		return nil, err
+	}
 	baseQuery := `
 	SELECT
 		id,
@@ -244,7 +263,8 @@ func (driver *Driver) FindMigrationHistoryList(ctx context.Context, find *db.Mig
 		schema_prev,
 		execution_duration_ns,
 		issue_id,
-		payload
+		payload,
+		` + checksumColumn + `
 		FROM bytebase_migration_history `
 	paramNames, params := []string{}, []interface{}{}
 	if v := find.ID; v != nil {
@@ -264,6 +284,12 @@ func (driver *Driver) FindMigrationHistoryList(ctx context.Context, find *db.Mig
 	if v := find.Source; v != nil {
 		paramNames, params = append(paramNames, "source"), append(params, *v)
 	}
+	if v := find.Type; v != nil {
+		paramNames, params = append(paramNames, "type"), append(params, *v)
+	}
+	if v := find.ExcludeType; v != nil {
+		paramNames, params = append(paramNames, "type <> ?"), append(params, *v)
+	}
 	var query = baseQuery +
 		db.FormatParamNameInQuestionMark(paramNames) +
 		`ORDER BY id DESC`
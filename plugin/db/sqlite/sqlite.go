@@ -12,9 +12,11 @@ import (
 	// Import sqlite3 driver.
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/bytebase/bytebase/plugin/db"
 	"github.com/bytebase/bytebase/plugin/db/util"
+	"github.com/bytebase/bytebase/plugin/tracing"
 )
 
 var (
@@ -124,6 +126,9 @@ func (driver *Driver) hasBytebaseDatabase() (bool, error) {
 
 // Execute executes a SQL statement.
 func (driver *Driver) Execute(ctx context.Context, statement string, _ bool) error {
+	ctx, span := tracing.Start(ctx, "Driver.Execute", attribute.String("db.system", string(db.SQLite)))
+	defer span.End()
+
 	var remainingStmts []string
 	f := func(stmt string) error {
 		// This is a fake CREATE DATABASE statement. Engine driver will recognize it and establish a connection to create the database.
@@ -33,7 +33,9 @@ CREATE TABLE bytebase_migration_history (
     schema_prev MEDIUMTEXT NOT NULL,
     execution_duration_ns INTEGER NOT NULL,
     issue_id TEXT NOT NULL,
-    payload TEXT NOT NULL
+    payload TEXT NOT NULL,
+    -- Record the checksum of the migration statement to detect tampering with the applied migration.
+    checksum TEXT NOT NULL DEFAULT ''
 );
 
 CREATE UNIQUE INDEX bytebase_idx_unique_migration_history_namespace_sequence ON bytebase_migration_history (namespace, sequence);
@@ -14,11 +14,13 @@ import (
 
 	"github.com/blang/semver/v4"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 
 	"github.com/bytebase/bytebase/common"
 	"github.com/bytebase/bytebase/common/log"
 	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/plugin/tracing"
 )
 
 // FormatErrorWithQuery will format the error with failed query.
@@ -129,6 +131,41 @@ func NeedsSetupMigrationSchema(ctx context.Context, sqldb *sql.DB, query string)
 	return true, nil
 }
 
+// UpgradeMigrationSchemaIfNeeded upgrades the migration schema set up by the earlier releases.
+// The query returns a row if the migration schema is up to date, otherwise the upgrade statement is executed.
+func UpgradeMigrationSchemaIfNeeded(ctx context.Context, sqldb *sql.DB, query, upgradeStatement string) error {
+	upgrade, err := NeedsSetupMigrationSchema(ctx, sqldb, query)
+	if err != nil {
+		return err
+	}
+	if !upgrade {
+		return nil
+	}
+	if _, err := sqldb.ExecContext(ctx, upgradeStatement); err != nil {
+		return FormatErrorWithQuery(err, upgradeStatement)
+	}
+	return nil
+}
+
+// GetMigrationHistoryChecksumColumn returns the checksum column to select from the migration history table.
+// The migration schema set up by the earlier releases is only upgraded before executing a migration, and the
+// read-only connections can't upgrade it, so it returns an empty checksum instead if the column doesn't exist yet.
+// The query returns a row if the checksum column exists.
+func GetMigrationHistoryChecksumColumn(ctx context.Context, driver db.Driver, database, query string) (string, error) {
+	sqldb, err := driver.GetDBConnection(ctx, database)
+	if err != nil {
+		return "", err
+	}
+	missing, err := NeedsSetupMigrationSchema(ctx, sqldb, query)
+	if err != nil {
+		return "", err
+	}
+	if missing {
+		return "'' AS checksum", nil
+	}
+	return "checksum", nil
+}
+
 // MigrationExecutor is an adapter for ExecuteMigration().
 type MigrationExecutor interface {
 	db.Driver
@@ -191,13 +228,19 @@ func ExecuteMigration(ctx context.Context, executor MigrationExecutor, m *db.Mig
 		doMigrate = false
 	}
 	if doMigrate {
+		var sqlDB *sql.DB
 		// Switch to the target database only if we're NOT creating this target database.
 		if !m.CreateDatabase {
-			if _, err := executor.GetDBConnection(ctx, m.Database); err != nil {
+			var err error
+			if sqlDB, err = executor.GetDBConnection(ctx, m.Database); err != nil {
 				return -1, "", err
 			}
 		}
-		if err := executor.Execute(ctx, statement, m.CreateDatabase); err != nil {
+		if m.ExecuteFunc != nil && sqlDB != nil {
+			if err := m.ExecuteFunc(ctx, sqlDB); err != nil {
+				return -1, "", FormatError(err)
+			}
+		} else if err := executor.Execute(ctx, statement, m.CreateDatabase); err != nil {
 			return -1, "", FormatError(err)
 		}
 	}
@@ -269,11 +312,14 @@ func BeginMigration(ctx context.Context, executor MigrationExecutor, m *db.Migra
 	}
 
 	// Check if there is any higher version already been applied since the last baseline or branch.
-	if version, err := executor.FindLargestVersionSinceBaseline(ctx, tx, m.Namespace); err != nil {
-		return -1, err
-	} else if version != nil && len(*version) > 0 && *version >= m.Version {
-		// len(*version) > 0 is used because Clickhouse will always return non-nil version with empty string.
-		return -1, common.Errorf(common.MigrationOutOfOrder, "database %q has already applied version %s which >= %s", m.Database, *version, m.Version)
+	// Repeatable migrations are re-applied regardless of the version order.
+	if m.Type != db.Repeatable {
+		if version, err := executor.FindLargestVersionSinceBaseline(ctx, tx, m.Namespace); err != nil {
+			return -1, err
+		} else if version != nil && len(*version) > 0 && *version >= m.Version {
+			// len(*version) > 0 is used because Clickhouse will always return non-nil version with empty string.
+			return -1, common.Errorf(common.MigrationOutOfOrder, "database %q has already applied version %s which >= %s", m.Database, *version, m.Version)
+		}
 	}
 
 	// Phase 2 - Record migration history as PENDING.
@@ -321,7 +367,14 @@ func EndMigration(ctx context.Context, executor MigrationExecutor, startedNs int
 }
 
 // Query will execute a readonly / SELECT query.
-func Query(ctx context.Context, dbType db.Type, sqldb *sql.DB, statement string, limit int, readOnly bool) ([]interface{}, error) {
+func Query(ctx context.Context, dbType db.Type, sqldb *sql.DB, statement string, limit int, readOnly bool) (_ []interface{}, err error) {
+	ctx, span := tracing.Start(ctx, "Driver.Query",
+		attribute.String("db.system", string(dbType)),
+		attribute.String("db.statement", statement),
+	)
+	defer func() {
+		tracing.End(span, err)
+	}()
 	if !readOnly {
 		return queryAdmin(ctx, sqldb, statement, limit)
 	}
@@ -583,6 +636,7 @@ func FindMigrationHistoryList(ctx context.Context, findMigrationHistoryListQuery
 			&history.ExecutionDurationNs,
 			&history.IssueID,
 			&history.Payload,
+			&history.Checksum,
 		); err != nil {
 			return nil, err
 		}
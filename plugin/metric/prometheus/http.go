@@ -0,0 +1,77 @@
+package prometheus
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// MetricsPath is the path of the endpoint serving the metrics.
+const MetricsPath = "/metrics"
+
+var (
+	// HTTPRequestCount is the number of the HTTP requests by route and status.
+	HTTPRequestCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of HTTP requests.",
+	}, []string{"method", "route", "status"})
+	// HTTPRequestDuration is the latency of the HTTP requests by route and status.
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of HTTP requests.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		HTTPRequestCount,
+		HTTPRequestDuration,
+	)
+}
+
+// Register registers the metrics endpoint and the middleware recording the HTTP requests to the echo server.
+func Register(e *echo.Echo) {
+	e.Use(HTTPMiddleware)
+	e.GET(MetricsPath, echo.WrapHandler(promhttp.Handler()))
+}
+
+// HTTPMiddleware records the count and the latency of the HTTP requests.
+// The route is the path pattern matched by the router, e.g. "/api/issue/:issueID", which keeps the cardinality low.
+func HTTPMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		route := c.Path()
+		if route == MetricsPath {
+			return next(c)
+		}
+		if route == "" {
+			route = "unmatched"
+		}
+
+		start := time.Now()
+		err := next(c)
+		// The error is turned into the response by the HTTP error handler after the middleware returns.
+		status := c.Response().Status
+		if err != nil {
+			status = http.StatusInternalServerError
+			var httpError *echo.HTTPError
+			if errors.As(err, &httpError) {
+				status = httpError.Code
+			}
+		}
+
+		labels := []string{c.Request().Method, route, strconv.Itoa(status)}
+		HTTPRequestCount.WithLabelValues(labels...).Inc()
+		ObserveSince(HTTPRequestDuration, start, labels...)
+		return err
+	}
+}
@@ -0,0 +1,44 @@
+package prometheus
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegister(t *testing.T) {
+	e := echo.New()
+	Register(e)
+	e.GET("/api/issue/:issueID", func(c echo.Context) error {
+		return c.String(http.StatusOK, c.Param("issueID"))
+	})
+	e.GET("/api/pipeline/:pipelineID", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusNotFound, "pipeline not found")
+	})
+
+	for _, path := range []string{"/api/issue/101", "/api/issue/102", "/api/pipeline/101"} {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	TaskRunDuration.WithLabelValues("bb.task.database.schema.update", string(OutcomeDone)).Observe(1)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, MetricsPath, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	for _, series := range []string{
+		`bytebase_http_requests_total{method="GET",route="/api/issue/:issueID",status="200"} 2`,
+		`bytebase_http_requests_total{method="GET",route="/api/pipeline/:pipelineID",status="404"} 1`,
+		`bytebase_http_request_duration_seconds_count{method="GET",route="/api/issue/:issueID",status="200"} 2`,
+		`bytebase_task_run_duration_seconds_count{outcome="done",type="bb.task.database.schema.update"} 1`,
+		`bytebase_task_scheduler_queue_depth`,
+	} {
+		assert.Contains(t, body, series)
+	}
+	// The scrapes of the metrics endpoint are not recorded.
+	assert.NotContains(t, body, `route="/metrics"`)
+}
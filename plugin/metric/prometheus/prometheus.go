@@ -0,0 +1,126 @@
+// Package prometheus exposes the Bytebase server internals as Prometheus collectors.
+// The collectors are registered to the default registry, which is served by the /metrics endpoint.
+package prometheus
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "bytebase"
+
+// Outcome is the outcome label value for runs and deliveries.
+type Outcome string
+
+const (
+	// OutcomeDone is the outcome for a successful run.
+	OutcomeDone Outcome = "done"
+	// OutcomeFailed is the outcome for a failed run.
+	OutcomeFailed Outcome = "failed"
+	// OutcomeRetry is the outcome for a run that hit a transient error and will be retried.
+	OutcomeRetry Outcome = "retry"
+	// OutcomeCanceled is the outcome for a canceled run.
+	OutcomeCanceled Outcome = "canceled"
+)
+
+// Runner is the label value for the background runners.
+type Runner string
+
+const (
+	// RunnerSchemaSyncer is the schema syncer runner.
+	RunnerSchemaSyncer Runner = "schema_syncer"
+	// RunnerAnomalyScanner is the anomaly scanner runner.
+	RunnerAnomalyScanner Runner = "anomaly_scanner"
+)
+
+var (
+	// TaskQueueDepth is the number of RUNNING tasks found by the task scheduler in the last iteration,
+	// including the ones held up behind an earlier task on the same database.
+	TaskQueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "task_scheduler",
+		Name:      "queue_depth",
+		Help:      "Number of running tasks waiting for or being executed by the task scheduler.",
+	})
+	// TaskExecutorRunning is the number of task executors currently running.
+	TaskExecutorRunning = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "task_scheduler",
+		Name:      "running_executors",
+		Help:      "Number of task executors currently running.",
+	})
+	// TaskRunDuration is the duration of a single task executor run by task type and outcome.
+	TaskRunDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "task",
+		Name:      "run_duration_seconds",
+		Help:      "Duration of task executor runs.",
+		Buckets:   []float64{0.1, 0.5, 1, 5, 10, 30, 60, 300, 600, 1800, 3600},
+	}, []string{"type", "outcome"})
+	// TaskCheckRunDuration is the duration of a single task check run by task check type and outcome.
+	TaskCheckRunDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "task_check",
+		Name:      "run_duration_seconds",
+		Help:      "Duration of task check runs.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"type", "outcome"})
+	// RunnerCycleDuration is the duration of a single cycle of the background runners.
+	RunnerCycleDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "runner",
+		Name:      "cycle_duration_seconds",
+		Help:      "Duration of a single cycle of the background runners.",
+		Buckets:   []float64{0.1, 0.5, 1, 5, 10, 30, 60, 300, 600},
+	}, []string{"runner"})
+	// DriverOpenDuration is the latency of opening a database driver by engine and outcome.
+	DriverOpenDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "driver",
+		Name:      "open_duration_seconds",
+		Help:      "Latency of opening database drivers.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"engine", "outcome"})
+	// DriverPingDuration is the latency of pinging a database by engine and outcome.
+	DriverPingDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "driver",
+		Name:      "ping_duration_seconds",
+		Help:      "Latency of pinging databases.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"engine", "outcome"})
+	// WebhookDeliveryFailures is the number of failed outgoing webhook deliveries by webhook type.
+	WebhookDeliveryFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "webhook",
+		Name:      "delivery_failures_total",
+		Help:      "Number of failed outgoing webhook deliveries.",
+	}, []string{"type"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		TaskQueueDepth,
+		TaskExecutorRunning,
+		TaskRunDuration,
+		TaskCheckRunDuration,
+		RunnerCycleDuration,
+		DriverOpenDuration,
+		DriverPingDuration,
+		WebhookDeliveryFailures,
+	)
+}
+
+// ObserveSince observes the elapsed time since start into the histogram with labels.
+func ObserveSince(histogram *prometheus.HistogramVec, start time.Time, labels ...string) {
+	histogram.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+}
+
+// OutcomeFromError returns OutcomeDone if err is nil, and OutcomeFailed otherwise.
+func OutcomeFromError(err error) Outcome {
+	if err != nil {
+		return OutcomeFailed
+	}
+	return OutcomeDone
+}
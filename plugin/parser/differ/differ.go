@@ -12,6 +12,8 @@ import (
 // SchemaDiffer is the interface for schema differ.
 type SchemaDiffer interface {
 	SchemaDiff(oldStmt, newStmt string) (string, error)
+	// SummarizeDiff summarizes the schema diff statements returned by SchemaDiff into structured changes.
+	SummarizeDiff(diffStmt string) ([]*SchemaChange, error)
 }
 
 var (
@@ -44,3 +46,14 @@ func SchemaDiff(engineType parser.EngineType, oldStmt, newStmt string) (string,
 	}
 	return p.SchemaDiff(oldStmt, newStmt)
 }
+
+// SummarizeDiff returns the structured changes of the schema diff statements.
+func SummarizeDiff(engineType parser.EngineType, diffStmt string) ([]*SchemaChange, error) {
+	differMu.RLock()
+	p, ok := differs[engineType]
+	differMu.RUnlock()
+	if !ok {
+		return nil, errors.Errorf("engine: unknown engine type %v", engineType)
+	}
+	return p.SummarizeDiff(diffStmt)
+}
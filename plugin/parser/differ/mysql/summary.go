@@ -0,0 +1,112 @@
+package mysql
+
+import (
+	"github.com/pingcap/tidb/parser"
+	"github.com/pingcap/tidb/parser/ast"
+	"github.com/pkg/errors"
+
+	bbparser "github.com/bytebase/bytebase/plugin/parser"
+	"github.com/bytebase/bytebase/plugin/parser/differ"
+)
+
+// primaryKeyIndexName is the index name MySQL gives to the primary key.
+const primaryKeyIndexName = "PRIMARY"
+
+// SummarizeDiff summarizes the schema diff statements into structured changes on tables, columns, indexes and constraints.
+// Views, triggers, events, functions and procedures are not included.
+func (*SchemaDiffer) SummarizeDiff(diffStmt string) ([]*differ.SchemaChange, error) {
+	_, supportStmts, err := bbparser.ExtractTiDBUnsupportStmts(diffStmt)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to extract TiDB unsupport statements from diff statements %q", diffStmt)
+	}
+	nodes, _, err := parser.New().Parse(supportStmts, "", "")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse diff statement %q", diffStmt)
+	}
+
+	var changeList []*differ.SchemaChange
+	for _, node := range nodes {
+		switch stmt := node.(type) {
+		case *ast.CreateTableStmt:
+			changeList = append(changeList, &differ.SchemaChange{
+				Action:     differ.SchemaChangeActionAdd,
+				ObjectType: differ.SchemaObjectTypeTable,
+				Table:      stmt.Table.Name.O,
+			})
+		case *ast.DropTableStmt:
+			if stmt.IsView {
+				continue
+			}
+			for _, table := range stmt.Tables {
+				changeList = append(changeList, &differ.SchemaChange{
+					Action:     differ.SchemaChangeActionDrop,
+					ObjectType: differ.SchemaObjectTypeTable,
+					Table:      table.Name.O,
+				})
+			}
+		case *ast.AlterTableStmt:
+			for _, spec := range stmt.Specs {
+				changeList = append(changeList, summarizeAlterTableSpec(stmt.Table.Name.O, spec)...)
+			}
+		case *ast.CreateIndexStmt:
+			changeList = append(changeList, &differ.SchemaChange{
+				Action:     differ.SchemaChangeActionAdd,
+				ObjectType: differ.SchemaObjectTypeIndex,
+				Table:      stmt.Table.Name.O,
+				Name:       stmt.IndexName,
+			})
+		case *ast.DropIndexStmt:
+			changeList = append(changeList, &differ.SchemaChange{
+				Action:     differ.SchemaChangeActionDrop,
+				ObjectType: differ.SchemaObjectTypeIndex,
+				Table:      stmt.Table.Name.O,
+				Name:       stmt.IndexName,
+			})
+		}
+	}
+	return differ.MergeSchemaChange(changeList), nil
+}
+
+func summarizeAlterTableSpec(tableName string, spec *ast.AlterTableSpec) []*differ.SchemaChange {
+	newChange := func(action differ.SchemaChangeAction, objectType differ.SchemaObjectType, name string) *differ.SchemaChange {
+		return &differ.SchemaChange{
+			Action:     action,
+			ObjectType: objectType,
+			Table:      tableName,
+			Name:       name,
+		}
+	}
+
+	switch spec.Tp {
+	case ast.AlterTableAddColumns:
+		var changeList []*differ.SchemaChange
+		for _, column := range spec.NewColumns {
+			changeList = append(changeList, newChange(differ.SchemaChangeActionAdd, differ.SchemaObjectTypeColumn, column.Name.Name.O))
+		}
+		return changeList
+	case ast.AlterTableDropColumn:
+		return []*differ.SchemaChange{newChange(differ.SchemaChangeActionDrop, differ.SchemaObjectTypeColumn, spec.OldColumnName.Name.O)}
+	case ast.AlterTableModifyColumn, ast.AlterTableChangeColumn:
+		return []*differ.SchemaChange{newChange(differ.SchemaChangeActionAlter, differ.SchemaObjectTypeColumn, spec.NewColumns[0].Name.Name.O)}
+	case ast.AlterTableAddConstraint:
+		switch spec.Constraint.Tp {
+		case ast.ConstraintPrimaryKey:
+			return []*differ.SchemaChange{newChange(differ.SchemaChangeActionAdd, differ.SchemaObjectTypeIndex, primaryKeyIndexName)}
+		case ast.ConstraintForeignKey, ast.ConstraintCheck:
+			return []*differ.SchemaChange{newChange(differ.SchemaChangeActionAdd, differ.SchemaObjectTypeConstraint, spec.Constraint.Name)}
+		default:
+			return []*differ.SchemaChange{newChange(differ.SchemaChangeActionAdd, differ.SchemaObjectTypeIndex, spec.Constraint.Name)}
+		}
+	case ast.AlterTableDropIndex:
+		return []*differ.SchemaChange{newChange(differ.SchemaChangeActionDrop, differ.SchemaObjectTypeIndex, spec.Name)}
+	case ast.AlterTableDropPrimaryKey:
+		return []*differ.SchemaChange{newChange(differ.SchemaChangeActionDrop, differ.SchemaObjectTypeIndex, primaryKeyIndexName)}
+	case ast.AlterTableDropForeignKey:
+		return []*differ.SchemaChange{newChange(differ.SchemaChangeActionDrop, differ.SchemaObjectTypeConstraint, spec.Name)}
+	case ast.AlterTableDropCheck:
+		return []*differ.SchemaChange{newChange(differ.SchemaChangeActionDrop, differ.SchemaObjectTypeConstraint, spec.Constraint.Name)}
+	default:
+		// Table options and other table level changes.
+		return []*differ.SchemaChange{newChange(differ.SchemaChangeActionAlter, differ.SchemaObjectTypeTable, "")}
+	}
+}
@@ -0,0 +1,29 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bytebase/bytebase/plugin/parser/differ"
+)
+
+func TestSummarizeDiff(t *testing.T) {
+	oldSchema := "CREATE TABLE `user` (`id` int NOT NULL, `name` varchar(64) DEFAULT NULL, `age` int DEFAULT NULL, PRIMARY KEY (`id`), KEY `idx_name` (`name`));\n" +
+		"CREATE TABLE `log` (`id` int NOT NULL);\n"
+	newSchema := "CREATE TABLE `user` (`id` int NOT NULL, `name` varchar(128) DEFAULT NULL, `email` varchar(64) DEFAULT NULL, PRIMARY KEY (`id`), KEY `idx_name` (`name`, `email`));\n" +
+		"CREATE TABLE `post` (`id` int NOT NULL);\n"
+
+	diff, err := (&SchemaDiffer{}).SchemaDiff(oldSchema, newSchema)
+	require.NoError(t, err)
+	changeList, err := (&SchemaDiffer{}).SummarizeDiff(diff)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []*differ.SchemaChange{
+		{Action: differ.SchemaChangeActionAdd, ObjectType: differ.SchemaObjectTypeTable, Table: "post"},
+		{Action: differ.SchemaChangeActionDrop, ObjectType: differ.SchemaObjectTypeTable, Table: "log"},
+		{Action: differ.SchemaChangeActionAdd, ObjectType: differ.SchemaObjectTypeColumn, Table: "user", Name: "email"},
+		{Action: differ.SchemaChangeActionDrop, ObjectType: differ.SchemaObjectTypeColumn, Table: "user", Name: "age"},
+		{Action: differ.SchemaChangeActionAlter, ObjectType: differ.SchemaObjectTypeColumn, Table: "user", Name: "name"},
+		{Action: differ.SchemaChangeActionAlter, ObjectType: differ.SchemaObjectTypeIndex, Table: "user", Name: "idx_name"},
+	}, changeList)
+}
@@ -0,0 +1,110 @@
+package pg
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/bytebase/bytebase/plugin/parser"
+	"github.com/bytebase/bytebase/plugin/parser/ast"
+	"github.com/bytebase/bytebase/plugin/parser/differ"
+)
+
+// SummarizeDiff summarizes the schema diff statements into structured changes on tables, columns, indexes and constraints.
+// Primary keys and unique constraints are reported as indexes.
+func (*SchemaDiffer) SummarizeDiff(diffStmt string) ([]*differ.SchemaChange, error) {
+	nodes, err := parser.Parse(parser.Postgres, parser.ParseContext{}, diffStmt)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse diff statement %q", diffStmt)
+	}
+
+	var changeList []*differ.SchemaChange
+	for _, node := range nodes {
+		switch stmt := node.(type) {
+		case *ast.CreateTableStmt:
+			changeList = append(changeList, newSchemaChange(differ.SchemaChangeActionAdd, differ.SchemaObjectTypeTable, stmt.Name, ""))
+		case *ast.DropTableStmt:
+			for _, table := range stmt.TableList {
+				changeList = append(changeList, newSchemaChange(differ.SchemaChangeActionDrop, differ.SchemaObjectTypeTable, table, ""))
+			}
+		case *ast.AlterTableStmt:
+			for _, item := range stmt.AlterItemList {
+				changeList = append(changeList, summarizeAlterItem(stmt.Table, item)...)
+			}
+		case *ast.CreateIndexStmt:
+			changeList = append(changeList, newSchemaChange(differ.SchemaChangeActionAdd, differ.SchemaObjectTypeIndex, stmt.Index.Table, stmt.Index.Name))
+		case *ast.DropIndexStmt:
+			for _, index := range stmt.IndexList {
+				changeList = append(changeList, newSchemaChange(differ.SchemaChangeActionDrop, differ.SchemaObjectTypeIndex, index.Table, index.Name))
+			}
+		}
+	}
+
+	// A modified constraint is dropped and re-added, so we take the type of the dropped constraint from the added one.
+	addedObjectType := make(map[differ.SchemaChange]differ.SchemaObjectType)
+	for _, change := range changeList {
+		if change.Action == differ.SchemaChangeActionAdd && change.Name != "" {
+			addedObjectType[differ.SchemaChange{Schema: change.Schema, Table: change.Table, Name: change.Name}] = change.ObjectType
+		}
+	}
+	for _, change := range changeList {
+		if change.Action == differ.SchemaChangeActionDrop && change.ObjectType == differ.SchemaObjectTypeConstraint {
+			if objectType, ok := addedObjectType[differ.SchemaChange{Schema: change.Schema, Table: change.Table, Name: change.Name}]; ok {
+				change.ObjectType = objectType
+			}
+		}
+	}
+	return differ.MergeSchemaChange(changeList), nil
+}
+
+func summarizeAlterItem(table *ast.TableDef, item ast.Node) []*differ.SchemaChange {
+	switch item := item.(type) {
+	case *ast.AddColumnListStmt:
+		var changeList []*differ.SchemaChange
+		for _, column := range item.ColumnList {
+			changeList = append(changeList, newSchemaChange(differ.SchemaChangeActionAdd, differ.SchemaObjectTypeColumn, table, column.ColumnName))
+		}
+		return changeList
+	case *ast.DropColumnStmt:
+		return []*differ.SchemaChange{newSchemaChange(differ.SchemaChangeActionDrop, differ.SchemaObjectTypeColumn, table, item.ColumnName)}
+	case *ast.AlterColumnTypeStmt:
+		return []*differ.SchemaChange{newSchemaChange(differ.SchemaChangeActionAlter, differ.SchemaObjectTypeColumn, table, item.ColumnName)}
+	case *ast.SetNotNullStmt:
+		return []*differ.SchemaChange{newSchemaChange(differ.SchemaChangeActionAlter, differ.SchemaObjectTypeColumn, table, item.ColumnName)}
+	case *ast.DropNotNullStmt:
+		return []*differ.SchemaChange{newSchemaChange(differ.SchemaChangeActionAlter, differ.SchemaObjectTypeColumn, table, item.ColumnName)}
+	case *ast.SetDefaultStmt:
+		return []*differ.SchemaChange{newSchemaChange(differ.SchemaChangeActionAlter, differ.SchemaObjectTypeColumn, table, item.ColumnName)}
+	case *ast.DropDefaultStmt:
+		return []*differ.SchemaChange{newSchemaChange(differ.SchemaChangeActionAlter, differ.SchemaObjectTypeColumn, table, item.ColumnName)}
+	case *ast.RenameColumnStmt:
+		return []*differ.SchemaChange{newSchemaChange(differ.SchemaChangeActionAlter, differ.SchemaObjectTypeColumn, table, item.ColumnName)}
+	case *ast.AddConstraintStmt:
+		return []*differ.SchemaChange{newSchemaChange(differ.SchemaChangeActionAdd, constraintObjectType(item.Constraint), table, item.Constraint.Name)}
+	case *ast.DropConstraintStmt:
+		// The type of the dropped constraint is unknown from the statement.
+		return []*differ.SchemaChange{newSchemaChange(differ.SchemaChangeActionDrop, differ.SchemaObjectTypeConstraint, table, item.ConstraintName)}
+	default:
+		return []*differ.SchemaChange{newSchemaChange(differ.SchemaChangeActionAlter, differ.SchemaObjectTypeTable, table, "")}
+	}
+}
+
+func constraintObjectType(constraint *ast.ConstraintDef) differ.SchemaObjectType {
+	switch constraint.Type {
+	case ast.ConstraintTypePrimary, ast.ConstraintTypeUnique, ast.ConstraintTypePrimaryUsingIndex, ast.ConstraintTypeUniqueUsingIndex:
+		return differ.SchemaObjectTypeIndex
+	default:
+		return differ.SchemaObjectTypeConstraint
+	}
+}
+
+func newSchemaChange(action differ.SchemaChangeAction, objectType differ.SchemaObjectType, table *ast.TableDef, name string) *differ.SchemaChange {
+	change := &differ.SchemaChange{
+		Action:     action,
+		ObjectType: objectType,
+		Name:       name,
+	}
+	if table != nil {
+		change.Schema = table.Schema
+		change.Table = table.Name
+	}
+	return change
+}
@@ -0,0 +1,31 @@
+package pg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bytebase/bytebase/plugin/parser/differ"
+)
+
+func TestSummarizeDiff(t *testing.T) {
+	oldSchema := `CREATE TABLE public.users (id integer NOT NULL, name text, age integer);
+ALTER TABLE ONLY public.users ADD CONSTRAINT users_name_key UNIQUE (name);
+`
+	newSchema := `CREATE TABLE public.users (id integer NOT NULL, name character varying(64) NOT NULL, email text);
+CREATE TABLE public.posts (id integer NOT NULL);
+ALTER TABLE ONLY public.users ADD CONSTRAINT users_name_key UNIQUE (name, email);
+`
+
+	diff, err := (&SchemaDiffer{}).SchemaDiff(oldSchema, newSchema)
+	require.NoError(t, err)
+	changeList, err := (&SchemaDiffer{}).SummarizeDiff(diff)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []*differ.SchemaChange{
+		{Action: differ.SchemaChangeActionAdd, ObjectType: differ.SchemaObjectTypeTable, Schema: "public", Table: "posts"},
+		{Action: differ.SchemaChangeActionAdd, ObjectType: differ.SchemaObjectTypeColumn, Schema: "public", Table: "users", Name: "email"},
+		{Action: differ.SchemaChangeActionDrop, ObjectType: differ.SchemaObjectTypeColumn, Schema: "public", Table: "users", Name: "age"},
+		{Action: differ.SchemaChangeActionAlter, ObjectType: differ.SchemaObjectTypeColumn, Schema: "public", Table: "users", Name: "name"},
+		{Action: differ.SchemaChangeActionAlter, ObjectType: differ.SchemaObjectTypeIndex, Schema: "public", Table: "users", Name: "users_name_key"},
+	}, changeList)
+}
@@ -0,0 +1,66 @@
+package differ
+
+// SchemaChangeAction is the action of a schema change.
+type SchemaChangeAction string
+
+const (
+	// SchemaChangeActionAdd adds the object.
+	SchemaChangeActionAdd SchemaChangeAction = "ADD"
+	// SchemaChangeActionDrop drops the object.
+	SchemaChangeActionDrop SchemaChangeAction = "DROP"
+	// SchemaChangeActionAlter alters the object.
+	SchemaChangeActionAlter SchemaChangeAction = "ALTER"
+)
+
+// SchemaObjectType is the type of the object changed by a schema change.
+type SchemaObjectType string
+
+const (
+	// SchemaObjectTypeTable is the object type for tables.
+	SchemaObjectTypeTable SchemaObjectType = "TABLE"
+	// SchemaObjectTypeColumn is the object type for columns.
+	SchemaObjectTypeColumn SchemaObjectType = "COLUMN"
+	// SchemaObjectTypeIndex is the object type for indexes, including primary keys and unique keys.
+	SchemaObjectTypeIndex SchemaObjectType = "INDEX"
+	// SchemaObjectTypeConstraint is the object type for foreign keys and check constraints.
+	SchemaObjectTypeConstraint SchemaObjectType = "CONSTRAINT"
+)
+
+// SchemaChange is a structured change in the schema diff.
+type SchemaChange struct {
+	Action     SchemaChangeAction `json:"action"`
+	ObjectType SchemaObjectType   `json:"objectType"`
+	// Schema is the schema of the table, only applicable to PostgreSQL.
+	Schema string `json:"schema,omitempty"`
+	Table  string `json:"table"`
+	// Name is the name of the column, index or constraint, empty for tables.
+	Name string `json:"name,omitempty"`
+}
+
+// MergeSchemaChange merges the drop and add of the same object into one alter change,
+// as the differ replaces a modified index or constraint by dropping and re-creating it.
+// Duplicate changes are removed and the order of the first occurrence is kept.
+func MergeSchemaChange(changeList []*SchemaChange) []*SchemaChange {
+	type objectKey struct {
+		objectType SchemaObjectType
+		schema     string
+		table      string
+		name       string
+	}
+	var result []*SchemaChange
+	changeMap := make(map[objectKey]*SchemaChange)
+	for _, change := range changeList {
+		key := objectKey{objectType: change.ObjectType, schema: change.Schema, table: change.Table, name: change.Name}
+		existing, ok := changeMap[key]
+		if !ok {
+			c := *change
+			changeMap[key] = &c
+			result = append(result, &c)
+			continue
+		}
+		if existing.Action != change.Action {
+			existing.Action = SchemaChangeActionAlter
+		}
+	}
+	return result
+}
@@ -0,0 +1,1365 @@
+// Package azure is the plugin for Azure DevOps Repos.
+package azure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/vcs"
+	"github.com/bytebase/bytebase/plugin/vcs/internal/oauth"
+)
+
+const (
+	// apiVersion is the version of the Azure DevOps REST API.
+	apiVersion = "7.0"
+	// oauthURL is the URL of the Azure DevOps OAuth service, which is shared by
+	// all organizations.
+	oauthURL = "https://app.vssps.visualstudio.com"
+	// apiPageSize is the page size when listing the changes of a pull request.
+	apiPageSize = 1000
+	// emptyObjectID is the object ID used as the old object ID when creating a ref.
+	emptyObjectID = "0000000000000000000000000000000000000000"
+)
+
+// commitIDRegex matches the full SHA-1 commit ID.
+var commitIDRegex = regexp.MustCompile(`^[0-9a-fA-F]{40}$`)
+
+func init() {
+	vcs.Register(vcs.AzureDevOps, newProvider)
+}
+
+var _ vcs.Provider = (*Provider)(nil)
+
+// Provider is an Azure DevOps VCS provider.
+//
+// The instance URL is the URL of the organization (e.g.
+// https://dev.azure.com/{organization}) or the project collection of Azure
+// DevOps Server (e.g. https://{server}/tfs/{collection}). The repository ID is
+// the full path "{project}/{repository}" of the repository.
+type Provider struct {
+	client *http.Client
+}
+
+func newProvider(config vcs.ProviderConfig) vcs.Provider {
+	if config.Client == nil {
+		config.Client = &http.Client{}
+	}
+	return &Provider{
+		client: config.Client,
+	}
+}
+
+// APIURL returns the API URL path of Azure DevOps, which is the same as the
+// organization URL.
+func (*Provider) APIURL(instanceURL string) string {
+	return instanceURL
+}
+
+// Project represents an Azure DevOps API response for a project.
+type Project struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Repository represents an Azure DevOps API response for a Git repository.
+type Repository struct {
+	ID            string  `json:"id"`
+	Name          string  `json:"name"`
+	URL           string  `json:"url"`
+	RemoteURL     string  `json:"remoteUrl"`
+	WebURL        string  `json:"webUrl"`
+	DefaultBranch string  `json:"defaultBranch"`
+	Project       Project `json:"project"`
+}
+
+// fullPath returns the full path of the repository, which is used as the
+// repository ID.
+func (r Repository) fullPath() string {
+	return fmt.Sprintf("%s/%s", r.Project.Name, r.Name)
+}
+
+// webURL returns the web URL of the repository. The webUrl field is not
+// included in the webhook payloads, while the remoteUrl field is the same as the
+// web URL.
+func (r Repository) webURL() string {
+	if r.WebURL != "" {
+		return r.WebURL
+	}
+	return r.RemoteURL
+}
+
+// RepositoryList represents an Azure DevOps API response for a list of Git repositories.
+type RepositoryList struct {
+	Count int          `json:"count"`
+	Value []Repository `json:"value"`
+}
+
+// Identity represents an Azure DevOps API response for an identity.
+type Identity struct {
+	ProviderDisplayName string `json:"providerDisplayName"`
+	Properties          struct {
+		Account struct {
+			Value string `json:"$value"`
+		} `json:"Account"`
+	} `json:"properties"`
+}
+
+// ConnectionData represents an Azure DevOps API response for the connection data.
+type ConnectionData struct {
+	AuthenticatedUser Identity `json:"authenticatedUser"`
+}
+
+// CommitUser represents an Azure DevOps API response for a commit author or committer.
+type CommitUser struct {
+	Name  string    `json:"name"`
+	Email string    `json:"email"`
+	Date  time.Time `json:"date"`
+}
+
+// Item represents an Azure DevOps API response for an item in a Git repository.
+type Item struct {
+	ObjectID string `json:"objectId"`
+	// GitObjectType is one of "blob", "tree" and "commit".
+	GitObjectType string `json:"gitObjectType"`
+	CommitID      string `json:"commitId"`
+	Path          string `json:"path"`
+	IsFolder      bool   `json:"isFolder"`
+	Content       string `json:"content"`
+}
+
+// ItemList represents an Azure DevOps API response for a list of items.
+type ItemList struct {
+	Count int    `json:"count"`
+	Value []Item `json:"value"`
+}
+
+// Change represents an Azure DevOps API response for a change of an item.
+type Change struct {
+	Item Item `json:"item"`
+	// ChangeType is a comma-separated list of change types, e.g. "add", "edit",
+	// "delete" and "edit, rename".
+	ChangeType string `json:"changeType"`
+}
+
+// hasChangeType returns true if the change contains the given change type.
+func (c Change) hasChangeType(changeType string) bool {
+	for _, t := range strings.Split(c.ChangeType, ",") {
+		if strings.TrimSpace(t) == changeType {
+			return true
+		}
+	}
+	return false
+}
+
+// Commit represents an Azure DevOps API response for a commit.
+type Commit struct {
+	CommitID  string     `json:"commitId"`
+	Author    CommitUser `json:"author"`
+	Comment   string     `json:"comment"`
+	URL       string     `json:"url"`
+	RemoteURL string     `json:"remoteUrl"`
+	Changes   []Change   `json:"changes"`
+}
+
+// Ref represents an Azure DevOps API response for a Git ref.
+type Ref struct {
+	Name     string `json:"name"`
+	ObjectID string `json:"objectId"`
+}
+
+// RefList represents an Azure DevOps API response for a list of Git refs.
+type RefList struct {
+	Count int   `json:"count"`
+	Value []Ref `json:"value"`
+}
+
+// RefUpdate represents an Azure DevOps API message for updating a Git ref.
+type RefUpdate struct {
+	Name        string `json:"name"`
+	OldObjectID string `json:"oldObjectId"`
+	NewObjectID string `json:"newObjectId,omitempty"`
+}
+
+// RefUpdateResult represents an Azure DevOps API response for the result of updating a Git ref.
+type RefUpdateResult struct {
+	Name         string `json:"name"`
+	Success      bool   `json:"success"`
+	UpdateStatus string `json:"updateStatus"`
+}
+
+// RefUpdateResultList represents an Azure DevOps API response for a list of results of updating Git refs.
+type RefUpdateResultList struct {
+	Count int               `json:"count"`
+	Value []RefUpdateResult `json:"value"`
+}
+
+// ItemContent is the API message for the new content of an item in a push.
+type ItemContent struct {
+	Content string `json:"content"`
+	// ContentType is either "rawtext" or "base64encoded".
+	ContentType string `json:"contentType"`
+}
+
+// ItemChange is the API message for the change of an item in a push.
+type ItemChange struct {
+	ChangeType string `json:"changeType"`
+	Item       struct {
+		Path string `json:"path"`
+	} `json:"item"`
+	NewContent ItemContent `json:"newContent"`
+}
+
+// PushCommit is the API message for a commit in a push.
+type PushCommit struct {
+	Comment string       `json:"comment"`
+	Changes []ItemChange `json:"changes"`
+}
+
+// PushCreate is the API message to create a push.
+type PushCreate struct {
+	RefUpdates []RefUpdate  `json:"refUpdates"`
+	Commits    []PushCommit `json:"commits"`
+}
+
+// PullRequestCompletionOptions is the API message for the completion options of a pull request.
+type PullRequestCompletionOptions struct {
+	DeleteSourceBranch bool `json:"deleteSourceBranch"`
+}
+
+// PullRequestCreate is the API message to create the pull request.
+type PullRequestCreate struct {
+	SourceRefName     string                       `json:"sourceRefName"`
+	TargetRefName     string                       `json:"targetRefName"`
+	Title             string                       `json:"title"`
+	Description       string                       `json:"description"`
+	CompletionOptions PullRequestCompletionOptions `json:"completionOptions"`
+}
+
+// PullRequest is the API message for Azure DevOps pull request.
+type PullRequest struct {
+	PullRequestID int        `json:"pullRequestId"`
+	Repository    Repository `json:"repository"`
+}
+
+// PullRequestIteration is the API message for an iteration of a pull request.
+type PullRequestIteration struct {
+	ID              int `json:"id"`
+	SourceRefCommit struct {
+		CommitID string `json:"commitId"`
+	} `json:"sourceRefCommit"`
+}
+
+// PullRequestIterationList is the API message for a list of iterations of a pull request.
+type PullRequestIterationList struct {
+	Count int                    `json:"count"`
+	Value []PullRequestIteration `json:"value"`
+}
+
+// PullRequestIterationChanges is the API message for the changes of an iteration of a pull request.
+type PullRequestIterationChanges struct {
+	ChangeEntries []Change `json:"changeEntries"`
+	NextSkip      int      `json:"nextSkip"`
+	NextTop       int      `json:"nextTop"`
+}
+
+// WebhookType is the type of the event of the Azure DevOps service hook.
+type WebhookType string
+
+const (
+	// WebhookPush is the webhook type for push.
+	WebhookPush WebhookType = "git.push"
+)
+
+// WebhookPublisherInputs is the API message for the publisher inputs of a service hook subscription.
+type WebhookPublisherInputs struct {
+	// ProjectID is the ID of the project of the repository. It is filled by the
+	// provider if empty.
+	ProjectID string `json:"projectId"`
+	// Repository is the ID of the repository. It is filled by the provider if empty.
+	Repository string `json:"repository"`
+	// Branch filters the events by the branch, empty means any branch.
+	Branch string `json:"branch,omitempty"`
+}
+
+// WebhookConsumerInputs is the API message for the consumer inputs of a web hooks service hook subscription.
+type WebhookConsumerInputs struct {
+	URL string `json:"url"`
+	// Azure DevOps does not sign the payloads, the basic authentication
+	// credentials are sent with every request instead.
+	BasicAuthUsername string `json:"basicAuthUsername,omitempty"`
+	BasicAuthPassword string `json:"basicAuthPassword,omitempty"`
+}
+
+// WebhookCreateOrUpdate represents an Azure DevOps API request for creating or
+// replacing a service hook subscription.
+type WebhookCreateOrUpdate struct {
+	// PublisherID is "tfs" for Azure DevOps events.
+	PublisherID     string      `json:"publisherId"`
+	EventType       WebhookType `json:"eventType"`
+	ResourceVersion string      `json:"resourceVersion"`
+	// ConsumerID is "webHooks" for delivering events to the HTTP endpoint.
+	ConsumerID string `json:"consumerId"`
+	// ConsumerActionID is "httpRequest" for the web hooks consumer.
+	ConsumerActionID string                 `json:"consumerActionId"`
+	PublisherInputs  WebhookPublisherInputs `json:"publisherInputs"`
+	ConsumerInputs   WebhookConsumerInputs  `json:"consumerInputs"`
+}
+
+// WebhookInfo represents an Azure DevOps API response for the service hook subscription.
+type WebhookInfo struct {
+	ID string `json:"id"`
+}
+
+// WebhookIdentity is the API message for the identity in the webhook.
+type WebhookIdentity struct {
+	DisplayName string `json:"displayName"`
+	UniqueName  string `json:"uniqueName"`
+}
+
+// WebhookCommit is the API message for the commit in the webhook push event.
+type WebhookCommit struct {
+	CommitID string     `json:"commitId"`
+	Author   CommitUser `json:"author"`
+	Comment  string     `json:"comment"`
+	URL      string     `json:"url"`
+}
+
+// WebhookPushResource is the API message for the resource of the webhook push event.
+type WebhookPushResource struct {
+	Commits    []WebhookCommit `json:"commits"`
+	RefUpdates []RefUpdate     `json:"refUpdates"`
+	Repository Repository      `json:"repository"`
+	PushedBy   WebhookIdentity `json:"pushedBy"`
+}
+
+// WebhookPushEvent is the API message for the webhook push event.
+type WebhookPushEvent struct {
+	SubscriptionID string              `json:"subscriptionId"`
+	EventType      WebhookType         `json:"eventType"`
+	Resource       WebhookPushResource `json:"resource"`
+}
+
+// repositoryAPIURL returns the API URL of the repository with given full path
+// in the form of "{project}/{repository}".
+func (p *Provider) repositoryAPIURL(instanceURL, repositoryID string) string {
+	project, repository := repositoryID, ""
+	if i := strings.Index(repositoryID, "/"); i >= 0 {
+		project, repository = repositoryID[:i], repositoryID[i+1:]
+	}
+	return fmt.Sprintf("%s/%s/_apis/git/repositories/%s", p.APIURL(instanceURL), url.PathEscape(project), url.PathEscape(repository))
+}
+
+// versionDescriptor returns the query parameters of the version descriptor for
+// the ref, which is either a commit ID or a branch name.
+func versionDescriptor(ref string) string {
+	versionType := "branch"
+	if commitIDRegex.MatchString(ref) {
+		versionType = "commit"
+	}
+	return fmt.Sprintf("versionDescriptor.version=%s&versionDescriptor.versionType=%s", url.QueryEscape(ref), versionType)
+}
+
+// TryLogin tries to fetch the user info from the current OAuth context.
+//
+// Docs: https://learn.microsoft.com/en-us/azure/devops/integrate/concepts/rest-api-versioning
+func (p *Provider) TryLogin(ctx context.Context, oauthCtx common.OauthContext, instanceURL string) (*vcs.UserInfo, error) {
+	url := fmt.Sprintf("%s/_apis/connectionData", p.APIURL(instanceURL))
+	code, _, body, err := oauth.Get(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		tokenRefresher(
+			oauthContext{
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "GET %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return nil, common.Errorf(common.NotFound, "failed to read user info from URL %s", url)
+	} else if code >= 300 {
+		return nil, errors.Errorf("failed to read user info from URL %s, status code: %d, body: %s", url, code, body)
+	}
+
+	var connectionData ConnectionData
+	if err := json.Unmarshal([]byte(body), &connectionData); err != nil {
+		return nil, errors.Wrap(err, "unmarshal")
+	}
+	return &vcs.UserInfo{
+		PublicEmail: connectionData.AuthenticatedUser.Properties.Account.Value,
+		Name:        connectionData.AuthenticatedUser.ProviderDisplayName,
+		State:       vcs.StateActive,
+	}, nil
+}
+
+// FetchCommitByID fetches the commit data by its ID from the repository,
+// including the files added and modified by the commit.
+//
+// Docs: https://learn.microsoft.com/en-us/rest/api/azure/devops/git/commits/get
+func (p *Provider) FetchCommitByID(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, commitID string) (*vcs.Commit, error) {
+	url := fmt.Sprintf("%s/commits/%s?changeCount=%d&api-version=%s", p.repositoryAPIURL(instanceURL, repositoryID), commitID, apiPageSize, apiVersion)
+	code, _, body, err := oauth.Get(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		tokenRefresher(
+			oauthContext{
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "GET %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return nil, common.Errorf(common.NotFound, "failed to fetch commit data from URL %s", url)
+	} else if code >= 300 {
+		return nil, errors.Errorf("failed to fetch commit data from URL %s, status code: %d, body: %s", url, code, body)
+	}
+
+	commit := &Commit{}
+	if err := json.Unmarshal([]byte(body), commit); err != nil {
+		return nil, errors.Wrap(err, "unmarshal body")
+	}
+
+	vcsCommit := &vcs.Commit{
+		ID:          commit.CommitID,
+		Title:       commitTitle(commit.Comment),
+		Message:     commit.Comment,
+		CreatedTs:   commit.Author.Date.Unix(),
+		URL:         commit.RemoteURL,
+		AuthorName:  commit.Author.Name,
+		AuthorEmail: commit.Author.Email,
+	}
+	for _, change := range commit.Changes {
+		if change.Item.GitObjectType != "blob" {
+			continue
+		}
+		filePath := strings.TrimPrefix(change.Item.Path, "/")
+		switch {
+		case change.hasChangeType("add"), change.hasChangeType("rename"):
+			vcsCommit.AddedList = append(vcsCommit.AddedList, filePath)
+		case change.hasChangeType("edit"):
+			vcsCommit.ModifiedList = append(vcsCommit.ModifiedList, filePath)
+		}
+	}
+	return vcsCommit, nil
+}
+
+// FetchUserInfo fetches user info of given user ID.
+//
+// Azure DevOps does not support fetching other users by the name through the
+// Git API, thus this is not supported.
+func (*Provider) FetchUserInfo(_ context.Context, _ common.OauthContext, _, _ string) (*vcs.UserInfo, error) {
+	return nil, errors.New("fetching user info is not supported for Azure DevOps")
+}
+
+// FetchRepositoryActiveMemberList fetches all active members of a repository.
+//
+// Azure DevOps manages repository permissions through security groups of the
+// project instead of repository members, thus syncing members is not supported.
+func (*Provider) FetchRepositoryActiveMemberList(_ context.Context, _ common.OauthContext, _, _ string) ([]*vcs.RepositoryMember, error) {
+	return nil, errors.New("Azure DevOps manages repository permissions through security groups, syncing repository members is not supported")
+}
+
+// oauthResponse is an Azure DevOps OAuth response.
+type oauthResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	// ExpiresIn is returned as a string by Azure DevOps.
+	ExpiresIn        json.Number `json:"expires_in"`
+	Error            string      `json:"error,omitempty"`
+	ErrorDescription string      `json:"error_description,omitempty"`
+}
+
+// toVCSOAuthToken converts the response to *vcs.OAuthToken.
+func (o oauthResponse) toVCSOAuthToken() *vcs.OAuthToken {
+	expiresIn, _ := o.ExpiresIn.Int64()
+	oauthToken := &vcs.OAuthToken{
+		AccessToken:  o.AccessToken,
+		RefreshToken: o.RefreshToken,
+		ExpiresIn:    expiresIn,
+		CreatedAt:    time.Now().Unix(),
+	}
+	if oauthToken.ExpiresIn != 0 {
+		oauthToken.ExpiresTs = oauthToken.CreatedAt + oauthToken.ExpiresIn
+	}
+	return oauthToken
+}
+
+// oauthContext is the request context for refreshing oauth token.
+type oauthContext struct {
+	ClientSecret string
+	RefreshToken string
+}
+
+// requestOAuthToken requests the OAuth token endpoint with the client secret
+// and the grant.
+func requestOAuthToken(ctx context.Context, client *http.Client, clientSecret string, grant url.Values) (*oauthResponse, error) {
+	grant.Set("client_assertion_type", "urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
+	grant.Set("client_assertion", clientSecret)
+
+	url := fmt.Sprintf("%s/oauth2/token", oauthURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(grant.Encode()))
+	if err != nil {
+		return nil, errors.Wrapf(err, "construct POST %s", url)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "POST %s", url)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read OAuth response body, code %v", resp.StatusCode)
+	}
+
+	oauthResp := new(oauthResponse)
+	if err := json.Unmarshal(body, oauthResp); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal OAuth response body, code %v", resp.StatusCode)
+	}
+	if oauthResp.Error != "" {
+		return nil, errors.Errorf("failed to request OAuth token, error: %v, error_description: %v", oauthResp.Error, oauthResp.ErrorDescription)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("non-200 POST %s status code %d with body %q", url, resp.StatusCode, body)
+	}
+	return oauthResp, nil
+}
+
+// ExchangeOAuthToken exchanges OAuth content with the provided authorization code.
+//
+// If the client secret is empty, i.e. the VCS provider is registered without an
+// OAuth application, the code is treated as a personal access token (PAT) and
+// is verified against the instance. This is the only way to authenticate with
+// Azure DevOps Server which does not support OAuth.
+//
+// Docs: https://learn.microsoft.com/en-us/azure/devops/integrate/get-started/authentication/oauth
+func (p *Provider) ExchangeOAuthToken(ctx context.Context, instanceURL string, oauthExchange *common.OAuthExchange) (*vcs.OAuthToken, error) {
+	if oauthExchange.ClientSecret == "" {
+		token := oauth.BasicAuthToken("", oauthExchange.Code)
+		if _, err := p.TryLogin(ctx, common.OauthContext{AccessToken: token}, instanceURL); err != nil {
+			return nil, errors.Wrap(err, "failed to verify the personal access token")
+		}
+		return &vcs.OAuthToken{
+			AccessToken: token,
+			CreatedAt:   time.Now().Unix(),
+		}, nil
+	}
+
+	grant := url.Values{}
+	grant.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+	grant.Set("assertion", oauthExchange.Code)
+	grant.Set("redirect_uri", oauthExchange.RedirectURL)
+	oauthResp, err := requestOAuthToken(ctx, p.client, oauthExchange.ClientSecret, grant)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to exchange OAuth token")
+	}
+	return oauthResp.toVCSOAuthToken(), nil
+}
+
+// FetchAllRepositoryList fetches all repositories in the organization that the
+// authenticated user has access to.
+//
+// Docs: https://learn.microsoft.com/en-us/rest/api/azure/devops/git/repositories/list
+func (p *Provider) FetchAllRepositoryList(ctx context.Context, oauthCtx common.OauthContext, instanceURL string) ([]*vcs.Repository, error) {
+	url := fmt.Sprintf("%s/_apis/git/repositories?api-version=%s", p.APIURL(instanceURL), apiVersion)
+	code, _, body, err := oauth.Get(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		tokenRefresher(
+			oauthContext{
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "GET %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return nil, common.Errorf(common.NotFound, "failed to fetch repository list from URL %s", url)
+	} else if code >= 300 {
+		return nil, errors.Errorf("failed to fetch repository list from URL %s, status code: %d, body: %s", url, code, body)
+	}
+
+	var repoList RepositoryList
+	if err := json.Unmarshal([]byte(body), &repoList); err != nil {
+		return nil, errors.Wrap(err, "unmarshal")
+	}
+
+	var allRepos []*vcs.Repository
+	for _, r := range repoList.Value {
+		allRepos = append(allRepos,
+			&vcs.Repository{
+				// Azure DevOps repositories are identified by GUIDs, the full path is used as the external ID.
+				Name:     r.Name,
+				FullPath: r.fullPath(),
+				WebURL:   r.webURL(),
+			},
+		)
+	}
+	return allRepos, nil
+}
+
+// FetchRepositoryFileList fetches the all files from the given repository tree
+// recursively.
+//
+// Docs: https://learn.microsoft.com/en-us/rest/api/azure/devops/git/items/list
+func (p *Provider) FetchRepositoryFileList(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, ref, filePath string) ([]*vcs.RepositoryTreeNode, error) {
+	url := fmt.Sprintf("%s/items?scopePath=%s&recursionLevel=Full&%s&api-version=%s",
+		p.repositoryAPIURL(instanceURL, repositoryID),
+		url.QueryEscape("/"+strings.Trim(filePath, "/")),
+		versionDescriptor(ref),
+		apiVersion,
+	)
+	code, _, body, err := oauth.Get(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		tokenRefresher(
+			oauthContext{
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "GET %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return nil, common.Errorf(common.NotFound, "failed to fetch repository file list from URL %s", url)
+	} else if code >= 300 {
+		return nil, errors.Errorf("failed to fetch repository file list from URL %s, status code: %d, body: %s", url, code, body)
+	}
+
+	var itemList ItemList
+	if err := json.Unmarshal([]byte(body), &itemList); err != nil {
+		return nil, errors.Wrap(err, "unmarshal body")
+	}
+
+	var allTreeNodes []*vcs.RepositoryTreeNode
+	for _, item := range itemList.Value {
+		if item.GitObjectType != "blob" {
+			continue
+		}
+		allTreeNodes = append(allTreeNodes,
+			&vcs.RepositoryTreeNode{
+				Path: strings.TrimPrefix(item.Path, "/"),
+				Type: item.GitObjectType,
+			},
+		)
+	}
+	return allTreeNodes, nil
+}
+
+// CreateFile creates a file at given path in the repository.
+//
+// Docs: https://learn.microsoft.com/en-us/rest/api/azure/devops/git/pushes/create
+func (p *Provider) CreateFile(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, filePath string, fileCommitCreate vcs.FileCommitCreate) error {
+	changeType := "add"
+	if fileCommitCreate.LastCommitID != "" {
+		changeType = "edit"
+	}
+	return p.pushFile(ctx, oauthCtx, instanceURL, repositoryID, filePath, fileCommitCreate, changeType)
+}
+
+// OverwriteFile overwrites an existing file at given path in the repository.
+//
+// Docs: https://learn.microsoft.com/en-us/rest/api/azure/devops/git/pushes/create
+func (p *Provider) OverwriteFile(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, filePath string, fileCommitCreate vcs.FileCommitCreate) error {
+	return p.pushFile(ctx, oauthCtx, instanceURL, repositoryID, filePath, fileCommitCreate, "edit")
+}
+
+// pushFile pushes a commit with the change of the file to the branch. Azure
+// DevOps requires the current commit of the branch as the old object ID of the
+// push, which is fetched first.
+func (p *Provider) pushFile(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, filePath string, fileCommitCreate vcs.FileCommitCreate, changeType string) error {
+	branch, err := p.GetBranch(ctx, oauthCtx, instanceURL, repositoryID, fileCommitCreate.Branch)
+	if err != nil {
+		return errors.Wrapf(err, "get branch %q", fileCommitCreate.Branch)
+	}
+
+	change := ItemChange{
+		ChangeType: changeType,
+		NewContent: ItemContent{
+			Content:     fileCommitCreate.Content,
+			ContentType: "rawtext",
+		},
+	}
+	change.Item.Path = "/" + strings.TrimPrefix(filePath, "/")
+	body, err := json.Marshal(
+		PushCreate{
+			RefUpdates: []RefUpdate{
+				{
+					Name:        "refs/heads/" + fileCommitCreate.Branch,
+					OldObjectID: branch.LastCommitID,
+				},
+			},
+			Commits: []PushCommit{
+				{
+					Comment: fileCommitCreate.CommitMessage,
+					Changes: []ItemChange{change},
+				},
+			},
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, "marshal push")
+	}
+
+	url := fmt.Sprintf("%s/pushes?api-version=%s", p.repositoryAPIURL(instanceURL, repositoryID), apiVersion)
+	code, _, resp, err := oauth.Post(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		bytes.NewReader(body),
+		tokenRefresher(
+			oauthContext{
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return errors.Wrapf(err, "POST %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return common.Errorf(common.NotFound, "failed to create/update file through URL %s", url)
+	} else if code >= 300 {
+		return errors.Errorf("failed to create/update file through URL %s, status code: %d, body: %s",
+			url,
+			code,
+			resp,
+		)
+	}
+	return nil
+}
+
+// ReadFileMeta reads the metadata of the given file in the repository.
+//
+// NOTE: The LastCommitID is the ID of the last commit that changed the file.
+//
+// Docs: https://learn.microsoft.com/en-us/rest/api/azure/devops/git/items/get
+func (p *Provider) ReadFileMeta(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, filePath, ref string) (*vcs.FileMeta, error) {
+	item, err := p.readFile(ctx, oauthCtx, instanceURL, repositoryID, filePath, ref)
+	if err != nil {
+		return nil, errors.Wrap(err, "read file")
+	}
+
+	itemPath := strings.TrimPrefix(item.Path, "/")
+	return &vcs.FileMeta{
+		Name:         path.Base(itemPath),
+		Path:         itemPath,
+		Size:         int64(len(item.Content)),
+		LastCommitID: item.CommitID,
+	}, nil
+}
+
+// ReadFileContent reads the content of the given file in the repository.
+//
+// Docs: https://learn.microsoft.com/en-us/rest/api/azure/devops/git/items/get
+func (p *Provider) ReadFileContent(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, filePath, ref string) (string, error) {
+	item, err := p.readFile(ctx, oauthCtx, instanceURL, repositoryID, filePath, ref)
+	if err != nil {
+		return "", errors.Wrap(err, "read file")
+	}
+	return item.Content, nil
+}
+
+// readFile reads the given file in the repository with its content.
+func (p *Provider) readFile(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, filePath, ref string) (*Item, error) {
+	url := fmt.Sprintf("%s/items?path=%s&includeContent=true&$format=json&%s&api-version=%s",
+		p.repositoryAPIURL(instanceURL, repositoryID),
+		url.QueryEscape("/"+strings.TrimPrefix(filePath, "/")),
+		versionDescriptor(ref),
+		apiVersion,
+	)
+	code, _, body, err := oauth.Get(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		tokenRefresher(
+			oauthContext{
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "GET %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return nil, common.Errorf(common.NotFound, "failed to read file from URL %s", url)
+	} else if code >= 300 {
+		return nil, errors.Errorf("failed to read file from URL %s, status code: %d, body: %s", url, code, body)
+	}
+
+	var item Item
+	if err := json.Unmarshal([]byte(body), &item); err != nil {
+		return nil, errors.Wrap(err, "unmarshal body")
+	}
+	if item.IsFolder || item.GitObjectType == "tree" {
+		return nil, errors.Errorf("%q is a directory not a file", filePath)
+	}
+	return &item, nil
+}
+
+// ListPullRequestFile lists the changed files in the latest iteration of the
+// pull request.
+//
+// Docs: https://learn.microsoft.com/en-us/rest/api/azure/devops/git/pull-request-iteration-changes/get
+func (p *Provider) ListPullRequestFile(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, pullRequestID string) ([]*vcs.PullRequestFile, error) {
+	iteration, err := p.getLatestPullRequestIteration(ctx, oauthCtx, instanceURL, repositoryID, pullRequestID)
+	if err != nil {
+		return nil, errors.Wrap(err, "get latest pull request iteration")
+	}
+
+	var allChanges []Change
+	skip := 0
+	for {
+		changes, err := p.listPaginatedPullRequestIterationChanges(ctx, oauthCtx, instanceURL, repositoryID, pullRequestID, iteration.ID, skip)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to list pull request file")
+		}
+		allChanges = append(allChanges, changes.ChangeEntries...)
+
+		if changes.NextTop == 0 {
+			break
+		}
+		skip = changes.NextSkip
+	}
+
+	var res []*vcs.PullRequestFile
+	for _, change := range allChanges {
+		if change.Item.GitObjectType == "tree" || change.Item.IsFolder {
+			continue
+		}
+		res = append(res, &vcs.PullRequestFile{
+			Path:         strings.TrimPrefix(change.Item.Path, "/"),
+			LastCommitID: iteration.SourceRefCommit.CommitID,
+			IsDeleted:    change.hasChangeType("delete"),
+		})
+	}
+	return res, nil
+}
+
+// getLatestPullRequestIteration gets the latest iteration of the pull request,
+// which is created for every push to the source branch.
+//
+// Docs: https://learn.microsoft.com/en-us/rest/api/azure/devops/git/pull-request-iterations/list
+func (p *Provider) getLatestPullRequestIteration(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, pullRequestID string) (*PullRequestIteration, error) {
+	url := fmt.Sprintf("%s/pullRequests/%s/iterations?api-version=%s", p.repositoryAPIURL(instanceURL, repositoryID), pullRequestID, apiVersion)
+	code, _, body, err := oauth.Get(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		tokenRefresher(
+			oauthContext{
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "GET %s", url)
+	}
+	if code == http.StatusNotFound {
+		return nil, common.Errorf(common.NotFound, "failed to list pull request iterations from URL %s", url)
+	} else if code >= 300 {
+		return nil, errors.Errorf("failed to list pull request iterations from URL %s, status code: %d, body: %s", url, code, body)
+	}
+
+	var iterations PullRequestIterationList
+	if err := json.Unmarshal([]byte(body), &iterations); err != nil {
+		return nil, errors.Wrap(err, "unmarshal body")
+	}
+	if len(iterations.Value) == 0 {
+		return nil, errors.Errorf("pull request %s has no iteration", pullRequestID)
+	}
+
+	latest := iterations.Value[0]
+	for _, iteration := range iterations.Value {
+		if iteration.ID > latest.ID {
+			latest = iteration
+		}
+	}
+	return &latest, nil
+}
+
+// listPaginatedPullRequestIterationChanges lists the changes of the pull
+// request iteration compared with the target branch with pagination.
+func (p *Provider) listPaginatedPullRequestIterationChanges(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, pullRequestID string, iterationID, skip int) (*PullRequestIterationChanges, error) {
+	url := fmt.Sprintf("%s/pullRequests/%s/iterations/%d/changes?$top=%d&$skip=%d&$compareTo=0&api-version=%s", p.repositoryAPIURL(instanceURL, repositoryID), pullRequestID, iterationID, apiPageSize, skip, apiVersion)
+	code, _, body, err := oauth.Get(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		tokenRefresher(
+			oauthContext{
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "GET %s", url)
+	}
+	if code == http.StatusNotFound {
+		return nil, common.Errorf(common.NotFound, "failed to list pull request file from URL %s", url)
+	} else if code >= 300 {
+		return nil, errors.Errorf("failed to list pull request file from URL %s, status code: %d, body: %s", url, code, body)
+	}
+
+	changes := new(PullRequestIterationChanges)
+	if err := json.Unmarshal([]byte(body), changes); err != nil {
+		return nil, errors.Wrap(err, "unmarshal body")
+	}
+	return changes, nil
+}
+
+// GetBranch gets the given branch in the repository.
+//
+// Docs: https://learn.microsoft.com/en-us/rest/api/azure/devops/git/refs/list
+func (p *Provider) GetBranch(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, branchName string) (*vcs.BranchInfo, error) {
+	url := fmt.Sprintf("%s/refs?filter=%s&api-version=%s", p.repositoryAPIURL(instanceURL, repositoryID), url.QueryEscape("heads/"+branchName), apiVersion)
+	code, _, body, err := oauth.Get(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		tokenRefresher(
+			oauthContext{
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "GET %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return nil, common.Errorf(common.NotFound, "failed to get branch from URL %s", url)
+	} else if code >= 300 {
+		return nil, errors.Errorf("failed to get branch from URL %s, status code: %d, body: %s", url, code, body)
+	}
+
+	var refList RefList
+	if err := json.Unmarshal([]byte(body), &refList); err != nil {
+		return nil, errors.Wrap(err, "unmarshal body")
+	}
+
+	// The filter matches the refs by prefix.
+	refName := "refs/heads/" + branchName
+	for _, ref := range refList.Value {
+		if ref.Name == refName {
+			return &vcs.BranchInfo{
+				Name:         branchName,
+				LastCommitID: ref.ObjectID,
+			}, nil
+		}
+	}
+	return nil, common.Errorf(common.NotFound, "branch %q not found from URL %s", branchName, url)
+}
+
+// CreateBranch creates the branch in the repository.
+//
+// Docs: https://learn.microsoft.com/en-us/rest/api/azure/devops/git/refs/update-refs
+func (p *Provider) CreateBranch(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID string, branch *vcs.BranchInfo) error {
+	body, err := json.Marshal(
+		[]RefUpdate{
+			{
+				Name:        "refs/heads/" + branch.Name,
+				OldObjectID: emptyObjectID,
+				NewObjectID: branch.LastCommitID,
+			},
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, "marshal branch create")
+	}
+
+	url := fmt.Sprintf("%s/refs?api-version=%s", p.repositoryAPIURL(instanceURL, repositoryID), apiVersion)
+	code, _, resp, err := oauth.Post(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		bytes.NewReader(body),
+		tokenRefresher(
+			oauthContext{
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return errors.Wrapf(err, "POST %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return common.Errorf(common.NotFound, "failed to create branch from URL %s", url)
+	} else if code >= 300 {
+		return errors.Errorf("failed to create branch from URL %s, status code: %d, body: %s", url, code, resp)
+	}
+
+	var results RefUpdateResultList
+	if err := json.Unmarshal([]byte(resp), &results); err != nil {
+		return errors.Wrap(err, "unmarshal body")
+	}
+	for _, result := range results.Value {
+		if !result.Success {
+			return errors.Errorf("failed to create branch %q, status: %s", result.Name, result.UpdateStatus)
+		}
+	}
+	return nil
+}
+
+// CreatePullRequest creates the pull request in the repository.
+//
+// Docs: https://learn.microsoft.com/en-us/rest/api/azure/devops/git/pull-requests/create
+func (p *Provider) CreatePullRequest(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID string, pullRequestCreate *vcs.PullRequestCreate) (*vcs.PullRequest, error) {
+	body, err := json.Marshal(
+		PullRequestCreate{
+			SourceRefName: "refs/heads/" + pullRequestCreate.Head,
+			TargetRefName: "refs/heads/" + pullRequestCreate.Base,
+			Title:         pullRequestCreate.Title,
+			Description:   pullRequestCreate.Body,
+			CompletionOptions: PullRequestCompletionOptions{
+				DeleteSourceBranch: pullRequestCreate.RemoveHeadAfterMerged,
+			},
+		},
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal pull request create")
+	}
+
+	url := fmt.Sprintf("%s/pullrequests?api-version=%s", p.repositoryAPIURL(instanceURL, repositoryID), apiVersion)
+	code, _, resp, err := oauth.Post(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		bytes.NewReader(body),
+		tokenRefresher(
+			oauthContext{
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "POST %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return nil, common.Errorf(common.NotFound, "failed to create pull request from URL %s", url)
+	} else if code >= 300 {
+		return nil, errors.Errorf("failed to create pull request from URL %s, status code: %d, body: %s", url, code, resp)
+	}
+
+	var res PullRequest
+	if err := json.Unmarshal([]byte(resp), &res); err != nil {
+		return nil, err
+	}
+
+	return &vcs.PullRequest{
+		URL: fmt.Sprintf("%s/pullrequest/%d", res.Repository.webURL(), res.PullRequestID),
+	}, nil
+}
+
+// CreateCommitStatus creates the status of the commit.
+func (*Provider) CreateCommitStatus(_ context.Context, _ common.OauthContext, _, _, _ string, _ *vcs.CommitStatus) error {
+	return vcs.ErrNotImplemented
+}
+
+// UpsertPullRequestReview posts the review with inline comments to the pull request.
+func (*Provider) UpsertPullRequestReview(_ context.Context, _ common.OauthContext, _, _, _ string, _ *vcs.PullRequestReview) error {
+	return vcs.ErrNotImplemented
+}
+
+// UpsertEnvironmentVariable creates or updates the environment variable in the repository.
+//
+// Azure Pipelines keeps the secret variables in the pipeline definitions or the
+// variable groups of the project rather than the repository, thus this is not
+// supported.
+func (*Provider) UpsertEnvironmentVariable(_ context.Context, _ common.OauthContext, _, _, _, _ string) error {
+	return errors.New("Azure DevOps does not support repository environment variables")
+}
+
+// completeWebhookPayload fills the project ID and the repository ID of the
+// service hook subscription if missing, because the subscriptions are created
+// for the organization and the repository is identified by the GUID.
+func (p *Provider) completeWebhookPayload(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID string, payload []byte) ([]byte, error) {
+	var webhook WebhookCreateOrUpdate
+	if err := json.Unmarshal(payload, &webhook); err != nil {
+		return nil, errors.Wrap(err, "unmarshal webhook payload")
+	}
+	if webhook.PublisherInputs.ProjectID != "" && webhook.PublisherInputs.Repository != "" {
+		return payload, nil
+	}
+
+	repo, err := p.getRepository(ctx, oauthCtx, instanceURL, repositoryID)
+	if err != nil {
+		return nil, errors.Wrap(err, "get repository")
+	}
+	webhook.PublisherInputs.ProjectID = repo.Project.ID
+	webhook.PublisherInputs.Repository = repo.ID
+	return json.Marshal(webhook)
+}
+
+// getRepository gets the repository.
+//
+// Docs: https://learn.microsoft.com/en-us/rest/api/azure/devops/git/repositories/get-repository
+func (p *Provider) getRepository(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID string) (*Repository, error) {
+	url := fmt.Sprintf("%s?api-version=%s", p.repositoryAPIURL(instanceURL, repositoryID), apiVersion)
+	code, _, body, err := oauth.Get(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		tokenRefresher(
+			oauthContext{
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "GET %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return nil, common.Errorf(common.NotFound, "failed to get repository from URL %s", url)
+	} else if code >= 300 {
+		return nil, errors.Errorf("failed to get repository from URL %s, status code: %d, body: %s", url, code, body)
+	}
+
+	repo := new(Repository)
+	if err := json.Unmarshal([]byte(body), repo); err != nil {
+		return nil, errors.Wrap(err, "unmarshal body")
+	}
+	return repo, nil
+}
+
+// CreateWebhook creates a service hook subscription for the repository with
+// given payload.
+//
+// Docs: https://learn.microsoft.com/en-us/rest/api/azure/devops/hooks/subscriptions/create
+func (p *Provider) CreateWebhook(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID string, payload []byte) (string, error) {
+	payload, err := p.completeWebhookPayload(ctx, oauthCtx, instanceURL, repositoryID, payload)
+	if err != nil {
+		return "", err
+	}
+
+	url := fmt.Sprintf("%s/_apis/hooks/subscriptions?api-version=%s", p.APIURL(instanceURL), apiVersion)
+	code, _, body, err := oauth.Post(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		bytes.NewReader(payload),
+		tokenRefresher(
+			oauthContext{
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return "", errors.Wrapf(err, "POST %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return "", common.Errorf(common.NotFound, "failed to create webhook through URL %s", url)
+	} else if code >= 300 {
+		return "", errors.Errorf("failed to create webhook through URL %s, status code: %d, body: %s", url, code, body)
+	}
+
+	var webhookInfo WebhookInfo
+	if err = json.Unmarshal([]byte(body), &webhookInfo); err != nil {
+		return "", errors.Wrap(err, "unmarshal body")
+	}
+	return webhookInfo.ID, nil
+}
+
+// PatchWebhook replaces the service hook subscription with given payload.
+//
+// Docs: https://learn.microsoft.com/en-us/rest/api/azure/devops/hooks/subscriptions/replace-subscription
+func (p *Provider) PatchWebhook(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, webhookID string, payload []byte) error {
+	payload, err := p.completeWebhookPayload(ctx, oauthCtx, instanceURL, repositoryID, payload)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/_apis/hooks/subscriptions/%s?api-version=%s", p.APIURL(instanceURL), webhookID, apiVersion)
+	code, _, body, err := oauth.Put(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		bytes.NewReader(payload),
+		tokenRefresher(
+			oauthContext{
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return errors.Wrapf(err, "PUT %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return common.Errorf(common.NotFound, "failed to patch webhook through URL %s", url)
+	} else if code >= 300 {
+		return errors.Errorf("failed to patch webhook through URL %s, status code: %d, body: %s", url, code, body)
+	}
+	return nil
+}
+
+// DeleteWebhook deletes the service hook subscription.
+//
+// Docs: https://learn.microsoft.com/en-us/rest/api/azure/devops/hooks/subscriptions/delete
+func (p *Provider) DeleteWebhook(ctx context.Context, oauthCtx common.OauthContext, instanceURL, _, webhookID string) error {
+	url := fmt.Sprintf("%s/_apis/hooks/subscriptions/%s?api-version=%s", p.APIURL(instanceURL), webhookID, apiVersion)
+	code, _, body, err := oauth.Delete(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		tokenRefresher(
+			oauthContext{
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return errors.Wrapf(err, "DELETE %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return nil // It is OK if the webhook has already gone
+	} else if code >= 300 {
+		return errors.Errorf("failed to delete webhook through URL %s, status code: %d, body: %s", url, code, body)
+	}
+	return nil
+}
+
+func tokenRefresher(oauthCtx oauthContext, refresher common.TokenRefresher) oauth.TokenRefresher {
+	return func(ctx context.Context, client *http.Client, oldToken *string) error {
+		// Personal access tokens cannot be refreshed, they must be replaced by the user.
+		if oauth.IsBasicAuthToken(*oldToken) || oauthCtx.RefreshToken == "" {
+			return errors.New("the personal access token has expired or been revoked, please update the access token of the repository")
+		}
+
+		grant := url.Values{}
+		grant.Set("grant_type", "refresh_token")
+		grant.Set("assertion", oauthCtx.RefreshToken)
+		r, err := requestOAuthToken(ctx, client, oauthCtx.ClientSecret, grant)
+		if err != nil {
+			return errors.Wrap(err, "failed to refresh OAuth token")
+		}
+
+		// Update the old token to new value for retries.
+		*oldToken = r.AccessToken
+
+		token := r.toVCSOAuthToken()
+		return refresher(token.AccessToken, token.RefreshToken, token.ExpiresTs)
+	}
+}
+
+// commitTitle returns the title of the commit message. Per Git convention, the
+// message title and body are separated by two new line characters.
+func commitTitle(message string) string {
+	return strings.TrimSpace(strings.SplitN(message, "\n\n", 2)[0])
+}
+
+// ToVCS returns the push event in VCS format.
+//
+// NOTE: Azure DevOps does not include the changed files in the push event,
+// they have to be fetched for each commit.
+func (p WebhookPushEvent) ToVCS() vcs.PushEvent {
+	var ref string
+	for _, refUpdate := range p.Resource.RefUpdates {
+		if strings.HasPrefix(refUpdate.Name, "refs/heads/") {
+			ref = refUpdate.Name
+			break
+		}
+	}
+
+	repo := p.Resource.Repository
+	// The commits are listed in reverse chronological order.
+	var commitList []vcs.Commit
+	for i := len(p.Resource.Commits) - 1; i >= 0; i-- {
+		commit := p.Resource.Commits[i]
+		commitList = append(commitList, vcs.Commit{
+			ID:          commit.CommitID,
+			Title:       commitTitle(commit.Comment),
+			Message:     commit.Comment,
+			CreatedTs:   commit.Author.Date.Unix(),
+			URL:         fmt.Sprintf("%s/commit/%s", repo.webURL(), commit.CommitID),
+			AuthorName:  commit.Author.Name,
+			AuthorEmail: commit.Author.Email,
+		})
+	}
+	return vcs.PushEvent{
+		Ref:                ref,
+		RepositoryID:       repo.fullPath(),
+		RepositoryURL:      repo.webURL(),
+		RepositoryFullPath: repo.fullPath(),
+		AuthorName:         p.Resource.PushedBy.DisplayName,
+		CommitList:         commitList,
+	}
+}
@@ -0,0 +1,670 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/vcs"
+	"github.com/bytebase/bytebase/plugin/vcs/internal/oauth"
+)
+
+const testInstanceURL = "https://dev.azure.com/fabrikam"
+
+func TestProvider_TryLogin(t *testing.T) {
+	p := newProvider(
+		vcs.ProviderConfig{
+			Client: &http.Client{
+				Transport: &common.MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						assert.Equal(t, "/fabrikam/_apis/connectionData", r.URL.Path)
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body: io.NopCloser(strings.NewReader(`
+{
+  "authenticatedUser": {
+    "id": "0bf1a8c5-4a6b-4e21-a2a3-6ff2b1c6e2d1",
+    "providerDisplayName": "Jamal Hartnett",
+    "properties": {
+      "Account": {"$type": "System.String", "$value": "fabrikamfiber4@hotmail.com"}
+    }
+  }
+}
+`)),
+						}, nil
+					},
+				},
+			},
+		},
+	)
+
+	ctx := context.Background()
+	got, err := p.TryLogin(ctx, common.OauthContext{}, testInstanceURL)
+	require.NoError(t, err)
+
+	want := &vcs.UserInfo{
+		PublicEmail: "fabrikamfiber4@hotmail.com",
+		Name:        "Jamal Hartnett",
+		State:       vcs.StateActive,
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestProvider_FetchCommitByID(t *testing.T) {
+	p := newProvider(
+		vcs.ProviderConfig{
+			Client: &http.Client{
+				Transport: &common.MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						assert.Equal(t, "/fabrikam/Fabrikam-Fiber-Git/_apis/git/repositories/Fabrikam Fiber/commits/be67f8871a4d2c75f13a51c1d3c30ac0d74d4ef4", r.URL.Path)
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body: io.NopCloser(strings.NewReader(`
+{
+  "commitId": "be67f8871a4d2c75f13a51c1d3c30ac0d74d4ef4",
+  "author": {"name": "Jamal Hartnett", "email": "fabrikamfiber4@hotmail.com", "date": "2015-02-25T19:01:00Z"},
+  "comment": "Add migration\n\nCreate the table.",
+  "remoteUrl": "https://dev.azure.com/fabrikam/Fabrikam-Fiber-Git/_git/Fabrikam%20Fiber/commit/be67f8871a4d2c75f13a51c1d3c30ac0d74d4ef4",
+  "changes": [
+    {"item": {"gitObjectType": "tree", "path": "/prod", "isFolder": true}, "changeType": "add"},
+    {"item": {"gitObjectType": "blob", "path": "/prod/v1__create_table.sql"}, "changeType": "add"},
+    {"item": {"gitObjectType": "blob", "path": "/prod/v2__alter_table.sql"}, "changeType": "edit, rename"},
+    {"item": {"gitObjectType": "blob", "path": "/README.md"}, "changeType": "edit"},
+    {"item": {"gitObjectType": "blob", "path": "/LICENSE"}, "changeType": "delete"}
+  ]
+}
+`)),
+						}, nil
+					},
+				},
+			},
+		},
+	)
+
+	ctx := context.Background()
+	got, err := p.FetchCommitByID(ctx, common.OauthContext{}, testInstanceURL, "Fabrikam-Fiber-Git/Fabrikam Fiber", "be67f8871a4d2c75f13a51c1d3c30ac0d74d4ef4")
+	require.NoError(t, err)
+
+	want := &vcs.Commit{
+		ID:           "be67f8871a4d2c75f13a51c1d3c30ac0d74d4ef4",
+		Title:        "Add migration",
+		Message:      "Add migration\n\nCreate the table.",
+		CreatedTs:    1424890860,
+		URL:          "https://dev.azure.com/fabrikam/Fabrikam-Fiber-Git/_git/Fabrikam%20Fiber/commit/be67f8871a4d2c75f13a51c1d3c30ac0d74d4ef4",
+		AuthorName:   "Jamal Hartnett",
+		AuthorEmail:  "fabrikamfiber4@hotmail.com",
+		AddedList:    []string{"prod/v1__create_table.sql", "prod/v2__alter_table.sql"},
+		ModifiedList: []string{"README.md"},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestProvider_ExchangeOAuthToken(t *testing.T) {
+	t.Run("OAuth", func(t *testing.T) {
+		p := newProvider(
+			vcs.ProviderConfig{
+				Client: &http.Client{
+					Transport: &common.MockRoundTripper{
+						MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+							assert.Equal(t, "app.vssps.visualstudio.com", r.URL.Host)
+							assert.Equal(t, "/oauth2/token", r.URL.Path)
+							require.NoError(t, r.ParseForm())
+							assert.Equal(t, "client-secret", r.PostForm.Get("client_assertion"))
+							assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.PostForm.Get("grant_type"))
+							assert.Equal(t, "code", r.PostForm.Get("assertion"))
+							return &http.Response{
+								StatusCode: http.StatusOK,
+								Body: io.NopCloser(strings.NewReader(`
+{
+  "access_token": "eyJ0eXAiOiJKV1QiLCJhbGciOiJSUzI1NiJ9.access",
+  "token_type": "jwt-bearer",
+  "expires_in": "3599",
+  "refresh_token": "eyJ0eXAiOiJKV1QiLCJhbGciOiJSUzI1NiJ9.refresh"
+}
+`)),
+							}, nil
+						},
+					},
+				},
+			},
+		)
+
+		ctx := context.Background()
+		got, err := p.ExchangeOAuthToken(ctx, testInstanceURL,
+			&common.OAuthExchange{
+				ClientID:     "client-id",
+				ClientSecret: "client-secret",
+				Code:         "code",
+				RedirectURL:  "https://bytebase.example.com/oauth/callback",
+			},
+		)
+		require.NoError(t, err)
+		assert.Equal(t, "eyJ0eXAiOiJKV1QiLCJhbGciOiJSUzI1NiJ9.access", got.AccessToken)
+		assert.Equal(t, "eyJ0eXAiOiJKV1QiLCJhbGciOiJSUzI1NiJ9.refresh", got.RefreshToken)
+		assert.Equal(t, int64(3599), got.ExpiresIn)
+		assert.Equal(t, got.CreatedAt+3599, got.ExpiresTs)
+	})
+
+	t.Run("personal access token", func(t *testing.T) {
+		p := newProvider(
+			vcs.ProviderConfig{
+				Client: &http.Client{
+					Transport: &common.MockRoundTripper{
+						MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+							assert.Equal(t, "/fabrikam/_apis/connectionData", r.URL.Path)
+							_, password, ok := r.BasicAuth()
+							if !ok || password != "pat" {
+								return &http.Response{
+									StatusCode: http.StatusUnauthorized,
+									Body:       io.NopCloser(strings.NewReader(``)),
+								}, nil
+							}
+							return &http.Response{
+								StatusCode: http.StatusOK,
+								Body:       io.NopCloser(strings.NewReader(`{"authenticatedUser": {"providerDisplayName": "Jamal Hartnett"}}`)),
+							}, nil
+						},
+					},
+				},
+			},
+		)
+
+		ctx := context.Background()
+		got, err := p.ExchangeOAuthToken(ctx, testInstanceURL, &common.OAuthExchange{Code: "pat"})
+		require.NoError(t, err)
+		assert.Equal(t, oauth.BasicAuthToken("", "pat"), got.AccessToken)
+		assert.Empty(t, got.RefreshToken)
+		assert.Zero(t, got.ExpiresTs)
+
+		_, err = p.ExchangeOAuthToken(ctx, testInstanceURL, &common.OAuthExchange{Code: "invalid"})
+		assert.Error(t, err)
+	})
+}
+
+func TestProvider_FetchAllRepositoryList(t *testing.T) {
+	p := newProvider(
+		vcs.ProviderConfig{
+			Client: &http.Client{
+				Transport: &common.MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						assert.Equal(t, "/fabrikam/_apis/git/repositories", r.URL.Path)
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body: io.NopCloser(strings.NewReader(`
+{
+  "count": 2,
+  "value": [
+    {
+      "id": "5febef5a-833d-4e14-b9c0-14cb638f91e6",
+      "name": "AnotherRepository",
+      "remoteUrl": "https://dev.azure.com/fabrikam/Fabrikam-Fiber-Git/_git/AnotherRepository",
+      "webUrl": "https://dev.azure.com/fabrikam/Fabrikam-Fiber-Git/_git/AnotherRepository",
+      "project": {"id": "6ce954b1-ce1f-45d1-b94d-e6bf2464ba2c", "name": "Fabrikam-Fiber-Git"}
+    },
+    {
+      "id": "278d5cd2-584d-4b63-824a-2ba458937249",
+      "name": "Fabrikam",
+      "remoteUrl": "https://dev.azure.com/fabrikam/Fabrikam-Fiber-TFVC/_git/Fabrikam",
+      "webUrl": "https://dev.azure.com/fabrikam/Fabrikam-Fiber-TFVC/_git/Fabrikam",
+      "project": {"id": "281f9a5b-af0d-49b4-a1df-fe6f5e5f84d0", "name": "Fabrikam-Fiber-TFVC"}
+    }
+  ]
+}
+`)),
+						}, nil
+					},
+				},
+			},
+		},
+	)
+
+	ctx := context.Background()
+	got, err := p.FetchAllRepositoryList(ctx, common.OauthContext{}, testInstanceURL)
+	require.NoError(t, err)
+
+	want := []*vcs.Repository{
+		{
+			Name:     "AnotherRepository",
+			FullPath: "Fabrikam-Fiber-Git/AnotherRepository",
+			WebURL:   "https://dev.azure.com/fabrikam/Fabrikam-Fiber-Git/_git/AnotherRepository",
+		},
+		{
+			Name:     "Fabrikam",
+			FullPath: "Fabrikam-Fiber-TFVC/Fabrikam",
+			WebURL:   "https://dev.azure.com/fabrikam/Fabrikam-Fiber-TFVC/_git/Fabrikam",
+		},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestProvider_FetchRepositoryFileList(t *testing.T) {
+	p := newProvider(
+		vcs.ProviderConfig{
+			Client: &http.Client{
+				Transport: &common.MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						assert.Equal(t, "/fabrikam/Fabrikam-Fiber-Git/_apis/git/repositories/Fabrikam/items", r.URL.Path)
+						assert.Equal(t, "/prod", r.URL.Query().Get("scopePath"))
+						assert.Equal(t, "Full", r.URL.Query().Get("recursionLevel"))
+						assert.Equal(t, "main", r.URL.Query().Get("versionDescriptor.version"))
+						assert.Equal(t, "branch", r.URL.Query().Get("versionDescriptor.versionType"))
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body: io.NopCloser(strings.NewReader(`
+{
+  "count": 3,
+  "value": [
+    {"objectId": "3d21ec53a331a6f037a91c368710b99387d012c1", "gitObjectType": "tree", "path": "/prod", "isFolder": true},
+    {"objectId": "7638417db6d59f3c431d3e1f261cc637155684cd", "gitObjectType": "blob", "path": "/prod/v1__create_table.sql"},
+    {"objectId": "1acc419d4d6a9ce985db7be48c6349a0475975b5", "gitObjectType": "blob", "path": "/prod/v2__alter_table.sql"}
+  ]
+}
+`)),
+						}, nil
+					},
+				},
+			},
+		},
+	)
+
+	ctx := context.Background()
+	got, err := p.FetchRepositoryFileList(ctx, common.OauthContext{}, testInstanceURL, "Fabrikam-Fiber-Git/Fabrikam", "main", "prod")
+	require.NoError(t, err)
+
+	want := []*vcs.RepositoryTreeNode{
+		{Path: "prod/v1__create_table.sql", Type: "blob"},
+		{Path: "prod/v2__alter_table.sql", Type: "blob"},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestProvider_CreateFile(t *testing.T) {
+	p := newProvider(
+		vcs.ProviderConfig{
+			Client: &http.Client{
+				Transport: &common.MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						switch r.URL.Path {
+						case "/fabrikam/Fabrikam-Fiber-Git/_apis/git/repositories/Fabrikam/refs":
+							assert.Equal(t, "heads/main", r.URL.Query().Get("filter"))
+							return &http.Response{
+								StatusCode: http.StatusOK,
+								Body: io.NopCloser(strings.NewReader(`
+{
+  "count": 2,
+  "value": [
+    {"name": "refs/heads/main", "objectId": "be67f8871a4d2c75f13a51c1d3c30ac0d74d4ef4"},
+    {"name": "refs/heads/main-backup", "objectId": "7638417db6d59f3c431d3e1f261cc637155684cd"}
+  ]
+}
+`)),
+							}, nil
+						case "/fabrikam/Fabrikam-Fiber-Git/_apis/git/repositories/Fabrikam/pushes":
+							body, err := io.ReadAll(r.Body)
+							require.NoError(t, err)
+							var push PushCreate
+							require.NoError(t, json.Unmarshal(body, &push))
+
+							require.Len(t, push.RefUpdates, 1)
+							assert.Equal(t, "refs/heads/main", push.RefUpdates[0].Name)
+							assert.Equal(t, "be67f8871a4d2c75f13a51c1d3c30ac0d74d4ef4", push.RefUpdates[0].OldObjectID)
+							require.Len(t, push.Commits, 1)
+							assert.Equal(t, "Create table", push.Commits[0].Comment)
+							require.Len(t, push.Commits[0].Changes, 1)
+							change := push.Commits[0].Changes[0]
+							assert.Equal(t, "add", change.ChangeType)
+							assert.Equal(t, "/prod/v1__create_table.sql", change.Item.Path)
+							assert.Equal(t, ItemContent{Content: "CREATE TABLE t (id INT);", ContentType: "rawtext"}, change.NewContent)
+							return &http.Response{
+								StatusCode: http.StatusCreated,
+								Body:       io.NopCloser(strings.NewReader(`{}`)),
+							}, nil
+						}
+						return nil, errors.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+					},
+				},
+			},
+		},
+	)
+
+	ctx := context.Background()
+	err := p.CreateFile(ctx, common.OauthContext{}, testInstanceURL, "Fabrikam-Fiber-Git/Fabrikam", "prod/v1__create_table.sql",
+		vcs.FileCommitCreate{
+			Branch:        "main",
+			Content:       "CREATE TABLE t (id INT);",
+			CommitMessage: "Create table",
+		},
+	)
+	require.NoError(t, err)
+}
+
+func TestProvider_ReadFileMeta(t *testing.T) {
+	p := newProvider(
+		vcs.ProviderConfig{
+			Client: &http.Client{
+				Transport: &common.MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						assert.Equal(t, "/fabrikam/Fabrikam-Fiber-Git/_apis/git/repositories/Fabrikam/items", r.URL.Path)
+						assert.Equal(t, "/prod/v1__create_table.sql", r.URL.Query().Get("path"))
+						assert.Equal(t, "be67f8871a4d2c75f13a51c1d3c30ac0d74d4ef4", r.URL.Query().Get("versionDescriptor.version"))
+						assert.Equal(t, "commit", r.URL.Query().Get("versionDescriptor.versionType"))
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body: io.NopCloser(strings.NewReader(`
+{
+  "objectId": "7638417db6d59f3c431d3e1f261cc637155684cd",
+  "gitObjectType": "blob",
+  "commitId": "1acc419d4d6a9ce985db7be48c6349a0475975b5",
+  "path": "/prod/v1__create_table.sql",
+  "content": "CREATE TABLE t (id INT);"
+}
+`)),
+						}, nil
+					},
+				},
+			},
+		},
+	)
+
+	ctx := context.Background()
+	got, err := p.ReadFileMeta(ctx, common.OauthContext{}, testInstanceURL, "Fabrikam-Fiber-Git/Fabrikam", "prod/v1__create_table.sql", "be67f8871a4d2c75f13a51c1d3c30ac0d74d4ef4")
+	require.NoError(t, err)
+
+	want := &vcs.FileMeta{
+		Name:         "v1__create_table.sql",
+		Path:         "prod/v1__create_table.sql",
+		Size:         24,
+		LastCommitID: "1acc419d4d6a9ce985db7be48c6349a0475975b5",
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestProvider_ListPullRequestFile(t *testing.T) {
+	p := newProvider(
+		vcs.ProviderConfig{
+			Client: &http.Client{
+				Transport: &common.MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						switch r.URL.Path {
+						case "/fabrikam/Fabrikam-Fiber-Git/_apis/git/repositories/Fabrikam/pullRequests/22/iterations":
+							return &http.Response{
+								StatusCode: http.StatusOK,
+								Body: io.NopCloser(strings.NewReader(`
+{
+  "count": 2,
+  "value": [
+    {"id": 1, "sourceRefCommit": {"commitId": "7638417db6d59f3c431d3e1f261cc637155684cd"}},
+    {"id": 2, "sourceRefCommit": {"commitId": "be67f8871a4d2c75f13a51c1d3c30ac0d74d4ef4"}}
+  ]
+}
+`)),
+							}, nil
+						case "/fabrikam/Fabrikam-Fiber-Git/_apis/git/repositories/Fabrikam/pullRequests/22/iterations/2/changes":
+							if r.URL.Query().Get("$skip") == "0" {
+								return &http.Response{
+									StatusCode: http.StatusOK,
+									Body: io.NopCloser(strings.NewReader(`
+{
+  "changeEntries": [
+    {"item": {"gitObjectType": "tree", "path": "/prod", "isFolder": true}, "changeType": "add"},
+    {"item": {"gitObjectType": "blob", "path": "/prod/v1__create_table.sql"}, "changeType": "add"}
+  ],
+  "nextSkip": 2,
+  "nextTop": 1000
+}
+`)),
+								}, nil
+							}
+							assert.Equal(t, "2", r.URL.Query().Get("$skip"))
+							return &http.Response{
+								StatusCode: http.StatusOK,
+								Body: io.NopCloser(strings.NewReader(`
+{
+  "changeEntries": [
+    {"item": {"gitObjectType": "blob", "path": "/prod/v0__drop_table.sql"}, "changeType": "delete"}
+  ],
+  "nextSkip": 0,
+  "nextTop": 0
+}
+`)),
+							}, nil
+						}
+						return nil, errors.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+					},
+				},
+			},
+		},
+	)
+
+	ctx := context.Background()
+	got, err := p.ListPullRequestFile(ctx, common.OauthContext{}, testInstanceURL, "Fabrikam-Fiber-Git/Fabrikam", "22")
+	require.NoError(t, err)
+
+	want := []*vcs.PullRequestFile{
+		{
+			Path:         "prod/v1__create_table.sql",
+			LastCommitID: "be67f8871a4d2c75f13a51c1d3c30ac0d74d4ef4",
+			IsDeleted:    false,
+		},
+		{
+			Path:         "prod/v0__drop_table.sql",
+			LastCommitID: "be67f8871a4d2c75f13a51c1d3c30ac0d74d4ef4",
+			IsDeleted:    true,
+		},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestProvider_CreateWebhook(t *testing.T) {
+	p := newProvider(
+		vcs.ProviderConfig{
+			Client: &http.Client{
+				Transport: &common.MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						switch r.URL.Path {
+						case "/fabrikam/Fabrikam-Fiber-Git/_apis/git/repositories/Fabrikam":
+							return &http.Response{
+								StatusCode: http.StatusOK,
+								Body: io.NopCloser(strings.NewReader(`
+{
+  "id": "278d5cd2-584d-4b63-824a-2ba458937249",
+  "name": "Fabrikam",
+  "project": {"id": "6ce954b1-ce1f-45d1-b94d-e6bf2464ba2c", "name": "Fabrikam-Fiber-Git"}
+}
+`)),
+							}, nil
+						case "/fabrikam/_apis/hooks/subscriptions":
+							body, err := io.ReadAll(r.Body)
+							require.NoError(t, err)
+							var webhook WebhookCreateOrUpdate
+							require.NoError(t, json.Unmarshal(body, &webhook))
+							assert.Equal(t, WebhookPush, webhook.EventType)
+							assert.Equal(t, "6ce954b1-ce1f-45d1-b94d-e6bf2464ba2c", webhook.PublisherInputs.ProjectID)
+							assert.Equal(t, "278d5cd2-584d-4b63-824a-2ba458937249", webhook.PublisherInputs.Repository)
+							assert.Equal(t, "https://bytebase.example.com/hook/azure/1", webhook.ConsumerInputs.URL)
+							return &http.Response{
+								StatusCode: http.StatusOK,
+								Body:       io.NopCloser(strings.NewReader(`{"id": "fd672255-8b6b-4769-9260-beea83d752ce"}`)),
+							}, nil
+						}
+						return nil, errors.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+					},
+				},
+			},
+		},
+	)
+
+	payload, err := json.Marshal(
+		WebhookCreateOrUpdate{
+			PublisherID:      "tfs",
+			EventType:        WebhookPush,
+			ResourceVersion:  "1.0",
+			ConsumerID:       "webHooks",
+			ConsumerActionID: "httpRequest",
+			ConsumerInputs: WebhookConsumerInputs{
+				URL:               "https://bytebase.example.com/hook/azure/1",
+				BasicAuthUsername: "bytebase",
+				BasicAuthPassword: "secret",
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	got, err := p.CreateWebhook(ctx, common.OauthContext{}, testInstanceURL, "Fabrikam-Fiber-Git/Fabrikam", payload)
+	require.NoError(t, err)
+	assert.Equal(t, "fd672255-8b6b-4769-9260-beea83d752ce", got)
+}
+
+func TestOAuth_RefreshToken(t *testing.T) {
+	ctx := context.Background()
+	client := &http.Client{
+		Transport: &common.MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				if r.URL.Path == "/oauth2/token" {
+					require.NoError(t, r.ParseForm())
+					assert.Equal(t, "refresh_token", r.PostForm.Get("grant_type"))
+					assert.Equal(t, "refresh", r.PostForm.Get("assertion"))
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body: io.NopCloser(strings.NewReader(`
+{
+  "access_token": "eyJ0eXAiOiJKV1QiLCJhbGciOiJSUzI1NiJ9.access",
+  "token_type": "jwt-bearer",
+  "expires_in": "3599",
+  "refresh_token": "eyJ0eXAiOiJKV1QiLCJhbGciOiJSUzI1NiJ9.refresh"
+}
+`)),
+					}, nil
+				}
+
+				token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+				if token == "expired" {
+					// Azure DevOps redirects to the sign-in page for the expired token.
+					return &http.Response{
+						StatusCode: http.StatusNonAuthoritativeInfo,
+						Header:     http.Header{"X-Tfs-Fedauthredirect": []string{"https://spsprodcus5.vssps.visualstudio.com/_signin"}},
+						Body:       io.NopCloser(strings.NewReader(`<html></html>`)),
+					}, nil
+				}
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader(`{}`)),
+				}, nil
+			},
+		},
+	}
+
+	t.Run("OAuth", func(t *testing.T) {
+		token := "expired"
+		calledRefresher := false
+		refresher := func(_, _ string, _ int64) error {
+			calledRefresher = true
+			return nil
+		}
+
+		_, _, _, err := oauth.Get(
+			ctx,
+			client,
+			testInstanceURL+"/_apis/connectionData",
+			&token,
+			tokenRefresher(
+				oauthContext{
+					ClientSecret: "client-secret",
+					RefreshToken: "refresh",
+				},
+				refresher,
+			),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, "eyJ0eXAiOiJKV1QiLCJhbGciOiJSUzI1NiJ9.access", token)
+		assert.True(t, calledRefresher)
+	})
+
+	t.Run("personal access token", func(t *testing.T) {
+		token := oauth.BasicAuthToken("", "expired")
+		err := tokenRefresher(oauthContext{}, nil)(ctx, client, &token)
+		assert.Error(t, err)
+	})
+}
+
+func TestWebhookPushEvent_ToVCS(t *testing.T) {
+	payload := `
+{
+  "subscriptionId": "fd672255-8b6b-4769-9260-beea83d752ce",
+  "eventType": "git.push",
+  "resource": {
+    "commits": [
+      {
+        "commitId": "be67f8871a4d2c75f13a51c1d3c30ac0d74d4ef4",
+        "author": {"name": "Jamal Hartnett", "email": "fabrikamfiber4@hotmail.com", "date": "2015-02-25T19:01:00Z"},
+        "comment": "Alter table",
+        "url": "https://dev.azure.com/fabrikam/_apis/git/repositories/278d5cd2-584d-4b63-824a-2ba458937249/commits/be67f8871a4d2c75f13a51c1d3c30ac0d74d4ef4"
+      },
+      {
+        "commitId": "7638417db6d59f3c431d3e1f261cc637155684cd",
+        "author": {"name": "Jamal Hartnett", "email": "fabrikamfiber4@hotmail.com", "date": "2015-02-25T18:01:00Z"},
+        "comment": "Add migration\n\nCreate the table.",
+        "url": "https://dev.azure.com/fabrikam/_apis/git/repositories/278d5cd2-584d-4b63-824a-2ba458937249/commits/7638417db6d59f3c431d3e1f261cc637155684cd"
+      }
+    ],
+    "refUpdates": [
+      {
+        "name": "refs/heads/main",
+        "oldObjectId": "aad331d8d3b131fa9ae03cf5e53965b51942618a",
+        "newObjectId": "be67f8871a4d2c75f13a51c1d3c30ac0d74d4ef4"
+      }
+    ],
+    "repository": {
+      "id": "278d5cd2-584d-4b63-824a-2ba458937249",
+      "name": "Fabrikam",
+      "url": "https://dev.azure.com/fabrikam/_apis/git/repositories/278d5cd2-584d-4b63-824a-2ba458937249",
+      "remoteUrl": "https://dev.azure.com/fabrikam/Fabrikam-Fiber-Git/_git/Fabrikam",
+      "project": {"id": "6ce954b1-ce1f-45d1-b94d-e6bf2464ba2c", "name": "Fabrikam-Fiber-Git"}
+    },
+    "pushedBy": {"displayName": "Jamal Hartnett", "uniqueName": "fabrikamfiber4@hotmail.com"}
+  }
+}
+`
+	var pushEvent WebhookPushEvent
+	require.NoError(t, json.Unmarshal([]byte(payload), &pushEvent))
+
+	got := pushEvent.ToVCS()
+	want := vcs.PushEvent{
+		Ref:                "refs/heads/main",
+		RepositoryID:       "Fabrikam-Fiber-Git/Fabrikam",
+		RepositoryURL:      "https://dev.azure.com/fabrikam/Fabrikam-Fiber-Git/_git/Fabrikam",
+		RepositoryFullPath: "Fabrikam-Fiber-Git/Fabrikam",
+		AuthorName:         "Jamal Hartnett",
+		CommitList: []vcs.Commit{
+			{
+				ID:          "7638417db6d59f3c431d3e1f261cc637155684cd",
+				Title:       "Add migration",
+				Message:     "Add migration\n\nCreate the table.",
+				CreatedTs:   time.Date(2015, 2, 25, 18, 1, 0, 0, time.UTC).Unix(),
+				URL:         "https://dev.azure.com/fabrikam/Fabrikam-Fiber-Git/_git/Fabrikam/commit/7638417db6d59f3c431d3e1f261cc637155684cd",
+				AuthorName:  "Jamal Hartnett",
+				AuthorEmail: "fabrikamfiber4@hotmail.com",
+			},
+			{
+				ID:          "be67f8871a4d2c75f13a51c1d3c30ac0d74d4ef4",
+				Title:       "Alter table",
+				Message:     "Alter table",
+				CreatedTs:   time.Date(2015, 2, 25, 19, 1, 0, 0, time.UTC).Unix(),
+				URL:         "https://dev.azure.com/fabrikam/Fabrikam-Fiber-Git/_git/Fabrikam/commit/be67f8871a4d2c75f13a51c1d3c30ac0d74d4ef4",
+				AuthorName:  "Jamal Hartnett",
+				AuthorEmail: "fabrikamfiber4@hotmail.com",
+			},
+		},
+	}
+	assert.Equal(t, want, got)
+}
@@ -0,0 +1,1290 @@
+// Package bitbucket is the plugin for Bitbucket Cloud.
+package bitbucket
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/vcs"
+	"github.com/bytebase/bytebase/plugin/vcs/internal/oauth"
+)
+
+const (
+	// bitbucketCloudURL is URL for the Bitbucket Cloud.
+	bitbucketCloudURL = "https://bitbucket.org"
+
+	// apiPageSize is the default page size when making API requests.
+	apiPageSize = 100
+	// diffStatPageSize is the page size when listing the changed files, which allows at most 500.
+	diffStatPageSize = 500
+)
+
+func init() {
+	vcs.Register(vcs.BitbucketCloud, newProvider)
+}
+
+var _ vcs.Provider = (*Provider)(nil)
+
+// Provider is a Bitbucket Cloud VCS provider.
+//
+// Bitbucket identifies a repository by its full name "{workspace}/{repo_slug}",
+// which is used as the repository ID in all methods.
+type Provider struct {
+	client *http.Client
+}
+
+func newProvider(config vcs.ProviderConfig) vcs.Provider {
+	if config.Client == nil {
+		config.Client = &http.Client{}
+	}
+	return &Provider{
+		client: config.Client,
+	}
+}
+
+// APIURL returns the API URL path of Bitbucket Cloud.
+func (*Provider) APIURL(instanceURL string) string {
+	if instanceURL == bitbucketCloudURL {
+		return "https://api.bitbucket.org/2.0"
+	}
+
+	// Otherwise the API is served under the instance URL, e.g. a proxy in front of the Bitbucket Cloud.
+	return fmt.Sprintf("%s/2.0", instanceURL)
+}
+
+// Link is the API message for a Bitbucket link.
+type Link struct {
+	Href string `json:"href"`
+}
+
+// Links is the API message for Bitbucket links.
+type Links struct {
+	HTML Link `json:"html"`
+}
+
+// User represents a Bitbucket API response for a user.
+type User struct {
+	UUID        string `json:"uuid"`
+	AccountID   string `json:"account_id"`
+	DisplayName string `json:"display_name"`
+	Nickname    string `json:"nickname"`
+}
+
+// UserEmail represents a Bitbucket API response for an email address of the authenticated user.
+type UserEmail struct {
+	Email       string `json:"email"`
+	IsPrimary   bool   `json:"is_primary"`
+	IsConfirmed bool   `json:"is_confirmed"`
+}
+
+// Repository represents a Bitbucket API response for a repository.
+type Repository struct {
+	UUID     string `json:"uuid"`
+	Name     string `json:"name"`
+	FullName string `json:"full_name"`
+	Links    Links  `json:"links"`
+}
+
+// RepositoryPermission represents a Bitbucket API response for the permission of the authenticated user on a repository.
+type RepositoryPermission struct {
+	Permission string     `json:"permission"`
+	Repository Repository `json:"repository"`
+}
+
+// TreeEntry represents a Bitbucket API response for an entry of the repository source tree.
+type TreeEntry struct {
+	// Type is either "commit_file" or "commit_directory".
+	Type   string `json:"type"`
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	Commit struct {
+		Hash string `json:"hash"`
+	} `json:"commit"`
+}
+
+// CommitAuthor represents a Bitbucket API response for a commit author.
+type CommitAuthor struct {
+	// Raw is the raw author string in the format of "Name <email>".
+	Raw  string `json:"raw"`
+	User *User  `json:"user"`
+}
+
+// name returns the display name of the author, falling back to the name in the raw author string.
+func (a CommitAuthor) name() string {
+	if a.User != nil && a.User.DisplayName != "" {
+		return a.User.DisplayName
+	}
+	if address, err := mail.ParseAddress(a.Raw); err == nil {
+		return address.Name
+	}
+	return a.Raw
+}
+
+// email returns the email in the raw author string.
+func (a CommitAuthor) email() string {
+	if address, err := mail.ParseAddress(a.Raw); err == nil {
+		return address.Address
+	}
+	return ""
+}
+
+// CommitParent represents a Bitbucket API response for a commit parent.
+type CommitParent struct {
+	Hash string `json:"hash"`
+}
+
+// Commit represents a Bitbucket API response for a commit.
+type Commit struct {
+	Hash    string         `json:"hash"`
+	Date    time.Time      `json:"date"`
+	Message string         `json:"message"`
+	Author  CommitAuthor   `json:"author"`
+	Parents []CommitParent `json:"parents"`
+	Links   Links          `json:"links"`
+}
+
+// DiffStatFile is the API message for a file in the diff stat.
+type DiffStatFile struct {
+	Path string `json:"path"`
+}
+
+// DiffStat represents a Bitbucket API response for the change of a file.
+type DiffStat struct {
+	// Status is one of "added", "removed", "modified" and "renamed".
+	Status string        `json:"status"`
+	Old    *DiffStatFile `json:"old"`
+	New    *DiffStatFile `json:"new"`
+}
+
+// BranchTarget is the API message for the commit a Bitbucket branch points to.
+type BranchTarget struct {
+	Hash string `json:"hash"`
+}
+
+// Branch represents a Bitbucket API message for a branch.
+type Branch struct {
+	Name   string       `json:"name"`
+	Target BranchTarget `json:"target"`
+}
+
+// PullRequestBranch is the API message for the branch of a pull request.
+type PullRequestBranch struct {
+	Name string `json:"name"`
+}
+
+// PullRequestEndpoint is the API message for the source or destination of a pull request.
+type PullRequestEndpoint struct {
+	Branch PullRequestBranch `json:"branch"`
+	Commit *BranchTarget     `json:"commit,omitempty"`
+}
+
+// PullRequestCreate represents a Bitbucket API request for creating a pull request.
+type PullRequestCreate struct {
+	Title             string              `json:"title"`
+	Description       string              `json:"description"`
+	Source            PullRequestEndpoint `json:"source"`
+	Destination       PullRequestEndpoint `json:"destination"`
+	CloseSourceBranch bool                `json:"close_source_branch"`
+}
+
+// PullRequest represents a Bitbucket API response for a pull request.
+type PullRequest struct {
+	ID     int                 `json:"id"`
+	Source PullRequestEndpoint `json:"source"`
+	Links  Links               `json:"links"`
+}
+
+// PipelineVariable represents a Bitbucket API message for a repository pipeline variable.
+type PipelineVariable struct {
+	UUID    string `json:"uuid,omitempty"`
+	Key     string `json:"key"`
+	Value   string `json:"value"`
+	Secured bool   `json:"secured"`
+}
+
+// WebhookType is the Bitbucket webhook event type in the X-Event-Key header.
+type WebhookType string
+
+const (
+	// WebhookPush is the webhook type for push.
+	WebhookPush WebhookType = "repo:push"
+	// WebhookPing is the webhook type sent when testing the webhook connection.
+	WebhookPing WebhookType = "diagnostics:ping"
+)
+
+// WebhookCreateOrUpdate represents a Bitbucket API request for creating or
+// updating a webhook.
+type WebhookCreateOrUpdate struct {
+	Description string `json:"description"`
+	// URL is the URL to which the payloads will be delivered.
+	URL    string `json:"url"`
+	Active bool   `json:"active"`
+	// Secret is used as the key to generate the HMAC hex digest value in the
+	// X-Hub-Signature header.
+	Secret string `json:"secret,omitempty"`
+	// Events determines what events the hook is triggered for, e.g. "repo:push".
+	Events []string `json:"events"`
+}
+
+// WebhookInfo represents a Bitbucket API response for the webhook information.
+type WebhookInfo struct {
+	UUID string `json:"uuid"`
+}
+
+// WebhookPushChangeRef is the API message for the ref of a change in the webhook push event.
+type WebhookPushChangeRef struct {
+	// Type is one of "branch", "tag" and "named_branch".
+	Type   string       `json:"type"`
+	Name   string       `json:"name"`
+	Target BranchTarget `json:"target"`
+}
+
+// WebhookPushChange is the API message for a change in the webhook push event.
+type WebhookPushChange struct {
+	// Old is nil if the ref is created by the push.
+	Old *WebhookPushChangeRef `json:"old"`
+	// New is nil if the ref is deleted by the push.
+	New *WebhookPushChangeRef `json:"new"`
+	// Commits contains at most 5 latest commits pushed to the ref, in reverse chronological order.
+	Commits   []Commit `json:"commits"`
+	Truncated bool     `json:"truncated"`
+}
+
+// WebhookPushDetail is the API message for the push in the webhook push event.
+type WebhookPushDetail struct {
+	Changes []WebhookPushChange `json:"changes"`
+}
+
+// WebhookPushEvent is the API message for webhook push event.
+//
+// NOTE: Unlike GitHub and GitLab, Bitbucket does not include the changed files
+// in the payload, callers need to fetch them by FetchCommitByID.
+type WebhookPushEvent struct {
+	Push       WebhookPushDetail `json:"push"`
+	Repository Repository        `json:"repository"`
+	Actor      User              `json:"actor"`
+}
+
+// paginatedResponse represents a Bitbucket API response of a paginated list.
+type paginatedResponse struct {
+	Values json.RawMessage `json:"values"`
+	// Next is the URL of the next page, empty if it is the last page.
+	Next string `json:"next"`
+}
+
+// TryLogin tries to fetch the user info from the current OAuth context.
+//
+// Docs: https://developer.atlassian.com/cloud/bitbucket/rest/api-group-users/#api-user-get
+func (p *Provider) TryLogin(ctx context.Context, oauthCtx common.OauthContext, instanceURL string) (*vcs.UserInfo, error) {
+	url := fmt.Sprintf("%s/user", p.APIURL(instanceURL))
+	code, _, body, err := oauth.Get(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		tokenRefresher(
+			instanceURL,
+			oauthContext{
+				ClientID:     oauthCtx.ClientID,
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "GET %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return nil, common.Errorf(common.NotFound, "failed to read user info from URL %s", url)
+	} else if code >= 300 {
+		return nil, errors.Errorf("failed to read user info from URL %s, status code: %d, body: %s", url, code, body)
+	}
+
+	var user User
+	if err := json.Unmarshal([]byte(body), &user); err != nil {
+		return nil, errors.Wrap(err, "unmarshal")
+	}
+
+	emailList, err := p.fetchUserEmailList(ctx, oauthCtx, instanceURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch user email list")
+	}
+	var email string
+	for _, e := range emailList {
+		if e.IsPrimary && e.IsConfirmed {
+			email = e.Email
+			break
+		}
+	}
+	return &vcs.UserInfo{
+		PublicEmail: email,
+		Name:        user.DisplayName,
+		State:       vcs.StateActive,
+	}, nil
+}
+
+// fetchUserEmailList fetches the email addresses of the authenticated user.
+//
+// Docs: https://developer.atlassian.com/cloud/bitbucket/rest/api-group-users/#api-user-emails-get
+func (p *Provider) fetchUserEmailList(ctx context.Context, oauthCtx common.OauthContext, instanceURL string) ([]UserEmail, error) {
+	var emailList []UserEmail
+	url := fmt.Sprintf("%s/user/emails?pagelen=%d", p.APIURL(instanceURL), apiPageSize)
+	for url != "" {
+		var emails []UserEmail
+		next, err := p.fetchPaginatedList(ctx, oauthCtx, instanceURL, url, &emails)
+		if err != nil {
+			return nil, errors.Wrap(err, "fetch paginated list")
+		}
+		emailList = append(emailList, emails...)
+		url = next
+	}
+	return emailList, nil
+}
+
+// FetchCommitByID fetches the commit data by its ID from the repository,
+// including the files added and modified by the commit.
+//
+// Docs: https://developer.atlassian.com/cloud/bitbucket/rest/api-group-commits/#api-repositories-workspace-repo-slug-commit-commit-get
+func (p *Provider) FetchCommitByID(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, commitID string) (*vcs.Commit, error) {
+	url := fmt.Sprintf("%s/repositories/%s/commit/%s", p.APIURL(instanceURL), repositoryID, commitID)
+	code, _, body, err := oauth.Get(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		tokenRefresher(
+			instanceURL,
+			oauthContext{
+				ClientID:     oauthCtx.ClientID,
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "GET %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return nil, common.Errorf(common.NotFound, "failed to fetch commit data from URL %s", url)
+	} else if code >= 300 {
+		return nil, errors.Errorf("failed to fetch commit data from URL %s, status code: %d, body: %s", url, code, body)
+	}
+
+	commit := &Commit{}
+	if err := json.Unmarshal([]byte(body), commit); err != nil {
+		return nil, errors.Wrap(err, "unmarshal body")
+	}
+
+	diffStatList, err := p.fetchDiffStatList(ctx, oauthCtx, instanceURL, repositoryID, commit.Hash)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch diff stat")
+	}
+
+	vcsCommit := commit.toVCS()
+	for _, diffStat := range diffStatList {
+		switch diffStat.Status {
+		case "added", "renamed":
+			vcsCommit.AddedList = append(vcsCommit.AddedList, diffStat.New.Path)
+		case "modified":
+			vcsCommit.ModifiedList = append(vcsCommit.ModifiedList, diffStat.New.Path)
+		}
+	}
+	return vcsCommit, nil
+}
+
+// fetchDiffStatList fetches the files changed by the commit, compared with its first parent.
+//
+// Docs: https://developer.atlassian.com/cloud/bitbucket/rest/api-group-commits/#api-repositories-workspace-repo-slug-diffstat-spec-get
+func (p *Provider) fetchDiffStatList(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, spec string) ([]DiffStat, error) {
+	var diffStatList []DiffStat
+	url := fmt.Sprintf("%s/repositories/%s/diffstat/%s?pagelen=%d", p.APIURL(instanceURL), repositoryID, spec, diffStatPageSize)
+	for url != "" {
+		var diffStats []DiffStat
+		next, err := p.fetchPaginatedList(ctx, oauthCtx, instanceURL, url, &diffStats)
+		if err != nil {
+			return nil, errors.Wrap(err, "fetch paginated list")
+		}
+		diffStatList = append(diffStatList, diffStats...)
+		url = next
+	}
+	return diffStatList, nil
+}
+
+// FetchUserInfo fetches user info of given user ID.
+//
+// NOTE: Bitbucket does not expose the email addresses of other users.
+//
+// Docs: https://developer.atlassian.com/cloud/bitbucket/rest/api-group-users/#api-users-selected-user-get
+func (p *Provider) FetchUserInfo(ctx context.Context, oauthCtx common.OauthContext, instanceURL, user string) (*vcs.UserInfo, error) {
+	url := fmt.Sprintf("%s/users/%s", p.APIURL(instanceURL), url.PathEscape(user))
+	code, _, body, err := oauth.Get(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		tokenRefresher(
+			instanceURL,
+			oauthContext{
+				ClientID:     oauthCtx.ClientID,
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "GET %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return nil, common.Errorf(common.NotFound, "failed to read user info from URL %s", url)
+	} else if code >= 300 {
+		return nil, errors.Errorf("failed to read user info from URL %s, status code: %d, body: %s", url, code, body)
+	}
+
+	var u User
+	if err := json.Unmarshal([]byte(body), &u); err != nil {
+		return nil, errors.Wrap(err, "unmarshal")
+	}
+	return &vcs.UserInfo{
+		Name:  u.DisplayName,
+		State: vcs.StateActive,
+	}, nil
+}
+
+// FetchRepositoryActiveMemberList fetches all active members of a repository.
+//
+// Bitbucket does not expose the email addresses of the repository members,
+// which are required to map them to Bytebase principals, so syncing members is
+// not supported.
+func (*Provider) FetchRepositoryActiveMemberList(_ context.Context, _ common.OauthContext, _, _ string) ([]*vcs.RepositoryMember, error) {
+	return nil, errors.New("Bitbucket does not expose the email addresses of repository members, syncing repository members is not supported")
+}
+
+// oauthResponse is a Bitbucket OAuth response.
+type oauthResponse struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error,omitempty"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// toVCSOAuthToken converts the response to *vcs.OAuthToken.
+func (o oauthResponse) toVCSOAuthToken() *vcs.OAuthToken {
+	oauthToken := &vcs.OAuthToken{
+		AccessToken:  o.AccessToken,
+		RefreshToken: o.RefreshToken,
+		ExpiresIn:    o.ExpiresIn,
+		CreatedAt:    time.Now().Unix(),
+	}
+	if oauthToken.ExpiresIn != 0 {
+		oauthToken.ExpiresTs = oauthToken.CreatedAt + oauthToken.ExpiresIn
+	}
+	return oauthToken
+}
+
+// requestOAuthToken requests the OAuth token endpoint with the client credentials and the grant.
+func requestOAuthToken(ctx context.Context, client *http.Client, instanceURL, clientID, clientSecret string, grant url.Values) (*oauthResponse, error) {
+	url := fmt.Sprintf("%s/site/oauth2/access_token", instanceURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(grant.Encode()))
+	if err != nil {
+		return nil, errors.Wrapf(err, "construct POST %s", url)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(clientID, clientSecret)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "POST %s", url)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read OAuth response body, code %v", resp.StatusCode)
+	}
+
+	oauthResp := new(oauthResponse)
+	if err := json.Unmarshal(body, oauthResp); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal OAuth response body, code %v", resp.StatusCode)
+	}
+	if oauthResp.Error != "" {
+		return nil, errors.Errorf("failed to request OAuth token, error: %v, error_description: %v", oauthResp.Error, oauthResp.ErrorDescription)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("non-200 POST %s status code %d with body %q", url, resp.StatusCode, body)
+	}
+	return oauthResp, nil
+}
+
+// ExchangeOAuthToken exchanges OAuth content with the provided authorization code.
+//
+// Docs: https://developer.atlassian.com/cloud/bitbucket/oauth-2/
+func (p *Provider) ExchangeOAuthToken(ctx context.Context, instanceURL string, oauthExchange *common.OAuthExchange) (*vcs.OAuthToken, error) {
+	grant := url.Values{}
+	grant.Set("grant_type", "authorization_code")
+	grant.Set("code", oauthExchange.Code)
+	oauthResp, err := requestOAuthToken(ctx, p.client, instanceURL, oauthExchange.ClientID, oauthExchange.ClientSecret, grant)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to exchange OAuth token")
+	}
+	return oauthResp.toVCSOAuthToken(), nil
+}
+
+// FetchAllRepositoryList fetches all repositories where the authenticated user
+// has admin permissions, which is required to create webhook in the repository.
+//
+// Docs: https://developer.atlassian.com/cloud/bitbucket/rest/api-group-users/#api-user-permissions-repositories-get
+func (p *Provider) FetchAllRepositoryList(ctx context.Context, oauthCtx common.OauthContext, instanceURL string) ([]*vcs.Repository, error) {
+	var allRepos []*vcs.Repository
+	url := fmt.Sprintf("%s/user/permissions/repositories?q=%s&pagelen=%d", p.APIURL(instanceURL), url.QueryEscape(`permission="admin"`), apiPageSize)
+	for url != "" {
+		var permissions []RepositoryPermission
+		next, err := p.fetchPaginatedList(ctx, oauthCtx, instanceURL, url, &permissions)
+		if err != nil {
+			return nil, errors.Wrap(err, "fetch paginated list")
+		}
+		for _, permission := range permissions {
+			r := permission.Repository
+			allRepos = append(allRepos,
+				&vcs.Repository{
+					// Bitbucket repositories do not have a numeric ID, the full name is used as the external ID.
+					Name:     r.Name,
+					FullPath: r.FullName,
+					WebURL:   r.Links.HTML.Href,
+				},
+			)
+		}
+		url = next
+	}
+	return allRepos, nil
+}
+
+// FetchRepositoryFileList fetches the all files from the given repository tree
+// recursively.
+//
+// Docs: https://developer.atlassian.com/cloud/bitbucket/rest/api-group-source/#api-repositories-workspace-repo-slug-src-commit-path-get
+func (p *Provider) FetchRepositoryFileList(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, ref, filePath string) ([]*vcs.RepositoryTreeNode, error) {
+	var allTreeNodes []*vcs.RepositoryTreeNode
+	// The max_depth lists the files in the subdirectories up to the depth in the same response.
+	url := fmt.Sprintf("%s/repositories/%s/src/%s/%s?max_depth=%d&pagelen=%d", p.APIURL(instanceURL), repositoryID, url.PathEscape(ref), escapeFilePath(filePath), 100, apiPageSize)
+	for url != "" {
+		var entries []TreeEntry
+		next, err := p.fetchPaginatedList(ctx, oauthCtx, instanceURL, url, &entries)
+		if err != nil {
+			return nil, errors.Wrap(err, "fetch paginated list")
+		}
+		for _, entry := range entries {
+			if entry.Type != "commit_file" {
+				continue
+			}
+			allTreeNodes = append(allTreeNodes,
+				&vcs.RepositoryTreeNode{
+					Path: entry.Path,
+					Type: "blob",
+				},
+			)
+		}
+		url = next
+	}
+	return allTreeNodes, nil
+}
+
+// CreateFile creates a file at given path in the repository.
+//
+// Docs: https://developer.atlassian.com/cloud/bitbucket/rest/api-group-source/#api-repositories-workspace-repo-slug-src-post
+func (p *Provider) CreateFile(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, filePath string, fileCommitCreate vcs.FileCommitCreate) error {
+	form := url.Values{}
+	form.Set(filePath, fileCommitCreate.Content)
+	form.Set("message", fileCommitCreate.CommitMessage)
+	form.Set("branch", fileCommitCreate.Branch)
+	if fileCommitCreate.LastCommitID != "" {
+		// Bitbucket rejects the commit if the branch head is not the parent, which detects conflicting writes.
+		form.Set("parents", fileCommitCreate.LastCommitID)
+	}
+
+	url := fmt.Sprintf("%s/repositories/%s/src", p.APIURL(instanceURL), repositoryID)
+	code, _, resp, err := oauth.PostForm(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		form,
+		tokenRefresher(
+			instanceURL,
+			oauthContext{
+				ClientID:     oauthCtx.ClientID,
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return errors.Wrapf(err, "POST %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return common.Errorf(common.NotFound, "failed to create/update file through URL %s", url)
+	} else if code >= 300 {
+		return errors.Errorf("failed to create/update file through URL %s, status code: %d, body: %s",
+			url,
+			code,
+			resp,
+		)
+	}
+	return nil
+}
+
+// OverwriteFile overwrites an existing file at given path in the repository.
+//
+// Docs: https://developer.atlassian.com/cloud/bitbucket/rest/api-group-source/#api-repositories-workspace-repo-slug-src-post
+func (p *Provider) OverwriteFile(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, filePath string, fileCommitCreate vcs.FileCommitCreate) error {
+	return p.CreateFile(ctx, oauthCtx, instanceURL, repositoryID, filePath, fileCommitCreate)
+}
+
+// ReadFileMeta reads the metadata of the given file in the repository.
+//
+// Docs: https://developer.atlassian.com/cloud/bitbucket/rest/api-group-source/#api-repositories-workspace-repo-slug-src-commit-path-get
+func (p *Provider) ReadFileMeta(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, filePath, ref string) (*vcs.FileMeta, error) {
+	body, err := p.readFile(ctx, oauthCtx, instanceURL, repositoryID, filePath, ref, true /* meta */)
+	if err != nil {
+		return nil, errors.Wrap(err, "read file")
+	}
+
+	var entry TreeEntry
+	if err := json.Unmarshal([]byte(body), &entry); err != nil {
+		return nil, errors.Wrap(err, "unmarshal body")
+	}
+	if entry.Type != "commit_file" {
+		return nil, errors.Errorf("%q is a directory not a file", filePath)
+	}
+
+	return &vcs.FileMeta{
+		Name:         entry.Path[strings.LastIndex(entry.Path, "/")+1:],
+		Path:         entry.Path,
+		Size:         entry.Size,
+		LastCommitID: entry.Commit.Hash,
+	}, nil
+}
+
+// ReadFileContent reads the content of the given file in the repository.
+//
+// Docs: https://developer.atlassian.com/cloud/bitbucket/rest/api-group-source/#api-repositories-workspace-repo-slug-src-commit-path-get
+func (p *Provider) ReadFileContent(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, filePath, ref string) (string, error) {
+	body, err := p.readFile(ctx, oauthCtx, instanceURL, repositoryID, filePath, ref, false /* meta */)
+	if err != nil {
+		return "", errors.Wrap(err, "read file")
+	}
+	return body, nil
+}
+
+// readFile reads the raw content or the metadata of the given file in the repository.
+func (p *Provider) readFile(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, filePath, ref string, meta bool) (string, error) {
+	url := fmt.Sprintf("%s/repositories/%s/src/%s/%s", p.APIURL(instanceURL), repositoryID, url.PathEscape(ref), escapeFilePath(filePath))
+	if meta {
+		url += "?format=meta"
+	}
+	code, _, body, err := oauth.Get(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		tokenRefresher(
+			instanceURL,
+			oauthContext{
+				ClientID:     oauthCtx.ClientID,
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return "", errors.Wrapf(err, "GET %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return "", common.Errorf(common.NotFound, "failed to read file from URL %s", url)
+	} else if code >= 300 {
+		return "",
+			errors.Errorf("failed to read file from URL %s, status code: %d, body: %s",
+				url,
+				code,
+				body,
+			)
+	}
+	return body, nil
+}
+
+// ListPullRequestFile lists the changed files in the pull request.
+//
+// Docs: https://developer.atlassian.com/cloud/bitbucket/rest/api-group-pullrequests/#api-repositories-workspace-repo-slug-pullrequests-pull-request-id-diffstat-get
+func (p *Provider) ListPullRequestFile(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, pullRequestID string) ([]*vcs.PullRequestFile, error) {
+	pullRequest, err := p.getPullRequest(ctx, oauthCtx, instanceURL, repositoryID, pullRequestID)
+	if err != nil {
+		return nil, errors.Wrap(err, "get pull request")
+	}
+	var lastCommitID string
+	if pullRequest.Source.Commit != nil {
+		lastCommitID = pullRequest.Source.Commit.Hash
+	}
+
+	var res []*vcs.PullRequestFile
+	url := fmt.Sprintf("%s/repositories/%s/pullrequests/%s/diffstat?pagelen=%d", p.APIURL(instanceURL), repositoryID, pullRequestID, diffStatPageSize)
+	for url != "" {
+		var diffStats []DiffStat
+		next, err := p.fetchPaginatedList(ctx, oauthCtx, instanceURL, url, &diffStats)
+		if err != nil {
+			return nil, errors.Wrap(err, "fetch paginated list")
+		}
+		for _, diffStat := range diffStats {
+			if diffStat.Status == "removed" {
+				res = append(res, &vcs.PullRequestFile{
+					Path:         diffStat.Old.Path,
+					LastCommitID: lastCommitID,
+					IsDeleted:    true,
+				})
+				continue
+			}
+			res = append(res, &vcs.PullRequestFile{
+				Path:         diffStat.New.Path,
+				LastCommitID: lastCommitID,
+			})
+		}
+		url = next
+	}
+	return res, nil
+}
+
+// getPullRequest gets the pull request in the repository.
+//
+// Docs: https://developer.atlassian.com/cloud/bitbucket/rest/api-group-pullrequests/#api-repositories-workspace-repo-slug-pullrequests-pull-request-id-get
+func (p *Provider) getPullRequest(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, pullRequestID string) (*PullRequest, error) {
+	url := fmt.Sprintf("%s/repositories/%s/pullrequests/%s", p.APIURL(instanceURL), repositoryID, pullRequestID)
+	code, _, body, err := oauth.Get(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		tokenRefresher(
+			instanceURL,
+			oauthContext{
+				ClientID:     oauthCtx.ClientID,
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "GET %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return nil, common.Errorf(common.NotFound, "failed to get pull request from URL %s", url)
+	} else if code >= 300 {
+		return nil, errors.Errorf("failed to get pull request from URL %s, status code: %d, body: %s",
+			url,
+			code,
+			body,
+		)
+	}
+
+	pullRequest := new(PullRequest)
+	if err := json.Unmarshal([]byte(body), pullRequest); err != nil {
+		return nil, errors.Wrap(err, "unmarshal body")
+	}
+	return pullRequest, nil
+}
+
+// GetBranch gets the given branch in the repository.
+//
+// Docs: https://developer.atlassian.com/cloud/bitbucket/rest/api-group-refs/#api-repositories-workspace-repo-slug-refs-branches-name-get
+func (p *Provider) GetBranch(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, branchName string) (*vcs.BranchInfo, error) {
+	url := fmt.Sprintf("%s/repositories/%s/refs/branches/%s", p.APIURL(instanceURL), repositoryID, url.PathEscape(branchName))
+	code, _, body, err := oauth.Get(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		tokenRefresher(
+			instanceURL,
+			oauthContext{
+				ClientID:     oauthCtx.ClientID,
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "GET %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return nil, common.Errorf(common.NotFound, "failed to get branch from URL %s", url)
+	} else if code >= 300 {
+		return nil, errors.Errorf("failed to get branch from URL %s, status code: %d, body: %s",
+			url,
+			code,
+			body,
+		)
+	}
+
+	res := new(Branch)
+	if err := json.Unmarshal([]byte(body), res); err != nil {
+		return nil, err
+	}
+
+	return &vcs.BranchInfo{
+		Name:         res.Name,
+		LastCommitID: res.Target.Hash,
+	}, nil
+}
+
+// CreateBranch creates the branch in the repository.
+//
+// Docs: https://developer.atlassian.com/cloud/bitbucket/rest/api-group-refs/#api-repositories-workspace-repo-slug-refs-branches-post
+func (p *Provider) CreateBranch(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID string, branch *vcs.BranchInfo) error {
+	body, err := json.Marshal(
+		Branch{
+			Name: branch.Name,
+			Target: BranchTarget{
+				Hash: branch.LastCommitID,
+			},
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, "marshal branch create")
+	}
+
+	url := fmt.Sprintf("%s/repositories/%s/refs/branches", p.APIURL(instanceURL), repositoryID)
+	code, _, resp, err := oauth.Post(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		bytes.NewReader(body),
+		tokenRefresher(
+			instanceURL,
+			oauthContext{
+				ClientID:     oauthCtx.ClientID,
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return errors.Wrapf(err, "POST %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return common.Errorf(common.NotFound, "failed to create branch from URL %s", url)
+	} else if code >= 300 {
+		return errors.Errorf("failed to create branch from URL %s, status code: %d, body: %s",
+			url,
+			code,
+			resp,
+		)
+	}
+
+	return nil
+}
+
+// CreatePullRequest creates the pull request in the repository.
+//
+// Docs: https://developer.atlassian.com/cloud/bitbucket/rest/api-group-pullrequests/#api-repositories-workspace-repo-slug-pullrequests-post
+func (p *Provider) CreatePullRequest(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID string, pullRequestCreate *vcs.PullRequestCreate) (*vcs.PullRequest, error) {
+	body, err := json.Marshal(
+		PullRequestCreate{
+			Title:       pullRequestCreate.Title,
+			Description: pullRequestCreate.Body,
+			Source: PullRequestEndpoint{
+				Branch: PullRequestBranch{Name: pullRequestCreate.Head},
+			},
+			Destination: PullRequestEndpoint{
+				Branch: PullRequestBranch{Name: pullRequestCreate.Base},
+			},
+			CloseSourceBranch: pullRequestCreate.RemoveHeadAfterMerged,
+		},
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal pull request create")
+	}
+
+	url := fmt.Sprintf("%s/repositories/%s/pullrequests", p.APIURL(instanceURL), repositoryID)
+	code, _, resp, err := oauth.Post(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		bytes.NewReader(body),
+		tokenRefresher(
+			instanceURL,
+			oauthContext{
+				ClientID:     oauthCtx.ClientID,
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "POST %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return nil, common.Errorf(common.NotFound, "failed to create pull request from URL %s", url)
+	} else if code >= 300 {
+		return nil, errors.Errorf("failed to create pull request from URL %s, status code: %d, body: %s",
+			url,
+			code,
+			resp,
+		)
+	}
+
+	var res PullRequest
+	if err := json.Unmarshal([]byte(resp), &res); err != nil {
+		return nil, err
+	}
+
+	return &vcs.PullRequest{
+		URL: res.Links.HTML.Href,
+	}, nil
+}
+
+// CreateCommitStatus creates the status of the commit.
+func (*Provider) CreateCommitStatus(_ context.Context, _ common.OauthContext, _, _, _ string, _ *vcs.CommitStatus) error {
+	return vcs.ErrNotImplemented
+}
+
+// UpsertPullRequestReview posts the review with inline comments to the pull request.
+func (*Provider) UpsertPullRequestReview(_ context.Context, _ common.OauthContext, _, _, _ string, _ *vcs.PullRequestReview) error {
+	return vcs.ErrNotImplemented
+}
+
+// UpsertEnvironmentVariable creates or updates the secured pipeline variable in the repository.
+//
+// Docs: https://developer.atlassian.com/cloud/bitbucket/rest/api-group-pipelines/#api-repositories-workspace-repo-slug-pipelines-config-variables-post
+func (p *Provider) UpsertEnvironmentVariable(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, key, value string) error {
+	var variable *PipelineVariable
+	url := fmt.Sprintf("%s/repositories/%s/pipelines_config/variables?pagelen=%d", p.APIURL(instanceURL), repositoryID, apiPageSize)
+	for url != "" && variable == nil {
+		var variables []PipelineVariable
+		next, err := p.fetchPaginatedList(ctx, oauthCtx, instanceURL, url, &variables)
+		if err != nil {
+			return errors.Wrap(err, "fetch paginated list")
+		}
+		for i := range variables {
+			if variables[i].Key == key {
+				variable = &variables[i]
+				break
+			}
+		}
+		url = next
+	}
+
+	body, err := json.Marshal(
+		PipelineVariable{
+			Key:     key,
+			Value:   value,
+			Secured: true,
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, "marshal pipeline variable")
+	}
+
+	apiURL := fmt.Sprintf("%s/repositories/%s/pipelines_config/variables", p.APIURL(instanceURL), repositoryID)
+	var code int
+	var resp string
+	tokenRefresher := tokenRefresher(
+		instanceURL,
+		oauthContext{
+			ClientID:     oauthCtx.ClientID,
+			ClientSecret: oauthCtx.ClientSecret,
+			RefreshToken: oauthCtx.RefreshToken,
+		},
+		oauthCtx.Refresher,
+	)
+	if variable == nil {
+		url = apiURL
+		code, _, resp, err = oauth.Post(ctx, p.client, url, &oauthCtx.AccessToken, bytes.NewReader(body), tokenRefresher)
+	} else {
+		url = fmt.Sprintf("%s/%s", apiURL, escapeFilePath(variable.UUID))
+		code, _, resp, err = oauth.Put(ctx, p.client, url, &oauthCtx.AccessToken, bytes.NewReader(body), tokenRefresher)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "upsert %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return common.Errorf(common.NotFound, "failed to upsert environment variable from URL %s", url)
+	} else if code >= 300 {
+		return errors.Errorf("failed to upsert environment variable from URL %s, status code: %d, body: %s",
+			url,
+			code,
+			resp,
+		)
+	}
+	return nil
+}
+
+// CreateWebhook creates a webhook in the repository with given payload.
+//
+// Docs: https://developer.atlassian.com/cloud/bitbucket/rest/api-group-repositories/#api-repositories-workspace-repo-slug-hooks-post
+func (p *Provider) CreateWebhook(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID string, payload []byte) (string, error) {
+	url := fmt.Sprintf("%s/repositories/%s/hooks", p.APIURL(instanceURL), repositoryID)
+	code, _, body, err := oauth.Post(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		bytes.NewReader(payload),
+		tokenRefresher(
+			instanceURL,
+			oauthContext{
+				ClientID:     oauthCtx.ClientID,
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return "", errors.Wrapf(err, "POST %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return "", common.Errorf(common.NotFound, "failed to create webhook through URL %s", url)
+	}
+
+	// Bitbucket returns 201 HTTP status codes upon successful webhook creation.
+	if code != http.StatusCreated {
+		return "", errors.Errorf("failed to create webhook through URL %s, status code: %d, body: %s",
+			url,
+			code,
+			body,
+		)
+	}
+
+	var webhookInfo WebhookInfo
+	if err = json.Unmarshal([]byte(body), &webhookInfo); err != nil {
+		return "", errors.Wrap(err, "unmarshal body")
+	}
+	return webhookInfo.UUID, nil
+}
+
+// PatchWebhook patches the webhook in the repository with given payload.
+//
+// Docs: https://developer.atlassian.com/cloud/bitbucket/rest/api-group-repositories/#api-repositories-workspace-repo-slug-hooks-uid-put
+func (p *Provider) PatchWebhook(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, webhookID string, payload []byte) error {
+	url := fmt.Sprintf("%s/repositories/%s/hooks/%s", p.APIURL(instanceURL), repositoryID, url.PathEscape(webhookID))
+	code, _, body, err := oauth.Put(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		bytes.NewReader(payload),
+		tokenRefresher(
+			instanceURL,
+			oauthContext{
+				ClientID:     oauthCtx.ClientID,
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return errors.Wrapf(err, "PUT %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return common.Errorf(common.NotFound, "failed to patch webhook through URL %s", url)
+	} else if code >= 300 {
+		return errors.Errorf("failed to patch webhook through URL %s, status code: %d, body: %s",
+			url,
+			code,
+			body,
+		)
+	}
+	return nil
+}
+
+// DeleteWebhook deletes the webhook from the repository.
+//
+// Docs: https://developer.atlassian.com/cloud/bitbucket/rest/api-group-repositories/#api-repositories-workspace-repo-slug-hooks-uid-delete
+func (p *Provider) DeleteWebhook(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, webhookID string) error {
+	url := fmt.Sprintf("%s/repositories/%s/hooks/%s", p.APIURL(instanceURL), repositoryID, url.PathEscape(webhookID))
+	code, _, body, err := oauth.Delete(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		tokenRefresher(
+			instanceURL,
+			oauthContext{
+				ClientID:     oauthCtx.ClientID,
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return errors.Wrapf(err, "DELETE %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return nil // It is OK if the webhook has already gone
+	} else if code >= 300 {
+		return errors.Errorf("failed to delete webhook through URL %s, status code: %d, body: %s",
+			url,
+			code,
+			body,
+		)
+	}
+	return nil
+}
+
+// fetchPaginatedList fetches a page of the paginated list from the URL and
+// unmarshals the values into v. It returns the URL of the next page, which is
+// empty if it is the last page.
+func (p *Provider) fetchPaginatedList(ctx context.Context, oauthCtx common.OauthContext, instanceURL, url string, v interface{}) (next string, err error) {
+	code, _, body, err := oauth.Get(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		tokenRefresher(
+			instanceURL,
+			oauthContext{
+				ClientID:     oauthCtx.ClientID,
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return "", errors.Wrapf(err, "GET %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return "", common.Errorf(common.NotFound, "failed to fetch list from URL %s", url)
+	} else if code >= 300 {
+		return "", errors.Errorf("failed to fetch list from URL %s, status code: %d, body: %s",
+			url,
+			code,
+			body,
+		)
+	}
+
+	var resp paginatedResponse
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		return "", errors.Wrap(err, "unmarshal body")
+	}
+	if len(resp.Values) > 0 {
+		if err := json.Unmarshal(resp.Values, v); err != nil {
+			return "", errors.Wrap(err, "unmarshal values")
+		}
+	}
+	return resp.Next, nil
+}
+
+// escapeFilePath escapes each segment of the file path.
+func escapeFilePath(filePath string) string {
+	segments := strings.Split(strings.Trim(filePath, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// oauthContext is the request context for refreshing oauth token.
+type oauthContext struct {
+	ClientID     string
+	ClientSecret string
+	RefreshToken string
+}
+
+func tokenRefresher(instanceURL string, oauthCtx oauthContext, refresher common.TokenRefresher) oauth.TokenRefresher {
+	return func(ctx context.Context, client *http.Client, oldToken *string) error {
+		grant := url.Values{}
+		grant.Set("grant_type", "refresh_token")
+		grant.Set("refresh_token", oauthCtx.RefreshToken)
+		r, err := requestOAuthToken(ctx, client, instanceURL, oauthCtx.ClientID, oauthCtx.ClientSecret, grant)
+		if err != nil {
+			return errors.Wrap(err, "failed to refresh OAuth token")
+		}
+
+		// Update the old token to new value for retries.
+		*oldToken = r.AccessToken
+
+		token := r.toVCSOAuthToken()
+		return refresher(token.AccessToken, token.RefreshToken, token.ExpiresTs)
+	}
+}
+
+// toVCS converts the commit to the VCS commit without the changed files.
+func (c Commit) toVCS() *vcs.Commit {
+	// Per Git convention, the message title and body are separated by two new line characters.
+	messages := strings.SplitN(c.Message, "\n\n", 2)
+	messageTitle := strings.TrimSpace(messages[0])
+	return &vcs.Commit{
+		ID:          c.Hash,
+		Title:       messageTitle,
+		Message:     c.Message,
+		CreatedTs:   c.Date.Unix(),
+		URL:         c.Links.HTML.Href,
+		AuthorName:  c.Author.name(),
+		AuthorEmail: c.Author.email(),
+	}
+}
+
+// ToVCS returns the push events in VCS format, one for each branch updated by the push.
+//
+// The commits are in chronological order and do not include the changed files,
+// which need to be fetched by FetchCommitByID.
+func (p WebhookPushEvent) ToVCS() []vcs.PushEvent {
+	var pushEventList []vcs.PushEvent
+	for _, change := range p.Push.Changes {
+		// Skip the deleted refs and tags.
+		if change.New == nil || change.New.Type != "branch" {
+			continue
+		}
+		var commitList []vcs.Commit
+		// Bitbucket lists the commits in reverse chronological order.
+		for i := len(change.Commits) - 1; i >= 0; i-- {
+			commitList = append(commitList, *change.Commits[i].toVCS())
+		}
+		pushEventList = append(pushEventList, vcs.PushEvent{
+			Ref:                fmt.Sprintf("refs/heads/%s", change.New.Name),
+			RepositoryID:       p.Repository.FullName,
+			RepositoryURL:      p.Repository.Links.HTML.Href,
+			RepositoryFullPath: p.Repository.FullName,
+			AuthorName:         p.Actor.DisplayName,
+			CommitList:         commitList,
+		})
+	}
+	return pushEventList
+}
@@ -0,0 +1,508 @@
+package bitbucket
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/vcs"
+	"github.com/bytebase/bytebase/plugin/vcs/internal/oauth"
+)
+
+func TestProvider_FetchCommitByID(t *testing.T) {
+	p := newProvider(
+		vcs.ProviderConfig{
+			Client: &http.Client{
+				Transport: &common.MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						switch r.URL.Path {
+						case "/2.0/repositories/octocat/hello-world/commit/7638417db6d5":
+							return &http.Response{
+								StatusCode: http.StatusOK,
+								Body: io.NopCloser(strings.NewReader(`
+{
+  "type": "commit",
+  "hash": "7638417db6d5",
+  "date": "2014-11-07T22:01:45+00:00",
+  "author": {
+    "type": "author",
+    "raw": "Monalisa Octocat <octocat@example.com>"
+  },
+  "message": "Add migration\n\nCreate the book table.",
+  "links": {
+    "html": {
+      "href": "https://bitbucket.org/octocat/hello-world/commits/7638417db6d5"
+    }
+  },
+  "parents": [
+    {
+      "type": "commit",
+      "hash": "1acc419d4d6a"
+    }
+  ]
+}
+`)),
+							}, nil
+						case "/2.0/repositories/octocat/hello-world/diffstat/7638417db6d5":
+							return &http.Response{
+								StatusCode: http.StatusOK,
+								Body: io.NopCloser(strings.NewReader(`
+{
+  "pagelen": 500,
+  "values": [
+    {"type": "diffstat", "status": "added", "old": null, "new": {"path": "prod/db##ver2##migrate##book.sql"}},
+    {"type": "diffstat", "status": "modified", "old": {"path": "README.md"}, "new": {"path": "README.md"}},
+    {"type": "diffstat", "status": "removed", "old": {"path": "obsolete.sql"}, "new": null}
+  ],
+  "page": 1,
+  "size": 3
+}
+`)),
+							}, nil
+						}
+						return nil, errors.Errorf("unexpected request path %s", r.URL.Path)
+					},
+				},
+			},
+		},
+	)
+
+	ctx := context.Background()
+	got, err := p.FetchCommitByID(ctx, common.OauthContext{}, bitbucketCloudURL, "octocat/hello-world", "7638417db6d5")
+	require.NoError(t, err)
+
+	want := &vcs.Commit{
+		ID:           "7638417db6d5",
+		Title:        "Add migration",
+		Message:      "Add migration\n\nCreate the book table.",
+		CreatedTs:    1415397705,
+		URL:          "https://bitbucket.org/octocat/hello-world/commits/7638417db6d5",
+		AuthorName:   "Monalisa Octocat",
+		AuthorEmail:  "octocat@example.com",
+		AddedList:    []string{"prod/db##ver2##migrate##book.sql"},
+		ModifiedList: []string{"README.md"},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestProvider_ExchangeOAuthToken(t *testing.T) {
+	p := newProvider(
+		vcs.ProviderConfig{
+			Client: &http.Client{
+				Transport: &common.MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						assert.Equal(t, "/site/oauth2/access_token", r.URL.Path)
+						assert.Equal(t, "application/x-www-form-urlencoded", r.Header.Get("Content-Type"))
+						clientID, clientSecret, ok := r.BasicAuth()
+						assert.True(t, ok)
+						assert.Equal(t, "test_client_id", clientID)
+						assert.Equal(t, "test_client_secret", clientSecret)
+
+						body, err := io.ReadAll(r.Body)
+						require.NoError(t, err)
+						assert.Equal(t, "code=test_code&grant_type=authorization_code", string(body))
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body: io.NopCloser(strings.NewReader(`
+{
+  "access_token": "test_access_token",
+  "scopes": "repository:write webhook",
+  "token_type": "bearer",
+  "expires_in": 7200,
+  "refresh_token": "test_refresh_token"
+}
+`)),
+						}, nil
+					},
+				},
+			},
+		},
+	)
+
+	ctx := context.Background()
+	got, err := p.ExchangeOAuthToken(
+		ctx,
+		bitbucketCloudURL,
+		&common.OAuthExchange{
+			ClientID:     "test_client_id",
+			ClientSecret: "test_client_secret",
+			Code:         "test_code",
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "test_access_token", got.AccessToken)
+	assert.Equal(t, "test_refresh_token", got.RefreshToken)
+	assert.Equal(t, int64(7200), got.ExpiresIn)
+	assert.Equal(t, got.CreatedAt+7200, got.ExpiresTs)
+}
+
+func TestProvider_FetchAllRepositoryList(t *testing.T) {
+	p := newProvider(
+		vcs.ProviderConfig{
+			Client: &http.Client{
+				Transport: &common.MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						assert.Equal(t, "/2.0/user/permissions/repositories", r.URL.Path)
+						if r.URL.Query().Get("page") == "" {
+							return &http.Response{
+								StatusCode: http.StatusOK,
+								Body: io.NopCloser(strings.NewReader(`
+{
+  "values": [
+    {
+      "permission": "admin",
+      "repository": {
+        "type": "repository",
+        "full_name": "octocat/hello-world",
+        "name": "hello-world",
+        "uuid": "{b7d3fa2a-f0d2-4ac4-bd52-8b0f1d9a1e35}",
+        "links": {"html": {"href": "https://bitbucket.org/octocat/hello-world"}}
+      }
+    }
+  ],
+  "next": "https://api.bitbucket.org/2.0/user/permissions/repositories?page=2"
+}
+`)),
+							}, nil
+						}
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body: io.NopCloser(strings.NewReader(`
+{
+  "values": [
+    {
+      "permission": "admin",
+      "repository": {
+        "type": "repository",
+        "full_name": "octocat/linguist",
+        "name": "linguist",
+        "uuid": "{0c7c5b0e-6a60-4f7b-9a53-54f3d0d6c8f1}",
+        "links": {"html": {"href": "https://bitbucket.org/octocat/linguist"}}
+      }
+    }
+  ]
+}
+`)),
+						}, nil
+					},
+				},
+			},
+		},
+	)
+
+	ctx := context.Background()
+	got, err := p.FetchAllRepositoryList(ctx, common.OauthContext{}, bitbucketCloudURL)
+	require.NoError(t, err)
+
+	want := []*vcs.Repository{
+		{
+			Name:     "hello-world",
+			FullPath: "octocat/hello-world",
+			WebURL:   "https://bitbucket.org/octocat/hello-world",
+		},
+		{
+			Name:     "linguist",
+			FullPath: "octocat/linguist",
+			WebURL:   "https://bitbucket.org/octocat/linguist",
+		},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestProvider_CreateFile(t *testing.T) {
+	p := newProvider(
+		vcs.ProviderConfig{
+			Client: &http.Client{
+				Transport: &common.MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						assert.Equal(t, "/2.0/repositories/octocat/hello-world/src", r.URL.Path)
+						require.NoError(t, r.ParseForm())
+						assert.Equal(t, "CREATE TABLE book (id INT);", r.PostForm.Get("prod/db##LATEST.sql"))
+						assert.Equal(t, "Update schema", r.PostForm.Get("message"))
+						assert.Equal(t, "main", r.PostForm.Get("branch"))
+						assert.Equal(t, "7638417db6d5", r.PostForm.Get("parents"))
+						return &http.Response{
+							StatusCode: http.StatusCreated,
+							Body:       io.NopCloser(strings.NewReader("")),
+						}, nil
+					},
+				},
+			},
+		},
+	)
+
+	ctx := context.Background()
+	err := p.CreateFile(
+		ctx,
+		common.OauthContext{},
+		bitbucketCloudURL,
+		"octocat/hello-world",
+		"prod/db##LATEST.sql",
+		vcs.FileCommitCreate{
+			Branch:        "main",
+			Content:       "CREATE TABLE book (id INT);",
+			CommitMessage: "Update schema",
+			LastCommitID:  "7638417db6d5",
+		},
+	)
+	require.NoError(t, err)
+}
+
+func TestProvider_ReadFileMeta(t *testing.T) {
+	p := newProvider(
+		vcs.ProviderConfig{
+			Client: &http.Client{
+				Transport: &common.MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						assert.Equal(t, "/2.0/repositories/octocat/hello-world/src/main/prod/db%23%23LATEST.sql", r.URL.EscapedPath())
+						assert.Equal(t, "meta", r.URL.Query().Get("format"))
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body: io.NopCloser(strings.NewReader(`
+{
+  "type": "commit_file",
+  "path": "prod/db##LATEST.sql",
+  "size": 27,
+  "commit": {
+    "type": "commit",
+    "hash": "7638417db6d5"
+  }
+}
+`)),
+						}, nil
+					},
+				},
+			},
+		},
+	)
+
+	ctx := context.Background()
+	got, err := p.ReadFileMeta(ctx, common.OauthContext{}, bitbucketCloudURL, "octocat/hello-world", "prod/db##LATEST.sql", "main")
+	require.NoError(t, err)
+
+	want := &vcs.FileMeta{
+		Name:         "db##LATEST.sql",
+		Path:         "prod/db##LATEST.sql",
+		Size:         27,
+		LastCommitID: "7638417db6d5",
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestProvider_CreateWebhook(t *testing.T) {
+	p := newProvider(
+		vcs.ProviderConfig{
+			Client: &http.Client{
+				Transport: &common.MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						assert.Equal(t, "/2.0/repositories/octocat/hello-world/hooks", r.URL.Path)
+						return &http.Response{
+							StatusCode: http.StatusCreated,
+							Body: io.NopCloser(strings.NewReader(`
+{
+  "type": "webhook_subscription",
+  "uuid": "{2d4c7a1e-8d7b-4c55-9a1c-0f3e2b6d5a90}",
+  "url": "https://bytebase.example.com/hook/bitbucket/1",
+  "active": true,
+  "events": ["repo:push"]
+}
+`)),
+						}, nil
+					},
+				},
+			},
+		},
+	)
+
+	ctx := context.Background()
+	got, err := p.CreateWebhook(ctx, common.OauthContext{}, bitbucketCloudURL, "octocat/hello-world", []byte(""))
+	require.NoError(t, err)
+	assert.Equal(t, "{2d4c7a1e-8d7b-4c55-9a1c-0f3e2b6d5a90}", got)
+}
+
+func TestProvider_ListPullRequestFile(t *testing.T) {
+	p := newProvider(
+		vcs.ProviderConfig{
+			Client: &http.Client{
+				Transport: &common.MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						switch r.URL.Path {
+						case "/2.0/repositories/octocat/hello-world/pullrequests/1":
+							return &http.Response{
+								StatusCode: http.StatusOK,
+								Body: io.NopCloser(strings.NewReader(`
+{
+  "id": 1,
+  "source": {
+    "branch": {"name": "feature/foo"},
+    "commit": {"hash": "7638417db6d5"}
+  },
+  "links": {"html": {"href": "https://bitbucket.org/octocat/hello-world/pull-requests/1"}}
+}
+`)),
+							}, nil
+						case "/2.0/repositories/octocat/hello-world/pullrequests/1/diffstat":
+							return &http.Response{
+								StatusCode: http.StatusOK,
+								Body: io.NopCloser(strings.NewReader(`
+{
+  "values": [
+    {"type": "diffstat", "status": "added", "old": null, "new": {"path": "prod/db##ver2##migrate##book.sql"}},
+    {"type": "diffstat", "status": "removed", "old": {"path": "prod/db##ver1##migrate##book.sql"}, "new": null}
+  ]
+}
+`)),
+							}, nil
+						}
+						return nil, errors.Errorf("unexpected request path %s", r.URL.Path)
+					},
+				},
+			},
+		},
+	)
+
+	ctx := context.Background()
+	got, err := p.ListPullRequestFile(ctx, common.OauthContext{}, bitbucketCloudURL, "octocat/hello-world", "1")
+	require.NoError(t, err)
+
+	want := []*vcs.PullRequestFile{
+		{
+			Path:         "prod/db##ver2##migrate##book.sql",
+			LastCommitID: "7638417db6d5",
+		},
+		{
+			Path:         "prod/db##ver1##migrate##book.sql",
+			LastCommitID: "7638417db6d5",
+			IsDeleted:    true,
+		},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestOAuth_RefreshToken(t *testing.T) {
+	ctx := context.Background()
+	client := &http.Client{
+		Transport: &common.MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				if r.URL.Path == "/site/oauth2/access_token" {
+					body, err := io.ReadAll(r.Body)
+					require.NoError(t, err)
+					assert.Equal(t, "grant_type=refresh_token&refresh_token=test_refresh_token", string(body))
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body: io.NopCloser(strings.NewReader(`
+{
+  "access_token": "refreshed_access_token",
+  "token_type": "bearer",
+  "expires_in": 7200,
+  "refresh_token": "test_refresh_token"
+}
+`)),
+					}, nil
+				}
+
+				token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+				if token == "expired" {
+					return &http.Response{
+						StatusCode: http.StatusUnauthorized,
+						Body:       io.NopCloser(strings.NewReader(`{"type": "error", "error": {"message": "Access token expired."}}`)),
+					}, nil
+				}
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader(`{}`)),
+				}, nil
+			},
+		},
+	}
+	token := "expired"
+
+	calledRefresher := false
+	refresher := func(_, _ string, _ int64) error {
+		calledRefresher = true
+		return nil
+	}
+
+	_, _, _, err := oauth.Get(
+		ctx,
+		client,
+		"https://api.bitbucket.org/2.0/user",
+		&token,
+		tokenRefresher(
+			bitbucketCloudURL,
+			oauthContext{
+				RefreshToken: "test_refresh_token",
+			},
+			refresher,
+		),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "refreshed_access_token", token)
+	assert.True(t, calledRefresher)
+}
+
+func TestWebhookPushEvent_ToVCS(t *testing.T) {
+	payload := `
+{
+  "push": {
+    "changes": [
+      {
+        "old": {"type": "branch", "name": "main", "target": {"hash": "1acc419d4d6a"}},
+        "new": {"type": "branch", "name": "main", "target": {"hash": "7638417db6d5"}},
+        "commits": [
+          {
+            "hash": "7638417db6d5",
+            "date": "2014-11-07T22:01:45+00:00",
+            "message": "Second commit",
+            "author": {"raw": "Monalisa Octocat <octocat@example.com>"},
+            "links": {"html": {"href": "https://bitbucket.org/octocat/hello-world/commits/7638417db6d5"}}
+          },
+          {
+            "hash": "5b7a0c4f2e1d",
+            "date": "2014-11-07T21:01:45+00:00",
+            "message": "First commit",
+            "author": {"raw": "Monalisa Octocat <octocat@example.com>"},
+            "links": {"html": {"href": "https://bitbucket.org/octocat/hello-world/commits/5b7a0c4f2e1d"}}
+          }
+        ]
+      },
+      {
+        "old": {"type": "tag", "name": "v1.0.0", "target": {"hash": "1acc419d4d6a"}},
+        "new": null,
+        "commits": []
+      }
+    ]
+  },
+  "repository": {
+    "full_name": "octocat/hello-world",
+    "name": "hello-world",
+    "links": {"html": {"href": "https://bitbucket.org/octocat/hello-world"}}
+  },
+  "actor": {
+    "display_name": "Monalisa Octocat"
+  }
+}
+`
+	var pushEvent WebhookPushEvent
+	require.NoError(t, json.Unmarshal([]byte(payload), &pushEvent))
+
+	got := pushEvent.ToVCS()
+	require.Len(t, got, 1)
+	assert.Equal(t, "refs/heads/main", got[0].Ref)
+	assert.Equal(t, "octocat/hello-world", got[0].RepositoryID)
+	assert.Equal(t, "https://bitbucket.org/octocat/hello-world", got[0].RepositoryURL)
+	assert.Equal(t, "Monalisa Octocat", got[0].AuthorName)
+	require.Len(t, got[0].CommitList, 2)
+	// The commits are in chronological order.
+	assert.Equal(t, "5b7a0c4f2e1d", got[0].CommitList[0].ID)
+	assert.Equal(t, "7638417db6d5", got[0].CommitList[1].ID)
+	assert.Equal(t, "octocat@example.com", got[0].CommitList[1].AuthorEmail)
+}
@@ -0,0 +1,1198 @@
+// Package bitbucketserver is the plugin for Bitbucket Server and Bitbucket Data Center.
+package bitbucketserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/vcs"
+	"github.com/bytebase/bytebase/plugin/vcs/internal/oauth"
+)
+
+const (
+	// apiPageSize is the default page size when making API requests.
+	apiPageSize = 100
+	// emptyCommitID is the commit ID used as the from hash when a ref is created.
+	emptyCommitID = "0000000000000000000000000000000000000000"
+)
+
+func init() {
+	vcs.Register(vcs.BitbucketServer, newProvider)
+}
+
+var _ vcs.Provider = (*Provider)(nil)
+
+// Provider is a Bitbucket Server VCS provider, which also works with Bitbucket
+// Data Center.
+//
+// Bitbucket Server identifies a repository by the project key and the
+// repository slug, the full path "{projectKey}/{repositorySlug}" is used as the
+// repository ID in all methods.
+type Provider struct {
+	client *http.Client
+}
+
+func newProvider(config vcs.ProviderConfig) vcs.Provider {
+	if config.Client == nil {
+		config.Client = &http.Client{}
+	}
+	return &Provider{
+		client: config.Client,
+	}
+}
+
+// APIURL returns the API URL path of Bitbucket Server.
+func (*Provider) APIURL(instanceURL string) string {
+	return fmt.Sprintf("%s/rest/api/1.0", instanceURL)
+}
+
+// Link is the API message for a Bitbucket Server link.
+type Link struct {
+	Href string `json:"href"`
+}
+
+// Links is the API message for Bitbucket Server links.
+type Links struct {
+	Self []Link `json:"self"`
+}
+
+// href returns the first self link.
+func (l Links) href() string {
+	if len(l.Self) == 0 {
+		return ""
+	}
+	return l.Self[0].Href
+}
+
+// User represents a Bitbucket Server API response for a user.
+type User struct {
+	Name         string `json:"name"`
+	EmailAddress string `json:"emailAddress"`
+	DisplayName  string `json:"displayName"`
+	Active       bool   `json:"active"`
+	Slug         string `json:"slug"`
+}
+
+// Project represents a Bitbucket Server API response for a project.
+type Project struct {
+	Key  string `json:"key"`
+	Name string `json:"name"`
+}
+
+// Repository represents a Bitbucket Server API response for a repository.
+type Repository struct {
+	ID      int64   `json:"id"`
+	Slug    string  `json:"slug"`
+	Name    string  `json:"name"`
+	Project Project `json:"project"`
+	Links   Links   `json:"links"`
+}
+
+// fullPath returns the full path of the repository, which is used as the
+// repository ID.
+func (r Repository) fullPath() string {
+	return fmt.Sprintf("%s/%s", r.Project.Key, r.Slug)
+}
+
+// CommitParent represents a Bitbucket Server API response for a commit parent.
+type CommitParent struct {
+	ID string `json:"id"`
+}
+
+// Commit represents a Bitbucket Server API response for a commit.
+type Commit struct {
+	ID     string `json:"id"`
+	Author User   `json:"author"`
+	// AuthorTimestamp is the author time in milliseconds.
+	AuthorTimestamp int64          `json:"authorTimestamp"`
+	Message         string         `json:"message"`
+	Parents         []CommitParent `json:"parents"`
+}
+
+// Path represents a Bitbucket Server API response for a file path.
+type Path struct {
+	ToString string `json:"toString"`
+}
+
+// Change represents a Bitbucket Server API response for the change of a file.
+type Change struct {
+	Path    Path  `json:"path"`
+	SrcPath *Path `json:"srcPath,omitempty"`
+	// Type is one of "ADD", "MODIFY", "DELETE", "MOVE" and "COPY".
+	Type string `json:"type"`
+}
+
+// Branch represents a Bitbucket Server API response for a branch.
+type Branch struct {
+	// ID is the full ref name, e.g. "refs/heads/main".
+	ID           string `json:"id"`
+	DisplayID    string `json:"displayId"`
+	LatestCommit string `json:"latestCommit"`
+}
+
+// BranchCreate represents a Bitbucket Server API request for creating a branch.
+type BranchCreate struct {
+	Name       string `json:"name"`
+	StartPoint string `json:"startPoint"`
+}
+
+// PullRequestRef is the API message for the source or destination ref of a pull request.
+type PullRequestRef struct {
+	ID           string `json:"id"`
+	LatestCommit string `json:"latestCommit,omitempty"`
+}
+
+// PullRequestCreate represents a Bitbucket Server API request for creating a pull request.
+type PullRequestCreate struct {
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	FromRef     PullRequestRef `json:"fromRef"`
+	ToRef       PullRequestRef `json:"toRef"`
+}
+
+// PullRequest represents a Bitbucket Server API response for a pull request.
+type PullRequest struct {
+	ID      int            `json:"id"`
+	FromRef PullRequestRef `json:"fromRef"`
+	ToRef   PullRequestRef `json:"toRef"`
+	Links   Links          `json:"links"`
+}
+
+// WebhookType is the Bitbucket Server webhook event type in the X-Event-Key header.
+type WebhookType string
+
+const (
+	// WebhookPush is the webhook type for pushing to the refs of a repository.
+	WebhookPush WebhookType = "repo:refs_changed"
+	// WebhookPing is the webhook type sent when testing the webhook connection.
+	WebhookPing WebhookType = "diagnostics:ping"
+)
+
+// WebhookConfiguration is the API message for the configuration of a webhook.
+type WebhookConfiguration struct {
+	// Secret is used as the key to generate the HMAC hex digest value in the
+	// X-Hub-Signature header.
+	Secret string `json:"secret,omitempty"`
+}
+
+// WebhookCreateOrUpdate represents a Bitbucket Server API request for creating
+// or updating a webhook.
+type WebhookCreateOrUpdate struct {
+	Name string `json:"name"`
+	// URL is the URL to which the payloads will be delivered.
+	URL           string               `json:"url"`
+	Active        bool                 `json:"active"`
+	Configuration WebhookConfiguration `json:"configuration"`
+	// Events determines what events the hook is triggered for, e.g. "repo:refs_changed".
+	Events []string `json:"events"`
+}
+
+// WebhookInfo represents a Bitbucket Server API response for the webhook information.
+type WebhookInfo struct {
+	ID int `json:"id"`
+}
+
+// WebhookRef is the API message for the ref changed by the push.
+type WebhookRef struct {
+	ID        string `json:"id"`
+	DisplayID string `json:"displayId"`
+	// Type is either "BRANCH" or "TAG".
+	Type string `json:"type"`
+}
+
+// WebhookPushChange is the API message for a ref change in the webhook push event.
+type WebhookPushChange struct {
+	Ref      WebhookRef `json:"ref"`
+	FromHash string     `json:"fromHash"`
+	ToHash   string     `json:"toHash"`
+	// Type is one of "ADD", "UPDATE" and "DELETE".
+	Type string `json:"type"`
+}
+
+// WebhookPushEvent is the API message for webhook push event.
+//
+// NOTE: Bitbucket Server includes neither the commits nor the changed files in
+// the payload, callers need to fetch them by FetchPushedCommitList and
+// FetchCommitByID.
+type WebhookPushEvent struct {
+	EventKey   WebhookType         `json:"eventKey"`
+	Actor      User                `json:"actor"`
+	Repository Repository          `json:"repository"`
+	Changes    []WebhookPushChange `json:"changes"`
+}
+
+// pagedResponse represents a Bitbucket Server API response of a paged list.
+type pagedResponse struct {
+	Values        json.RawMessage `json:"values"`
+	IsLastPage    bool            `json:"isLastPage"`
+	NextPageStart int             `json:"nextPageStart"`
+}
+
+// TryLogin tries to fetch the user info from the current OAuth context.
+//
+// Bitbucket Server returns the name of the authenticated user in the
+// X-AUSERNAME header of every REST response.
+//
+// Docs: https://developer.atlassian.com/server/bitbucket/rest/v811/api-group-system-maintenance/#api-api-latest-users-userslug-get
+func (p *Provider) TryLogin(ctx context.Context, oauthCtx common.OauthContext, instanceURL string) (*vcs.UserInfo, error) {
+	url := fmt.Sprintf("%s/application-properties", p.APIURL(instanceURL))
+	code, header, body, err := oauth.Get(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		tokenRefresher(
+			instanceURL,
+			oauthContext{
+				ClientID:     oauthCtx.ClientID,
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "GET %s", url)
+	}
+	if code >= 300 {
+		return nil, errors.Errorf("failed to read user info from URL %s, status code: %d, body: %s", url, code, body)
+	}
+
+	username := header.Get("X-AUSERNAME")
+	if username == "" {
+		return nil, errors.Errorf("failed to read user info from URL %s, the request is not authenticated", url)
+	}
+	return p.FetchUserInfo(ctx, oauthCtx, instanceURL, username)
+}
+
+// FetchCommitByID fetches the commit data by its ID from the repository,
+// including the files added and modified by the commit.
+//
+// Docs: https://developer.atlassian.com/server/bitbucket/rest/v811/api-group-repository/#api-api-latest-projects-projectkey-repos-repositoryslug-commits-commitid-get
+func (p *Provider) FetchCommitByID(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, commitID string) (*vcs.Commit, error) {
+	url := fmt.Sprintf("%s/%s/commits/%s", p.APIURL(instanceURL), repositoryPath(repositoryID), url.PathEscape(commitID))
+	code, _, body, err := oauth.Get(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		tokenRefresher(
+			instanceURL,
+			oauthContext{
+				ClientID:     oauthCtx.ClientID,
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "GET %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return nil, common.Errorf(common.NotFound, "failed to fetch commit data from URL %s", url)
+	} else if code >= 300 {
+		return nil, errors.Errorf("failed to fetch commit data from URL %s, status code: %d, body: %s", url, code, body)
+	}
+
+	commit := &Commit{}
+	if err := json.Unmarshal([]byte(body), commit); err != nil {
+		return nil, errors.Wrap(err, "unmarshal body")
+	}
+
+	var changeList []Change
+	changesURL := fmt.Sprintf("%s/changes", url)
+	if err := p.fetchPagedList(ctx, oauthCtx, instanceURL, changesURL, func(values json.RawMessage) error {
+		var changes []Change
+		if err := json.Unmarshal(values, &changes); err != nil {
+			return err
+		}
+		changeList = append(changeList, changes...)
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "fetch commit changes")
+	}
+
+	vcsCommit := commit.toVCS(instanceURL, repositoryID)
+	for _, change := range changeList {
+		switch change.Type {
+		case "ADD", "MOVE", "COPY":
+			vcsCommit.AddedList = append(vcsCommit.AddedList, change.Path.ToString)
+		case "MODIFY":
+			vcsCommit.ModifiedList = append(vcsCommit.ModifiedList, change.Path.ToString)
+		}
+	}
+	return vcsCommit, nil
+}
+
+// FetchPushedCommitList fetches the commits pushed by the ref change in
+// chronological order, without the changed files.
+//
+// If the ref is created by the push, only the latest commit of the ref is
+// returned because the commits may have been pushed to other branches already.
+//
+// Docs: https://developer.atlassian.com/server/bitbucket/rest/v811/api-group-repository/#api-api-latest-projects-projectkey-repos-repositoryslug-commits-get
+func (p *Provider) FetchPushedCommitList(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID string, change WebhookPushChange) ([]vcs.Commit, error) {
+	query := url.Values{}
+	query.Set("until", change.ToHash)
+	if change.FromHash != "" && change.FromHash != emptyCommitID {
+		query.Set("since", change.FromHash)
+	}
+	url := fmt.Sprintf("%s/%s/commits?%s", p.APIURL(instanceURL), repositoryPath(repositoryID), query.Encode())
+
+	var commitList []vcs.Commit
+	if err := p.fetchPagedList(ctx, oauthCtx, instanceURL, url, func(values json.RawMessage) error {
+		var commits []Commit
+		if err := json.Unmarshal(values, &commits); err != nil {
+			return err
+		}
+		for _, commit := range commits {
+			commitList = append(commitList, *commit.toVCS(instanceURL, repositoryID))
+		}
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "fetch commit list")
+	}
+	if query.Get("since") == "" && len(commitList) > 1 {
+		commitList = commitList[:1]
+	}
+
+	// Bitbucket Server lists the commits in reverse chronological order.
+	for i, j := 0, len(commitList)-1; i < j; i, j = i+1, j-1 {
+		commitList[i], commitList[j] = commitList[j], commitList[i]
+	}
+	return commitList, nil
+}
+
+// FetchUserInfo fetches user info of given user slug.
+//
+// Docs: https://developer.atlassian.com/server/bitbucket/rest/v811/api-group-system-maintenance/#api-api-latest-users-userslug-get
+func (p *Provider) FetchUserInfo(ctx context.Context, oauthCtx common.OauthContext, instanceURL, user string) (*vcs.UserInfo, error) {
+	url := fmt.Sprintf("%s/users/%s", p.APIURL(instanceURL), url.PathEscape(user))
+	code, _, body, err := oauth.Get(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		tokenRefresher(
+			instanceURL,
+			oauthContext{
+				ClientID:     oauthCtx.ClientID,
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "GET %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return nil, common.Errorf(common.NotFound, "failed to read user info from URL %s", url)
+	} else if code >= 300 {
+		return nil, errors.Errorf("failed to read user info from URL %s, status code: %d, body: %s", url, code, body)
+	}
+
+	var u User
+	if err := json.Unmarshal([]byte(body), &u); err != nil {
+		return nil, errors.Wrap(err, "unmarshal")
+	}
+	state := vcs.StateActive
+	if !u.Active {
+		state = vcs.StateArchived
+	}
+	return &vcs.UserInfo{
+		PublicEmail: u.EmailAddress,
+		Name:        u.DisplayName,
+		State:       state,
+	}, nil
+}
+
+// FetchRepositoryActiveMemberList fetches all active members of a repository.
+//
+// Bitbucket Server grants the repository access mostly through the project and
+// group permissions, which are not listed as the repository members, so syncing
+// members is not supported.
+func (*Provider) FetchRepositoryActiveMemberList(_ context.Context, _ common.OauthContext, _, _ string) ([]*vcs.RepositoryMember, error) {
+	return nil, errors.New("Bitbucket Server grants repository access through project and group permissions, syncing repository members is not supported")
+}
+
+// oauthResponse is a Bitbucket Server OAuth response.
+type oauthResponse struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error,omitempty"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// toVCSOAuthToken converts the response to *vcs.OAuthToken.
+func (o oauthResponse) toVCSOAuthToken() *vcs.OAuthToken {
+	oauthToken := &vcs.OAuthToken{
+		AccessToken:  o.AccessToken,
+		RefreshToken: o.RefreshToken,
+		ExpiresIn:    o.ExpiresIn,
+		CreatedAt:    time.Now().Unix(),
+	}
+	if oauthToken.ExpiresIn != 0 {
+		oauthToken.ExpiresTs = oauthToken.CreatedAt + oauthToken.ExpiresIn
+	}
+	return oauthToken
+}
+
+// requestOAuthToken requests the OAuth token endpoint with the client credentials and the grant.
+func requestOAuthToken(ctx context.Context, client *http.Client, instanceURL, clientID, clientSecret string, grant url.Values) (*oauthResponse, error) {
+	grant.Set("client_id", clientID)
+	grant.Set("client_secret", clientSecret)
+
+	url := fmt.Sprintf("%s/rest/oauth2/latest/token", instanceURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(grant.Encode()))
+	if err != nil {
+		return nil, errors.Wrapf(err, "construct POST %s", url)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "POST %s", url)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read OAuth response body, code %v", resp.StatusCode)
+	}
+
+	oauthResp := new(oauthResponse)
+	if err := json.Unmarshal(body, oauthResp); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal OAuth response body, code %v", resp.StatusCode)
+	}
+	if oauthResp.Error != "" {
+		return nil, errors.Errorf("failed to request OAuth token, error: %v, error_description: %v", oauthResp.Error, oauthResp.ErrorDescription)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("non-200 POST %s status code %d with body %q", url, resp.StatusCode, body)
+	}
+	return oauthResp, nil
+}
+
+// ExchangeOAuthToken exchanges OAuth content with the provided authorization code.
+//
+// Docs: https://confluence.atlassian.com/bitbucketserver/bitbucket-oauth-2-0-provider-api-1108483661.html
+func (p *Provider) ExchangeOAuthToken(ctx context.Context, instanceURL string, oauthExchange *common.OAuthExchange) (*vcs.OAuthToken, error) {
+	grant := url.Values{}
+	grant.Set("grant_type", "authorization_code")
+	grant.Set("code", oauthExchange.Code)
+	grant.Set("redirect_uri", oauthExchange.RedirectURL)
+	oauthResp, err := requestOAuthToken(ctx, p.client, instanceURL, oauthExchange.ClientID, oauthExchange.ClientSecret, grant)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to exchange OAuth token")
+	}
+	return oauthResp.toVCSOAuthToken(), nil
+}
+
+// FetchAllRepositoryList fetches all repositories where the authenticated user
+// has admin permissions, which is required to create webhook in the repository.
+//
+// Docs: https://developer.atlassian.com/server/bitbucket/rest/v811/api-group-repository/#api-api-latest-repos-get
+func (p *Provider) FetchAllRepositoryList(ctx context.Context, oauthCtx common.OauthContext, instanceURL string) ([]*vcs.Repository, error) {
+	var allRepos []*vcs.Repository
+	url := fmt.Sprintf("%s/repos?permission=REPO_ADMIN", p.APIURL(instanceURL))
+	if err := p.fetchPagedList(ctx, oauthCtx, instanceURL, url, func(values json.RawMessage) error {
+		var repos []Repository
+		if err := json.Unmarshal(values, &repos); err != nil {
+			return err
+		}
+		for _, r := range repos {
+			allRepos = append(allRepos,
+				&vcs.Repository{
+					ID:       r.ID,
+					Name:     r.Name,
+					FullPath: r.fullPath(),
+					WebURL:   r.Links.href(),
+				},
+			)
+		}
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "fetch repository list")
+	}
+	return allRepos, nil
+}
+
+// FetchRepositoryFileList fetches the all files from the given repository tree
+// recursively.
+//
+// Docs: https://developer.atlassian.com/server/bitbucket/rest/v811/api-group-repository/#api-api-latest-projects-projectkey-repos-repositoryslug-files-path-get
+func (p *Provider) FetchRepositoryFileList(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, ref, filePath string) ([]*vcs.RepositoryTreeNode, error) {
+	var allTreeNodes []*vcs.RepositoryTreeNode
+	prefix := strings.Trim(filePath, "/")
+	url := fmt.Sprintf("%s/%s/files/%s?at=%s", p.APIURL(instanceURL), repositoryPath(repositoryID), escapeFilePath(filePath), url.QueryEscape(ref))
+	if err := p.fetchPagedList(ctx, oauthCtx, instanceURL, url, func(values json.RawMessage) error {
+		// The file paths are relative to the given path.
+		var paths []string
+		if err := json.Unmarshal(values, &paths); err != nil {
+			return err
+		}
+		for _, path := range paths {
+			if prefix != "" {
+				path = fmt.Sprintf("%s/%s", prefix, path)
+			}
+			allTreeNodes = append(allTreeNodes,
+				&vcs.RepositoryTreeNode{
+					Path: path,
+					Type: "blob",
+				},
+			)
+		}
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "fetch file list")
+	}
+	return allTreeNodes, nil
+}
+
+// CreateFile creates a file at given path in the repository.
+//
+// Docs: https://developer.atlassian.com/server/bitbucket/rest/v811/api-group-repository/#api-api-latest-projects-projectkey-repos-repositoryslug-browse-path-put
+func (p *Provider) CreateFile(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, filePath string, fileCommitCreate vcs.FileCommitCreate) error {
+	form := url.Values{}
+	form.Set("content", fileCommitCreate.Content)
+	form.Set("message", fileCommitCreate.CommitMessage)
+	form.Set("branch", fileCommitCreate.Branch)
+	if fileCommitCreate.LastCommitID != "" {
+		// Bitbucket Server rejects the commit if the file has been changed since the source commit, which detects conflicting writes.
+		form.Set("sourceCommitId", fileCommitCreate.LastCommitID)
+	}
+
+	url := fmt.Sprintf("%s/%s/browse/%s", p.APIURL(instanceURL), repositoryPath(repositoryID), escapeFilePath(filePath))
+	code, _, resp, err := oauth.PutMultipartForm(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		form,
+		tokenRefresher(
+			instanceURL,
+			oauthContext{
+				ClientID:     oauthCtx.ClientID,
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return errors.Wrapf(err, "PUT %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return common.Errorf(common.NotFound, "failed to create/update file through URL %s", url)
+	} else if code >= 300 {
+		return errors.Errorf("failed to create/update file through URL %s, status code: %d, body: %s",
+			url,
+			code,
+			resp,
+		)
+	}
+	return nil
+}
+
+// OverwriteFile overwrites an existing file at given path in the repository.
+//
+// Docs: https://developer.atlassian.com/server/bitbucket/rest/v811/api-group-repository/#api-api-latest-projects-projectkey-repos-repositoryslug-browse-path-put
+func (p *Provider) OverwriteFile(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, filePath string, fileCommitCreate vcs.FileCommitCreate) error {
+	return p.CreateFile(ctx, oauthCtx, instanceURL, repositoryID, filePath, fileCommitCreate)
+}
+
+// ReadFileMeta reads the metadata of the given file in the repository.
+//
+// Bitbucket Server doesn't have an API for the file metadata, so the latest
+// commit changing the file is fetched in addition to the file content.
+//
+// Docs: https://developer.atlassian.com/server/bitbucket/rest/v811/api-group-repository/#api-api-latest-projects-projectkey-repos-repositoryslug-commits-get
+func (p *Provider) ReadFileMeta(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, filePath, ref string) (*vcs.FileMeta, error) {
+	content, err := p.ReadFileContent(ctx, oauthCtx, instanceURL, repositoryID, filePath, ref)
+	if err != nil {
+		return nil, errors.Wrap(err, "read file content")
+	}
+
+	url := fmt.Sprintf("%s/%s/commits?path=%s&until=%s&limit=1", p.APIURL(instanceURL), repositoryPath(repositoryID), url.QueryEscape(filePath), url.QueryEscape(ref))
+	code, _, body, err := oauth.Get(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		tokenRefresher(
+			instanceURL,
+			oauthContext{
+				ClientID:     oauthCtx.ClientID,
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "GET %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return nil, common.Errorf(common.NotFound, "failed to read the last commit of the file from URL %s", url)
+	} else if code >= 300 {
+		return nil, errors.Errorf("failed to read the last commit of the file from URL %s, status code: %d, body: %s", url, code, body)
+	}
+
+	var resp pagedResponse
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		return nil, errors.Wrap(err, "unmarshal body")
+	}
+	var commits []Commit
+	if err := json.Unmarshal(resp.Values, &commits); err != nil {
+		return nil, errors.Wrap(err, "unmarshal values")
+	}
+	if len(commits) == 0 {
+		return nil, common.Errorf(common.NotFound, "no commit changes the file %q", filePath)
+	}
+
+	filePath = strings.Trim(filePath, "/")
+	return &vcs.FileMeta{
+		Name:         filePath[strings.LastIndex(filePath, "/")+1:],
+		Path:         filePath,
+		Size:         int64(len(content)),
+		LastCommitID: commits[0].ID,
+	}, nil
+}
+
+// ReadFileContent reads the content of the given file in the repository.
+//
+// Docs: https://developer.atlassian.com/server/bitbucket/rest/v811/api-group-repository/#api-api-latest-projects-projectkey-repos-repositoryslug-raw-path-get
+func (p *Provider) ReadFileContent(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, filePath, ref string) (string, error) {
+	url := fmt.Sprintf("%s/%s/raw/%s?at=%s", p.APIURL(instanceURL), repositoryPath(repositoryID), escapeFilePath(filePath), url.QueryEscape(ref))
+	code, _, body, err := oauth.Get(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		tokenRefresher(
+			instanceURL,
+			oauthContext{
+				ClientID:     oauthCtx.ClientID,
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return "", errors.Wrapf(err, "GET %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return "", common.Errorf(common.NotFound, "failed to read file from URL %s", url)
+	} else if code >= 300 {
+		return "",
+			errors.Errorf("failed to read file from URL %s, status code: %d, body: %s",
+				url,
+				code,
+				body,
+			)
+	}
+	return body, nil
+}
+
+// ListPullRequestFile lists the changed files in the pull request.
+//
+// Docs: https://developer.atlassian.com/server/bitbucket/rest/v811/api-group-pull-requests/#api-api-latest-projects-projectkey-repos-repositoryslug-pull-requests-pullrequestid-changes-get
+func (p *Provider) ListPullRequestFile(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, pullRequestID string) ([]*vcs.PullRequestFile, error) {
+	pullRequest, err := p.getPullRequest(ctx, oauthCtx, instanceURL, repositoryID, pullRequestID)
+	if err != nil {
+		return nil, errors.Wrap(err, "get pull request")
+	}
+	lastCommitID := pullRequest.FromRef.LatestCommit
+
+	var res []*vcs.PullRequestFile
+	url := fmt.Sprintf("%s/%s/pull-requests/%s/changes", p.APIURL(instanceURL), repositoryPath(repositoryID), pullRequestID)
+	if err := p.fetchPagedList(ctx, oauthCtx, instanceURL, url, func(values json.RawMessage) error {
+		var changes []Change
+		if err := json.Unmarshal(values, &changes); err != nil {
+			return err
+		}
+		for _, change := range changes {
+			res = append(res, &vcs.PullRequestFile{
+				Path:         change.Path.ToString,
+				LastCommitID: lastCommitID,
+				IsDeleted:    change.Type == "DELETE",
+			})
+		}
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "fetch pull request changes")
+	}
+	return res, nil
+}
+
+// getPullRequest gets the pull request in the repository.
+//
+// Docs: https://developer.atlassian.com/server/bitbucket/rest/v811/api-group-pull-requests/#api-api-latest-projects-projectkey-repos-repositoryslug-pull-requests-pullrequestid-get
+func (p *Provider) getPullRequest(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, pullRequestID string) (*PullRequest, error) {
+	url := fmt.Sprintf("%s/%s/pull-requests/%s", p.APIURL(instanceURL), repositoryPath(repositoryID), pullRequestID)
+	code, _, body, err := oauth.Get(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		tokenRefresher(
+			instanceURL,
+			oauthContext{
+				ClientID:     oauthCtx.ClientID,
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "GET %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return nil, common.Errorf(common.NotFound, "failed to get pull request from URL %s", url)
+	} else if code >= 300 {
+		return nil, errors.Errorf("failed to get pull request from URL %s, status code: %d, body: %s",
+			url,
+			code,
+			body,
+		)
+	}
+
+	pullRequest := new(PullRequest)
+	if err := json.Unmarshal([]byte(body), pullRequest); err != nil {
+		return nil, errors.Wrap(err, "unmarshal body")
+	}
+	return pullRequest, nil
+}
+
+// GetBranch gets the given branch in the repository.
+//
+// Docs: https://developer.atlassian.com/server/bitbucket/rest/v811/api-group-repository/#api-api-latest-projects-projectkey-repos-repositoryslug-branches-get
+func (p *Provider) GetBranch(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, branchName string) (*vcs.BranchInfo, error) {
+	var branch *Branch
+	url := fmt.Sprintf("%s/%s/branches?filterText=%s", p.APIURL(instanceURL), repositoryPath(repositoryID), url.QueryEscape(branchName))
+	if err := p.fetchPagedList(ctx, oauthCtx, instanceURL, url, func(values json.RawMessage) error {
+		// The filter matches the branches containing the text, find the exact one.
+		var branches []Branch
+		if err := json.Unmarshal(values, &branches); err != nil {
+			return err
+		}
+		for i := range branches {
+			if branches[i].DisplayID == branchName {
+				branch = &branches[i]
+				break
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "fetch branch list")
+	}
+	if branch == nil {
+		return nil, common.Errorf(common.NotFound, "failed to get branch %q from URL %s", branchName, url)
+	}
+
+	return &vcs.BranchInfo{
+		Name:         branch.DisplayID,
+		LastCommitID: branch.LatestCommit,
+	}, nil
+}
+
+// CreateBranch creates the branch in the repository.
+//
+// Docs: https://developer.atlassian.com/server/bitbucket/rest/v811/api-group-repository/#api-api-latest-projects-projectkey-repos-repositoryslug-branches-post
+func (p *Provider) CreateBranch(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID string, branch *vcs.BranchInfo) error {
+	body, err := json.Marshal(
+		BranchCreate{
+			Name:       branch.Name,
+			StartPoint: branch.LastCommitID,
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, "marshal branch create")
+	}
+
+	url := fmt.Sprintf("%s/%s/branches", p.APIURL(instanceURL), repositoryPath(repositoryID))
+	code, _, resp, err := oauth.Post(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		bytes.NewReader(body),
+		tokenRefresher(
+			instanceURL,
+			oauthContext{
+				ClientID:     oauthCtx.ClientID,
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return errors.Wrapf(err, "POST %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return common.Errorf(common.NotFound, "failed to create branch from URL %s", url)
+	} else if code >= 300 {
+		return errors.Errorf("failed to create branch from URL %s, status code: %d, body: %s",
+			url,
+			code,
+			resp,
+		)
+	}
+
+	return nil
+}
+
+// CreatePullRequest creates the pull request in the repository.
+//
+// Docs: https://developer.atlassian.com/server/bitbucket/rest/v811/api-group-pull-requests/#api-api-latest-projects-projectkey-repos-repositoryslug-pull-requests-post
+func (p *Provider) CreatePullRequest(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID string, pullRequestCreate *vcs.PullRequestCreate) (*vcs.PullRequest, error) {
+	body, err := json.Marshal(
+		PullRequestCreate{
+			Title:       pullRequestCreate.Title,
+			Description: pullRequestCreate.Body,
+			FromRef: PullRequestRef{
+				ID: fmt.Sprintf("refs/heads/%s", pullRequestCreate.Head),
+			},
+			ToRef: PullRequestRef{
+				ID: fmt.Sprintf("refs/heads/%s", pullRequestCreate.Base),
+			},
+		},
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal pull request create")
+	}
+
+	url := fmt.Sprintf("%s/%s/pull-requests", p.APIURL(instanceURL), repositoryPath(repositoryID))
+	code, _, resp, err := oauth.Post(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		bytes.NewReader(body),
+		tokenRefresher(
+			instanceURL,
+			oauthContext{
+				ClientID:     oauthCtx.ClientID,
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "POST %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return nil, common.Errorf(common.NotFound, "failed to create pull request from URL %s", url)
+	} else if code >= 300 {
+		return nil, errors.Errorf("failed to create pull request from URL %s, status code: %d, body: %s",
+			url,
+			code,
+			resp,
+		)
+	}
+
+	var res PullRequest
+	if err := json.Unmarshal([]byte(resp), &res); err != nil {
+		return nil, err
+	}
+
+	return &vcs.PullRequest{
+		URL: res.Links.href(),
+	}, nil
+}
+
+// CreateCommitStatus creates the status of the commit.
+func (*Provider) CreateCommitStatus(_ context.Context, _ common.OauthContext, _, _, _ string, _ *vcs.CommitStatus) error {
+	return vcs.ErrNotImplemented
+}
+
+// UpsertPullRequestReview posts the review with inline comments to the pull request.
+func (*Provider) UpsertPullRequestReview(_ context.Context, _ common.OauthContext, _, _, _ string, _ *vcs.PullRequestReview) error {
+	return vcs.ErrNotImplemented
+}
+
+// UpsertEnvironmentVariable creates or updates the environment variable in the repository.
+//
+// Bitbucket Server doesn't have a built-in CI, so there is no repository variable.
+func (*Provider) UpsertEnvironmentVariable(_ context.Context, _ common.OauthContext, _, _, _, _ string) error {
+	return common.Errorf(common.NotImplemented, "Bitbucket Server doesn't support repository variables")
+}
+
+// CreateWebhook creates a webhook in the repository with given payload.
+//
+// Docs: https://developer.atlassian.com/server/bitbucket/rest/v811/api-group-repository/#api-api-latest-projects-projectkey-repos-repositoryslug-webhooks-post
+func (p *Provider) CreateWebhook(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID string, payload []byte) (string, error) {
+	url := fmt.Sprintf("%s/%s/webhooks", p.APIURL(instanceURL), repositoryPath(repositoryID))
+	code, _, body, err := oauth.Post(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		bytes.NewReader(payload),
+		tokenRefresher(
+			instanceURL,
+			oauthContext{
+				ClientID:     oauthCtx.ClientID,
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return "", errors.Wrapf(err, "POST %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return "", common.Errorf(common.NotFound, "failed to create webhook through URL %s", url)
+	}
+
+	// Bitbucket Server returns 201 HTTP status codes upon successful webhook creation.
+	if code != http.StatusCreated {
+		return "", errors.Errorf("failed to create webhook through URL %s, status code: %d, body: %s",
+			url,
+			code,
+			body,
+		)
+	}
+
+	var webhookInfo WebhookInfo
+	if err = json.Unmarshal([]byte(body), &webhookInfo); err != nil {
+		return "", errors.Wrap(err, "unmarshal body")
+	}
+	return fmt.Sprintf("%d", webhookInfo.ID), nil
+}
+
+// PatchWebhook patches the webhook in the repository with given payload.
+//
+// Docs: https://developer.atlassian.com/server/bitbucket/rest/v811/api-group-repository/#api-api-latest-projects-projectkey-repos-repositoryslug-webhooks-webhookid-put
+func (p *Provider) PatchWebhook(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, webhookID string, payload []byte) error {
+	url := fmt.Sprintf("%s/%s/webhooks/%s", p.APIURL(instanceURL), repositoryPath(repositoryID), url.PathEscape(webhookID))
+	code, _, body, err := oauth.Put(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		bytes.NewReader(payload),
+		tokenRefresher(
+			instanceURL,
+			oauthContext{
+				ClientID:     oauthCtx.ClientID,
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return errors.Wrapf(err, "PUT %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return common.Errorf(common.NotFound, "failed to patch webhook through URL %s", url)
+	} else if code >= 300 {
+		return errors.Errorf("failed to patch webhook through URL %s, status code: %d, body: %s",
+			url,
+			code,
+			body,
+		)
+	}
+	return nil
+}
+
+// DeleteWebhook deletes the webhook from the repository.
+//
+// Docs: https://developer.atlassian.com/server/bitbucket/rest/v811/api-group-repository/#api-api-latest-projects-projectkey-repos-repositoryslug-webhooks-webhookid-delete
+func (p *Provider) DeleteWebhook(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, webhookID string) error {
+	url := fmt.Sprintf("%s/%s/webhooks/%s", p.APIURL(instanceURL), repositoryPath(repositoryID), url.PathEscape(webhookID))
+	code, _, body, err := oauth.Delete(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		tokenRefresher(
+			instanceURL,
+			oauthContext{
+				ClientID:     oauthCtx.ClientID,
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return errors.Wrapf(err, "DELETE %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return nil // It is OK if the webhook has already gone
+	} else if code >= 300 {
+		return errors.Errorf("failed to delete webhook through URL %s, status code: %d, body: %s",
+			url,
+			code,
+			body,
+		)
+	}
+	return nil
+}
+
+// fetchPagedList fetches all pages of the paged list from the URL and calls
+// handleValues with the values of each page.
+func (p *Provider) fetchPagedList(ctx context.Context, oauthCtx common.OauthContext, instanceURL, url string, handleValues func(values json.RawMessage) error) error {
+	separator := "?"
+	if strings.Contains(url, "?") {
+		separator = "&"
+	}
+	start := 0
+	for {
+		pageURL := fmt.Sprintf("%s%sstart=%d&limit=%d", url, separator, start, apiPageSize)
+		code, _, body, err := oauth.Get(
+			ctx,
+			p.client,
+			pageURL,
+			&oauthCtx.AccessToken,
+			tokenRefresher(
+				instanceURL,
+				oauthContext{
+					ClientID:     oauthCtx.ClientID,
+					ClientSecret: oauthCtx.ClientSecret,
+					RefreshToken: oauthCtx.RefreshToken,
+				},
+				oauthCtx.Refresher,
+			),
+		)
+		if err != nil {
+			return errors.Wrapf(err, "GET %s", pageURL)
+		}
+
+		if code == http.StatusNotFound {
+			return common.Errorf(common.NotFound, "failed to fetch list from URL %s", pageURL)
+		} else if code >= 300 {
+			return errors.Errorf("failed to fetch list from URL %s, status code: %d, body: %s",
+				pageURL,
+				code,
+				body,
+			)
+		}
+
+		var resp pagedResponse
+		if err := json.Unmarshal([]byte(body), &resp); err != nil {
+			return errors.Wrap(err, "unmarshal body")
+		}
+		if len(resp.Values) > 0 {
+			if err := handleValues(resp.Values); err != nil {
+				return errors.Wrap(err, "unmarshal values")
+			}
+		}
+		if resp.IsLastPage {
+			return nil
+		}
+		start = resp.NextPageStart
+	}
+}
+
+// repositoryPath returns the API path of the repository with the full path
+// "{projectKey}/{repositorySlug}".
+func repositoryPath(repositoryID string) string {
+	projectKey, repositorySlug, _ := strings.Cut(repositoryID, "/")
+	return fmt.Sprintf("projects/%s/repos/%s", url.PathEscape(projectKey), url.PathEscape(repositorySlug))
+}
+
+// escapeFilePath escapes each segment of the file path.
+func escapeFilePath(filePath string) string {
+	segments := strings.Split(strings.Trim(filePath, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// oauthContext is the request context for refreshing oauth token.
+type oauthContext struct {
+	ClientID     string
+	ClientSecret string
+	RefreshToken string
+}
+
+func tokenRefresher(instanceURL string, oauthCtx oauthContext, refresher common.TokenRefresher) oauth.TokenRefresher {
+	return func(ctx context.Context, client *http.Client, oldToken *string) error {
+		grant := url.Values{}
+		grant.Set("grant_type", "refresh_token")
+		grant.Set("refresh_token", oauthCtx.RefreshToken)
+		r, err := requestOAuthToken(ctx, client, instanceURL, oauthCtx.ClientID, oauthCtx.ClientSecret, grant)
+		if err != nil {
+			return errors.Wrap(err, "failed to refresh OAuth token")
+		}
+
+		// Update the old token to new value for retries.
+		*oldToken = r.AccessToken
+
+		token := r.toVCSOAuthToken()
+		return refresher(token.AccessToken, token.RefreshToken, token.ExpiresTs)
+	}
+}
+
+// toVCS converts the commit to the VCS commit without the changed files.
+func (c Commit) toVCS(instanceURL, repositoryID string) *vcs.Commit {
+	// Per Git convention, the message title and body are separated by two new line characters.
+	messages := strings.SplitN(c.Message, "\n\n", 2)
+	messageTitle := strings.TrimSpace(messages[0])
+	projectKey, repositorySlug, _ := strings.Cut(repositoryID, "/")
+	return &vcs.Commit{
+		ID:          c.ID,
+		Title:       messageTitle,
+		Message:     c.Message,
+		CreatedTs:   time.UnixMilli(c.AuthorTimestamp).Unix(),
+		URL:         fmt.Sprintf("%s/projects/%s/repos/%s/commits/%s", instanceURL, projectKey, repositorySlug, c.ID),
+		AuthorName:  c.Author.DisplayName,
+		AuthorEmail: c.Author.EmailAddress,
+	}
+}
+
+// ToVCS returns the push events in VCS format without the commits, one for each
+// branch updated by the push, along with the ref changes to fetch the commits
+// by FetchPushedCommitList.
+func (p WebhookPushEvent) ToVCS() ([]vcs.PushEvent, []WebhookPushChange) {
+	var pushEventList []vcs.PushEvent
+	var changeList []WebhookPushChange
+	for _, change := range p.Changes {
+		// Skip the deleted refs and tags.
+		if change.Type == "DELETE" || change.Ref.Type != "BRANCH" {
+			continue
+		}
+		pushEventList = append(pushEventList, vcs.PushEvent{
+			Ref:                change.Ref.ID,
+			RepositoryID:       p.Repository.fullPath(),
+			RepositoryURL:      p.Repository.Links.href(),
+			RepositoryFullPath: p.Repository.fullPath(),
+			AuthorName:         p.Actor.DisplayName,
+		})
+		changeList = append(changeList, change)
+	}
+	return pushEventList, changeList
+}
@@ -0,0 +1,429 @@
+package bitbucketserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/vcs"
+	"github.com/bytebase/bytebase/plugin/vcs/internal/oauth"
+)
+
+const testInstanceURL = "https://bitbucket.example.com"
+
+func TestProvider_FetchCommitByID(t *testing.T) {
+	p := newProvider(
+		vcs.ProviderConfig{
+			Client: &http.Client{
+				Transport: &common.MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						switch r.URL.Path {
+						case "/rest/api/1.0/projects/OCTO/repos/hello-world/commits/7638417db6d5":
+							return &http.Response{
+								StatusCode: http.StatusOK,
+								Body: io.NopCloser(strings.NewReader(`
+{
+  "id": "7638417db6d5",
+  "displayId": "7638417db6d",
+  "author": {
+    "name": "octocat",
+    "emailAddress": "octocat@example.com",
+    "displayName": "Monalisa Octocat"
+  },
+  "authorTimestamp": 1415397705000,
+  "message": "Add migration\n\nCreate the book table.",
+  "parents": [
+    {"id": "1acc419d4d6a"}
+  ]
+}
+`)),
+							}, nil
+						case "/rest/api/1.0/projects/OCTO/repos/hello-world/commits/7638417db6d5/changes":
+							return &http.Response{
+								StatusCode: http.StatusOK,
+								Body: io.NopCloser(strings.NewReader(`
+{
+  "values": [
+    {"path": {"toString": "prod/db##ver2##migrate##book.sql"}, "type": "ADD"},
+    {"path": {"toString": "README.md"}, "type": "MODIFY"},
+    {"path": {"toString": "obsolete.sql"}, "type": "DELETE"}
+  ],
+  "isLastPage": true
+}
+`)),
+							}, nil
+						}
+						return nil, errors.Errorf("unexpected request path %s", r.URL.Path)
+					},
+				},
+			},
+		},
+	)
+
+	ctx := context.Background()
+	got, err := p.FetchCommitByID(ctx, common.OauthContext{}, testInstanceURL, "OCTO/hello-world", "7638417db6d5")
+	require.NoError(t, err)
+
+	want := &vcs.Commit{
+		ID:           "7638417db6d5",
+		Title:        "Add migration",
+		Message:      "Add migration\n\nCreate the book table.",
+		CreatedTs:    1415397705,
+		URL:          "https://bitbucket.example.com/projects/OCTO/repos/hello-world/commits/7638417db6d5",
+		AuthorName:   "Monalisa Octocat",
+		AuthorEmail:  "octocat@example.com",
+		AddedList:    []string{"prod/db##ver2##migrate##book.sql"},
+		ModifiedList: []string{"README.md"},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestProvider_FetchPushedCommitList(t *testing.T) {
+	tests := []struct {
+		name      string
+		change    WebhookPushChange
+		wantQuery string
+		want      []string
+	}{
+		{
+			name: "update branch",
+			change: WebhookPushChange{
+				FromHash: "1acc419d4d6a",
+				ToHash:   "7638417db6d5",
+				Type:     "UPDATE",
+			},
+			wantQuery: "limit=100&since=1acc419d4d6a&start=0&until=7638417db6d5",
+			want:      []string{"2bcc419d4d6a", "7638417db6d5"},
+		},
+		{
+			name: "create branch",
+			change: WebhookPushChange{
+				FromHash: emptyCommitID,
+				ToHash:   "7638417db6d5",
+				Type:     "ADD",
+			},
+			wantQuery: "limit=100&start=0&until=7638417db6d5",
+			want:      []string{"7638417db6d5"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := newProvider(
+				vcs.ProviderConfig{
+					Client: &http.Client{
+						Transport: &common.MockRoundTripper{
+							MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+								assert.Equal(t, "/rest/api/1.0/projects/OCTO/repos/hello-world/commits", r.URL.Path)
+								assert.Equal(t, test.wantQuery, r.URL.Query().Encode())
+								return &http.Response{
+									StatusCode: http.StatusOK,
+									Body: io.NopCloser(strings.NewReader(`
+{
+  "values": [
+    {"id": "7638417db6d5", "message": "Add migration"},
+    {"id": "2bcc419d4d6a", "message": "Add schema"}
+  ],
+  "isLastPage": true
+}
+`)),
+								}, nil
+							},
+						},
+					},
+				},
+			).(*Provider)
+
+			ctx := context.Background()
+			got, err := p.FetchPushedCommitList(ctx, common.OauthContext{}, testInstanceURL, "OCTO/hello-world", test.change)
+			require.NoError(t, err)
+
+			var gotIDs []string
+			for _, commit := range got {
+				gotIDs = append(gotIDs, commit.ID)
+			}
+			assert.Equal(t, test.want, gotIDs)
+		})
+	}
+}
+
+func TestProvider_ExchangeOAuthToken(t *testing.T) {
+	p := newProvider(
+		vcs.ProviderConfig{
+			Client: &http.Client{
+				Transport: &common.MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						assert.Equal(t, "/rest/oauth2/latest/token", r.URL.Path)
+						assert.Equal(t, "application/x-www-form-urlencoded", r.Header.Get("Content-Type"))
+
+						body, err := io.ReadAll(r.Body)
+						require.NoError(t, err)
+						assert.Equal(t, "client_id=test_client_id&client_secret=test_client_secret&code=test_code&grant_type=authorization_code&redirect_uri=http%3A%2F%2Flocalhost%3A3000", string(body))
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body: io.NopCloser(strings.NewReader(`
+{
+  "access_token": "test_access_token",
+  "token_type": "bearer",
+  "expires_in": 7200,
+  "refresh_token": "test_refresh_token"
+}
+`)),
+						}, nil
+					},
+				},
+			},
+		},
+	)
+
+	ctx := context.Background()
+	got, err := p.ExchangeOAuthToken(ctx, testInstanceURL,
+		&common.OAuthExchange{
+			ClientID:     "test_client_id",
+			ClientSecret: "test_client_secret",
+			Code:         "test_code",
+			RedirectURL:  "http://localhost:3000",
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "test_access_token", got.AccessToken)
+	assert.Equal(t, "test_refresh_token", got.RefreshToken)
+	assert.Equal(t, got.CreatedAt+7200, got.ExpiresTs)
+}
+
+func TestProvider_FetchRepositoryFileList(t *testing.T) {
+	p := newProvider(
+		vcs.ProviderConfig{
+			Client: &http.Client{
+				Transport: &common.MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						assert.Equal(t, "/rest/api/1.0/projects/OCTO/repos/hello-world/files/prod", r.URL.Path)
+						assert.Equal(t, "main", r.URL.Query().Get("at"))
+
+						// Return two pages to make sure all pages are fetched.
+						if r.URL.Query().Get("start") == "0" {
+							return &http.Response{
+								StatusCode: http.StatusOK,
+								Body:       io.NopCloser(strings.NewReader(`{"values": ["db##ver1##migrate##book.sql"], "isLastPage": false, "nextPageStart": 1}`)),
+							}, nil
+						}
+						assert.Equal(t, "1", r.URL.Query().Get("start"))
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(strings.NewReader(`{"values": ["nested/db##ver2##migrate##author.sql"], "isLastPage": true}`)),
+						}, nil
+					},
+				},
+			},
+		},
+	)
+
+	ctx := context.Background()
+	got, err := p.FetchRepositoryFileList(ctx, common.OauthContext{}, testInstanceURL, "OCTO/hello-world", "main", "prod")
+	require.NoError(t, err)
+
+	want := []*vcs.RepositoryTreeNode{
+		{
+			Path: "prod/db##ver1##migrate##book.sql",
+			Type: "blob",
+		},
+		{
+			Path: "prod/nested/db##ver2##migrate##author.sql",
+			Type: "blob",
+		},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestProvider_CreateFile(t *testing.T) {
+	p := newProvider(
+		vcs.ProviderConfig{
+			Client: &http.Client{
+				Transport: &common.MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						assert.Equal(t, http.MethodPut, r.Method)
+						assert.Equal(t, "/rest/api/1.0/projects/OCTO/repos/hello-world/browse/prod/db##ver1##migrate##book.sql", r.URL.Path)
+
+						require.NoError(t, r.ParseMultipartForm(1<<20))
+						assert.Equal(t, "main", r.FormValue("branch"))
+						assert.Equal(t, "CREATE TABLE book;", r.FormValue("content"))
+						assert.Equal(t, "Create book", r.FormValue("message"))
+						assert.Equal(t, "7638417db6d5", r.FormValue("sourceCommitId"))
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(strings.NewReader(`{"id": "2bcc419d4d6a"}`)),
+						}, nil
+					},
+				},
+			},
+		},
+	)
+
+	ctx := context.Background()
+	err := p.CreateFile(ctx, common.OauthContext{}, testInstanceURL, "OCTO/hello-world", "prod/db##ver1##migrate##book.sql",
+		vcs.FileCommitCreate{
+			Branch:        "main",
+			Content:       "CREATE TABLE book;",
+			CommitMessage: "Create book",
+			LastCommitID:  "7638417db6d5",
+		},
+	)
+	require.NoError(t, err)
+}
+
+func TestProvider_GetBranch(t *testing.T) {
+	p := newProvider(
+		vcs.ProviderConfig{
+			Client: &http.Client{
+				Transport: &common.MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						assert.Equal(t, "/rest/api/1.0/projects/OCTO/repos/hello-world/branches", r.URL.Path)
+						assert.Contains(t, "main", r.URL.Query().Get("filterText"))
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body: io.NopCloser(strings.NewReader(`
+{
+  "values": [
+    {"id": "refs/heads/feature/main", "displayId": "feature/main", "latestCommit": "1acc419d4d6a"},
+    {"id": "refs/heads/main", "displayId": "main", "latestCommit": "7638417db6d5"}
+  ],
+  "isLastPage": true
+}
+`)),
+						}, nil
+					},
+				},
+			},
+		},
+	)
+
+	ctx := context.Background()
+	got, err := p.GetBranch(ctx, common.OauthContext{}, testInstanceURL, "OCTO/hello-world", "main")
+	require.NoError(t, err)
+	assert.Equal(t, &vcs.BranchInfo{Name: "main", LastCommitID: "7638417db6d5"}, got)
+
+	_, err = p.GetBranch(ctx, common.OauthContext{}, testInstanceURL, "OCTO/hello-world", "mai")
+	assert.True(t, common.ErrorCode(err) == common.NotFound)
+}
+
+func TestOAuth_RefreshToken(t *testing.T) {
+	ctx := context.Background()
+	client := &http.Client{
+		Transport: &common.MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				if r.URL.Path == "/rest/oauth2/latest/token" {
+					body, err := io.ReadAll(r.Body)
+					require.NoError(t, err)
+					assert.Equal(t, "client_id=&client_secret=&grant_type=refresh_token&refresh_token=test_refresh_token", string(body))
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body: io.NopCloser(strings.NewReader(`
+{
+  "access_token": "refreshed_access_token",
+  "token_type": "bearer",
+  "expires_in": 7200,
+  "refresh_token": "test_refresh_token"
+}
+`)),
+					}, nil
+				}
+
+				token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+				if token == "expired" {
+					return &http.Response{
+						StatusCode: http.StatusUnauthorized,
+						Body:       io.NopCloser(strings.NewReader(`{"errors": [{"message": "Authentication failed. Please check your credentials and try again.", "exceptionName": "com.atlassian.bitbucket.auth.IncorrectPasswordAuthenticationException"}]}`)),
+					}, nil
+				}
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader(`{}`)),
+				}, nil
+			},
+		},
+	}
+	token := "expired"
+
+	calledRefresher := false
+	refresher := func(_, _ string, _ int64) error {
+		calledRefresher = true
+		return nil
+	}
+
+	_, _, _, err := oauth.Get(
+		ctx,
+		client,
+		fmt.Sprintf("%s/rest/api/1.0/application-properties", testInstanceURL),
+		&token,
+		tokenRefresher(
+			testInstanceURL,
+			oauthContext{
+				RefreshToken: "test_refresh_token",
+			},
+			refresher,
+		),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "refreshed_access_token", token)
+	assert.True(t, calledRefresher)
+}
+
+func TestWebhookPushEvent_ToVCS(t *testing.T) {
+	payload := `
+{
+  "eventKey": "repo:refs_changed",
+  "actor": {"name": "octocat", "displayName": "Monalisa Octocat"},
+  "repository": {
+    "slug": "hello-world",
+    "id": 84,
+    "name": "Hello World",
+    "project": {"key": "OCTO", "name": "Octocat"},
+    "links": {"self": [{"href": "https://bitbucket.example.com/projects/OCTO/repos/hello-world/browse"}]}
+  },
+  "changes": [
+    {
+      "ref": {"id": "refs/heads/main", "displayId": "main", "type": "BRANCH"},
+      "fromHash": "1acc419d4d6a",
+      "toHash": "7638417db6d5",
+      "type": "UPDATE"
+    },
+    {
+      "ref": {"id": "refs/tags/v1.0", "displayId": "v1.0", "type": "TAG"},
+      "fromHash": "0000000000000000000000000000000000000000",
+      "toHash": "7638417db6d5",
+      "type": "ADD"
+    },
+    {
+      "ref": {"id": "refs/heads/obsolete", "displayId": "obsolete", "type": "BRANCH"},
+      "fromHash": "1acc419d4d6a",
+      "toHash": "0000000000000000000000000000000000000000",
+      "type": "DELETE"
+    }
+  ]
+}
+`
+	var pushEvent WebhookPushEvent
+	require.NoError(t, json.Unmarshal([]byte(payload), &pushEvent))
+
+	gotEvents, gotChanges := pushEvent.ToVCS()
+	wantEvents := []vcs.PushEvent{
+		{
+			Ref:                "refs/heads/main",
+			RepositoryID:       "OCTO/hello-world",
+			RepositoryURL:      "https://bitbucket.example.com/projects/OCTO/repos/hello-world/browse",
+			RepositoryFullPath: "OCTO/hello-world",
+			AuthorName:         "Monalisa Octocat",
+		},
+	}
+	assert.Equal(t, wantEvents, gotEvents)
+	require.Len(t, gotChanges, 1)
+	assert.Equal(t, "7638417db6d5", gotChanges[0].ToHash)
+}
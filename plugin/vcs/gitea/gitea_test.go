@@ -0,0 +1,612 @@
+package gitea
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/vcs"
+	"github.com/bytebase/bytebase/plugin/vcs/internal/oauth"
+)
+
+const testInstanceURL = "https://gitea.example.com"
+
+func TestProvider_FetchUserInfo(t *testing.T) {
+	p := newProvider(
+		vcs.ProviderConfig{
+			Client: &http.Client{
+				Transport: &common.MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						assert.Equal(t, "/api/v1/users/octocat", r.URL.Path)
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body: io.NopCloser(strings.NewReader(`
+{
+  "id": 1,
+  "login": "octocat",
+  "full_name": "monalisa octocat",
+  "email": "octocat@example.com",
+  "avatar_url": "https://gitea.example.com/avatars/1",
+  "is_admin": false
+}
+`)),
+						}, nil
+					},
+				},
+			},
+		},
+	)
+
+	ctx := context.Background()
+	got, err := p.FetchUserInfo(ctx, common.OauthContext{}, testInstanceURL, "octocat")
+	require.NoError(t, err)
+
+	want := &vcs.UserInfo{
+		PublicEmail: "octocat@example.com",
+		Name:        "monalisa octocat",
+		State:       vcs.StateActive,
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestProvider_FetchRepositoryActiveMemberList(t *testing.T) {
+	p := newProvider(
+		vcs.ProviderConfig{
+			Client: &http.Client{
+				Transport: &common.MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						switch r.URL.Path {
+						case "/api/v1/repos/octocat/hello-world/collaborators":
+							return &http.Response{
+								StatusCode: http.StatusOK,
+								Body: io.NopCloser(strings.NewReader(`
+[
+  {"id": 1, "login": "octocat", "full_name": "monalisa octocat", "email": "octocat@example.com"},
+  {"id": 2, "login": "hubot", "full_name": "", "email": "hubot@example.com"}
+]
+`)),
+							}, nil
+						case "/api/v1/repos/octocat/hello-world/collaborators/octocat/permission":
+							return &http.Response{
+								StatusCode: http.StatusOK,
+								Body:       io.NopCloser(strings.NewReader(`{"permission": "admin"}`)),
+							}, nil
+						case "/api/v1/repos/octocat/hello-world/collaborators/hubot/permission":
+							return &http.Response{
+								StatusCode: http.StatusOK,
+								Body:       io.NopCloser(strings.NewReader(`{"permission": "write"}`)),
+							}, nil
+						}
+						return nil, errors.Errorf("unexpected request path %q", r.URL.Path)
+					},
+				},
+			},
+		},
+	)
+
+	ctx := context.Background()
+	got, err := p.FetchRepositoryActiveMemberList(ctx, common.OauthContext{}, testInstanceURL, "octocat/hello-world")
+	require.NoError(t, err)
+
+	want := []*vcs.RepositoryMember{
+		{
+			Name:         "monalisa octocat",
+			Email:        "octocat@example.com",
+			Role:         common.ProjectOwner,
+			VCSRole:      string(RepositoryRoleAdmin),
+			State:        vcs.StateActive,
+			RoleProvider: vcs.Gitea,
+		},
+		{
+			Name:         "hubot",
+			Email:        "hubot@example.com",
+			Role:         common.ProjectDeveloper,
+			VCSRole:      string(RepositoryRoleWrite),
+			State:        vcs.StateActive,
+			RoleProvider: vcs.Gitea,
+		},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestProvider_FetchCommitByID(t *testing.T) {
+	p := newProvider(
+		vcs.ProviderConfig{
+			Client: &http.Client{
+				Transport: &common.MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						assert.Equal(t, "/api/v1/repos/octocat/hello-world/git/commits/7638417db6d59f3c431d3e1f261cc637155684cd", r.URL.Path)
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body: io.NopCloser(strings.NewReader(`
+{
+  "sha": "7638417db6d59f3c431d3e1f261cc637155684cd",
+  "html_url": "https://gitea.example.com/octocat/hello-world/commit/7638417db6d59f3c431d3e1f261cc637155684cd",
+  "commit": {
+    "message": "Fix all the bugs\n\nAnd add migrations.",
+    "author": {
+      "name": "Monalisa Octocat",
+      "email": "octocat@example.com",
+      "date": "2014-11-07T22:01:45Z"
+    }
+  },
+  "files": [
+    {"filename": "prod/v1__create_table.sql", "status": "added"},
+    {"filename": "prod/v0__baseline.sql", "status": "modified"},
+    {"filename": "README.md", "status": "removed"}
+  ]
+}
+`)),
+						}, nil
+					},
+				},
+			},
+		},
+	)
+
+	ctx := context.Background()
+	got, err := p.FetchCommitByID(ctx, common.OauthContext{}, testInstanceURL, "octocat/hello-world", "7638417db6d59f3c431d3e1f261cc637155684cd")
+	require.NoError(t, err)
+
+	want := &vcs.Commit{
+		ID:           "7638417db6d59f3c431d3e1f261cc637155684cd",
+		Title:        "Fix all the bugs",
+		Message:      "Fix all the bugs\n\nAnd add migrations.",
+		CreatedTs:    1415397705,
+		URL:          "https://gitea.example.com/octocat/hello-world/commit/7638417db6d59f3c431d3e1f261cc637155684cd",
+		AuthorName:   "Monalisa Octocat",
+		AuthorEmail:  "octocat@example.com",
+		AddedList:    []string{"prod/v1__create_table.sql"},
+		ModifiedList: []string{"prod/v0__baseline.sql"},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestProvider_ExchangeOAuthToken(t *testing.T) {
+	p := newProvider(
+		vcs.ProviderConfig{
+			Client: &http.Client{
+				Transport: &common.MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						assert.Equal(t, "/login/oauth/access_token", r.URL.Path)
+
+						body, err := io.ReadAll(r.Body)
+						require.NoError(t, err)
+						var oauthCtx oauthContext
+						require.NoError(t, json.Unmarshal(body, &oauthCtx))
+						assert.Equal(t, "authorization_code", oauthCtx.GrantType)
+						assert.Equal(t, "test_code", oauthCtx.Code)
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body: io.NopCloser(strings.NewReader(`
+{
+  "access_token": "gta_e72e16c7e42f292c6912e7710c838347ae178b4a",
+  "token_type": "bearer",
+  "expires_in": 3600,
+  "refresh_token": "gtr_1b4a2e77838347a7e420ce178f2e7c6912e16924"
+}
+`)),
+						}, nil
+					},
+				},
+			},
+		},
+	)
+
+	ctx := context.Background()
+	got, err := p.ExchangeOAuthToken(ctx, testInstanceURL,
+		&common.OAuthExchange{
+			ClientID:     "test_client_id",
+			ClientSecret: "test_client_secret",
+			Code:         "test_code",
+			RedirectURL:  "http://localhost:3000",
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "gta_e72e16c7e42f292c6912e7710c838347ae178b4a", got.AccessToken)
+	assert.Equal(t, "gtr_1b4a2e77838347a7e420ce178f2e7c6912e16924", got.RefreshToken)
+	assert.Equal(t, got.CreatedAt+3600, got.ExpiresTs)
+}
+
+func TestProvider_FetchAllRepositoryList(t *testing.T) {
+	p := newProvider(
+		vcs.ProviderConfig{
+			Client: &http.Client{
+				Transport: &common.MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						assert.Equal(t, "/api/v1/user/repos", r.URL.Path)
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body: io.NopCloser(strings.NewReader(`
+[
+  {
+    "id": 1296269,
+    "name": "hello-world",
+    "full_name": "octocat/hello-world",
+    "html_url": "https://gitea.example.com/octocat/hello-world",
+    "permissions": {"admin": true, "push": true, "pull": true}
+  },
+  {
+    "id": 1296270,
+    "name": "read-only",
+    "full_name": "octocat/read-only",
+    "html_url": "https://gitea.example.com/octocat/read-only",
+    "permissions": {"admin": false, "push": false, "pull": true}
+  }
+]
+`)),
+						}, nil
+					},
+				},
+			},
+		},
+	)
+
+	ctx := context.Background()
+	got, err := p.FetchAllRepositoryList(ctx, common.OauthContext{}, testInstanceURL)
+	require.NoError(t, err)
+
+	want := []*vcs.Repository{
+		{
+			ID:       1296269,
+			Name:     "hello-world",
+			FullPath: "octocat/hello-world",
+			WebURL:   "https://gitea.example.com/octocat/hello-world",
+		},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestProvider_FetchRepositoryFileList(t *testing.T) {
+	p := newProvider(
+		vcs.ProviderConfig{
+			Client: &http.Client{
+				Transport: &common.MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						assert.Equal(t, "/api/v1/repos/octocat/hello-world/git/trees/main", r.URL.Path)
+						if r.URL.Query().Get("page") == "1" {
+							return &http.Response{
+								StatusCode: http.StatusOK,
+								Body: io.NopCloser(strings.NewReader(`
+{
+  "sha": "9fb037999f264ba9a7fc6274d15fa3ae2ab98312",
+  "tree": [
+    {"path": "prod", "type": "tree"},
+    {"path": "prod/v1__create_table.sql", "type": "blob"}
+  ],
+  "truncated": true,
+  "page": 1,
+  "total_count": 3
+}
+`)),
+							}, nil
+						}
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body: io.NopCloser(strings.NewReader(`
+{
+  "sha": "9fb037999f264ba9a7fc6274d15fa3ae2ab98312",
+  "tree": [
+    {"path": "README.md", "type": "blob"}
+  ],
+  "truncated": false,
+  "page": 2,
+  "total_count": 3
+}
+`)),
+						}, nil
+					},
+				},
+			},
+		},
+	)
+
+	ctx := context.Background()
+	got, err := p.FetchRepositoryFileList(ctx, common.OauthContext{}, testInstanceURL, "octocat/hello-world", "main", "prod")
+	require.NoError(t, err)
+
+	want := []*vcs.RepositoryTreeNode{
+		{
+			Path: "prod/v1__create_table.sql",
+			Type: "blob",
+		},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestProvider_CreateFile(t *testing.T) {
+	p := newProvider(
+		vcs.ProviderConfig{
+			Client: &http.Client{
+				Transport: &common.MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						assert.Equal(t, "/api/v1/repos/octocat/hello-world/contents/prod/v1__create_table.sql", r.URL.Path)
+
+						body, err := io.ReadAll(r.Body)
+						require.NoError(t, err)
+						var fileCommit FileCommit
+						require.NoError(t, json.Unmarshal(body, &fileCommit))
+
+						switch r.Method {
+						case http.MethodPost:
+							assert.Empty(t, fileCommit.SHA)
+						case http.MethodPut:
+							assert.Equal(t, "3d21ec53a331a6f037a91c368710b99387d012c1", fileCommit.SHA)
+						default:
+							return nil, errors.Errorf("unexpected method %q", r.Method)
+						}
+						return &http.Response{
+							StatusCode: http.StatusCreated,
+							Body:       io.NopCloser(strings.NewReader(`{}`)),
+						}, nil
+					},
+				},
+			},
+		},
+	)
+
+	ctx := context.Background()
+	err := p.CreateFile(ctx, common.OauthContext{}, testInstanceURL, "octocat/hello-world", "prod/v1__create_table.sql",
+		vcs.FileCommitCreate{
+			Branch:        "main",
+			Content:       "CREATE TABLE t (id INT);",
+			CommitMessage: "Create table",
+		},
+	)
+	require.NoError(t, err)
+
+	err = p.CreateFile(ctx, common.OauthContext{}, testInstanceURL, "octocat/hello-world", "prod/v1__create_table.sql",
+		vcs.FileCommitCreate{
+			Branch:        "main",
+			Content:       "CREATE TABLE t (id INT);",
+			CommitMessage: "Update table",
+			LastCommitID:  "3d21ec53a331a6f037a91c368710b99387d012c1",
+		},
+	)
+	require.NoError(t, err)
+}
+
+func TestProvider_ReadFileContent(t *testing.T) {
+	p := newProvider(
+		vcs.ProviderConfig{
+			Client: &http.Client{
+				Transport: &common.MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						assert.Equal(t, "/api/v1/repos/octocat/hello-world/contents/README.md", r.URL.Path)
+						assert.Equal(t, "main", r.URL.Query().Get("ref"))
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body: io.NopCloser(strings.NewReader(`
+{
+  "type": "file",
+  "encoding": "base64",
+  "size": 12,
+  "name": "README.md",
+  "path": "README.md",
+  "content": "SGVsbG8gR2l0ZWEh",
+  "sha": "3d21ec53a331a6f037a91c368710b99387d012c1",
+  "last_commit_sha": "7638417db6d59f3c431d3e1f261cc637155684cd"
+}
+`)),
+						}, nil
+					},
+				},
+			},
+		},
+	)
+
+	ctx := context.Background()
+	got, err := p.ReadFileContent(ctx, common.OauthContext{}, testInstanceURL, "octocat/hello-world", "README.md", "main")
+	require.NoError(t, err)
+	assert.Equal(t, "Hello Gitea!", got)
+
+	meta, err := p.ReadFileMeta(ctx, common.OauthContext{}, testInstanceURL, "octocat/hello-world", "README.md", "main")
+	require.NoError(t, err)
+	want := &vcs.FileMeta{
+		Name:         "README.md",
+		Path:         "README.md",
+		Size:         12,
+		LastCommitID: "3d21ec53a331a6f037a91c368710b99387d012c1",
+	}
+	assert.Equal(t, want, meta)
+}
+
+func TestProvider_CreateWebhook(t *testing.T) {
+	p := newProvider(
+		vcs.ProviderConfig{
+			Client: &http.Client{
+				Transport: &common.MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						assert.Equal(t, "/api/v1/repos/octocat/hello-world/hooks", r.URL.Path)
+						return &http.Response{
+							StatusCode: http.StatusCreated,
+							Body: io.NopCloser(strings.NewReader(`
+{
+  "id": 12345678,
+  "type": "gitea",
+  "active": true,
+  "events": ["push"],
+  "config": {"content_type": "json", "url": "https://example.com/webhook"}
+}
+`)),
+						}, nil
+					},
+				},
+			},
+		},
+	)
+
+	ctx := context.Background()
+	got, err := p.CreateWebhook(ctx, common.OauthContext{}, testInstanceURL, "octocat/hello-world", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "12345678", got)
+}
+
+func TestProvider_ListPullRequestFile(t *testing.T) {
+	p := newProvider(
+		vcs.ProviderConfig{
+			Client: &http.Client{
+				Transport: &common.MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						assert.Equal(t, "/api/v1/repos/octocat/hello-world/pulls/1/files", r.URL.Path)
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body: io.NopCloser(strings.NewReader(`
+[
+  {
+    "filename": "prod/v1__create_table.sql",
+    "status": "added",
+    "contents_url": "https://gitea.example.com/api/v1/repos/octocat/hello-world/contents/prod/v1__create_table.sql?ref=6dcb09b5b57875f334f61aebed695e2e4193db5e"
+  },
+  {
+    "filename": "prod/v0__baseline.sql",
+    "status": "deleted",
+    "contents_url": "https://gitea.example.com/api/v1/repos/octocat/hello-world/contents/prod/v0__baseline.sql?ref=6dcb09b5b57875f334f61aebed695e2e4193db5e"
+  }
+]
+`)),
+						}, nil
+					},
+				},
+			},
+		},
+	)
+
+	ctx := context.Background()
+	got, err := p.ListPullRequestFile(ctx, common.OauthContext{}, testInstanceURL, "octocat/hello-world", "1")
+	require.NoError(t, err)
+
+	want := []*vcs.PullRequestFile{
+		{
+			Path:         "prod/v1__create_table.sql",
+			LastCommitID: "6dcb09b5b57875f334f61aebed695e2e4193db5e",
+			IsDeleted:    false,
+		},
+		{
+			Path:         "prod/v0__baseline.sql",
+			LastCommitID: "6dcb09b5b57875f334f61aebed695e2e4193db5e",
+			IsDeleted:    true,
+		},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestOAuth_RefreshToken(t *testing.T) {
+	ctx := context.Background()
+	client := &http.Client{
+		Transport: &common.MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				if r.URL.Path == "/login/oauth/access_token" {
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body: io.NopCloser(strings.NewReader(`
+{
+  "access_token": "gta_e72e16c7e42f292c6912e7710c838347ae178b4a",
+  "token_type": "bearer",
+  "expires_in": 3600,
+  "refresh_token": "gtr_1b4a2e77838347a7e420ce178f2e7c6912e16924"
+}
+`)),
+					}, nil
+				}
+
+				token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+				if token == "expired" {
+					return &http.Response{
+						StatusCode: http.StatusUnauthorized,
+						Body: io.NopCloser(strings.NewReader(`
+{"error":"invalid_token","error_description":"Token is expired. You can either do re-authorization or token refresh."}
+`)),
+					}, nil
+				}
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader(`{}`)),
+				}, nil
+			},
+		},
+	}
+	token := "expired"
+
+	calledRefresher := false
+	refresher := func(_, _ string, _ int64) error {
+		calledRefresher = true
+		return nil
+	}
+
+	_, _, _, err := oauth.Get(
+		ctx,
+		client,
+		testInstanceURL+"/api/v1/users/octocat",
+		&token,
+		tokenRefresher(
+			testInstanceURL,
+			oauthContext{},
+			refresher,
+		),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "gta_e72e16c7e42f292c6912e7710c838347ae178b4a", token)
+	assert.True(t, calledRefresher)
+}
+
+func TestWebhookPushEvent_ToVCS(t *testing.T) {
+	payload := `
+{
+  "ref": "refs/heads/main",
+  "before": "1acc419d4d6a9ce985db7be48c6349a0475975b5",
+  "after": "7638417db6d59f3c431d3e1f261cc637155684cd",
+  "commits": [
+    {
+      "id": "7638417db6d59f3c431d3e1f261cc637155684cd",
+      "message": "Add migration\n\nCreate the table.",
+      "url": "https://gitea.example.com/octocat/hello-world/commit/7638417db6d59f3c431d3e1f261cc637155684cd",
+      "author": {"name": "Monalisa Octocat", "email": "octocat@example.com", "username": "octocat"},
+      "timestamp": "2014-11-07T22:01:45Z",
+      "added": ["prod/v1__create_table.sql"],
+      "removed": [],
+      "modified": ["README.md"]
+    }
+  ],
+  "repository": {
+    "id": 1296269,
+    "full_name": "octocat/hello-world",
+    "html_url": "https://gitea.example.com/octocat/hello-world"
+  },
+  "sender": {"login": "octocat"}
+}
+`
+	var pushEvent WebhookPushEvent
+	require.NoError(t, json.Unmarshal([]byte(payload), &pushEvent))
+
+	got := pushEvent.ToVCS()
+	want := vcs.PushEvent{
+		Ref:                "refs/heads/main",
+		RepositoryID:       "octocat/hello-world",
+		RepositoryURL:      "https://gitea.example.com/octocat/hello-world",
+		RepositoryFullPath: "octocat/hello-world",
+		AuthorName:         "octocat",
+		CommitList: []vcs.Commit{
+			{
+				ID:           "7638417db6d59f3c431d3e1f261cc637155684cd",
+				Title:        "Add migration",
+				Message:      "Add migration\n\nCreate the table.",
+				CreatedTs:    1415397705,
+				URL:          "https://gitea.example.com/octocat/hello-world/commit/7638417db6d59f3c431d3e1f261cc637155684cd",
+				AuthorName:   "Monalisa Octocat",
+				AuthorEmail:  "octocat@example.com",
+				AddedList:    []string{"prod/v1__create_table.sql"},
+				ModifiedList: []string{"README.md"},
+			},
+		},
+	}
+	assert.Equal(t, want, got)
+}
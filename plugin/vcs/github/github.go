@@ -131,6 +131,8 @@ const (
 	WebhookPush WebhookType = "push"
 	// WebhookPing is the webhook type for ping.
 	WebhookPing WebhookType = "ping"
+	// WebhookPullRequest is the webhook type for pull request.
+	WebhookPullRequest WebhookType = "pull_request"
 )
 
 // WebhookInfo represents a GitHub API response for the webhook information.
@@ -208,6 +210,31 @@ type WebhookPushEvent struct {
 	Commits    []WebhookCommit   `json:"commits"`
 }
 
+// WebhookPullRequestBranch is the API message for the head or base branch of a webhook pull request.
+type WebhookPullRequestBranch struct {
+	Ref string `json:"ref"`
+	SHA string `json:"sha"`
+}
+
+// WebhookPullRequestInfo is the API message for webhook pull request.
+type WebhookPullRequestInfo struct {
+	HTMLURL string                   `json:"html_url"`
+	Title   string                   `json:"title"`
+	Merged  bool                     `json:"merged"`
+	Head    WebhookPullRequestBranch `json:"head"`
+	Base    WebhookPullRequestBranch `json:"base"`
+}
+
+// WebhookPullRequestEvent is the API message for webhook pull request event.
+type WebhookPullRequestEvent struct {
+	// Action is one of "opened", "reopened", "synchronize", "closed", "edited" and so on.
+	Action      string                 `json:"action"`
+	Number      int                    `json:"number"`
+	PullRequest WebhookPullRequestInfo `json:"pull_request"`
+	Repository  WebhookRepository      `json:"repository"`
+	Sender      WebhookSender          `json:"sender"`
+}
+
 // fetchUserInfoImpl fetches user information from the given resourceURI, which
 // should be either "user" or "users/{username}".
 func (p *Provider) fetchUserInfoImpl(ctx context.Context, oauthCtx common.OauthContext, instanceURL, resourceURI string) (*vcs.UserInfo, error) {
@@ -1060,6 +1087,240 @@ func (p *Provider) CreatePullRequest(ctx context.Context, oauthCtx common.OauthC
 	}, nil
 }
 
+// CommitStatusCreate is the API message for creating a commit status.
+type CommitStatusCreate struct {
+	// State is one of "error", "failure", "pending" and "success".
+	State       string `json:"state"`
+	TargetURL   string `json:"target_url,omitempty"`
+	Description string `json:"description"`
+	Context     string `json:"context"`
+}
+
+// CreateCommitStatus creates the status of the commit.
+//
+// Docs: https://docs.github.com/en/rest/commits/statuses#create-a-commit-status
+func (p *Provider) CreateCommitStatus(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, commitID string, status *vcs.CommitStatus) error {
+	state := "pending"
+	switch status.State {
+	case vcs.CommitStatusSuccess:
+		state = "success"
+	case vcs.CommitStatusFailure:
+		state = "failure"
+	case vcs.CommitStatusError:
+		state = "error"
+	}
+	url := fmt.Sprintf("%s/repos/%s/statuses/%s", p.APIURL(instanceURL), repositoryID, commitID)
+	return oauth.WriteResource(
+		ctx,
+		p.client,
+		http.MethodPost,
+		url,
+		&oauthCtx.AccessToken,
+		tokenRefresher(
+			instanceURL,
+			oauthContext{
+				ClientID:     oauthCtx.ClientID,
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+		"commit status",
+		CommitStatusCreate{
+			State:       state,
+			TargetURL:   status.TargetURL,
+			Description: status.Description,
+			Context:     status.Context,
+		},
+	)
+}
+
+// Comment is the API message for a comment on the pull request, which is
+// either an issue comment or a review comment.
+type Comment struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+}
+
+// IssueCommentCreate is the API message for creating or updating an issue comment.
+type IssueCommentCreate struct {
+	Body string `json:"body"`
+}
+
+// ReviewCommentCreate is the API message for creating a review comment on a
+// line of the pull request diff.
+type ReviewCommentCreate struct {
+	CommitID string `json:"commit_id"`
+	Path     string `json:"path"`
+	Line     int    `json:"line"`
+	// Side is either "LEFT" for the deletions or "RIGHT" for the additions.
+	Side string `json:"side"`
+	Body string `json:"body"`
+}
+
+// UpsertPullRequestReview posts the review with inline comments to the pull
+// request. The review body is kept in a single issue comment updated in place,
+// and the inline review comments of the previous review are deleted before
+// posting the new ones.
+//
+// Docs: https://docs.github.com/en/rest/pulls/comments
+func (p *Provider) UpsertPullRequestReview(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, pullRequestID string, review *vcs.PullRequestReview) error {
+	marker := review.Marker()
+
+	reviewComments, err := p.listComments(ctx, oauthCtx, instanceURL, fmt.Sprintf("%s/repos/%s/pulls/%s/comments", p.APIURL(instanceURL), repositoryID, pullRequestID))
+	if err != nil {
+		return errors.Wrap(err, "list review comments")
+	}
+	for _, comment := range reviewComments {
+		if !strings.Contains(comment.Body, marker) {
+			continue
+		}
+		if err := p.deleteComment(ctx, oauthCtx, instanceURL, fmt.Sprintf("%s/repos/%s/pulls/comments/%d", p.APIURL(instanceURL), repositoryID, comment.ID)); err != nil {
+			return errors.Wrap(err, "delete review comment")
+		}
+	}
+
+	issueComments, err := p.listComments(ctx, oauthCtx, instanceURL, fmt.Sprintf("%s/repos/%s/issues/%s/comments", p.APIURL(instanceURL), repositoryID, pullRequestID))
+	if err != nil {
+		return errors.Wrap(err, "list issue comments")
+	}
+	method, url := http.MethodPost, fmt.Sprintf("%s/repos/%s/issues/%s/comments", p.APIURL(instanceURL), repositoryID, pullRequestID)
+	for _, comment := range issueComments {
+		if strings.Contains(comment.Body, marker) {
+			method, url = http.MethodPatch, fmt.Sprintf("%s/repos/%s/issues/comments/%d", p.APIURL(instanceURL), repositoryID, comment.ID)
+			break
+		}
+	}
+	if err := oauth.WriteResource(
+		ctx,
+		p.client,
+		method,
+		url,
+		&oauthCtx.AccessToken,
+		tokenRefresher(
+			instanceURL,
+			oauthContext{
+				ClientID:     oauthCtx.ClientID,
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+		"issue comment",
+		IssueCommentCreate{Body: fmt.Sprintf("%s\n\n%s", review.Body, marker)},
+	); err != nil {
+		return err
+	}
+
+	url = fmt.Sprintf("%s/repos/%s/pulls/%s/comments", p.APIURL(instanceURL), repositoryID, pullRequestID)
+	for _, comment := range review.CommentList {
+		if err := oauth.WriteResource(
+			ctx,
+			p.client,
+			http.MethodPost,
+			url,
+			&oauthCtx.AccessToken,
+			tokenRefresher(
+				instanceURL,
+				oauthContext{
+					ClientID:     oauthCtx.ClientID,
+					ClientSecret: oauthCtx.ClientSecret,
+					RefreshToken: oauthCtx.RefreshToken,
+				},
+				oauthCtx.Refresher,
+			),
+			"review comment",
+			ReviewCommentCreate{
+				CommitID: review.CommitID,
+				Path:     comment.Path,
+				Line:     comment.Line,
+				Side:     "RIGHT",
+				Body:     fmt.Sprintf("%s\n\n%s", comment.Body, marker),
+			},
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listComments lists all the issue comments or the review comments from the URL.
+func (p *Provider) listComments(ctx context.Context, oauthCtx common.OauthContext, instanceURL, url string) ([]Comment, error) {
+	var allComments []Comment
+	for page := 1; ; page++ {
+		pageURL := fmt.Sprintf("%s?per_page=%d&page=%d", url, apiPageSize, page)
+		code, _, body, err := oauth.Get(
+			ctx,
+			p.client,
+			pageURL,
+			&oauthCtx.AccessToken,
+			tokenRefresher(
+				instanceURL,
+				oauthContext{
+					ClientID:     oauthCtx.ClientID,
+					ClientSecret: oauthCtx.ClientSecret,
+					RefreshToken: oauthCtx.RefreshToken,
+				},
+				oauthCtx.Refresher,
+			),
+		)
+		if err != nil {
+			return nil, errors.Wrapf(err, "GET %s", pageURL)
+		}
+		if code == http.StatusNotFound {
+			return nil, common.Errorf(common.NotFound, "failed to list comments from URL %s", pageURL)
+		} else if code >= 300 {
+			return nil, errors.Errorf("failed to list comments from URL %s, status code: %d, body: %s",
+				pageURL,
+				code,
+				body,
+			)
+		}
+
+		var comments []Comment
+		if err := json.Unmarshal([]byte(body), &comments); err != nil {
+			return nil, err
+		}
+		if len(comments) == 0 {
+			return allComments, nil
+		}
+		allComments = append(allComments, comments...)
+	}
+}
+
+// deleteComment deletes the issue comment or the review comment by the URL.
+func (p *Provider) deleteComment(ctx context.Context, oauthCtx common.OauthContext, instanceURL, url string) error {
+	code, _, body, err := oauth.Delete(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		tokenRefresher(
+			instanceURL,
+			oauthContext{
+				ClientID:     oauthCtx.ClientID,
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return errors.Wrapf(err, "DELETE %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return nil // It is OK if the comment has already gone
+	} else if code >= 300 {
+		return errors.Errorf("failed to delete comment through URL %s, status code: %d, body: %s",
+			url,
+			code,
+			body,
+		)
+	}
+	return nil
+}
+
 // RepositorySecretUpdate is the API message to update the repository secret.
 type RepositorySecretUpdate struct {
 	EncryptedValue string `json:"encrypted_value"`
@@ -1437,3 +1698,37 @@ func (p WebhookPushEvent) ToVCS() vcs.PushEvent {
 		CommitList:         commitList,
 	}
 }
+
+// ToVCS returns the pull request event in VCS format. It returns false if the
+// action is not relevant to the migration files in the pull request, e.g.
+// labels or title changes.
+func (p WebhookPullRequestEvent) ToVCS() (vcs.PullRequestEvent, bool) {
+	var action vcs.PullRequestEventAction
+	switch p.Action {
+	case "opened", "reopened":
+		action = vcs.PullRequestEventOpened
+	case "synchronize":
+		action = vcs.PullRequestEventUpdated
+	case "closed":
+		action = vcs.PullRequestEventClosed
+		if p.PullRequest.Merged {
+			action = vcs.PullRequestEventMerged
+		}
+	default:
+		return vcs.PullRequestEvent{}, false
+	}
+
+	return vcs.PullRequestEvent{
+		Action:             action,
+		RepositoryID:       p.Repository.FullName,
+		RepositoryURL:      p.Repository.HTMLURL,
+		RepositoryFullPath: p.Repository.FullName,
+		PullRequestID:      strconv.Itoa(p.Number),
+		URL:                p.PullRequest.HTMLURL,
+		Title:              p.PullRequest.Title,
+		SourceBranch:       p.PullRequest.Head.Ref,
+		TargetBranch:       p.PullRequest.Base.Ref,
+		HeadCommitID:       p.PullRequest.Head.SHA,
+		AuthorName:         p.Sender.Login,
+	}, true
+}
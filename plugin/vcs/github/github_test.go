@@ -2,6 +2,8 @@ package github
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"strings"
@@ -1342,6 +1344,126 @@ func TestProvider_CreatePullRequest(t *testing.T) {
 	assert.Equal(t, "https://github.com/octocat/Hello-World/pull/1347", res.URL)
 }
 
+func TestProvider_CreateCommitStatus(t *testing.T) {
+	p := newProvider(
+		vcs.ProviderConfig{
+			Client: &http.Client{
+				Transport: &common.MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						assert.Equal(t, http.MethodPost, r.Method)
+						assert.Equal(t, "/repos/octocat/Hello-World/statuses/6dcb09b5b57875f334f61aebed695e2e4193db5e", r.URL.Path)
+
+						body, err := io.ReadAll(r.Body)
+						require.NoError(t, err)
+						var got CommitStatusCreate
+						require.NoError(t, json.Unmarshal(body, &got))
+						want := CommitStatusCreate{
+							State:       "failure",
+							TargetURL:   "https://bytebase.example.com/setting/sql-review",
+							Description: "SQL review found 1 error(s) and 0 warning(s)",
+							Context:     "bytebase/sql-review",
+						}
+						assert.Equal(t, want, got)
+						return &http.Response{
+							StatusCode: http.StatusCreated,
+							Body:       io.NopCloser(strings.NewReader("{}")),
+						}, nil
+					},
+				},
+			},
+		},
+	)
+
+	ctx := context.Background()
+	err := p.CreateCommitStatus(ctx, common.OauthContext{}, githubComURL, "octocat/Hello-World", "6dcb09b5b57875f334f61aebed695e2e4193db5e",
+		&vcs.CommitStatus{
+			State:       vcs.CommitStatusFailure,
+			Context:     "bytebase/sql-review",
+			Description: "SQL review found 1 error(s) and 0 warning(s)",
+			TargetURL:   "https://bytebase.example.com/setting/sql-review",
+		},
+	)
+	require.NoError(t, err)
+}
+
+func TestProvider_UpsertPullRequestReview(t *testing.T) {
+	var gotRequests []string
+	var gotIssueComment IssueCommentCreate
+	var gotReviewComments []ReviewCommentCreate
+	p := newProvider(
+		vcs.ProviderConfig{
+			Client: &http.Client{
+				Transport: &common.MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						gotRequests = append(gotRequests, fmt.Sprintf("%s %s", r.Method, r.URL.Path))
+						respBody := "{}"
+						switch fmt.Sprintf("%s %s", r.Method, r.URL.Path) {
+						case "GET /repos/octocat/Hello-World/pulls/1347/comments":
+							respBody = "[]"
+							if r.URL.Query().Get("page") == "1" {
+								respBody = `[{"id": 10, "body": "Outdated advice\n\n<!-- bytebase/sql-review -->"}, {"id": 11, "body": "LGTM"}]`
+							}
+						case "GET /repos/octocat/Hello-World/issues/1347/comments":
+							respBody = "[]"
+							if r.URL.Query().Get("page") == "1" {
+								respBody = `[{"id": 20, "body": "SQL review passed\n\n<!-- bytebase/sql-review -->"}]`
+							}
+						case "DELETE /repos/octocat/Hello-World/pulls/comments/10":
+						case "PATCH /repos/octocat/Hello-World/issues/comments/20":
+							body, err := io.ReadAll(r.Body)
+							require.NoError(t, err)
+							require.NoError(t, json.Unmarshal(body, &gotIssueComment))
+						case "POST /repos/octocat/Hello-World/pulls/1347/comments":
+							body, err := io.ReadAll(r.Body)
+							require.NoError(t, err)
+							var comment ReviewCommentCreate
+							require.NoError(t, json.Unmarshal(body, &comment))
+							gotReviewComments = append(gotReviewComments, comment)
+						default:
+							return nil, errors.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+						}
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(strings.NewReader(respBody)),
+						}, nil
+					},
+				},
+			},
+		},
+	)
+
+	ctx := context.Background()
+	err := p.UpsertPullRequestReview(ctx, common.OauthContext{}, githubComURL, "octocat/Hello-World", "1347",
+		&vcs.PullRequestReview{
+			Context:  "bytebase/sql-review",
+			CommitID: "6dcb09b5b57875f334f61aebed695e2e4193db5e",
+			Body:     "SQL review found 1 error(s) and 0 warning(s)",
+			CommentList: []*vcs.PullRequestComment{
+				{
+					Path: "migration/1.0.0##create_table.sql",
+					Line: 3,
+					Body: "Table requires PRIMARY KEY",
+				},
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	assert.Contains(t, gotRequests, "DELETE /repos/octocat/Hello-World/pulls/comments/10")
+	assert.NotContains(t, gotRequests, "DELETE /repos/octocat/Hello-World/pulls/comments/11")
+	assert.Equal(t, IssueCommentCreate{Body: "SQL review found 1 error(s) and 0 warning(s)\n\n<!-- bytebase/sql-review -->"}, gotIssueComment)
+	want := []ReviewCommentCreate{
+		{
+			CommitID: "6dcb09b5b57875f334f61aebed695e2e4193db5e",
+			Path:     "migration/1.0.0##create_table.sql",
+			Line:     3,
+			Side:     "RIGHT",
+			Body:     "Table requires PRIMARY KEY\n\n<!-- bytebase/sql-review -->",
+		},
+	}
+	assert.Equal(t, want, gotReviewComments)
+}
+
 func TestProvider_UpsertEnvironmentVariable(t *testing.T) {
 	p := newProvider(
 		vcs.ProviderConfig{
@@ -1433,3 +1555,62 @@ func TestProvider_ListPullRequestFile(t *testing.T) {
 	}
 	assert.Equal(t, want, got)
 }
+
+func TestWebhookPullRequestEvent_ToVCS(t *testing.T) {
+	payload := `
+{
+  "action": "%s",
+  "number": 2,
+  "pull_request": {
+    "html_url": "https://github.com/octocat/Hello-World/pull/2",
+    "title": "Add migration",
+    "merged": %t,
+    "head": {"ref": "feature", "sha": "6dcb09b5b57875f334f61aebed695e2e4193db5e"},
+    "base": {"ref": "main", "sha": "1acc419d4d6a9ce985db7be48c6349a0475975b5"}
+  },
+  "repository": {
+    "id": 1296269,
+    "full_name": "octocat/Hello-World",
+    "html_url": "https://github.com/octocat/Hello-World"
+  },
+  "sender": {"login": "octocat"}
+}
+`
+	tests := []struct {
+		action     string
+		merged     bool
+		wantAction vcs.PullRequestEventAction
+		wantOK     bool
+	}{
+		{action: "opened", wantAction: vcs.PullRequestEventOpened, wantOK: true},
+		{action: "reopened", wantAction: vcs.PullRequestEventOpened, wantOK: true},
+		{action: "synchronize", wantAction: vcs.PullRequestEventUpdated, wantOK: true},
+		{action: "closed", merged: true, wantAction: vcs.PullRequestEventMerged, wantOK: true},
+		{action: "closed", wantAction: vcs.PullRequestEventClosed, wantOK: true},
+		{action: "labeled", wantOK: false},
+	}
+	for _, test := range tests {
+		var pullRequestEvent WebhookPullRequestEvent
+		require.NoError(t, json.Unmarshal([]byte(fmt.Sprintf(payload, test.action, test.merged)), &pullRequestEvent))
+
+		got, ok := pullRequestEvent.ToVCS()
+		require.Equal(t, test.wantOK, ok, test.action)
+		if !ok {
+			continue
+		}
+		want := vcs.PullRequestEvent{
+			Action:             test.wantAction,
+			RepositoryID:       "octocat/Hello-World",
+			RepositoryURL:      "https://github.com/octocat/Hello-World",
+			RepositoryFullPath: "octocat/Hello-World",
+			PullRequestID:      "2",
+			URL:                "https://github.com/octocat/Hello-World/pull/2",
+			Title:              "Add migration",
+			SourceBranch:       "feature",
+			TargetBranch:       "main",
+			HeadCommitID:       "6dcb09b5b57875f334f61aebed695e2e4193db5e",
+			AuthorName:         "octocat",
+		}
+		assert.Equal(t, want, got, test.action)
+	}
+}
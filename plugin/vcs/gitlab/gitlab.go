@@ -38,6 +38,8 @@ type WebhookType string
 const (
 	// WebhookPush is the webhook type for push.
 	WebhookPush WebhookType = "push"
+	// WebhookMergeRequest is the webhook type for merge request.
+	WebhookMergeRequest WebhookType = "merge_request"
 )
 
 // WebhookInfo represents a GitLab API response for the webhook information.
@@ -51,12 +53,9 @@ type WebhookCreate struct {
 	SecretToken string `json:"token"`
 	// This is set to true
 	PushEvents bool `json:"push_events"`
-	// For now, there is no native dry run DDL support in mysql/postgres. One may wonder if we could wrap the DDL
-	// in a transaction and just not commit at the end, unfortunately there are side effects which are hard to control.
-	// See https://www.postgresql.org/message-id/CAMsr%2BYGiYQ7PYvYR2Voio37YdCpp79j5S%2BcmgVJMOLM2LnRQcA%40mail.gmail.com
-	// So we can't possibly display useful info when reviewing a MR, thus we don't enable this event.
-	// Saying that, delivering a souding dry run solution would be great and hopefully we can achieve that one day.
-	// MergeRequestsEvents  bool   `json:"merge_requests_events"`
+	// MergeRequestsEvents is set to true to create draft issues from opened merge requests, so that the task checks
+	// could run before the change is merged.
+	MergeRequestsEvents   bool `json:"merge_requests_events"`
 	EnableSSLVerification bool `json:"enable_ssl_verification"`
 }
 
@@ -94,6 +93,41 @@ type WebhookPushEvent struct {
 	CommitList []WebhookCommit `json:"commits"`
 }
 
+// WebhookUser is the API message for webhook user.
+type WebhookUser struct {
+	Name     string `json:"name"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// WebhookMergeRequestCommit is the API message for the last commit of a webhook merge request.
+type WebhookMergeRequestCommit struct {
+	ID     string              `json:"id"`
+	Author WebhookCommitAuthor `json:"author"`
+}
+
+// WebhookMergeRequestAttributes is the API message for webhook merge request attributes.
+type WebhookMergeRequestAttributes struct {
+	IID          int    `json:"iid"`
+	Title        string `json:"title"`
+	URL          string `json:"url"`
+	SourceBranch string `json:"source_branch"`
+	TargetBranch string `json:"target_branch"`
+	// Action is one of "open", "close", "reopen", "update", "approved", "unapproved", "approval", "unapproval" and "merge".
+	Action string `json:"action"`
+	// OldRev is only set on the "update" action when there are new commits pushed to the source branch.
+	OldRev     string                    `json:"oldrev"`
+	LastCommit WebhookMergeRequestCommit `json:"last_commit"`
+}
+
+// WebhookMergeRequestEvent is the API message for webhook merge request event.
+type WebhookMergeRequestEvent struct {
+	ObjectKind       WebhookType                   `json:"object_kind"`
+	User             WebhookUser                   `json:"user"`
+	Project          WebhookProject                `json:"project"`
+	ObjectAttributes WebhookMergeRequestAttributes `json:"object_attributes"`
+}
+
 // Commit is the API message for commit.
 type Commit struct {
 	ID         string `json:"id"`
@@ -939,7 +973,8 @@ func (p *Provider) CreateBranch(ctx context.Context, oauthCtx common.OauthContex
 
 // MergeRequest is the API message for GitLab merge request.
 type MergeRequest struct {
-	WebURL string `json:"web_url"`
+	WebURL   string               `json:"web_url"`
+	DiffRefs MergeRequestDiffRefs `json:"diff_refs"`
 }
 
 // CreatePullRequest creates the pull request in the repository.
@@ -1000,6 +1035,301 @@ func (p *Provider) CreatePullRequest(ctx context.Context, oauthCtx common.OauthC
 	}, nil
 }
 
+// CommitStatusCreate is the API message for creating a commit status.
+type CommitStatusCreate struct {
+	// State is one of "pending", "running", "success", "failed" and "canceled".
+	State       string `json:"state"`
+	Name        string `json:"name"`
+	TargetURL   string `json:"target_url,omitempty"`
+	Description string `json:"description"`
+}
+
+// CreateCommitStatus creates the status of the commit.
+//
+// Docs: https://docs.gitlab.com/ee/api/commits.html#post-the-build-status-to-a-commit
+func (p *Provider) CreateCommitStatus(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, commitID string, status *vcs.CommitStatus) error {
+	state := "pending"
+	switch status.State {
+	case vcs.CommitStatusSuccess:
+		state = "success"
+	case vcs.CommitStatusFailure, vcs.CommitStatusError:
+		state = "failed"
+	}
+	url := fmt.Sprintf("%s/projects/%s/statuses/%s", p.APIURL(instanceURL), repositoryID, commitID)
+	return oauth.WriteResource(
+		ctx,
+		p.client,
+		http.MethodPost,
+		url,
+		&oauthCtx.AccessToken,
+		tokenRefresher(
+			instanceURL,
+			oauthContext{
+				ClientID:     oauthCtx.ClientID,
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+		"commit status",
+		CommitStatusCreate{
+			State:       state,
+			Name:        status.Context,
+			TargetURL:   status.TargetURL,
+			Description: status.Description,
+		},
+	)
+}
+
+// MergeRequestDiffRefs is the API message for the diff references of a GitLab
+// merge request, which are required to position a comment on the diff.
+type MergeRequestDiffRefs struct {
+	BaseSHA  string `json:"base_sha"`
+	HeadSHA  string `json:"head_sha"`
+	StartSHA string `json:"start_sha"`
+}
+
+// MergeRequestNoteCreate is the API message for creating a merge request note.
+type MergeRequestNoteCreate struct {
+	Body string `json:"body"`
+}
+
+// MergeRequestPosition is the API message for the position of a merge request
+// discussion on the diff.
+type MergeRequestPosition struct {
+	PositionType string `json:"position_type"`
+	BaseSHA      string `json:"base_sha"`
+	HeadSHA      string `json:"head_sha"`
+	StartSHA     string `json:"start_sha"`
+	OldPath      string `json:"old_path"`
+	NewPath      string `json:"new_path"`
+	NewLine      int    `json:"new_line"`
+}
+
+// MergeRequestDiscussionCreate is the API message for creating a merge request
+// discussion.
+type MergeRequestDiscussionCreate struct {
+	Body     string                `json:"body"`
+	Position *MergeRequestPosition `json:"position"`
+}
+
+// MergeRequestNote is the API message for a note of the merge request discussion.
+type MergeRequestNote struct {
+	ID   int    `json:"id"`
+	Body string `json:"body"`
+}
+
+// MergeRequestDiscussion is the API message for a merge request discussion.
+type MergeRequestDiscussion struct {
+	ID string `json:"id"`
+	// IndividualNote is true for the standalone notes which are not on the diff.
+	IndividualNote bool               `json:"individual_note"`
+	Notes          []MergeRequestNote `json:"notes"`
+}
+
+// UpsertPullRequestReview posts the review with inline comments to the pull
+// request. GitLab does not group comments into a review through the API, so the
+// review body is kept in a single note updated in place, and each inline
+// comment is posted as a discussion on the diff after deleting the discussions
+// of the previous review.
+//
+// Docs: https://docs.gitlab.com/ee/api/discussions.html#merge-requests
+func (p *Provider) UpsertPullRequestReview(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, pullRequestID string, review *vcs.PullRequestReview) error {
+	mergeRequest, err := p.getMergeRequest(ctx, oauthCtx, instanceURL, repositoryID, pullRequestID)
+	if err != nil {
+		return errors.Wrap(err, "get merge request")
+	}
+	discussions, err := p.listMergeRequestDiscussions(ctx, oauthCtx, instanceURL, repositoryID, pullRequestID)
+	if err != nil {
+		return errors.Wrap(err, "list merge request discussions")
+	}
+
+	marker := review.Marker()
+	notesURL := fmt.Sprintf("%s/projects/%s/merge_requests/%s/notes", p.APIURL(instanceURL), repositoryID, pullRequestID)
+	method, url := http.MethodPost, notesURL
+	for _, discussion := range discussions {
+		if len(discussion.Notes) == 0 || !strings.Contains(discussion.Notes[0].Body, marker) {
+			continue
+		}
+		noteURL := fmt.Sprintf("%s/%d", notesURL, discussion.Notes[0].ID)
+		if discussion.IndividualNote {
+			method, url = http.MethodPut, noteURL
+			continue
+		}
+		// Deleting the only note deletes the discussion as well.
+		code, _, body, err := oauth.Delete(
+			ctx,
+			p.client,
+			noteURL,
+			&oauthCtx.AccessToken,
+			tokenRefresher(
+				instanceURL,
+				oauthContext{
+					ClientID:     oauthCtx.ClientID,
+					ClientSecret: oauthCtx.ClientSecret,
+					RefreshToken: oauthCtx.RefreshToken,
+				},
+				oauthCtx.Refresher,
+			),
+		)
+		if err != nil {
+			return errors.Wrapf(err, "DELETE %s", noteURL)
+		}
+		if code >= 300 && code != http.StatusNotFound {
+			return errors.Errorf("failed to delete merge request note through URL %s, status code: %d, body: %s",
+				noteURL,
+				code,
+				body,
+			)
+		}
+	}
+
+	if err := oauth.WriteResource(
+		ctx,
+		p.client,
+		method,
+		url,
+		&oauthCtx.AccessToken,
+		tokenRefresher(
+			instanceURL,
+			oauthContext{
+				ClientID:     oauthCtx.ClientID,
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+		"merge request note",
+		MergeRequestNoteCreate{Body: fmt.Sprintf("%s\n\n%s", review.Body, marker)},
+	); err != nil {
+		return err
+	}
+
+	url = fmt.Sprintf("%s/projects/%s/merge_requests/%s/discussions", p.APIURL(instanceURL), repositoryID, pullRequestID)
+	for _, comment := range review.CommentList {
+		discussion := MergeRequestDiscussionCreate{
+			Body: fmt.Sprintf("%s\n\n%s", comment.Body, marker),
+			Position: &MergeRequestPosition{
+				PositionType: "text",
+				BaseSHA:      mergeRequest.DiffRefs.BaseSHA,
+				HeadSHA:      mergeRequest.DiffRefs.HeadSHA,
+				StartSHA:     mergeRequest.DiffRefs.StartSHA,
+				OldPath:      comment.Path,
+				NewPath:      comment.Path,
+				NewLine:      comment.Line,
+			},
+		}
+		if err := oauth.WriteResource(
+			ctx,
+			p.client,
+			http.MethodPost,
+			url,
+			&oauthCtx.AccessToken,
+			tokenRefresher(
+				instanceURL,
+				oauthContext{
+					ClientID:     oauthCtx.ClientID,
+					ClientSecret: oauthCtx.ClientSecret,
+					RefreshToken: oauthCtx.RefreshToken,
+				},
+				oauthCtx.Refresher,
+			),
+			"merge request discussion",
+			discussion,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listMergeRequestDiscussions lists all the discussions of the merge request.
+//
+// Docs: https://docs.gitlab.com/ee/api/discussions.html#list-project-merge-request-discussion-items
+func (p *Provider) listMergeRequestDiscussions(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, pullRequestID string) ([]MergeRequestDiscussion, error) {
+	var allDiscussions []MergeRequestDiscussion
+	for page := 1; ; page++ {
+		url := fmt.Sprintf("%s/projects/%s/merge_requests/%s/discussions?page=%d&per_page=%d", p.APIURL(instanceURL), repositoryID, pullRequestID, page, apiPageSize)
+		code, _, body, err := oauth.Get(
+			ctx,
+			p.client,
+			url,
+			&oauthCtx.AccessToken,
+			tokenRefresher(
+				instanceURL,
+				oauthContext{
+					ClientID:     oauthCtx.ClientID,
+					ClientSecret: oauthCtx.ClientSecret,
+					RefreshToken: oauthCtx.RefreshToken,
+				},
+				oauthCtx.Refresher,
+			),
+		)
+		if err != nil {
+			return nil, errors.Wrapf(err, "GET %s", url)
+		}
+		if code == http.StatusNotFound {
+			return nil, common.Errorf(common.NotFound, "failed to list merge request discussions from URL %s", url)
+		} else if code >= 300 {
+			return nil, errors.Errorf("failed to list merge request discussions from URL %s, status code: %d, body: %s",
+				url,
+				code,
+				body,
+			)
+		}
+
+		var discussions []MergeRequestDiscussion
+		if err := json.Unmarshal([]byte(body), &discussions); err != nil {
+			return nil, err
+		}
+		if len(discussions) == 0 {
+			return allDiscussions, nil
+		}
+		allDiscussions = append(allDiscussions, discussions...)
+	}
+}
+
+// getMergeRequest gets the merge request.
+//
+// Docs: https://docs.gitlab.com/ee/api/merge_requests.html#get-single-mr
+func (p *Provider) getMergeRequest(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, pullRequestID string) (*MergeRequest, error) {
+	url := fmt.Sprintf("%s/projects/%s/merge_requests/%s", p.APIURL(instanceURL), repositoryID, pullRequestID)
+	code, _, body, err := oauth.Get(
+		ctx,
+		p.client,
+		url,
+		&oauthCtx.AccessToken,
+		tokenRefresher(
+			instanceURL,
+			oauthContext{
+				ClientID:     oauthCtx.ClientID,
+				ClientSecret: oauthCtx.ClientSecret,
+				RefreshToken: oauthCtx.RefreshToken,
+			},
+			oauthCtx.Refresher,
+		),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "GET %s", url)
+	}
+
+	if code == http.StatusNotFound {
+		return nil, common.Errorf(common.NotFound, "failed to get merge request from URL %s", url)
+	} else if code >= 300 {
+		return nil, errors.Errorf("failed to get merge request from URL %s, status code: %d, body: %s",
+			url,
+			code,
+			body,
+		)
+	}
+
+	var res MergeRequest
+	if err := json.Unmarshal([]byte(body), &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
 // EnvironmentVariable is the API message for environment variable in GitLab project.
 type EnvironmentVariable struct {
 	Key   string `json:"key"`
@@ -1414,3 +1744,44 @@ func (p WebhookPushEvent) ToVCS() (vcs.PushEvent, error) {
 		CommitList:         commitList,
 	}, nil
 }
+
+// ToVCS returns the merge request event in VCS format. It returns false if the
+// action is not relevant to the migration files in the merge request, e.g.
+// approvals or title changes.
+func (p WebhookMergeRequestEvent) ToVCS() (vcs.PullRequestEvent, bool) {
+	var action vcs.PullRequestEventAction
+	switch p.ObjectAttributes.Action {
+	case "open", "reopen":
+		action = vcs.PullRequestEventOpened
+	case "update":
+		if p.ObjectAttributes.OldRev == "" {
+			return vcs.PullRequestEvent{}, false
+		}
+		action = vcs.PullRequestEventUpdated
+	case "merge":
+		action = vcs.PullRequestEventMerged
+	case "close":
+		action = vcs.PullRequestEventClosed
+	default:
+		return vcs.PullRequestEvent{}, false
+	}
+
+	authorEmail := p.ObjectAttributes.LastCommit.Author.Email
+	if authorEmail == "" {
+		authorEmail = p.User.Email
+	}
+	return vcs.PullRequestEvent{
+		Action:             action,
+		RepositoryID:       fmt.Sprintf("%v", p.Project.ID),
+		RepositoryURL:      p.Project.WebURL,
+		RepositoryFullPath: p.Project.FullPath,
+		PullRequestID:      strconv.Itoa(p.ObjectAttributes.IID),
+		URL:                p.ObjectAttributes.URL,
+		Title:              p.ObjectAttributes.Title,
+		SourceBranch:       p.ObjectAttributes.SourceBranch,
+		TargetBranch:       p.ObjectAttributes.TargetBranch,
+		HeadCommitID:       p.ObjectAttributes.LastCommit.ID,
+		AuthorName:         p.User.Name,
+		AuthorEmail:        authorEmail,
+	}, true
+}
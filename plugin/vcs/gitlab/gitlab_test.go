@@ -2,6 +2,8 @@ package gitlab
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"strings"
@@ -1021,6 +1023,153 @@ func TestProvider_CreatePullRequest(t *testing.T) {
 	assert.Equal(t, "http://gitlab.example.com/my-group/my-project/merge_requests/1", res.URL)
 }
 
+func TestProvider_CreateCommitStatus(t *testing.T) {
+	p := newProvider(
+		vcs.ProviderConfig{
+			Client: &http.Client{
+				Transport: &common.MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						assert.Equal(t, http.MethodPost, r.Method)
+						assert.Equal(t, "/api/v4/projects/1/statuses/2be7ddb704c7b6b83732fdd5b9f09d5a397b5f8f", r.URL.Path)
+
+						body, err := io.ReadAll(r.Body)
+						require.NoError(t, err)
+						var got CommitStatusCreate
+						require.NoError(t, json.Unmarshal(body, &got))
+						want := CommitStatusCreate{
+							State:       "failed",
+							Name:        "bytebase/sql-review",
+							TargetURL:   "https://bytebase.example.com/setting/sql-review",
+							Description: "SQL review found 1 error(s) and 0 warning(s)",
+						}
+						assert.Equal(t, want, got)
+						return &http.Response{
+							StatusCode: http.StatusCreated,
+							Body:       io.NopCloser(strings.NewReader("{}")),
+						}, nil
+					},
+				},
+			},
+		},
+	)
+
+	ctx := context.Background()
+	err := p.CreateCommitStatus(ctx, common.OauthContext{}, "", "1", "2be7ddb704c7b6b83732fdd5b9f09d5a397b5f8f",
+		&vcs.CommitStatus{
+			State:       vcs.CommitStatusError,
+			Context:     "bytebase/sql-review",
+			Description: "SQL review found 1 error(s) and 0 warning(s)",
+			TargetURL:   "https://bytebase.example.com/setting/sql-review",
+		},
+	)
+	require.NoError(t, err)
+}
+
+func TestProvider_UpsertPullRequestReview(t *testing.T) {
+	var gotRequests []string
+	var gotNoteList []MergeRequestNoteCreate
+	var gotDiscussionList []MergeRequestDiscussionCreate
+	p := newProvider(
+		vcs.ProviderConfig{
+			Client: &http.Client{
+				Transport: &common.MockRoundTripper{
+					MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+						gotRequests = append(gotRequests, fmt.Sprintf("%s %s", r.Method, r.URL.Path))
+						respBody := "{}"
+						switch fmt.Sprintf("%s %s", r.Method, r.URL.Path) {
+						case "GET /api/v4/projects/1/merge_requests/1":
+							// Example response taken from https://docs.gitlab.com/ee/api/merge_requests.html#get-single-mr
+							respBody = `
+{
+  "id": 1,
+  "iid": 1,
+  "project_id": 1,
+  "title": "test1",
+  "web_url": "http://gitlab.example.com/my-group/my-project/merge_requests/1",
+  "diff_refs": {
+    "base_sha": "c380d3acebd181f13629a25d2e2acca46ffe1e00",
+    "head_sha": "2be7ddb704c7b6b83732fdd5b9f09d5a397b5f8f",
+    "start_sha": "c380d3acebd181f13629a25d2e2acca46ffe1e00"
+  }
+}
+`
+						case "GET /api/v4/projects/1/merge_requests/1/discussions":
+							respBody = "[]"
+							if r.URL.Query().Get("page") == "1" {
+								respBody = `
+[
+  {"id": "6a9c1750b37d513a43987b574953fceb50b03ce7", "individual_note": true, "notes": [{"id": 1126, "body": "SQL review passed\n\n<!-- bytebase/sql-review -->"}]},
+  {"id": "87805b7c09016a7058e91bdbe7b29d1f284a39e6", "individual_note": false, "notes": [{"id": 1127, "body": "Outdated advice\n\n<!-- bytebase/sql-review -->"}]},
+  {"id": "9bc5b8b4a1a30cba0d8bea5e8c8a5d6e7e2a4f01", "individual_note": false, "notes": [{"id": 1128, "body": "LGTM"}]}
+]
+`
+							}
+						case "DELETE /api/v4/projects/1/merge_requests/1/notes/1127":
+						case "PUT /api/v4/projects/1/merge_requests/1/notes/1126":
+							body, err := io.ReadAll(r.Body)
+							require.NoError(t, err)
+							var note MergeRequestNoteCreate
+							require.NoError(t, json.Unmarshal(body, &note))
+							gotNoteList = append(gotNoteList, note)
+						case "POST /api/v4/projects/1/merge_requests/1/discussions":
+							body, err := io.ReadAll(r.Body)
+							require.NoError(t, err)
+							var discussion MergeRequestDiscussionCreate
+							require.NoError(t, json.Unmarshal(body, &discussion))
+							gotDiscussionList = append(gotDiscussionList, discussion)
+						default:
+							return nil, errors.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+						}
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(strings.NewReader(respBody)),
+						}, nil
+					},
+				},
+			},
+		},
+	)
+
+	ctx := context.Background()
+	err := p.UpsertPullRequestReview(ctx, common.OauthContext{}, "", "1", "1",
+		&vcs.PullRequestReview{
+			Context:  "bytebase/sql-review",
+			CommitID: "2be7ddb704c7b6b83732fdd5b9f09d5a397b5f8f",
+			Body:     "SQL review found 1 error(s) and 0 warning(s)",
+			CommentList: []*vcs.PullRequestComment{
+				{
+					Path: "migration/1.0.0##create_table.sql",
+					Line: 3,
+					Body: "Table requires PRIMARY KEY",
+				},
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	assert.Contains(t, gotRequests, "DELETE /api/v4/projects/1/merge_requests/1/notes/1127")
+	assert.NotContains(t, gotRequests, "DELETE /api/v4/projects/1/merge_requests/1/notes/1128")
+	wantNoteList := []MergeRequestNoteCreate{
+		{Body: "SQL review found 1 error(s) and 0 warning(s)\n\n<!-- bytebase/sql-review -->"},
+	}
+	assert.Equal(t, wantNoteList, gotNoteList)
+	wantDiscussionList := []MergeRequestDiscussionCreate{
+		{
+			Body: "Table requires PRIMARY KEY\n\n<!-- bytebase/sql-review -->",
+			Position: &MergeRequestPosition{
+				PositionType: "text",
+				BaseSHA:      "c380d3acebd181f13629a25d2e2acca46ffe1e00",
+				HeadSHA:      "2be7ddb704c7b6b83732fdd5b9f09d5a397b5f8f",
+				StartSHA:     "c380d3acebd181f13629a25d2e2acca46ffe1e00",
+				OldPath:      "migration/1.0.0##create_table.sql",
+				NewPath:      "migration/1.0.0##create_table.sql",
+				NewLine:      3,
+			},
+		},
+	}
+	assert.Equal(t, wantDiscussionList, gotDiscussionList)
+}
+
 func TestProvider_UpsertEnvironmentVariable(t *testing.T) {
 	p := newProvider(
 		vcs.ProviderConfig{
@@ -1156,3 +1305,70 @@ func TestProvider_ListPullRequestFile(t *testing.T) {
 	}
 	assert.Equal(t, want, got)
 }
+
+func TestWebhookMergeRequestEvent_ToVCS(t *testing.T) {
+	payload := `
+{
+  "object_kind": "merge_request",
+  "user": {"name": "Administrator", "username": "root", "email": "admin@example.com"},
+  "project": {
+    "id": 1,
+    "web_url": "http://example.com/gitlabhq/gitlab-test",
+    "path_with_namespace": "gitlabhq/gitlab-test"
+  },
+  "object_attributes": {
+    "iid": 1,
+    "title": "Add migration",
+    "url": "http://example.com/gitlabhq/gitlab-test/-/merge_requests/1",
+    "source_branch": "ms-viewport",
+    "target_branch": "master",
+    "action": "%s",
+    "oldrev": "%s",
+    "last_commit": {
+      "id": "da1560886d4f094c3e6c9ef40349f7d38b5d27d7",
+      "author": {"name": "GitLab dev user", "email": "gitlabdev@dv6700.(none)"}
+    }
+  }
+}
+`
+	tests := []struct {
+		action     string
+		oldRev     string
+		wantAction vcs.PullRequestEventAction
+		wantOK     bool
+	}{
+		{action: "open", wantAction: vcs.PullRequestEventOpened, wantOK: true},
+		{action: "reopen", wantAction: vcs.PullRequestEventOpened, wantOK: true},
+		{action: "update", oldRev: "1acc419d4d6a9ce985db7be48c6349a0475975b5", wantAction: vcs.PullRequestEventUpdated, wantOK: true},
+		// Updating the title or description does not push new commits.
+		{action: "update", wantOK: false},
+		{action: "merge", wantAction: vcs.PullRequestEventMerged, wantOK: true},
+		{action: "close", wantAction: vcs.PullRequestEventClosed, wantOK: true},
+		{action: "approved", wantOK: false},
+	}
+	for _, test := range tests {
+		var mergeRequestEvent WebhookMergeRequestEvent
+		require.NoError(t, json.Unmarshal([]byte(fmt.Sprintf(payload, test.action, test.oldRev)), &mergeRequestEvent))
+
+		got, ok := mergeRequestEvent.ToVCS()
+		require.Equal(t, test.wantOK, ok, test.action)
+		if !ok {
+			continue
+		}
+		want := vcs.PullRequestEvent{
+			Action:             test.wantAction,
+			RepositoryID:       "1",
+			RepositoryURL:      "http://example.com/gitlabhq/gitlab-test",
+			RepositoryFullPath: "gitlabhq/gitlab-test",
+			PullRequestID:      "1",
+			URL:                "http://example.com/gitlabhq/gitlab-test/-/merge_requests/1",
+			Title:              "Add migration",
+			SourceBranch:       "ms-viewport",
+			TargetBranch:       "master",
+			HeadCommitID:       "da1560886d4f094c3e6c9ef40349f7d38b5d27d7",
+			AuthorName:         "Administrator",
+			AuthorEmail:        "gitlabdev@dv6700.(none)",
+		}
+		assert.Equal(t, want, got, test.action)
+	}
+}
@@ -4,20 +4,53 @@ package oauth
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/pkg/errors"
+
+	"github.com/bytebase/bytebase/common"
 )
 
 // TokenRefresher is a function to refresh the OAuth token and assign back to
 // the old token upon a successful refresh.
 type TokenRefresher func(ctx context.Context, client *http.Client, oldToken *string) error
 
+// basicAuthScheme is the prefix of the tokens returned by BasicAuthToken.
+const basicAuthScheme = "Basic "
+
+// BasicAuthToken returns a token that authenticates requests with the basic
+// authentication scheme using given credentials instead of the bearer scheme.
+// It is used for personal access tokens (PAT), e.g. Azure DevOps expects the
+// PAT as the password with an empty username.
+func BasicAuthToken(username, password string) string {
+	return basicAuthScheme + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+}
+
+// IsBasicAuthToken returns true if the token is returned by BasicAuthToken.
+func IsBasicAuthToken(token string) bool {
+	return strings.HasPrefix(token, basicAuthScheme)
+}
+
+// authorizationHeader returns the value of the Authorization header for the token.
+func authorizationHeader(token string) string {
+	if IsBasicAuthToken(token) {
+		return token
+	}
+	return fmt.Sprintf("Bearer %s", token)
+}
+
 func requester(ctx context.Context, client *http.Client, method, url string, token *string, body io.Reader) func() (*http.Response, error) {
+	return requesterWithContentType(ctx, client, method, url, "application/json", token, body)
+}
+
+func requesterWithContentType(ctx context.Context, client *http.Client, method, url, contentType string, token *string, body io.Reader) func() (*http.Response, error) {
 	// The body may be read multiple times but io.Reader is meant to be read once,
 	// so we read the body first and build the reader every time.
 	var bodyBytes []byte
@@ -36,8 +69,8 @@ func requester(ctx context.Context, client *http.Client, method, url string, tok
 			return nil, errors.Wrapf(err, "construct %s %s", method, url)
 		}
 
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", *token))
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Add("Authorization", authorizationHeader(*token))
 		resp, err := client.Do(req)
 		if err != nil {
 			return nil, errors.Wrapf(err, "%s %s", method, url)
@@ -52,6 +85,13 @@ func Post(ctx context.Context, client *http.Client, url string, token *string, b
 	return retry(ctx, client, token, tokenRefresher, requester(ctx, client, http.MethodPost, url, token, body))
 }
 
+// PostForm makes a HTTP POST request with the URL-encoded form to the given URL
+// using the token. It refreshes token and retries the request in the case of
+// the token has expired.
+func PostForm(ctx context.Context, client *http.Client, url string, token *string, form url.Values, tokenRefresher TokenRefresher) (code int, header http.Header, respBody string, err error) {
+	return retry(ctx, client, token, tokenRefresher, requesterWithContentType(ctx, client, http.MethodPost, url, "application/x-www-form-urlencoded", token, strings.NewReader(form.Encode())))
+}
+
 // Get makes a HTTP GET request to the given URL using the token. It refreshes
 // token and retries the request in the case of the token has expired.
 func Get(ctx context.Context, client *http.Client, url string, token *string, tokenRefresher TokenRefresher) (code int, header http.Header, respBody string, err error) {
@@ -64,6 +104,25 @@ func Put(ctx context.Context, client *http.Client, url string, token *string, bo
 	return retry(ctx, client, token, tokenRefresher, requester(ctx, client, http.MethodPut, url, token, body))
 }
 
+// PutMultipartForm makes a HTTP PUT request with the multipart form to the
+// given URL using the token. It refreshes token and retries the request in the
+// case of the token has expired.
+func PutMultipartForm(ctx context.Context, client *http.Client, url string, token *string, form url.Values, tokenRefresher TokenRefresher) (code int, header http.Header, respBody string, err error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for key, values := range form {
+		for _, value := range values {
+			if err := writer.WriteField(key, value); err != nil {
+				return 0, nil, "", errors.Wrapf(err, "write form field %q", key)
+			}
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return 0, nil, "", errors.Wrap(err, "close multipart writer")
+	}
+	return retry(ctx, client, token, tokenRefresher, requesterWithContentType(ctx, client, http.MethodPut, url, writer.FormDataContentType(), token, &body))
+}
+
 // Patch makes a HTTP PATCH request to the given URL using the token. It
 // refreshes token and retries the request in the case of the token has expired.
 func Patch(ctx context.Context, client *http.Client, url string, token *string, body io.Reader, tokenRefresher TokenRefresher) (code int, header http.Header, respBody string, err error) {
@@ -76,6 +135,33 @@ func Delete(ctx context.Context, client *http.Client, url string, token *string,
 	return retry(ctx, client, token, tokenRefresher, requester(ctx, client, http.MethodDelete, url, token, nil))
 }
 
+// WriteResource creates or updates the resource with the JSON payload by the
+// POST, PUT or PATCH request to the URL using the token. The resourceName is
+// only used in the error messages.
+func WriteResource(ctx context.Context, client *http.Client, method, url string, token *string, tokenRefresher TokenRefresher, resourceName string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrapf(err, "marshal %s", resourceName)
+	}
+
+	code, _, resp, err := retry(ctx, client, token, tokenRefresher, requester(ctx, client, method, url, token, bytes.NewReader(body)))
+	if err != nil {
+		return errors.Wrapf(err, "%s %s", method, url)
+	}
+
+	if code == http.StatusNotFound {
+		return common.Errorf(common.NotFound, "failed to write %s through URL %s", resourceName, url)
+	} else if code >= 300 {
+		return errors.Errorf("failed to write %s through URL %s, status code: %d, body: %s",
+			resourceName,
+			url,
+			code,
+			resp,
+		)
+	}
+	return nil
+}
+
 const maxRetries = 3
 
 func retry(ctx context.Context, client *http.Client, token *string, tokenRefresher TokenRefresher, f func() (*http.Response, error)) (code int, header http.Header, respBody string, err error) {
@@ -98,7 +184,7 @@ func retry(ctx context.Context, client *http.Client, token *string, tokenRefresh
 			return 0, nil, "", errors.Wrapf(err, "read response body with status code %d", resp.StatusCode)
 		}
 
-		if err = getOAuthErrorDetails(resp.StatusCode, body); err != nil {
+		if err = getOAuthErrorDetails(resp.StatusCode, resp.Header, body); err != nil {
 			if _, ok := err.(*oauthError); ok {
 				// Refresh the token
 				if err := tokenRefresher(ctx, client, token); err != nil {
@@ -122,17 +208,73 @@ func (e oauthError) Error() string {
 	return fmt.Sprintf("OAuth response error %q description %q", e.Err, e.ErrorDescription)
 }
 
+// azureDevOpsError is the error format of Azure DevOps.
+type azureDevOpsError struct {
+	Message string `json:"message"`
+	TypeKey string `json:"typeKey"`
+}
+
+// bitbucketServerError is the error format of Bitbucket Server.
+type bitbucketServerError struct {
+	Errors []struct {
+		Message       string `json:"message"`
+		ExceptionName string `json:"exceptionName"`
+	} `json:"errors"`
+}
+
+type bitbucketError struct {
+	Type  string `json:"type"`
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
 // getOAuthErrorDetails only returns error if it's an OAuth error. For other
 // errors like 404 we don't return error. We do this because this method is only
 // intended to be used by oauth to refresh access token on expiration.
 //
 // When it's error like 404, GitLab API doesn't return it as error so we keep
 // the similar behavior and let caller check the response status code.
-func getOAuthErrorDetails(code int, body []byte) error {
+func getOAuthErrorDetails(code int, header http.Header, body []byte) error {
+	// Azure DevOps responds 203 with the sign-in page when the access token has
+	// expired, or 401 if the redirection is suppressed by the client. Other
+	// providers may respond 203 legitimately, so only the sign-in redirection
+	// marked by the Azure DevOps header is treated as an OAuth error.
+	if code == http.StatusNonAuthoritativeInfo && header.Get("X-TFS-FedAuthRedirect") != "" {
+		return &oauthError{Err: "invalid_token", ErrorDescription: "Azure DevOps redirected to the sign-in page"}
+	}
 	if 200 <= code && code < 300 {
 		return nil
 	}
 
+	var ae azureDevOpsError
+	if err := json.Unmarshal(body, &ae); err == nil && ae.TypeKey != "" {
+		if code == http.StatusUnauthorized && ae.TypeKey == "UnauthorizedRequestException" {
+			return &oauthError{Err: "invalid_token", ErrorDescription: ae.Message}
+		}
+		return nil
+	}
+
+	// Bitbucket Cloud responds with its own error format for the expired token.
+	// {"type":"error","error":{"message":"Access token expired."}}
+	var be bitbucketError
+	if err := json.Unmarshal(body, &be); err == nil && be.Type == "error" {
+		if code == http.StatusUnauthorized && strings.Contains(be.Error.Message, "expired") {
+			return &oauthError{Err: "invalid_token", ErrorDescription: be.Error.Message}
+		}
+		return nil
+	}
+
+	// Bitbucket Server responds 401 with its own error format when the access
+	// token has expired.
+	var bse bitbucketServerError
+	if err := json.Unmarshal(body, &bse); err == nil && len(bse.Errors) > 0 {
+		if code == http.StatusUnauthorized {
+			return &oauthError{Err: "invalid_token", ErrorDescription: bse.Errors[0].Message}
+		}
+		return nil
+	}
+
 	var oe oauthError
 	if err := json.Unmarshal(body, &oe); err != nil {
 		// If we failed to unmarshal body with oauth error, it's not oauthError and we should return nil.
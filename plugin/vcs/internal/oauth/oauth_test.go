@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"testing"
 
@@ -35,6 +36,30 @@ func TestPost(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestPostForm(t *testing.T) {
+	ctx := context.Background()
+	client := &http.Client{
+		Transport: &common.MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "application/x-www-form-urlencoded", r.Header.Get("Content-Type"))
+				assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				assert.Equal(t, "branch=main&message=POST+form", string(body))
+				return &http.Response{}, nil
+			},
+		},
+	}
+	token := "token"
+	form := url.Values{}
+	form.Set("message", "POST form")
+	form.Set("branch", "main")
+	_, _, _, err := PostForm(ctx, client, "", &token, form, nil)
+	require.NoError(t, err)
+}
+
 func TestGet(t *testing.T) {
 	ctx := context.Background()
 	client := &http.Client{
@@ -73,6 +98,31 @@ func TestPut(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestPutMultipartForm(t *testing.T) {
+	ctx := context.Background()
+	client := &http.Client{
+		Transport: &common.MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				assert.Equal(t, http.MethodPut, r.Method)
+				assert.True(t, strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data; boundary="))
+				assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+				err := r.ParseMultipartForm(1 << 20)
+				require.NoError(t, err)
+				assert.Equal(t, "PUT form", r.FormValue("message"))
+				assert.Equal(t, "main", r.FormValue("branch"))
+				return &http.Response{}, nil
+			},
+		},
+	}
+	token := "token"
+	form := url.Values{}
+	form.Set("message", "PUT form")
+	form.Set("branch", "main")
+	_, _, _, err := PutMultipartForm(ctx, client, "", &token, form, nil)
+	require.NoError(t, err)
+}
+
 func TestPatch(t *testing.T) {
 	ctx := context.Background()
 	client := &http.Client{
@@ -111,6 +161,101 @@ func TestDelete(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestGet_BasicAuthToken(t *testing.T) {
+	ctx := context.Background()
+	client := &http.Client{
+		Transport: &common.MockRoundTripper{
+			MockRoundTrip: func(r *http.Request) (*http.Response, error) {
+				username, password, ok := r.BasicAuth()
+				assert.True(t, ok)
+				assert.Equal(t, "", username)
+				assert.Equal(t, "pat", password)
+				return &http.Response{}, nil
+			},
+		},
+	}
+	token := BasicAuthToken("", "pat")
+	assert.True(t, IsBasicAuthToken(token))
+	_, _, _, err := Get(ctx, client, "", &token, nil)
+	require.NoError(t, err)
+}
+
+func TestGetOAuthErrorDetails(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    int
+		header  http.Header
+		body    string
+		wantErr bool
+	}{
+		{
+			name:    "OAuth expired token",
+			code:    http.StatusUnauthorized,
+			body:    `{"error":"invalid_token","error_description":"Token is expired. You can either do re-authorization or token refresh."}`,
+			wantErr: true,
+		},
+		{
+			name:    "Bitbucket expired token",
+			code:    http.StatusUnauthorized,
+			body:    `{"type":"error","error":{"message":"Access token expired."}}`,
+			wantErr: true,
+		},
+		{
+			name:    "Bitbucket Server unauthorized",
+			code:    http.StatusUnauthorized,
+			body:    `{"errors":[{"context":null,"message":"Authentication failed. Please check your credentials and try again.","exceptionName":"com.atlassian.bitbucket.auth.IncorrectPasswordAuthenticationException"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "Bitbucket Server not found",
+			code:    http.StatusNotFound,
+			body:    `{"errors":[{"context":null,"message":"Repository octocat/hello-world does not exist.","exceptionName":"com.atlassian.bitbucket.repository.NoSuchRepositoryException"}]}`,
+			wantErr: false,
+		},
+		{
+			name:    "Azure DevOps unauthorized",
+			code:    http.StatusUnauthorized,
+			body:    `{"$id":"1","innerException":null,"message":"TF400813: The user '' is not authorized to access this resource.","typeKey":"UnauthorizedRequestException","errorCode":0}`,
+			wantErr: true,
+		},
+		{
+			name:    "Azure DevOps sign-in redirection",
+			code:    http.StatusNonAuthoritativeInfo,
+			header:  http.Header{"X-Tfs-Fedauthredirect": []string{"https://spsprodcus5.vssps.visualstudio.com/_signin"}},
+			body:    `<html></html>`,
+			wantErr: true,
+		},
+		{
+			name:    "Non-authoritative information",
+			code:    http.StatusNonAuthoritativeInfo,
+			body:    `{}`,
+			wantErr: false,
+		},
+		{
+			name:    "Azure DevOps not found",
+			code:    http.StatusNotFound,
+			body:    `{"$id":"1","innerException":null,"message":"TF401019: The Git repository does not exist.","typeKey":"GitRepositoryNotFoundException","errorCode":0}`,
+			wantErr: false,
+		},
+		{
+			name:    "Not found",
+			code:    http.StatusNotFound,
+			body:    `{"message":"404 Not Found"}`,
+			wantErr: false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := getOAuthErrorDetails(test.code, test.header, []byte(test.body))
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
 func TestRetry_Exceeded(t *testing.T) {
 	ctx := context.Background()
 	token := "expired"
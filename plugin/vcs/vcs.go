@@ -22,6 +22,14 @@ const (
 	GitLabSelfHost Type = "GITLAB_SELF_HOST"
 	// GitHubCom is the VCS type for GitHub.com.
 	GitHubCom Type = "GITHUB_COM"
+	// BitbucketCloud is the VCS type for Bitbucket Cloud.
+	BitbucketCloud Type = "BITBUCKET_CLOUD"
+	// BitbucketServer is the VCS type for Bitbucket Server and Bitbucket Data Center.
+	BitbucketServer Type = "BITBUCKET_SERVER"
+	// Gitea is the VCS type for Gitea and Forgejo.
+	Gitea Type = "GITEA"
+	// AzureDevOps is the VCS type for Azure DevOps Services and Server.
+	AzureDevOps Type = "AZURE_DEVOPS"
 
 	// SQLReviewAPISecretName is the api secret name used in GitHub action or GitLab CI workflow.
 	SQLReviewAPISecretName = "SQL_REVIEW_API_SECRET"
@@ -103,6 +111,38 @@ type PushEvent struct {
 	FileCommit FileCommit `json:"fileCommit"`
 }
 
+// PullRequestEventAction is the action of a VCS pull request event.
+type PullRequestEventAction string
+
+const (
+	// PullRequestEventOpened is the action for a newly opened or reopened pull request.
+	PullRequestEventOpened PullRequestEventAction = "OPENED"
+	// PullRequestEventUpdated is the action for new commits pushed to the source branch of a pull request.
+	PullRequestEventUpdated PullRequestEventAction = "UPDATED"
+	// PullRequestEventMerged is the action for a merged pull request.
+	PullRequestEventMerged PullRequestEventAction = "MERGED"
+	// PullRequestEventClosed is the action for a pull request closed without merging.
+	PullRequestEventClosed PullRequestEventAction = "CLOSED"
+)
+
+// PullRequestEvent is the API message for a VCS pull request event.
+type PullRequestEvent struct {
+	Action             PullRequestEventAction
+	RepositoryID       string
+	RepositoryURL      string
+	RepositoryFullPath string
+	// PullRequestID is the ID used to fetch the pull request from the VCS API, e.g. the MR iid in GitLab.
+	PullRequestID string
+	URL           string
+	Title         string
+	SourceBranch  string
+	TargetBranch  string
+	// HeadCommitID is the latest commit of the source branch.
+	HeadCommitID string
+	AuthorName   string
+	AuthorEmail  string
+}
+
 // State is the state of a VCS user account.
 type State string
 
@@ -168,6 +208,65 @@ type PullRequest struct {
 	URL string `json:"url"`
 }
 
+// CommitStatusState is the state of a commit status.
+type CommitStatusState string
+
+const (
+	// CommitStatusPending is the commit status state for PENDING.
+	CommitStatusPending CommitStatusState = "PENDING"
+	// CommitStatusSuccess is the commit status state for SUCCESS.
+	CommitStatusSuccess CommitStatusState = "SUCCESS"
+	// CommitStatusFailure is the commit status state for FAILURE.
+	CommitStatusFailure CommitStatusState = "FAILURE"
+	// CommitStatusError is the commit status state for ERROR.
+	CommitStatusError CommitStatusState = "ERROR"
+)
+
+// CommitStatus is the API message for the status of a commit, which is shown
+// as a check on the pull requests containing the commit.
+type CommitStatus struct {
+	State CommitStatusState
+	// Context is the label to differentiate the status from the statuses of
+	// other systems, e.g. "bytebase/sql-review".
+	Context     string
+	Description string
+	TargetURL   string
+}
+
+// PullRequestReview is the API message for reviewing a pull request with
+// inline comments.
+type PullRequestReview struct {
+	// Context is the label to identify the reviews posted by the same system,
+	// e.g. "bytebase/sql-review". A new review replaces the previous review with
+	// the same context on the pull request.
+	Context string
+	// CommitID is the commit that the inline comments are attached to, which
+	// should be the head commit of the pull request.
+	CommitID    string
+	Body        string
+	CommentList []*PullRequestComment
+}
+
+// Marker returns the hidden marker that the providers append to every comment
+// of the review, which is used to find the comments of the previous review
+// with the same context.
+func (r *PullRequestReview) Marker() string {
+	return fmt.Sprintf("<!-- %s -->", r.Context)
+}
+
+// PullRequestComment is the API message for an inline comment on a line of a
+// file in the pull request.
+type PullRequestComment struct {
+	Path string
+	// Line is the line number in the new version of the file, starting from 1.
+	Line int
+	Body string
+}
+
+// ErrNotImplemented is returned by the providers for the optional features that
+// are not implemented for the VCS yet, which the callers may skip silently.
+var ErrNotImplemented = common.Errorf(common.NotImplemented, "not implemented for the VCS provider")
+
 // Provider is the interface for VCS provider.
 type Provider interface {
 	// Returns the API URL for a given VCS instance URL
@@ -270,6 +369,23 @@ type Provider interface {
 	ListPullRequestFile(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, pullRequestID string) ([]*PullRequestFile, error)
 	// pullRequestCreate: the new pull request info
 	CreatePullRequest(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID string, pullRequestCreate *PullRequestCreate) (*PullRequest, error)
+	// CreateCommitStatus creates the status of the commit.
+	//
+	// oauthCtx: OAuth context to create the commit status
+	// instanceURL: VCS instance URL
+	// repositoryID: the repository ID from the external VCS system (note this is NOT the ID of Bytebase's own repository resource)
+	// commitID: the commit ID
+	// status: the commit status
+	CreateCommitStatus(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, commitID string, status *CommitStatus) error
+	// UpsertPullRequestReview posts the review with inline comments to the pull
+	// request, replacing the previous review with the same context.
+	//
+	// oauthCtx: OAuth context to create the review
+	// instanceURL: VCS instance URL
+	// repositoryID: the repository ID from the external VCS system (note this is NOT the ID of Bytebase's own repository resource)
+	// pullRequestID: the pull request id
+	// review: the review with inline comments
+	UpsertPullRequestReview(ctx context.Context, oauthCtx common.OauthContext, instanceURL, repositoryID, pullRequestID string, review *PullRequestReview) error
 	// UpsertEnvironmentVariable creates or updates the environment variable in the repository.
 	//
 	// oauthCtx: OAuth context to create the webhook
@@ -10,6 +10,7 @@ import (
 
 	"github.com/bytebase/bytebase/api"
 	"github.com/bytebase/bytebase/common/log"
+	"github.com/bytebase/bytebase/plugin/metric/prometheus"
 	"github.com/bytebase/bytebase/plugin/webhook"
 	"github.com/bytebase/bytebase/store"
 
@@ -167,6 +168,7 @@ func postWebhookList(webhookCtx webhook.Context, webhookList []*api.ProjectWebho
 		webhookCtx.URL = hook.URL
 		webhookCtx.CreatedTs = time.Now().Unix()
 		if err := webhook.Post(hook.Type, webhookCtx); err != nil {
+			prometheus.WebhookDeliveryFailures.WithLabelValues(hook.Type).Inc()
 			// The external webhook endpoint might be invalid which is out of our code control, so we just emit a warning
 			log.Warn("Failed to post webhook event after changing the issue status",
 				zap.String("webhook_type", hook.Type),
@@ -281,6 +283,12 @@ func (m *ActivityManager) getWebhookContext(ctx context.Context, activity *api.A
 		default:
 			title = fmt.Sprintf("Updated issue - %s", meta.issue.Name)
 		}
+	case api.ActivityPipelineRolloutHalt:
+		level = webhook.WebhookError
+		title = fmt.Sprintf("Rollout halted - %s", meta.issue.Name)
+	case api.ActivityPipelineTaskMaintenanceWindowOverride:
+		level = webhook.WebhookWarn
+		title = fmt.Sprintf("Maintenance window overridden - %s", meta.issue.Name)
 	case api.ActivityPipelineTaskStatusUpdate:
 		update := &api.ActivityPipelineTaskStatusUpdatePayload{}
 		if err := json.Unmarshal([]byte(activity.Payload), update); err != nil {
@@ -394,6 +402,10 @@ func shouldPostInbox(activity *api.Activity, createType api.ActivityType) (bool,
 		return true, nil
 	case api.ActivityPipelineTaskEarliestAllowedTimeUpdate:
 		return true, nil
+	case api.ActivityPipelineTaskMaintenanceWindowOverride:
+		return true, nil
+	case api.ActivityPipelineRolloutHalt:
+		return true, nil
 	case api.ActivityPipelineTaskStatusUpdate:
 		update := new(api.ActivityPipelineTaskStatusUpdatePayload)
 		if err := json.Unmarshal([]byte(activity.Payload), update); err != nil {
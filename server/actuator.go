@@ -22,6 +22,7 @@ func (s *Server) registerActuatorRoutes(g *echo.Group) {
 			Readonly:    s.profile.Readonly,
 			Demo:        s.profile.Demo,
 			ExternalURL: s.profile.ExternalURL,
+			Leader:      s.LeaderElector != nil && s.LeaderElector.IsLeader(),
 		}
 
 		if s.profile.Demo && strings.HasPrefix(s.profile.DemoDataDir, demoDataPath) {
@@ -1,14 +1,18 @@
 package server
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/google/jsonapi"
 	"github.com/labstack/echo/v4"
 
 	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/plugin/db"
 )
 
 func (s *Server) registerAnomalyRoutes(g *echo.Group) {
@@ -51,4 +55,119 @@ func (s *Server) registerAnomalyRoutes(g *echo.Group) {
 		}
 		return nil
 	})
+	// Create a schema update issue applying the reconciliation DDL to bring the database back to the expected schema.
+	g.POST("/anomaly/:anomalyID/schema-drift/reconcile", func(c echo.Context) error {
+		ctx := c.Request().Context()
+		database, payload, err := s.getSchemaDriftAnomaly(ctx, c.Param("anomalyID"))
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(payload.Reconciliation) == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("No reconciliation statement is available for the schema drift of database %q", database.Name))
+		}
+
+		issue, err := s.createSchemaDriftIssue(ctx, database, &api.MigrationDetail{
+			MigrationType: db.Migrate,
+			DatabaseID:    database.ID,
+			Statement:     payload.Reconciliation,
+		}, fmt.Sprintf("[%s] Reconcile schema drift", database.Name),
+			fmt.Sprintf("Reconcile the schema drift of database %q with the schema of version %s.", database.Name, payload.Version),
+			c.Get(getPrincipalIDContextKey()).(int),
+		)
+		if err != nil {
+			return err
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, issue); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal create issue response").SetInternal(err)
+		}
+		return nil
+	})
+
+	// Create an issue establishing a new baseline from the actual schema of the database.
+	g.POST("/anomaly/:anomalyID/schema-drift/baseline", func(c echo.Context) error {
+		ctx := c.Request().Context()
+		database, _, err := s.getSchemaDriftAnomaly(ctx, c.Param("anomalyID"))
+		if err != nil {
+			return err
+		}
+
+		issue, err := s.createSchemaDriftIssue(ctx, database, &api.MigrationDetail{
+			MigrationType: db.Baseline,
+			DatabaseID:    database.ID,
+		}, fmt.Sprintf("[%s] Re-baseline drifted schema", database.Name),
+			fmt.Sprintf("Establish a new baseline from the actual schema of database %q.", database.Name),
+			c.Get(getPrincipalIDContextKey()).(int),
+		)
+		if err != nil {
+			return err
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, issue); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal create issue response").SetInternal(err)
+		}
+		return nil
+	})
+}
+
+// getSchemaDriftAnomaly gets the database and the payload of an active schema drift anomaly.
+func (s *Server) getSchemaDriftAnomaly(ctx context.Context, idStr string) (*api.Database, *api.AnomalyDatabaseSchemaDriftPayload, error) {
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return nil, nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("ID is not a number: %s", idStr)).SetInternal(err)
+	}
+	normalRowStatus := api.Normal
+	anomalyType := api.AnomalyDatabaseSchemaDrift
+	anomalyList, err := s.store.FindAnomaly(ctx, &api.AnomalyFind{
+		ID:        &id,
+		RowStatus: &normalRowStatus,
+		Type:      &anomalyType,
+	})
+	if err != nil {
+		return nil, nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch anomaly with ID %d", id)).SetInternal(err)
+	}
+	if len(anomalyList) == 0 || anomalyList[0].DatabaseID == nil {
+		return nil, nil, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Active schema drift anomaly not found with ID %d", id))
+	}
+	anomaly := anomalyList[0]
+
+	payload := &api.AnomalyDatabaseSchemaDriftPayload{}
+	if err := json.Unmarshal([]byte(anomaly.Payload), payload); err != nil {
+		return nil, nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to unmarshal payload of anomaly with ID %d", id)).SetInternal(err)
+	}
+	database, err := s.store.GetDatabase(ctx, &api.DatabaseFind{ID: anomaly.DatabaseID})
+	if err != nil {
+		return nil, nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch database with ID %d", *anomaly.DatabaseID)).SetInternal(err)
+	}
+	if database == nil {
+		return nil, nil, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Database not found with ID %d", *anomaly.DatabaseID))
+	}
+	return database, payload, nil
+}
+
+// createSchemaDriftIssue creates an issue remediating the schema drift of the database.
+func (s *Server) createSchemaDriftIssue(ctx context.Context, database *api.Database, detail *api.MigrationDetail, name, description string, creatorID int) (*api.Issue, error) {
+	createContext, err := json.Marshal(
+		&api.MigrationContext{
+			DetailList: []*api.MigrationDetail{detail},
+		},
+	)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal update schema context").SetInternal(err)
+	}
+	issue, err := s.createIssue(ctx, &api.IssueCreate{
+		ProjectID:   database.ProjectID,
+		Name:        name,
+		Type:        api.IssueDatabaseSchemaUpdate,
+		Description: description,
+		// Let the server pick the default assignee.
+		AssigneeID:    api.SystemBotID,
+		CreateContext: string(createContext),
+	}, creatorID)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "Failed to create schema drift issue").SetInternal(err)
+	}
+	return issue, nil
 }
@@ -15,11 +15,19 @@ import (
 	"github.com/bytebase/bytebase/common"
 	"github.com/bytebase/bytebase/common/log"
 	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/plugin/metric/prometheus"
+	"github.com/bytebase/bytebase/plugin/parser"
+	"github.com/bytebase/bytebase/plugin/parser/differ"
+	"github.com/bytebase/bytebase/plugin/vcs"
 )
 
 const (
 	// The chosen interval is a balance between anomaly staleness tolerance and background load.
 	anomalyScanInterval = time.Duration(10) * time.Minute
+	// minDatabaseSizeForGrowthAnomaly is the minimum size of a database in bytes to check the growth ratio.
+	minDatabaseSizeForGrowthAnomaly = 64 * 1024 * 1024
+	// migrationChecksumScanLimit is the number of the most recent migration histories of a database to check the checksum.
+	migrationChecksumScanLimit = 100
 )
 
 // NewAnomalyScanner creates a anomaly scanner.
@@ -33,6 +41,17 @@ func NewAnomalyScanner(server *Server) *AnomalyScanner {
 // AnomalyScanner is the anomaly scanner.
 type AnomalyScanner struct {
 	server *Server
+	// vcsChecksumCache caches the checksum of the migration files read from the VCS repositories, so that
+	// a migration file is only read again after the branch moves. The key is "{repository ID}/{file path}"
+	// and the value is *vcsFileChecksum.
+	vcsChecksumCache sync.Map
+}
+
+// vcsFileChecksum is the checksum of a file in the VCS repository at a commit.
+type vcsFileChecksum struct {
+	commitID string
+	// checksum is empty if the file cannot be read at the commit, e.g. it has been moved or deleted.
+	checksum string
 }
 
 // Run will run the anomaly scanner once.
@@ -48,6 +67,8 @@ func (s *AnomalyScanner) Run(ctx context.Context, wg *sync.WaitGroup) {
 		case <-ticker.C:
 			log.Debug("New anomaly scanner round started...")
 			func() {
+				start := time.Now()
+				defer prometheus.ObserveSince(prometheus.RunnerCycleDuration, start, string(prometheus.RunnerAnomalyScanner))
 				defer func() {
 					if r := recover(); r != nil {
 						err, ok := r.(error)
@@ -78,6 +99,12 @@ func (s *AnomalyScanner) Run(ctx context.Context, wg *sync.WaitGroup) {
 					backupPlanPolicyMap[env.ID] = policy
 				}
 
+				databaseSizeSetting, err := s.getDatabaseSizeSetting(ctx)
+				if err != nil {
+					log.Error("Failed to retrieve database size anomaly setting", zap.Error(err))
+					return
+				}
+
 				rowStatus := api.Normal
 				instanceFind := &api.InstanceFind{
 					RowStatus: &rowStatus,
@@ -136,6 +163,8 @@ func (s *AnomalyScanner) Run(ctx context.Context, wg *sync.WaitGroup) {
 						for _, database := range dbList {
 							s.checkDatabaseAnomaly(ctx, instance, database)
 							s.checkBackupAnomaly(ctx, instance, database, backupPlanPolicyMap)
+							s.checkDatabaseSizeAnomaly(ctx, instance, database, databaseSizeSetting)
+							s.checkMigrationChecksumAnomaly(ctx, instance, database)
 						}
 					}(instance)
 
@@ -222,6 +251,12 @@ func (s *AnomalyScanner) checkInstanceAnomaly(ctx context.Context, instance *api
 						zap.String("type", string(api.AnomalyInstanceMigrationSchema)),
 						zap.Error(err))
 				}
+				// Upgrade the migration schema set up by the earlier releases, so that the migration history can be checked.
+				if err := driver.SetupMigrationIfNeeded(ctx); err != nil {
+					log.Error("Failed to upgrade migration schema",
+						zap.String("instance", instance.Name),
+						zap.Error(err))
+				}
 			}
 		}
 	}
@@ -324,6 +359,17 @@ func (s *AnomalyScanner) checkDatabaseAnomaly(ctx context.Context, instance *api
 					Expect:  list[0].Schema,
 					Actual:  schemaBuf.String(),
 				}
+				changeList, reconciliation, err := diffSchemaDrift(instance.Engine, anomalyPayload.Expect, anomalyPayload.Actual)
+				if err != nil {
+					// We still report the drift without the structured diff.
+					log.Warn("Failed to diff schema drift",
+						zap.String("instance", instance.Name),
+						zap.String("database", database.Name),
+						zap.String("type", string(api.AnomalyDatabaseSchemaDrift)),
+						zap.Error(err))
+				}
+				anomalyPayload.ChangeList = changeList
+				anomalyPayload.Reconciliation = reconciliation
 				payload, err := json.Marshal(anomalyPayload)
 				if err != nil {
 					log.Error("Failed to marshal anomaly payload",
@@ -363,6 +409,37 @@ func (s *AnomalyScanner) checkDatabaseAnomaly(ctx context.Context, instance *api
 	}
 }
 
+// diffSchemaDrift returns the structured changes from the expected schema to the actual schema,
+// and the DDL statements to reconcile the actual schema with the expected schema.
+// Engines without a schema differ return empty results.
+func diffSchemaDrift(engineType db.Type, expect, actual string) ([]*differ.SchemaChange, string, error) {
+	var engine parser.EngineType
+	switch engineType {
+	case db.Postgres:
+		engine = parser.Postgres
+	case db.MySQL:
+		engine = parser.MySQL
+	case db.TiDB:
+		engine = parser.TiDB
+	default:
+		return nil, "", nil
+	}
+
+	diff, err := differ.SchemaDiff(engine, expect, actual)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to diff the expected schema with the actual schema")
+	}
+	changeList, err := differ.SummarizeDiff(engine, diff)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to summarize the schema diff")
+	}
+	reconciliation, err := differ.SchemaDiff(engine, actual, expect)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to diff the actual schema with the expected schema")
+	}
+	return changeList, reconciliation, nil
+}
+
 func (s *AnomalyScanner) checkBackupAnomaly(ctx context.Context, instance *api.Instance, database *api.Database, policyMap map[int]*api.BackupPlanPolicy) {
 	schedule := api.BackupPlanPolicyScheduleUnset
 	backupSetting, err := s.server.store.GetBackupSettingByDatabaseID(ctx, database.ID)
@@ -524,3 +601,341 @@ func (s *AnomalyScanner) checkBackupAnomaly(ctx context.Context, instance *api.I
 		}
 	}
 }
+
+func (s *AnomalyScanner) getDatabaseSizeSetting(ctx context.Context) (*api.SettingAnomalyDatabaseSizeValue, error) {
+	settingName := api.SettingAnomalyDatabaseSize
+	setting, err := s.server.store.GetSetting(ctx, &api.SettingFind{Name: &settingName})
+	if err != nil {
+		return nil, err
+	}
+	value := &api.SettingAnomalyDatabaseSizeValue{}
+	if setting == nil || setting.Value == "" {
+		return value, nil
+	}
+	if err := json.Unmarshal([]byte(setting.Value), value); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal setting %q", settingName)
+	}
+	return value, nil
+}
+
+func (s *AnomalyScanner) checkDatabaseSizeAnomaly(ctx context.Context, instance *api.Instance, database *api.Database, setting *api.SettingAnomalyDatabaseSizeValue) {
+	// Compare the size of today with the previous day.
+	sinceTs := time.Now().Add(-48 * time.Hour).Unix()
+	historyList, err := s.server.store.FindTableSizeHistory(ctx, &api.TableSizeHistoryFind{
+		DatabaseID:  &database.ID,
+		Granularity: api.TableSizeHistoryGranularityDay,
+		SinceTs:     &sinceTs,
+	})
+	if err != nil {
+		log.Error("Failed to retrieve table size history",
+			zap.String("instance", instance.Name),
+			zap.String("database", database.Name),
+			zap.String("type", string(api.AnomalyDatabaseSizeGrowth)),
+			zap.Error(err))
+		return
+	}
+
+	var anomalyPayload *api.AnomalyDatabaseSizeGrowthPayload
+	if len(historyList) > 0 {
+		current := historyList[len(historyList)-1]
+		size := current.DataSize + current.IndexSize
+		payload := &api.AnomalyDatabaseSizeGrowthPayload{
+			Size: size,
+		}
+		violated := false
+		if setting.MaxSize > 0 && size > setting.MaxSize {
+			payload.MaxSize = setting.MaxSize
+			violated = true
+		}
+		if len(historyList) > 1 {
+			previous := historyList[len(historyList)-2]
+			payload.PreviousSize = previous.DataSize + previous.IndexSize
+			// Ignore the growth of tiny databases to avoid noise.
+			if payload.PreviousSize >= minDatabaseSizeForGrowthAnomaly {
+				payload.GrowthRatio = float64(size-payload.PreviousSize) / float64(payload.PreviousSize)
+				if setting.MaxDailyGrowthRatio > 0 && payload.GrowthRatio > setting.MaxDailyGrowthRatio {
+					payload.MaxDailyGrowthRatio = setting.MaxDailyGrowthRatio
+					violated = true
+				}
+			}
+		}
+		if violated {
+			anomalyPayload = payload
+		}
+	}
+
+	if anomalyPayload != nil {
+		payload, err := json.Marshal(*anomalyPayload)
+		if err != nil {
+			log.Error("Failed to marshal anomaly payload",
+				zap.String("instance", instance.Name),
+				zap.String("database", database.Name),
+				zap.String("type", string(api.AnomalyDatabaseSizeGrowth)),
+				zap.Error(err))
+			return
+		}
+		if _, err = s.server.store.UpsertActiveAnomaly(ctx, &api.AnomalyUpsert{
+			CreatorID:  api.SystemBotID,
+			InstanceID: instance.ID,
+			DatabaseID: &database.ID,
+			Type:       api.AnomalyDatabaseSizeGrowth,
+			Payload:    string(payload),
+		}); err != nil {
+			log.Error("Failed to create anomaly",
+				zap.String("instance", instance.Name),
+				zap.String("database", database.Name),
+				zap.String("type", string(api.AnomalyDatabaseSizeGrowth)),
+				zap.Error(err))
+		}
+		return
+	}
+
+	err = s.server.store.ArchiveAnomaly(ctx, &api.AnomalyArchive{
+		DatabaseID: &database.ID,
+		Type:       api.AnomalyDatabaseSizeGrowth,
+	})
+	if err != nil && common.ErrorCode(err) != common.NotFound {
+		log.Error("Failed to close anomaly",
+			zap.String("instance", instance.Name),
+			zap.String("database", database.Name),
+			zap.String("type", string(api.AnomalyDatabaseSizeGrowth)),
+			zap.Error(err))
+	}
+}
+
+func (s *AnomalyScanner) checkMigrationChecksumAnomaly(ctx context.Context, instance *api.Instance, database *api.Database) {
+	driver, err := s.server.getAdminDatabaseDriver(ctx, instance, database.Name)
+	// Skip the check if we cannot connect to the database (we have database connection anomaly to cover that).
+	if err != nil {
+		return
+	}
+	defer driver.Close(ctx)
+
+	setup, err := driver.NeedsSetupMigration(ctx)
+	if err != nil {
+		log.Debug("Failed to check anomaly",
+			zap.String("instance", instance.Name),
+			zap.String("database", database.Name),
+			zap.String("type", string(api.AnomalyDatabaseMigrationChecksumMismatch)),
+			zap.Error(err))
+		return
+	}
+	// Skip the check if migration schema is not ready (we have instance anomaly to cover that).
+	if setup {
+		return
+	}
+	limit := migrationChecksumScanLimit
+	historyList, err := driver.FindMigrationHistoryList(ctx, &db.MigrationHistoryFind{
+		Database: &database.Name,
+		Limit:    &limit,
+	})
+	if err != nil {
+		log.Error("Failed to check anomaly",
+			zap.String("instance", instance.Name),
+			zap.String("database", database.Name),
+			zap.String("type", string(api.AnomalyDatabaseMigrationChecksumMismatch)),
+			zap.Error(err))
+		return
+	}
+
+	mismatchList := getHistoryChecksumMismatchList(historyList)
+	repo, err := s.server.store.GetRepository(ctx, &api.RepositoryFind{ProjectID: &database.ProjectID})
+	if err != nil {
+		log.Error("Failed to find linked repository",
+			zap.String("instance", instance.Name),
+			zap.String("database", database.Name),
+			zap.String("type", string(api.AnomalyDatabaseMigrationChecksumMismatch)),
+			zap.Error(err))
+		return
+	}
+	if repo != nil {
+		mismatchList = append(mismatchList, s.getVCSChecksumMismatchList(ctx, repo, database, historyList)...)
+	}
+
+	if len(mismatchList) > 0 {
+		payload, err := json.Marshal(api.AnomalyDatabaseMigrationChecksumMismatchPayload{
+			MismatchList: mismatchList,
+		})
+		if err != nil {
+			log.Error("Failed to marshal anomaly payload",
+				zap.String("instance", instance.Name),
+				zap.String("database", database.Name),
+				zap.String("type", string(api.AnomalyDatabaseMigrationChecksumMismatch)),
+				zap.Error(err))
+			return
+		}
+		if _, err = s.server.store.UpsertActiveAnomaly(ctx, &api.AnomalyUpsert{
+			CreatorID:  api.SystemBotID,
+			InstanceID: instance.ID,
+			DatabaseID: &database.ID,
+			Type:       api.AnomalyDatabaseMigrationChecksumMismatch,
+			Payload:    string(payload),
+		}); err != nil {
+			log.Error("Failed to create anomaly",
+				zap.String("instance", instance.Name),
+				zap.String("database", database.Name),
+				zap.String("type", string(api.AnomalyDatabaseMigrationChecksumMismatch)),
+				zap.Error(err))
+		}
+		return
+	}
+
+	err = s.server.store.ArchiveAnomaly(ctx, &api.AnomalyArchive{
+		DatabaseID: &database.ID,
+		Type:       api.AnomalyDatabaseMigrationChecksumMismatch,
+	})
+	if err != nil && common.ErrorCode(err) != common.NotFound {
+		log.Error("Failed to close anomaly",
+			zap.String("instance", instance.Name),
+			zap.String("database", database.Name),
+			zap.String("type", string(api.AnomalyDatabaseMigrationChecksumMismatch)),
+			zap.Error(err))
+	}
+}
+
+// getHistoryChecksumMismatchList returns the applied migrations whose stored statement diverges from the recorded checksum,
+// which means the migration history has been modified after the migration was applied.
+func getHistoryChecksumMismatchList(historyList []*db.MigrationHistory) []*api.MigrationChecksumMismatch {
+	var mismatchList []*api.MigrationChecksumMismatch
+	for _, history := range historyList {
+		// The migration histories recorded by the earlier releases have no checksum.
+		if history.Status != db.Done || history.Checksum == "" {
+			continue
+		}
+		if checksum := db.Checksum(history.Statement); checksum != history.Checksum {
+			mismatchList = append(mismatchList, &api.MigrationChecksumMismatch{
+				MigrationHistoryID: history.ID,
+				Version:            history.Version,
+				Source:             api.MigrationChecksumMismatchSourceHistory,
+				Expect:             history.Checksum,
+				Actual:             checksum,
+			})
+		}
+	}
+	return mismatchList
+}
+
+// getVCSChecksumMismatchList returns the applied migrations whose migration file in the VCS repository diverges from
+// the recorded checksum, which means the migration file has been modified after the migration was applied.
+func (s *AnomalyScanner) getVCSChecksumMismatchList(ctx context.Context, repo *api.Repository, database *api.Database, historyList []*db.MigrationHistory) []*api.MigrationChecksumMismatch {
+	var mismatchList []*api.MigrationChecksumMismatch
+	oauthCtx := common.OauthContext{
+		ClientID:     repo.VCS.ApplicationID,
+		ClientSecret: repo.VCS.Secret,
+		AccessToken:  repo.AccessToken,
+		RefreshToken: repo.RefreshToken,
+		Refresher:    s.server.refreshToken(ctx, repo.WebURL),
+	}
+	// branchHeadMap is the map from the branch name to the head commit ID, which is empty if the branch cannot be found.
+	branchHeadMap := make(map[string]string)
+	// Only the latest application of a repeatable migration reflects the current file content.
+	checkedRepeatable := make(map[string]bool)
+	for _, history := range historyList {
+		if history.Status != db.Done || history.Source != db.VCS || history.Checksum == "" {
+			continue
+		}
+		// The statements of the other migration types are not the file content, e.g. the SDL migration statement is the schema diff.
+		if history.Type != db.Migrate && history.Type != db.Data && history.Type != db.Repeatable {
+			continue
+		}
+		var payload db.MigrationInfoPayload
+		if err := json.Unmarshal([]byte(history.Payload), &payload); err != nil || payload.VCSPushEvent == nil {
+			continue
+		}
+		if history.Type == db.Repeatable {
+			if checkedRepeatable[payload.RepeatableName] {
+				continue
+			}
+			checkedRepeatable[payload.RepeatableName] = true
+		}
+		filePath := getMigrationFilePath(repo, database, history, payload)
+		if filePath == "" {
+			continue
+		}
+		branch := repo.BranchFilter
+		if name, err := parseBranchNameFromRefs(payload.VCSPushEvent.Ref); err == nil {
+			branch = name
+		}
+		commitID, ok := branchHeadMap[branch]
+		if !ok {
+			branchInfo, err := vcs.Get(repo.VCS.Type, vcs.ProviderConfig{}).GetBranch(ctx, oauthCtx, repo.VCS.InstanceURL, repo.ExternalID, branch)
+			if err != nil {
+				log.Debug("Failed to get branch",
+					zap.String("database", database.Name),
+					zap.String("branch", branch),
+					zap.Error(err))
+			} else {
+				commitID = branchInfo.LastCommitID
+			}
+			branchHeadMap[branch] = commitID
+		}
+		if commitID == "" {
+			continue
+		}
+		checksum := s.getVCSFileChecksum(ctx, repo, filePath, commitID)
+		if checksum == "" {
+			continue
+		}
+		if checksum != history.Checksum {
+			mismatchList = append(mismatchList, &api.MigrationChecksumMismatch{
+				MigrationHistoryID: history.ID,
+				Version:            history.Version,
+				Source:             api.MigrationChecksumMismatchSourceVCS,
+				FilePath:           filePath,
+				Expect:             history.Checksum,
+				Actual:             checksum,
+			})
+		}
+	}
+	return mismatchList
+}
+
+// getVCSFileChecksum returns the checksum of the file in the VCS repository at the commit, or an empty string if the
+// file cannot be read. The file is only read if the commit differs from the one cached for the file.
+func (s *AnomalyScanner) getVCSFileChecksum(ctx context.Context, repo *api.Repository, filePath, commitID string) string {
+	key := fmt.Sprintf("%d/%s", repo.ID, filePath)
+	if v, ok := s.vcsChecksumCache.Load(key); ok {
+		if cached := v.(*vcsFileChecksum); cached.commitID == commitID {
+			return cached.checksum
+		}
+	}
+
+	content, err := s.server.readFileContentAtRef(ctx, repo, filePath, commitID)
+	if err != nil {
+		log.Debug("Failed to read migration file",
+			zap.String("file", filePath),
+			zap.String("commit", commitID),
+			zap.Error(err))
+		// The migration file may have been moved or deleted, which doesn't change the applied migration.
+		// Other errors may be transient, so the file is read again in the next scan.
+		if common.ErrorCode(err) == common.NotFound {
+			s.vcsChecksumCache.Store(key, &vcsFileChecksum{commitID: commitID})
+		}
+		return ""
+	}
+	checksum := db.Checksum(content)
+	s.vcsChecksumCache.Store(key, &vcsFileChecksum{commitID: commitID, checksum: checksum})
+	return checksum
+}
+
+// getMigrationFilePath returns the path of the migration file in the VCS repository from which the migration history
+// is applied, or an empty string if the file cannot be located from the push event.
+func getMigrationFilePath(repo *api.Repository, database *api.Database, history *db.MigrationHistory, payload db.MigrationInfoPayload) string {
+	if history.Type == db.Repeatable {
+		return payload.RepeatableName
+	}
+	for _, item := range payload.VCSPushEvent.GetDistinctFileList() {
+		// The statements in a YAML file are not the file content.
+		if item.IsYAML {
+			continue
+		}
+		mi, fType, _, err := getFileInfo(item, []*api.Repository{repo})
+		if err != nil || fType != migrationFileType {
+			continue
+		}
+		if mi.Version == history.Version && (mi.Database == "" || mi.Database == database.Name) {
+			return item.FileName
+		}
+	}
+	return ""
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/plugin/db"
+)
+
+func TestGetHistoryChecksumMismatchList(t *testing.T) {
+	statement := "CREATE TABLE t(id INT);"
+	historyList := []*db.MigrationHistory{
+		{
+			ID:        1,
+			Status:    db.Done,
+			Version:   "0001",
+			Statement: statement,
+			Checksum:  db.Checksum(statement),
+		},
+		{
+			// Recorded by the earlier releases without a checksum.
+			ID:        2,
+			Status:    db.Done,
+			Version:   "0002",
+			Statement: statement,
+		},
+		{
+			ID:        3,
+			Status:    db.Failed,
+			Version:   "0003",
+			Statement: "DROP TABLE t;",
+			Checksum:  db.Checksum(statement),
+		},
+		{
+			ID:        4,
+			Status:    db.Done,
+			Version:   "0004",
+			Statement: "DROP TABLE t;",
+			Checksum:  db.Checksum(statement),
+		},
+	}
+	want := []*api.MigrationChecksumMismatch{
+		{
+			MigrationHistoryID: 4,
+			Version:            "0004",
+			Source:             api.MigrationChecksumMismatchSourceHistory,
+			Expect:             db.Checksum(statement),
+			Actual:             db.Checksum("DROP TABLE t;"),
+		},
+	}
+	require.Equal(t, want, getHistoryChecksumMismatchList(historyList))
+}
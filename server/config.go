@@ -65,6 +65,9 @@ type Profile struct {
 	MetricConnectionKey string
 	// DisableMetric will disable the metric collector.
 	DisableMetric bool
+	// OTLPEndpoint is the OTLP/HTTP collector URL to export traces to, e.g. http://localhost:4318.
+	// Tracing is disabled if it's empty.
+	OTLPEndpoint string
 }
 
 func (prof *Profile) useEmbedDB() bool {
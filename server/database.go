@@ -384,6 +384,78 @@ func (s *Server) registerDatabaseRoutes(g *echo.Group) {
 		return nil
 	})
 
+	g.GET("/database/:databaseID/slow-query", func(c echo.Context) error {
+		ctx := c.Request().Context()
+		id, err := strconv.Atoi(c.Param("databaseID"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("ID is not a number: %s", c.Param("databaseID"))).SetInternal(err)
+		}
+
+		limit := 10
+		if limitStr := c.QueryParam("limit"); limitStr != "" {
+			v, err := strconv.Atoi(limitStr)
+			if err != nil || v <= 0 {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Query parameter limit is not a positive number: %s", limitStr)).SetInternal(err)
+			}
+			limit = v
+		}
+
+		slowQueryFind := &api.SlowQueryFind{
+			DatabaseID: &id,
+			Limit:      &limit,
+		}
+		slowQueryList, err := s.store.FindSlowQuery(ctx, slowQueryFind)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch slow query list for database ID: %d", id)).SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, slowQueryList); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal fetch slow query list response: %v", id)).SetInternal(err)
+		}
+		return nil
+	})
+
+	g.GET("/database/:databaseID/size-history", func(c echo.Context) error {
+		ctx := c.Request().Context()
+		id, err := strconv.Atoi(c.Param("databaseID"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("ID is not a number: %s", c.Param("databaseID"))).SetInternal(err)
+		}
+
+		tableSizeHistoryFind := &api.TableSizeHistoryFind{
+			DatabaseID:  &id,
+			Granularity: api.TableSizeHistoryGranularityDay,
+		}
+		if granularity := c.QueryParam("granularity"); granularity != "" {
+			tableSizeHistoryFind.Granularity = api.TableSizeHistoryGranularity(granularity)
+			if tableSizeHistoryFind.Granularity.Seconds() == 0 {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid granularity: %s", granularity))
+			}
+		}
+		// Return the size history of the whole database if the table is not specified.
+		if tableName := c.QueryParam("table"); tableName != "" {
+			tableSizeHistoryFind.TableName = &tableName
+		}
+		if sinceStr := c.QueryParam("since"); sinceStr != "" {
+			sinceTs, err := strconv.ParseInt(sinceStr, 10, 64)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Query parameter since is not a number: %s", sinceStr)).SetInternal(err)
+			}
+			tableSizeHistoryFind.SinceTs = &sinceTs
+		}
+		historyList, err := s.store.FindTableSizeHistory(ctx, tableSizeHistoryFind)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch size history for database ID: %d", id)).SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, historyList); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal fetch size history response: %v", id)).SetInternal(err)
+		}
+		return nil
+	})
+
 	g.GET("/database/:databaseID/schema", func(c echo.Context) error {
 		ctx := c.Request().Context()
 		id, err := strconv.Atoi(c.Param("databaseID"))
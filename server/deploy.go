@@ -3,6 +3,7 @@ package server
 import (
 	"encoding/json"
 	"sort"
+	"time"
 
 	"github.com/bytebase/bytebase/api"
 )
@@ -22,9 +23,23 @@ func isMatchExpression(labels map[string]string, expression *api.LabelSelectorRe
 			}
 		}
 		return false
+	case api.NotInOperatorType:
+		value, ok := labels[expression.Key]
+		if !ok {
+			return true
+		}
+		for _, exprValue := range expression.Values {
+			if exprValue == value {
+				return false
+			}
+		}
+		return true
 	case api.ExistsOperatorType:
 		_, ok := labels[expression.Key]
 		return ok
+	case api.DoesNotExistOperatorType:
+		_, ok := labels[expression.Key]
+		return !ok
 	default:
 		return false
 	}
@@ -118,6 +133,76 @@ func getDatabaseMatrixFromDeploymentSchedule(schedule *api.DeploymentSchedule, b
 	return deployments, matrix, nil
 }
 
+// getDeploymentPreviewList returns the databases matched by each deployment of the schedule, including the deployments
+// which match no database.
+func getDeploymentPreviewList(schedule *api.DeploymentSchedule, baseDatabaseName, dbNameTemplate string, databaseList []*api.Database) ([]*api.DeploymentPreview, error) {
+	deployments, matrix, err := getDatabaseMatrixFromDeploymentSchedule(schedule, baseDatabaseName, dbNameTemplate, databaseList)
+	if err != nil {
+		return nil, err
+	}
+	matched := make(map[*api.Deployment][]*api.Database)
+	for i, deployment := range deployments {
+		matched[deployment] = matrix[i]
+	}
+
+	var previewList []*api.DeploymentPreview
+	for _, deployment := range schedule.Deployments {
+		preview := &api.DeploymentPreview{
+			Name:         deployment.Name,
+			DatabaseList: []*api.DeploymentPreviewDatabase{},
+		}
+		for _, database := range matched[deployment] {
+			previewDatabase := &api.DeploymentPreviewDatabase{
+				ID:   database.ID,
+				Name: database.Name,
+			}
+			if database.Instance != nil {
+				previewDatabase.InstanceName = database.Instance.Name
+			}
+			preview.DatabaseList = append(preview.DatabaseList, previewDatabase)
+		}
+		previewList = append(previewList, preview)
+	}
+	return previewList, nil
+}
+
+// getRolloutTaskSet returns the IDs of the tasks in a stage which are allowed to start under the rollout controls at now.
+// The tasks are split into batches in the order of task ID. A batch is allowed to start after all the tasks of the
+// previous batches finish, i.e. done, failed or canceled, and the pause after the last of them passes.
+func getRolloutTaskSet(taskList []*api.Task, rollout *api.DeploymentRollout, now time.Time) map[int]bool {
+	sortedTaskList := append([]*api.Task{}, taskList...)
+	sort.Slice(sortedTaskList, func(i, j int) bool {
+		return sortedTaskList[i].ID < sortedTaskList[j].ID
+	})
+	batchSize := rollout.GetBatchSize(len(sortedTaskList))
+	pause := time.Duration(rollout.PauseSeconds) * time.Second
+
+	taskSet := make(map[int]bool)
+	var lastFinishedTs int64
+	for start := 0; start < len(sortedTaskList); start += batchSize {
+		if start > 0 && now.Before(time.Unix(lastFinishedTs, 0).Add(pause)) {
+			break
+		}
+		end := start + batchSize
+		if end > len(sortedTaskList) {
+			end = len(sortedTaskList)
+		}
+		finished := true
+		for _, task := range sortedTaskList[start:end] {
+			taskSet[task.ID] = true
+			if task.Status != api.TaskDone && task.Status != api.TaskFailed && task.Status != api.TaskCanceled {
+				finished = false
+			} else if task.UpdatedTs > lastFinishedTs {
+				lastFinishedTs = task.UpdatedTs
+			}
+		}
+		if !finished {
+			break
+		}
+	}
+	return taskSet
+}
+
 // formatDatabaseName will return the full database name given the dbNameTemplate, base database name, and labels.
 func formatDatabaseName(baseDatabaseName, dbNameTemplate string, labels map[string]string) (string, error) {
 	if dbNameTemplate == "" {
@@ -1,7 +1,9 @@
 package server
 
 import (
+	"sort"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -248,3 +250,165 @@ func TestGetDatabaseMatrixFromDeploymentSchedule(t *testing.T) {
 		assert.Equal(t, matrix, test.want)
 	}
 }
+
+func TestGetDeploymentPreviewList(t *testing.T) {
+	dbs := []*api.Database{
+		{
+			ID:     1,
+			Name:   "hello",
+			Labels: "[{\"key\":\"bb.location\",\"value\":\"us-central1\"},{\"key\":\"bb.environment\",\"value\":\"Prod\"}]",
+		},
+		{
+			ID:     2,
+			Name:   "hello",
+			Labels: "[{\"key\":\"bb.location\",\"value\":\"europe-west1\"},{\"key\":\"bb.environment\",\"value\":\"Prod\"}]",
+		},
+		{
+			ID:     3,
+			Name:   "hello",
+			Labels: "[{\"key\":\"bb.environment\",\"value\":\"Prod\"}]",
+		},
+	}
+	prodExpression := &api.LabelSelectorRequirement{Key: api.EnvironmentKeyName, Operator: api.InOperatorType, Values: []string{"Prod"}}
+	schedule := &api.DeploymentSchedule{
+		Deployments: []*api.Deployment{
+			{
+				Name: "No location",
+				Spec: &api.DeploymentSpec{
+					Selector: &api.LabelSelector{
+						MatchExpressions: []*api.LabelSelectorRequirement{
+							prodExpression,
+							{Key: "bb.location", Operator: api.DoesNotExistOperatorType},
+						},
+					},
+				},
+			},
+			{
+				Name: "Not in Europe",
+				Spec: &api.DeploymentSpec{
+					Selector: &api.LabelSelector{
+						MatchExpressions: []*api.LabelSelectorRequirement{
+							prodExpression,
+							{Key: "bb.location", Operator: api.NotInOperatorType, Values: []string{"europe-west1"}},
+						},
+					},
+				},
+			},
+			{
+				Name: "Asia",
+				Spec: &api.DeploymentSpec{
+					Selector: &api.LabelSelector{
+						MatchExpressions: []*api.LabelSelectorRequirement{
+							prodExpression,
+							{Key: "bb.location", Operator: api.InOperatorType, Values: []string{"asia-east1"}},
+						},
+					},
+				},
+			},
+			{
+				Name: "Rest",
+				Spec: &api.DeploymentSpec{
+					Selector: &api.LabelSelector{
+						MatchExpressions: []*api.LabelSelectorRequirement{
+							prodExpression,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	previewList, err := getDeploymentPreviewList(schedule, "hello", "", dbs)
+	assert.NoError(t, err)
+	got := make(map[string][]int)
+	for _, preview := range previewList {
+		got[preview.Name] = []int{}
+		for _, database := range preview.DatabaseList {
+			got[preview.Name] = append(got[preview.Name], database.ID)
+		}
+	}
+	assert.Equal(t, map[string][]int{
+		"No location":   {3},
+		"Not in Europe": {1},
+		"Asia":          {},
+		"Rest":          {2},
+	}, got)
+}
+
+func TestGetRolloutTaskSet(t *testing.T) {
+	now := time.Unix(10000, 0)
+	newTask := func(id int, status api.TaskStatus, updatedTs int64) *api.Task {
+		return &api.Task{ID: id, Status: status, UpdatedTs: updatedTs}
+	}
+
+	tests := []struct {
+		name     string
+		taskList []*api.Task
+		rollout  *api.DeploymentRollout
+		want     []int
+	}{
+		{
+			name: "all in one batch",
+			taskList: []*api.Task{
+				newTask(1, api.TaskPending, 0),
+				newTask(2, api.TaskPending, 0),
+				newTask(3, api.TaskPending, 0),
+			},
+			rollout: &api.DeploymentRollout{},
+			want:    []int{1, 2, 3},
+		},
+		{
+			name: "first batch running",
+			taskList: []*api.Task{
+				newTask(3, api.TaskPending, 0),
+				newTask(1, api.TaskRunning, 0),
+				newTask(2, api.TaskPending, 0),
+			},
+			rollout: &api.DeploymentRollout{BatchSize: 2},
+			want:    []int{1, 2},
+		},
+		{
+			name: "first batch finished with failure",
+			taskList: []*api.Task{
+				newTask(1, api.TaskDone, 9000),
+				newTask(2, api.TaskFailed, 9500),
+				newTask(3, api.TaskPending, 0),
+				newTask(4, api.TaskPending, 0),
+			},
+			rollout: &api.DeploymentRollout{BatchPercentage: 50, MaxFailureCount: 1},
+			want:    []int{1, 2, 3, 4},
+		},
+		{
+			name: "pause between batches",
+			taskList: []*api.Task{
+				newTask(1, api.TaskDone, 9000),
+				newTask(2, api.TaskDone, 9500),
+				newTask(3, api.TaskPending, 0),
+			},
+			rollout: &api.DeploymentRollout{BatchSize: 2, PauseSeconds: 600},
+			want:    []int{1, 2},
+		},
+		{
+			name: "pause passed",
+			taskList: []*api.Task{
+				newTask(1, api.TaskDone, 9000),
+				newTask(2, api.TaskDone, 9300),
+				newTask(3, api.TaskPending, 0),
+			},
+			rollout: &api.DeploymentRollout{BatchSize: 2, PauseSeconds: 600},
+			want:    []int{1, 2, 3},
+		},
+	}
+
+	for _, test := range tests {
+		taskSet := getRolloutTaskSet(test.taskList, test.rollout, now)
+		var got []int
+		for _, task := range test.taskList {
+			if taskSet[task.ID] {
+				got = append(got, task.ID)
+			}
+		}
+		sort.Ints(got)
+		assert.Equal(t, test.want, got, test.name)
+	}
+}
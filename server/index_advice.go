@@ -0,0 +1,308 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/jsonapi"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common/log"
+	indexAdvisor "github.com/bytebase/bytebase/plugin/advisor/index"
+	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/store"
+)
+
+const (
+	// indexAdviceSlowQueryLimit is the maximum number of slow queries analyzed by the index advisor.
+	indexAdviceSlowQueryLimit = 50
+	// indexAdviceSQLEditorQueryLimit is the maximum number of recent SQL editor queries on the instance analyzed by the index advisor.
+	indexAdviceSQLEditorQueryLimit = 200
+)
+
+var (
+	// pgSeqScanReg matches the sequential scan node in the PostgreSQL text format query plan, e.g. "Seq Scan on t  (cost=...)".
+	pgSeqScanReg = regexp.MustCompile(`Seq Scan on (\S+)`)
+)
+
+func (s *Server) registerIndexAdviceRoutes(g *echo.Group) {
+	g.GET("/database/:databaseID/index-advice", func(c echo.Context) error {
+		ctx := c.Request().Context()
+		database, err := s.getIndexAdviceDatabase(ctx, c.Param("databaseID"))
+		if err != nil {
+			return err
+		}
+
+		sourceList := []api.IndexAdviceSource{api.IndexAdviceSourceSlowQuery, api.IndexAdviceSourceSQLEditor}
+		if source := c.QueryParam("source"); source != "" {
+			switch api.IndexAdviceSource(source) {
+			case api.IndexAdviceSourceSlowQuery, api.IndexAdviceSourceSQLEditor:
+				sourceList = []api.IndexAdviceSource{api.IndexAdviceSource(source)}
+			default:
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid index advice source: %s", source))
+			}
+		}
+
+		queryList, err := s.getIndexAdviceQueryList(ctx, database, sourceList)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch queries for database ID: %d", database.ID)).SetInternal(err)
+		}
+		explainIndexAdviceQueryList(ctx, database, queryList)
+
+		databaseCatalog, err := s.store.NewCatalog(ctx, database.ID, database.Instance.Engine)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch catalog for database ID: %d", database.ID)).SetInternal(err)
+		}
+		catalogDatabase := databaseCatalog.(*store.Catalog).Database
+
+		recommendationList, err := indexAdvisor.Recommend(database.Instance.Engine, catalogDatabase, queryList)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to recommend indexes for database ID: %d", database.ID)).SetInternal(err)
+		}
+		indexAdvice := &api.IndexAdvice{
+			DatabaseID:         database.ID,
+			RecommendationList: recommendationList,
+			RedundantIndexList: indexAdvisor.FindRedundantIndex(database.Instance.Engine, catalogDatabase),
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, indexAdvice); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal index advice response: %v", database.ID)).SetInternal(err)
+		}
+		return nil
+	})
+
+	g.POST("/database/:databaseID/index-advice/issue", func(c echo.Context) error {
+		ctx := c.Request().Context()
+		database, err := s.getIndexAdviceDatabase(ctx, c.Param("databaseID"))
+		if err != nil {
+			return err
+		}
+
+		issueCreate := &api.IndexAdviceIssueCreate{}
+		if err := jsonapi.UnmarshalPayload(c.Request().Body, issueCreate); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformed create index advice issue request").SetInternal(err)
+		}
+		if strings.TrimSpace(issueCreate.Statement) == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "Statement must not be empty")
+		}
+
+		createContext, err := json.Marshal(
+			&api.MigrationContext{
+				DetailList: []*api.MigrationDetail{
+					{
+						MigrationType: db.Migrate,
+						DatabaseID:    database.ID,
+						Statement:     issueCreate.Statement,
+					},
+				},
+			},
+		)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal update schema context").SetInternal(err)
+		}
+		issue, err := s.createIssue(ctx, &api.IssueCreate{
+			ProjectID:   database.ProjectID,
+			Name:        fmt.Sprintf("[%s] Apply index advice", database.Name),
+			Type:        api.IssueDatabaseSchemaUpdate,
+			Description: "Apply the index changes suggested by the index advisor.",
+			// Let the server pick the default assignee.
+			AssigneeID:    api.SystemBotID,
+			CreateContext: string(createContext),
+		}, c.Get(getPrincipalIDContextKey()).(int))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create schema update issue").SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, issue); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal create issue response").SetInternal(err)
+		}
+		return nil
+	})
+}
+
+// getIndexAdviceDatabase gets the database supported by the index advisor.
+func (s *Server) getIndexAdviceDatabase(ctx context.Context, idStr string) (*api.Database, error) {
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("ID is not a number: %s", idStr)).SetInternal(err)
+	}
+	database, err := s.store.GetDatabase(ctx, &api.DatabaseFind{ID: &id})
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch database with ID %d", id)).SetInternal(err)
+	}
+	if database == nil {
+		return nil, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Database not found with ID %d", id))
+	}
+	switch database.Instance.Engine {
+	case db.MySQL, db.TiDB, db.Postgres:
+	default:
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Index advisor is not supported for engine %s", database.Instance.Engine))
+	}
+	return database, nil
+}
+
+// getIndexAdviceQueryList gets the queries of the database from the sources.
+func (s *Server) getIndexAdviceQueryList(ctx context.Context, database *api.Database, sourceList []api.IndexAdviceSource) ([]*indexAdvisor.Query, error) {
+	var queryList []*indexAdvisor.Query
+	for _, source := range sourceList {
+		switch source {
+		case api.IndexAdviceSourceSlowQuery:
+			limit := indexAdviceSlowQueryLimit
+			slowQueryList, err := s.store.FindSlowQuery(ctx, &api.SlowQueryFind{
+				DatabaseID: &database.ID,
+				Limit:      &limit,
+			})
+			if err != nil {
+				return nil, err
+			}
+			for _, slowQuery := range slowQueryList {
+				statement := slowQuery.SampleQuery
+				if statement == "" {
+					statement = slowQuery.NormalizedQuery
+				}
+				queryList = append(queryList, &indexAdvisor.Query{
+					Statement: statement,
+					Calls:     slowQuery.Calls,
+				})
+			}
+		case api.IndexAdviceSourceSQLEditor:
+			typePrefix := string(api.ActivitySQLEditorQuery)
+			limit := indexAdviceSQLEditorQueryLimit
+			order := api.DESC
+			activityList, err := s.store.FindActivity(ctx, &api.ActivityFind{
+				TypePrefix:  &typePrefix,
+				ContainerID: &database.InstanceID,
+				Limit:       &limit,
+				Order:       &order,
+			})
+			if err != nil {
+				return nil, err
+			}
+			// Count the executions of the same statement.
+			queryMap := make(map[string]*indexAdvisor.Query)
+			for _, activity := range activityList {
+				payload := &api.ActivitySQLEditorQueryPayload{}
+				if err := json.Unmarshal([]byte(activity.Payload), payload); err != nil {
+					return nil, errors.Wrapf(err, "failed to unmarshal SQL editor query activity payload %d", activity.ID)
+				}
+				if payload.DatabaseID != database.ID || payload.Error != "" {
+					continue
+				}
+				statement := strings.TrimSpace(payload.Statement)
+				if query, ok := queryMap[statement]; ok {
+					query.Calls++
+					continue
+				}
+				query := &indexAdvisor.Query{
+					Statement: statement,
+					Calls:     1,
+				}
+				queryMap[statement] = query
+				queryList = append(queryList, query)
+			}
+		}
+	}
+	return queryList, nil
+}
+
+// explainIndexAdviceQueryList runs EXPLAIN for the SELECT queries through the read-only data source
+// and sets the tables scanned sequentially. The query plan stays unknown if EXPLAIN fails,
+// e.g. the statement is normalized with parameter placeholders.
+func explainIndexAdviceQueryList(ctx context.Context, database *api.Database, queryList []*indexAdvisor.Query) {
+	if database.Instance.Engine != db.MySQL && database.Instance.Engine != db.Postgres {
+		return
+	}
+	driver, err := tryGetReadOnlyDatabaseDriver(ctx, database.Instance, database.Name)
+	if err != nil {
+		log.Warn("Failed to get read-only database driver for index advice", zap.String("database", database.Name), zap.Error(err))
+		return
+	}
+	defer driver.Close(ctx)
+	sqlDB, err := driver.GetDBConnection(ctx, database.Name)
+	if err != nil {
+		log.Warn("Failed to get database connection for index advice", zap.String("database", database.Name), zap.Error(err))
+		return
+	}
+
+	for _, query := range queryList {
+		if !strings.HasPrefix(strings.ToUpper(strings.TrimSpace(query.Statement)), "SELECT") {
+			continue
+		}
+		fullScanTableList, err := explainFullScanTableList(ctx, sqlDB, database.Instance.Engine, query.Statement)
+		if err != nil {
+			log.Debug("Failed to explain query for index advice", zap.String("statement", query.Statement), zap.Error(err))
+			continue
+		}
+		query.FullScanTableList = fullScanTableList
+	}
+}
+
+// explainFullScanTableList returns the tables scanned sequentially in the query plan.
+// MySQL refers to the tables by their aliases if specified.
+func explainFullScanTableList(ctx context.Context, sqlDB *sql.DB, engine db.Type, statement string) ([]string, error) {
+	tx, err := sqlDB.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf("EXPLAIN %s", strings.TrimSuffix(strings.TrimSpace(statement), ";")))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columnNameList, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	tableIndex, typeIndex := -1, -1
+	for i, columnName := range columnNameList {
+		switch strings.ToLower(columnName) {
+		case "table":
+			tableIndex = i
+		case "type":
+			typeIndex = i
+		}
+	}
+	if engine == db.MySQL && (tableIndex < 0 || typeIndex < 0) {
+		return nil, errors.Errorf("unexpected MySQL EXPLAIN columns %v", columnNameList)
+	}
+
+	fullScanTableList := []string{}
+	for rows.Next() {
+		valueList := make([]sql.NullString, len(columnNameList))
+		valuePtrList := make([]interface{}, len(columnNameList))
+		for i := range valueList {
+			valuePtrList[i] = &valueList[i]
+		}
+		if err := rows.Scan(valuePtrList...); err != nil {
+			return nil, err
+		}
+		switch engine {
+		case db.MySQL:
+			if valueList[typeIndex].String == "ALL" {
+				fullScanTableList = append(fullScanTableList, valueList[tableIndex].String)
+			}
+		case db.Postgres:
+			if matches := pgSeqScanReg.FindStringSubmatch(valueList[0].String); len(matches) > 1 {
+				fullScanTableList = append(fullScanTableList, matches[1])
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return fullScanTableList, nil
+}
@@ -398,6 +398,7 @@ func (s *Server) registerInstanceRoutes(g *echo.Group) {
 			ExecutionDurationNs:   entry.ExecutionDurationNs,
 			IssueID:               entry.IssueID,
 			Payload:               entry.Payload,
+			Checksum:              entry.Checksum,
 		}); err != nil {
 			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal migration history response for instance: %v", instance.Name)).SetInternal(err)
 		}
@@ -469,6 +470,7 @@ func (s *Server) registerInstanceRoutes(g *echo.Group) {
 				ExecutionDurationNs:   entry.ExecutionDurationNs,
 				IssueID:               entry.IssueID,
 				Payload:               entry.Payload,
+				Checksum:              entry.Checksum,
 			})
 		}
 
@@ -154,6 +154,13 @@ func (s *Server) registerIssueRoutes(g *echo.Group) {
 		}
 
 		s.setTaskProgressForIssue(issue)
+		var taskList []*api.Task
+		for _, stage := range issue.Pipeline.StageList {
+			taskList = append(taskList, stage.TaskList...)
+		}
+		if err := s.setTaskMaintenanceWindow(ctx, taskList); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to compute the maintenance window of issue ID: %v", id)).SetInternal(err)
+		}
 
 		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
 		if err := jsonapi.MarshalPayload(c.Response().Writer, issue); err != nil {
@@ -295,6 +302,8 @@ func (s *Server) registerIssueRoutes(g *echo.Group) {
 				return echo.NewHTTPError(http.StatusNotFound).SetInternal(err)
 			} else if common.ErrorCode(err) == common.Conflict {
 				return echo.NewHTTPError(http.StatusConflict).SetInternal(err)
+			} else if common.ErrorCode(err) == common.Invalid {
+				return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessage(err)).SetInternal(err)
 			}
 			return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
 		}
@@ -336,6 +345,9 @@ func (s *Server) createIssue(ctx context.Context, issueCreate *api.IssueCreate,
 		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Cannot set assignee with user id %d", issueCreate.AssigneeID))
 	}
 
+	if issueCreate.Draft {
+		pipelineCreate.Status = api.PipelineDraft
+	}
 	pipeline, err := s.createPipeline(ctx, issueCreate, pipelineCreate, creatorID)
 	if err != nil {
 		return nil, err
@@ -371,8 +383,11 @@ func (s *Server) createIssue(ctx context.Context, issueCreate *api.IssueCreate,
 		return nil, errors.Wrapf(err, "failed to schedule task check after creating the issue: %v", issue.Name)
 	}
 
-	if err := s.ScheduleActiveStage(ctx, issue.Pipeline); err != nil {
-		return nil, errors.Wrapf(err, "failed to schedule task after creating the issue: %v", issue.Name)
+	// The task checks of a draft issue run early, but its tasks are not scheduled until the issue is activated.
+	if issue.Status != api.IssueDraft {
+		if err := s.ScheduleActiveStage(ctx, issue.Pipeline); err != nil {
+			return nil, errors.Wrapf(err, "failed to schedule task after creating the issue: %v", issue.Name)
+		}
 	}
 
 	createActivityPayload := api.ActivityIssueCreatePayload{
@@ -638,8 +653,8 @@ func (s *Server) getPipelineCreateForDatabaseSchemaAndDataUpdate(ctx context.Con
 		}
 		databaseNameCount, databaseIDCount := 0, 0
 		for _, detail := range c.DetailList {
-			if detail.MigrationType != db.Migrate && detail.MigrationType != db.Data {
-				return nil, echo.NewHTTPError(http.StatusBadRequest, "Only Migrate and Data type migration can be performed on tenant mode project")
+			if detail.MigrationType != db.Migrate && detail.MigrationType != db.Data && detail.MigrationType != db.Repeatable {
+				return nil, echo.NewHTTPError(http.StatusBadRequest, "Only Migrate, Data and Repeatable type migration can be performed on tenant mode project")
 			}
 			if detail.Statement == "" {
 				return nil, echo.NewHTTPError(http.StatusBadRequest, "Failed to create issue, sql statement missing")
@@ -701,6 +716,19 @@ func (s *Server) getPipelineCreateForDatabaseSchemaAndDataUpdate(ctx context.Con
 			}
 
 			migrationDetail := c.DetailList[0]
+			if len(migrationDetail.EnvironmentIDList) > 0 {
+				environmentSet := make(map[int]bool)
+				for _, environmentID := range migrationDetail.EnvironmentIDList {
+					environmentSet[environmentID] = true
+				}
+				var filteredDBList []*api.Database
+				for _, database := range dbList {
+					if environmentSet[database.Instance.EnvironmentID] {
+						filteredDBList = append(filteredDBList, database)
+					}
+				}
+				dbList = filteredDBList
+			}
 			baseDBName := migrationDetail.DatabaseName
 			deployments, matrix, err := s.getTenantDatabaseMatrix(ctx, issueCreate.ProjectID, project.DBNameTemplate, dbList, baseDBName)
 			if err != nil {
@@ -730,10 +758,17 @@ func (s *Server) getPipelineCreateForDatabaseSchemaAndDataUpdate(ctx context.Con
 					return nil, echo.NewHTTPError(http.StatusInternalServerError, err.Error()).SetInternal(err)
 				}
 
+				stagePayload, err := json.Marshal(api.StagePayload{
+					Rollout: deployments[i].Spec.Rollout,
+				})
+				if err != nil {
+					return nil, echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal stage payload").SetInternal(err)
+				}
 				create.StageList = append(create.StageList, api.StageCreate{
 					Name:          deployments[i].Name,
 					EnvironmentID: environmentID,
 					TaskList:      taskCreateList,
+					Payload:       string(stagePayload),
 				})
 			}
 		}
@@ -750,7 +785,7 @@ func (s *Server) getPipelineCreateForDatabaseSchemaAndDataUpdate(ctx context.Con
 		}
 		envToDatabaseMap := make(map[envKey][]*versionTask)
 		for _, d := range c.DetailList {
-			if d.MigrationType == db.Migrate && d.Statement == "" {
+			if (d.MigrationType == db.Migrate || d.MigrationType == db.Repeatable) && d.Statement == "" {
 				return nil, echo.NewHTTPError(http.StatusBadRequest, "Failed to create issue, sql statement missing")
 			}
 			database, err := s.store.GetDatabase(ctx, &api.DatabaseFind{ID: &d.DatabaseID})
@@ -808,6 +843,9 @@ func getTaskAndDagListByVersion(versionTaskList []*versionTask) ([]api.TaskCreat
 	for _, databaseID := range databaseList {
 		list := databaseMap[databaseID]
 		sort.Slice(list, func(i, j int) bool {
+			if list[i].repeatable != list[j].repeatable {
+				return !list[i].repeatable
+			}
 			return list[i].version < list[j].version
 		})
 		for i := 0; i < len(list)-1; i++ {
@@ -859,6 +897,9 @@ func (s *Server) getPipelineCreateForDatabaseSchemaUpdateGhost(ctx context.Conte
 		if database == nil {
 			return nil, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("database ID not found: %d", detail.DatabaseID))
 		}
+		if err := validateOnlineMigration(database.Instance.Engine, detail.Statement); err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid online migration statement: %v", err))
+		}
 
 		taskCreateList, taskIndexDAGList, err := createGhostTaskList(database, c.VCSPushEvent, detail, schemaVersion)
 		if err != nil {
@@ -885,6 +926,8 @@ func getOrDefaultSchemaVersion(detail *api.MigrationDetail) string {
 type versionTask struct {
 	task    *api.TaskCreate
 	version string
+	// repeatable tasks are ordered after the versioned tasks of the same database.
+	repeatable bool
 }
 
 func getUpdateTask(database *api.Database, vcsPushEvent *vcs.PushEvent, d *api.MigrationDetail, schemaVersion string) (*versionTask, error) {
@@ -919,6 +962,23 @@ func getUpdateTask(database *api.Database, vcsPushEvent *vcs.PushEvent, d *api.M
 			return nil, echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal database schema update payload").SetInternal(err)
 		}
 		payloadString = string(bytes)
+	case db.Repeatable:
+		if d.RepeatableName == "" {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "Repeatable migration should have the repeatable name")
+		}
+		taskName = fmt.Sprintf("Repeatable DDL(schema) for %q", database.Name)
+		taskType = api.TaskDatabaseSchemaUpdate
+		payload := api.TaskDatabaseSchemaUpdatePayload{
+			Statement:      d.Statement,
+			SchemaVersion:  schemaVersion,
+			VCSPushEvent:   vcsPushEvent,
+			RepeatableName: d.RepeatableName,
+		}
+		bytes, err := json.Marshal(payload)
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal database schema update payload").SetInternal(err)
+		}
+		payloadString = string(bytes)
 	case db.MigrateSDL:
 		taskName = fmt.Sprintf("SDL for %q", database.Name)
 		taskType = api.TaskDatabaseSchemaUpdateSDL
@@ -935,10 +995,25 @@ func getUpdateTask(database *api.Database, vcsPushEvent *vcs.PushEvent, d *api.M
 	case db.Data:
 		taskName = fmt.Sprintf("DML(data) for %q", database.Name)
 		taskType = api.TaskDatabaseDataUpdate
+		if d.DataUpdateChunk != nil {
+			if err := d.DataUpdateChunk.Validate(); err != nil {
+				return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid data update chunk option: %v", err))
+			}
+			if _, err := parseDataUpdateChunkStatement(database.Instance.Engine, strings.TrimSpace(d.Statement)); err != nil {
+				return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Statement can't be executed in chunks: %v", err))
+			}
+		}
+		if d.RowSnapshot {
+			if err := validateRowSnapshot(database.Instance.Engine, d.Statement, d.DataUpdateChunk); err != nil {
+				return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid row snapshot option: %v", err))
+			}
+		}
 		payload := api.TaskDatabaseDataUpdatePayload{
 			Statement:     d.Statement,
 			SchemaVersion: schemaVersion,
 			VCSPushEvent:  vcsPushEvent,
+			Chunk:         d.DataUpdateChunk,
+			RowSnapshot:   d.RowSnapshot,
 		}
 		bytes, err := json.Marshal(payload)
 		if err != nil {
@@ -960,7 +1035,8 @@ func getUpdateTask(database *api.Database, vcsPushEvent *vcs.PushEvent, d *api.M
 			EarliestAllowedTs: d.EarliestAllowedTs,
 			Payload:           payloadString,
 		},
-		version: schemaVersion,
+		version:    schemaVersion,
+		repeatable: d.MigrationType == db.Repeatable,
 	}, nil
 }
 
@@ -1118,6 +1194,11 @@ func (s *Server) createPITRTaskList(ctx context.Context, originDatabase *api.Dat
 // creates gh-ost TaskCreate list and dependency.
 func createGhostTaskList(database *api.Database, vcsPushEvent *vcs.PushEvent, detail *api.UpdateSchemaGhostDetail, schemaVersion string) ([]api.TaskCreate, []api.TaskIndexDAG, error) {
 	var taskCreateList []api.TaskCreate
+	// Postgres runs the trigger-based online migration instead of gh-ost.
+	method := "gh-ost"
+	if database.Instance.Engine == db.Postgres {
+		method = "online"
+	}
 	// task "sync"
 	payloadSync := api.TaskDatabaseSchemaUpdateGhostSyncPayload{
 		Statement:     detail.Statement,
@@ -1129,7 +1210,7 @@ func createGhostTaskList(database *api.Database, vcsPushEvent *vcs.PushEvent, de
 		return nil, nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to marshal database schema update gh-ost sync payload, error: %v", err))
 	}
 	taskCreateList = append(taskCreateList, api.TaskCreate{
-		Name:              fmt.Sprintf("Update %q schema gh-ost sync", database.Name),
+		Name:              fmt.Sprintf("Update %q schema %s sync", database.Name, method),
 		InstanceID:        database.InstanceID,
 		DatabaseID:        &database.ID,
 		Status:            api.TaskPendingApproval,
@@ -1146,7 +1227,7 @@ func createGhostTaskList(database *api.Database, vcsPushEvent *vcs.PushEvent, de
 		return nil, nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to marshal database schema update ghost cutover payload, error: %v", err))
 	}
 	taskCreateList = append(taskCreateList, api.TaskCreate{
-		Name:              fmt.Sprintf("Update %q schema gh-ost cutover", database.Name),
+		Name:              fmt.Sprintf("Update %q schema %s cutover", database.Name, method),
 		InstanceID:        database.InstanceID,
 		DatabaseID:        &database.ID,
 		Status:            api.TaskPendingApproval,
@@ -1268,6 +1349,8 @@ func getDatabaseNameAndStatement(dbType db.Type, createDatabaseContext api.Creat
 func (s *Server) changeIssueStatus(ctx context.Context, issue *api.Issue, newStatus api.IssueStatus, updaterID int, comment string) (*api.Issue, error) {
 	var pipelineStatus api.PipelineStatus
 	switch newStatus {
+	case api.IssueDraft:
+		return nil, &common.Error{Code: common.Invalid, Err: errors.Errorf("failed to change issue %v status, an issue cannot be changed back to draft", issue.Name)}
 	case api.IssueOpen:
 		pipelineStatus = api.PipelineOpen
 	case api.IssueDone:
@@ -1319,6 +1402,13 @@ func (s *Server) changeIssueStatus(ctx context.Context, issue *api.Issue, newSta
 		return nil, errors.Wrapf(err, "failed to update issue %q's status with patch %v", issue.Name, issuePatch)
 	}
 
+	// Schedule the tasks of an activated draft issue right away instead of waiting for the next scheduler round.
+	if issue.Status == api.IssueDraft && newStatus == api.IssueOpen {
+		if err := s.ScheduleActiveStage(ctx, updatedIssue.Pipeline); err != nil {
+			return nil, errors.Wrapf(err, "failed to schedule task after activating the issue: %v", issue.Name)
+		}
+	}
+
 	payload, err := json.Marshal(api.ActivityIssueStatusUpdatePayload{
 		OldStatus: issue.Status,
 		NewStatus: newStatus,
@@ -1504,19 +1594,50 @@ func getPeerTenantDatabase(databaseMatrix [][]*api.Database, environmentID int)
 }
 
 func (s *Server) setTaskProgressForIssue(issue *api.Issue) {
-	if s.TaskScheduler == nil {
-		// readonly server doesn't have a TaskScheduler.
-		return
-	}
 	for _, stage := range issue.Pipeline.StageList {
 		for _, task := range stage.TaskList {
-			if progress, ok := s.TaskScheduler.taskProgress.Load(task.ID); ok {
-				task.Progress = progress.(api.Progress)
-			}
+			s.setTaskProgress(task)
 		}
 	}
 }
 
+// setTaskProgress loads the progress and the queue status of the task from the task scheduler in memory.
+// If the task isn't scheduled by this server, e.g. a readonly server or a replica which isn't the leader, they are
+// loaded from the running task run persisted by the task scheduler instead.
+func (s *Server) setTaskProgress(task *api.Task) {
+	// readonly server doesn't have a TaskScheduler, and only the leader runs the TaskScheduler.
+	if s.TaskScheduler != nil && s.LeaderElector.IsLeader() {
+		if queue, ok := s.TaskScheduler.taskQueue.Load(task.ID); ok {
+			task.Queue = queue.(api.TaskQueue)
+			return
+		}
+		if progress, ok := s.TaskScheduler.taskProgress.Load(task.ID); ok {
+			task.Progress = progress.(api.Progress)
+			return
+		}
+	}
+	setTaskProgressFromTaskRun(task)
+}
+
+// setTaskProgressFromTaskRun loads the progress and the queue status of the task from the running task run persisted by
+// the task scheduler.
+func setTaskProgressFromTaskRun(task *api.Task) {
+	for _, taskRun := range task.TaskRunList {
+		if taskRun.Status != api.TaskRunRunning {
+			continue
+		}
+		var progress api.Progress
+		if err := json.Unmarshal([]byte(taskRun.Progress), &progress); err == nil {
+			task.Progress = progress
+		}
+		var queue api.TaskQueue
+		if err := json.Unmarshal([]byte(taskRun.Queue), &queue); err == nil {
+			task.Queue = queue
+		}
+		return
+	}
+}
+
 func marshalPageToken(id int) (string, error) {
 	b, err := json.Marshal(id)
 	if err != nil {
@@ -253,6 +253,26 @@ func TestGetTaskAndDagListByVersion(t *testing.T) {
 				{FromIndex: 3, ToIndex: 4},
 			},
 		},
+		{
+			versionTaskList: []*versionTask{
+				{
+					task:       &api.TaskCreate{DatabaseID: &databaseID1, Name: "repeatable"},
+					version:    "20221020000000-6f3c8e1a",
+					repeatable: true,
+				},
+				{
+					task:    &api.TaskCreate{DatabaseID: &databaseID1, Name: "task1"},
+					version: "v1",
+				},
+			},
+			wantTaskList: []api.TaskCreate{
+				{DatabaseID: &databaseID1, Name: "task1"},
+				{DatabaseID: &databaseID1, Name: "repeatable"},
+			},
+			wantDAGList: []api.TaskIndexDAG{
+				{FromIndex: 0, ToIndex: 1},
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/bytebase/bytebase/common/log"
+	"github.com/bytebase/bytebase/store"
+)
+
+const (
+	// leaderElectionInterval is the interval for a follower to campaign for the leader and for the leader to check
+	// it still holds the leader lock.
+	// If the leader crashes, Postgres releases the leader lock once the session ends, which takes at most the TCP
+	// keepalive timeout of the lock session if the leader is unreachable. A follower takes over within another interval.
+	leaderElectionInterval = time.Duration(5) * time.Second
+	// leaderLockCheckTimeout is the timeout for the leader to check the session holding the leader lock.
+	leaderLockCheckTimeout = time.Duration(3) * time.Second
+)
+
+// NewLeaderElector creates a new leader elector.
+// onElected starts the runners with the leader context, each of which calls wg.Done() when exiting.
+// onStepDown is called after all the runners exit.
+func NewLeaderElector(store *store.Store, onElected func(ctx context.Context, wg *sync.WaitGroup) error, onStepDown func()) *LeaderElector {
+	return &LeaderElector{
+		store:      store,
+		onElected:  onElected,
+		onStepDown: onStepDown,
+	}
+}
+
+// LeaderElector elects the leader among the replicas sharing the same metadata db with a Postgres advisory lock.
+// Only the leader runs the background runners, e.g. the task scheduler, so that the replicas don't run the same work.
+type LeaderElector struct {
+	store      *store.Store
+	onElected  func(ctx context.Context, wg *sync.WaitGroup) error
+	onStepDown func()
+	isLeader   atomic.Bool
+}
+
+// IsLeader returns whether this replica is the leader.
+func (e *LeaderElector) IsLeader() bool {
+	return e.isLeader.Load()
+}
+
+// Run will run the leader elector.
+func (e *LeaderElector) Run(ctx context.Context, wg *sync.WaitGroup) {
+	ticker := time.NewTicker(leaderElectionInterval)
+	defer ticker.Stop()
+	defer wg.Done()
+	log.Debug(fmt.Sprintf("Leader elector started and will campaign every %v", leaderElectionInterval))
+	for {
+		lock, err := e.store.TryAcquireAdvisoryLock(ctx, store.AdvisoryLockKeyLeader)
+		if err != nil {
+			log.Error("Failed to campaign for the leader", zap.Error(err))
+		} else if lock != nil {
+			e.lead(ctx, lock)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done(): // if cancel() execute
+			return
+		}
+	}
+}
+
+// lead runs the runners until the context is canceled or the leader lock is lost.
+func (e *LeaderElector) lead(ctx context.Context, lock *store.AdvisoryLock) {
+	log.Info("Elected as the leader, start the runners")
+	e.isLeader.Store(true)
+	leaderCtx, cancel := context.WithCancel(ctx)
+	var runnerWG sync.WaitGroup
+	defer func() {
+		cancel()
+		runnerWG.Wait()
+		e.onStepDown()
+		e.isLeader.Store(false)
+		// Use a fresh context because the server context may be canceled already.
+		releaseCtx, releaseCancel := context.WithTimeout(context.Background(), leaderLockCheckTimeout)
+		defer releaseCancel()
+		if err := lock.Release(releaseCtx); err != nil {
+			log.Warn("Failed to release the leader lock", zap.Error(err))
+		}
+	}()
+
+	if err := e.onElected(leaderCtx, &runnerWG); err != nil {
+		log.Error("Failed to start the runners, step down from the leader", zap.Error(err))
+		return
+	}
+
+	ticker := time.NewTicker(leaderElectionInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			checkCtx, checkCancel := context.WithTimeout(ctx, leaderLockCheckTimeout)
+			err := lock.Check(checkCtx)
+			checkCancel()
+			if err != nil {
+				log.Error("Lost the leader lock, step down from the leader", zap.Error(err))
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -2,18 +2,42 @@ package server
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
+	"go.uber.org/zap"
 
 	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common/log"
 )
 
 // ScheduleActiveStage tries to schedule the tasks in the active stage.
+// For the stage with the rollout controls, only the tasks in the rolling out batches are scheduled.
 func (s *Server) ScheduleActiveStage(ctx context.Context, pipeline *api.Pipeline) error {
 	stage := getActiveStage(pipeline.StageList)
 	if stage == nil {
 		return nil
 	}
+
+	// rolloutTaskSet is nil if the stage has no rollout controls.
+	var rolloutTaskSet map[int]bool
+	payload := &api.StagePayload{}
+	if err := json.Unmarshal([]byte(stage.Payload), payload); err != nil {
+		return errors.Wrapf(err, "failed to unmarshal stage payload %q", stage.Payload)
+	}
+	if payload.Rollout != nil {
+		halted, err := s.haltRolloutIfNeeded(ctx, pipeline, stage, payload)
+		if err != nil {
+			return errors.Wrap(err, "failed to halt the rollout")
+		}
+		if halted {
+			return nil
+		}
+		rolloutTaskSet = getRolloutTaskSet(stage.TaskList, payload.Rollout, time.Now())
+	}
+
 	for _, task := range stage.TaskList {
 		switch task.Status {
 		case api.TaskPendingApproval:
@@ -38,6 +62,9 @@ func (s *Server) ScheduleActiveStage(ctx context.Context, pipeline *api.Pipeline
 				}
 			}
 		case api.TaskPending:
+			if rolloutTaskSet != nil && !rolloutTaskSet[task.ID] {
+				continue
+			}
 			_, err := s.TaskScheduler.ScheduleIfNeeded(ctx, task)
 			if err != nil {
 				return errors.Wrap(err, "failed to schedule task")
@@ -47,6 +74,134 @@ func (s *Server) ScheduleActiveStage(ctx context.Context, pipeline *api.Pipeline
 	return nil
 }
 
+// haltRolloutIfNeeded halts the rest of the rollout if the failed tasks in the stage exceed the max failure count.
+// The tasks not started yet in the stage and the following stages are canceled, and the user may resume the rollout by
+// rerunning them, after which the rollout only halts again on new failures.
+func (s *Server) haltRolloutIfNeeded(ctx context.Context, pipeline *api.Pipeline, stage *api.Stage, payload *api.StagePayload) (bool, error) {
+	failedTaskRunIDList := getFailedTaskRunIDList(stage.TaskList)
+	if !shouldHaltRollout(failedTaskRunIDList, payload) {
+		return false, nil
+	}
+	failureCount := len(failedTaskRunIDList)
+
+	var taskIDList []int
+	following := false
+	for _, st := range pipeline.StageList {
+		if st.ID == stage.ID {
+			following = true
+		}
+		if !following {
+			continue
+		}
+		for _, task := range st.TaskList {
+			if task.Status == api.TaskPendingApproval || task.Status == api.TaskPending {
+				taskIDList = append(taskIDList, task.ID)
+			}
+		}
+	}
+
+	payload.RolloutHaltTaskRunIDList = failedTaskRunIDList
+	bytes, err := json.Marshal(payload)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to marshal stage payload")
+	}
+	payloadStr := string(bytes)
+	if _, err := s.store.PatchStage(ctx, &api.StagePatch{
+		ID:        stage.ID,
+		UpdaterID: api.SystemBotID,
+		Payload:   &payloadStr,
+	}); err != nil {
+		return false, errors.Wrapf(err, "failed to patch stage %d", stage.ID)
+	}
+
+	if len(taskIDList) > 0 {
+		comment := fmt.Sprintf("Canceled because the rollout halted after %d databases failed.", failureCount)
+		if _, err := s.store.PatchTaskStatus(ctx, &api.TaskStatusPatch{
+			IDList:    taskIDList,
+			UpdaterID: api.SystemBotID,
+			Status:    api.TaskCanceled,
+			Comment:   &comment,
+		}); err != nil {
+			return false, errors.Wrapf(err, "failed to cancel the rest of the rollout in stage %d", stage.ID)
+		}
+	}
+	log.Warn("Rollout halted because the failed tasks exceed the max failure count",
+		zap.Int("pipeline_id", pipeline.ID),
+		zap.Int("stage_id", stage.ID),
+		zap.Int("failure_count", failureCount),
+		zap.Int("max_failure_count", payload.Rollout.MaxFailureCount),
+		zap.Int("canceled_task_count", len(taskIDList)),
+	)
+
+	issue, err := s.store.GetIssueByPipelineID(ctx, pipeline.ID)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to fetch issue with pipeline ID %d", pipeline.ID)
+	}
+	if issue == nil {
+		return true, nil
+	}
+	activityPayload, err := json.Marshal(api.ActivityPipelineRolloutHaltPayload{
+		StageID:           stage.ID,
+		StageName:         stage.Name,
+		FailureCount:      failureCount,
+		MaxFailureCount:   payload.Rollout.MaxFailureCount,
+		CanceledTaskCount: len(taskIDList),
+		IssueName:         issue.Name,
+	})
+	if err != nil {
+		return false, errors.Wrap(err, "failed to marshal rollout halt activity payload")
+	}
+	if _, err := s.ActivityManager.CreateActivity(ctx, &api.ActivityCreate{
+		CreatorID:   api.SystemBotID,
+		ContainerID: pipeline.ID,
+		Type:        api.ActivityPipelineRolloutHalt,
+		Level:       api.ActivityError,
+		Payload:     string(activityPayload),
+	}, &ActivityMeta{
+		issue: issue,
+	}); err != nil {
+		return false, errors.Wrapf(err, "failed to create activity after halting the rollout of stage %d", stage.ID)
+	}
+	return true, nil
+}
+
+// getFailedTaskRunIDList returns the IDs of the task runs in which the failed tasks fail most recently.
+func getFailedTaskRunIDList(taskList []*api.Task) []int {
+	var idList []int
+	for _, task := range taskList {
+		if task.Status != api.TaskFailed {
+			continue
+		}
+		id := 0
+		for _, taskRun := range task.TaskRunList {
+			if taskRun.Status == api.TaskRunFailed && taskRun.ID > id {
+				id = taskRun.ID
+			}
+		}
+		idList = append(idList, id)
+	}
+	return idList
+}
+
+// shouldHaltRollout returns whether the rollout should halt given the failed task runs in the stage.
+// The rollout halts if the failures exceed the max failure count, and some of them aren't counted when the rollout
+// halted last time, e.g. a task fails again after the user resumes the rollout.
+func shouldHaltRollout(failedTaskRunIDList []int, payload *api.StagePayload) bool {
+	if len(failedTaskRunIDList) <= payload.Rollout.MaxFailureCount {
+		return false
+	}
+	counted := make(map[int]bool)
+	for _, id := range payload.RolloutHaltTaskRunIDList {
+		counted[id] = true
+	}
+	for _, id := range failedTaskRunIDList {
+		if !counted[id] {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *Server) schedulePipelineTaskCheck(ctx context.Context, pipeline *api.Pipeline) error {
 	var createList []*api.TaskCheckRunCreate
 	for _, stage := range pipeline.StageList {
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bytebase/bytebase/api"
+)
+
+func TestGetFailedTaskRunIDList(t *testing.T) {
+	taskList := []*api.Task{
+		{
+			ID:     1,
+			Status: api.TaskFailed,
+			TaskRunList: []*api.TaskRun{
+				{ID: 11, Status: api.TaskRunFailed},
+				{ID: 14, Status: api.TaskRunFailed},
+			},
+		},
+		{
+			ID:     2,
+			Status: api.TaskDone,
+			TaskRunList: []*api.TaskRun{
+				{ID: 12, Status: api.TaskRunFailed},
+				{ID: 15, Status: api.TaskRunDone},
+			},
+		},
+		{
+			ID:     3,
+			Status: api.TaskFailed,
+			TaskRunList: []*api.TaskRun{
+				{ID: 13, Status: api.TaskRunFailed},
+			},
+		},
+	}
+	assert.Equal(t, []int{14, 13}, getFailedTaskRunIDList(taskList))
+}
+
+func TestShouldHaltRollout(t *testing.T) {
+	tests := []struct {
+		name                string
+		failedTaskRunIDList []int
+		haltTaskRunIDList   []int
+		want                bool
+	}{
+		{
+			name:                "within the max failure count",
+			failedTaskRunIDList: []int{11},
+			want:                false,
+		},
+		{
+			name:                "exceed the max failure count",
+			failedTaskRunIDList: []int{11, 12},
+			want:                true,
+		},
+		{
+			name:                "halted on the same failures",
+			failedTaskRunIDList: []int{11, 12},
+			haltTaskRunIDList:   []int{11, 12},
+			want:                false,
+		},
+		{
+			name:                "rerun fixes one of the failures",
+			failedTaskRunIDList: []int{12},
+			haltTaskRunIDList:   []int{11, 12},
+			want:                false,
+		},
+		{
+			// The failure count doesn't increase, but task 1 fails again after the rollout resumes.
+			name:                "rerun fails again",
+			failedTaskRunIDList: []int{13, 12},
+			haltTaskRunIDList:   []int{11, 12},
+			want:                true,
+		},
+	}
+
+	for _, test := range tests {
+		payload := &api.StagePayload{
+			Rollout:                  &api.DeploymentRollout{MaxFailureCount: 1},
+			RolloutHaltTaskRunIDList: test.haltTaskRunIDList,
+		}
+		assert.Equal(t, test.want, shouldHaltRollout(test.failedTaskRunIDList, payload), test.name)
+	}
+}
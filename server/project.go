@@ -21,6 +21,10 @@ import (
 	"github.com/bytebase/bytebase/common"
 	"github.com/bytebase/bytebase/common/log"
 	vcsPlugin "github.com/bytebase/bytebase/plugin/vcs"
+	"github.com/bytebase/bytebase/plugin/vcs/azure"
+	"github.com/bytebase/bytebase/plugin/vcs/bitbucket"
+	"github.com/bytebase/bytebase/plugin/vcs/bitbucketserver"
+	"github.com/bytebase/bytebase/plugin/vcs/gitea"
 	"github.com/bytebase/bytebase/plugin/vcs/github"
 	"github.com/bytebase/bytebase/plugin/vcs/gitlab"
 )
@@ -192,7 +196,7 @@ func (s *Server) registerProjectRoutes(g *echo.Group) {
 				return echo.NewHTTPError(http.StatusBadRequest, "Please transfer all databases under the project before archiving the project.")
 			}
 
-			issueList, err := s.store.FindIssueStripped(ctx, &api.IssueFind{ProjectID: &id, StatusList: []api.IssueStatus{api.IssueOpen}})
+			issueList, err := s.store.FindIssueStripped(ctx, &api.IssueFind{ProjectID: &id, StatusList: []api.IssueStatus{api.IssueOpen, api.IssueDraft}})
 			if err != nil {
 				return echo.NewHTTPError(http.StatusInternalServerError, errors.Errorf("failed to find issues in the project %d", id)).SetInternal(err)
 			}
@@ -239,6 +243,9 @@ func (s *Server) registerProjectRoutes(g *echo.Group) {
 		if strings.Contains(repositoryCreate.BranchFilter, "*") && repositoryCreate.SchemaPathTemplate != "" {
 			return echo.NewHTTPError(http.StatusBadRequest, "Schema path template is supported only if branch doesn't have wildcard.")
 		}
+		if err := s.validateBranchMapping(ctx, &repositoryCreate.BranchMapping); err != nil {
+			return err
+		}
 
 		// We need to check the FilePathTemplate in create repository request.
 		// This avoids to a certain extent that the creation succeeds but does not work.
@@ -258,6 +265,10 @@ func (s *Server) registerProjectRoutes(g *echo.Group) {
 			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Malformed create linked repository request: %s", err.Error()))
 		}
 
+		if err := api.ValidateRepositoryRepeatableFilePathTemplate(repositoryCreate.RepeatableFilePathTemplate, project.TenantMode, project.DBNameTemplate); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Malformed create linked repository request: %s", err.Error()))
+		}
+
 		if err := api.ValidateRepositorySchemaPathTemplate(repositoryCreate.SchemaPathTemplate, project.TenantMode); err != nil {
 			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Malformed create linked repository request: %s", err.Error()))
 		}
@@ -285,6 +296,9 @@ func (s *Server) registerProjectRoutes(g *echo.Group) {
 			repositoryCreate.WebhookEndpointID = repo.WebhookEndpointID
 			repositoryCreate.WebhookSecretToken = repo.WebhookSecretToken
 			repositoryCreate.ExternalWebhookID = repo.ExternalWebhookID
+			if err := s.updatePullRequestWebhook(ctx, repo); err != nil {
+				log.Warn("Failed to update the webhook to subscribe to the pull request events", zap.String("repository", repo.WebURL), zap.Error(err))
+			}
 		} else {
 			repositoryCreate.WebhookEndpointID = fmt.Sprintf("%s-%d", s.workspaceID, time.Now().Unix())
 			secretToken, err := common.RandomString(gitlab.SecretTokenLength)
@@ -353,6 +367,9 @@ func (s *Server) registerProjectRoutes(g *echo.Group) {
 		if repository.EnableSQLReviewCI {
 			return echo.NewHTTPError(http.StatusBadRequest, "SQL review CI is already enabled")
 		}
+		if repository.VCS.Type != vcsPlugin.GitHubCom && repository.VCS.Type != vcsPlugin.GitLabSelfHost && repository.VCS.Type != vcsPlugin.Gitea {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("SQL review CI is not supported for VCS type %q", repository.VCS.Type))
+		}
 
 		pullRequest, err := s.setupVCSSQLReviewCI(ctx, repository)
 		if err != nil {
@@ -438,6 +455,12 @@ func (s *Server) registerProjectRoutes(g *echo.Group) {
 			}
 		}
 
+		if repoPatch.RepeatableFilePathTemplate != nil {
+			if err := api.ValidateRepositoryRepeatableFilePathTemplate(*repoPatch.RepeatableFilePathTemplate, project.TenantMode, project.DBNameTemplate); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Malformed patch linked repository request: %s", err.Error()))
+			}
+		}
+
 		if repoPatch.SchemaPathTemplate != nil {
 			if err := api.ValidateRepositorySchemaPathTemplate(*repoPatch.SchemaPathTemplate, project.TenantMode); err != nil {
 				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Malformed create linked repository request: %s", err.Error()))
@@ -480,6 +503,11 @@ func (s *Server) registerProjectRoutes(g *echo.Group) {
 		if strings.Contains(newBranchFilter, "*") && newSchemaPathTemplate != "" {
 			return echo.NewHTTPError(http.StatusBadRequest, "Schema path template is supported only if branch doesn't have wildcard.")
 		}
+		if repoPatch.BranchMapping != nil {
+			if err := s.validateBranchMapping(ctx, repoPatch.BranchMapping); err != nil {
+				return err
+			}
+		}
 
 		// We need to check the FilePathTemplate in create repository request.
 		// This avoids to a certain extent that the creation succeeds but does not work.
@@ -505,6 +533,11 @@ func (s *Server) registerProjectRoutes(g *echo.Group) {
 		if err != nil {
 			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to update repository for project ID: %d", projectID)).SetInternal(err)
 		}
+		// The existing webhook is brought up to date with the repository settings, so that the repositories linked
+		// before draft issues are created from pull requests don't need to be relinked.
+		if err := s.updatePullRequestWebhook(ctx, repo); err != nil {
+			log.Warn("Failed to update the webhook to subscribe to the pull request events", zap.String("repository", repo.WebURL), zap.Error(err))
+		}
 
 		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
 		if err := jsonapi.MarshalPayload(c.Response().Writer, updatedRepo); err != nil {
@@ -654,6 +687,54 @@ func (s *Server) registerProjectRoutes(g *echo.Group) {
 		return nil
 	})
 
+	// Previews the databases matched by each deployment of the deployment configuration before saving it.
+	// The databases are matched against the database name template of the project if the base database name is specified.
+	g.POST("/project/:id/deployment/preview", func(c echo.Context) error {
+		ctx := c.Request().Context()
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("ID is not a number: %s", c.Param("id"))).SetInternal(err)
+		}
+
+		deploymentConfigUpsert := &api.DeploymentConfigUpsert{}
+		if err := jsonapi.UnmarshalPayload(c.Request().Body, deploymentConfigUpsert); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformed preview deployment configuration request").SetInternal(err)
+		}
+		schedule, err := api.ValidateAndGetDeploymentSchedule(deploymentConfigUpsert.Payload)
+		if err != nil {
+			if common.ErrorCode(err) == common.Invalid {
+				return echo.NewHTTPError(http.StatusBadRequest, common.ErrorMessage(err))
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformed deployment schedule").SetInternal(err)
+		}
+
+		project, err := s.store.GetProjectByID(ctx, id)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch project ID: %v", id)).SetInternal(err)
+		}
+		if project == nil {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Project not found with ID %d", id))
+		}
+
+		databaseList, err := s.store.FindDatabase(ctx, &api.DatabaseFind{
+			ProjectID: &id,
+		})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch databases in project ID: %v", id)).SetInternal(err)
+		}
+
+		dbNameTemplate := ""
+		baseDatabaseName := c.QueryParam("databaseName")
+		if baseDatabaseName != "" {
+			dbNameTemplate = project.DBNameTemplate
+		}
+		previewList, err := getDeploymentPreviewList(schedule, baseDatabaseName, dbNameTemplate, databaseList)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to preview deployment configuration for project ID: %v", id)).SetInternal(err)
+		}
+		return c.JSON(http.StatusOK, previewList)
+	})
+
 	g.POST("/project/:projectID/sync-sheet", func(c echo.Context) error {
 		ctx := c.Request().Context()
 		currentPrincipalID := c.Get(getPrincipalIDContextKey()).(int)
@@ -809,6 +890,14 @@ func (s *Server) registerProjectRoutes(g *echo.Group) {
 				sheetSource = api.SheetFromGitLabSelfHost
 			case vcsPlugin.GitHubCom:
 				sheetSource = api.SheetFromGitHubCom
+			case vcsPlugin.BitbucketCloud:
+				sheetSource = api.SheetFromBitbucketCloud
+			case vcsPlugin.BitbucketServer:
+				sheetSource = api.SheetFromBitbucketServer
+			case vcsPlugin.Gitea:
+				sheetSource = api.SheetFromGitea
+			case vcsPlugin.AzureDevOps:
+				sheetSource = api.SheetFromAzureDevOps
 			}
 			vscSheetType := api.SheetForSQL
 			sheetFind := &api.SheetFind{
@@ -863,6 +952,25 @@ func (s *Server) registerProjectRoutes(g *echo.Group) {
 	})
 }
 
+// validateBranchMapping validates the branch mapping setting of a repository.
+func (s *Server) validateBranchMapping(ctx context.Context, setting *api.BranchMappingSetting) *echo.HTTPError {
+	if err := setting.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid branch mapping: %v", err))
+	}
+	for _, mapping := range setting.MappingList {
+		for _, environmentID := range mapping.EnvironmentIDList {
+			environment, err := s.store.GetEnvironmentByID(ctx, environmentID)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch environment ID: %d", environmentID)).SetInternal(err)
+			}
+			if environment == nil || environment.RowStatus == api.Archived {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Environment ID not found: %d", environmentID))
+			}
+		}
+	}
+	return nil
+}
+
 func (s *Server) setupVCSSQLReviewCI(ctx context.Context, repository *api.Repository) (*vcsPlugin.PullRequest, error) {
 	branch, err := s.setupVCSSQLReviewBranch(ctx, repository)
 	if err != nil {
@@ -897,6 +1005,10 @@ func (s *Server) setupVCSSQLReviewCI(ctx context.Context, repository *api.Reposi
 		if err := s.setupVCSSQLReviewCIForGitLab(ctx, repository, branch, sqlReviewEndpoint); err != nil {
 			return nil, err
 		}
+	case vcsPlugin.Gitea:
+		if err := s.setupVCSSQLReviewCIForGitea(ctx, repository, branch, sqlReviewEndpoint); err != nil {
+			return nil, err
+		}
 	}
 
 	return vcsPlugin.Get(repository.VCS.Type, vcsPlugin.ProviderConfig{}).CreatePullRequest(
@@ -1015,6 +1127,58 @@ func (s *Server) setupVCSSQLReviewCIForGitHub(ctx context.Context, repository *a
 	)
 }
 
+// setupVCSSQLReviewCIForGitea will create the pull request in Gitea to setup SQL review workflow.
+func (s *Server) setupVCSSQLReviewCIForGitea(ctx context.Context, repository *api.Repository, branch *vcsPlugin.BranchInfo, sqlReviewEndpoint string) error {
+	sqlReviewConfig := gitea.SetupSQLReviewCI(sqlReviewEndpoint)
+	fileLastCommitID := ""
+
+	fileMeta, err := vcsPlugin.Get(repository.VCS.Type, vcsPlugin.ProviderConfig{}).ReadFileMeta(
+		ctx,
+		common.OauthContext{
+			ClientID:     repository.VCS.ApplicationID,
+			ClientSecret: repository.VCS.Secret,
+			AccessToken:  repository.AccessToken,
+			RefreshToken: repository.RefreshToken,
+			Refresher:    s.refreshToken(ctx, repository.WebURL),
+		},
+		repository.VCS.InstanceURL,
+		repository.ExternalID,
+		gitea.SQLReviewActionFilePath,
+		branch.Name,
+	)
+	if err != nil {
+		log.Debug(
+			"Failed to get file meta",
+			zap.String("file", gitea.SQLReviewActionFilePath),
+			zap.String("last_commit", branch.LastCommitID),
+			zap.Int("code", common.ErrorCode(err).Int()),
+			zap.Error(err),
+		)
+	} else if fileMeta != nil {
+		fileLastCommitID = fileMeta.LastCommitID
+	}
+
+	return vcsPlugin.Get(repository.VCS.Type, vcsPlugin.ProviderConfig{}).CreateFile(
+		ctx,
+		common.OauthContext{
+			ClientID:     repository.VCS.ApplicationID,
+			ClientSecret: repository.VCS.Secret,
+			AccessToken:  repository.AccessToken,
+			RefreshToken: repository.RefreshToken,
+			Refresher:    s.refreshToken(ctx, repository.WebURL),
+		},
+		repository.VCS.InstanceURL,
+		repository.ExternalID,
+		gitea.SQLReviewActionFilePath,
+		vcsPlugin.FileCommitCreate{
+			Branch:        branch.Name,
+			CommitMessage: sqlReviewInVCSPRTitle,
+			Content:       sqlReviewConfig,
+			LastCommitID:  fileLastCommitID,
+		},
+	)
+}
+
 // setupVCSSQLReviewCIForGitLab will create or update SQL review related files in GitLab to setup SQL review CI.
 func (s *Server) setupVCSSQLReviewCIForGitLab(ctx context.Context, repository *api.Repository, branch *vcsPlugin.BranchInfo, sqlReviewEndpoint string) error {
 	// create or update the .gitlab-ci.yml
@@ -1144,31 +1308,132 @@ func (s *Server) createOrUpdateVCSSQLReviewFileForGitLab(
 	)
 }
 
-func (s *Server) createVCSWebhook(ctx context.Context, vcsType vcsPlugin.Type, webhookEndpointID, secretToken, accessToken, instanceURL, externalRepoID string) (string, error) {
-	// Create a new webhook and retrieve the created webhook ID
-	var webhookCreatePayload []byte
-	var err error
+// getPullRequestWebhookPayload returns the payload to create or update the webhook subscribing to the push and pull
+// request events. It returns nil if draft issues are not created from the pull requests of the VCS.
+func getPullRequestWebhookPayload(vcsType vcsPlugin.Type, urlHost, webhookEndpointID, secretToken string) ([]byte, error) {
 	switch vcsType {
 	case vcsPlugin.GitLabSelfHost:
 		webhookCreate := gitlab.WebhookCreate{
-			URL:                   fmt.Sprintf("%s/hook/gitlab/%s", s.profile.ExternalURL, webhookEndpointID),
+			URL:                   fmt.Sprintf("%s/hook/gitlab/%s", urlHost, webhookEndpointID),
 			SecretToken:           secretToken,
 			PushEvents:            true,
+			MergeRequestsEvents:   true,
 			EnableSSLVerification: false, // TODO(tianzhou): This is set to false, be lax to not enable_ssl_verification
 		}
-		webhookCreatePayload, err = json.Marshal(webhookCreate)
+		payload, err := json.Marshal(webhookCreate)
 		if err != nil {
-			return "", errors.Wrap(err, "failed to marshal request body for creating webhook")
+			return nil, errors.Wrap(err, "failed to marshal request body for webhook")
 		}
+		return payload, nil
 	case vcsPlugin.GitHubCom:
 		webhookPost := github.WebhookCreateOrUpdate{
 			Config: github.WebhookConfig{
-				URL:         fmt.Sprintf("%s/hook/github/%s", s.profile.ExternalURL, webhookEndpointID),
+				URL:         fmt.Sprintf("%s/hook/github/%s", urlHost, webhookEndpointID),
 				ContentType: "json",
 				Secret:      secretToken,
 				InsecureSSL: 1, // TODO: Allow user to specify this value through api.RepositoryCreate
 			},
-			Events: []string{"push"},
+			Events: []string{string(github.WebhookPush), string(github.WebhookPullRequest)},
+		}
+		payload, err := json.Marshal(webhookPost)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to marshal request body for webhook")
+		}
+		return payload, nil
+	}
+	return nil, nil
+}
+
+// updatePullRequestWebhook updates the webhook of the repository to subscribe to the pull request events. The
+// webhooks created before draft issues are created from pull requests only subscribe to the push events.
+func (s *Server) updatePullRequestWebhook(ctx context.Context, repo *api.Repository) error {
+	payload, err := getPullRequestWebhookPayload(repo.VCS.Type, repo.WebhookURLHost, repo.WebhookEndpointID, repo.WebhookSecretToken)
+	if err != nil {
+		return err
+	}
+	if payload == nil {
+		return nil
+	}
+	return vcsPlugin.Get(repo.VCS.Type, vcsPlugin.ProviderConfig{}).PatchWebhook(
+		ctx,
+		common.OauthContext{
+			ClientID:     repo.VCS.ApplicationID,
+			ClientSecret: repo.VCS.Secret,
+			AccessToken:  repo.AccessToken,
+			RefreshToken: repo.RefreshToken,
+			Refresher:    s.refreshToken(ctx, repo.WebURL),
+		},
+		repo.VCS.InstanceURL,
+		repo.ExternalID,
+		repo.ExternalWebhookID,
+		payload,
+	)
+}
+
+func (s *Server) createVCSWebhook(ctx context.Context, vcsType vcsPlugin.Type, webhookEndpointID, secretToken, accessToken, instanceURL, externalRepoID string) (string, error) {
+	// Create a new webhook and retrieve the created webhook ID
+	var webhookCreatePayload []byte
+	var err error
+	switch vcsType {
+	case vcsPlugin.GitLabSelfHost, vcsPlugin.GitHubCom:
+		webhookCreatePayload, err = getPullRequestWebhookPayload(vcsType, s.profile.ExternalURL, webhookEndpointID, secretToken)
+		if err != nil {
+			return "", err
+		}
+	case vcsPlugin.BitbucketCloud:
+		webhookPost := bitbucket.WebhookCreateOrUpdate{
+			Description: "Bytebase GitOps",
+			URL:         fmt.Sprintf("%s/hook/bitbucket/%s", s.profile.ExternalURL, webhookEndpointID),
+			Active:      true,
+			Secret:      secretToken,
+			Events:      []string{string(bitbucket.WebhookPush)},
+		}
+		webhookCreatePayload, err = json.Marshal(webhookPost)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to marshal request body for creating webhook")
+		}
+	case vcsPlugin.BitbucketServer:
+		webhookPost := bitbucketserver.WebhookCreateOrUpdate{
+			Name:   "Bytebase GitOps",
+			URL:    fmt.Sprintf("%s/hook/bitbucket-server/%s", s.profile.ExternalURL, webhookEndpointID),
+			Active: true,
+			Configuration: bitbucketserver.WebhookConfiguration{
+				Secret: secretToken,
+			},
+			Events: []string{string(bitbucketserver.WebhookPush)},
+		}
+		webhookCreatePayload, err = json.Marshal(webhookPost)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to marshal request body for creating webhook")
+		}
+	case vcsPlugin.Gitea:
+		webhookPost := gitea.WebhookCreate{
+			Type: "gitea",
+			Config: gitea.WebhookConfig{
+				URL:         fmt.Sprintf("%s/hook/gitea/%s", s.profile.ExternalURL, webhookEndpointID),
+				ContentType: "json",
+				Secret:      secretToken,
+			},
+			Events: []string{string(gitea.WebhookPush)},
+			Active: true,
+		}
+		webhookCreatePayload, err = json.Marshal(webhookPost)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to marshal request body for creating webhook")
+		}
+	case vcsPlugin.AzureDevOps:
+		// The project and repository IDs of the publisher inputs are filled by the provider.
+		webhookPost := azure.WebhookCreateOrUpdate{
+			PublisherID:      "tfs",
+			EventType:        azure.WebhookPush,
+			ResourceVersion:  "1.0",
+			ConsumerID:       "webHooks",
+			ConsumerActionID: "httpRequest",
+			ConsumerInputs: azure.WebhookConsumerInputs{
+				URL:               fmt.Sprintf("%s/hook/azure/%s", s.profile.ExternalURL, webhookEndpointID),
+				BasicAuthUsername: "bytebase",
+				BasicAuthPassword: secretToken,
+			},
 		}
 		webhookCreatePayload, err = json.Marshal(webhookPost)
 		if err != nil {
@@ -11,6 +11,7 @@ import (
 
 	"github.com/bytebase/bytebase/api"
 	"github.com/bytebase/bytebase/common/log"
+	"github.com/bytebase/bytebase/plugin/metric/prometheus"
 )
 
 const (
@@ -42,9 +43,17 @@ func (s *SchemaSyncer) Run(ctx context.Context, wg *sync.WaitGroup) {
 	for {
 		select {
 		case <-ticker.C:
+			start := time.Now()
 			s.syncAllInstances(ctx)
 			// Sync all databases for all instances.
 			s.syncAllDatabases(ctx, nil /* instanceID */)
+			if err := s.server.store.DeleteExpiredTableSizeHistory(ctx, time.Now().Unix()); err != nil {
+				log.Error("Failed to delete expired table size history", zap.Error(err))
+			}
+			if err := s.server.store.DeleteExpiredWebhookDelivery(ctx, time.Now().Unix()); err != nil {
+				log.Error("Failed to delete expired webhook deliveries", zap.Error(err))
+			}
+			prometheus.ObserveSince(prometheus.RunnerCycleDuration, start, string(prometheus.RunnerSchemaSyncer))
 		case instance := <-instanceDatabaseSyncChan:
 			// Sync all databases for instance.
 			s.syncAllDatabases(ctx, &instance.ID)
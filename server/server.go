@@ -3,6 +3,7 @@ package server
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"runtime"
@@ -17,12 +18,12 @@ import (
 	"github.com/casbin/casbin/v2/model"
 	"github.com/google/uuid"
 	"github.com/labstack/echo-contrib/pprof"
-	"github.com/labstack/echo-contrib/prometheus"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/pkg/errors"
 	scas "github.com/qiangmzsx/string-adapter/v2"
 	echoSwagger "github.com/swaggo/echo-swagger"
+	"go.uber.org/zap"
 
 	"github.com/bytebase/bytebase/api"
 	"github.com/bytebase/bytebase/common"
@@ -32,7 +33,9 @@ import (
 	enterpriseService "github.com/bytebase/bytebase/enterprise/service"
 	"github.com/bytebase/bytebase/metric"
 	metricCollector "github.com/bytebase/bytebase/metric/collector"
+	"github.com/bytebase/bytebase/plugin/metric/prometheus"
 	s3bb "github.com/bytebase/bytebase/plugin/storage/s3"
+	"github.com/bytebase/bytebase/plugin/tracing"
 	"github.com/bytebase/bytebase/resources/mysqlutil"
 	"github.com/bytebase/bytebase/resources/postgres"
 	"github.com/bytebase/bytebase/store"
@@ -50,9 +53,15 @@ type Server struct {
 	SchemaSyncer       *SchemaSyncer
 	BackupRunner       *BackupRunner
 	AnomalyScanner     *AnomalyScanner
+	SlowQueryCollector *SlowQueryCollector
 	ApplicationRunner  *ApplicationRunner
+	LeaderElector      *LeaderElector
 	runnerWG           sync.WaitGroup
 
+	// sqlReviewLocks serializes posting SQL review results to the same pull request,
+	// keyed by "{repository ID}/{pull request ID}".
+	sqlReviewLocks sync.Map
+
 	ActivityManager *ActivityManager
 
 	LicenseService enterpriseAPI.LicenseService
@@ -70,6 +79,9 @@ type Server struct {
 
 	s3Client *s3bb.Client
 
+	// shutdownTracing flushes and stops the trace exporter.
+	shutdownTracing func(context.Context) error
+
 	// boot specifies that whether the server boot correctly
 	cancel context.CancelFunc
 }
@@ -126,6 +138,7 @@ func NewServer(ctx context.Context, prof Profile) (*Server, error) {
 	log.Info(fmt.Sprintf("backupBucket=%s", prof.BackupBucket))
 	log.Info(fmt.Sprintf("backupRegion=%s", prof.BackupRegion))
 	log.Info(fmt.Sprintf("backupCredentialFile=%s", prof.BackupCredentialFile))
+	log.Info(fmt.Sprintf("otlpEndpoint=%s", prof.OTLPEndpoint))
 	log.Info("-----Config END-------")
 
 	serverStarted := false
@@ -137,6 +150,11 @@ func NewServer(ctx context.Context, prof Profile) (*Server, error) {
 
 	var err error
 
+	s.shutdownTracing, err = tracing.Init(ctx, prof.OTLPEndpoint, prof.Version)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to init tracing")
+	}
+
 	resourceDir := common.GetResourceDir(prof.DataDir)
 	// Install mysqlutil
 	if err := mysqlutil.Install(resourceDir); err != nil {
@@ -297,8 +315,14 @@ func NewServer(ctx context.Context, prof Profile) (*Server, error) {
 		// Anomaly scanner
 		s.AnomalyScanner = NewAnomalyScanner(s)
 
+		// Slow query collector
+		s.SlowQueryCollector = NewSlowQueryCollector(s)
+
 		// Metric reporter
 		s.initMetricReporter(config.workspaceID)
+
+		// Leader elector
+		s.LeaderElector = NewLeaderElector(s.store, s.startRunners, s.TaskScheduler.stopAllRunningExecutors)
 	}
 
 	// Middleware
@@ -318,6 +342,7 @@ func NewServer(ctx context.Context, prof Profile) (*Server, error) {
 			`"status":${status},"error":"${error}"}` + "\n",
 	}))
 	e.Use(recoverMiddleware)
+	e.Use(tracingMiddleware)
 	e.GET("/swagger/*", echoSwagger.WrapHandler)
 
 	webhookGroup := e.Group("/hook")
@@ -355,11 +380,13 @@ func NewServer(ctx context.Context, prof Profile) (*Server, error) {
 	s.registerMemberRoutes(apiGroup)
 	s.registerPolicyRoutes(apiGroup)
 	s.registerProjectRoutes(apiGroup)
+	s.registerWebhookDeliveryRoutes(apiGroup)
 	s.registerProjectWebhookRoutes(apiGroup)
 	s.registerProjectMemberRoutes(apiGroup)
 	s.registerEnvironmentRoutes(apiGroup)
 	s.registerInstanceRoutes(apiGroup)
 	s.registerDatabaseRoutes(apiGroup)
+	s.registerIndexAdviceRoutes(apiGroup)
 	s.registerIssueRoutes(apiGroup)
 	s.registerIssueSubscriberRoutes(apiGroup)
 	s.registerTaskRoutes(apiGroup)
@@ -382,8 +409,7 @@ func NewServer(ctx context.Context, prof Profile) (*Server, error) {
 	// Register pprof endpoints.
 	pprof.Register(e)
 	// Register prometheus metrics endpoint.
-	p := prometheus.NewPrometheus("api", nil)
-	p.Use(e)
+	prometheus.Register(e)
 
 	s.LicenseService, err = enterpriseService.NewLicenseService(prof.Mode, s.store)
 	if err != nil {
@@ -469,6 +495,23 @@ func getInitSetting(ctx context.Context, store *store.Store) (*config, error) {
 		return nil, err
 	}
 
+	// initial database size anomaly thresholds
+	databaseSizeValue, err := json.Marshal(api.SettingAnomalyDatabaseSizeValue{
+		MaxSize:             0,
+		MaxDailyGrowthRatio: 1,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal initial database size anomaly setting")
+	}
+	if _, _, err := store.CreateSettingIfNotExist(ctx, &api.SettingCreate{
+		CreatorID:   api.SystemBotID,
+		Name:        api.SettingAnomalyDatabaseSize,
+		Value:       string(databaseSizeValue),
+		Description: "The thresholds of the database size and daily growth ratio to raise anomalies.",
+	}); err != nil {
+		return nil, err
+	}
+
 	// initial feishu app
 	if _, _, err := store.CreateSettingIfNotExist(ctx, &api.SettingCreate{
 		CreatorID:   api.SystemBotID,
@@ -487,32 +530,43 @@ func (s *Server) Run(ctx context.Context, port int) error {
 	ctx, cancel := context.WithCancel(ctx)
 	s.cancel = cancel
 	if !s.profile.Readonly {
-		if err := s.TaskScheduler.ClearRunningTasks(ctx); err != nil {
-			return errors.Wrap(err, "failed to clear existing RUNNING tasks before start the task scheduler")
-		}
+		// Only the leader among the replicas sharing the metadata db runs the runners.
 		// runnerWG waits for all goroutines to complete.
 		s.runnerWG.Add(1)
-		go s.TaskScheduler.Run(ctx, &s.runnerWG)
-		s.runnerWG.Add(1)
-		go s.TaskCheckScheduler.Run(ctx, &s.runnerWG)
-		s.runnerWG.Add(1)
-		go s.SchemaSyncer.Run(ctx, &s.runnerWG)
-		s.runnerWG.Add(1)
-		go s.BackupRunner.Run(ctx, &s.runnerWG)
-		s.runnerWG.Add(1)
-		go s.AnomalyScanner.Run(ctx, &s.runnerWG)
-		s.runnerWG.Add(1)
-		go s.ApplicationRunner.Run(ctx, &s.runnerWG)
-
-		if s.MetricReporter != nil {
-			s.runnerWG.Add(1)
-			go s.MetricReporter.Run(ctx, &s.runnerWG)
-		}
+		go s.LeaderElector.Run(ctx, &s.runnerWG)
 	}
 
 	return s.e.Start(fmt.Sprintf(":%d", port))
 }
 
+// startRunners starts the runners after the server is elected as the leader.
+func (s *Server) startRunners(ctx context.Context, wg *sync.WaitGroup) error {
+	// The RUNNING tasks are left over by the previous leader, which has exited or lost the leader lock.
+	if err := s.TaskScheduler.AdoptRunningTasks(ctx); err != nil {
+		return errors.Wrap(err, "failed to adopt existing RUNNING tasks before start the task scheduler")
+	}
+	wg.Add(1)
+	go s.TaskScheduler.Run(ctx, wg)
+	wg.Add(1)
+	go s.TaskCheckScheduler.Run(ctx, wg)
+	wg.Add(1)
+	go s.SchemaSyncer.Run(ctx, wg)
+	wg.Add(1)
+	go s.BackupRunner.Run(ctx, wg)
+	wg.Add(1)
+	go s.AnomalyScanner.Run(ctx, wg)
+	wg.Add(1)
+	go s.SlowQueryCollector.Run(ctx, wg)
+	wg.Add(1)
+	go s.ApplicationRunner.Run(ctx, wg)
+
+	if s.MetricReporter != nil {
+		wg.Add(1)
+		go s.MetricReporter.Run(ctx, wg)
+	}
+	return nil
+}
+
 // Shutdown will shut down the server.
 func (s *Server) Shutdown(ctx context.Context) error {
 	log.Info("Trying to stop Bytebase ....")
@@ -552,6 +606,13 @@ func (s *Server) Shutdown(ctx context.Context) error {
 	if s.metaDB != nil {
 		s.metaDB.Close()
 	}
+
+	// Flush the remaining spans.
+	if s.shutdownTracing != nil {
+		if err := s.shutdownTracing(ctx); err != nil {
+			log.Warn("Failed to shutdown tracing", zap.Error(err))
+		}
+	}
 	log.Info("Bytebase stopped properly")
 
 	return nil
@@ -17,6 +17,7 @@ import (
 var whitelistSettings = []api.SettingName{
 	api.SettingBrandingLogo,
 	api.SettingAppIM,
+	api.SettingAnomalyDatabaseSize,
 }
 
 func (s *Server) registerSettingRoutes(g *echo.Group) {
@@ -87,6 +88,16 @@ func (s *Server) registerSettingRoutes(g *echo.Group) {
 			settingPatch.Value = string(b)
 		}
 
+		if settingPatch.Name == api.SettingAnomalyDatabaseSize {
+			var value api.SettingAnomalyDatabaseSizeValue
+			if err := json.Unmarshal([]byte(settingPatch.Value), &value); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "Malformed setting value for database size anomaly").SetInternal(err)
+			}
+			if value.MaxSize < 0 || value.MaxDailyGrowthRatio < 0 {
+				return echo.NewHTTPError(http.StatusBadRequest, "Database size anomaly thresholds must not be negative")
+			}
+		}
+
 		setting, err := s.store.PatchSetting(ctx, settingPatch)
 		if err != nil {
 			if common.ErrorCode(err) == common.NotFound {
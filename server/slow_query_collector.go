@@ -0,0 +1,171 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common/log"
+	"github.com/bytebase/bytebase/plugin/advisor"
+	advisorDB "github.com/bytebase/bytebase/plugin/advisor/db"
+	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/plugin/db/mysql"
+	"github.com/bytebase/bytebase/plugin/db/pg"
+)
+
+const (
+	slowQueryCollectInterval = time.Duration(30) * time.Minute
+	// slowQueryLimitPerInstance is the maximum number of queries collected from each instance.
+	slowQueryLimitPerInstance = 200
+)
+
+// NewSlowQueryCollector creates a slow query collector.
+func NewSlowQueryCollector(server *Server) *SlowQueryCollector {
+	return &SlowQueryCollector{
+		server: server,
+	}
+}
+
+// SlowQueryCollector collects the query statistics from the MySQL and Postgres instances
+// and reviews the collected queries with the SQL review rules applicable to queries.
+type SlowQueryCollector struct {
+	server *Server
+}
+
+// queryStatisticsGetter is implemented by the drivers supporting query statistics collection.
+type queryStatisticsGetter interface {
+	GetQueryStatistics(ctx context.Context, limit int) ([]*db.QueryStatistics, error)
+}
+
+var (
+	_ queryStatisticsGetter = (*mysql.Driver)(nil)
+	_ queryStatisticsGetter = (*pg.Driver)(nil)
+)
+
+// Run will run the slow query collector.
+func (c *SlowQueryCollector) Run(ctx context.Context, wg *sync.WaitGroup) {
+	ticker := time.NewTicker(slowQueryCollectInterval)
+	defer ticker.Stop()
+	defer wg.Done()
+	log.Debug(fmt.Sprintf("Slow query collector started and will run every %v", slowQueryCollectInterval))
+	for {
+		select {
+		case <-ticker.C:
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						err, ok := r.(error)
+						if !ok {
+							err = errors.Errorf("%v", r)
+						}
+						log.Error("Slow query collector PANIC RECOVER", zap.Error(err), zap.Stack("panic-stack"))
+					}
+				}()
+
+				ctx := context.Background()
+
+				rowStatus := api.Normal
+				instanceList, err := c.server.store.FindInstance(ctx, &api.InstanceFind{
+					RowStatus: &rowStatus,
+				})
+				if err != nil {
+					log.Error("Failed to retrieve instance list", zap.Error(err))
+					return
+				}
+				for _, instance := range instanceList {
+					if instance.Engine != db.MySQL && instance.Engine != db.Postgres {
+						continue
+					}
+					if err := c.collectInstance(ctx, instance); err != nil {
+						log.Warn("Failed to collect slow queries",
+							zap.String("instance", instance.Name),
+							zap.Error(err))
+					}
+				}
+			}()
+		case <-ctx.Done(): // if cancel() execute
+			return
+		}
+	}
+}
+
+// collectInstance collects the slow queries of the instance and replaces the stored ones.
+func (c *SlowQueryCollector) collectInstance(ctx context.Context, instance *api.Instance) error {
+	driver, err := c.server.getAdminDatabaseDriver(ctx, instance, "" /* databaseName */)
+	if err != nil {
+		return err
+	}
+	defer driver.Close(ctx)
+
+	getter, ok := driver.(queryStatisticsGetter)
+	if !ok {
+		return errors.Errorf("query statistics is not supported for engine %s", instance.Engine)
+	}
+	statisticsList, err := getter.GetQueryStatistics(ctx, slowQueryLimitPerInstance)
+	if err != nil {
+		return errors.Wrap(err, "failed to get query statistics")
+	}
+
+	databaseList, err := c.server.store.FindDatabase(ctx, &api.DatabaseFind{
+		InstanceID: &instance.ID,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to find databases")
+	}
+	databaseIDMap := make(map[string]int)
+	for _, database := range databaseList {
+		databaseIDMap[database.Name] = database.ID
+	}
+
+	advisorDBType, err := advisorDB.ConvertToAdvisorDBType(string(instance.Engine))
+	if err != nil {
+		return err
+	}
+
+	var upsertList []*api.SlowQueryUpsert
+	for _, statistics := range statisticsList {
+		databaseID, ok := databaseIDMap[statistics.Database]
+		if !ok {
+			// Skip the databases not synced to Bytebase yet.
+			continue
+		}
+		statement := statistics.SampleQuery
+		if statement == "" {
+			statement = statistics.NormalizedQuery
+		}
+		slowQueryPayload := api.SlowQueryPayload{}
+		adviceList, err := advisor.QueryReviewCheck(advisorDBType, statement)
+		if err != nil {
+			// Record the error of the query and go on, so that a single query failing the review doesn't
+			// fail the collection of the instance.
+			slowQueryPayload.ReviewError = err.Error()
+		} else {
+			slowQueryPayload.AdviceList = adviceList
+		}
+		payload, err := json.Marshal(slowQueryPayload)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal slow query payload")
+		}
+		upsertList = append(upsertList, &api.SlowQueryUpsert{
+			InstanceID:      instance.ID,
+			DatabaseID:      databaseID,
+			Fingerprint:     statistics.Fingerprint,
+			NormalizedQuery: statistics.NormalizedQuery,
+			SampleQuery:     statistics.SampleQuery,
+			Calls:           statistics.Calls,
+			TotalLatencyNs:  statistics.TotalLatencyNs,
+			MaxLatencyNs:    statistics.MaxLatencyNs,
+			RowsExamined:    statistics.RowsExamined,
+			RowsReturned:    statistics.RowsReturned,
+			Payload:         string(payload),
+		})
+	}
+
+	return c.server.store.ReplaceSlowQueryList(ctx, instance.ID, upsertList)
+}
@@ -23,6 +23,7 @@ import (
 	advisorDB "github.com/bytebase/bytebase/plugin/advisor/db"
 	"github.com/bytebase/bytebase/plugin/db"
 	"github.com/bytebase/bytebase/plugin/db/util"
+	"github.com/bytebase/bytebase/plugin/metric/prometheus"
 	"github.com/bytebase/bytebase/plugin/parser"
 	"github.com/bytebase/bytebase/plugin/parser/ast"
 	"github.com/bytebase/bytebase/store"
@@ -96,7 +97,10 @@ func (s *Server) registerSQLRoutes(g *echo.Group) {
 			resultSet.Error = errors.Wrapf(err, "failed to connect %q for user %q", hostPort, connectionInfo.Username).Error()
 		} else {
 			defer db.Close(ctx)
-			if err := db.Ping(ctx); err != nil {
+			start := time.Now()
+			err := db.Ping(ctx)
+			prometheus.ObserveSince(prometheus.DriverPingDuration, start, string(connectionInfo.Engine), string(prometheus.OutcomeFromError(err)))
+			if err != nil {
 				resultSet.Error = err.Error()
 			}
 		}
@@ -706,6 +710,9 @@ func (s *Server) syncDatabaseSchema(ctx context.Context, instance *api.Instance,
 	if err := syncTableSchema(ctx, s.store, database, schema); err != nil {
 		return err
 	}
+	if err := syncTableSizeHistory(ctx, s.store, database, schema); err != nil {
+		return err
+	}
 	if err := syncViewSchema(ctx, s.store, database, schema); err != nil {
 		return err
 	}
@@ -716,6 +723,11 @@ func syncTableSchema(ctx context.Context, store *store.Store, database *api.Data
 	return store.SetTableList(ctx, schema, database.ID)
 }
 
+// syncTableSizeHistory appends the table sizes to the history because the table list only keeps the latest sizes.
+func syncTableSizeHistory(ctx context.Context, store *store.Store, database *api.Database, schema *db.Schema) error {
+	return store.UpsertTableSizeHistory(ctx, schema, database.ID, time.Now().Unix())
+}
+
 func syncViewSchema(ctx context.Context, store *store.Store, database *api.Database, schema *db.Schema) error {
 	return store.SetViewList(ctx, schema, database.ID)
 }
@@ -727,9 +739,12 @@ func syncDBExtensionSchema(ctx context.Context, store *store.Store, database *ap
 func getLatestSchemaVersion(ctx context.Context, driver db.Driver, databaseName string) (string, error) {
 	// TODO(d): support semantic versioning.
 	limit := 1
+	// Repeatable migrations are not versioned, so they don't change the schema version.
+	repeatable := db.Repeatable
 	history, err := driver.FindMigrationHistoryList(ctx, &db.MigrationHistoryFind{
-		Database: &databaseName,
-		Limit:    &limit,
+		Database:    &databaseName,
+		ExcludeType: &repeatable,
+		Limit:       &limit,
 	})
 	if err != nil {
 		return "", errors.Wrapf(err, "failed to get migration history for database %q", databaseName)
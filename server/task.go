@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/jsonapi"
 	"github.com/labstack/echo/v4"
@@ -17,11 +19,16 @@ import (
 	"github.com/bytebase/bytebase/common/log"
 )
 
+const (
+	// taskProgressStreamInterval is the interval to push the task progress through the server-sent events stream.
+	taskProgressStreamInterval = time.Duration(1) * time.Second
+)
+
 var (
 	applicableTaskStatusTransition = map[api.TaskStatus][]api.TaskStatus{
 		api.TaskPendingApproval: {api.TaskPending},
 		api.TaskPending:         {api.TaskCanceled, api.TaskRunning, api.TaskPendingApproval},
-		api.TaskRunning:         {api.TaskDone, api.TaskFailed, api.TaskCanceled},
+		api.TaskRunning:         {api.TaskDone, api.TaskFailed, api.TaskCanceled, api.TaskPending},
 		api.TaskDone:            {},
 		api.TaskFailed:          {api.TaskPendingApproval},
 		api.TaskCanceled:        {api.TaskPendingApproval},
@@ -44,11 +51,18 @@ func (s *Server) canUpdateTaskStatement(ctx context.Context, task *api.Task) *ec
 	// Allow frontend to change the SQL statement of
 	// 1. a PendingApproval task which hasn't started yet
 	// 2. a Failed task which can be retried
-	// 3. a Pending task which can't be scheduled because of failed task checks, task dependency or earliest allowed time
+	// 3. a Pending task which can't be scheduled because of a draft pipeline, failed task checks, task dependency or earliest allowed time
 	if task.Status != api.TaskPendingApproval && task.Status != api.TaskFailed && task.Status != api.TaskPending {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("cannot update task in %q state", task.Status))
 	}
 	if task.Status == api.TaskPending {
+		pipeline, err := s.store.GetPipelineByID(ctx, task.PipelineID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to get the pipeline of the task").SetInternal(err)
+		}
+		if pipeline != nil && pipeline.Status == api.PipelineDraft {
+			return nil
+		}
 		ok, err := s.TaskScheduler.canSchedule(ctx, task)
 		if err != nil {
 			return echo.NewHTTPError(http.StatusInternalServerError, "failed to check whether the task can be scheduled").SetInternal(err)
@@ -61,6 +75,99 @@ func (s *Server) canUpdateTaskStatement(ctx context.Context, task *api.Task) *ec
 }
 
 func (s *Server) registerTaskRoutes(g *echo.Group) {
+	g.GET("/pipeline/:pipelineID/task/:taskID", func(c echo.Context) error {
+		ctx := c.Request().Context()
+		pipelineID, err := strconv.Atoi(c.Param("pipelineID"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Pipeline ID is not a number: %s", c.Param("pipelineID"))).SetInternal(err)
+		}
+		taskID, err := strconv.Atoi(c.Param("taskID"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Task ID is not a number: %s", c.Param("taskID"))).SetInternal(err)
+		}
+
+		task, err := s.store.GetTaskByID(ctx, taskID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch task ID: %d", taskID)).SetInternal(err)
+		}
+		if task == nil || task.PipelineID != pipelineID {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Task not found with ID %d in pipeline %d", taskID, pipelineID))
+		}
+		s.setTaskProgress(task)
+		if err := s.setTaskMaintenanceWindow(ctx, []*api.Task{task}); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to compute the maintenance window of task ID: %d", taskID)).SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, task); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal task ID response: %d", taskID)).SetInternal(err)
+		}
+		return nil
+	})
+
+	// Pushes the task progress through the server-sent events stream until the task is no longer running.
+	// The progress is read from the running task run persisted by the task scheduler, so that any replica can serve the stream.
+	g.GET("/pipeline/:pipelineID/task/:taskID/progress/stream", func(c echo.Context) error {
+		ctx := c.Request().Context()
+		pipelineID, err := strconv.Atoi(c.Param("pipelineID"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Pipeline ID is not a number: %s", c.Param("pipelineID"))).SetInternal(err)
+		}
+		taskID, err := strconv.Atoi(c.Param("taskID"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Task ID is not a number: %s", c.Param("taskID"))).SetInternal(err)
+		}
+
+		task, err := s.store.GetTaskByID(ctx, taskID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch task ID: %d", taskID)).SetInternal(err)
+		}
+		if task == nil || task.PipelineID != pipelineID {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Task not found with ID %d in pipeline %d", taskID, pipelineID))
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, "text/event-stream")
+		c.Response().Header().Set(echo.HeaderCacheControl, "no-cache")
+		c.Response().Header().Set(echo.HeaderConnection, "keep-alive")
+		c.Response().WriteHeader(http.StatusOK)
+
+		ticker := time.NewTicker(taskProgressStreamInterval)
+		defer ticker.Stop()
+		for {
+			setTaskProgressFromTaskRun(task)
+			bytes, err := json.Marshal(api.TaskProgressEvent{
+				TaskID:   task.ID,
+				Status:   task.Status,
+				Progress: task.Progress,
+				Queue:    task.Queue,
+			})
+			if err != nil {
+				return errors.Wrap(err, "failed to marshal task progress event")
+			}
+			if _, err := fmt.Fprintf(c.Response(), "event: progress\ndata: %s\n\n", bytes); err != nil {
+				// The client has gone.
+				return nil
+			}
+			c.Response().Flush()
+
+			// The progress of the task which isn't running doesn't change.
+			if task.Status != api.TaskRunning {
+				return nil
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return nil
+			}
+			if task, err = s.store.GetTaskByID(ctx, taskID); err != nil {
+				return errors.Wrapf(err, "failed to fetch task ID: %d", taskID)
+			}
+			if task == nil {
+				return nil
+			}
+		}
+	})
+
 	g.PATCH("/pipeline/:pipelineID/task/all", func(c echo.Context) error {
 		ctx := c.Request().Context()
 		pipelineID, err := strconv.Atoi(c.Param("pipelineID"))
@@ -206,6 +313,10 @@ func (s *Server) registerTaskRoutes(g *echo.Group) {
 		if !ok {
 			return echo.NewHTTPError(http.StatusUnauthorized, "Not allowed to change task status")
 		}
+		// A running task only goes back to pending when the task scheduler retries the failed run automatically.
+		if task.Status == api.TaskRunning && taskStatusPatch.Status == api.TaskPending {
+			return echo.NewHTTPError(http.StatusBadRequest, "Only the task scheduler can retry a running task")
+		}
 
 		taskPatched, err := s.patchTaskStatus(ctx, task, taskStatusPatch)
 		if err != nil {
@@ -225,6 +336,95 @@ func (s *Server) registerTaskRoutes(g *echo.Group) {
 		return nil
 	})
 
+	// Break-glass override for the owner to start the task outside the maintenance windows and change freezes.
+	g.POST("/pipeline/:pipelineID/task/:taskID/maintenance-window/override", func(c echo.Context) error {
+		ctx := c.Request().Context()
+		taskID, err := strconv.Atoi(c.Param("taskID"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Task ID is not a number: %s", c.Param("taskID"))).SetInternal(err)
+		}
+
+		// Only Owner can override the maintenance windows.
+		role := c.Get(getRoleContextKey()).(api.Role)
+		if role != api.Owner {
+			return echo.NewHTTPError(http.StatusForbidden, "Only the workspace owner can override the maintenance windows")
+		}
+
+		override := &api.TaskMaintenanceWindowOverride{}
+		if err := jsonapi.UnmarshalPayload(c.Request().Body, override); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformed override task maintenance window request").SetInternal(err)
+		}
+		if override.Comment == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "Comment is required to override the maintenance windows")
+		}
+
+		task, err := s.store.GetTaskByID(ctx, taskID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch task ID: %d", taskID)).SetInternal(err)
+		}
+		if task == nil {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Task not found with ID %d", taskID))
+		}
+		// The running task which is not started yet may be held up in the queue by the maintenance windows.
+		queued := task.Status == api.TaskRunning && !isTaskRunStarted(task)
+		if task.Status != api.TaskPendingApproval && task.Status != api.TaskPending && !queued {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("cannot override the maintenance windows of task in %q state", task.Status))
+		}
+		if task.MaintenanceWindowOverride {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("The maintenance windows of task %q are overridden already", task.Name))
+		}
+
+		issue, err := s.store.GetIssueByPipelineID(ctx, task.PipelineID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch issue with pipeline ID: %d", task.PipelineID)).SetInternal(err)
+		}
+		if issue == nil {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Issue not found with pipeline ID: %d", task.PipelineID))
+		}
+
+		if err := s.setTaskMaintenanceWindow(ctx, []*api.Task{task}); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to compute the maintenance window of task ID: %d", taskID)).SetInternal(err)
+		}
+
+		overridden := true
+		taskPatched, err := s.store.PatchTask(ctx, &api.TaskPatch{
+			ID:                        taskID,
+			UpdaterID:                 c.Get(getPrincipalIDContextKey()).(int),
+			MaintenanceWindowOverride: &overridden,
+		})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to override the maintenance windows of task ID: %d", taskID)).SetInternal(err)
+		}
+
+		payload, err := json.Marshal(api.ActivityPipelineTaskMaintenanceWindowOverridePayload{
+			TaskID:    taskPatched.ID,
+			Reason:    task.MaintenanceWindow.Reason,
+			IssueName: issue.Name,
+			TaskName:  taskPatched.Name,
+		})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, errors.Wrapf(err, "failed to marshal maintenance window override activity payload: %v", task.Name))
+		}
+		if _, err := s.ActivityManager.CreateActivity(ctx, &api.ActivityCreate{
+			CreatorID:   taskPatched.UpdaterID,
+			ContainerID: taskPatched.PipelineID,
+			Type:        api.ActivityPipelineTaskMaintenanceWindowOverride,
+			Level:       api.ActivityWarn,
+			Comment:     override.Comment,
+			Payload:     string(payload),
+		}, &ActivityMeta{
+			issue: issue,
+		}); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to create activity after overriding the maintenance windows of task: %v", taskPatched.Name)).SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, taskPatched); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal override task \"%v\" maintenance window response", taskPatched.Name)).SetInternal(err)
+		}
+		return nil
+	})
+
 	g.POST("/pipeline/:pipelineID/task/:taskID/check", func(c echo.Context) error {
 		ctx := c.Request().Context()
 		taskID, err := strconv.Atoi(c.Param("taskID"))
@@ -312,6 +512,18 @@ func (s *Server) patchTask(ctx context.Context, task *api.Task, taskPatch *api.T
 			}
 			oldStatement = payload.Statement
 			payload.Statement = *taskPatch.Statement
+			if payload.Chunk != nil {
+				if _, err := parseDataUpdateChunkStatement(task.Instance.Engine, strings.TrimSpace(payload.Statement)); err != nil {
+					return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Statement can't be executed in chunks: %v", err))
+				}
+			}
+			if payload.RowSnapshot {
+				if err := validateRowSnapshot(task.Instance.Engine, payload.Statement, payload.Chunk); err != nil {
+					return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid row snapshot option: %v", err))
+				}
+			}
+			// The chunks executed by the previous attempts are for the old statement.
+			payload.ChunkCursor = nil
 			// 1. For VCS workflows, patchTask only happens when we modify the same file.
 			// 	  In that case, we want to use the same schema version parsed from the file name.
 			//    The task executor will force retry using the new SQL statement.
@@ -349,6 +561,9 @@ func (s *Server) patchTask(ctx context.Context, task *api.Task, taskPatch *api.T
 			}
 			oldStatement = payload.Statement
 			payload.Statement = *taskPatch.Statement
+			if err := validateOnlineMigration(task.Instance.Engine, payload.Statement); err != nil {
+				return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid online migration statement: %v", err))
+			}
 			// We should update the schema version if we've updated the SQL, otherwise we will
 			// get migration history version conflict if the previous task has been attempted.
 			payload.SchemaVersion = common.DefaultMigrationVersion()
@@ -536,6 +751,29 @@ func (s *Server) patchTask(ctx context.Context, task *api.Task, taskPatch *api.T
 	return taskPatched, nil
 }
 
+// setTaskMaintenanceWindow computes when the tasks waiting to start are allowed to start by the maintenance window policy of their environments.
+func (s *Server) setTaskMaintenanceWindow(ctx context.Context, taskList []*api.Task) error {
+	now := time.Now()
+	policyMap := make(map[int]*api.MaintenanceWindowPolicy)
+	for _, task := range taskList {
+		if task.Status != api.TaskPendingApproval && task.Status != api.TaskPending {
+			continue
+		}
+		environmentID := task.Instance.EnvironmentID
+		policy, ok := policyMap[environmentID]
+		if !ok {
+			p, err := s.store.GetMaintenanceWindowPolicyByEnvID(ctx, environmentID)
+			if err != nil {
+				return errors.Wrapf(err, "failed to get the maintenance window policy of environment %d", environmentID)
+			}
+			policy = p
+			policyMap[environmentID] = policy
+		}
+		task.MaintenanceWindow = getTaskMaintenanceWindow(task, policy, now)
+	}
+	return nil
+}
+
 // canPrincipalBeAssignee checks if a principal could be the assignee of an issue, judging by the principal role and the environment policy.
 func (s *Server) canPrincipalBeAssignee(ctx context.Context, principalID int, environmentID int, projectID int, issueType api.IssueType) (bool, error) {
 	policy, err := s.store.GetPipelineApprovalPolicy(ctx, environmentID)
@@ -684,13 +922,13 @@ func (s *Server) patchTaskStatus(ctx context.Context, task *api.Task, taskStatus
 		if !taskCancellationImplemented[task.Type] {
 			return nil, common.Errorf(common.NotImplemented, "Canceling task type %s is not supported", task.Type)
 		}
+		// The task executor runs on the leader, which cancels the executor once the task is no longer RUNNING.
+		// Cancel it right away if this replica is the leader.
 		s.TaskScheduler.runningExecutorsMutex.Lock()
-		cancel, ok := s.TaskScheduler.runningExecutorsCancel[task.ID]
-		s.TaskScheduler.runningExecutorsMutex.Unlock()
-		if !ok {
-			return nil, errors.New("failed to cancel task")
+		if cancel, ok := s.TaskScheduler.runningExecutorsCancel[task.ID]; ok {
+			cancel()
 		}
-		cancel()
+		s.TaskScheduler.runningExecutorsMutex.Unlock()
 		result, err := json.Marshal(api.TaskRunResultPayload{
 			Detail: "Task cancellation requested.",
 		})
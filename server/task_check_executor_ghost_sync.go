@@ -3,12 +3,14 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"strings"
 
 	"github.com/github/gh-ost/go/logic"
 	"github.com/pkg/errors"
 
 	"github.com/bytebase/bytebase/api"
 	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/plugin/db"
 )
 
 // NewTaskCheckGhostSyncExecutor creates a task check gh-ost sync executor.
@@ -59,6 +61,10 @@ func (*TaskCheckGhostSyncExecutor) Run(ctx context.Context, server *Server, task
 		return nil, common.Errorf(common.Internal, "failed to find database %d", task.DatabaseID)
 	}
 
+	if task.Instance.Engine == db.Postgres {
+		return runPGOnlineMigrationCheck(ctx, server, task)
+	}
+
 	adminDataSource := api.DataSourceFromInstanceWithType(task.Instance, api.Admin)
 	if adminDataSource == nil {
 		return nil, common.Errorf(common.Internal, "admin data source not found for instance %d", task.InstanceID)
@@ -110,3 +116,50 @@ func (*TaskCheckGhostSyncExecutor) Run(ctx context.Context, server *Server, task
 		},
 	}, nil
 }
+
+// runPGOnlineMigrationCheck dry runs the trigger-based online migration of Postgres by setting up the shadow table
+// and the trigger in a transaction which is rolled back.
+func runPGOnlineMigrationCheck(ctx context.Context, server *Server, task *api.Task) ([]api.TaskCheckResult, error) {
+	payload := &api.TaskDatabaseSchemaUpdateGhostSyncPayload{}
+	if err := json.Unmarshal([]byte(task.Payload), payload); err != nil {
+		return nil, common.Wrapf(err, common.Internal, "invalid database schema update gh-ost sync payload")
+	}
+
+	err := func() error {
+		m, err := getPGOnlineMigration(strings.TrimSpace(payload.Statement), task.ID)
+		if err != nil {
+			return err
+		}
+		driver, err := server.getAdminDatabaseDriver(ctx, task.Instance, task.Database.Name)
+		if err != nil {
+			return err
+		}
+		defer driver.Close(ctx)
+		sqlDB, err := driver.GetDBConnection(ctx, task.Database.Name)
+		if err != nil {
+			return err
+		}
+		return dryRunPGOnlineMigration(ctx, sqlDB, m)
+	}()
+	if err != nil {
+		return []api.TaskCheckResult{
+			{
+				Status:    api.TaskCheckStatusError,
+				Namespace: api.BBNamespace,
+				Code:      common.Internal.Int(),
+				Title:     "Online migration dry run failed",
+				Content:   err.Error(),
+			},
+		}, nil
+	}
+
+	return []api.TaskCheckResult{
+		{
+			Status:    api.TaskCheckStatusSuccess,
+			Namespace: api.BBNamespace,
+			Code:      common.Ok.Int(),
+			Title:     "OK",
+			Content:   "Online migration dry run succeeded",
+		},
+	}, nil
+}
@@ -8,11 +8,14 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 
 	"github.com/bytebase/bytebase/api"
 	"github.com/bytebase/bytebase/common"
 	"github.com/bytebase/bytebase/common/log"
+	"github.com/bytebase/bytebase/plugin/metric/prometheus"
+	"github.com/bytebase/bytebase/plugin/tracing"
 )
 
 // NewTaskCheckScheduler creates a task check scheduler.
@@ -52,7 +55,8 @@ func (s *TaskCheckScheduler) Run(ctx context.Context, wg *sync.WaitGroup) {
 					}
 				}()
 
-				ctx := context.Background()
+				ctx, span := tracing.Start(context.Background(), "TaskCheckScheduler.Run")
+				defer span.End()
 
 				// Inspect all running task checks
 				taskCheckRunStatusList := []api.TaskCheckRunStatus{api.TaskCheckRunRunning}
@@ -89,7 +93,14 @@ func (s *TaskCheckScheduler) Run(ctx context.Context, wg *sync.WaitGroup) {
 							delete(runningTaskChecks, taskCheckRun.ID)
 							mu.Unlock()
 						}()
+						ctx, span := tracing.Start(ctx, "TaskCheckExecutor.Run",
+							attribute.Int("task_check_run.id", taskCheckRun.ID),
+							attribute.String("task_check_run.type", string(taskCheckRun.Type)),
+						)
+						start := time.Now()
 						checkResultList, err := executor.Run(ctx, s.server, taskCheckRun)
+						tracing.End(span, err)
+						prometheus.ObserveSince(prometheus.TaskCheckRunDuration, start, string(taskCheckRun.Type), string(prometheus.OutcomeFromError(err)))
 
 						if err == nil {
 							bytes, err := json.Marshal(api.TaskCheckRunResultPayload{
@@ -7,8 +7,11 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 
 	"github.com/bytebase/bytebase/api"
@@ -18,6 +21,7 @@ import (
 	"github.com/bytebase/bytebase/plugin/db/mysql"
 	"github.com/bytebase/bytebase/plugin/parser"
 	"github.com/bytebase/bytebase/plugin/parser/transform"
+	"github.com/bytebase/bytebase/plugin/tracing"
 	vcsPlugin "github.com/bytebase/bytebase/plugin/vcs"
 	"github.com/bytebase/bytebase/store"
 )
@@ -40,6 +44,13 @@ type TaskExecutor interface {
 
 // RunTaskExecutorOnce wraps a TaskExecutor.RunOnce call with panic recovery.
 func RunTaskExecutorOnce(ctx context.Context, exec TaskExecutor, server *Server, task *api.Task) (terminated bool, result *api.TaskRunResultPayload, err error) {
+	ctx, span := tracing.Start(ctx, "TaskExecutor.RunOnce",
+		attribute.Int("task.id", task.ID),
+		attribute.String("task.type", string(task.Type)),
+	)
+	defer func() {
+		tracing.End(span, err)
+	}()
 	defer func() {
 		if r := recover(); r != nil {
 			panicErr, ok := r.(error)
@@ -56,6 +67,58 @@ func RunTaskExecutorOnce(ctx context.Context, exec TaskExecutor, server *Server,
 	return exec.RunOnce(ctx, server, task)
 }
 
+// maxProgressStatementLength is the maximum length of the statement being executed in the task progress.
+const maxProgressStatementLength = 1024
+
+// statementProgress records the progress of executing the statements of a task, which is reported by the driver.
+type statementProgress struct {
+	progress atomic.Value // api.Progress
+}
+
+// withReporter returns a copy of ctx carrying the reporter which records the progress reported by the driver.
+func (p *statementProgress) withReporter(ctx context.Context) context.Context {
+	createdTs := time.Now().Unix()
+	p.progress.Store(api.Progress{
+		CreatedTs: createdTs,
+		UpdatedTs: createdTs,
+	})
+	return db.WithExecuteProgressReporter(ctx, func(progress db.ExecuteProgress) {
+		currentStatement := strings.TrimSpace(progress.CurrentStatement)
+		if len(currentStatement) > maxProgressStatementLength {
+			currentStatement = currentStatement[:maxProgressStatementLength] + "..."
+		}
+		p.progress.Store(api.Progress{
+			TotalUnit:         int64(progress.TotalStatement),
+			CompletedUnit:     int64(progress.ExecutedStatement),
+			CreatedTs:         createdTs,
+			UpdatedTs:         time.Now().Unix(),
+			TotalStatement:    progress.TotalStatement,
+			ExecutedStatement: progress.ExecutedStatement,
+			CurrentStatement:  currentStatement,
+		})
+	})
+}
+
+// get returns the progress with UpdatedTs set to now, so that UpdatedTs - CreatedTs is the elapsed time even if
+// a statement takes long.
+func (p *statementProgress) get() api.Progress {
+	v := p.progress.Load()
+	if v == nil {
+		return api.Progress{}
+	}
+	progress := v.(api.Progress)
+	progress.UpdatedTs = time.Now().Unix()
+	return progress
+}
+
+// update updates the progress reported by the executor itself instead of the driver, e.g. the executed chunks.
+func (p *statementProgress) update(f func(progress *api.Progress)) {
+	progress, _ := p.progress.Load().(api.Progress)
+	f(&progress)
+	progress.UpdatedTs = time.Now().Unix()
+	p.progress.Store(progress)
+}
+
 func preMigration(ctx context.Context, server *Server, task *api.Task, migrationType db.MigrationType, statement, schemaVersion string, vcsPushEvent *vcsPlugin.PushEvent) (*db.MigrationInfo, error) {
 	if task.Database == nil {
 		msg := "missing database when updating schema"
@@ -119,7 +182,7 @@ func preMigration(ctx context.Context, server *Server, task *api.Task, migration
 	// We will force migration for baseline, migrate and data type of migrations.
 	// This usually happens when the previous attempt fails and the client retries the migration.
 	// We also force migration for VCS migrations, which is usually a modified file to correct a former wrong migration commit.
-	if mi.Type == db.Baseline || mi.Type == db.Migrate || mi.Type == db.Data {
+	if mi.Type == db.Baseline || mi.Type == db.Migrate || mi.Type == db.Data || mi.Type == db.Repeatable {
 		mi.Force = true
 	}
 
@@ -151,8 +214,15 @@ func executeMigration(ctx context.Context, server *Server, task *api.Task, state
 	if setup {
 		return 0, "", common.Errorf(common.MigrationSchemaMissing, "missing migration schema for instance %q", task.Instance.Name)
 	}
+	// Upgrade the migration schema set up by the earlier releases before recording the migration history.
+	if err := driver.SetupMigrationIfNeeded(ctx); err != nil {
+		return 0, "", errors.Wrapf(err, "failed to upgrade migration schema for instance %q", task.Instance.Name)
+	}
 
-	if task.Type == api.TaskDatabaseDataUpdate && task.Instance.Engine == db.MySQL {
+	// The binlog of the data update executed in chunks can't be filtered by the thread ID, since the chunks are
+	// executed on the pooled connections.
+	recordBinlog := task.Type == api.TaskDatabaseDataUpdate && task.Instance.Engine == db.MySQL && mi.ExecuteFunc == nil
+	if recordBinlog {
 		updatedTask, err := setThreadIDAndStartBinlogCoordinate(ctx, driver, task, server.store)
 		if err != nil {
 			return 0, "", errors.Wrap(err, "failed to update the task payload for MySQL rollback SQL")
@@ -165,7 +235,7 @@ func executeMigration(ctx context.Context, server *Server, task *api.Task, state
 		return 0, "", err
 	}
 
-	if task.Type == api.TaskDatabaseDataUpdate && task.Instance.Engine == db.MySQL {
+	if recordBinlog {
 		_, err := setMigrationIDAndEndBinlogCoordinate(ctx, driver, task, server.store, migrationID)
 		if err != nil {
 			return 0, "", errors.Wrap(err, "failed to update the task payload for MySQL rollback SQL")
@@ -274,8 +344,18 @@ func postMigration(ctx context.Context, server *Server, task *api.Task, vcsPushE
 	// On the presence of schema path template and non-wildcard branch filter, We write back the latest schema after migration for VCS-based projects for
 	// 1) baseline migration for SDL,
 	// 2) all DDL/Ghost migrations.
+	// For the repository using branch mapping, we write back to the branch of the push event instead.
 	writeBack := false
-	if repo != nil && repo.SchemaPathTemplate != "" && !strings.Contains(repo.BranchFilter, "*") {
+	writeBackBranch := ""
+	if repo != nil {
+		writeBackBranch = repo.BranchFilter
+		if repo.BranchMapping.Enabled() && vcsPushEvent != nil {
+			if branch, err := parseBranchNameFromRefs(vcsPushEvent.Ref); err == nil {
+				writeBackBranch = branch
+			}
+		}
+	}
+	if repo != nil && repo.SchemaPathTemplate != "" && !strings.Contains(writeBackBranch, "*") {
 		if repo.Project.SchemaChangeType == api.ProjectSchemaChangeTypeSDL {
 			if task.Type == api.TaskDatabaseSchemaBaseline {
 				writeBack = true
@@ -339,7 +419,7 @@ func postMigration(ctx context.Context, server *Server, task *api.Task, vcsPushE
 			bytebaseURL = fmt.Sprintf("%s/issue/%s?stage=%d", server.profile.ExternalURL, api.IssueSlug(issue), task.StageID)
 		}
 
-		commitID, err := writeBackLatestSchema(ctx, server, repo, vcsPushEvent, mi, repo.BranchFilter, latestSchemaFile, schema, bytebaseURL)
+		commitID, err := writeBackLatestSchema(ctx, server, repo, vcsPushEvent, mi, writeBackBranch, latestSchemaFile, schema, bytebaseURL)
 		if err != nil {
 			return true, nil, err
 		}
@@ -350,7 +430,7 @@ func postMigration(ctx context.Context, server *Server, task *api.Task, vcsPushE
 				TaskID:             task.ID,
 				VCSInstanceURL:     repo.VCS.InstanceURL,
 				RepositoryFullPath: repo.FullPath,
-				Branch:             repo.BranchFilter,
+				Branch:             writeBackBranch,
 				FilePath:           latestSchemaFile,
 				CommitID:           commitID,
 			})
@@ -19,15 +19,23 @@ func NewDataUpdateTaskExecutor() TaskExecutor {
 // DataUpdateTaskExecutor is the data update (DML) task executor.
 type DataUpdateTaskExecutor struct {
 	completed int32
+	progress  statementProgress
 }
 
 // RunOnce will run the data update (DML) task executor once.
-func (*DataUpdateTaskExecutor) RunOnce(ctx context.Context, server *Server, task *api.Task) (terminated bool, result *api.TaskRunResultPayload, err error) {
+func (exec *DataUpdateTaskExecutor) RunOnce(ctx context.Context, server *Server, task *api.Task) (terminated bool, result *api.TaskRunResultPayload, err error) {
 	payload := &api.TaskDatabaseDataUpdatePayload{}
 	if err := json.Unmarshal([]byte(task.Payload), payload); err != nil {
 		return true, nil, errors.Wrap(err, "invalid database data update payload")
 	}
 
+	ctx = exec.progress.withReporter(ctx)
+	if payload.Chunk != nil {
+		return exec.runChunkedMigration(ctx, server, task, payload)
+	}
+	if payload.RowSnapshot {
+		return exec.runRowSnapshotMigration(ctx, server, task, payload)
+	}
 	return runMigration(ctx, server, task, db.Data, payload.Statement, payload.SchemaVersion, payload.VCSPushEvent)
 }
 
@@ -37,6 +45,6 @@ func (exec *DataUpdateTaskExecutor) IsCompleted() bool {
 }
 
 // GetProgress returns the task progress.
-func (*DataUpdateTaskExecutor) GetProgress() api.Progress {
-	return api.Progress{}
+func (exec *DataUpdateTaskExecutor) GetProgress() api.Progress {
+	return exec.progress.get()
 }
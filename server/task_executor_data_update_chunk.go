@@ -0,0 +1,558 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	pgquery "github.com/pganalyze/pg_query_go/v2"
+	tidbparser "github.com/pingcap/tidb/parser"
+	tidbast "github.com/pingcap/tidb/parser/ast"
+	"github.com/pingcap/tidb/parser/format"
+	"github.com/pingcap/tidb/parser/opcode"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common/log"
+	"github.com/bytebase/bytebase/plugin/advisor/catalog"
+	"github.com/bytebase/bytebase/plugin/db"
+)
+
+const (
+	// dataUpdateChunkThrottleInterval is the interval to check the replica lag while the chunked execution is throttled.
+	dataUpdateChunkThrottleInterval = time.Duration(1) * time.Second
+)
+
+// dataUpdateChunkStatement is a single-table UPDATE or DELETE statement with a WHERE clause, which can be executed
+// in primary key ranged chunks.
+type dataUpdateChunkStatement struct {
+	engine    db.Type
+	statement string
+	// databaseName is the database qualifying the table for MySQL and TiDB, empty if not qualified.
+	databaseName string
+	// schemaName is empty for MySQL and TiDB.
+	schemaName string
+	tableName  string
+}
+
+// chunkKeyQuery is the queries to split a table into primary key ranged chunks.
+// The chunks cover the whole primary key range of the table in order, and a chunk covers [begin, end] where end is
+// the chunk size-th key from begin. The rows inserted beyond the last key after the chunks start are not covered.
+type chunkKeyQuery struct {
+	// firstBegin selects the first key of the table.
+	firstBegin string
+	// nextBegin selects the first key after the end of the last chunk.
+	nextBegin string
+	// end selects the end of the chunk from its begin, and lastEnd selects the last key of the table if the chunk
+	// has fewer rows than the chunk size.
+	end     string
+	lastEnd string
+}
+
+// dataUpdateChunkQuery is the queries to execute a data update statement in primary key ranged chunks.
+type dataUpdateChunkQuery struct {
+	chunkKeyQuery
+	// chunk is the statement restricted to the primary key range of the chunk, which takes begin and end as args.
+	chunk string
+}
+
+// parseDataUpdateChunkStatement checks the statement can be executed in chunks and finds the table it updates.
+func parseDataUpdateChunkStatement(engine db.Type, statement string) (*dataUpdateChunkStatement, error) {
+	s := &dataUpdateChunkStatement{
+		engine:    engine,
+		statement: statement,
+	}
+	switch engine {
+	case db.MySQL, db.TiDB:
+		_, table, _, err := parseMySQLDataUpdate(statement)
+		if err != nil {
+			return nil, err
+		}
+		s.databaseName = table.Schema.O
+		s.tableName = table.Name.O
+	case db.Postgres:
+		_, table, _, err := parsePGDataUpdate(statement)
+		if err != nil {
+			return nil, err
+		}
+		s.schemaName = table.Schemaname
+		if s.schemaName == "" {
+			s.schemaName = "public"
+		}
+		s.tableName = table.Relname
+	default:
+		return nil, errors.Errorf("chunked data update is not supported for %s", engine)
+	}
+	return s, nil
+}
+
+// parseMySQLDataUpdate parses the single-table UPDATE or DELETE statement and returns the statement node, the table
+// and the WHERE clause of the statement.
+func parseMySQLDataUpdate(statement string) (tidbast.StmtNode, *tidbast.TableName, *tidbast.ExprNode, error) {
+	stmtList, _, err := tidbparser.New().Parse(statement, "", "")
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "failed to parse the statement")
+	}
+	if len(stmtList) != 1 {
+		return nil, nil, nil, errors.Errorf("chunked data update requires exactly one statement, got %d", len(stmtList))
+	}
+	var tableRefs *tidbast.TableRefsClause
+	var where *tidbast.ExprNode
+	switch stmt := stmtList[0].(type) {
+	case *tidbast.UpdateStmt:
+		if stmt.MultipleTable || stmt.With != nil {
+			return nil, nil, nil, errors.New("chunked data update requires a single-table UPDATE statement")
+		}
+		if stmt.Order != nil || stmt.Limit != nil {
+			return nil, nil, nil, errors.New("chunked data update doesn't support ORDER BY or LIMIT")
+		}
+		tableRefs, where = stmt.TableRefs, &stmt.Where
+	case *tidbast.DeleteStmt:
+		if stmt.IsMultiTable || stmt.With != nil {
+			return nil, nil, nil, errors.New("chunked data update requires a single-table DELETE statement")
+		}
+		if stmt.Order != nil || stmt.Limit != nil {
+			return nil, nil, nil, errors.New("chunked data update doesn't support ORDER BY or LIMIT")
+		}
+		tableRefs, where = stmt.TableRefs, &stmt.Where
+	default:
+		return nil, nil, nil, errors.New("chunked data update requires an UPDATE or DELETE statement")
+	}
+	if *where == nil {
+		return nil, nil, nil, errors.New("chunked data update requires a WHERE clause")
+	}
+	if tableRefs == nil || tableRefs.TableRefs == nil || tableRefs.TableRefs.Right != nil {
+		return nil, nil, nil, errors.New("chunked data update requires a single table")
+	}
+	source, ok := tableRefs.TableRefs.Left.(*tidbast.TableSource)
+	if !ok {
+		return nil, nil, nil, errors.New("chunked data update requires a single table")
+	}
+	table, ok := source.Source.(*tidbast.TableName)
+	if !ok {
+		return nil, nil, nil, errors.New("chunked data update requires a single table")
+	}
+	return stmtList[0], table, where, nil
+}
+
+// parsePGDataUpdate parses the single-table UPDATE or DELETE statement and returns the parse result, the table
+// and the WHERE clause of the statement.
+func parsePGDataUpdate(statement string) (*pgquery.ParseResult, *pgquery.RangeVar, **pgquery.Node, error) {
+	result, err := pgquery.Parse(statement)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "failed to parse the statement")
+	}
+	if len(result.Stmts) != 1 {
+		return nil, nil, nil, errors.Errorf("chunked data update requires exactly one statement, got %d", len(result.Stmts))
+	}
+	var table *pgquery.RangeVar
+	var where **pgquery.Node
+	switch node := result.Stmts[0].Stmt.Node.(type) {
+	case *pgquery.Node_UpdateStmt:
+		if len(node.UpdateStmt.FromClause) > 0 || node.UpdateStmt.WithClause != nil {
+			return nil, nil, nil, errors.New("chunked data update requires a single-table UPDATE statement")
+		}
+		table, where = node.UpdateStmt.Relation, &node.UpdateStmt.WhereClause
+	case *pgquery.Node_DeleteStmt:
+		if len(node.DeleteStmt.UsingClause) > 0 || node.DeleteStmt.WithClause != nil {
+			return nil, nil, nil, errors.New("chunked data update requires a single-table DELETE statement")
+		}
+		table, where = node.DeleteStmt.Relation, &node.DeleteStmt.WhereClause
+	default:
+		return nil, nil, nil, errors.New("chunked data update requires an UPDATE or DELETE statement")
+	}
+	if *where == nil {
+		return nil, nil, nil, errors.New("chunked data update requires a WHERE clause")
+	}
+	return result, table, where, nil
+}
+
+// getDataUpdateChunkQuery returns the queries to execute the statement in chunks by the primary key column pk.
+func getDataUpdateChunkQuery(s *dataUpdateChunkStatement, pk string, chunkSize int) (*dataUpdateChunkQuery, error) {
+	switch s.engine {
+	case db.MySQL, db.TiDB:
+		table, column := quoteMySQLIdentifier(s.tableName), quoteMySQLIdentifier(pk)
+		chunk, err := getMySQLChunkStatement(s.statement, fmt.Sprintf("%s >= ? AND %s <= ?", column, column))
+		if err != nil {
+			return nil, err
+		}
+		return &dataUpdateChunkQuery{
+			chunkKeyQuery: chunkKeyQuery{
+				firstBegin: fmt.Sprintf("SELECT MIN(%s) FROM %s", column, table),
+				nextBegin:  fmt.Sprintf("SELECT MIN(%s) FROM %s WHERE %s > ?", column, table, column),
+				end:        fmt.Sprintf("SELECT %s FROM %s WHERE %s >= ? ORDER BY %s LIMIT 1 OFFSET %d", column, table, column, column, chunkSize-1),
+				lastEnd:    fmt.Sprintf("SELECT MAX(%s) FROM %s WHERE %s >= ?", column, table, column),
+			},
+			chunk: chunk,
+		}, nil
+	case db.Postgres:
+		table := fmt.Sprintf("%s.%s", quotePGIdentifier(s.schemaName), quotePGIdentifier(s.tableName))
+		column := quotePGIdentifier(pk)
+		chunk, err := getPGChunkStatement(s.statement, fmt.Sprintf("%s >= $1 AND %s <= $2", column, column))
+		if err != nil {
+			return nil, err
+		}
+		return &dataUpdateChunkQuery{
+			chunkKeyQuery: getPGChunkKeyQuery(table, column, chunkSize),
+			chunk:         chunk,
+		}, nil
+	default:
+		return nil, errors.Errorf("chunked data update is not supported for %s", s.engine)
+	}
+}
+
+// getPGChunkKeyQuery returns the queries to split the qualified table into chunks by the quoted primary key column.
+func getPGChunkKeyQuery(table string, column string, chunkSize int) chunkKeyQuery {
+	return chunkKeyQuery{
+		firstBegin: fmt.Sprintf("SELECT MIN(%s) FROM %s", column, table),
+		nextBegin:  fmt.Sprintf("SELECT MIN(%s) FROM %s WHERE %s > $1", column, table, column),
+		end:        fmt.Sprintf("SELECT %s FROM %s WHERE %s >= $1 ORDER BY %s LIMIT 1 OFFSET %d", column, table, column, column, chunkSize-1),
+		lastEnd:    fmt.Sprintf("SELECT MAX(%s) FROM %s WHERE %s >= $1", column, table, column),
+	}
+}
+
+// getChunkRange returns the key range [begin, end] of the chunk after lastKey, or of the first chunk if lastKey is nil.
+// ok is false if there are no more rows after lastKey.
+func (q *chunkKeyQuery) getChunkRange(ctx context.Context, sqlDB *sql.DB, lastKey *string) (begin string, end string, ok bool, err error) {
+	var nullBegin sql.NullString
+	if lastKey == nil {
+		if err := sqlDB.QueryRowContext(ctx, q.firstBegin).Scan(&nullBegin); err != nil {
+			return "", "", false, errors.Wrap(err, "failed to find the begin of the first chunk")
+		}
+	} else if err := sqlDB.QueryRowContext(ctx, q.nextBegin, *lastKey).Scan(&nullBegin); err != nil {
+		return "", "", false, errors.Wrap(err, "failed to find the begin of the next chunk")
+	}
+	if !nullBegin.Valid {
+		return "", "", false, nil
+	}
+	if err := sqlDB.QueryRowContext(ctx, q.end, nullBegin.String).Scan(&end); err != nil {
+		if err != sql.ErrNoRows {
+			return "", "", false, errors.Wrap(err, "failed to find the end of the chunk")
+		}
+		if err := sqlDB.QueryRowContext(ctx, q.lastEnd, nullBegin.String).Scan(&end); err != nil {
+			return "", "", false, errors.Wrap(err, "failed to find the end of the last chunk")
+		}
+	}
+	return nullBegin.String, end, true, nil
+}
+
+// getMySQLChunkStatement returns the statement with its WHERE clause ANDed with the condition.
+func getMySQLChunkStatement(statement string, condition string) (string, error) {
+	stmt, _, where, err := parseMySQLDataUpdate(statement)
+	if err != nil {
+		return "", err
+	}
+	conditionStmtList, _, err := tidbparser.New().Parse(fmt.Sprintf("SELECT 1 FROM t WHERE %s", condition), "", "")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse the chunk condition %q", condition)
+	}
+	*where = &tidbast.BinaryOperationExpr{
+		Op: opcode.LogicAnd,
+		L:  &tidbast.ParenthesesExpr{Expr: *where},
+		R:  conditionStmtList[0].(*tidbast.SelectStmt).Where,
+	}
+	var buf strings.Builder
+	if err := stmt.Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &buf)); err != nil {
+		return "", errors.Wrap(err, "failed to restore the chunk statement")
+	}
+	return buf.String(), nil
+}
+
+// getPGChunkStatement returns the statement with its WHERE clause ANDed with the condition.
+func getPGChunkStatement(statement string, condition string) (string, error) {
+	result, _, where, err := parsePGDataUpdate(statement)
+	if err != nil {
+		return "", err
+	}
+	conditionResult, err := pgquery.Parse(fmt.Sprintf("SELECT 1 FROM t WHERE %s", condition))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse the chunk condition %q", condition)
+	}
+	*where = pgquery.MakeBoolExprNode(pgquery.BoolExprType_AND_EXPR, []*pgquery.Node{
+		*where,
+		conditionResult.Stmts[0].Stmt.GetSelectStmt().WhereClause,
+	}, 0)
+	chunk, err := pgquery.Deparse(result)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to deparse the chunk statement")
+	}
+	return chunk, nil
+}
+
+func quoteMySQLIdentifier(name string) string {
+	return fmt.Sprintf("`%s`", strings.ReplaceAll(name, "`", "``"))
+}
+
+func quotePGIdentifier(name string) string {
+	return fmt.Sprintf(`"%s"`, strings.ReplaceAll(name, `"`, `""`))
+}
+
+// runChunkedMigration runs the data update migration in primary key ranged chunks.
+// The migration history is recorded once for all the chunks. If a chunk fails, the task fails and the cursor in the
+// task payload points to the last executed chunk, so that the rerun of the task resumes from the next chunk.
+func (exec *DataUpdateTaskExecutor) runChunkedMigration(ctx context.Context, server *Server, task *api.Task, payload *api.TaskDatabaseDataUpdatePayload) (terminated bool, result *api.TaskRunResultPayload, err error) {
+	if err := payload.Chunk.Validate(); err != nil {
+		return true, nil, errors.Wrap(err, "invalid chunk option")
+	}
+	mi, err := preMigration(ctx, server, task, db.Data, payload.Statement, payload.SchemaVersion, payload.VCSPushEvent)
+	if err != nil {
+		return true, nil, err
+	}
+	stmt, err := parseDataUpdateChunkStatement(task.Instance.Engine, strings.TrimSpace(payload.Statement))
+	if err != nil {
+		return true, nil, err
+	}
+	if stmt.databaseName != "" && stmt.databaseName != task.Database.Name {
+		return true, nil, errors.Errorf("chunked data update can't update table %q in another database %q", stmt.tableName, stmt.databaseName)
+	}
+	pk, err := getDataUpdateChunkPrimaryKey(ctx, server, task, stmt)
+	if err != nil {
+		return true, nil, err
+	}
+	query, err := getDataUpdateChunkQuery(stmt, pk, payload.Chunk.ChunkSize)
+	if err != nil {
+		return true, nil, err
+	}
+	totalChunk := exec.estimateTotalChunk(ctx, server, task, stmt, payload.Chunk.ChunkSize)
+
+	mi.ExecuteFunc = func(ctx context.Context, sqlDB *sql.DB) error {
+		lagDB := sqlDB
+		if payload.Chunk.MaxReplicaLagSeconds > 0 && task.Instance.Engine == db.MySQL {
+			// The replica lag of MySQL is only visible on the replica.
+			if dataSource := api.DataSourceFromInstanceWithType(task.Instance, api.RO); dataSource == nil || dataSource.HostOverride == "" {
+				return errors.New("checking the replica lag requires a read-only data source with the host override pointing to the replica")
+			}
+			driver, err := tryGetReadOnlyDatabaseDriver(ctx, task.Instance, "")
+			if err != nil {
+				return errors.Wrap(err, "failed to connect to the replica")
+			}
+			defer driver.Close(ctx)
+			if lagDB, err = driver.GetDBConnection(ctx, ""); err != nil {
+				return errors.Wrap(err, "failed to connect to the replica")
+			}
+		}
+		return exec.executeChunks(ctx, server, task, payload, sqlDB, lagDB, pk, query, totalChunk)
+	}
+	migrationID, schema, err := executeMigration(ctx, server, task, payload.Statement, mi)
+	if err != nil {
+		return true, nil, err
+	}
+	return postMigration(ctx, server, task, payload.VCSPushEvent, mi, migrationID, schema)
+}
+
+// getDataUpdateChunkPrimaryKey finds the primary key column of the updated table in the catalog.
+// Only the single-column primary key is supported.
+func getDataUpdateChunkPrimaryKey(ctx context.Context, server *Server, task *api.Task, stmt *dataUpdateChunkStatement) (string, error) {
+	c, err := server.store.NewCatalog(ctx, task.Database.ID, task.Instance.Engine)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get the catalog")
+	}
+	if c == nil {
+		return "", errors.Errorf("failed to find the catalog of database %q", task.Database.Name)
+	}
+	index := c.GetFinder().Origin.FindPrimaryKey(&catalog.PrimaryKeyFind{
+		SchemaName: stmt.schemaName,
+		TableName:  stmt.tableName,
+	})
+	if index == nil {
+		return "", errors.Errorf("table %q has no primary key, or it's not synced yet", stmt.tableName)
+	}
+	columnList := index.ExpressionList()
+	if len(columnList) != 1 {
+		return "", errors.Errorf("chunked data update requires a single-column primary key, but the primary key of table %q has %d columns", stmt.tableName, len(columnList))
+	}
+	return columnList[0], nil
+}
+
+// estimateTotalChunk estimates the number of chunks with the synced row count of the table. 0 means unknown.
+func (*DataUpdateTaskExecutor) estimateTotalChunk(ctx context.Context, server *Server, task *api.Task, stmt *dataUpdateChunkStatement, chunkSize int) int {
+	tableName := stmt.tableName
+	if stmt.schemaName != "" {
+		tableName = fmt.Sprintf("%s.%s", stmt.schemaName, stmt.tableName)
+	}
+	tableList, err := server.store.FindTable(ctx, &api.TableFind{
+		DatabaseID: &task.Database.ID,
+		Name:       &tableName,
+	})
+	if err != nil || len(tableList) != 1 {
+		return 0
+	}
+	return int((tableList[0].RowCount + int64(chunkSize) - 1) / int64(chunkSize))
+}
+
+// executeChunks executes the chunks after the cursor one by one. Each chunk is committed in its own transaction and
+// the cursor is saved into the task payload after it.
+func (exec *DataUpdateTaskExecutor) executeChunks(ctx context.Context, server *Server, task *api.Task, payload *api.TaskDatabaseDataUpdatePayload, sqlDB *sql.DB, lagDB *sql.DB, pk string, query *dataUpdateChunkQuery, totalChunk int) error {
+	startedAt := time.Now()
+	resumedChunk := 0
+	if payload.ChunkCursor != nil {
+		resumedChunk = payload.ChunkCursor.ExecutedChunk
+	}
+	for {
+		if payload.Chunk.MaxReplicaLagSeconds > 0 {
+			if err := exec.waitForReplicaLag(ctx, task.Instance.Engine, lagDB, payload.Chunk.MaxReplicaLagSeconds); err != nil {
+				return err
+			}
+		}
+
+		var lastKey *string
+		if payload.ChunkCursor != nil {
+			lastKey = &payload.ChunkCursor.LastKey
+		}
+		begin, end, ok, err := query.getChunkRange(ctx, sqlDB, lastKey)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			// No more rows after the last chunk.
+			return nil
+		}
+
+		cursor := api.DataUpdateChunkCursor{}
+		if payload.ChunkCursor != nil {
+			cursor = *payload.ChunkCursor
+		}
+		exec.progress.update(func(progress *api.Progress) {
+			progress.TotalUnit = int64(totalChunk)
+			if progress.TotalUnit <= int64(cursor.ExecutedChunk) {
+				// The row count is synced periodically and may be stale.
+				progress.TotalUnit = int64(cursor.ExecutedChunk + 1)
+			}
+			progress.CompletedUnit = int64(cursor.ExecutedChunk)
+			progress.CurrentStatement = fmt.Sprintf("Chunk %d: %s in [%s, %s]", cursor.ExecutedChunk+1, pk, begin, end)
+			if executed := cursor.ExecutedChunk - resumedChunk; executed > 0 {
+				perChunk := time.Since(startedAt) / time.Duration(executed)
+				progress.ETASeconds = int64(perChunk.Seconds() * float64(progress.TotalUnit-progress.CompletedUnit))
+			}
+		})
+		sqlResult, err := sqlDB.ExecContext(ctx, query.chunk, begin, end)
+		if err != nil {
+			return errors.Wrapf(err, "failed to execute chunk %d with %s in [%s, %s]", cursor.ExecutedChunk+1, pk, begin, end)
+		}
+		affectedRows, err := sqlResult.RowsAffected()
+		if err != nil {
+			return errors.Wrap(err, "failed to get the affected rows of the chunk")
+		}
+		cursor.LastKey = end
+		cursor.ExecutedChunk++
+		cursor.AffectedRows += affectedRows
+		payload.ChunkCursor = &cursor
+		if err := saveDataUpdateChunkCursor(ctx, server, task, payload); err != nil {
+			return err
+		}
+		exec.progress.update(func(progress *api.Progress) {
+			progress.CompletedUnit = int64(cursor.ExecutedChunk)
+		})
+
+		if payload.Chunk.PauseMilliseconds > 0 {
+			select {
+			case <-time.After(time.Duration(payload.Chunk.PauseMilliseconds) * time.Millisecond):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// saveDataUpdateChunkCursor saves the chunk cursor into the task payload.
+func saveDataUpdateChunkCursor(ctx context.Context, server *Server, task *api.Task, payload *api.TaskDatabaseDataUpdatePayload) error {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal task payload")
+	}
+	payloadString := string(payloadBytes)
+	if _, err := server.store.PatchTask(ctx, &api.TaskPatch{
+		ID:        task.ID,
+		UpdaterID: api.SystemBotID,
+		Payload:   &payloadString,
+	}); err != nil {
+		return errors.Wrapf(err, "failed to patch task %d with the chunk cursor", task.ID)
+	}
+	return nil
+}
+
+// waitForReplicaLag waits until the replica lag is within maxLagSeconds.
+func (exec *DataUpdateTaskExecutor) waitForReplicaLag(ctx context.Context, engine db.Type, lagDB *sql.DB, maxLagSeconds int) error {
+	for {
+		lag, err := getReplicaLagSeconds(ctx, engine, lagDB)
+		if err != nil {
+			return errors.Wrap(err, "failed to check the replica lag")
+		}
+		comment := ""
+		if lag > float64(maxLagSeconds) {
+			comment = fmt.Sprintf("Throttled as the replica lag %.1fs exceeds %ds", lag, maxLagSeconds)
+		}
+		progressPayload, err := json.Marshal(api.ProgressPayload{Comment: comment})
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal progress payload")
+		}
+		exec.progress.update(func(progress *api.Progress) {
+			progress.Payload = string(progressPayload)
+		})
+		if comment == "" {
+			return nil
+		}
+		log.Debug(comment, zap.Float64("lag", lag))
+
+		select {
+		case <-time.After(dataUpdateChunkThrottleInterval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// getReplicaLagSeconds returns the replica lag in seconds.
+// For Postgres, it's the max replay lag of the replicas on the primary. For MySQL, it's the lag on the replica.
+func getReplicaLagSeconds(ctx context.Context, engine db.Type, lagDB *sql.DB) (float64, error) {
+	switch engine {
+	case db.Postgres:
+		var lag float64
+		if err := lagDB.QueryRowContext(ctx, "SELECT COALESCE(EXTRACT(EPOCH FROM MAX(replay_lag)), 0) FROM pg_stat_replication").Scan(&lag); err != nil {
+			return 0, err
+		}
+		return lag, nil
+	case db.MySQL:
+		rows, err := lagDB.QueryContext(ctx, "SHOW SLAVE STATUS")
+		if err != nil {
+			return 0, err
+		}
+		defer rows.Close()
+		columnList, err := rows.Columns()
+		if err != nil {
+			return 0, err
+		}
+		if !rows.Next() {
+			if err := rows.Err(); err != nil {
+				return 0, err
+			}
+			return 0, errors.New("the read-only data source is not a replica")
+		}
+		valueList := make([]sql.NullString, len(columnList))
+		valuePtrList := make([]interface{}, len(columnList))
+		for i := range valueList {
+			valuePtrList[i] = &valueList[i]
+		}
+		if err := rows.Scan(valuePtrList...); err != nil {
+			return 0, err
+		}
+		for i, column := range columnList {
+			if column != "Seconds_Behind_Master" {
+				continue
+			}
+			if !valueList[i].Valid {
+				return 0, errors.New("the replication is not running on the replica")
+			}
+			return strconv.ParseFloat(valueList[i].String, 64)
+		}
+		return 0, errors.New("failed to find Seconds_Behind_Master in the replica status")
+	default:
+		// There is no replica lag to check, e.g. TiDB replicates with Raft.
+		return 0, nil
+	}
+}
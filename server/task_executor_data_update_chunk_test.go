@@ -0,0 +1,113 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/bytebase/bytebase/plugin/db"
+)
+
+func TestGetDataUpdateChunkQuery(t *testing.T) {
+	tests := []struct {
+		engine    db.Type
+		statement string
+		want      *dataUpdateChunkQuery
+		wantErr   bool
+	}{
+		{
+			engine:    db.MySQL,
+			statement: "UPDATE t SET a = 1 WHERE b = 2 OR c = 3",
+			want: &dataUpdateChunkQuery{
+				chunkKeyQuery: chunkKeyQuery{
+					firstBegin: "SELECT MIN(`id`) FROM `t`",
+					nextBegin:  "SELECT MIN(`id`) FROM `t` WHERE `id` > ?",
+					end:        "SELECT `id` FROM `t` WHERE `id` >= ? ORDER BY `id` LIMIT 1 OFFSET 999",
+					lastEnd:    "SELECT MAX(`id`) FROM `t` WHERE `id` >= ?",
+				},
+				chunk: "UPDATE `t` SET `a`=1 WHERE (`b`=2 OR `c`=3) AND `id`>=? AND `id`<=?",
+			},
+		},
+		{
+			engine:    db.MySQL,
+			statement: "DELETE FROM t WHERE b < 10;",
+			want: &dataUpdateChunkQuery{
+				chunkKeyQuery: chunkKeyQuery{
+					firstBegin: "SELECT MIN(`id`) FROM `t`",
+					nextBegin:  "SELECT MIN(`id`) FROM `t` WHERE `id` > ?",
+					end:        "SELECT `id` FROM `t` WHERE `id` >= ? ORDER BY `id` LIMIT 1 OFFSET 999",
+					lastEnd:    "SELECT MAX(`id`) FROM `t` WHERE `id` >= ?",
+				},
+				chunk: "DELETE FROM `t` WHERE (`b`<10) AND `id`>=? AND `id`<=?",
+			},
+		},
+		{
+			engine:    db.Postgres,
+			statement: "UPDATE s.t SET a = 1 WHERE b = 2 OR c = 3",
+			want: &dataUpdateChunkQuery{
+				chunkKeyQuery: chunkKeyQuery{
+					firstBegin: `SELECT MIN("id") FROM "s"."t"`,
+					nextBegin:  `SELECT MIN("id") FROM "s"."t" WHERE "id" > $1`,
+					end:        `SELECT "id" FROM "s"."t" WHERE "id" >= $1 ORDER BY "id" LIMIT 1 OFFSET 999`,
+					lastEnd:    `SELECT MAX("id") FROM "s"."t" WHERE "id" >= $1`,
+				},
+				chunk: "UPDATE s.t SET a = 1 WHERE (b = 2 OR c = 3) AND (id >= $1 AND id <= $2)",
+			},
+		},
+		{
+			engine:    db.Postgres,
+			statement: "DELETE FROM t WHERE b < 10",
+			want: &dataUpdateChunkQuery{
+				chunkKeyQuery: chunkKeyQuery{
+					firstBegin: `SELECT MIN("id") FROM "public"."t"`,
+					nextBegin:  `SELECT MIN("id") FROM "public"."t" WHERE "id" > $1`,
+					end:        `SELECT "id" FROM "public"."t" WHERE "id" >= $1 ORDER BY "id" LIMIT 1 OFFSET 999`,
+					lastEnd:    `SELECT MAX("id") FROM "public"."t" WHERE "id" >= $1`,
+				},
+				chunk: "DELETE FROM t WHERE b < 10 AND (id >= $1 AND id <= $2)",
+			},
+		},
+		{
+			engine:    db.MySQL,
+			statement: "UPDATE t SET a = 1",
+			wantErr:   true,
+		},
+		{
+			engine:    db.MySQL,
+			statement: "DELETE FROM t WHERE b < 10 LIMIT 100",
+			wantErr:   true,
+		},
+		{
+			engine:    db.MySQL,
+			statement: "UPDATE t1 JOIN t2 ON t1.id = t2.id SET t1.a = 1 WHERE t2.b = 2",
+			wantErr:   true,
+		},
+		{
+			engine:    db.Postgres,
+			statement: "UPDATE t1 SET a = t2.a FROM t2 WHERE t1.id = t2.id",
+			wantErr:   true,
+		},
+		{
+			engine:    db.Postgres,
+			statement: "INSERT INTO t VALUES (1)",
+			wantErr:   true,
+		},
+		{
+			engine:    db.Postgres,
+			statement: "DELETE FROM t WHERE a = 1; DELETE FROM t WHERE a = 2",
+			wantErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		stmt, err := parseDataUpdateChunkStatement(test.engine, test.statement)
+		if test.wantErr {
+			require.Error(t, err, test.statement)
+			continue
+		}
+		require.NoError(t, err, test.statement)
+		query, err := getDataUpdateChunkQuery(stmt, "id", 1000)
+		require.NoError(t, err, test.statement)
+		require.Equal(t, test.want, query, test.statement)
+	}
+}
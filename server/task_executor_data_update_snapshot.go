@@ -0,0 +1,223 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	pgquery "github.com/pganalyze/pg_query_go/v2"
+	"github.com/pkg/errors"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/plugin/advisor/catalog"
+	"github.com/bytebase/bytebase/plugin/db"
+)
+
+const (
+	// pgRowSnapshotSchema is the schema holding the tables of the row snapshots in the database.
+	pgRowSnapshotSchema = "bbdataarchive"
+	// pgMaxIdentifierLength is the max length of a Postgres identifier, which is NAMEDATALEN - 1.
+	pgMaxIdentifierLength = 63
+)
+
+// pgRowSnapshot is the plan to snapshot the rows changed by the UPDATE and DELETE statements of a Postgres data update.
+type pgRowSnapshot struct {
+	// statement is the data update statement with the snapshot statements inserted before the UPDATE and DELETE
+	// statements, so that each snapshot is taken right before the change in the same transaction.
+	statement string
+	// tableList is the qualified names of the snapshot tables.
+	tableList []string
+	// compensatingStatement restores the changed rows from the snapshot tables.
+	compensatingStatement string
+}
+
+// getPGRowSnapshot plans the row snapshots of the data update statement of the task.
+// findPrimaryKey returns the primary key columns of the table, which are required to restore the updated rows.
+func getPGRowSnapshot(statement string, taskID int, findPrimaryKey func(schemaName, tableName string) []string) (*pgRowSnapshot, error) {
+	result, err := pgquery.Parse(statement)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the statement")
+	}
+
+	snapshot := &pgRowSnapshot{}
+	statementList := []string{fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s;", quotePGIdentifier(pgRowSnapshotSchema))}
+	var compensatingList []string
+	for _, rawStmt := range result.Stmts {
+		text := statement[rawStmt.StmtLocation:]
+		if rawStmt.StmtLen > 0 {
+			text = statement[rawStmt.StmtLocation : rawStmt.StmtLocation+rawStmt.StmtLen]
+		}
+		text = strings.TrimSpace(text)
+
+		var table *pgquery.RangeVar
+		var where *pgquery.Node
+		var updatedColumnList []string
+		switch node := rawStmt.Stmt.Node.(type) {
+		case *pgquery.Node_UpdateStmt:
+			if len(node.UpdateStmt.FromClause) > 0 || node.UpdateStmt.WithClause != nil {
+				return nil, errors.Errorf("row snapshot requires single-table UPDATE statements, got %q", text)
+			}
+			table, where = node.UpdateStmt.Relation, node.UpdateStmt.WhereClause
+			for _, target := range node.UpdateStmt.TargetList {
+				updatedColumnList = append(updatedColumnList, target.GetResTarget().Name)
+			}
+		case *pgquery.Node_DeleteStmt:
+			if len(node.DeleteStmt.UsingClause) > 0 || node.DeleteStmt.WithClause != nil {
+				return nil, errors.Errorf("row snapshot requires single-table DELETE statements, got %q", text)
+			}
+			table, where = node.DeleteStmt.Relation, node.DeleteStmt.WhereClause
+		default:
+			statementList = append(statementList, text+";")
+			continue
+		}
+
+		schemaName := table.Schemaname
+		if schemaName == "" {
+			schemaName = "public"
+		}
+		tableName := fmt.Sprintf("%s.%s", quotePGIdentifier(schemaName), quotePGIdentifier(table.Relname))
+		snapshotTableName := fmt.Sprintf("%s.%s", quotePGIdentifier(pgRowSnapshotSchema), quotePGIdentifier(getPGRowSnapshotTableName(taskID, len(snapshot.tableList)+1, table.Relname)))
+		selectStmt, err := getPGSnapshotSelectStatement(table, where)
+		if err != nil {
+			return nil, err
+		}
+		statementList = append(statementList, fmt.Sprintf("CREATE TABLE %s AS %s;", snapshotTableName, selectStmt), text+";")
+		snapshot.tableList = append(snapshot.tableList, snapshotTableName)
+
+		if updatedColumnList == nil {
+			compensatingList = append(compensatingList, fmt.Sprintf("INSERT INTO %s OVERRIDING SYSTEM VALUE SELECT * FROM %s;", tableName, snapshotTableName))
+			continue
+		}
+		pkList := findPrimaryKey(schemaName, table.Relname)
+		if len(pkList) == 0 {
+			return nil, errors.Errorf("row snapshot requires the primary key to restore the updated rows, but table %q has no primary key", tableName)
+		}
+		var setList, matchList []string
+		for _, column := range updatedColumnList {
+			for _, pk := range pkList {
+				if column == pk {
+					return nil, errors.Errorf("row snapshot can't restore the rows of table %q with the updated primary key %q", tableName, pk)
+				}
+			}
+			setList = append(setList, fmt.Sprintf("%s = bbsnapshot.%s", quotePGIdentifier(column), quotePGIdentifier(column)))
+		}
+		for _, pk := range pkList {
+			matchList = append(matchList, fmt.Sprintf("%s.%s = bbsnapshot.%s", tableName, quotePGIdentifier(pk), quotePGIdentifier(pk)))
+		}
+		compensatingList = append(compensatingList, fmt.Sprintf("UPDATE %s SET %s FROM %s AS bbsnapshot WHERE %s;", tableName, strings.Join(setList, ", "), snapshotTableName, strings.Join(matchList, " AND ")))
+	}
+	if len(snapshot.tableList) == 0 {
+		return nil, errors.New("row snapshot requires at least one UPDATE or DELETE statement")
+	}
+
+	// Restore in the reverse order of the changes.
+	for i := len(compensatingList) - 1; i >= 0; i-- {
+		snapshot.compensatingStatement += compensatingList[i] + "\n"
+	}
+	snapshot.statement = strings.Join(statementList, "\n")
+	return snapshot, nil
+}
+
+// getPGSnapshotSelectStatement returns the SELECT statement of the rows in the table matching the WHERE clause.
+func getPGSnapshotSelectStatement(table *pgquery.RangeVar, where *pgquery.Node) (string, error) {
+	result, err := pgquery.Parse("SELECT * FROM t")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse the snapshot statement")
+	}
+	selectStmt := result.Stmts[0].Stmt.GetSelectStmt()
+	selectStmt.FromClause = []*pgquery.Node{{Node: &pgquery.Node_RangeVar{RangeVar: table}}}
+	selectStmt.WhereClause = where
+	statement, err := pgquery.Deparse(result)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to deparse the snapshot statement")
+	}
+	return statement, nil
+}
+
+// getPGRowSnapshotTableName returns the name of the snapshot table, e.g. task123_1_employee for the first UPDATE or
+// DELETE statement of task 123 on table employee, truncated to the max identifier length.
+func getPGRowSnapshotTableName(taskID int, ordinal int, tableName string) string {
+	name := fmt.Sprintf("task%d_%d_%s", taskID, ordinal, tableName)
+	if len(name) > pgMaxIdentifierLength {
+		name = name[:pgMaxIdentifierLength]
+		for !utf8.ValidString(name) {
+			name = name[:len(name)-1]
+		}
+	}
+	return name
+}
+
+// validateRowSnapshot checks the row snapshot option of a data update.
+// The primary keys are checked when the task runs since they may change before then.
+func validateRowSnapshot(engine db.Type, statement string, chunk *api.DataUpdateChunk) error {
+	if engine != db.Postgres {
+		return errors.Errorf("row snapshot is not supported for %s", engine)
+	}
+	if chunk != nil {
+		return errors.New("row snapshot can't be used with the chunked execution")
+	}
+	// Use a placeholder primary key to check the statement only.
+	if _, err := getPGRowSnapshot(strings.TrimSpace(statement), 0, func(string, string) []string {
+		return []string{""}
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// runRowSnapshotMigration runs the Postgres data update migration with the row snapshots taken before the changes,
+// and saves the snapshot tables and the compensating statement into the task payload.
+func (*DataUpdateTaskExecutor) runRowSnapshotMigration(ctx context.Context, server *Server, task *api.Task, payload *api.TaskDatabaseDataUpdatePayload) (terminated bool, result *api.TaskRunResultPayload, err error) {
+	if task.Instance.Engine != db.Postgres {
+		return true, nil, errors.Errorf("row snapshot is not supported for %s", task.Instance.Engine)
+	}
+	if task.Database == nil {
+		return true, nil, errors.New("missing database when updating data")
+	}
+	c, err := server.store.NewCatalog(ctx, task.Database.ID, task.Instance.Engine)
+	if err != nil {
+		return true, nil, errors.Wrap(err, "failed to get the catalog")
+	}
+	if c == nil {
+		return true, nil, errors.Errorf("failed to find the catalog of database %q", task.Database.Name)
+	}
+	snapshot, err := getPGRowSnapshot(strings.TrimSpace(payload.Statement), task.ID, func(schemaName, tableName string) []string {
+		index := c.GetFinder().Origin.FindPrimaryKey(&catalog.PrimaryKeyFind{
+			SchemaName: schemaName,
+			TableName:  tableName,
+		})
+		if index == nil {
+			return nil
+		}
+		return index.ExpressionList()
+	})
+	if err != nil {
+		return true, nil, err
+	}
+
+	terminated, result, err = runMigration(ctx, server, task, db.Data, snapshot.statement, payload.SchemaVersion, payload.VCSPushEvent)
+	if err != nil {
+		return terminated, result, err
+	}
+
+	payload.RowSnapshotTableList = snapshot.tableList
+	payload.CompensatingStatement = snapshot.compensatingStatement
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return true, nil, errors.Wrap(err, "failed to marshal task payload")
+	}
+	payloadString := string(payloadBytes)
+	if _, err := server.store.PatchTask(ctx, &api.TaskPatch{
+		ID:        task.ID,
+		UpdaterID: api.SystemBotID,
+		Payload:   &payloadString,
+	}); err != nil {
+		return true, nil, errors.Wrapf(err, "failed to patch task %d with the row snapshot", task.ID)
+	}
+	if result != nil {
+		result.Detail = fmt.Sprintf("%s Snapshot the changed rows into %s.", result.Detail, strings.Join(snapshot.tableList, ", "))
+	}
+	return terminated, result, nil
+}
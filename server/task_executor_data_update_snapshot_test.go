@@ -0,0 +1,66 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPGRowSnapshot(t *testing.T) {
+	pkMap := map[string][]string{
+		"public.t": {"id"},
+		"s.u":      {"a", "b"},
+	}
+	findPrimaryKey := func(schemaName, tableName string) []string {
+		return pkMap[schemaName+"."+tableName]
+	}
+	tests := []struct {
+		statement string
+		want      *pgRowSnapshot
+		wantErr   bool
+	}{
+		{
+			statement: "UPDATE t SET x = 1, y = y + 1 WHERE z > 10;\nINSERT INTO v VALUES (1);\nDELETE FROM s.u AS uu WHERE uu.c = 'a'",
+			want: &pgRowSnapshot{
+				statement: `CREATE SCHEMA IF NOT EXISTS "bbdataarchive";
+CREATE TABLE "bbdataarchive"."task12_1_t" AS SELECT * FROM t WHERE z > 10;
+UPDATE t SET x = 1, y = y + 1 WHERE z > 10;
+INSERT INTO v VALUES (1);
+CREATE TABLE "bbdataarchive"."task12_2_u" AS SELECT * FROM s.u uu WHERE uu.c = 'a';
+DELETE FROM s.u AS uu WHERE uu.c = 'a';`,
+				tableList: []string{`"bbdataarchive"."task12_1_t"`, `"bbdataarchive"."task12_2_u"`},
+				compensatingStatement: `INSERT INTO "s"."u" OVERRIDING SYSTEM VALUE SELECT * FROM "bbdataarchive"."task12_2_u";
+UPDATE "public"."t" SET "x" = bbsnapshot."x", "y" = bbsnapshot."y" FROM "bbdataarchive"."task12_1_t" AS bbsnapshot WHERE "public"."t"."id" = bbsnapshot."id";
+`,
+			},
+		},
+		{
+			// No primary key to restore the updated rows.
+			statement: "UPDATE w SET x = 1",
+			wantErr:   true,
+		},
+		{
+			// The primary key is updated.
+			statement: "UPDATE t SET id = id + 1",
+			wantErr:   true,
+		},
+		{
+			statement: "UPDATE t SET x = v.x FROM v WHERE t.id = v.id",
+			wantErr:   true,
+		},
+		{
+			statement: "INSERT INTO t VALUES (1)",
+			wantErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		snapshot, err := getPGRowSnapshot(test.statement, 12, findPrimaryKey)
+		if test.wantErr {
+			require.Error(t, err, test.statement)
+			continue
+		}
+		require.NoError(t, err, test.statement)
+		require.Equal(t, test.want, snapshot, test.statement)
+	}
+}
@@ -108,6 +108,10 @@ func (exec *DatabaseCreateTaskExecutor) RunOnce(ctx context.Context, server *Ser
 		mi.IssueID = strconv.Itoa(issue.ID)
 	}
 
+	// Upgrade the migration schema set up by the earlier releases before recording the migration history.
+	if err := driver.SetupMigrationIfNeeded(ctx); err != nil {
+		return true, nil, errors.Wrapf(err, "failed to upgrade migration schema for instance %q", task.Instance.Name)
+	}
 	migrationID, _, err := driver.ExecuteMigration(ctx, mi, statement)
 	if err != nil {
 		return true, nil, err
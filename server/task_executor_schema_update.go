@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"sync/atomic"
 
 	"github.com/pkg/errors"
@@ -19,6 +20,7 @@ func NewSchemaUpdateTaskExecutor() TaskExecutor {
 // SchemaUpdateTaskExecutor is the schema update (DDL) task executor.
 type SchemaUpdateTaskExecutor struct {
 	completed int32
+	progress  statementProgress
 }
 
 // RunOnce will run the schema update (DDL) task executor once.
@@ -29,15 +31,100 @@ func (exec *SchemaUpdateTaskExecutor) RunOnce(ctx context.Context, server *Serve
 		return true, nil, errors.Wrap(err, "invalid database schema update payload")
 	}
 
+	ctx = exec.progress.withReporter(ctx)
+	if payload.RepeatableName != "" {
+		return runRepeatableMigration(ctx, server, task, payload)
+	}
 	return runMigration(ctx, server, task, db.Migrate, payload.Statement, payload.SchemaVersion, payload.VCSPushEvent)
 }
 
+// runRepeatableMigration applies the repeatable migration if its checksum differs from the one
+// last applied to the database, and skips it otherwise.
+func runRepeatableMigration(ctx context.Context, server *Server, task *api.Task, payload *api.TaskDatabaseSchemaUpdatePayload) (terminated bool, result *api.TaskRunResultPayload, err error) {
+	mi, err := preMigration(ctx, server, task, db.Repeatable, payload.Statement, payload.SchemaVersion, payload.VCSPushEvent)
+	if err != nil {
+		return true, nil, err
+	}
+
+	checksum := db.Checksum(payload.Statement)
+	appliedChecksum, err := getAppliedRepeatableMigrationChecksum(ctx, server, task, payload.RepeatableName)
+	if err != nil {
+		return true, nil, err
+	}
+	if appliedChecksum == checksum {
+		return true, &api.TaskRunResultPayload{
+			Detail:  fmt.Sprintf("Skipped repeatable migration %q for database %q as the checksum is unchanged.", payload.RepeatableName, task.Database.Name),
+			Version: mi.Version,
+		}, nil
+	}
+
+	miPayload := &db.MigrationInfoPayload{
+		VCSPushEvent:   payload.VCSPushEvent,
+		RepeatableName: payload.RepeatableName,
+	}
+	bytes, err := json.Marshal(miPayload)
+	if err != nil {
+		return true, nil, errors.Wrap(err, "failed to marshal repeatable migration payload")
+	}
+	mi.Payload = string(bytes)
+
+	migrationID, schema, err := executeMigration(ctx, server, task, payload.Statement, mi)
+	if err != nil {
+		return true, nil, err
+	}
+	return postMigration(ctx, server, task, payload.VCSPushEvent, mi, migrationID, schema)
+}
+
+// getAppliedRepeatableMigrationChecksum returns the checksum of the repeatable migration last applied
+// to the database, which is recorded in the checksum column of the migration history along with the
+// other migrations. It returns empty if the repeatable migration has never been applied.
+func getAppliedRepeatableMigrationChecksum(ctx context.Context, server *Server, task *api.Task, repeatableName string) (string, error) {
+	databaseName := task.Database.Name
+	driver, err := server.getAdminDatabaseDriver(ctx, task.Instance, databaseName)
+	if err != nil {
+		return "", err
+	}
+	defer driver.Close(ctx)
+
+	// Leave the missing migration schema to be reported by the migration execution.
+	setup, err := driver.NeedsSetupMigration(ctx)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to check migration setup for instance %q", task.Instance.Name)
+	}
+	if setup {
+		return "", nil
+	}
+
+	migrationType := db.Repeatable
+	historyList, err := driver.FindMigrationHistoryList(ctx, &db.MigrationHistoryFind{
+		Database: &databaseName,
+		Type:     &migrationType,
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to find repeatable migration history for database %q", databaseName)
+	}
+	// The migration history list is ordered by ID in descending order.
+	for _, history := range historyList {
+		if history.Status != db.Done {
+			continue
+		}
+		var historyPayload db.MigrationInfoPayload
+		if err := json.Unmarshal([]byte(history.Payload), &historyPayload); err != nil {
+			return "", errors.Wrapf(err, "failed to unmarshal the payload of migration history %d", history.ID)
+		}
+		if historyPayload.RepeatableName == repeatableName {
+			return history.Checksum, nil
+		}
+	}
+	return "", nil
+}
+
 // IsCompleted tells the scheduler if the task execution has completed.
 func (exec *SchemaUpdateTaskExecutor) IsCompleted() bool {
 	return atomic.LoadInt32(&exec.completed) == 1
 }
 
 // GetProgress returns the task progress.
-func (*SchemaUpdateTaskExecutor) GetProgress() api.Progress {
-	return api.Progress{}
+func (exec *SchemaUpdateTaskExecutor) GetProgress() api.Progress {
+	return exec.progress.get()
 }
@@ -52,6 +52,10 @@ func (exec *SchemaUpdateGhostCutoverTaskExecutor) RunOnce(ctx context.Context, s
 		return true, nil, errors.Wrap(err, "invalid database schema update gh-ost sync payload")
 	}
 
+	if task.Instance.Engine == db.Postgres {
+		return runPGOnlineCutover(ctx, server, task, syncTaskID, payload)
+	}
+
 	tableName, err := getTableNameFromStatement(payload.Statement)
 	if err != nil {
 		return true, nil, errors.Wrap(err, "failed to parse table name from statement")
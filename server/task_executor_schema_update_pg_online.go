@@ -0,0 +1,813 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgconn"
+	pgquery "github.com/pganalyze/pg_query_go/v2"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common/log"
+	"github.com/bytebase/bytebase/plugin/db"
+)
+
+const (
+	// pgOnlineMigrationChunkSize is the number of rows copied into the shadow table in a batch.
+	pgOnlineMigrationChunkSize = 1000
+	// pgOnlineMigrationLockTimeout is the lock_timeout of the cutover, which bounds how long the cutover and the
+	// writes queued behind it wait for the lock on the table.
+	pgOnlineMigrationLockTimeout = "3s"
+	// pgOnlineMigrationCutoverRetry is the number of the cutover attempts if it times out acquiring the lock.
+	pgOnlineMigrationCutoverRetry    = 10
+	pgOnlineMigrationCutoverInterval = time.Duration(1) * time.Second
+	// pgLockNotAvailableCode is the SQLSTATE of lock_not_available, which is raised by the lock timeout.
+	pgLockNotAvailableCode = "55P03"
+)
+
+// pgOnlineMigration is the plan of the trigger-based online schema change of a Postgres table.
+// The ALTER TABLE statement is applied to an empty shadow table, which is kept in sync with the table by a trigger
+// while the existing rows are copied in batches. The cutover swaps the tables by renaming the original table to the
+// old table, which is dropped after the cutover. The names follow the gh-ost convention with the sync task ID, e.g.
+// _employee_123_gho.
+type pgOnlineMigration struct {
+	syncTaskID int
+	schemaName string
+	tableName  string
+	// shadowTableName, oldTableName and triggerName are unqualified. The trigger function has the same name as the
+	// trigger.
+	shadowTableName string
+	oldTableName    string
+	triggerName     string
+	// alterShadowStatement is the ALTER TABLE statement on the shadow table.
+	alterShadowStatement string
+}
+
+// getPGOnlineMigration plans the online schema change of the ALTER TABLE statement of the sync task.
+func getPGOnlineMigration(statement string, syncTaskID int) (*pgOnlineMigration, error) {
+	result, err := pgquery.Parse(statement)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the statement")
+	}
+	if len(result.Stmts) != 1 {
+		return nil, errors.Errorf("online migration requires exactly one ALTER TABLE statement, got %d statements", len(result.Stmts))
+	}
+	node, ok := result.Stmts[0].Stmt.Node.(*pgquery.Node_AlterTableStmt)
+	if !ok || node.AlterTableStmt.Relkind != pgquery.ObjectType_OBJECT_TABLE {
+		return nil, errors.New("online migration requires an ALTER TABLE statement")
+	}
+	for _, cmd := range node.AlterTableStmt.Cmds {
+		alterCmd := cmd.GetAlterTableCmd()
+		if alterCmd == nil {
+			continue
+		}
+		switch alterCmd.Subtype {
+		case pgquery.AlterTableType_AT_AlterColumnType:
+			// The rows are copied into the shadow table with the assignment cast instead of the USING expression.
+			if columnDef := alterCmd.Def.GetColumnDef(); columnDef != nil && columnDef.RawDefault != nil {
+				return nil, errors.New("online migration doesn't support changing the column type with USING")
+			}
+		case pgquery.AlterTableType_AT_AddInherit, pgquery.AlterTableType_AT_DropInherit,
+			pgquery.AlterTableType_AT_AttachPartition, pgquery.AlterTableType_AT_DetachPartition:
+			return nil, errors.New("online migration doesn't support changing the inheritance or partitions")
+		}
+	}
+
+	relation := node.AlterTableStmt.Relation
+	m := &pgOnlineMigration{
+		syncTaskID:      syncTaskID,
+		schemaName:      relation.Schemaname,
+		tableName:       relation.Relname,
+		shadowTableName: getPGOnlineMigrationName(relation.Relname, syncTaskID, "gho"),
+		oldTableName:    getPGOnlineMigrationName(relation.Relname, syncTaskID, "del"),
+		triggerName:     getPGOnlineMigrationName(relation.Relname, syncTaskID, "sync"),
+	}
+	if m.schemaName == "" {
+		m.schemaName = "public"
+	}
+	node.AlterTableStmt.Relation = &pgquery.RangeVar{
+		Schemaname:     m.schemaName,
+		Relname:        m.shadowTableName,
+		Inh:            true,
+		Relpersistence: relation.Relpersistence,
+	}
+	if m.alterShadowStatement, err = pgquery.Deparse(result); err != nil {
+		return nil, errors.Wrap(err, "failed to deparse the statement on the shadow table")
+	}
+	return m, nil
+}
+
+// getPGOnlineMigrationName returns the name of the object for the table, e.g. _employee_123_gho, with the table name
+// truncated to keep the name within the max identifier length.
+func getPGOnlineMigrationName(tableName string, syncTaskID int, suffix string) string {
+	suffix = fmt.Sprintf("_%d_%s", syncTaskID, suffix)
+	if len(tableName)+len(suffix)+1 > pgMaxIdentifierLength {
+		tableName = tableName[:pgMaxIdentifierLength-len(suffix)-1]
+		for !utf8.ValidString(tableName) {
+			tableName = tableName[:len(tableName)-1]
+		}
+	}
+	return fmt.Sprintf("_%s%s", tableName, suffix)
+}
+
+func (m *pgOnlineMigration) qualify(name string) string {
+	return fmt.Sprintf("%s.%s", quotePGIdentifier(m.schemaName), quotePGIdentifier(name))
+}
+
+// getSetupStatementList returns the statements to create the shadow table from scratch.
+func (m *pgOnlineMigration) getSetupStatementList() []string {
+	table, shadowTable := m.qualify(m.tableName), m.qualify(m.shadowTableName)
+	return []string{
+		// Clean up the previous attempt of the sync task.
+		fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s", quotePGIdentifier(m.triggerName), table),
+		fmt.Sprintf("DROP FUNCTION IF EXISTS %s()", m.qualify(m.triggerName)),
+		fmt.Sprintf("DROP TABLE IF EXISTS %s", shadowTable),
+		fmt.Sprintf("CREATE TABLE %s (LIKE %s INCLUDING ALL)", shadowTable, table),
+		m.alterShadowStatement,
+	}
+}
+
+// getTriggerStatementList returns the statements to create the trigger replaying the changes of the table on the
+// shadow table by the primary key column pk. columnList is the columns copied into the shadow table.
+func (m *pgOnlineMigration) getTriggerStatementList(pk string, columnList []string) []string {
+	var quotedColumnList, newColumnList []string
+	for _, column := range columnList {
+		quotedColumnList = append(quotedColumnList, quotePGIdentifier(column))
+		newColumnList = append(newColumnList, fmt.Sprintf("NEW.%s", quotePGIdentifier(column)))
+	}
+	shadowTable := m.qualify(m.shadowTableName)
+	function := fmt.Sprintf(`CREATE FUNCTION %s() RETURNS trigger LANGUAGE plpgsql AS $bbonline$
+BEGIN
+  IF TG_OP IN ('UPDATE', 'DELETE') THEN
+    DELETE FROM %s WHERE %s = OLD.%s;
+  END IF;
+  IF TG_OP IN ('INSERT', 'UPDATE') THEN
+    INSERT INTO %s (%s) OVERRIDING SYSTEM VALUE VALUES (%s);
+  END IF;
+  RETURN NULL;
+END;
+$bbonline$`, m.qualify(m.triggerName), shadowTable, quotePGIdentifier(pk), quotePGIdentifier(pk), shadowTable, strings.Join(quotedColumnList, ", "), strings.Join(newColumnList, ", "))
+	// EXECUTE PROCEDURE instead of EXECUTE FUNCTION for Postgres 10 and before.
+	trigger := fmt.Sprintf("CREATE TRIGGER %s AFTER INSERT OR UPDATE OR DELETE ON %s FOR EACH ROW EXECUTE PROCEDURE %s()", quotePGIdentifier(m.triggerName), m.qualify(m.tableName), m.qualify(m.triggerName))
+	return []string{function, trigger}
+}
+
+// getBackfillStatement returns the statement copying the rows of the table in the primary key range [$1, $2] into
+// the shadow table. The rows are locked so that the trigger of a concurrent change always runs after the copy, and
+// the rows already copied by the trigger are skipped.
+func (m *pgOnlineMigration) getBackfillStatement(pk string, columnList []string) string {
+	var quotedColumnList []string
+	for _, column := range columnList {
+		quotedColumnList = append(quotedColumnList, quotePGIdentifier(column))
+	}
+	columns, column := strings.Join(quotedColumnList, ", "), quotePGIdentifier(pk)
+	return fmt.Sprintf("INSERT INTO %s (%s) OVERRIDING SYSTEM VALUE SELECT %s FROM %s WHERE %s >= $1 AND %s <= $2 FOR SHARE ON CONFLICT (%s) DO NOTHING",
+		m.qualify(m.shadowTableName), columns, columns, m.qualify(m.tableName), column, column, column)
+}
+
+// getCutoverStatementList returns the statements to swap the table with the shadow table.
+func (m *pgOnlineMigration) getCutoverStatementList() []string {
+	return []string{
+		fmt.Sprintf("SET LOCAL lock_timeout = '%s'", pgOnlineMigrationLockTimeout),
+		fmt.Sprintf("LOCK TABLE %s IN ACCESS EXCLUSIVE MODE", m.qualify(m.tableName)),
+		fmt.Sprintf("DROP TRIGGER %s ON %s", quotePGIdentifier(m.triggerName), m.qualify(m.tableName)),
+		fmt.Sprintf("DROP FUNCTION %s()", m.qualify(m.triggerName)),
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", m.qualify(m.tableName), quotePGIdentifier(m.oldTableName)),
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", m.qualify(m.shadowTableName), quotePGIdentifier(m.tableName)),
+	}
+}
+
+// getDropOldTableStatementList returns the statements to drop the old table after the cutover, along with its indexes.
+func (m *pgOnlineMigration) getDropOldTableStatementList() []string {
+	return []string{
+		fmt.Sprintf("SET LOCAL lock_timeout = '%s'", pgOnlineMigrationLockTimeout),
+		fmt.Sprintf("DROP TABLE %s", m.qualify(m.oldTableName)),
+	}
+}
+
+// getGrantStatement returns the statement to grant the privilege on the shadow table to the grantee, or PUBLIC if
+// the grantee is empty.
+func (m *pgOnlineMigration) getGrantStatement(grantee, privilege string, grantable bool) string {
+	role := "PUBLIC"
+	if grantee != "" {
+		role = quotePGIdentifier(grantee)
+	}
+	stmt := fmt.Sprintf("GRANT %s ON %s TO %s", privilege, m.qualify(m.shadowTableName), role)
+	if grantable {
+		stmt += " WITH GRANT OPTION"
+	}
+	return stmt
+}
+
+// pgPolicy is the row-level security policy of a Postgres table.
+type pgPolicy struct {
+	name       string
+	permissive bool
+	// command is the polcmd of pg_policy, e.g. r for SELECT and * for ALL.
+	command string
+	// roleList is the quoted roles the policy applies to, or PUBLIC.
+	roleList string
+	// using and check are the USING and the WITH CHECK expressions, empty if absent.
+	using string
+	check string
+}
+
+// getPolicyStatement returns the statement to create the policy on the shadow table.
+func (m *pgOnlineMigration) getPolicyStatement(policy *pgPolicy) string {
+	kind := "RESTRICTIVE"
+	if policy.permissive {
+		kind = "PERMISSIVE"
+	}
+	command := map[string]string{
+		"r": "SELECT",
+		"a": "INSERT",
+		"w": "UPDATE",
+		"d": "DELETE",
+	}[policy.command]
+	if command == "" {
+		command = "ALL"
+	}
+	stmt := fmt.Sprintf("CREATE POLICY %s ON %s AS %s FOR %s TO %s", quotePGIdentifier(policy.name), m.qualify(m.shadowTableName), kind, command, policy.roleList)
+	if policy.using != "" {
+		stmt += fmt.Sprintf(" USING (%s)", policy.using)
+	}
+	if policy.check != "" {
+		stmt += fmt.Sprintf(" WITH CHECK (%s)", policy.check)
+	}
+	return stmt
+}
+
+// beginPGOwnerTransaction begins a transaction with the role of the database owner, so that the shadow table is
+// created by the database owner as the tables created by the other migrations. The cutover hands the shadow table
+// over to the owner of the table.
+func beginPGOwnerTransaction(ctx context.Context, sqlDB *sql.DB) (*sql.Tx, error) {
+	tx, err := sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	var owner string
+	if err := tx.QueryRowContext(ctx, "SELECT pg_catalog.pg_get_userbyid(datdba) FROM pg_catalog.pg_database WHERE datname = current_database()").Scan(&owner); err != nil {
+		_ = tx.Rollback()
+		return nil, errors.Wrap(err, "failed to get the database owner")
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL ROLE %s", quotePGIdentifier(owner))); err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+	return tx, nil
+}
+
+// checkPGOnlineMigrationTable checks the table can be changed online and returns its primary key column.
+// Only the single-column primary key is supported, and the objects which aren't carried over by the shadow table,
+// e.g. the foreign keys, the triggers, the dependent views, the publications and the column privileges, aren't allowed.
+// The database owner creating the shadow table must have the privileges of the table owner to hand the table over.
+func checkPGOnlineMigrationTable(ctx context.Context, tx *sql.Tx, m *pgOnlineMigration) (string, error) {
+	table := m.qualify(m.tableName)
+	var relkind sql.NullString
+	if err := tx.QueryRowContext(ctx, "SELECT relkind FROM pg_catalog.pg_class WHERE oid = to_regclass($1)", table).Scan(&relkind); err != nil {
+		if err == sql.ErrNoRows {
+			return "", errors.Errorf("table %s doesn't exist", table)
+		}
+		return "", err
+	}
+	if relkind.String != "r" {
+		return "", errors.Errorf("online migration requires a regular table, but %s is not", table)
+	}
+
+	pkList, err := getPGPrimaryKey(ctx, tx, table)
+	if err != nil {
+		return "", err
+	}
+	if len(pkList) != 1 {
+		return "", errors.Errorf("online migration requires a single-column primary key, but the primary key of table %s has %d columns", table, len(pkList))
+	}
+
+	for _, check := range []struct {
+		query   string
+		message string
+	}{
+		{
+			query:   "SELECT COUNT(*) FROM pg_catalog.pg_constraint WHERE contype = 'f' AND (conrelid = $1::regclass OR confrelid = $1::regclass)",
+			message: "online migration doesn't support the table with foreign keys or referenced by foreign keys",
+		},
+		{
+			query:   "SELECT COUNT(*) FROM pg_catalog.pg_inherits WHERE inhrelid = $1::regclass OR inhparent = $1::regclass",
+			message: "online migration doesn't support the table with inheritance",
+		},
+		{
+			query:   "SELECT COUNT(*) FROM pg_catalog.pg_trigger WHERE tgrelid = $1::regclass AND NOT tgisinternal AND tgname <> $2",
+			message: "online migration doesn't support the table with triggers",
+		},
+		{
+			query:   "SELECT COUNT(DISTINCT r.ev_class) FROM pg_catalog.pg_depend d JOIN pg_catalog.pg_rewrite r ON r.oid = d.objid WHERE d.classid = 'pg_catalog.pg_rewrite'::regclass AND d.refobjid = $1::regclass AND r.ev_class <> $1::regclass",
+			message: "online migration doesn't support the table referenced by views",
+		},
+		{
+			// pg_publication_rel doesn't exist before Postgres 10, and to_regclass returns NULL.
+			query:   "SELECT COUNT(*) FROM pg_catalog.pg_depend WHERE classid = to_regclass('pg_catalog.pg_publication_rel') AND refobjid = $1::regclass",
+			message: "online migration doesn't support the table in publications",
+		},
+		{
+			query:   "SELECT COUNT(*) FROM pg_catalog.pg_attribute WHERE attrelid = $1::regclass AND attacl IS NOT NULL AND NOT attisdropped",
+			message: "online migration doesn't support the table with column privileges",
+		},
+		{
+			query:   "SELECT COUNT(*) FROM pg_catalog.pg_class WHERE oid = $1::regclass AND NOT pg_catalog.pg_has_role(relowner, 'USAGE')",
+			message: "online migration requires the database owner to have the privileges of the table owner",
+		},
+	} {
+		args := []interface{}{table}
+		if strings.Contains(check.query, "$2") {
+			args = append(args, m.triggerName)
+		}
+		var count int
+		if err := tx.QueryRowContext(ctx, check.query, args...).Scan(&count); err != nil {
+			return "", err
+		}
+		if count > 0 {
+			return "", errors.New(check.message)
+		}
+	}
+	return pkList[0], nil
+}
+
+// getPGPrimaryKey returns the primary key columns of the qualified table.
+func getPGPrimaryKey(ctx context.Context, tx *sql.Tx, table string) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT a.attname
+		FROM pg_catalog.pg_index i
+			JOIN LATERAL unnest(i.indkey) WITH ORDINALITY AS k(attnum, ordinality) ON TRUE
+			JOIN pg_catalog.pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = k.attnum
+		WHERE i.indrelid = $1::regclass AND i.indisprimary
+		ORDER BY k.ordinality`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var pkList []string
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, err
+		}
+		pkList = append(pkList, column)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return pkList, nil
+}
+
+// setupPGOnlineMigration creates the shadow table and the trigger in the transaction, and returns the primary key
+// column and the columns copied into the shadow table.
+func setupPGOnlineMigration(ctx context.Context, tx *sql.Tx, m *pgOnlineMigration) (string, []string, error) {
+	pk, err := checkPGOnlineMigrationTable(ctx, tx, m)
+	if err != nil {
+		return "", nil, err
+	}
+	for _, stmt := range m.getSetupStatementList() {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return "", nil, errors.Wrapf(err, "failed to execute %q", stmt)
+		}
+	}
+	shadowPKList, err := getPGPrimaryKey(ctx, tx, m.qualify(m.shadowTableName))
+	if err != nil {
+		return "", nil, err
+	}
+	if len(shadowPKList) != 1 || shadowPKList[0] != pk {
+		return "", nil, errors.Errorf("online migration can't change the primary key %q", pk)
+	}
+
+	// The generated columns are computed in the shadow table, and the dropped columns are left out.
+	rows, err := tx.QueryContext(ctx, `
+		SELECT c.column_name
+		FROM information_schema.columns c
+			JOIN information_schema.columns s ON s.table_schema = c.table_schema AND s.column_name = c.column_name
+		WHERE c.table_schema = $1 AND c.table_name = $2 AND c.is_generated = 'NEVER'
+			AND s.table_name = $3 AND s.is_generated = 'NEVER'
+		ORDER BY c.ordinal_position`, m.schemaName, m.tableName, m.shadowTableName)
+	if err != nil {
+		return "", nil, err
+	}
+	defer rows.Close()
+	var columnList []string
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return "", nil, err
+		}
+		columnList = append(columnList, column)
+	}
+	if err := rows.Err(); err != nil {
+		return "", nil, err
+	}
+
+	for _, stmt := range m.getTriggerStatementList(pk, columnList) {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return "", nil, errors.Wrap(err, "failed to create the trigger")
+		}
+	}
+	return pk, columnList, nil
+}
+
+// dryRunPGOnlineMigration sets up the online migration in a transaction and rolls it back.
+func dryRunPGOnlineMigration(ctx context.Context, sqlDB *sql.DB, m *pgOnlineMigration) error {
+	tx, err := beginPGOwnerTransaction(ctx, sqlDB)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	_, _, err = setupPGOnlineMigration(ctx, tx, m)
+	return err
+}
+
+// runPGOnlineSync creates the shadow table with the trigger, and copies the rows into it in batches.
+// The trigger keeps the shadow table in sync until the cutover task swaps the tables. Rerunning the task recreates
+// the shadow table.
+func (exec *SchemaUpdateGhostSyncTaskExecutor) runPGOnlineSync(ctx context.Context, server *Server, task *api.Task, statement string) (terminated bool, result *api.TaskRunResultPayload, err error) {
+	m, err := getPGOnlineMigration(strings.TrimSpace(statement), task.ID)
+	if err != nil {
+		return true, nil, err
+	}
+	driver, err := server.getAdminDatabaseDriver(ctx, task.Instance, task.Database.Name)
+	if err != nil {
+		return true, nil, err
+	}
+	defer driver.Close(ctx)
+	sqlDB, err := driver.GetDBConnection(ctx, task.Database.Name)
+	if err != nil {
+		return true, nil, err
+	}
+
+	tx, err := beginPGOwnerTransaction(ctx, sqlDB)
+	if err != nil {
+		return true, nil, err
+	}
+	defer tx.Rollback()
+	pk, columnList, err := setupPGOnlineMigration(ctx, tx, m)
+	if err != nil {
+		return true, nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return true, nil, errors.Wrap(err, "failed to create the shadow table")
+	}
+
+	if err := exec.backfillPGOnlineMigration(ctx, sqlDB, m, pk, columnList); err != nil {
+		return true, nil, err
+	}
+	if _, err := sqlDB.ExecContext(ctx, fmt.Sprintf("ANALYZE %s", m.qualify(m.shadowTableName))); err != nil {
+		return true, nil, errors.Wrap(err, "failed to analyze the shadow table")
+	}
+	return true, &api.TaskRunResultPayload{Detail: "sync done"}, nil
+}
+
+// backfillPGOnlineMigration copies the rows of the table into the shadow table in primary key ranged chunks.
+func (exec *SchemaUpdateGhostSyncTaskExecutor) backfillPGOnlineMigration(ctx context.Context, sqlDB *sql.DB, m *pgOnlineMigration, pk string, columnList []string) error {
+	var rowsEstimate float64
+	if err := sqlDB.QueryRowContext(ctx, "SELECT reltuples FROM pg_catalog.pg_class WHERE oid = $1::regclass", m.qualify(m.tableName)).Scan(&rowsEstimate); err != nil {
+		return errors.Wrap(err, "failed to estimate the row count")
+	}
+	query := getPGChunkKeyQuery(m.qualify(m.tableName), quotePGIdentifier(pk), pgOnlineMigrationChunkSize)
+	backfill := m.getBackfillStatement(pk, columnList)
+
+	createdTs := time.Now().Unix()
+	var completedUnit int64
+	var lastKey *string
+	for {
+		begin, end, ok, err := query.getChunkRange(ctx, sqlDB, lastKey)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		if _, err := sqlDB.ExecContext(ctx, backfill, begin, end); err != nil {
+			return errors.Wrapf(err, "failed to copy the rows with %s in [%s, %s]", pk, begin, end)
+		}
+		lastKey = &end
+
+		completedUnit += pgOnlineMigrationChunkSize
+		totalUnit := int64(rowsEstimate)
+		if totalUnit < completedUnit {
+			// The estimate is updated by VACUUM and ANALYZE and may be stale.
+			totalUnit = completedUnit
+		}
+		var etaSeconds int64
+		if elapsed := time.Now().Unix() - createdTs; elapsed > 0 {
+			etaSeconds = (totalUnit - completedUnit) * elapsed / completedUnit
+		}
+		exec.progress.Store(api.Progress{
+			TotalUnit:        totalUnit,
+			CompletedUnit:    completedUnit,
+			CreatedTs:        createdTs,
+			UpdatedTs:        time.Now().Unix(),
+			CurrentStatement: fmt.Sprintf("Copy the rows with %s in [%s, %s]", pk, begin, end),
+			ETASeconds:       etaSeconds,
+		})
+	}
+}
+
+// runPGOnlineCutover swaps the table with the shadow table synced by the sync task, and records the migration
+// history of the ALTER TABLE statement.
+func runPGOnlineCutover(ctx context.Context, server *Server, task *api.Task, syncTaskID int, payload *api.TaskDatabaseSchemaUpdateGhostSyncPayload) (terminated bool, result *api.TaskRunResultPayload, err error) {
+	statement := strings.TrimSpace(payload.Statement)
+	m, err := getPGOnlineMigration(statement, syncTaskID)
+	if err != nil {
+		return true, nil, err
+	}
+	mi, err := preMigration(ctx, server, task, db.Migrate, statement, payload.SchemaVersion, payload.VCSPushEvent)
+	if err != nil {
+		return true, nil, err
+	}
+	// dropErr is the error dropping the old table, which is kept for the user to drop manually.
+	var dropErr error
+	mi.ExecuteFunc = func(ctx context.Context, sqlDB *sql.DB) error {
+		for i := 1; ; i++ {
+			err := cutoverPGOnlineMigration(ctx, sqlDB, m)
+			if err == nil {
+				break
+			}
+			var pgErr *pgconn.PgError
+			if !errors.As(err, &pgErr) || pgErr.Code != pgLockNotAvailableCode || i == pgOnlineMigrationCutoverRetry {
+				return err
+			}
+			log.Debug("online migration cutover timed out acquiring the lock, retrying",
+				zap.Int("task_id", task.ID),
+				zap.Int("attempt", i),
+			)
+			select {
+			case <-time.After(pgOnlineMigrationCutoverInterval):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		// The old table is dropped after the cutover commits, so that the cutover doesn't hold the lock any longer.
+		// It's dropped before the schema is dumped for the migration history.
+		if dropErr = dropPGOnlineMigrationOldTable(ctx, sqlDB, m); dropErr != nil {
+			log.Warn("Failed to drop the old table after the online migration cutover",
+				zap.Int("task_id", task.ID),
+				zap.String("table", m.qualify(m.oldTableName)),
+				zap.Error(dropErr),
+			)
+		}
+		return nil
+	}
+	migrationID, schema, err := executeMigration(ctx, server, task, statement, mi)
+	if err != nil {
+		return true, nil, err
+	}
+	terminated, result, err = postMigration(ctx, server, task, payload.VCSPushEvent, mi, migrationID, schema)
+	if result != nil && dropErr != nil {
+		result.Detail += fmt.Sprintf(" The old table %s is kept because it failed to be dropped: %v. Please drop it manually.", m.qualify(m.oldTableName), dropErr)
+	}
+	return terminated, result, err
+}
+
+// dropPGOnlineMigrationOldTable drops the old table and its indexes after the cutover.
+func dropPGOnlineMigrationOldTable(ctx context.Context, sqlDB *sql.DB, m *pgOnlineMigration) error {
+	tx, err := beginPGOwnerTransaction(ctx, sqlDB)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, stmt := range m.getDropOldTableStatementList() {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// cutoverPGOnlineMigration swaps the table with the shadow table in a transaction with the lock timeout.
+// Besides the table names, the identity sequences, the sequences owned by the columns, the index names, the owner, the
+// privileges and the row-level security policies are carried over to the new table. They are read after locking the
+// table, so that the changes after the sync aren't lost.
+func cutoverPGOnlineMigration(ctx context.Context, sqlDB *sql.DB, m *pgOnlineMigration) error {
+	tx, err := beginPGOwnerTransaction(ctx, sqlDB)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var shadowTable sql.NullString
+	if err := tx.QueryRowContext(ctx, "SELECT to_regclass($1)::text", m.qualify(m.shadowTableName)).Scan(&shadowTable); err != nil {
+		return err
+	}
+	if !shadowTable.Valid {
+		return errors.Errorf("shadow table %s doesn't exist, please rerun the sync task", m.qualify(m.shadowTableName))
+	}
+
+	cutoverList := m.getCutoverStatementList()
+	// Lock the table before reading the sequences and the indexes.
+	for _, stmt := range cutoverList[:2] {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	// The table may be changed after the sync, e.g. added to a publication.
+	if _, err := checkPGOnlineMigrationTable(ctx, tx, m); err != nil {
+		return err
+	}
+	privilegeList, err := getPGOnlineMigrationPrivilegeList(ctx, tx, m)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range privilegeList {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return errors.Wrapf(err, "failed to carry over the privileges with %q", stmt)
+		}
+	}
+	// The identity columns of the shadow table have their own sequences, which continue from the original ones.
+	if _, err := tx.ExecContext(ctx, `
+		SELECT pg_catalog.setval(pg_catalog.pg_get_serial_sequence($2, a.attname), COALESCE(pg_catalog.pg_sequence_last_value(s.seq), 1), pg_catalog.pg_sequence_last_value(s.seq) IS NOT NULL)
+		FROM pg_catalog.pg_attribute a
+			JOIN LATERAL (SELECT pg_catalog.pg_get_serial_sequence($1, a.attname)::regclass AS seq) s ON s.seq IS NOT NULL
+		WHERE a.attrelid = $2::regclass AND a.attidentity <> '' AND NOT a.attisdropped`,
+		m.qualify(m.tableName), m.qualify(m.shadowTableName)); err != nil {
+		return errors.Wrap(err, "failed to sync the identity sequences")
+	}
+	indexRenameList, err := getPGOnlineMigrationIndexRenameList(ctx, tx, m)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range cutoverList[2:] {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	for _, stmt := range indexRenameList {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return errors.Wrap(err, "failed to rename the indexes")
+		}
+	}
+
+	// The serial columns of the shadow table use the same sequences, which are owned by the old table.
+	rows, err := tx.QueryContext(ctx, `
+		SELECT d.objid::regclass::text, a.attname
+		FROM pg_catalog.pg_depend d
+			JOIN pg_catalog.pg_class s ON s.oid = d.objid AND s.relkind = 'S'
+			JOIN pg_catalog.pg_attribute a ON a.attrelid = d.refobjid AND a.attnum = d.refobjsubid
+			JOIN pg_catalog.pg_attribute n ON n.attrelid = $2::regclass AND n.attname = a.attname AND NOT n.attisdropped
+		WHERE d.classid = 'pg_catalog.pg_class'::regclass AND d.refclassid = 'pg_catalog.pg_class'::regclass
+			AND d.deptype = 'a' AND d.refobjid = $1::regclass`,
+		m.qualify(m.oldTableName), m.qualify(m.tableName))
+	if err != nil {
+		return err
+	}
+	var ownedByList []string
+	for rows.Next() {
+		var sequence, column string
+		if err := rows.Scan(&sequence, &column); err != nil {
+			rows.Close()
+			return err
+		}
+		ownedByList = append(ownedByList, fmt.Sprintf("ALTER SEQUENCE %s OWNED BY %s.%s", sequence, m.qualify(m.tableName), quotePGIdentifier(column)))
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return err
+	}
+	rows.Close()
+	for _, stmt := range ownedByList {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return errors.Wrap(err, "failed to transfer the sequence ownership")
+		}
+	}
+
+	return tx.Commit()
+}
+
+// getPGOnlineMigrationPrivilegeList returns the statements to give the shadow table the owner, the privileges and the
+// row-level security policies of the table, which aren't copied by CREATE TABLE LIKE.
+func getPGOnlineMigrationPrivilegeList(ctx context.Context, tx *sql.Tx, m *pgOnlineMigration) ([]string, error) {
+	table, shadowTable := m.qualify(m.tableName), m.qualify(m.shadowTableName)
+	var owner string
+	var hasACL, rowSecurity, forceRowSecurity bool
+	var policyCount int
+	if err := tx.QueryRowContext(ctx, `
+		SELECT pg_catalog.pg_get_userbyid(c.relowner), c.relacl IS NOT NULL, c.relrowsecurity, c.relforcerowsecurity,
+			(SELECT COUNT(*) FROM pg_catalog.pg_policy p WHERE p.polrelid = c.oid)
+		FROM pg_catalog.pg_class c
+		WHERE c.oid = $1::regclass`, table).Scan(&owner, &hasACL, &rowSecurity, &forceRowSecurity, &policyCount); err != nil {
+		return nil, errors.Wrap(err, "failed to get the owner of the table")
+	}
+	// The shadow table is created by the database owner, which has the privileges of the table owner.
+	stmtList := []string{fmt.Sprintf("ALTER TABLE %s OWNER TO %s", shadowTable, quotePGIdentifier(owner))}
+
+	// The NULL ACL means the default privileges of the owner, which the shadow table has already.
+	if hasACL {
+		stmtList = append(stmtList, fmt.Sprintf("REVOKE ALL ON %s FROM PUBLIC, %s", shadowTable, quotePGIdentifier(owner)))
+		rows, err := tx.QueryContext(ctx, `
+			SELECT CASE WHEN a.grantee = 0 THEN '' ELSE pg_catalog.pg_get_userbyid(a.grantee) END, a.privilege_type, a.is_grantable
+			FROM pg_catalog.pg_class c, pg_catalog.aclexplode(c.relacl) a
+			WHERE c.oid = $1::regclass
+			ORDER BY 1, 2`, table)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get the privileges of the table")
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var grantee, privilege string
+			var grantable bool
+			if err := rows.Scan(&grantee, &privilege, &grantable); err != nil {
+				return nil, err
+			}
+			stmtList = append(stmtList, m.getGrantStatement(grantee, privilege, grantable))
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	if rowSecurity {
+		stmtList = append(stmtList, fmt.Sprintf("ALTER TABLE %s ENABLE ROW LEVEL SECURITY", shadowTable))
+	}
+	if forceRowSecurity {
+		stmtList = append(stmtList, fmt.Sprintf("ALTER TABLE %s FORCE ROW LEVEL SECURITY", shadowTable))
+	}
+	if policyCount == 0 {
+		return stmtList, nil
+	}
+	rows, err := tx.QueryContext(ctx, `
+		SELECT p.polname, p.polpermissive, p.polcmd::text,
+			COALESCE((SELECT string_agg(CASE WHEN r.oid = 0 THEN 'PUBLIC' ELSE pg_catalog.quote_ident(pg_catalog.pg_get_userbyid(r.oid)) END, ', ') FROM unnest(p.polroles) AS r(oid)), 'PUBLIC'),
+			COALESCE(pg_catalog.pg_get_expr(p.polqual, p.polrelid), ''),
+			COALESCE(pg_catalog.pg_get_expr(p.polwithcheck, p.polrelid), '')
+		FROM pg_catalog.pg_policy p
+		WHERE p.polrelid = $1::regclass
+		ORDER BY p.polname`, table)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the row-level security policies of the table")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var policy pgPolicy
+		if err := rows.Scan(&policy.name, &policy.permissive, &policy.command, &policy.roleList, &policy.using, &policy.check); err != nil {
+			return nil, err
+		}
+		stmtList = append(stmtList, m.getPolicyStatement(&policy))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return stmtList, nil
+}
+
+// getPGOnlineMigrationIndexRenameList returns the statements to give the indexes of the shadow table the names of
+// the matching indexes of the table, and rename the indexes of the table to keep the names unique. The indexes match
+// if they have the same definition except the names.
+func getPGOnlineMigrationIndexRenameList(ctx context.Context, tx *sql.Tx, m *pgOnlineMigration) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT o.relname, n.relname
+		FROM pg_catalog.pg_index oi
+			JOIN pg_catalog.pg_class o ON o.oid = oi.indexrelid
+			JOIN pg_catalog.pg_index ni ON ni.indrelid = $2::regclass
+				AND ni.indisunique = oi.indisunique AND ni.indisprimary = oi.indisprimary
+				AND substring(pg_catalog.pg_get_indexdef(ni.indexrelid) from ' USING .*$') = substring(pg_catalog.pg_get_indexdef(oi.indexrelid) from ' USING .*$')
+			JOIN pg_catalog.pg_class n ON n.oid = ni.indexrelid
+		WHERE oi.indrelid = $1::regclass
+		ORDER BY o.relname, n.relname`,
+		m.qualify(m.tableName), m.qualify(m.shadowTableName))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var oldList, renameList []string
+	renamed := make(map[string]bool)
+	for rows.Next() {
+		var index, shadowIndex string
+		if err := rows.Scan(&index, &shadowIndex); err != nil {
+			return nil, err
+		}
+		if renamed[index] || renamed[shadowIndex] {
+			continue
+		}
+		renamed[index], renamed[shadowIndex] = true, true
+		oldIndex := getPGOnlineMigrationName(index, m.syncTaskID, "del")
+		oldList = append(oldList, fmt.Sprintf("ALTER INDEX %s RENAME TO %s", m.qualify(index), quotePGIdentifier(oldIndex)))
+		renameList = append(renameList, fmt.Sprintf("ALTER INDEX %s RENAME TO %s", m.qualify(shadowIndex), quotePGIdentifier(index)))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return append(oldList, renameList...), nil
+}
+
+// validateOnlineMigration checks the statement can be changed online on the engine.
+func validateOnlineMigration(engine db.Type, statement string) error {
+	switch engine {
+	case db.MySQL:
+		return nil
+	case db.Postgres:
+		_, err := getPGOnlineMigration(strings.TrimSpace(statement), 0)
+		return err
+	default:
+		return errors.Errorf("online migration is not supported for %s", engine)
+	}
+}
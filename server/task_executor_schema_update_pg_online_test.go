@@ -0,0 +1,144 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	pgquery "github.com/pganalyze/pg_query_go/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPGOnlineMigration(t *testing.T) {
+	tests := []struct {
+		statement string
+		want      *pgOnlineMigration
+		wantErr   bool
+	}{
+		{
+			statement: "ALTER TABLE employee ADD COLUMN age int NOT NULL DEFAULT 0",
+			want: &pgOnlineMigration{
+				syncTaskID:           123,
+				schemaName:           "public",
+				tableName:            "employee",
+				shadowTableName:      "_employee_123_gho",
+				oldTableName:         "_employee_123_del",
+				triggerName:          "_employee_123_sync",
+				alterShadowStatement: "ALTER TABLE public._employee_123_gho ADD COLUMN age int NOT NULL DEFAULT 0",
+			},
+		},
+		{
+			statement: "ALTER TABLE hr.employee ALTER COLUMN name TYPE text, DROP COLUMN age;",
+			want: &pgOnlineMigration{
+				syncTaskID:           123,
+				schemaName:           "hr",
+				tableName:            "employee",
+				shadowTableName:      "_employee_123_gho",
+				oldTableName:         "_employee_123_del",
+				triggerName:          "_employee_123_sync",
+				alterShadowStatement: "ALTER TABLE hr._employee_123_gho ALTER COLUMN name TYPE text, DROP age",
+			},
+		},
+		{
+			statement: "ALTER TABLE employee ALTER COLUMN age TYPE bigint USING age::bigint",
+			wantErr:   true,
+		},
+		{
+			statement: "ALTER TABLE employee RENAME TO staff",
+			wantErr:   true,
+		},
+		{
+			statement: "ALTER INDEX employee_pkey SET (fillfactor = 70)",
+			wantErr:   true,
+		},
+		{
+			statement: "ALTER TABLE employee ADD COLUMN a int; ALTER TABLE employee ADD COLUMN b int",
+			wantErr:   true,
+		},
+		{
+			statement: "CREATE TABLE employee (id int)",
+			wantErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		m, err := getPGOnlineMigration(test.statement, 123)
+		if test.wantErr {
+			require.Error(t, err, test.statement)
+			continue
+		}
+		require.NoError(t, err, test.statement)
+		require.Equal(t, test.want, m, test.statement)
+	}
+}
+
+func TestGetPGOnlineMigrationName(t *testing.T) {
+	name := getPGOnlineMigrationName(strings.Repeat("a", 60), 123, "gho")
+	require.Equal(t, "_"+strings.Repeat("a", 54)+"_123_gho", name)
+	require.Len(t, name, pgMaxIdentifierLength)
+}
+
+func TestPGOnlineMigrationStatement(t *testing.T) {
+	m, err := getPGOnlineMigration("ALTER TABLE employee ADD COLUMN age int", 123)
+	require.NoError(t, err)
+
+	var statementList []string
+	statementList = append(statementList, m.getSetupStatementList()...)
+	statementList = append(statementList, m.getTriggerStatementList("id", []string{"id", "name"})...)
+	statementList = append(statementList, m.getBackfillStatement("id", []string{"id", "name"}))
+	statementList = append(statementList, m.getCutoverStatementList()...)
+	statementList = append(statementList, m.getDropOldTableStatementList()...)
+	for _, statement := range statementList {
+		_, err := pgquery.Parse(statement)
+		require.NoError(t, err, statement)
+	}
+
+	require.Equal(t,
+		`INSERT INTO "public"."_employee_123_gho" ("id", "name") OVERRIDING SYSTEM VALUE SELECT "id", "name" FROM "public"."employee" WHERE "id" >= $1 AND "id" <= $2 FOR SHARE ON CONFLICT ("id") DO NOTHING`,
+		m.getBackfillStatement("id", []string{"id", "name"}),
+	)
+	require.Equal(t, []string{
+		`SET LOCAL lock_timeout = '3s'`,
+		`LOCK TABLE "public"."employee" IN ACCESS EXCLUSIVE MODE`,
+		`DROP TRIGGER "_employee_123_sync" ON "public"."employee"`,
+		`DROP FUNCTION "public"."_employee_123_sync"()`,
+		`ALTER TABLE "public"."employee" RENAME TO "_employee_123_del"`,
+		`ALTER TABLE "public"."_employee_123_gho" RENAME TO "employee"`,
+	}, m.getCutoverStatementList())
+	require.Equal(t, []string{
+		`SET LOCAL lock_timeout = '3s'`,
+		`DROP TABLE "public"."_employee_123_del"`,
+	}, m.getDropOldTableStatementList())
+}
+
+func TestPGOnlineMigrationPrivilegeStatement(t *testing.T) {
+	m, err := getPGOnlineMigration("ALTER TABLE employee ADD COLUMN age int", 123)
+	require.NoError(t, err)
+
+	statementList := []string{
+		m.getGrantStatement("", "SELECT", false),
+		m.getGrantStatement("app", "UPDATE", true),
+		m.getPolicyStatement(&pgPolicy{
+			name:       "tenant_isolation",
+			permissive: true,
+			command:    "*",
+			roleList:   `PUBLIC`,
+			using:      "(tenant_id = (current_setting('app.tenant_id'::text))::integer)",
+		}),
+		m.getPolicyStatement(&pgPolicy{
+			name:     "insert_own",
+			command:  "a",
+			roleList: `app, "Reporter"`,
+			check:    "(owner = CURRENT_USER)",
+		}),
+	}
+	for _, statement := range statementList {
+		_, err := pgquery.Parse(statement)
+		require.NoError(t, err, statement)
+	}
+	require.Equal(t, []string{
+		`GRANT SELECT ON "public"."_employee_123_gho" TO PUBLIC`,
+		`GRANT UPDATE ON "public"."_employee_123_gho" TO "app" WITH GRANT OPTION`,
+		`CREATE POLICY "tenant_isolation" ON "public"."_employee_123_gho" AS PERMISSIVE FOR ALL TO PUBLIC USING ((tenant_id = (current_setting('app.tenant_id'::text))::integer))`,
+		`CREATE POLICY "insert_own" ON "public"."_employee_123_gho" AS RESTRICTIVE FOR INSERT TO app, "Reporter" WITH CHECK ((owner = CURRENT_USER))`,
+	}, statementList)
+}
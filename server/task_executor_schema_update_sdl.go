@@ -22,6 +22,7 @@ func NewSchemaUpdateSDLTaskExecutor() TaskExecutor {
 // SchemaUpdateSDLTaskExecutor is the schema update (SDL) task executor.
 type SchemaUpdateSDLTaskExecutor struct {
 	completed int32
+	progress  statementProgress
 }
 
 // RunOnce will run the schema update (SDL) task executor once.
@@ -36,6 +37,7 @@ func (exec *SchemaUpdateSDLTaskExecutor) RunOnce(ctx context.Context, server *Se
 	if err != nil {
 		return true, nil, errors.Wrap(err, "invalid database schema diff")
 	}
+	ctx = exec.progress.withReporter(ctx)
 	return runMigration(ctx, server, task, db.MigrateSDL, ddl, payload.SchemaVersion, payload.VCSPushEvent)
 }
 
@@ -45,8 +47,8 @@ func (exec *SchemaUpdateSDLTaskExecutor) IsCompleted() bool {
 }
 
 // GetProgress returns the task progress.
-func (*SchemaUpdateSDLTaskExecutor) GetProgress() api.Progress {
-	return api.Progress{}
+func (exec *SchemaUpdateSDLTaskExecutor) GetProgress() api.Progress {
+	return exec.progress.get()
 }
 
 // computeDatabaseSchemaDiff computes the diff between current database schema
@@ -17,10 +17,14 @@ import (
 	"github.com/bytebase/bytebase/common"
 	"github.com/bytebase/bytebase/common/log"
 	"github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/plugin/metric/prometheus"
+	"github.com/bytebase/bytebase/plugin/tracing"
 )
 
 const (
 	taskSchedulerInterval = time.Duration(1) * time.Second
+	// taskProgressPersistInterval is the interval to persist the task progress into the task run if only the elapsed time changes.
+	taskProgressPersistInterval = time.Duration(5) * time.Second
 )
 
 // NewTaskScheduler creates a new task scheduler.
@@ -29,6 +33,8 @@ func NewTaskScheduler(server *Server) *TaskScheduler {
 		executorGetters:        make(map[api.TaskType]func() TaskExecutor),
 		runningExecutors:       make(map[int]TaskExecutor),
 		runningExecutorsCancel: make(map[int]context.CancelFunc),
+		persistedProgress:      make(map[int]api.Progress),
+		persistedQueue:         make(map[int]api.TaskQueue),
 		server:                 server,
 	}
 }
@@ -39,9 +45,17 @@ type TaskScheduler struct {
 	runningExecutors       map[int]TaskExecutor
 	runningExecutorsCancel map[int]context.CancelFunc
 	runningExecutorsMutex  sync.Mutex
-	taskProgress           sync.Map // map[taskID]api.Progress
-	sharedTaskState        sync.Map // map[taskID]interface{}
-	server                 *Server
+	// runningExecutorsWG waits for the goroutines running the task executors.
+	runningExecutorsWG sync.WaitGroup
+	taskProgress       sync.Map // map[taskID]api.Progress
+	taskQueue          sync.Map // map[taskID]api.TaskQueue
+	sharedTaskState    sync.Map // map[taskID]interface{}
+	server             *Server
+
+	// persistedProgress is the task progress persisted most recently, only accessed by the scheduler loop.
+	persistedProgress map[int]api.Progress
+	// persistedQueue is the task queue status persisted most recently, only accessed by the scheduler loop.
+	persistedQueue map[int]api.TaskQueue
 }
 
 // Run will run the task scheduler.
@@ -49,6 +63,9 @@ func (s *TaskScheduler) Run(ctx context.Context, wg *sync.WaitGroup) {
 	ticker := time.NewTicker(taskSchedulerInterval)
 	defer ticker.Stop()
 	defer wg.Done()
+	// The persisted state may be changed by the other replicas since the last time this replica was the leader.
+	s.persistedProgress = make(map[int]api.Progress)
+	s.persistedQueue = make(map[int]api.TaskQueue)
 	log.Debug(fmt.Sprintf("Task scheduler started and will run every %v", taskSchedulerInterval))
 	for {
 		select {
@@ -64,14 +81,20 @@ func (s *TaskScheduler) Run(ctx context.Context, wg *sync.WaitGroup) {
 					}
 				}()
 
-				ctx := context.Background()
+				ctx, span := tracing.Start(context.Background(), "TaskScheduler.Run")
+				defer span.End()
 
 				// Update task progress
+				progressMap := make(map[int]api.Progress)
 				s.runningExecutorsMutex.Lock()
 				for i, executor := range s.runningExecutors {
-					s.taskProgress.Store(i, executor.GetProgress())
+					progress := executor.GetProgress()
+					s.taskProgress.Store(i, progress)
+					progressMap[i] = progress
 				}
+				prometheus.TaskExecutorRunning.Set(float64(len(s.runningExecutors)))
 				s.runningExecutorsMutex.Unlock()
+				s.persistTaskProgress(ctx, progressMap)
 
 				// Inspect all open pipelines and schedule the next PENDING task if applicable
 				pipelineStatus := api.PipelineOpen
@@ -105,6 +128,8 @@ func (s *TaskScheduler) Run(ctx context.Context, wg *sync.WaitGroup) {
 					log.Error("Failed to retrieve running tasks", zap.Error(err))
 					return
 				}
+				prometheus.TaskQueueDepth.Set(float64(len(taskList)))
+				s.cancelStoppedTaskExecutors(taskList)
 
 				// For each database, we will only execute the earliest running task (minimal task ID) and hold up the rest of the running tasks.
 				// Sort the taskList by ID first.
@@ -123,6 +148,8 @@ func (s *TaskScheduler) Run(ctx context.Context, wg *sync.WaitGroup) {
 					databaseRunningTasks[*task.DatabaseID] = task.ID
 				}
 
+				var executingTaskList, waitingTaskList []*api.Task
+				taskQueueMap := make(map[int]api.TaskQueue)
 				for _, task := range taskList {
 					// Skip task belongs to archived instances
 					if i := task.Instance; i == nil || i.RowStatus == api.Archived {
@@ -133,18 +160,21 @@ func (s *TaskScheduler) Run(ctx context.Context, wg *sync.WaitGroup) {
 					_, ok := s.runningExecutors[task.ID]
 					s.runningExecutorsMutex.Unlock()
 					if ok {
+						executingTaskList = append(executingTaskList, task)
 						continue
 					}
 					// Skip the task that is not the earliest task of the database.
 					// earliestTaskID is the one that should be executed.
 					if task.DatabaseID != nil {
 						if earliestTaskID, ok := databaseRunningTasks[*task.DatabaseID]; ok && earliestTaskID != task.ID {
+							taskQueueMap[task.ID] = api.TaskQueue{
+								Reason: fmt.Sprintf("Waiting for task %d on the same database", earliestTaskID),
+							}
 							continue
 						}
 					}
 
-					executorGetter, ok := s.executorGetters[task.Type]
-					if !ok {
+					if _, ok := s.executorGetters[task.Type]; !ok {
 						log.Error("Skip running task with unknown type",
 							zap.Int("id", task.ID),
 							zap.String("name", task.Name),
@@ -152,13 +182,39 @@ func (s *TaskScheduler) Run(ctx context.Context, wg *sync.WaitGroup) {
 						)
 						continue
 					}
+					waitingTaskList = append(waitingTaskList, task)
+				}
+
+				// Hold up the tasks outside the maintenance windows, which don't take up the concurrency limits.
+				waitingTaskList, windowQueueMap := holdTaskQueueByMaintenanceWindow(waitingTaskList, s.getMaintenanceWindowPolicyMap(ctx, waitingTaskList), time.Now())
+				for taskID, queue := range windowQueueMap {
+					taskQueueMap[taskID] = queue
+				}
+				// Hold up the tasks exceeding the concurrency limits of their instances and environments.
+				runnableTaskList, queueMap := arrangeTaskQueue(executingTaskList, waitingTaskList, s.getTaskConcurrencyLimitMap(ctx, waitingTaskList))
+				for taskID, queue := range queueMap {
+					taskQueueMap[taskID] = queue
+				}
+				s.taskQueue.Range(func(key, _ interface{}) bool {
+					if _, ok := taskQueueMap[key.(int)]; !ok {
+						s.taskQueue.Delete(key)
+					}
+					return true
+				})
+				for taskID, queue := range taskQueueMap {
+					s.taskQueue.Store(taskID, queue)
+				}
+				s.persistTaskQueue(ctx, taskQueueMap)
 
-					executor := executorGetter()
+				for _, task := range runnableTaskList {
+					executor := s.executorGetters[task.Type]()
 					s.runningExecutorsMutex.Lock()
 					s.runningExecutors[task.ID] = executor
 					s.runningExecutorsMutex.Unlock()
 
+					s.runningExecutorsWG.Add(1)
 					go func(ctx context.Context, task *api.Task, executor TaskExecutor) {
+						defer s.runningExecutorsWG.Done()
 						defer func() {
 							s.runningExecutorsMutex.Lock()
 							delete(s.runningExecutors, task.ID)
@@ -172,7 +228,15 @@ func (s *TaskScheduler) Run(ctx context.Context, wg *sync.WaitGroup) {
 						s.runningExecutorsCancel[task.ID] = cancel
 						s.runningExecutorsMutex.Unlock()
 
+						// Record the start of the task run, so that the queued task runs are told apart on restart.
+						// It also clears the persisted queue status of the task run.
+						if err := s.server.store.MarkTaskRunStarted(ctx, task.ID); err != nil {
+							log.Error("Failed to mark the task run as started", zap.Int("task_id", task.ID), zap.Error(err))
+						}
+
+						start := time.Now()
 						done, result, err := RunTaskExecutorOnce(executorCtx, executor, s.server, task)
+						prometheus.ObserveSince(prometheus.TaskRunDuration, start, string(task.Type), string(getTaskRunOutcome(executorCtx, done, err)))
 
 						select {
 						case <-executorCtx.Done():
@@ -186,6 +250,13 @@ func (s *TaskScheduler) Run(ctx context.Context, wg *sync.WaitGroup) {
 						default:
 						}
 
+						// Persist the final progress before the task run ends, e.g. which statement the task fails at.
+						if progress := executor.GetProgress(); done && progress.CreatedTs != 0 {
+							if err := s.patchTaskRunProgress(ctx, task.ID, progress); err != nil {
+								log.Error("Failed to persist task progress", zap.Int("task_id", task.ID), zap.Error(err))
+							}
+						}
+
 						if !done && err != nil {
 							log.Debug("Encountered transient error running task, will retry",
 								zap.Int("id", task.ID),
@@ -202,6 +273,9 @@ func (s *TaskScheduler) Run(ctx context.Context, wg *sync.WaitGroup) {
 								zap.String("type", string(task.Type)),
 								zap.Error(err),
 							)
+							if s.retryTaskIfNeeded(ctx, task, err) {
+								return
+							}
 							bytes, marshalErr := json.Marshal(api.TaskRunResultPayload{
 								Detail: err.Error(),
 							})
@@ -306,6 +380,270 @@ func (s *TaskScheduler) Run(ctx context.Context, wg *sync.WaitGroup) {
 	}
 }
 
+// persistTaskProgress persists the task progress into the running task runs, so that the progress is available on
+// all replicas. progressMap is the mapping from task ID to the progress of the executing tasks.
+func (s *TaskScheduler) persistTaskProgress(ctx context.Context, progressMap map[int]api.Progress) {
+	for taskID := range s.persistedProgress {
+		if _, ok := progressMap[taskID]; !ok {
+			delete(s.persistedProgress, taskID)
+		}
+	}
+	for taskID, progress := range progressMap {
+		prev, ok := s.persistedProgress[taskID]
+		if !shouldPersistTaskProgress(prev, ok, progress) {
+			continue
+		}
+		if err := s.patchTaskRunProgress(ctx, taskID, progress); err != nil {
+			log.Error("Failed to persist task progress", zap.Int("task_id", taskID), zap.Error(err))
+			continue
+		}
+		s.persistedProgress[taskID] = progress
+	}
+}
+
+// persistTaskQueue persists the queue status into the running task runs, so that the queue status is available on
+// all replicas. queueMap is the mapping from task ID to the queue status of the tasks held up.
+// The queue status of a task is cleared when the task executor starts, see MarkTaskRunStarted.
+func (s *TaskScheduler) persistTaskQueue(ctx context.Context, queueMap map[int]api.TaskQueue) {
+	for taskID := range s.persistedQueue {
+		if _, ok := queueMap[taskID]; !ok {
+			delete(s.persistedQueue, taskID)
+		}
+	}
+	for taskID, queue := range queueMap {
+		if prev, ok := s.persistedQueue[taskID]; ok && prev == queue {
+			continue
+		}
+		bytes, err := json.Marshal(queue)
+		if err != nil {
+			log.Error("Failed to marshal task queue", zap.Int("task_id", taskID), zap.Error(err))
+			continue
+		}
+		if err := s.server.store.PatchTaskRunQueue(ctx, taskID, string(bytes)); err != nil {
+			log.Error("Failed to persist task queue", zap.Int("task_id", taskID), zap.Error(err))
+			continue
+		}
+		s.persistedQueue[taskID] = queue
+	}
+}
+
+// cancelStoppedTaskExecutors cancels the task executors of the tasks which are no longer RUNNING, e.g. the task is
+// canceled through a replica which isn't the leader. runningTaskList is the list of the RUNNING tasks.
+func (s *TaskScheduler) cancelStoppedTaskExecutors(runningTaskList []*api.Task) {
+	runningTaskIDs := make(map[int]bool)
+	for _, task := range runningTaskList {
+		runningTaskIDs[task.ID] = true
+	}
+	s.runningExecutorsMutex.Lock()
+	defer s.runningExecutorsMutex.Unlock()
+	for taskID, cancel := range s.runningExecutorsCancel {
+		if !runningTaskIDs[taskID] {
+			cancel()
+		}
+	}
+}
+
+// patchTaskRunProgress updates the progress of the running task run of the task.
+func (s *TaskScheduler) patchTaskRunProgress(ctx context.Context, taskID int, progress api.Progress) error {
+	bytes, err := json.Marshal(progress)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal task progress")
+	}
+	return s.server.store.PatchTaskRunProgress(ctx, taskID, string(bytes))
+}
+
+// shouldPersistTaskProgress returns whether the progress should be persisted given the progress persisted most recently.
+// The progress is persisted if it changes, or every taskProgressPersistInterval to refresh the elapsed time.
+func shouldPersistTaskProgress(prev api.Progress, persisted bool, progress api.Progress) bool {
+	// The executor doesn't report progress.
+	if progress.CreatedTs == 0 {
+		return false
+	}
+	if !persisted {
+		return true
+	}
+	if progress.UpdatedTs-prev.UpdatedTs >= int64(taskProgressPersistInterval.Seconds()) {
+		return true
+	}
+	prev.UpdatedTs = progress.UpdatedTs
+	return prev != progress
+}
+
+// getTaskConcurrencyLimitMap returns the mapping from environment ID to the task concurrency policy for the environments of the tasks.
+func (s *TaskScheduler) getTaskConcurrencyLimitMap(ctx context.Context, taskList []*api.Task) map[int]*api.TaskConcurrencyPolicy {
+	limitMap := make(map[int]*api.TaskConcurrencyPolicy)
+	for _, task := range taskList {
+		environmentID := task.Instance.EnvironmentID
+		if _, ok := limitMap[environmentID]; ok {
+			continue
+		}
+		policy, err := s.server.store.GetTaskConcurrencyPolicyByEnvID(ctx, environmentID)
+		if err != nil {
+			log.Error("Failed to get task concurrency policy, skip the concurrency limit",
+				zap.Int("environment_id", environmentID),
+				zap.Error(err),
+			)
+			policy = &api.TaskConcurrencyPolicy{}
+		}
+		limitMap[environmentID] = policy
+	}
+	return limitMap
+}
+
+// getMaintenanceWindowPolicyMap returns the mapping from environment ID to its maintenance window policy for the
+// tasks. The environment whose policy fails to load is left out, so that its tasks keep waiting.
+func (s *TaskScheduler) getMaintenanceWindowPolicyMap(ctx context.Context, taskList []*api.Task) map[int]*api.MaintenanceWindowPolicy {
+	policyMap := make(map[int]*api.MaintenanceWindowPolicy)
+	for _, task := range taskList {
+		environmentID := task.Instance.EnvironmentID
+		if _, ok := policyMap[environmentID]; ok {
+			continue
+		}
+		policy, err := s.server.store.GetMaintenanceWindowPolicyByEnvID(ctx, environmentID)
+		if err != nil {
+			log.Error("Failed to get maintenance window policy, hold up the tasks",
+				zap.Int("environment_id", environmentID),
+				zap.Error(err),
+			)
+			continue
+		}
+		policyMap[environmentID] = policy
+	}
+	return policyMap
+}
+
+// retryTaskIfNeeded schedules the task failed with a transient error to run again after the backoff if the task retry
+// policy of the environment allows. The failed run is recorded as a failed task run, and the retry creates a new one.
+// It returns true if the task is scheduled to retry.
+func (s *TaskScheduler) retryTaskIfNeeded(ctx context.Context, task *api.Task, taskErr error) bool {
+	if !api.TaskRetryPolicyTaskTypes[task.Type] || !db.IsRetryableError(task.Instance.Engine, taskErr) {
+		return false
+	}
+	policy, err := s.server.store.GetTaskRetryPolicyByEnvID(ctx, task.Instance.EnvironmentID)
+	if err != nil {
+		log.Error("Failed to get task retry policy",
+			zap.Int("task_id", task.ID),
+			zap.Int("environment_id", task.Instance.EnvironmentID),
+			zap.Error(err),
+		)
+		return false
+	}
+	rule := policy.GetRule(task.Type)
+	if rule == nil {
+		return false
+	}
+	attempt := getTaskRetryAttempt(task.TaskRunList) + 1
+	if attempt >= rule.MaxAttempts {
+		return false
+	}
+
+	backoff := rule.GetBackoff(attempt)
+	retryTs := time.Now().Add(backoff).Unix()
+	bytes, err := json.Marshal(api.TaskRunResultPayload{
+		Detail:       taskErr.Error(),
+		RetryAttempt: attempt,
+		RetryTs:      retryTs,
+	})
+	if err != nil {
+		log.Error("Failed to marshal task run result",
+			zap.Int("task_id", task.ID),
+			zap.String("type", string(task.Type)),
+			zap.Error(err),
+		)
+		return false
+	}
+	// Delay the next run by the backoff.
+	if _, err := s.server.store.PatchTask(ctx, &api.TaskPatch{
+		ID:                task.ID,
+		UpdaterID:         api.SystemBotID,
+		EarliestAllowedTs: &retryTs,
+	}); err != nil {
+		log.Error("Failed to delay the task to retry",
+			zap.Int("task_id", task.ID),
+			zap.Error(err),
+		)
+		return false
+	}
+	if task.Type == api.TaskDatabaseBackup {
+		var payload api.TaskDatabaseBackupPayload
+		if err := json.Unmarshal([]byte(task.Payload), &payload); err != nil {
+			log.Error("Failed to parse the payload of backup task",
+				zap.Int("task_id", task.ID),
+				zap.Error(err),
+			)
+			return false
+		}
+		statusPendingCreate := string(api.BackupStatusPendingCreate)
+		if _, err := s.server.store.PatchBackup(ctx, &api.BackupPatch{
+			ID:        payload.BackupID,
+			UpdaterID: api.SystemBotID,
+			Status:    &statusPendingCreate,
+		}); err != nil {
+			log.Error("Failed to reset the backup status to retry",
+				zap.Int("task_id", task.ID),
+				zap.Int("backup_id", payload.BackupID),
+				zap.Error(err),
+			)
+			return false
+		}
+	}
+
+	code := common.ErrorCode(taskErr)
+	result := string(bytes)
+	comment := fmt.Sprintf("Retry in %v (attempt %d of %d) after the transient error.", backoff, attempt+1, rule.MaxAttempts)
+	if _, err := s.server.patchTaskStatus(ctx, task, &api.TaskStatusPatch{
+		IDList:    []int{task.ID},
+		UpdaterID: api.SystemBotID,
+		Status:    api.TaskPending,
+		Code:      &code,
+		Comment:   &comment,
+		Result:    &result,
+	}); err != nil {
+		log.Error("Failed to mark task as PENDING to retry",
+			zap.Int("id", task.ID),
+			zap.String("name", task.Name),
+			zap.Error(err),
+		)
+		return false
+	}
+	return true
+}
+
+// getTaskRetryAttempt returns the number of the consecutive latest task runs failed and retried automatically.
+func getTaskRetryAttempt(taskRunList []*api.TaskRun) int {
+	runList := make([]*api.TaskRun, len(taskRunList))
+	copy(runList, taskRunList)
+	sort.Slice(runList, func(i, j int) bool {
+		return runList[i].ID > runList[j].ID
+	})
+	attempt := 0
+	for _, run := range runList {
+		if run.Status == api.TaskRunRunning {
+			continue
+		}
+		if run.Status != api.TaskRunFailed {
+			break
+		}
+		var result api.TaskRunResultPayload
+		if err := json.Unmarshal([]byte(run.Result), &result); err != nil || result.RetryAttempt == 0 {
+			break
+		}
+		attempt++
+	}
+	return attempt
+}
+
+// getTaskRunOutcome returns the outcome of a single task executor run for metrics.
+func getTaskRunOutcome(ctx context.Context, done bool, err error) prometheus.Outcome {
+	if ctx.Err() != nil {
+		return prometheus.OutcomeCanceled
+	}
+	if !done && err != nil {
+		return prometheus.OutcomeRetry
+	}
+	return prometheus.OutcomeFromError(err)
+}
+
 // Register will register a task executor factory.
 func (s *TaskScheduler) Register(taskType api.TaskType, executorGetter func() TaskExecutor) {
 	if executorGetter == nil {
@@ -317,25 +655,30 @@ func (s *TaskScheduler) Register(taskType api.TaskType, executorGetter func() Ta
 	s.executorGetters[taskType] = executorGetter
 }
 
-// ClearRunningTasks changes all RUNNING tasks to CANCELED.
-// When there are running tasks and Bytebase server is shutdown, these task executors are stopped, but the tasks' status are still RUNNING.
-// When Bytebase is restarted, the task scheduler will re-schedule those RUNNING tasks, which should be CANCELED instead.
-// So we change their status to CANCELED before starting the scheduler.
-func (s *TaskScheduler) ClearRunningTasks(ctx context.Context) error {
+// AdoptRunningTasks takes over the RUNNING tasks left over by the previous leader before starting the scheduler.
+// When the previous leader exits or loses the leader lock, its task executors are stopped, but the tasks' status are still RUNNING.
+// The tasks held up in the queue have never started, and the task scheduler re-schedules them.
+// The started tasks may be stopped halfway, and rerunning them may apply the statements twice, so they are changed to CANCELED instead.
+func (s *TaskScheduler) AdoptRunningTasks(ctx context.Context) error {
 	taskFind := &api.TaskFind{StatusList: &[]api.TaskStatus{api.TaskRunning}}
 	runningTasks, err := s.server.store.FindTask(ctx, taskFind, false)
 	if err != nil {
 		return errors.Wrap(err, "failed to get running tasks")
 	}
 	for _, task := range runningTasks {
+		if !isTaskRunStarted(task) {
+			continue
+		}
+		comment := "Canceled because the server running the task exited or lost the leadership."
 		if _, err := s.server.store.PatchTaskStatus(ctx, &api.TaskStatusPatch{
 			IDList:    []int{task.ID},
 			UpdaterID: api.SystemBotID,
 			Status:    api.TaskCanceled,
+			Comment:   &comment,
 		}); err != nil {
-			return errors.Wrapf(err, "failed to change task %d's status to %s", task.ID, api.TaskFailed)
+			return errors.Wrapf(err, "failed to change task %d's status to %s", task.ID, api.TaskCanceled)
 		}
-		log.Debug(fmt.Sprintf("Changed task %d's status from RUNNING to %s", task.ID, api.TaskFailed))
+		log.Debug(fmt.Sprintf("Changed task %d's status from RUNNING to %s", task.ID, api.TaskCanceled))
 		// If it's a backup task, we also change the corresponding backup's status to FAILED, because the task is canceled just now.
 		if task.Type == api.TaskDatabaseBackup {
 			var payload api.TaskDatabaseBackupPayload
@@ -360,6 +703,28 @@ func (s *TaskScheduler) ClearRunningTasks(ctx context.Context) error {
 	return nil
 }
 
+// isTaskRunStarted returns whether the task executor has started to run the running task run of the task.
+func isTaskRunStarted(task *api.Task) bool {
+	for _, taskRun := range task.TaskRunList {
+		if taskRun.Status == api.TaskRunRunning && taskRun.StartedTs == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// stopAllRunningExecutors cancels all the running task executors and waits for them to exit, e.g. when the server
+// steps down from the leader. The leader lock is released afterwards, so that the new leader doesn't run the same
+// tasks at the same time.
+func (s *TaskScheduler) stopAllRunningExecutors() {
+	s.runningExecutorsMutex.Lock()
+	for _, cancel := range s.runningExecutorsCancel {
+		cancel()
+	}
+	s.runningExecutorsMutex.Unlock()
+	s.runningExecutorsWG.Wait()
+}
+
 func (s *TaskScheduler) passAllCheck(ctx context.Context, task *api.Task, allowedStatus api.TaskCheckStatus) (bool, error) {
 	// schema update, data update and gh-ost sync task have required task check.
 	if task.Type == api.TaskDatabaseSchemaUpdate || task.Type == api.TaskDatabaseSchemaUpdateSDL || task.Type == api.TaskDatabaseDataUpdate || task.Type == api.TaskDatabaseSchemaUpdateGhostSync {
@@ -449,13 +814,40 @@ func (s *TaskScheduler) canSchedule(ctx context.Context, task *api.Task) (bool,
 		return false, nil
 	}
 
+	policy, err := s.server.store.GetMaintenanceWindowPolicyByEnvID(ctx, task.Instance.EnvironmentID)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to get the maintenance window policy")
+	}
+	if getTaskMaintenanceWindow(task, policy, time.Now()).Reason != "" {
+		return false, nil
+	}
+
 	return s.passAllCheck(ctx, task, api.TaskCheckStatusWarn)
 }
 
+// getTaskMaintenanceWindow returns the maintenance window status of the task at now, which is empty if the task is allowed to start.
+func getTaskMaintenanceWindow(task *api.Task, policy *api.MaintenanceWindowPolicy, now time.Time) api.TaskMaintenanceWindow {
+	if task.MaintenanceWindowOverride {
+		return api.TaskMaintenanceWindow{}
+	}
+	ok, reason := policy.Check(now)
+	if ok {
+		return api.TaskMaintenanceWindow{}
+	}
+	maintenanceWindow := api.TaskMaintenanceWindow{
+		Reason: reason,
+	}
+	if next := policy.GetNextAllowedTime(now); !next.IsZero() {
+		maintenanceWindow.NextAllowedTs = next.Unix()
+	}
+	return maintenanceWindow
+}
+
 // ScheduleIfNeeded schedules the task if
 //  1. its required check does not contain error in the latest run.
 //  2. it has no blocking tasks.
 //  3. it has passed the earliest allowed time.
+//  4. it's within the maintenance windows and not in a change freeze of the environment, unless overridden by the owner.
 func (s *TaskScheduler) ScheduleIfNeeded(ctx context.Context, task *api.Task) (*api.Task, error) {
 	schedule, err := s.canSchedule(ctx, task)
 	if err != nil {
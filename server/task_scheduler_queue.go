@@ -0,0 +1,125 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+)
+
+// arrangeTaskQueue decides which of the waiting tasks can be executed now under the task concurrency limits.
+// The waiting tasks are queued fairly between projects, so that a project with many tasks, e.g. a tenant deployment
+// across hundreds of databases, doesn't starve the others. The project with the fewest executing tasks goes first,
+// and the earliest task breaks the tie.
+// limitMap is the mapping from environment ID to its task concurrency policy.
+// It returns the tasks to execute and the queue status of the tasks that have to keep waiting.
+func arrangeTaskQueue(executingTaskList []*api.Task, waitingTaskList []*api.Task, limitMap map[int]*api.TaskConcurrencyPolicy) ([]*api.Task, map[int]api.TaskQueue) {
+	instanceCount := make(map[int]int)
+	environmentCount := make(map[int]int)
+	projectCount := make(map[int]int)
+	for _, task := range executingTaskList {
+		instanceCount[task.InstanceID]++
+		environmentCount[task.Instance.EnvironmentID]++
+		projectCount[getTaskProjectID(task)]++
+	}
+
+	projectQueue := make(map[int][]*api.Task)
+	for _, task := range waitingTaskList {
+		projectID := getTaskProjectID(task)
+		projectQueue[projectID] = append(projectQueue[projectID], task)
+	}
+	for _, queue := range projectQueue {
+		sort.Slice(queue, func(i, j int) bool {
+			return queue[i].ID < queue[j].ID
+		})
+	}
+
+	var taskList []*api.Task
+	for len(projectQueue) > 0 {
+		nextProjectID := 0
+		var next *api.Task
+		for projectID, queue := range projectQueue {
+			if next == nil ||
+				projectCount[projectID] < projectCount[nextProjectID] ||
+				(projectCount[projectID] == projectCount[nextProjectID] && queue[0].ID < next.ID) {
+				nextProjectID = projectID
+				next = queue[0]
+			}
+		}
+		taskList = append(taskList, next)
+		projectCount[nextProjectID]++
+		if len(projectQueue[nextProjectID]) == 1 {
+			delete(projectQueue, nextProjectID)
+		} else {
+			projectQueue[nextProjectID] = projectQueue[nextProjectID][1:]
+		}
+	}
+
+	var runnableTaskList []*api.Task
+	queueMap := make(map[int]api.TaskQueue)
+	instanceQueueLength := make(map[int]int)
+	for _, task := range taskList {
+		reason := ""
+		if limit, ok := limitMap[task.Instance.EnvironmentID]; ok {
+			if limit.InstanceMaxConcurrentTasks > 0 && instanceCount[task.InstanceID] >= limit.InstanceMaxConcurrentTasks {
+				reason = fmt.Sprintf("Instance %q has reached the limit of %d concurrent tasks", task.Instance.Name, limit.InstanceMaxConcurrentTasks)
+			} else if limit.MaxConcurrentTasks > 0 && environmentCount[task.Instance.EnvironmentID] >= limit.MaxConcurrentTasks {
+				reason = fmt.Sprintf("Environment has reached the limit of %d concurrent tasks", limit.MaxConcurrentTasks)
+			}
+		}
+		if reason != "" {
+			instanceQueueLength[task.InstanceID]++
+			queueMap[task.ID] = api.TaskQueue{
+				Position: instanceQueueLength[task.InstanceID],
+				Reason:   reason,
+			}
+			continue
+		}
+		runnableTaskList = append(runnableTaskList, task)
+		instanceCount[task.InstanceID]++
+		environmentCount[task.Instance.EnvironmentID]++
+	}
+	return runnableTaskList, queueMap
+}
+
+// holdTaskQueueByMaintenanceWindow holds up the waiting tasks which are not allowed to start now by the maintenance
+// windows and change freezes of their environments. The tasks may have waited in the queue since they were scheduled
+// within the window. policyMap is the mapping from environment ID to its maintenance window policy, and the tasks of
+// an environment without the policy are held up as well.
+// It returns the tasks allowed to start and the queue status of the held tasks.
+func holdTaskQueueByMaintenanceWindow(waitingTaskList []*api.Task, policyMap map[int]*api.MaintenanceWindowPolicy, now time.Time) ([]*api.Task, map[int]api.TaskQueue) {
+	var allowedTaskList []*api.Task
+	queueMap := make(map[int]api.TaskQueue)
+	for _, task := range waitingTaskList {
+		reason := "Waiting for maintenance window"
+		if policy, ok := policyMap[task.Instance.EnvironmentID]; ok {
+			maintenanceWindow := getTaskMaintenanceWindow(task, policy, now)
+			if maintenanceWindow.Reason == "" {
+				allowedTaskList = append(allowedTaskList, task)
+				continue
+			}
+			reason = fmt.Sprintf("%s: %s", reason, maintenanceWindow.Reason)
+		}
+		queueMap[task.ID] = api.TaskQueue{
+			Reason: reason,
+		}
+	}
+	return allowedTaskList, queueMap
+}
+
+// getTaskProjectID returns the ID of the project owning the task.
+// The database is not created yet for the creating database task, so we get the project from the task payload.
+func getTaskProjectID(task *api.Task) int {
+	if task.Database != nil {
+		return task.Database.ProjectID
+	}
+	payload := &struct {
+		ProjectID int `json:"projectId,omitempty"`
+	}{}
+	if err := json.Unmarshal([]byte(task.Payload), payload); err != nil || payload.ProjectID == 0 {
+		return api.DefaultProjectID
+	}
+	return payload.ProjectID
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bytebase/bytebase/api"
+)
+
+func TestArrangeTaskQueue(t *testing.T) {
+	instance1 := &api.Instance{ID: 1, Name: "instance1", EnvironmentID: 1}
+	instance2 := &api.Instance{ID: 2, Name: "instance2", EnvironmentID: 1}
+	newTask := func(id int, instance *api.Instance, projectID int) *api.Task {
+		return &api.Task{
+			ID:         id,
+			InstanceID: instance.ID,
+			Instance:   instance,
+			Database:   &api.Database{ProjectID: projectID},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		executingList []*api.Task
+		waitingList   []*api.Task
+		limitMap      map[int]*api.TaskConcurrencyPolicy
+		wantRunnable  []int
+		wantQueue     map[int]int
+	}{
+		{
+			name: "no limit",
+			waitingList: []*api.Task{
+				newTask(1, instance1, 101),
+				newTask(2, instance1, 101),
+			},
+			limitMap: map[int]*api.TaskConcurrencyPolicy{
+				1: {},
+			},
+			wantRunnable: []int{1, 2},
+			wantQueue:    map[int]int{},
+		},
+		{
+			name: "fair between projects on the same instance",
+			waitingList: []*api.Task{
+				newTask(1, instance1, 101),
+				newTask(2, instance1, 101),
+				newTask(3, instance1, 101),
+				newTask(4, instance1, 102),
+			},
+			limitMap: map[int]*api.TaskConcurrencyPolicy{
+				1: {InstanceMaxConcurrentTasks: 2},
+			},
+			wantRunnable: []int{1, 4},
+			wantQueue:    map[int]int{2: 1, 3: 2},
+		},
+		{
+			name: "project with executing tasks goes after",
+			executingList: []*api.Task{
+				newTask(1, instance1, 101),
+			},
+			waitingList: []*api.Task{
+				newTask(2, instance1, 101),
+				newTask(3, instance1, 102),
+			},
+			limitMap: map[int]*api.TaskConcurrencyPolicy{
+				1: {InstanceMaxConcurrentTasks: 2},
+			},
+			wantRunnable: []int{3},
+			wantQueue:    map[int]int{2: 1},
+		},
+		{
+			name: "environment limit",
+			executingList: []*api.Task{
+				newTask(1, instance1, 101),
+			},
+			waitingList: []*api.Task{
+				newTask(2, instance2, 101),
+				newTask(3, instance2, 102),
+			},
+			limitMap: map[int]*api.TaskConcurrencyPolicy{
+				1: {MaxConcurrentTasks: 2, InstanceMaxConcurrentTasks: 2},
+			},
+			wantRunnable: []int{3},
+			wantQueue:    map[int]int{2: 1},
+		},
+	}
+
+	for _, test := range tests {
+		runnableList, queueMap := arrangeTaskQueue(test.executingList, test.waitingList, test.limitMap)
+		var runnable []int
+		for _, task := range runnableList {
+			runnable = append(runnable, task.ID)
+		}
+		assert.Equal(t, test.wantRunnable, runnable, test.name)
+		queue := make(map[int]int)
+		for taskID, q := range queueMap {
+			queue[taskID] = q.Position
+		}
+		assert.Equal(t, test.wantQueue, queue, test.name)
+	}
+}
+
+func TestHoldTaskQueueByMaintenanceWindow(t *testing.T) {
+	instance1 := &api.Instance{ID: 1, Name: "instance1", EnvironmentID: 1}
+	instance2 := &api.Instance{ID: 2, Name: "instance2", EnvironmentID: 2}
+	instance3 := &api.Instance{ID: 3, Name: "instance3", EnvironmentID: 3}
+	now := time.Date(2022, 11, 7, 12, 0, 0, 0, time.UTC)
+	policyMap := map[int]*api.MaintenanceWindowPolicy{
+		1: {
+			WindowList: []api.MaintenanceWindow{
+				{StartTime: "02:00", EndTime: "04:00"},
+			},
+		},
+		2: {},
+	}
+	waitingTaskList := []*api.Task{
+		{ID: 1, InstanceID: 1, Instance: instance1},
+		{ID: 2, InstanceID: 1, Instance: instance1, MaintenanceWindowOverride: true},
+		{ID: 3, InstanceID: 2, Instance: instance2},
+		// The maintenance window policy of the environment fails to load.
+		{ID: 4, InstanceID: 3, Instance: instance3},
+	}
+
+	allowedTaskList, queueMap := holdTaskQueueByMaintenanceWindow(waitingTaskList, policyMap, now)
+	var allowed []int
+	for _, task := range allowedTaskList {
+		allowed = append(allowed, task.ID)
+	}
+	assert.Equal(t, []int{2, 3}, allowed)
+	assert.Equal(t, map[int]api.TaskQueue{
+		1: {Reason: "Waiting for maintenance window: Outside the maintenance windows"},
+		4: {Reason: "Waiting for maintenance window"},
+	}, queueMap)
+}
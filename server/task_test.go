@@ -85,3 +85,170 @@ func TestAreAllTasksDone(t *testing.T) {
 		assert.Equal(t, test.want, res)
 	}
 }
+
+func TestGetTaskRetryAttempt(t *testing.T) {
+	tests := []struct {
+		taskRunList []*api.TaskRun
+		want        int
+	}{
+		{
+			taskRunList: nil,
+			want:        0,
+		},
+		{
+			taskRunList: []*api.TaskRun{
+				{ID: 1, Status: api.TaskRunFailed, Result: `{"detail":"connection reset","retryAttempt":1}`},
+				{ID: 2, Status: api.TaskRunFailed, Result: `{"detail":"lock wait timeout","retryAttempt":2}`},
+				{ID: 3, Status: api.TaskRunRunning},
+			},
+			want: 2,
+		},
+		{
+			// A manual retry after a non-retryable failure starts counting again.
+			taskRunList: []*api.TaskRun{
+				{ID: 1, Status: api.TaskRunFailed, Result: `{"detail":"lock wait timeout","retryAttempt":1}`},
+				{ID: 2, Status: api.TaskRunFailed, Result: `{"detail":"syntax error"}`},
+				{ID: 3, Status: api.TaskRunFailed, Result: `{"detail":"lock wait timeout","retryAttempt":1}`},
+			},
+			want: 1,
+		},
+		{
+			taskRunList: []*api.TaskRun{
+				{ID: 1, Status: api.TaskRunFailed, Result: `{"detail":"lock wait timeout","retryAttempt":1}`},
+				{ID: 2, Status: api.TaskRunDone, Result: `{"detail":"done"}`},
+			},
+			want: 0,
+		},
+	}
+
+	for _, test := range tests {
+		res := getTaskRetryAttempt(test.taskRunList)
+		assert.Equal(t, test.want, res)
+	}
+}
+
+func TestIsTaskRunStarted(t *testing.T) {
+	tests := []struct {
+		taskRunList []*api.TaskRun
+		want        bool
+	}{
+		{
+			// The task is held up in the queue.
+			taskRunList: []*api.TaskRun{
+				{ID: 1, Status: api.TaskRunRunning},
+			},
+			want: false,
+		},
+		{
+			taskRunList: []*api.TaskRun{
+				{ID: 1, Status: api.TaskRunRunning, StartedTs: 1667433600},
+			},
+			want: true,
+		},
+		{
+			taskRunList: []*api.TaskRun{
+				{ID: 1, Status: api.TaskRunFailed, StartedTs: 1667433600},
+				{ID: 2, Status: api.TaskRunRunning},
+			},
+			want: false,
+		},
+	}
+
+	for _, test := range tests {
+		res := isTaskRunStarted(&api.Task{TaskRunList: test.taskRunList})
+		assert.Equal(t, test.want, res)
+	}
+}
+
+func TestCancelStoppedTaskExecutors(t *testing.T) {
+	s := NewTaskScheduler(nil)
+	canceled := make(map[int]bool)
+	for _, taskID := range []int{1, 2, 3} {
+		taskID := taskID
+		s.runningExecutorsCancel[taskID] = func() { canceled[taskID] = true }
+	}
+
+	// Task 2 is canceled through a replica which isn't the leader.
+	s.cancelStoppedTaskExecutors([]*api.Task{{ID: 1}, {ID: 3}, {ID: 4}})
+	assert.Equal(t, map[int]bool{2: true}, canceled)
+}
+
+func TestSetTaskProgressFromTaskRun(t *testing.T) {
+	task := &api.Task{
+		TaskRunList: []*api.TaskRun{
+			{ID: 1, Status: api.TaskRunFailed, Progress: `{"totalUnit":3,"completedUnit":1}`},
+			{ID: 2, Status: api.TaskRunRunning, Progress: `{"totalUnit":3,"completedUnit":2}`, Queue: `{}`},
+		},
+	}
+	setTaskProgressFromTaskRun(task)
+	assert.Equal(t, api.Progress{TotalUnit: 3, CompletedUnit: 2}, task.Progress)
+	assert.Equal(t, api.TaskQueue{}, task.Queue)
+
+	task = &api.Task{
+		TaskRunList: []*api.TaskRun{
+			{ID: 1, Status: api.TaskRunRunning, Progress: `{}`, Queue: `{"position":2,"reason":"Waiting for the instance concurrency limit"}`},
+		},
+	}
+	setTaskProgressFromTaskRun(task)
+	assert.Equal(t, api.TaskQueue{Position: 2, Reason: "Waiting for the instance concurrency limit"}, task.Queue)
+}
+
+func TestShouldPersistTaskProgress(t *testing.T) {
+	progress := api.Progress{
+		TotalUnit:         10,
+		CompletedUnit:     3,
+		CreatedTs:         1000,
+		UpdatedTs:         1010,
+		TotalStatement:    10,
+		ExecutedStatement: 3,
+		CurrentStatement:  "ALTER TABLE t ADD COLUMN c INT;",
+	}
+	next := progress
+	next.UpdatedTs = 1011
+	nextStatement := next
+	nextStatement.CompletedUnit = 4
+	nextStatement.ExecutedStatement = 4
+	nextStatement.CurrentStatement = "ALTER TABLE t ADD COLUMN d INT;"
+	later := progress
+	later.UpdatedTs = 1015
+
+	tests := []struct {
+		prev      api.Progress
+		persisted bool
+		progress  api.Progress
+		want      bool
+	}{
+		{
+			// The executor doesn't report progress.
+			progress: api.Progress{},
+			want:     false,
+		},
+		{
+			progress: progress,
+			want:     true,
+		},
+		{
+			prev:      progress,
+			persisted: true,
+			progress:  next,
+			want:      false,
+		},
+		{
+			prev:      progress,
+			persisted: true,
+			progress:  nextStatement,
+			want:      true,
+		},
+		{
+			prev:      progress,
+			persisted: true,
+			progress:  later,
+			want:      true,
+		},
+	}
+
+	for _, test := range tests {
+		res := shouldPersistTaskProgress(test.prev, test.persisted, test.progress)
+		assert.Equal(t, test.want, res)
+	}
+}
@@ -0,0 +1,32 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+
+	"github.com/bytebase/bytebase/plugin/tracing"
+)
+
+// tracingMiddleware starts a span for each request and propagates it through the request context.
+// The incoming W3C trace context header is honored so that the span joins the caller's trace.
+func tracingMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		ctx := otel.GetTextMapPropagator().Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+		ctx, span := tracing.Start(ctx, fmt.Sprintf("%s %s", req.Method, c.Path()),
+			semconv.HTTPMethodKey.String(req.Method),
+			semconv.HTTPRouteKey.String(c.Path()),
+			semconv.HTTPTargetKey.String(req.URL.RequestURI()),
+		)
+		c.SetRequest(req.WithContext(ctx))
+
+		err := next(c)
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(c.Response().Status))
+		tracing.End(span, err)
+		return err
+	}
+}
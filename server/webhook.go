@@ -15,6 +15,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
@@ -28,6 +29,10 @@ import (
 	advisorDB "github.com/bytebase/bytebase/plugin/advisor/db"
 	"github.com/bytebase/bytebase/plugin/db"
 	"github.com/bytebase/bytebase/plugin/vcs"
+	"github.com/bytebase/bytebase/plugin/vcs/azure"
+	"github.com/bytebase/bytebase/plugin/vcs/bitbucket"
+	"github.com/bytebase/bytebase/plugin/vcs/bitbucketserver"
+	"github.com/bytebase/bytebase/plugin/vcs/gitea"
 	"github.com/bytebase/bytebase/plugin/vcs/github"
 	"github.com/bytebase/bytebase/plugin/vcs/gitlab"
 )
@@ -54,18 +59,36 @@ func (s *Server) registerWebhookRoutes(g *echo.Group) {
 		if err := json.Unmarshal(body, &pushEvent); err != nil {
 			return echo.NewHTTPError(http.StatusBadRequest, "Malformed push event").SetInternal(err)
 		}
-		// This shouldn't happen as we only setup webhook to receive push event, just in case.
+		verify := func(repo *api.Repository) (bool, error) {
+			ok := c.Request().Header.Get("X-Gitlab-Token") == repo.WebhookSecretToken
+			setWebhookVerification(c, ok)
+			return ok, nil
+		}
+		if pushEvent.ObjectKind == gitlab.WebhookMergeRequest {
+			var mergeRequestEvent gitlab.WebhookMergeRequestEvent
+			if err := json.Unmarshal(body, &mergeRequestEvent); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "Malformed merge request event").SetInternal(err)
+			}
+			pullRequestEvent, ok := mergeRequestEvent.ToVCS()
+			if !ok {
+				log.Debug("Ignored merge request event", zap.String("action", mergeRequestEvent.ObjectAttributes.Action))
+				return c.String(http.StatusOK, "OK")
+			}
+			return s.handlePullRequestWebhook(c, pullRequestEvent, verify)
+		}
+		// This shouldn't happen as we only setup webhook to receive push and merge request events, just in case.
 		if pushEvent.ObjectKind != gitlab.WebhookPush {
 			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid webhook event type, got %s, want push", pushEvent.ObjectKind))
 		}
 		repositoryID := fmt.Sprintf("%v", pushEvent.Project.ID)
 
 		filter := func(repo *api.Repository) (bool, error) {
-			if c.Request().Header.Get("X-Gitlab-Token") != repo.WebhookSecretToken {
-				return false, nil
+			ok, err := verify(repo)
+			if err != nil || !ok {
+				return false, err
 			}
 
-			return s.isWebhookEventBranch(pushEvent.Ref, repo.BranchFilter)
+			return s.isWebhookEventBranch(pushEvent.Ref, repo)
 		}
 		repositoryList, err := s.filterRepository(ctx, c.Param("id"), repositoryID, filter)
 		if err != nil {
@@ -86,12 +109,12 @@ func (s *Server) registerWebhookRoutes(g *echo.Group) {
 			return err
 		}
 		return c.String(http.StatusOK, strings.Join(createdMessages, "\n"))
-	})
+	}, s.webhookDeliveryMiddleware(vcs.GitLabSelfHost))
 
 	g.POST("/github/:id", func(c echo.Context) error {
 		ctx := c.Request().Context()
 
-		// This shouldn't happen as we only setup webhook to receive push event, just in case.
+		// This shouldn't happen as we only setup webhook to receive push and pull request events, just in case.
 		eventType := github.WebhookType(c.Request().Header.Get("X-GitHub-Event"))
 		// https://docs.github.com/en/developers/webhooks-and-events/webhooks/about-webhooks#ping-event
 		// When we create a new webhook, GitHub will send us a simple ping event to let us know we've set up the webhook correctly.
@@ -99,14 +122,35 @@ func (s *Server) registerWebhookRoutes(g *echo.Group) {
 		if eventType == github.WebhookPing {
 			return c.String(http.StatusOK, "OK")
 		}
-		if eventType != github.WebhookPush {
-			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid webhook event type, got %s, want %s", eventType, github.WebhookPush))
+		if eventType != github.WebhookPush && eventType != github.WebhookPullRequest {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid webhook event type, got %s, want %s or %s", eventType, github.WebhookPush, github.WebhookPullRequest))
 		}
 
 		body, err := io.ReadAll(c.Request().Body)
 		if err != nil {
 			return echo.NewHTTPError(http.StatusBadRequest, "Failed to read webhook request").SetInternal(err)
 		}
+		verify := func(repo *api.Repository) (bool, error) {
+			ok, err := validateGitHubWebhookSignature256(c.Request().Header.Get("X-Hub-Signature-256"), repo.WebhookSecretToken, body)
+			if err != nil {
+				return false, echo.NewHTTPError(http.StatusInternalServerError, "Failed to validate GitHub webhook signature").SetInternal(err)
+			}
+			setWebhookVerification(c, ok)
+			return ok, nil
+		}
+		if eventType == github.WebhookPullRequest {
+			var pullRequestPayload github.WebhookPullRequestEvent
+			if err := json.Unmarshal(body, &pullRequestPayload); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "Malformed pull request event").SetInternal(err)
+			}
+			pullRequestEvent, ok := pullRequestPayload.ToVCS()
+			if !ok {
+				log.Debug("Ignored pull request event", zap.String("action", pullRequestPayload.Action))
+				return c.String(http.StatusOK, "OK")
+			}
+			return s.handlePullRequestWebhook(c, pullRequestEvent, verify)
+		}
+
 		var pushEvent github.WebhookPushEvent
 		if err := json.Unmarshal(body, &pushEvent); err != nil {
 			return echo.NewHTTPError(http.StatusBadRequest, "Malformed push event").SetInternal(err)
@@ -114,15 +158,63 @@ func (s *Server) registerWebhookRoutes(g *echo.Group) {
 		repositoryID := pushEvent.Repository.FullName
 
 		filter := func(repo *api.Repository) (bool, error) {
-			ok, err := validateGitHubWebhookSignature256(c.Request().Header.Get("X-Hub-Signature-256"), repo.WebhookSecretToken, body)
+			ok, err := verify(repo)
+			if err != nil || !ok {
+				return false, err
+			}
+
+			return s.isWebhookEventBranch(pushEvent.Ref, repo)
+		}
+		repositoryList, err := s.filterRepository(ctx, c.Param("id"), repositoryID, filter)
+		if err != nil {
+			return err
+		}
+		if len(repositoryList) == 0 {
+			log.Debug("Empty handle repo list. Ignore this push event.")
+			return c.String(http.StatusOK, "OK")
+		}
+
+		baseVCSPushEvent := pushEvent.ToVCS()
+
+		createdMessages, err := s.processPushEvent(ctx, repositoryList, baseVCSPushEvent)
+		if err != nil {
+			return err
+		}
+		return c.String(http.StatusOK, strings.Join(createdMessages, "\n"))
+	}, s.webhookDeliveryMiddleware(vcs.GitHubCom))
+
+	g.POST("/gitea/:id", func(c echo.Context) error {
+		ctx := c.Request().Context()
+
+		// This shouldn't happen as we only setup webhook to receive push event, just in case.
+		// Forgejo sends the same X-Gitea-Event header for compatibility.
+		eventType := gitea.WebhookType(c.Request().Header.Get("X-Gitea-Event"))
+		if eventType != gitea.WebhookPush {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid webhook event type, got %s, want %s", eventType, gitea.WebhookPush))
+		}
+
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Failed to read webhook request").SetInternal(err)
+		}
+		var pushEvent gitea.WebhookPushEvent
+		if err := json.Unmarshal(body, &pushEvent); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformed push event").SetInternal(err)
+		}
+		repositoryID := pushEvent.Repository.FullName
+
+		filter := func(repo *api.Repository) (bool, error) {
+			// Gitea signs the payload with HMAC-SHA256 in hex without the "sha256=" prefix.
+			ok, err := validateGitHubWebhookSignature256(c.Request().Header.Get("X-Gitea-Signature"), repo.WebhookSecretToken, body)
 			if err != nil {
-				return false, echo.NewHTTPError(http.StatusInternalServerError, "Failed to validate GitHub webhook signature").SetInternal(err)
+				return false, echo.NewHTTPError(http.StatusInternalServerError, "Failed to validate Gitea webhook signature").SetInternal(err)
 			}
+			setWebhookVerification(c, ok)
 			if !ok {
 				return false, nil
 			}
 
-			return s.isWebhookEventBranch(pushEvent.Ref, repo.BranchFilter)
+			return s.isWebhookEventBranch(pushEvent.Ref, repo)
 		}
 		repositoryList, err := s.filterRepository(ctx, c.Param("id"), repositoryID, filter)
 		if err != nil {
@@ -140,7 +232,240 @@ func (s *Server) registerWebhookRoutes(g *echo.Group) {
 			return err
 		}
 		return c.String(http.StatusOK, strings.Join(createdMessages, "\n"))
-	})
+	}, s.webhookDeliveryMiddleware(vcs.Gitea))
+
+	g.POST("/bitbucket/:id", func(c echo.Context) error {
+		ctx := c.Request().Context()
+
+		// https://support.atlassian.com/bitbucket-cloud/docs/event-payloads/
+		// Bitbucket sends a ping event when the user tests the webhook connection.
+		eventType := bitbucket.WebhookType(c.Request().Header.Get("X-Event-Key"))
+		if eventType == bitbucket.WebhookPing {
+			return c.String(http.StatusOK, "OK")
+		}
+		if eventType != bitbucket.WebhookPush {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid webhook event type, got %s, want %s", eventType, bitbucket.WebhookPush))
+		}
+
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Failed to read webhook request").SetInternal(err)
+		}
+		var pushEvent bitbucket.WebhookPushEvent
+		if err := json.Unmarshal(body, &pushEvent); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformed push event").SetInternal(err)
+		}
+		repositoryID := pushEvent.Repository.FullName
+
+		// A single Bitbucket push event may update multiple branches.
+		var createdMessages []string
+		for _, baseVCSPushEvent := range pushEvent.ToVCS() {
+			ref := baseVCSPushEvent.Ref
+			filter := func(repo *api.Repository) (bool, error) {
+				// Bitbucket signs the payload in the same way as GitHub.
+				ok, err := validateGitHubWebhookSignature256(c.Request().Header.Get("X-Hub-Signature"), repo.WebhookSecretToken, body)
+				if err != nil {
+					return false, echo.NewHTTPError(http.StatusInternalServerError, "Failed to validate Bitbucket webhook signature").SetInternal(err)
+				}
+				setWebhookVerification(c, ok)
+				if !ok {
+					return false, nil
+				}
+
+				return s.isWebhookEventBranch(ref, repo)
+			}
+			repositoryList, err := s.filterRepository(ctx, c.Param("id"), repositoryID, filter)
+			if err != nil {
+				return err
+			}
+			if len(repositoryList) == 0 {
+				log.Debug("Empty handle repo list. Ignore this push event.", zap.String("ref", ref))
+				continue
+			}
+
+			// Bitbucket does not include the changed files in the push event, fetch them for each commit.
+			repo := repositoryList[0]
+			for i, commit := range baseVCSPushEvent.CommitList {
+				vcsCommit, err := vcs.Get(repo.VCS.Type, vcs.ProviderConfig{}).FetchCommitByID(
+					ctx,
+					common.OauthContext{
+						ClientID:     repo.VCS.ApplicationID,
+						ClientSecret: repo.VCS.Secret,
+						AccessToken:  repo.AccessToken,
+						RefreshToken: repo.RefreshToken,
+						Refresher:    s.refreshToken(ctx, repo.WebURL),
+					},
+					repo.VCS.InstanceURL,
+					repo.ExternalID,
+					commit.ID,
+				)
+				if err != nil {
+					return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch commit %s", commit.ID)).SetInternal(err)
+				}
+				baseVCSPushEvent.CommitList[i].AddedList = vcsCommit.AddedList
+				baseVCSPushEvent.CommitList[i].ModifiedList = vcsCommit.ModifiedList
+			}
+
+			messages, err := s.processPushEvent(ctx, repositoryList, baseVCSPushEvent)
+			if err != nil {
+				return err
+			}
+			createdMessages = append(createdMessages, messages...)
+		}
+		return c.String(http.StatusOK, strings.Join(createdMessages, "\n"))
+	}, s.webhookDeliveryMiddleware(vcs.BitbucketCloud))
+
+	g.POST("/bitbucket-server/:id", func(c echo.Context) error {
+		ctx := c.Request().Context()
+
+		// https://confluence.atlassian.com/bitbucketserver/event-payload-938025882.html
+		// Bitbucket Server sends a ping event when the user tests the webhook connection.
+		eventType := bitbucketserver.WebhookType(c.Request().Header.Get("X-Event-Key"))
+		if eventType == bitbucketserver.WebhookPing {
+			return c.String(http.StatusOK, "OK")
+		}
+		if eventType != bitbucketserver.WebhookPush {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid webhook event type, got %s, want %s", eventType, bitbucketserver.WebhookPush))
+		}
+
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Failed to read webhook request").SetInternal(err)
+		}
+		var pushEvent bitbucketserver.WebhookPushEvent
+		if err := json.Unmarshal(body, &pushEvent); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformed push event").SetInternal(err)
+		}
+
+		// A single Bitbucket Server push event may update multiple branches.
+		var createdMessages []string
+		baseVCSPushEventList, changeList := pushEvent.ToVCS()
+		for i, baseVCSPushEvent := range baseVCSPushEventList {
+			ref := baseVCSPushEvent.Ref
+			filter := func(repo *api.Repository) (bool, error) {
+				// Bitbucket Server signs the payload in the same way as GitHub.
+				ok, err := validateGitHubWebhookSignature256(c.Request().Header.Get("X-Hub-Signature"), repo.WebhookSecretToken, body)
+				if err != nil {
+					return false, echo.NewHTTPError(http.StatusInternalServerError, "Failed to validate Bitbucket Server webhook signature").SetInternal(err)
+				}
+				setWebhookVerification(c, ok)
+				if !ok {
+					return false, nil
+				}
+
+				return s.isWebhookEventBranch(ref, repo)
+			}
+			repositoryList, err := s.filterRepository(ctx, c.Param("id"), baseVCSPushEvent.RepositoryID, filter)
+			if err != nil {
+				return err
+			}
+			if len(repositoryList) == 0 {
+				log.Debug("Empty handle repo list. Ignore this push event.", zap.String("ref", ref))
+				continue
+			}
+
+			// Bitbucket Server includes neither the commits nor the changed files in
+			// the push event, fetch the pushed commits and then the changed files of each commit.
+			repo := repositoryList[0]
+			oauthCtx := common.OauthContext{
+				ClientID:     repo.VCS.ApplicationID,
+				ClientSecret: repo.VCS.Secret,
+				AccessToken:  repo.AccessToken,
+				RefreshToken: repo.RefreshToken,
+				Refresher:    s.refreshToken(ctx, repo.WebURL),
+			}
+			provider, ok := vcs.Get(repo.VCS.Type, vcs.ProviderConfig{}).(*bitbucketserver.Provider)
+			if !ok {
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Unexpected VCS type %s for Bitbucket Server webhook", repo.VCS.Type))
+			}
+			commitList, err := provider.FetchPushedCommitList(ctx, oauthCtx, repo.VCS.InstanceURL, repo.ExternalID, changeList[i])
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch commits pushed to %s", ref)).SetInternal(err)
+			}
+			for _, commit := range commitList {
+				vcsCommit, err := provider.FetchCommitByID(ctx, oauthCtx, repo.VCS.InstanceURL, repo.ExternalID, commit.ID)
+				if err != nil {
+					return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch commit %s", commit.ID)).SetInternal(err)
+				}
+				baseVCSPushEvent.CommitList = append(baseVCSPushEvent.CommitList, *vcsCommit)
+			}
+
+			messages, err := s.processPushEvent(ctx, repositoryList, baseVCSPushEvent)
+			if err != nil {
+				return err
+			}
+			createdMessages = append(createdMessages, messages...)
+		}
+		return c.String(http.StatusOK, strings.Join(createdMessages, "\n"))
+	}, s.webhookDeliveryMiddleware(vcs.BitbucketServer))
+
+	g.POST("/azure/:id", func(c echo.Context) error {
+		ctx := c.Request().Context()
+
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Failed to read webhook request").SetInternal(err)
+		}
+		var pushEvent azure.WebhookPushEvent
+		if err := json.Unmarshal(body, &pushEvent); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformed push event").SetInternal(err)
+		}
+		// This shouldn't happen as we only setup webhook to receive push event, just in case.
+		if pushEvent.EventType != azure.WebhookPush {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid webhook event type, got %s, want %s", pushEvent.EventType, azure.WebhookPush))
+		}
+
+		baseVCSPushEvent := pushEvent.ToVCS()
+		filter := func(repo *api.Repository) (bool, error) {
+			// Azure DevOps does not sign the payload, the secret token is sent as
+			// the password of the basic authentication instead.
+			_, password, ok := c.Request().BasicAuth()
+			ok = ok && subtle.ConstantTimeCompare([]byte(password), []byte(repo.WebhookSecretToken)) == 1
+			setWebhookVerification(c, ok)
+			if !ok {
+				return false, nil
+			}
+
+			return s.isWebhookEventBranch(baseVCSPushEvent.Ref, repo)
+		}
+		repositoryList, err := s.filterRepository(ctx, c.Param("id"), baseVCSPushEvent.RepositoryID, filter)
+		if err != nil {
+			return err
+		}
+		if len(repositoryList) == 0 {
+			log.Debug("Empty handle repo list. Ignore this push event.")
+			return c.String(http.StatusOK, "OK")
+		}
+
+		// Azure DevOps does not include the changed files in the push event, fetch them for each commit.
+		repo := repositoryList[0]
+		for i, commit := range baseVCSPushEvent.CommitList {
+			vcsCommit, err := vcs.Get(repo.VCS.Type, vcs.ProviderConfig{}).FetchCommitByID(
+				ctx,
+				common.OauthContext{
+					ClientID:     repo.VCS.ApplicationID,
+					ClientSecret: repo.VCS.Secret,
+					AccessToken:  repo.AccessToken,
+					RefreshToken: repo.RefreshToken,
+					Refresher:    s.refreshToken(ctx, repo.WebURL),
+				},
+				repo.VCS.InstanceURL,
+				repo.ExternalID,
+				commit.ID,
+			)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch commit %s", commit.ID)).SetInternal(err)
+			}
+			baseVCSPushEvent.CommitList[i].AddedList = vcsCommit.AddedList
+			baseVCSPushEvent.CommitList[i].ModifiedList = vcsCommit.ModifiedList
+		}
+
+		createdMessages, err := s.processPushEvent(ctx, repositoryList, baseVCSPushEvent)
+		if err != nil {
+			return err
+		}
+		return c.String(http.StatusOK, strings.Join(createdMessages, "\n"))
+	}, s.webhookDeliveryMiddleware(vcs.AzureDevOps))
 
 	// id is the webhookEndpointID in repository
 	// This endpoint is generated and injected into GitHub action & GitLab CI during the VCS setup.
@@ -222,11 +547,13 @@ func (s *Server) registerWebhookRoutes(g *echo.Group) {
 			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list pull request file").SetInternal(err)
 		}
 
+		headCommitID := ""
 		distinctFileList := []vcs.DistinctFileItem{}
 		for _, prFile := range prFiles {
 			if prFile.IsDeleted {
 				continue
 			}
+			headCommitID = prFile.LastCommitID
 			distinctFileList = append(distinctFileList, vcs.DistinctFileItem{
 				FileName: prFile.Path,
 				Commit: vcs.Commit{
@@ -261,9 +588,14 @@ func (s *Server) registerWebhookRoutes(g *echo.Group) {
 
 		wg.Wait()
 
+		// Posting the result makes several round trips to the VCS, so don't hold up
+		// the CI job that is waiting for the response.
+		go s.postSQLReviewResult(context.Background(), repo, request.PullRequestID, headCommitID, sqlCheckAdvice)
+
 		response := &api.VCSSQLReviewResult{}
 		switch repo.VCS.Type {
-		case vcs.GitHubCom:
+		case vcs.GitHubCom, vcs.Gitea:
+			// Gitea Actions understands the same workflow commands as GitHub Actions.
 			response = convertSQLAdiceToGitHubActionResult(sqlCheckAdvice)
 		case vcs.GitLabSelfHost:
 			response = convertSQLAdviceToGitLabCIResult(sqlCheckAdvice)
@@ -430,22 +762,86 @@ func (s *Server) filterRepository(ctx context.Context, webhookEndpointID string,
 	return filteredRepos, nil
 }
 
-func (*Server) isWebhookEventBranch(pushEventRef, branchFilter string) (bool, error) {
+// isWebhookEventBranch returns true if the repository is interested in the branch
+// of the event. The branches are decided by the branch mapping of the repository
+// if set, otherwise by the branch filter.
+func (*Server) isWebhookEventBranch(pushEventRef string, repo *api.Repository) (bool, error) {
 	branch, err := parseBranchNameFromRefs(pushEventRef)
 	if err != nil {
 		return false, echo.NewHTTPError(http.StatusBadRequest, "Invalid ref: %s", pushEventRef).SetInternal(err)
 	}
-	ok, err := filepath.Match(branchFilter, branch)
+	if repo.BranchMapping.Enabled() {
+		mapping, err := repo.BranchMapping.Match(branch)
+		if err != nil {
+			return false, err
+		}
+		if mapping == nil {
+			log.Debug("Skipping repo due to branch mapping mismatch", zap.String("branch", branch), zap.Int("repoID", repo.ID))
+			return false, nil
+		}
+		return true, nil
+	}
+	ok, err := filepath.Match(repo.BranchFilter, branch)
 	if err != nil {
 		return false, errors.Wrapf(err, "failed to match branch filter")
 	}
 	if !ok {
-		log.Debug("Skipping repo due to branch filter mismatch", zap.String("branch", branch), zap.String("filter", branchFilter))
+		log.Debug("Skipping repo due to branch filter mismatch", zap.String("branch", branch), zap.String("filter", repo.BranchFilter))
 		return false, nil
 	}
 	return true, nil
 }
 
+// getBranchMapping returns the branch mapping matching the branch of the ref.
+// It returns nil if the repository doesn't use the branch mapping, which means
+// the push event drives all environments.
+func getBranchMapping(repo *api.Repository, ref string) (*api.BranchMapping, error) {
+	if !repo.BranchMapping.Enabled() {
+		return nil, nil
+	}
+	branch, err := parseBranchNameFromRefs(ref)
+	if err != nil {
+		return nil, err
+	}
+	mapping, err := repo.BranchMapping.Match(branch)
+	if err != nil {
+		return nil, err
+	}
+	if mapping == nil {
+		return nil, errors.Errorf("branch %q doesn't match any branch mapping", branch)
+	}
+	return mapping, nil
+}
+
+// getBranchMappingRef returns the ref used to match the branch mapping. The push event derived from a pull
+// request refers to the source branch, while the migration files are applied to the environments of the
+// target branch once the pull request is merged.
+func getBranchMappingRef(pushEvent vcs.PushEvent, pullRequest *pullRequestDraft) string {
+	if pullRequest == nil {
+		return pushEvent.Ref
+	}
+	return "refs/heads/" + pullRequest.event.TargetBranch
+}
+
+// filterDatabasesByBranchMapping filters out the databases not in the environments
+// of the branch mapping. A nil mapping keeps all databases.
+func filterDatabasesByBranchMapping(mapping *api.BranchMapping, databases []*api.Database) []*api.Database {
+	if mapping == nil {
+		return databases
+	}
+	environmentSet := make(map[int]bool)
+	for _, environmentID := range mapping.EnvironmentIDList {
+		environmentSet[environmentID] = true
+	}
+	var filteredDatabases []*api.Database
+	for _, database := range databases {
+		if environmentSet[database.Instance.EnvironmentID] {
+			filteredDatabases = append(filteredDatabases, database)
+		}
+	}
+	return filteredDatabases
+}
+
 // validateGitHubWebhookSignature256 returns true if the signature matches the
 // HMAC hex digested SHA256 hash of the body using the given key.
 func validateGitHubWebhookSignature256(signature, key string, body []byte) (bool, error) {
@@ -492,9 +888,28 @@ func (s *Server) processPushEvent(ctx context.Context, repositoryList []*api.Rep
 		return nil, nil
 	}
 
+	return s.processDistinctFileList(ctx, repositoryList, baseVCSPushEvent, distinctFileList, nil)
+}
+
+// processDistinctFileList creates issues from the distinct files of the push event. If the pullRequest is not
+// nil, the push event is derived from the pull request and draft issues are created or updated for the pull
+// request instead. Otherwise, the added files which are already claimed by the issues created from pull requests
+// are skipped, and the draft issues among them are activated.
+func (s *Server) processDistinctFileList(ctx context.Context, repositoryList []*api.Repository, baseVCSPushEvent vcs.PushEvent, distinctFileList []vcs.DistinctFileItem, pullRequest *pullRequestDraft) ([]string, error) {
 	var createdMessageList []string
 	repoID2FileItemList := groupFileInfoByRepo(distinctFileList, repositoryList)
 	for _, fileInfoListInRepo := range repoID2FileItemList {
+		if pullRequest == nil {
+			unclaimedFileInfoList, activatedMessageList, err := s.claimPullRequestFiles(ctx, fileInfoListInRepo[0].repository, fileInfoListInRepo)
+			if err != nil {
+				return nil, err
+			}
+			createdMessageList = append(createdMessageList, activatedMessageList...)
+			if len(unclaimedFileInfoList) == 0 {
+				continue
+			}
+			fileInfoListInRepo = unclaimedFileInfoList
+		}
 		// There are possibly multiple files in the push event.
 		// Each file corresponds to a (database name, schema version) pair.
 		// We want the migration statements are sorted by the file's schema version, and grouped by the database name.
@@ -510,6 +925,7 @@ func (s *Server) processPushEvent(ctx context.Context, repositoryList []*api.Rep
 				pushEvent,
 				repository,
 				fileInfoListSorted,
+				pullRequest,
 			)
 			if err != nil {
 				return nil, err
@@ -537,6 +953,352 @@ func (s *Server) processPushEvent(ctx context.Context, repositoryList []*api.Rep
 	return createdMessageList, nil
 }
 
+// pullRequestDraft carries the pull request through the push event pipeline so that draft issues are created or
+// updated for the pull request.
+type pullRequestDraft struct {
+	event vcs.PullRequestEvent
+	// issueList is the list of the existing draft issues of the pull request. An issue is removed from the list
+	// once it is updated in place, and the rest are superseded by the newly created draft issues.
+	issueList []*pullRequestIssue
+}
+
+// pullRequestIssue is an issue created from a VCS pull request.
+type pullRequestIssue struct {
+	issue       *api.Issue
+	pullRequest *api.IssuePullRequestPayload
+}
+
+// handlePullRequestWebhook processes the pull request event for the repositories of the webhook endpoint whose
+// branch filter matches the target branch of the pull request. The verify function checks the webhook secret.
+func (s *Server) handlePullRequestWebhook(c echo.Context, pullRequestEvent vcs.PullRequestEvent, verify repositoryFilter) error {
+	ctx := c.Request().Context()
+	filter := func(repo *api.Repository) (bool, error) {
+		ok, err := verify(repo)
+		if err != nil || !ok {
+			return false, err
+		}
+
+		return s.isWebhookEventBranch("refs/heads/"+pullRequestEvent.TargetBranch, repo)
+	}
+	repositoryList, err := s.filterRepository(ctx, c.Param("id"), pullRequestEvent.RepositoryID, filter)
+	if err != nil {
+		return err
+	}
+	if len(repositoryList) == 0 {
+		log.Debug("Empty handle repo list. Ignore this pull request event.")
+		return c.String(http.StatusOK, "OK")
+	}
+
+	messages, err := s.processPullRequestEvent(ctx, repositoryList, pullRequestEvent)
+	if err != nil {
+		return err
+	}
+	return c.String(http.StatusOK, strings.Join(messages, "\n"))
+}
+
+// processPullRequestEvent creates or updates the draft issues from the migration files in an opened or updated
+// pull request. The draft issues are activated when the pull request is merged, and canceled when the pull
+// request is closed without merging.
+func (s *Server) processPullRequestEvent(ctx context.Context, repositoryList []*api.Repository, pullRequestEvent vcs.PullRequestEvent) ([]string, error) {
+	switch pullRequestEvent.Action {
+	case vcs.PullRequestEventOpened, vcs.PullRequestEventUpdated:
+		return s.upsertPullRequestIssueList(ctx, repositoryList, pullRequestEvent)
+	case vcs.PullRequestEventMerged:
+		return s.changePullRequestIssueStatus(ctx, repositoryList, pullRequestEvent, api.IssueOpen, fmt.Sprintf("Activated by merging pull request %s.", pullRequestEvent.URL))
+	case vcs.PullRequestEventClosed:
+		return s.changePullRequestIssueStatus(ctx, repositoryList, pullRequestEvent, api.IssueCanceled, fmt.Sprintf("Canceled by closing pull request %s without merging.", pullRequestEvent.URL))
+	}
+	return nil, nil
+}
+
+func (s *Server) upsertPullRequestIssueList(ctx context.Context, repositoryList []*api.Repository, pullRequestEvent vcs.PullRequestEvent) ([]string, error) {
+	repo := repositoryList[0]
+	prFiles, err := vcs.Get(repo.VCS.Type, vcs.ProviderConfig{}).ListPullRequestFile(
+		ctx,
+		common.OauthContext{
+			ClientID:     repo.VCS.ApplicationID,
+			ClientSecret: repo.VCS.Secret,
+			AccessToken:  repo.AccessToken,
+			RefreshToken: repo.RefreshToken,
+			Refresher:    s.refreshToken(ctx, repo.WebURL),
+		},
+		repo.VCS.InstanceURL,
+		repo.ExternalID,
+		pullRequestEvent.PullRequestID,
+	)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "Failed to list pull request file").SetInternal(err)
+	}
+
+	headCommitID := pullRequestEvent.HeadCommitID
+	var fileList []string
+	for _, prFile := range prFiles {
+		if prFile.IsDeleted {
+			continue
+		}
+		fileList = append(fileList, prFile.Path)
+		if headCommitID == "" {
+			headCommitID = prFile.LastCommitID
+		}
+	}
+
+	issueList, err := s.findPullRequestIssueList(ctx, repositoryList, pullRequestEvent.PullRequestID, []api.IssueStatus{api.IssueDraft})
+	if err != nil {
+		return nil, err
+	}
+	pullRequest := &pullRequestDraft{
+		event:     pullRequestEvent,
+		issueList: issueList,
+	}
+
+	var messageList []string
+	if len(fileList) != 0 {
+		// All migration files in the pull request are treated as added files at the head commit of the source branch.
+		pushEvent := vcs.PushEvent{
+			Ref:                "refs/heads/" + pullRequestEvent.SourceBranch,
+			RepositoryID:       pullRequestEvent.RepositoryID,
+			RepositoryURL:      pullRequestEvent.RepositoryURL,
+			RepositoryFullPath: pullRequestEvent.RepositoryFullPath,
+			AuthorName:         pullRequestEvent.AuthorName,
+			CommitList: []vcs.Commit{
+				{
+					ID:          headCommitID,
+					Title:       pullRequestEvent.Title,
+					Message:     pullRequestEvent.Title,
+					CreatedTs:   time.Now().Unix(),
+					URL:         pullRequestEvent.URL,
+					AuthorName:  pullRequestEvent.AuthorName,
+					AuthorEmail: pullRequestEvent.AuthorEmail,
+					AddedList:   fileList,
+				},
+			},
+		}
+		messageList, err = s.processDistinctFileList(ctx, repositoryList, pushEvent, pushEvent.GetDistinctFileList(), pullRequest)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The rest of the existing draft issues are superseded by the newly created ones, or no longer have any
+	// migration file in the pull request.
+	for _, prIssue := range pullRequest.issueList {
+		comment := fmt.Sprintf("Superseded by the latest changes of pull request %s.", pullRequestEvent.URL)
+		if _, err := s.changeIssueStatus(ctx, prIssue.issue, api.IssueCanceled, api.SystemBotID, comment); err != nil {
+			return nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to cancel the superseded draft issue %d", prIssue.issue.ID)).SetInternal(err)
+		}
+	}
+	return messageList, nil
+}
+
+// upsertPullRequestIssue updates the existing draft issue of the pull request in place if it has the same name
+// and files, and its tasks correspond to the migration details. Otherwise, a new draft issue is created.
+func (s *Server) upsertPullRequestIssue(ctx context.Context, issueName, issueDescription string, pushEvent vcs.PushEvent, creatorID int, repo *api.Repository, fileList []string, migrationDetailList []*api.MigrationDetail, pullRequest *pullRequestDraft) error {
+	sort.Strings(fileList)
+	for i, prIssue := range pullRequest.issueList {
+		if prIssue.issue.Name != issueName || prIssue.pullRequest.RepositoryID != repo.ID || !equalStringSlice(prIssue.pullRequest.FileList, fileList) {
+			continue
+		}
+		updated, err := s.tryUpdateDraftIssueTaskList(ctx, prIssue.issue, migrationDetailList)
+		if err != nil {
+			return err
+		}
+		if !updated {
+			break
+		}
+		pullRequest.issueList = append(pullRequest.issueList[:i], pullRequest.issueList[i+1:]...)
+		return nil
+	}
+
+	return s.createIssueFromMigrationDetailList(ctx, issueName, issueDescription, pushEvent, creatorID, repo.ProjectID, migrationDetailList, &api.IssuePullRequestPayload{
+		RepositoryID:  repo.ID,
+		PullRequestID: pullRequest.event.PullRequestID,
+		URL:           pullRequest.event.URL,
+		Title:         pullRequest.event.Title,
+		FileList:      fileList,
+	})
+}
+
+// tryUpdateDraftIssueTaskList patches the statements of the tasks in the draft issue with the migration details.
+// It returns false if the tasks do not correspond to the migration details one by one.
+func (s *Server) tryUpdateDraftIssueTaskList(ctx context.Context, issue *api.Issue, migrationDetailList []*api.MigrationDetail) (bool, error) {
+	var taskList []*api.Task
+	for _, stage := range issue.Pipeline.StageList {
+		taskList = append(taskList, stage.TaskList...)
+	}
+	if len(taskList) != len(migrationDetailList) {
+		return false, nil
+	}
+
+	matchedTaskList := make([]*api.Task, len(migrationDetailList))
+	matchedStatementList := make([]string, len(migrationDetailList))
+	matched := make(map[int]bool)
+	for i, detail := range migrationDetailList {
+		// The tasks of a tenant mode project are generated by the database name, which cannot be matched.
+		if detail.DatabaseID == 0 {
+			return false, nil
+		}
+		for _, task := range taskList {
+			if matched[task.ID] || task.DatabaseID == nil || *task.DatabaseID != detail.DatabaseID {
+				continue
+			}
+			payload := &api.TaskDatabaseSchemaUpdatePayload{}
+			if err := json.Unmarshal([]byte(task.Payload), payload); err != nil {
+				return false, errors.Wrapf(err, "failed to unmarshal the payload of task %d", task.ID)
+			}
+			// The schema version of a SDL task is generated rather than parsed from the file name.
+			// So is the schema version of a repeatable migration task, which is matched by the file instead.
+			if detail.MigrationType == db.Repeatable {
+				if payload.RepeatableName != detail.RepeatableName {
+					continue
+				}
+			} else if detail.SchemaVersion != "" && payload.SchemaVersion != detail.SchemaVersion {
+				continue
+			}
+			matchedTaskList[i] = task
+			matchedStatementList[i] = payload.Statement
+			matched[task.ID] = true
+			break
+		}
+		if matchedTaskList[i] == nil {
+			return false, nil
+		}
+	}
+
+	for i, task := range matchedTaskList {
+		statement := migrationDetailList[i].Statement
+		if statement == matchedStatementList[i] {
+			continue
+		}
+		taskPatch := &api.TaskPatch{
+			ID:        task.ID,
+			Statement: &statement,
+			UpdaterID: api.SystemBotID,
+		}
+		if _, err := s.patchTask(ctx, task, taskPatch, issue); err != nil {
+			log.Warn("Failed to patch the task of the draft issue", zap.Int("issueID", issue.ID), zap.Int("taskID", task.ID), zap.Error(err))
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// changePullRequestIssueStatus changes the status of the draft issues of the pull request.
+func (s *Server) changePullRequestIssueStatus(ctx context.Context, repositoryList []*api.Repository, pullRequestEvent vcs.PullRequestEvent, newStatus api.IssueStatus, comment string) ([]string, error) {
+	issueList, err := s.findPullRequestIssueList(ctx, repositoryList, pullRequestEvent.PullRequestID, []api.IssueStatus{api.IssueDraft})
+	if err != nil {
+		return nil, err
+	}
+
+	var messageList []string
+	for _, prIssue := range issueList {
+		updatedIssue, err := s.changeIssueStatus(ctx, prIssue.issue, newStatus, api.SystemBotID, comment)
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to change the status of the draft issue %d", prIssue.issue.ID)).SetInternal(err)
+		}
+		messageList = append(messageList, fmt.Sprintf("Changed issue %q status to %s", updatedIssue.Name, updatedIssue.Status))
+	}
+	return messageList, nil
+}
+
+// claimPullRequestFiles returns the files which are not claimed by the draft or open issues created from the
+// pull requests of the repository. The draft issues claiming the files are activated, as the files have been
+// pushed to the branch.
+func (s *Server) claimPullRequestFiles(ctx context.Context, repo *api.Repository, fileInfoList []fileInfo) ([]fileInfo, []string, error) {
+	issueList, err := s.findPullRequestIssueList(ctx, []*api.Repository{repo}, "", []api.IssueStatus{api.IssueDraft, api.IssueOpen})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(issueList) == 0 {
+		return fileInfoList, nil, nil
+	}
+	file2Issue := make(map[string]*pullRequestIssue)
+	for _, prIssue := range issueList {
+		for _, file := range prIssue.pullRequest.FileList {
+			file2Issue[file] = prIssue
+		}
+	}
+
+	var unclaimedFileInfoList []fileInfo
+	var messageList []string
+	for _, fileInfo := range fileInfoList {
+		prIssue, ok := file2Issue[fileInfo.item.FileName]
+		if !ok || fileInfo.item.ItemType != vcs.FileItemTypeAdded {
+			unclaimedFileInfoList = append(unclaimedFileInfoList, fileInfo)
+			continue
+		}
+		log.Debug("Skipped file claimed by the issue created from pull request", zap.String("file", fileInfo.item.FileName), zap.Int("issueID", prIssue.issue.ID))
+		if prIssue.issue.Status != api.IssueDraft {
+			continue
+		}
+		comment := fmt.Sprintf("Activated by pushing the files of pull request %s.", prIssue.pullRequest.URL)
+		updatedIssue, err := s.changeIssueStatus(ctx, prIssue.issue, api.IssueOpen, api.SystemBotID, comment)
+		if err != nil {
+			return nil, nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to activate the draft issue %d", prIssue.issue.ID)).SetInternal(err)
+		}
+		prIssue.issue = updatedIssue
+		messageList = append(messageList, fmt.Sprintf("Changed issue %q status to %s", updatedIssue.Name, updatedIssue.Status))
+	}
+	return unclaimedFileInfoList, messageList, nil
+}
+
+// findPullRequestIssueList finds the issues with the given statuses created from the pull requests of the
+// repositories. All pull requests are matched if the pullRequestID is empty.
+func (s *Server) findPullRequestIssueList(ctx context.Context, repositoryList []*api.Repository, pullRequestID string, statusList []api.IssueStatus) ([]*pullRequestIssue, error) {
+	repositoryIDs := make(map[int]bool)
+	projectIDs := make(map[int]bool)
+	for _, repo := range repositoryList {
+		repositoryIDs[repo.ID] = true
+		projectIDs[repo.ProjectID] = true
+	}
+
+	var prIssueList []*pullRequestIssue
+	for projectID := range projectIDs {
+		projectID := projectID
+		issueList, err := s.store.FindIssueStripped(ctx, &api.IssueFind{ProjectID: &projectID, StatusList: statusList})
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to find issues in project %d", projectID)).SetInternal(err)
+		}
+		for _, issue := range issueList {
+			var payload api.IssuePayload
+			if err := json.Unmarshal([]byte(issue.Payload), &payload); err != nil {
+				log.Warn("Failed to unmarshal issue payload", zap.Int("issueID", issue.ID), zap.Error(err))
+				continue
+			}
+			if payload.PullRequest == nil || !repositoryIDs[payload.PullRequest.RepositoryID] {
+				continue
+			}
+			if pullRequestID != "" && payload.PullRequest.PullRequestID != pullRequestID {
+				continue
+			}
+			// The stripped issue does not contain the pipeline.
+			fullIssue, err := s.store.GetIssueByID(ctx, issue.ID)
+			if err != nil {
+				return nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to get issue %d", issue.ID)).SetInternal(err)
+			}
+			if fullIssue == nil {
+				continue
+			}
+			prIssueList = append(prIssueList, &pullRequestIssue{
+				issue:       fullIssue,
+				pullRequest: payload.PullRequest,
+			})
+		}
+	}
+	return prIssueList, nil
+}
+
+func equalStringSlice(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 type fileInfo struct {
 	item          vcs.DistinctFileItem
 	migrationInfo *db.MigrationInfo
@@ -632,6 +1394,18 @@ func getFileInfo(fileItem vcs.DistinctFileItem, repositoryList []*api.Repository
 			)
 			continue
 		}
+		if mi == nil && repository.RepeatableFilePathTemplate != "" {
+			repeatableFilePathTemplate := path.Join(repository.BaseDirectory, repository.RepeatableFilePathTemplate)
+			mi, err = db.ParseRepeatableMigrationInfo(fileItem.FileName, repeatableFilePathTemplate, allowOmitDatabaseName)
+			if err != nil {
+				log.Error("Failed to parse repeatable migration file info",
+					zap.Int("project", repository.ProjectID),
+					zap.String("file", fileItem.FileName),
+					zap.Error(err),
+				)
+				continue
+			}
+		}
 		if mi != nil {
 			if fileItem.IsYAML && mi.Type != db.Data {
 				return nil, unknownFileType, nil, errors.New("only DML is allowed for YAML files in a tenant project")
@@ -676,10 +1450,11 @@ func getFileInfo(fileItem vcs.DistinctFileItem, repositoryList []*api.Repository
 // processFilesInProject attempts to create new issue(s) according to the repository type.
 // 1. For a state based project, we create one issue per schema file, and one issue for all of the rest migration files (if any).
 // 2. For a migration based project, we create one issue for all of the migration files. All schema files are ignored.
+// If the pullRequest is not nil, draft issues are created, or updated in place, for the pull request.
 // It returns "created=true" when new issue(s) has been created,
 // along with the creation message to be presented in the UI. An *echo.HTTPError
 // is returned in case of the error during the process.
-func (s *Server) processFilesInProject(ctx context.Context, pushEvent vcs.PushEvent, repo *api.Repository, fileInfoList []fileInfo) (string, bool, []*api.ActivityCreate, *echo.HTTPError) {
+func (s *Server) processFilesInProject(ctx context.Context, pushEvent vcs.PushEvent, repo *api.Repository, fileInfoList []fileInfo, pullRequest *pullRequestDraft) (string, bool, []*api.ActivityCreate, *echo.HTTPError) {
 	if repo.Project.TenantMode == api.TenantModeTenant && !s.feature(api.FeatureMultiTenancy) {
 		return "", false, nil, echo.NewHTTPError(http.StatusForbidden, api.FeatureMultiTenancy.AccessErrorMessage())
 	}
@@ -688,19 +1463,27 @@ func (s *Server) processFilesInProject(ctx context.Context, pushEvent vcs.PushEv
 	var activityCreateList []*api.ActivityCreate
 	var createdIssueList []string
 	var fileNameList []string
+	var filePathList []string
 
 	creatorID := s.getIssueCreatorID(ctx, pushEvent.CommitList[0].AuthorEmail)
+	branchMappingRef := getBranchMappingRef(pushEvent, pullRequest)
+	createIssue := func(issueName, issueDescription string, filePathList []string, migrationDetailList []*api.MigrationDetail) error {
+		if pullRequest == nil {
+			return s.createIssueFromMigrationDetailList(ctx, issueName, issueDescription, pushEvent, creatorID, repo.ProjectID, migrationDetailList, nil)
+		}
+		return s.upsertPullRequestIssue(ctx, issueName, issueDescription, pushEvent, creatorID, repo, filePathList, migrationDetailList, pullRequest)
+	}
 	for _, fileInfo := range fileInfoList {
 		if fileInfo.fType == schemaFileType {
 			if repo.Project.SchemaChangeType == api.ProjectSchemaChangeTypeSDL {
 				// Create one issue per schema file for SDL project.
-				migrationDetailListForFile, activityCreateListForFile := s.prepareIssueFromSDLFile(ctx, repo, pushEvent, fileInfo.migrationInfo, fileInfo.item.FileName)
+				migrationDetailListForFile, activityCreateListForFile := s.prepareIssueFromSDLFile(ctx, repo, pushEvent, branchMappingRef, fileInfo.migrationInfo, fileInfo.item.FileName)
 				activityCreateList = append(activityCreateList, activityCreateListForFile...)
 				if len(migrationDetailListForFile) != 0 {
 					databaseName := fileInfo.migrationInfo.Database
 					issueName := fmt.Sprintf(issueNameTemplate, databaseName, "Alter schema")
 					issueDescription := fmt.Sprintf("Apply schema diff by file %s", strings.TrimPrefix(fileInfo.item.FileName, repo.BaseDirectory+"/"))
-					if err := s.createIssueFromMigrationDetailList(ctx, issueName, issueDescription, pushEvent, creatorID, repo.ProjectID, migrationDetailListForFile); err != nil {
+					if err := createIssue(issueName, issueDescription, []string{fileInfo.item.FileName}, migrationDetailListForFile); err != nil {
 						return "", false, activityCreateList, echo.NewHTTPError(http.StatusInternalServerError, "Failed to create issue").SetInternal(err)
 					}
 					createdIssueList = append(createdIssueList, issueName)
@@ -715,11 +1498,12 @@ func (s *Server) processFilesInProject(ctx context.Context, pushEvent vcs.PushEv
 			// 1) DML is always migration-based.
 			// 2) We may have a limitation in SDL implementation.
 			// 3) User just wants to break the glass.
-			migrationDetailListForFile, activityCreateListForFile := s.prepareIssueFromFile(ctx, repo, pushEvent, fileInfo)
+			migrationDetailListForFile, activityCreateListForFile := s.prepareIssueFromFile(ctx, repo, pushEvent, branchMappingRef, fileInfo)
 			activityCreateList = append(activityCreateList, activityCreateListForFile...)
 			migrationDetailList = append(migrationDetailList, migrationDetailListForFile...)
 			if len(migrationDetailListForFile) != 0 {
 				fileNameList = append(fileNameList, strings.TrimPrefix(fileInfo.item.FileName, repo.BaseDirectory+"/"))
+				filePathList = append(filePathList, fileInfo.item.FileName)
 			}
 		}
 	}
@@ -731,7 +1515,7 @@ func (s *Server) processFilesInProject(ctx context.Context, pushEvent vcs.PushEv
 	// Create one issue per push event for DDL project, or non-schema files for SDL project.
 	migrateType := "Change data"
 	for _, d := range migrationDetailList {
-		if d.MigrationType == db.Migrate {
+		if d.MigrationType == db.Migrate || d.MigrationType == db.Repeatable {
 			migrateType = "Alter schema"
 			break
 		}
@@ -740,7 +1524,7 @@ func (s *Server) processFilesInProject(ctx context.Context, pushEvent vcs.PushEv
 	databaseName := fileInfoList[0].migrationInfo.Database
 	issueName := fmt.Sprintf(issueNameTemplate, databaseName, migrateType)
 	issueDescription := fmt.Sprintf("By VCS files %s", strings.Join(fileNameList, ", "))
-	if err := s.createIssueFromMigrationDetailList(ctx, issueName, issueDescription, pushEvent, creatorID, repo.ProjectID, migrationDetailList); err != nil {
+	if err := createIssue(issueName, issueDescription, filePathList, migrationDetailList); err != nil {
 		return "", len(createdIssueList) != 0, activityCreateList, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to create issue %s", issueName)).SetInternal(err)
 	}
 	createdIssueList = append(createdIssueList, issueName)
@@ -748,18 +1532,31 @@ func (s *Server) processFilesInProject(ctx context.Context, pushEvent vcs.PushEv
 	return fmt.Sprintf("Created issue %q from push event", strings.Join(createdIssueList, ",")), true, activityCreateList, nil
 }
 
+// sortFilesBySchemaVersion sorts the files by the database name and the schema version.
+// The repeatable migration files are ordered after the versioned ones of the same database.
 func sortFilesBySchemaVersion(fileInfoList []fileInfo) []fileInfo {
 	var ret []fileInfo
 	ret = append(ret, fileInfoList...)
 	sort.Slice(ret, func(i, j int) bool {
 		mi := ret[i].migrationInfo
 		mj := ret[j].migrationInfo
-		return mi.Database < mj.Database || (mi.Database == mj.Database && mi.Version < mj.Version)
+		if mi.Database != mj.Database {
+			return mi.Database < mj.Database
+		}
+		if ri, rj := mi.Type == db.Repeatable, mj.Type == db.Repeatable; ri != rj {
+			return rj
+		}
+		if mi.Type == db.Repeatable {
+			return ret[i].item.FileName < ret[j].item.FileName
+		}
+		return mi.Version < mj.Version
 	})
 	return ret
 }
 
-func (s *Server) createIssueFromMigrationDetailList(ctx context.Context, issueName, issueDescription string, pushEvent vcs.PushEvent, creatorID, projectID int, migrationDetailList []*api.MigrationDetail) error {
+// createIssueFromMigrationDetailList creates an issue from the migration details. If the pullRequest is not nil,
+// a draft issue is created for the pull request.
+func (s *Server) createIssueFromMigrationDetailList(ctx context.Context, issueName, issueDescription string, pushEvent vcs.PushEvent, creatorID, projectID int, migrationDetailList []*api.MigrationDetail, pullRequest *api.IssuePullRequestPayload) error {
 	createContext, err := json.Marshal(
 		&api.MigrationContext{
 			VCSPushEvent: &pushEvent,
@@ -773,7 +1570,7 @@ func (s *Server) createIssueFromMigrationDetailList(ctx context.Context, issueNa
 	// TODO(d): unify issue type for database changes.
 	issueType := api.IssueDatabaseDataUpdate
 	for _, detail := range migrationDetailList {
-		if detail.MigrationType == db.Migrate || detail.MigrationType == db.Baseline {
+		if detail.MigrationType == db.Migrate || detail.MigrationType == db.Baseline || detail.MigrationType == db.Repeatable {
 			issueType = api.IssueDatabaseSchemaUpdate
 		}
 	}
@@ -785,6 +1582,16 @@ func (s *Server) createIssueFromMigrationDetailList(ctx context.Context, issueNa
 		AssigneeID:    api.SystemBotID,
 		CreateContext: string(createContext),
 	}
+	activityComment := "Created issue %q."
+	if pullRequest != nil {
+		payload, err := json.Marshal(api.IssuePayload{PullRequest: pullRequest})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal issue payload").SetInternal(err)
+		}
+		issueCreate.Payload = string(payload)
+		issueCreate.Draft = true
+		activityComment = "Created draft issue %q."
+	}
 	issue, err := s.createIssue(ctx, issueCreate, creatorID)
 	if err != nil {
 		errMsg := "Failed to create schema update issue"
@@ -811,7 +1618,7 @@ func (s *Server) createIssueFromMigrationDetailList(ctx context.Context, issueNa
 		ContainerID: projectID,
 		Type:        api.ActivityProjectRepositoryPush,
 		Level:       api.ActivityInfo,
-		Comment:     fmt.Sprintf("Created issue %q.", issue.Name),
+		Comment:     fmt.Sprintf(activityComment, issue.Name),
 		Payload:     string(activityPayload),
 	}
 	if _, err = s.ActivityManager.CreateActivity(ctx, activityCreate, &ActivityMeta{}); err != nil {
@@ -917,6 +1724,12 @@ func getIgnoredFileActivityCreate(projectID int, pushEvent vcs.PushEvent, file s
 
 // readFileContent reads the content of the given file from the given repository.
 func (s *Server) readFileContent(ctx context.Context, pushEvent vcs.PushEvent, repo *api.Repository, file string) (string, error) {
+	return s.readFileContentAtRef(ctx, repo, file, pushEvent.CommitList[len(pushEvent.CommitList)-1].ID)
+}
+
+// readFileContentAtRef reads the content of the given file from the given repository at the given ref,
+// which is either a commit ID or a branch name.
+func (s *Server) readFileContentAtRef(ctx context.Context, repo *api.Repository, file, ref string) (string, error) {
 	// Retrieve the latest AccessToken and RefreshToken as the previous
 	// ReadFileContent call may have updated the stored token pair. ReadFileContent
 	// will fetch and store the new token pair if the existing token pair has
@@ -941,7 +1754,7 @@ func (s *Server) readFileContent(ctx context.Context, pushEvent vcs.PushEvent, r
 		repo.VCS.InstanceURL,
 		repo.ExternalID,
 		file,
-		pushEvent.CommitList[len(pushEvent.CommitList)-1].ID,
+		ref,
 	)
 	if err != nil {
 		return "", errors.Wrap(err, "read content")
@@ -950,8 +1763,9 @@ func (s *Server) readFileContent(ctx context.Context, pushEvent vcs.PushEvent, r
 }
 
 // prepareIssueFromSDLFile returns the migration info and a list of update
-// schema details derived from the given push event for SDL.
-func (s *Server) prepareIssueFromSDLFile(ctx context.Context, repo *api.Repository, pushEvent vcs.PushEvent, schemaInfo *db.MigrationInfo, file string) ([]*api.MigrationDetail, []*api.ActivityCreate) {
+// schema details derived from the given push event for SDL. The databases are
+// filtered by the branch mapping matching the branchMappingRef.
+func (s *Server) prepareIssueFromSDLFile(ctx context.Context, repo *api.Repository, pushEvent vcs.PushEvent, branchMappingRef string, schemaInfo *db.MigrationInfo, file string) ([]*api.MigrationDetail, []*api.ActivityCreate) {
 	dbName := schemaInfo.Database
 	if dbName == "" {
 		log.Debug("Ignored schema file without a database name", zap.String("file", file))
@@ -964,15 +1778,23 @@ func (s *Server) prepareIssueFromSDLFile(ctx context.Context, repo *api.Reposito
 		return nil, []*api.ActivityCreate{activityCreate}
 	}
 
+	mapping, err := getBranchMapping(repo, branchMappingRef)
+	if err != nil {
+		activityCreate := getIgnoredFileActivityCreate(repo.ProjectID, pushEvent, file, errors.Wrap(err, "Failed to match branch mapping"))
+		return nil, []*api.ActivityCreate{activityCreate}
+	}
+
 	var migrationDetailList []*api.MigrationDetail
 	if repo.Project.TenantMode == api.TenantModeTenant {
-		migrationDetailList = append(migrationDetailList,
-			&api.MigrationDetail{
-				MigrationType: db.MigrateSDL,
-				DatabaseName:  dbName,
-				Statement:     sdl,
-			},
-		)
+		detail := &api.MigrationDetail{
+			MigrationType: db.MigrateSDL,
+			DatabaseName:  dbName,
+			Statement:     sdl,
+		}
+		if mapping != nil {
+			detail.EnvironmentIDList = mapping.EnvironmentIDList
+		}
+		migrationDetailList = append(migrationDetailList, detail)
 		return migrationDetailList, nil
 	}
 
@@ -982,6 +1804,11 @@ func (s *Server) prepareIssueFromSDLFile(ctx context.Context, repo *api.Reposito
 		activityCreate := getIgnoredFileActivityCreate(repo.ProjectID, pushEvent, file, errors.Wrap(err, "Failed to find project databases"))
 		return nil, []*api.ActivityCreate{activityCreate}
 	}
+	databases = filterDatabasesByBranchMapping(mapping, databases)
+	if len(databases) == 0 {
+		log.Debug("Ignored schema file without databases in the environments of the branch mapping", zap.String("file", file), zap.String("ref", branchMappingRef))
+		return nil, nil
+	}
 
 	for _, database := range databases {
 		migrationDetailList = append(migrationDetailList,
@@ -997,8 +1824,9 @@ func (s *Server) prepareIssueFromSDLFile(ctx context.Context, repo *api.Reposito
 }
 
 // prepareIssueFromFile returns a list of update schema details derived
-// from the given push event for DDL.
-func (s *Server) prepareIssueFromFile(ctx context.Context, repo *api.Repository, pushEvent vcs.PushEvent, fileInfo fileInfo) ([]*api.MigrationDetail, []*api.ActivityCreate) {
+// from the given push event for DDL. The databases are filtered by the branch
+// mapping matching the branchMappingRef.
+func (s *Server) prepareIssueFromFile(ctx context.Context, repo *api.Repository, pushEvent vcs.PushEvent, branchMappingRef string, fileInfo fileInfo) ([]*api.MigrationDetail, []*api.ActivityCreate) {
 	content, err := s.readFileContent(ctx, pushEvent, repo, fileInfo.item.FileName)
 	if err != nil {
 		return nil, []*api.ActivityCreate{
@@ -1011,17 +1839,40 @@ func (s *Server) prepareIssueFromFile(ctx context.Context, repo *api.Repository,
 		}
 	}
 
+	mapping, err := getBranchMapping(repo, branchMappingRef)
+	if err != nil {
+		return nil, []*api.ActivityCreate{
+			getIgnoredFileActivityCreate(
+				repo.ProjectID,
+				pushEvent,
+				fileInfo.item.FileName,
+				errors.Wrap(err, "Failed to match branch mapping"),
+			),
+		}
+	}
+
+	// The repeatable migration file is re-applied whenever it changes, so we generate a
+	// new schema version for every change rather than parsing it from the file path.
+	schemaVersion, repeatableName := fileInfo.migrationInfo.Version, ""
+	repeatable := fileInfo.migrationInfo.Type == db.Repeatable
+	if repeatable {
+		schemaVersion, repeatableName = getRepeatableSchemaVersion(content), fileInfo.item.FileName
+	}
+
 	if repo.Project.TenantMode == api.TenantModeTenant {
 		// A non-YAML file means the whole file content is the SQL statement
 		if !fileInfo.item.IsYAML {
-			return []*api.MigrationDetail{
-				{
-					MigrationType: fileInfo.migrationInfo.Type,
-					DatabaseName:  fileInfo.migrationInfo.Database,
-					Statement:     content,
-					SchemaVersion: fileInfo.migrationInfo.Version,
-				},
-			}, nil
+			detail := &api.MigrationDetail{
+				MigrationType:  fileInfo.migrationInfo.Type,
+				DatabaseName:   fileInfo.migrationInfo.Database,
+				Statement:      content,
+				SchemaVersion:  schemaVersion,
+				RepeatableName: repeatableName,
+			}
+			if mapping != nil {
+				detail.EnvironmentIDList = mapping.EnvironmentIDList
+			}
+			return []*api.MigrationDetail{detail}, nil
 		}
 
 		var migrationFile api.MigrationFileYAML
@@ -1051,7 +1902,7 @@ func (s *Server) prepareIssueFromFile(ctx context.Context, repo *api.Repository,
 				}
 			}
 
-			for _, db := range dbList {
+			for _, db := range filterDatabasesByBranchMapping(mapping, dbList) {
 				migrationDetailList = append(migrationDetailList,
 					&api.MigrationDetail{
 						MigrationType: fileInfo.migrationInfo.Type,
@@ -1071,16 +1922,22 @@ func (s *Server) prepareIssueFromFile(ctx context.Context, repo *api.Repository,
 		activityCreate := getIgnoredFileActivityCreate(repo.ProjectID, pushEvent, fileInfo.item.FileName, errors.Wrap(err, "Failed to find project databases"))
 		return nil, []*api.ActivityCreate{activityCreate}
 	}
+	databases = filterDatabasesByBranchMapping(mapping, databases)
+	if len(databases) == 0 {
+		log.Debug("Ignored file without databases in the environments of the branch mapping", zap.String("file", fileInfo.item.FileName), zap.String("ref", branchMappingRef))
+		return nil, nil
+	}
 
-	if fileInfo.item.ItemType == vcs.FileItemTypeAdded {
+	if fileInfo.item.ItemType == vcs.FileItemTypeAdded || repeatable {
 		var migrationDetailList []*api.MigrationDetail
 		for _, database := range databases {
 			migrationDetailList = append(migrationDetailList,
 				&api.MigrationDetail{
-					MigrationType: fileInfo.migrationInfo.Type,
-					DatabaseID:    database.ID,
-					Statement:     content,
-					SchemaVersion: fileInfo.migrationInfo.Version,
+					MigrationType:  fileInfo.migrationInfo.Type,
+					DatabaseID:     database.ID,
+					Statement:      content,
+					SchemaVersion:  schemaVersion,
+					RepeatableName: repeatableName,
 				},
 			)
 		}
@@ -1100,6 +1957,12 @@ func (s *Server) prepareIssueFromFile(ctx context.Context, repo *api.Repository,
 	return nil, nil
 }
 
+// getRepeatableSchemaVersion returns a schema version for the change of a repeatable migration file.
+// The checksum suffix tells apart the repeatable migration files changed at the same time.
+func getRepeatableSchemaVersion(content string) string {
+	return fmt.Sprintf("%s-%s", common.DefaultMigrationVersion(), db.Checksum(content)[:8])
+}
+
 func (s *Server) tryUpdateTasksFromModifiedFile(ctx context.Context, databases []*api.Database, fileName, schemaVersion, statement string) error {
 	// For modified files, we try to update the existing issue's statement.
 	for _, database := range databases {
@@ -1267,3 +2130,128 @@ func convertSQLAdiceToGitHubActionResult(adviceMap map[string][]advisor.Advice)
 		Content: messageList,
 	}
 }
+
+// postSQLReviewResult posts the SQL review result back to the pull request through
+// the VCS provider, as a commit status with the overall verdict on the head commit
+// and a review with inline comments on the offending lines. The review replaces the
+// one posted for the previous run, so re-running the check doesn't pile up comments.
+func (s *Server) postSQLReviewResult(ctx context.Context, repo *api.Repository, pullRequestID, commitID string, adviceMap map[string][]advisor.Advice) {
+	if commitID == "" {
+		return
+	}
+
+	lock, _ := s.sqlReviewLocks.LoadOrStore(fmt.Sprintf("%d/%s", repo.ID, pullRequestID), &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	status, review := convertSQLAdviceToVCSReview(adviceMap)
+	status.TargetURL = fmt.Sprintf("%s/setting/sql-review", s.profile.ExternalURL)
+	review.CommitID = commitID
+
+	provider := vcs.Get(repo.VCS.Type, vcs.ProviderConfig{})
+	oauthCtx := common.OauthContext{
+		ClientID:     repo.VCS.ApplicationID,
+		ClientSecret: repo.VCS.Secret,
+		AccessToken:  repo.AccessToken,
+		RefreshToken: repo.RefreshToken,
+		Refresher:    s.refreshToken(ctx, repo.WebURL),
+	}
+	logError := func(msg string, err error) {
+		if common.ErrorCode(err) == common.NotImplemented {
+			log.Debug(msg, zap.String("vcs", string(repo.VCS.Type)), zap.Error(err))
+			return
+		}
+		log.Error(msg,
+			zap.Int("repository_id", repo.ID),
+			zap.String("pull_request", pullRequestID),
+			zap.Error(err),
+		)
+	}
+
+	if err := provider.CreateCommitStatus(ctx, oauthCtx, repo.VCS.InstanceURL, repo.ExternalID, commitID, status); err != nil {
+		logError("Failed to create commit status for SQL review", err)
+	}
+	if err := provider.UpsertPullRequestReview(ctx, oauthCtx, repo.VCS.InstanceURL, repo.ExternalID, pullRequestID, review); err != nil {
+		logError("Failed to upsert pull request review for SQL review", err)
+	}
+}
+
+// convertSQLAdviceToVCSReview will convert SQL advice map to the commit status and
+// the pull request review posted through the VCS provider. Warnings are reported
+// as comments but do not fail the commit status. Advice without a line number
+// can't be anchored to the diff, so it goes into the review body instead.
+func convertSQLAdviceToVCSReview(adviceMap map[string][]advisor.Advice) (*vcs.CommitStatus, *vcs.PullRequestReview) {
+	errorCount, warningCount := 0, 0
+	commentList := []*vcs.PullRequestComment{}
+	fileAdviceList := []string{}
+
+	fileList := []string{}
+	for filePath := range adviceMap {
+		fileList = append(fileList, filePath)
+	}
+	sort.Strings(fileList)
+
+	for _, filePath := range fileList {
+		for _, advice := range adviceMap[filePath] {
+			if advice.Code == 0 || advice.Status == advisor.Success {
+				continue
+			}
+
+			if advice.Status == advisor.Error {
+				errorCount++
+			} else {
+				warningCount++
+			}
+
+			if advice.Line <= 0 {
+				fileAdviceList = append(fileAdviceList, fmt.Sprintf(
+					"- `%s`: **[%s] %s (%d)** %s Doc: %s#%d",
+					filePath,
+					advice.Status,
+					advice.Title,
+					advice.Code,
+					advice.Content,
+					sqlReviewDocs,
+					advice.Code,
+				))
+				continue
+			}
+
+			commentList = append(commentList, &vcs.PullRequestComment{
+				Path: filePath,
+				Line: advice.Line,
+				Body: fmt.Sprintf(
+					"**[%s] %s (%d)**\n\n%s\n\nDoc: %s#%d",
+					advice.Status,
+					advice.Title,
+					advice.Code,
+					advice.Content,
+					sqlReviewDocs,
+					advice.Code,
+				),
+			})
+		}
+	}
+
+	status := &vcs.CommitStatus{
+		State:       vcs.CommitStatusSuccess,
+		Context:     "bytebase/sql-review",
+		Description: "SQL review passed",
+	}
+	if errorCount > 0 {
+		status.State = vcs.CommitStatusFailure
+	}
+	if errorCount > 0 || warningCount > 0 {
+		status.Description = fmt.Sprintf("SQL review found %d error(s) and %d warning(s)", errorCount, warningCount)
+	}
+
+	body := status.Description
+	if len(fileAdviceList) > 0 {
+		body = fmt.Sprintf("%s\n\n%s", body, strings.Join(fileAdviceList, "\n"))
+	}
+	return status, &vcs.PullRequestReview{
+		Context:     status.Context,
+		Body:        body,
+		CommentList: commentList,
+	}
+}
@@ -0,0 +1,358 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	"github.com/google/jsonapi"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/common/log"
+	"github.com/bytebase/bytebase/plugin/vcs"
+)
+
+const (
+	// webhookDeliveryListLimit is the maximum number of webhook deliveries returned by the list API.
+	webhookDeliveryListLimit = 100
+	// webhookDeliveryProcessingTimeout is the time after which a delivery still in the PROCESSING
+	// status is considered abandoned, e.g. the server crashed, so that a redelivery can take it over.
+	webhookDeliveryProcessingTimeout = 10 * time.Minute
+	// redactedHeaderValue replaces the value of the secret headers before recording the delivery.
+	redactedHeaderValue = "******"
+)
+
+// webhookDeliveryRouteName is the path segment of the webhook route for each VCS type.
+var webhookDeliveryRouteName = map[vcs.Type]string{
+	vcs.GitLabSelfHost:  "gitlab",
+	vcs.GitHubCom:       "github",
+	vcs.Gitea:           "gitea",
+	vcs.BitbucketCloud:  "bitbucket",
+	vcs.BitbucketServer: "bitbucket-server",
+	vcs.AzureDevOps:     "azure",
+}
+
+// webhookDeliveryIDHeaderList is the list of headers carrying the unique delivery ID assigned by the VCS provider.
+var webhookDeliveryIDHeaderList = []string{
+	"X-Gitlab-Event-UUID",
+	"X-GitHub-Delivery",
+	"X-Gitea-Delivery",
+	"X-Request-UUID",
+	"X-Request-Id",
+}
+
+// webhookEventHeaderList is the list of headers carrying the event type.
+var webhookEventHeaderList = []string{
+	"X-Gitlab-Event",
+	"X-GitHub-Event",
+	"X-Gitea-Event",
+	"X-Event-Key",
+}
+
+// webhookSecretHeaderList is the list of headers carrying the webhook secret token.
+var webhookSecretHeaderList = []string{
+	"X-Gitlab-Token",
+	"Authorization",
+}
+
+type webhookReplayContextKey struct{}
+
+func getWebhookVerificationContextKey() string {
+	return "webhookVerification"
+}
+
+// setWebhookVerification records the verification result of the inbound webhook request.
+// The request passes the verification if any of the repositories verifies it.
+func setWebhookVerification(c echo.Context, ok bool) {
+	if ok {
+		c.Set(getWebhookVerificationContextKey(), api.WebhookDeliveryVerificationPassed)
+		return
+	}
+	if v, _ := c.Get(getWebhookVerificationContextKey()).(api.WebhookDeliveryVerification); v != api.WebhookDeliveryVerificationPassed {
+		c.Set(getWebhookVerificationContextKey(), api.WebhookDeliveryVerificationFailed)
+	}
+}
+
+// getWebhookDeliveryID returns the delivery ID assigned by the VCS provider, or the
+// SHA256 of the payload if the VCS provider doesn't assign one, e.g. Azure DevOps.
+func getWebhookDeliveryID(header http.Header, body []byte) string {
+	for _, key := range webhookDeliveryIDHeaderList {
+		if v := header.Get(key); v != "" {
+			return v
+		}
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+func getWebhookEvent(header http.Header) string {
+	for _, key := range webhookEventHeaderList {
+		if v := header.Get(key); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// redactWebhookSecretHeaders returns a copy of the headers with the webhook secret token hidden,
+// so that the secret never lands in the database.
+func redactWebhookSecretHeaders(header http.Header) http.Header {
+	redacted := header.Clone()
+	for _, key := range webhookSecretHeaderList {
+		if redacted.Get(key) != "" {
+			redacted.Set(key, redactedHeaderValue)
+		}
+	}
+	return redacted
+}
+
+// restoreWebhookSecretHeaders puts the webhook secret token of the repository back to the redacted
+// headers for replaying the delivery. The secret is only restored for the deliveries which passed the
+// verification, so that replaying a forged delivery still fails the verification.
+func restoreWebhookSecretHeaders(header http.Header, delivery *api.WebhookDelivery, repo *api.Repository) {
+	if delivery.Verification != api.WebhookDeliveryVerificationPassed {
+		return
+	}
+	if header.Get("X-Gitlab-Token") == redactedHeaderValue {
+		header.Set("X-Gitlab-Token", repo.WebhookSecretToken)
+	}
+	if header.Get("Authorization") == redactedHeaderValue {
+		// Azure DevOps sends the secret as the password of the basic authentication.
+		req := &http.Request{Header: header}
+		req.SetBasicAuth("", repo.WebhookSecretToken)
+	}
+}
+
+// webhookDeliveryResponseWriter records the response body written by the webhook handler.
+type webhookDeliveryResponseWriter struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *webhookDeliveryResponseWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// webhookDeliveryMiddleware records every inbound VCS webhook delivery along with its
+// verification result and processing outcome, and skips the redelivered ones that
+// have been processed.
+func (s *Server) webhookDeliveryMiddleware(vcsType vcs.Type) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx := c.Request().Context()
+
+			body, err := io.ReadAll(c.Request().Body)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "Failed to read webhook request").SetInternal(err)
+			}
+			c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+			var delivery *api.WebhookDelivery
+			if replayID, ok := ctx.Value(webhookReplayContextKey{}).(int); ok {
+				// The replay is triggered by the user, so we process it regardless of the previous outcome.
+				status := api.WebhookDeliveryProcessing
+				delivery, err = s.store.PatchWebhookDelivery(ctx, &api.WebhookDeliveryPatch{
+					ID:                  replayID,
+					Status:              &status,
+					IncreaseReplayCount: true,
+				})
+				if err != nil {
+					return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to replay webhook delivery %d", replayID)).SetInternal(err)
+				}
+			} else {
+				headers, err := json.Marshal(redactWebhookSecretHeaders(c.Request().Header))
+				if err != nil {
+					return echo.NewHTTPError(http.StatusInternalServerError, "Failed to marshal webhook request headers").SetInternal(err)
+				}
+				deliveryCreate := &api.WebhookDeliveryCreate{
+					WebhookEndpointID: c.Param("id"),
+					VCSType:           vcsType,
+					DeliveryID:        getWebhookDeliveryID(c.Request().Header, body),
+					Event:             getWebhookEvent(c.Request().Header),
+					Headers:           string(headers),
+					Payload:           string(body),
+				}
+				delivery, err = s.store.CreateWebhookDelivery(ctx, deliveryCreate)
+				if err != nil {
+					if common.ErrorCode(err) != common.Conflict {
+						return echo.NewHTTPError(http.StatusInternalServerError, "Failed to record webhook delivery").SetInternal(err)
+					}
+					delivery, err = s.handleDuplicateWebhookDelivery(ctx, deliveryCreate)
+					if err != nil {
+						return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to handle duplicate webhook delivery %s", deliveryCreate.DeliveryID)).SetInternal(err)
+					}
+					if delivery == nil {
+						log.Debug("Skipped duplicate webhook delivery", zap.String("endpoint", deliveryCreate.WebhookEndpointID), zap.String("deliveryID", deliveryCreate.DeliveryID))
+						return c.String(http.StatusOK, fmt.Sprintf("Duplicate delivery %s skipped", deliveryCreate.DeliveryID))
+					}
+				}
+			}
+
+			writer := &webhookDeliveryResponseWriter{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = writer
+			handleErr := next(c)
+
+			verification, ok := c.Get(getWebhookVerificationContextKey()).(api.WebhookDeliveryVerification)
+			if !ok {
+				verification = api.WebhookDeliveryVerificationNone
+			}
+			status, responseCode, result := api.WebhookDeliverySucceeded, c.Response().Status, writer.body.String()
+			if handleErr != nil {
+				status, responseCode, result = api.WebhookDeliveryFailed, http.StatusInternalServerError, handleErr.Error()
+				if httpErr, ok := handleErr.(*echo.HTTPError); ok {
+					responseCode = httpErr.Code
+				}
+			}
+			// Use a fresh context so that the outcome is still recorded if the VCS provider closes the connection.
+			if _, err := s.store.PatchWebhookDelivery(context.Background(), &api.WebhookDeliveryPatch{
+				ID:           delivery.ID,
+				Verification: &verification,
+				Status:       &status,
+				ResponseCode: &responseCode,
+				Result:       &result,
+			}); err != nil {
+				log.Error("Failed to record webhook delivery outcome", zap.Int("deliveryID", delivery.ID), zap.Error(err))
+			}
+			return handleErr
+		}
+	}
+}
+
+// handleDuplicateWebhookDelivery returns the existing delivery to process again if it failed
+// last time, or its processing has been abandoned. Otherwise, it counts the duplicate and
+// returns nil so that the caller skips it.
+func (s *Server) handleDuplicateWebhookDelivery(ctx context.Context, create *api.WebhookDeliveryCreate) (*api.WebhookDelivery, error) {
+	delivery, err := s.store.GetWebhookDelivery(ctx, &api.WebhookDeliveryFind{
+		WebhookEndpointID: &create.WebhookEndpointID,
+		DeliveryID:        &create.DeliveryID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if delivery == nil {
+		return nil, common.Errorf(common.NotFound, "webhook delivery %s not found", create.DeliveryID)
+	}
+	retried, err := s.store.RetryWebhookDelivery(ctx, delivery.ID, time.Now().Add(-webhookDeliveryProcessingTimeout).Unix())
+	if err != nil {
+		return nil, err
+	}
+	if retried != nil {
+		return retried, nil
+	}
+	if _, err := s.store.PatchWebhookDelivery(ctx, &api.WebhookDeliveryPatch{
+		ID:                     delivery.ID,
+		IncreaseDuplicateCount: true,
+	}); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (s *Server) registerWebhookDeliveryRoutes(g *echo.Group) {
+	g.GET("/project/:projectID/repository/delivery", func(c echo.Context) error {
+		ctx := c.Request().Context()
+		repo, err := s.getProjectRepository(ctx, c.Param("projectID"))
+		if err != nil {
+			return err
+		}
+
+		limit := webhookDeliveryListLimit
+		deliveryList, err := s.store.FindWebhookDelivery(ctx, &api.WebhookDeliveryFind{
+			WebhookEndpointID: &repo.WebhookEndpointID,
+			Limit:             &limit,
+		})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch webhook delivery list for repository %d", repo.ID)).SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, deliveryList); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal webhook delivery list response for repository %d", repo.ID)).SetInternal(err)
+		}
+		return nil
+	})
+
+	g.POST("/project/:projectID/repository/delivery/:deliveryID/replay", func(c echo.Context) error {
+		ctx := c.Request().Context()
+		repo, err := s.getProjectRepository(ctx, c.Param("projectID"))
+		if err != nil {
+			return err
+		}
+		id, err := strconv.Atoi(c.Param("deliveryID"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Webhook delivery ID is not a number: %s", c.Param("deliveryID"))).SetInternal(err)
+		}
+
+		delivery, err := s.store.GetWebhookDelivery(ctx, &api.WebhookDeliveryFind{
+			ID:                &id,
+			WebhookEndpointID: &repo.WebhookEndpointID,
+		})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch webhook delivery %d", id)).SetInternal(err)
+		}
+		if delivery == nil {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Webhook delivery not found: %d", id))
+		}
+		routeName, ok := webhookDeliveryRouteName[delivery.VCSType]
+		if !ok {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Unsupported VCS type %s of webhook delivery %d", delivery.VCSType, id))
+		}
+
+		header := http.Header{}
+		if err := json.Unmarshal([]byte(delivery.Headers), &header); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to unmarshal headers of webhook delivery %d", id)).SetInternal(err)
+		}
+		restoreWebhookSecretHeaders(header, delivery, repo)
+		// The replay is dispatched to the webhook route in process, so it goes through the same
+		// verification and processing as the original delivery.
+		replayCtx := context.WithValue(ctx, webhookReplayContextKey{}, delivery.ID)
+		req, err := http.NewRequestWithContext(replayCtx, http.MethodPost, fmt.Sprintf("/hook/%s/%s", routeName, delivery.WebhookEndpointID), bytes.NewReader([]byte(delivery.Payload)))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to build replay request of webhook delivery %d", id)).SetInternal(err)
+		}
+		req.Header = header
+		s.e.ServeHTTP(httptest.NewRecorder(), req)
+
+		delivery, err = s.store.GetWebhookDelivery(ctx, &api.WebhookDeliveryFind{ID: &id})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch webhook delivery %d", id)).SetInternal(err)
+		}
+		if delivery == nil {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Webhook delivery not found: %d", id))
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, delivery); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal webhook delivery response: %d", id)).SetInternal(err)
+		}
+		return nil
+	})
+}
+
+// getProjectRepository returns the repository linked to the project.
+func (s *Server) getProjectRepository(ctx context.Context, projectIDStr string) (*api.Repository, error) {
+	projectID, err := strconv.Atoi(projectIDStr)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Project ID is not a number: %s", projectIDStr)).SetInternal(err)
+	}
+	repo, err := s.store.GetRepository(ctx, &api.RepositoryFind{ProjectID: &projectID})
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch repository for project ID: %d", projectID)).SetInternal(err)
+	}
+	if repo == nil {
+		return nil, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Repository not found for project ID: %d", projectID))
+	}
+	return repo, nil
+}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"net/http"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -48,6 +49,71 @@ func TestValidateGitHubWebhookSignature256(t *testing.T) {
 	})
 }
 
+func TestGetWebhookDeliveryID(t *testing.T) {
+	body := []byte(`{"ref":"refs/heads/main"}`)
+	tests := []struct {
+		name   string
+		header http.Header
+		want   string
+	}{
+		{
+			name:   "GitHub",
+			header: http.Header{"X-Github-Delivery": []string{"72d3162e-cc78-11e3-81ab-4c9367dc0958"}},
+			want:   "72d3162e-cc78-11e3-81ab-4c9367dc0958",
+		},
+		{
+			name:   "GitLab",
+			header: http.Header{"X-Gitlab-Event-Uuid": []string{"9cebe914-4827-408f-b014-cfa23a47a35f"}},
+			want:   "9cebe914-4827-408f-b014-cfa23a47a35f",
+		},
+		{
+			name:   "Payload digest",
+			header: http.Header{},
+			want:   "090ba1b9ca860d37bb4ca7492549a8a347caac3490f763399e6498622c9b47f9",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := getWebhookDeliveryID(test.header, body)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestRedactWebhookSecretHeaders(t *testing.T) {
+	header := http.Header{
+		"Content-Type":   []string{"application/json"},
+		"X-Gitlab-Token": []string{"secret"},
+	}
+	got := redactWebhookSecretHeaders(header)
+	assert.Equal(t, http.Header{
+		"Content-Type":   []string{"application/json"},
+		"X-Gitlab-Token": []string{"******"},
+	}, got)
+	// The headers of the request are left intact for the verification.
+	assert.Equal(t, "secret", header.Get("X-Gitlab-Token"))
+}
+
+func TestRestoreWebhookSecretHeaders(t *testing.T) {
+	repo := &api.Repository{WebhookSecretToken: "secret"}
+
+	header := http.Header{"X-Gitlab-Token": []string{"******"}}
+	restoreWebhookSecretHeaders(header, &api.WebhookDelivery{Verification: api.WebhookDeliveryVerificationPassed}, repo)
+	assert.Equal(t, "secret", header.Get("X-Gitlab-Token"))
+
+	header = http.Header{"Authorization": []string{"******"}}
+	restoreWebhookSecretHeaders(header, &api.WebhookDelivery{Verification: api.WebhookDeliveryVerificationPassed}, repo)
+	_, password, ok := (&http.Request{Header: header}).BasicAuth()
+	require.True(t, ok)
+	assert.Equal(t, "secret", password)
+
+	// Replaying a delivery that failed the verification must not pass it.
+	header = http.Header{"X-Gitlab-Token": []string{"******"}}
+	restoreWebhookSecretHeaders(header, &api.WebhookDelivery{Verification: api.WebhookDeliveryVerificationFailed}, repo)
+	assert.Equal(t, "******", header.Get("X-Gitlab-Token"))
+}
+
 func TestParseBranchNameFromGitHubRefs(t *testing.T) {
 	tests := []struct {
 		refs   string
@@ -85,6 +151,41 @@ func TestParseBranchNameFromGitHubRefs(t *testing.T) {
 	}
 }
 
+func TestGetBranchMappingOfPullRequest(t *testing.T) {
+	repo := &api.Repository{
+		BranchFilter: "main",
+		BranchMapping: api.BranchMappingSetting{
+			MappingList: []*api.BranchMapping{
+				{BranchFilter: "main", EnvironmentIDList: []int{101, 102}},
+				{BranchFilter: "release/*", EnvironmentIDList: []int{103}},
+			},
+		},
+	}
+	// The push event derived from a pull request refers to the feature branch.
+	pushEvent := vcs.PushEvent{Ref: "refs/heads/feat/add-index"}
+	pullRequest := &pullRequestDraft{
+		event: vcs.PullRequestEvent{
+			SourceBranch: "feat/add-index",
+			TargetBranch: "main",
+		},
+	}
+
+	_, err := getBranchMapping(repo, pushEvent.Ref)
+	require.Error(t, err)
+
+	ref := getBranchMappingRef(pushEvent, pullRequest)
+	assert.Equal(t, "refs/heads/main", ref)
+	mapping, err := getBranchMapping(repo, ref)
+	require.NoError(t, err)
+	assert.Equal(t, []int{101, 102}, mapping.EnvironmentIDList)
+
+	// A regular push event matches the branch mapping by its own branch.
+	pushEvent = vcs.PushEvent{Ref: "refs/heads/release/1.0"}
+	mapping, err = getBranchMapping(repo, getBranchMappingRef(pushEvent, nil))
+	require.NoError(t, err)
+	assert.Equal(t, []int{103}, mapping.EnvironmentIDList)
+}
+
 var mockSQLAdviceMap = map[string][]advisor.Advice{
 	"file1.sql": {
 		{
@@ -184,6 +285,60 @@ func TestVCSSQLReview_ConvertSQLAdviceToGitHubActionResult(t *testing.T) {
 	assert.Equal(t, expect, res.Content)
 }
 
+func TestVCSSQLReview_ConvertSQLAdviceToVCSReview(t *testing.T) {
+	status, review := convertSQLAdviceToVCSReview(mockSQLAdviceMap)
+	assert.Equal(t, &vcs.CommitStatus{
+		State:       vcs.CommitStatusFailure,
+		Context:     "bytebase/sql-review",
+		Description: "SQL review found 2 error(s) and 2 warning(s)",
+	}, status)
+	assert.Equal(t, status.Description, review.Body)
+	assert.Equal(t, "bytebase/sql-review", review.Context)
+
+	type location struct {
+		path string
+		line int
+	}
+	var got []location
+	for _, comment := range review.CommentList {
+		got = append(got, location{path: comment.Path, line: comment.Line})
+	}
+	assert.Equal(t, []location{
+		{path: "file1.sql", line: 1},
+		{path: "file1.sql", line: 2},
+		{path: "file2.sql", line: 1},
+		{path: "file2.sql", line: 4},
+	}, got)
+	assert.Equal(t,
+		"**[WARN] column.no-null (402)**\n\nColumn \"id\" in \"public\".\"book\" cannot have NULL value\n\nDoc: https://www.bytebase.com/docs/reference/error-code/advisor#402",
+		review.CommentList[0].Body,
+	)
+
+	// Advice without a line number goes into the review body rather than an inline comment.
+	status, review = convertSQLAdviceToVCSReview(map[string][]advisor.Advice{
+		"file3.sql": {
+			{
+				Status:  advisor.Error,
+				Code:    advisor.StatementSyntaxError,
+				Title:   "Syntax error",
+				Content: "Syntax error at end of input",
+				Line:    0,
+			},
+		},
+	})
+	assert.Equal(t, vcs.CommitStatusFailure, status.State)
+	assert.Equal(t, 0, len(review.CommentList))
+	assert.Equal(t,
+		"SQL review found 1 error(s) and 0 warning(s)\n\n- `file3.sql`: **[ERROR] Syntax error (201)** Syntax error at end of input Doc: https://www.bytebase.com/docs/reference/error-code/advisor#201",
+		review.Body,
+	)
+
+	status, review = convertSQLAdviceToVCSReview(map[string][]advisor.Advice{})
+	assert.Equal(t, vcs.CommitStatusSuccess, status.State)
+	assert.Equal(t, "SQL review passed", status.Description)
+	assert.Equal(t, 0, len(review.CommentList))
+}
+
 func TestGetFileInfo(t *testing.T) {
 	t.Run("a SQL format DDL", func(t *testing.T) {
 		mi, fileType, repo, err := getFileInfo(
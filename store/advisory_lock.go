@@ -0,0 +1,97 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// advisoryLockKeyMigration is held while migrating the metadata schema, so that the replicas starting at the same time
+	// don't migrate concurrently.
+	advisoryLockKeyMigration int64 = 0x62620001
+	// AdvisoryLockKeyLeader is held by the leader replica which runs the background runners.
+	AdvisoryLockKeyLeader int64 = 0x62620002
+
+	// The TCP keepalive settings for the session holding the advisory lock, so that Postgres releases the lock of a
+	// dead replica in about idle + interval * count = 25 seconds instead of waiting for the OS default of hours.
+	advisoryLockKeepalivesStmt = `
+		SET tcp_keepalives_idle = 10;
+		SET tcp_keepalives_interval = 5;
+		SET tcp_keepalives_count = 3;
+	`
+)
+
+// AdvisoryLock is a session level Postgres advisory lock held on a dedicated connection to the metadata db.
+// The lock is held until it's released or the session ends, e.g. the replica crashes or loses the connection.
+type AdvisoryLock struct {
+	conn *sql.Conn
+	key  int64
+}
+
+// TryAcquireAdvisoryLock tries to acquire the advisory lock without waiting.
+// It returns nil if the lock is held by another session.
+func (s *Store) TryAcquireAdvisoryLock(ctx context.Context, key int64) (*AdvisoryLock, error) {
+	conn, err := s.db.db.Conn(ctx)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	if _, err := conn.ExecContext(ctx, advisoryLockKeepalivesStmt); err != nil {
+		closeSession(conn)
+		return nil, FormatError(err)
+	}
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&acquired); err != nil {
+		closeSession(conn)
+		return nil, FormatError(err)
+	}
+	if !acquired {
+		closeSession(conn)
+		return nil, nil
+	}
+	return &AdvisoryLock{conn: conn, key: key}, nil
+}
+
+// acquireAdvisoryLock acquires the advisory lock on the db, waiting until the lock is available.
+func acquireAdvisoryLock(ctx context.Context, db *sql.DB, key int64) (*AdvisoryLock, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", key); err != nil {
+		closeSession(conn)
+		return nil, err
+	}
+	return &AdvisoryLock{conn: conn, key: key}, nil
+}
+
+// Check checks the session holding the lock is still alive, which means the lock is still held.
+func (l *AdvisoryLock) Check(ctx context.Context) error {
+	var one int
+	if err := l.conn.QueryRowContext(ctx, "SELECT 1").Scan(&one); err != nil {
+		return errors.Wrapf(err, "lost the session holding advisory lock %d", l.key)
+	}
+	return nil
+}
+
+// Release releases the lock and closes the dedicated connection.
+func (l *AdvisoryLock) Release(ctx context.Context) error {
+	// Closing the session releases the lock as well.
+	defer closeSession(l.conn)
+	if _, err := l.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", l.key); err != nil {
+		return errors.Wrapf(err, "failed to release advisory lock %d", l.key)
+	}
+	return nil
+}
+
+// closeSession closes the dedicated connection and ends its session.
+// Closing a sql.Conn only puts the connection back to the pool, and the session would keep the lock and the settings.
+func closeSession(conn *sql.Conn) {
+	// Returning driver.ErrBadConn makes database/sql close the connection instead of putting it back to the pool.
+	_ = conn.Raw(func(interface{}) error {
+		return driver.ErrBadConn
+	})
+	conn.Close()
+}
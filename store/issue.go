@@ -188,6 +188,10 @@ func (s *Store) CountIssueGroupByTypeAndStatus(ctx context.Context) ([]*metric.I
 // CreateIssueValidateOnly creates an issue for validation purpose
 // Do NOT write to the database.
 func (s *Store) CreateIssueValidateOnly(ctx context.Context, pipeline *api.Pipeline, create *api.IssueCreate, creatorID int) (*api.Issue, error) {
+	status := api.IssueOpen
+	if create.Draft {
+		status = api.IssueDraft
+	}
 	issue := &api.Issue{
 		CreatorID:   creatorID,
 		CreatedTs:   time.Now().Unix(),
@@ -195,7 +199,7 @@ func (s *Store) CreateIssueValidateOnly(ctx context.Context, pipeline *api.Pipel
 		UpdatedTs:   time.Now().Unix(),
 		ProjectID:   create.ProjectID,
 		Name:        create.Name,
-		Status:      api.IssueOpen,
+		Status:      status,
 		Type:        create.Type,
 		Description: create.Description,
 		AssigneeID:  create.AssigneeID,
@@ -217,10 +221,14 @@ func (s *Store) CreatePipelineValidateOnly(ctx context.Context, create *api.Pipe
 	// jsonapi resource relationships will collide different resources into the same bucket.
 	id := 0
 	ts := time.Now().Unix()
+	status := create.Status
+	if status == "" {
+		status = api.PipelineOpen
+	}
 	pipeline := &api.Pipeline{
 		ID:        id,
 		Name:      create.Name,
-		Status:    api.PipelineOpen,
+		Status:    status,
 		CreatorID: creatorID,
 		CreatedTs: ts,
 		UpdaterID: creatorID,
@@ -228,6 +236,9 @@ func (s *Store) CreatePipelineValidateOnly(ctx context.Context, create *api.Pipe
 	}
 	for _, sc := range create.StageList {
 		id++
+		if sc.Payload == "" {
+			sc.Payload = "{}"
+		}
 		stage := &api.Stage{
 			ID:            id,
 			Name:          sc.Name,
@@ -237,6 +248,7 @@ func (s *Store) CreatePipelineValidateOnly(ctx context.Context, create *api.Pipe
 			UpdatedTs:     ts,
 			PipelineID:    sc.PipelineID,
 			EnvironmentID: sc.EnvironmentID,
+			Payload:       sc.Payload,
 		}
 		// We don't know IDs before inserting, so we use array index instead.
 		// indexBlockedByIndex[indexA] holds indices of the tasks that block taskList[indexA]
@@ -561,6 +573,10 @@ func (*Store) createIssueImpl(ctx context.Context, tx *Tx, create *api.IssueCrea
 	if create.Payload == "" {
 		create.Payload = "{}"
 	}
+	status := api.IssueOpen
+	if create.Draft {
+		status = api.IssueDraft
+	}
 	query := `
 		INSERT INTO issue (
 			creator_id,
@@ -574,7 +590,7 @@ func (*Store) createIssueImpl(ctx context.Context, tx *Tx, create *api.IssueCrea
 			assignee_id,
 			payload
 		)
-		VALUES ($1, $2, $3, $4, $5, 'OPEN', $6, $7, $8, $9)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		RETURNING id, creator_id, created_ts, updater_id, updated_ts, project_id, pipeline_id, name, status, type, description, assignee_id, payload
 	`
 	var issueRaw issueRaw
@@ -584,6 +600,7 @@ func (*Store) createIssueImpl(ctx context.Context, tx *Tx, create *api.IssueCrea
 		create.ProjectID,
 		create.PipelineID,
 		create.Name,
+		status,
 		create.Type,
 		create.Description,
 		create.AssigneeID,
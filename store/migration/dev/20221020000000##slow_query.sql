@@ -0,0 +1,31 @@
+-- slow_query stores the statistics of the normalized queries collected from the instances.
+-- The statistics are cumulative since the engine last reset them.
+CREATE TABLE slow_query (
+    id SERIAL PRIMARY KEY,
+    created_ts BIGINT NOT NULL DEFAULT extract(epoch from now()),
+    updated_ts BIGINT NOT NULL DEFAULT extract(epoch from now()),
+    instance_id INTEGER NOT NULL REFERENCES instance (id),
+    database_id INTEGER NOT NULL REFERENCES db (id),
+    -- fingerprint identifies the normalized query, e.g. the digest on MySQL and the queryid on Postgres.
+    fingerprint TEXT NOT NULL,
+    normalized_query TEXT NOT NULL,
+    sample_query TEXT NOT NULL DEFAULT '',
+    calls BIGINT NOT NULL,
+    total_latency_ns BIGINT NOT NULL,
+    max_latency_ns BIGINT NOT NULL,
+    rows_examined BIGINT NOT NULL,
+    rows_returned BIGINT NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}'
+);
+
+CREATE UNIQUE INDEX idx_slow_query_unique_database_id_fingerprint ON slow_query(database_id, fingerprint);
+
+CREATE INDEX idx_slow_query_instance_id ON slow_query(instance_id);
+
+ALTER SEQUENCE slow_query_id_seq RESTART WITH 101;
+
+CREATE TRIGGER update_slow_query_updated_ts
+BEFORE
+UPDATE
+    ON slow_query FOR EACH ROW
+EXECUTE FUNCTION trigger_update_updated_ts();
@@ -0,0 +1,17 @@
+-- table_size_history stores the snapshots of the table sizes taken by the schema syncer.
+-- Each bucket keeps the latest snapshot taken within the bucket period of the granularity.
+CREATE TABLE table_size_history (
+    id SERIAL PRIMARY KEY,
+    database_id INTEGER NOT NULL REFERENCES db (id),
+    table_name TEXT NOT NULL,
+    granularity TEXT NOT NULL CHECK (granularity IN ('HOUR', 'DAY')),
+    -- ts is the start of the bucket period.
+    ts BIGINT NOT NULL,
+    row_count BIGINT NOT NULL,
+    data_size BIGINT NOT NULL,
+    index_size BIGINT NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_table_size_history_unique_database_id_granularity_ts_table_name ON table_size_history(database_id, granularity, ts, table_name);
+
+ALTER SEQUENCE table_size_history_id_seq RESTART WITH 101;
@@ -0,0 +1,5 @@
+ALTER TABLE pipeline DROP CONSTRAINT pipeline_status_check;
+ALTER TABLE pipeline ADD CONSTRAINT pipeline_status_check CHECK (status IN ('OPEN', 'DONE', 'CANCELED', 'DRAFT'));
+
+ALTER TABLE issue DROP CONSTRAINT issue_status_check;
+ALTER TABLE issue ADD CONSTRAINT issue_status_check CHECK (status IN ('OPEN', 'DONE', 'CANCELED', 'DRAFT'));
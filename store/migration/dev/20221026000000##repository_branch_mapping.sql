@@ -0,0 +1 @@
+ALTER TABLE repository ADD branch_mapping JSONB NOT NULL DEFAULT '{}';
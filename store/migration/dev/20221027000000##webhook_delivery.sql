@@ -0,0 +1,32 @@
+-- webhook_delivery stores the inbound VCS webhook deliveries for deduplication and troubleshooting.
+CREATE TABLE webhook_delivery (
+    id SERIAL PRIMARY KEY,
+    created_ts BIGINT NOT NULL DEFAULT extract(epoch from now()),
+    updated_ts BIGINT NOT NULL DEFAULT extract(epoch from now()),
+    -- webhook_endpoint_id is the webhook_endpoint_id of the repository receiving the delivery.
+    webhook_endpoint_id TEXT NOT NULL,
+    vcs_type TEXT NOT NULL,
+    -- delivery_id is assigned by the VCS provider, or the SHA256 of the payload if the VCS provider doesn't assign one.
+    delivery_id TEXT NOT NULL,
+    event TEXT NOT NULL,
+    headers JSONB NOT NULL DEFAULT '{}',
+    payload TEXT NOT NULL,
+    verification TEXT NOT NULL CHECK (verification IN ('NONE', 'PASSED', 'FAILED')) DEFAULT 'NONE',
+    status TEXT NOT NULL CHECK (status IN ('PROCESSING', 'SUCCEEDED', 'FAILED')),
+    response_code INTEGER NOT NULL DEFAULT 0,
+    result TEXT NOT NULL DEFAULT '',
+    duplicate_count INTEGER NOT NULL DEFAULT 0,
+    replay_count INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX idx_webhook_delivery_unique_webhook_endpoint_id_delivery_id ON webhook_delivery(webhook_endpoint_id, delivery_id);
+
+CREATE INDEX idx_webhook_delivery_webhook_endpoint_id_created_ts ON webhook_delivery(webhook_endpoint_id, created_ts);
+
+ALTER SEQUENCE webhook_delivery_id_seq RESTART WITH 101;
+
+CREATE TRIGGER update_webhook_delivery_updated_ts
+BEFORE
+UPDATE
+    ON webhook_delivery FOR EACH ROW
+EXECUTE FUNCTION trigger_update_updated_ts();
@@ -0,0 +1 @@
+ALTER TABLE repository ADD repeatable_file_path_template TEXT NOT NULL DEFAULT '';
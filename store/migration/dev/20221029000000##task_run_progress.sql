@@ -0,0 +1 @@
+ALTER TABLE task_run ADD progress JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1 @@
+ALTER TABLE task ADD maintenance_window_override BOOLEAN NOT NULL DEFAULT FALSE;
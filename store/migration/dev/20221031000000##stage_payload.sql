@@ -0,0 +1 @@
+ALTER TABLE stage ADD payload JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,5 @@
+-- The webhook secret tokens are redacted before the webhook deliveries are recorded,
+-- so redact the ones recorded before as well.
+UPDATE webhook_delivery SET headers = headers || '{"X-Gitlab-Token": ["******"]}' WHERE headers ? 'X-Gitlab-Token';
+
+UPDATE webhook_delivery SET headers = headers || '{"Authorization": ["******"]}' WHERE headers ? 'Authorization';
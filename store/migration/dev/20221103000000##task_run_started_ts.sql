@@ -0,0 +1,4 @@
+ALTER TABLE task_run ADD started_ts BIGINT NOT NULL DEFAULT 0;
+
+-- The task runs before this migration are regarded as started.
+UPDATE task_run SET started_ts = created_ts;
@@ -0,0 +1 @@
+ALTER TABLE task_run ADD queue JSONB NOT NULL DEFAULT '{}';
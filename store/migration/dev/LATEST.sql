@@ -168,7 +168,7 @@ CREATE TABLE project (
     -- db_name_template is only used when a project is in tenant mode.
     -- Empty value means {{DB_NAME}}.
     db_name_template TEXT NOT NULL,
-    role_provider TEXT NOT NULL CHECK (role_provider IN ('BYTEBASE', 'GITLAB_SELF_HOST', 'GITHUB_COM')) DEFAULT 'BYTEBASE',
+    role_provider TEXT NOT NULL CHECK (role_provider IN ('BYTEBASE', 'GITLAB_SELF_HOST', 'GITHUB_COM', 'GITEA')) DEFAULT 'BYTEBASE',
     schema_version_type TEXT NOT NULL CHECK (schema_version_type IN ('TIMESTAMP', 'SEMANTIC')) DEFAULT 'TIMESTAMP',
     schema_change_type TEXT NOT NULL CHECK (schema_change_type IN ('DDL', 'SDL')) DEFAULT 'DDL',
     lgtm_check JSONB NOT NULL DEFAULT '{}'
@@ -220,7 +220,7 @@ CREATE TABLE project_member (
     project_id INTEGER NOT NULL REFERENCES project (id),
     role TEXT NOT NULL CHECK (role IN ('OWNER', 'DEVELOPER')),
     principal_id INTEGER NOT NULL REFERENCES principal (id),
-    role_provider TEXT NOT NULL CHECK (role_provider IN ('BYTEBASE', 'GITLAB_SELF_HOST', 'GITHUB_COM')) DEFAULT 'BYTEBASE',
+    role_provider TEXT NOT NULL CHECK (role_provider IN ('BYTEBASE', 'GITLAB_SELF_HOST', 'GITHUB_COM', 'GITEA')) DEFAULT 'BYTEBASE',
     -- payload is determined by the type of role_provider
     payload JSONB NOT NULL DEFAULT '{}'
 );
@@ -606,7 +606,7 @@ CREATE TABLE pipeline (
     updater_id INTEGER NOT NULL REFERENCES principal (id),
     updated_ts BIGINT NOT NULL DEFAULT extract(epoch from now()),
     name TEXT NOT NULL,
-    status TEXT NOT NULL CHECK (status IN ('OPEN', 'DONE', 'CANCELED'))
+    status TEXT NOT NULL CHECK (status IN ('OPEN', 'DONE', 'CANCELED', 'DRAFT'))
 );
 
 CREATE INDEX idx_pipeline_status ON pipeline(status);
@@ -629,7 +629,8 @@ CREATE TABLE stage (
     updated_ts BIGINT NOT NULL DEFAULT extract(epoch from now()),
     pipeline_id INTEGER NOT NULL REFERENCES pipeline (id),
     environment_id INTEGER NOT NULL REFERENCES environment (id),
-    name TEXT NOT NULL
+    name TEXT NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}'
 );
 
 CREATE INDEX idx_stage_pipeline_id ON stage(pipeline_id);
@@ -659,7 +660,9 @@ CREATE TABLE task (
     status TEXT NOT NULL CHECK (status IN ('PENDING', 'PENDING_APPROVAL', 'RUNNING', 'DONE', 'FAILED', 'CANCELED')),
     type TEXT NOT NULL CHECK (type LIKE 'bb.task.%'),
     payload JSONB NOT NULL DEFAULT '{}',
-    earliest_allowed_ts BIGINT NOT NULL DEFAULT 0
+    earliest_allowed_ts BIGINT NOT NULL DEFAULT 0,
+    -- The task is allowed to start outside the maintenance windows and change freezes of the environment.
+    maintenance_window_override BOOLEAN NOT NULL DEFAULT FALSE
 );
 
 CREATE INDEX idx_task_pipeline_id_stage_id ON task(pipeline_id, stage_id);
@@ -714,7 +717,13 @@ CREATE TABLE task_run (
     comment TEXT NOT NULL DEFAULT '',
     -- result saves the task run result in json format
     result  JSONB NOT NULL DEFAULT '{}',
-    payload JSONB NOT NULL DEFAULT '{}'
+    payload JSONB NOT NULL DEFAULT '{}',
+    -- started_ts is when the task executor starts to run the task run, 0 means the task run is still queued
+    started_ts BIGINT NOT NULL DEFAULT 0,
+    -- progress saves the live progress of the running task run in json format
+    progress JSONB NOT NULL DEFAULT '{}',
+    -- queue saves the queue status of the running task run waiting for its turn in json format
+    queue JSONB NOT NULL DEFAULT '{}'
 );
 
 CREATE INDEX idx_task_run_task_id ON task_run(task_id);
@@ -768,7 +777,7 @@ CREATE TABLE issue (
     project_id INTEGER NOT NULL REFERENCES project (id),
     pipeline_id INTEGER NOT NULL REFERENCES pipeline (id),
     name TEXT NOT NULL,
-    status TEXT NOT NULL CHECK (status IN ('OPEN', 'DONE', 'CANCELED')),
+    status TEXT NOT NULL CHECK (status IN ('OPEN', 'DONE', 'CANCELED', 'DRAFT')),
     type TEXT NOT NULL CHECK (type LIKE 'bb.issue.%'),
     description TEXT NOT NULL DEFAULT '',
     -- While changing assignee_id, one should only change it to a non-robot DBA/owner.
@@ -881,7 +890,7 @@ CREATE TABLE vcs (
     updater_id INTEGER NOT NULL REFERENCES principal (id),
     updated_ts BIGINT NOT NULL DEFAULT extract(epoch from now()),
     name TEXT NOT NULL,
-    type TEXT NOT NULL CHECK (type IN ('GITLAB_SELF_HOST', 'GITHUB_COM')),
+    type TEXT NOT NULL CHECK (type IN ('GITLAB_SELF_HOST', 'GITHUB_COM', 'BITBUCKET_CLOUD', 'BITBUCKET_SERVER', 'GITEA', 'AZURE_DEVOPS')),
     instance_url TEXT NOT NULL CHECK ((instance_url LIKE 'http://%' OR instance_url LIKE 'https://%') AND instance_url = rtrim(instance_url, '/')),
     api_url TEXT NOT NULL CHECK ((api_url LIKE 'http://%' OR api_url LIKE 'https://%') AND api_url = rtrim(api_url, '/')),
     application_id TEXT NOT NULL,
@@ -920,10 +929,16 @@ CREATE TABLE repository (
     -- Branch we are interested.
     -- For GitLab, this corresponds to webhook's push_events_branch_filter. Wildcard is supported
     branch_filter TEXT NOT NULL DEFAULT '',
+    -- Branch mapping routes the pushes to the matching branches to a set of environments.
+    -- Empty mapping means the pushes to the branch filter drive all environments.
+    branch_mapping JSONB NOT NULL DEFAULT '{}',
     -- Base working directory we are interested.
     base_directory TEXT NOT NULL DEFAULT '',
     -- The file path template for matching the committed migration script.
     file_path_template TEXT NOT NULL DEFAULT '',
+    -- The dedicated file path template for matching the committed repeatable migration script.
+    -- If empty, then the repeatable migration can only be recognized by the {{TYPE}} in the file path template.
+    repeatable_file_path_template TEXT NOT NULL DEFAULT '',
     -- The file path template for storing the latest schema auto-generated by Bytebase after migration.
     -- If empty, then Bytebase won't auto generate it.
     schema_path_template TEXT NOT NULL DEFAULT '',
@@ -1094,7 +1109,7 @@ CREATE TABLE sheet (
     name TEXT NOT NULL,
     statement TEXT NOT NULL,
     visibility TEXT NOT NULL CHECK (visibility IN ('PRIVATE', 'PROJECT', 'PUBLIC')) DEFAULT 'PRIVATE',
-    source TEXT NOT NULL CHECK (source IN ('BYTEBASE', 'GITLAB_SELF_HOST', 'GITHUB_COM')) DEFAULT 'BYTEBASE',
+    source TEXT NOT NULL CHECK (source IN ('BYTEBASE', 'GITLAB_SELF_HOST', 'GITHUB_COM', 'BITBUCKET_CLOUD', 'BITBUCKET_SERVER', 'GITEA', 'AZURE_DEVOPS')) DEFAULT 'BYTEBASE',
     type TEXT NOT NULL CHECK (type IN ('SQL')) DEFAULT 'SQL',
     payload JSONB NOT NULL DEFAULT '{}'
 );
@@ -1152,3 +1167,86 @@ BEFORE
 UPDATE
     ON external_approval FOR EACH ROW
 EXECUTE FUNCTION trigger_update_updated_ts();
+
+-- slow_query stores the statistics of the normalized queries collected from the instances.
+-- The statistics are cumulative since the engine last reset them.
+CREATE TABLE slow_query (
+    id SERIAL PRIMARY KEY,
+    created_ts BIGINT NOT NULL DEFAULT extract(epoch from now()),
+    updated_ts BIGINT NOT NULL DEFAULT extract(epoch from now()),
+    instance_id INTEGER NOT NULL REFERENCES instance (id),
+    database_id INTEGER NOT NULL REFERENCES db (id),
+    -- fingerprint identifies the normalized query, e.g. the digest on MySQL and the queryid on Postgres.
+    fingerprint TEXT NOT NULL,
+    normalized_query TEXT NOT NULL,
+    sample_query TEXT NOT NULL DEFAULT '',
+    calls BIGINT NOT NULL,
+    total_latency_ns BIGINT NOT NULL,
+    max_latency_ns BIGINT NOT NULL,
+    rows_examined BIGINT NOT NULL,
+    rows_returned BIGINT NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}'
+);
+
+CREATE UNIQUE INDEX idx_slow_query_unique_database_id_fingerprint ON slow_query(database_id, fingerprint);
+
+CREATE INDEX idx_slow_query_instance_id ON slow_query(instance_id);
+
+ALTER SEQUENCE slow_query_id_seq RESTART WITH 101;
+
+CREATE TRIGGER update_slow_query_updated_ts
+BEFORE
+UPDATE
+    ON slow_query FOR EACH ROW
+EXECUTE FUNCTION trigger_update_updated_ts();
+
+-- table_size_history stores the snapshots of the table sizes taken by the schema syncer.
+-- Each bucket keeps the latest snapshot taken within the bucket period of the granularity.
+CREATE TABLE table_size_history (
+    id SERIAL PRIMARY KEY,
+    database_id INTEGER NOT NULL REFERENCES db (id),
+    table_name TEXT NOT NULL,
+    granularity TEXT NOT NULL CHECK (granularity IN ('HOUR', 'DAY')),
+    -- ts is the start of the bucket period.
+    ts BIGINT NOT NULL,
+    row_count BIGINT NOT NULL,
+    data_size BIGINT NOT NULL,
+    index_size BIGINT NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_table_size_history_unique_database_id_granularity_ts_table_name ON table_size_history(database_id, granularity, ts, table_name);
+
+ALTER SEQUENCE table_size_history_id_seq RESTART WITH 101;
+
+-- webhook_delivery stores the inbound VCS webhook deliveries for deduplication and troubleshooting.
+CREATE TABLE webhook_delivery (
+    id SERIAL PRIMARY KEY,
+    created_ts BIGINT NOT NULL DEFAULT extract(epoch from now()),
+    updated_ts BIGINT NOT NULL DEFAULT extract(epoch from now()),
+    -- webhook_endpoint_id is the webhook_endpoint_id of the repository receiving the delivery.
+    webhook_endpoint_id TEXT NOT NULL,
+    vcs_type TEXT NOT NULL,
+    -- delivery_id is assigned by the VCS provider, or the SHA256 of the payload if the VCS provider doesn't assign one.
+    delivery_id TEXT NOT NULL,
+    event TEXT NOT NULL,
+    headers JSONB NOT NULL DEFAULT '{}',
+    payload TEXT NOT NULL,
+    verification TEXT NOT NULL CHECK (verification IN ('NONE', 'PASSED', 'FAILED')) DEFAULT 'NONE',
+    status TEXT NOT NULL CHECK (status IN ('PROCESSING', 'SUCCEEDED', 'FAILED')),
+    response_code INTEGER NOT NULL DEFAULT 0,
+    result TEXT NOT NULL DEFAULT '',
+    duplicate_count INTEGER NOT NULL DEFAULT 0,
+    replay_count INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX idx_webhook_delivery_unique_webhook_endpoint_id_delivery_id ON webhook_delivery(webhook_endpoint_id, delivery_id);
+
+CREATE INDEX idx_webhook_delivery_webhook_endpoint_id_created_ts ON webhook_delivery(webhook_endpoint_id, created_ts);
+
+ALTER SEQUENCE webhook_delivery_id_seq RESTART WITH 101;
+
+CREATE TRIGGER update_webhook_delivery_updated_ts
+BEFORE
+UPDATE
+    ON webhook_delivery FOR EACH ROW
+EXECUTE FUNCTION trigger_update_updated_ts();
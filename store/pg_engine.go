@@ -14,9 +14,13 @@ import (
 
 	"github.com/blang/semver/v4"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
 
 	"github.com/bytebase/bytebase/common/log"
 	dbdriver "github.com/bytebase/bytebase/plugin/db"
+	"github.com/bytebase/bytebase/plugin/tracing"
 
 	// Register postgres driver.
 	_ "github.com/bytebase/bytebase/plugin/db/pg"
@@ -125,6 +129,21 @@ func (db *DB) Open(ctx context.Context) (err error) {
 		return nil
 	}
 
+	// Multiple replicas may share the external metadata db and start at the same time.
+	// We hold an advisory lock during the migration so that only one of them migrates the schema at a time.
+	if db.connCfg.StrictUseDb {
+		lock, lockDriver, err := db.acquireMigrationLock(ctx, databaseName)
+		if err != nil {
+			return errors.Wrap(err, "failed to acquire the migration lock")
+		}
+		defer lockDriver.Close(ctx)
+		defer func() {
+			if err := lock.Release(ctx); err != nil {
+				log.Warn("Failed to release the migration lock", zap.Error(err))
+			}
+		}()
+	}
+
 	// We are also using our own migration core to manage our own schema's migration history.
 	// So here we will create a "bytebase" database to store the migration history if the target
 	// db instance does not have one yet.
@@ -162,6 +181,33 @@ func (db *DB) Open(ctx context.Context) (err error) {
 	return nil
 }
 
+// acquireMigrationLock acquires the migration advisory lock on a dedicated connection to the metadata db.
+// The caller should release the lock and close the returned driver after the migration.
+func (db *DB) acquireMigrationLock(ctx context.Context, databaseName string) (*AdvisoryLock, dbdriver.Driver, error) {
+	d, err := dbdriver.Open(
+		ctx,
+		dbdriver.Postgres,
+		dbdriver.DriverConfig{PgInstanceDir: db.pgBaseDir},
+		db.connCfg,
+		dbdriver.ConnectionContext{},
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	sqldb, err := d.GetDBConnection(ctx, databaseName)
+	if err != nil {
+		d.Close(ctx)
+		return nil, nil, err
+	}
+	log.Info("Waiting for the metadata schema migration lock...")
+	lock, err := acquireAdvisoryLock(ctx, sqldb, advisoryLockKeyMigration)
+	if err != nil {
+		d.Close(ctx)
+		return nil, nil, err
+	}
+	return lock, d, nil
+}
+
 // getLatestVersion returns the latest schema version in semantic versioning format.
 // We expect our own migration history to use semantic versions.
 // If there's no migration history, version will be nil.
@@ -660,6 +706,14 @@ type Tx struct {
 	now time.Time
 }
 
+// startQuerySpan starts a span for the query on the metadata db.
+func startQuerySpan(ctx context.Context, name, query string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, name,
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.statement", strings.TrimSpace(query)),
+	)
+}
+
 // FormatError returns err as a Bytebase error, if possible.
 // Otherwise returns the original error.
 func FormatError(err error) error {
@@ -717,6 +771,8 @@ func FormatError(err error) error {
 			return common.Errorf(common.Conflict, "project deployment configuration already exists")
 		case strings.Contains(err.Error(), "issue_subscriber_pkey"):
 			return common.Errorf(common.Conflict, "issue subscriber already exists")
+		case strings.Contains(err.Error(), "idx_webhook_delivery_unique_webhook_endpoint_id_delivery_id"):
+			return common.Errorf(common.Conflict, "webhook delivery already exists")
 		}
 	}
 	return err
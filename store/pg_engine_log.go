@@ -12,6 +12,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/bytebase/bytebase/common/log"
+	"github.com/bytebase/bytebase/plugin/tracing"
 )
 
 // Replace mutiple whitespace characters including /t/n with a single space.
@@ -30,17 +31,26 @@ func (tx *Tx) PrepareContext(ctx context.Context, query string) (*sql.Stmt, erro
 // ExecContext overrides sql.Tx ExecContext.
 func (tx *Tx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
 	log.Debug("ExecContext", zap.String("query", cleanQuery(query)))
-	return tx.Tx.ExecContext(ctx, query, args...)
+	ctx, span := startQuerySpan(ctx, "store.Exec", query)
+	result, err := tx.Tx.ExecContext(ctx, query, args...)
+	tracing.End(span, err)
+	return result, err
 }
 
 // QueryContext overrides sql.Tx QueryContext.
 func (tx *Tx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
 	log.Debug("QueryContext", zap.String("query", cleanQuery(query)))
-	return tx.Tx.QueryContext(ctx, query, args...)
+	ctx, span := startQuerySpan(ctx, "store.Query", query)
+	rows, err := tx.Tx.QueryContext(ctx, query, args...)
+	tracing.End(span, err)
+	return rows, err
 }
 
 // QueryRowContext overrides sql.Tx QueryRowContext.
 func (tx *Tx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
 	log.Debug("QueryRowContext", zap.String("query", cleanQuery(query)))
-	return tx.Tx.QueryRowContext(ctx, query, args...)
+	ctx, span := startQuerySpan(ctx, "store.QueryRow", query)
+	row := tx.Tx.QueryRowContext(ctx, query, args...)
+	tracing.End(span, row.Err())
+	return row
 }
@@ -0,0 +1,36 @@
+//go:build !store.db
+// +build !store.db
+
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/bytebase/bytebase/plugin/tracing"
+)
+
+// ExecContext executes a query without returning any rows and traces it.
+func (tx *Tx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startQuerySpan(ctx, "store.Exec", query)
+	result, err := tx.Tx.ExecContext(ctx, query, args...)
+	tracing.End(span, err)
+	return result, err
+}
+
+// QueryContext executes a query that returns rows and traces it.
+// The span covers the query execution but not the iteration of the returned rows.
+func (tx *Tx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := startQuerySpan(ctx, "store.Query", query)
+	rows, err := tx.Tx.QueryContext(ctx, query, args...)
+	tracing.End(span, err)
+	return rows, err
+}
+
+// QueryRowContext executes a query that is expected to return at most one row and traces it.
+func (tx *Tx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := startQuerySpan(ctx, "store.QueryRow", query)
+	row := tx.Tx.QueryRowContext(ctx, query, args...)
+	tracing.End(span, row.Err())
+	return row
+}
@@ -277,6 +277,9 @@ func (s *Store) patchPipelineRaw(ctx context.Context, patch *api.PipelinePatch)
 
 // createPipelineImpl creates a new pipeline.
 func (*Store) createPipelineImpl(ctx context.Context, tx *Tx, create *api.PipelineCreate) (*pipelineRaw, error) {
+	if create.Status == "" {
+		create.Status = api.PipelineOpen
+	}
 	query := `
 		INSERT INTO pipeline (
 			creator_id,
@@ -284,7 +287,7 @@ func (*Store) createPipelineImpl(ctx context.Context, tx *Tx, create *api.Pipeli
 			name,
 			status
 		)
-		VALUES ($1, $2, $3, 'OPEN')
+		VALUES ($1, $2, $3, $4)
 		RETURNING id, creator_id, created_ts, updater_id, updated_ts, name, status
 	`
 	var pipelineRaw pipelineRaw
@@ -292,6 +295,7 @@ func (*Store) createPipelineImpl(ctx context.Context, tx *Tx, create *api.Pipeli
 		create.CreatorID,
 		create.CreatorID,
 		create.Name,
+		create.Status,
 	).Scan(
 		&pipelineRaw.ID,
 		&pipelineRaw.CreatorID,
@@ -247,6 +247,45 @@ func (s *Store) GetEnvironmentTierPolicyByEnvID(ctx context.Context, environment
 	return api.UnmarshalEnvironmentTierPolicy(policy.Payload)
 }
 
+// GetTaskRetryPolicyByEnvID will get the task retry policy for an environment.
+func (s *Store) GetTaskRetryPolicyByEnvID(ctx context.Context, environmentID int) (*api.TaskRetryPolicy, error) {
+	pType := api.PolicyTypeTaskRetry
+	policy, err := s.getPolicyRaw(ctx, &api.PolicyFind{
+		EnvironmentID: &environmentID,
+		Type:          &pType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return api.UnmarshalTaskRetryPolicy(policy.Payload)
+}
+
+// GetTaskConcurrencyPolicyByEnvID will get the task concurrency policy for an environment.
+func (s *Store) GetTaskConcurrencyPolicyByEnvID(ctx context.Context, environmentID int) (*api.TaskConcurrencyPolicy, error) {
+	pType := api.PolicyTypeTaskConcurrency
+	policy, err := s.getPolicyRaw(ctx, &api.PolicyFind{
+		EnvironmentID: &environmentID,
+		Type:          &pType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return api.UnmarshalTaskConcurrencyPolicy(policy.Payload)
+}
+
+// GetMaintenanceWindowPolicyByEnvID will get the maintenance window policy for an environment.
+func (s *Store) GetMaintenanceWindowPolicyByEnvID(ctx context.Context, environmentID int) (*api.MaintenanceWindowPolicy, error) {
+	pType := api.PolicyTypeMaintenanceWindow
+	policy, err := s.getPolicyRaw(ctx, &api.PolicyFind{
+		EnvironmentID: &environmentID,
+		Type:          &pType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return api.UnmarshalMaintenanceWindowPolicy(policy.Payload)
+}
+
 //
 // private functions
 //
@@ -28,23 +28,25 @@ type repositoryRaw struct {
 	ProjectID int
 
 	// Domain specific fields
-	Name               string
-	FullPath           string
-	WebURL             string
-	BranchFilter       string
-	BaseDirectory      string
-	FilePathTemplate   string
-	SchemaPathTemplate string
-	SheetPathTemplate  string
-	EnableSQLReviewCI  bool
-	ExternalID         string
-	ExternalWebhookID  string
-	WebhookURLHost     string
-	WebhookEndpointID  string
-	WebhookSecretToken string
-	AccessToken        string
-	ExpiresTs          int64
-	RefreshToken       string
+	Name                       string
+	FullPath                   string
+	WebURL                     string
+	BranchFilter               string
+	BranchMapping              api.BranchMappingSetting
+	BaseDirectory              string
+	FilePathTemplate           string
+	RepeatableFilePathTemplate string
+	SchemaPathTemplate         string
+	SheetPathTemplate          string
+	EnableSQLReviewCI          bool
+	ExternalID                 string
+	ExternalWebhookID          string
+	WebhookURLHost             string
+	WebhookEndpointID          string
+	WebhookSecretToken         string
+	AccessToken                string
+	ExpiresTs                  int64
+	RefreshToken               string
 }
 
 // toRepository creates an instance of Repository based on the repositoryRaw.
@@ -61,23 +63,25 @@ func (raw *repositoryRaw) toRepository() *api.Repository {
 		VCSID:     raw.VCSID,
 		ProjectID: raw.ProjectID,
 
-		Name:               raw.Name,
-		FullPath:           raw.FullPath,
-		WebURL:             raw.WebURL,
-		BranchFilter:       raw.BranchFilter,
-		BaseDirectory:      raw.BaseDirectory,
-		FilePathTemplate:   raw.FilePathTemplate,
-		SchemaPathTemplate: raw.SchemaPathTemplate,
-		SheetPathTemplate:  raw.SheetPathTemplate,
-		EnableSQLReviewCI:  raw.EnableSQLReviewCI,
-		ExternalID:         raw.ExternalID,
-		ExternalWebhookID:  raw.ExternalWebhookID,
-		WebhookURLHost:     raw.WebhookURLHost,
-		WebhookEndpointID:  raw.WebhookEndpointID,
-		WebhookSecretToken: raw.WebhookSecretToken,
-		AccessToken:        raw.AccessToken,
-		ExpiresTs:          raw.ExpiresTs,
-		RefreshToken:       raw.RefreshToken,
+		Name:                       raw.Name,
+		FullPath:                   raw.FullPath,
+		WebURL:                     raw.WebURL,
+		BranchFilter:               raw.BranchFilter,
+		BranchMapping:              raw.BranchMapping,
+		BaseDirectory:              raw.BaseDirectory,
+		FilePathTemplate:           raw.FilePathTemplate,
+		RepeatableFilePathTemplate: raw.RepeatableFilePathTemplate,
+		SchemaPathTemplate:         raw.SchemaPathTemplate,
+		SheetPathTemplate:          raw.SheetPathTemplate,
+		EnableSQLReviewCI:          raw.EnableSQLReviewCI,
+		ExternalID:                 raw.ExternalID,
+		ExternalWebhookID:          raw.ExternalWebhookID,
+		WebhookURLHost:             raw.WebhookURLHost,
+		WebhookEndpointID:          raw.WebhookEndpointID,
+		WebhookSecretToken:         raw.WebhookSecretToken,
+		AccessToken:                raw.AccessToken,
+		ExpiresTs:                  raw.ExpiresTs,
+		RefreshToken:               raw.RefreshToken,
 	}
 }
 
@@ -302,8 +306,10 @@ func (s *Store) createRepositoryImpl(ctx context.Context, tx *Tx, create *api.Re
 				full_path,
 				web_url,
 				branch_filter,
+				branch_mapping,
 				base_directory,
 				file_path_template,
+				repeatable_file_path_template,
 				schema_path_template,
 				sheet_path_template,
 				external_id,
@@ -315,8 +321,8 @@ func (s *Store) createRepositoryImpl(ctx context.Context, tx *Tx, create *api.Re
 				expires_ts,
 				refresh_token
 			)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
-			RETURNING id, creator_id, created_ts, updater_id, updated_ts, vcs_id, project_id, name, full_path, web_url, branch_filter, base_directory, file_path_template, schema_path_template, sheet_path_template, enable_sql_review_ci, external_id, external_webhook_id, webhook_url_host, webhook_endpoint_id, webhook_secret_token, access_token, expires_ts, refresh_token
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
+			RETURNING id, creator_id, created_ts, updater_id, updated_ts, vcs_id, project_id, name, full_path, web_url, branch_filter, branch_mapping, base_directory, file_path_template, repeatable_file_path_template, schema_path_template, sheet_path_template, enable_sql_review_ci, external_id, external_webhook_id, webhook_url_host, webhook_endpoint_id, webhook_secret_token, access_token, expires_ts, refresh_token
 		`
 		if err := tx.QueryRowContext(ctx, query,
 			create.CreatorID,
@@ -327,8 +333,10 @@ func (s *Store) createRepositoryImpl(ctx context.Context, tx *Tx, create *api.Re
 			create.FullPath,
 			create.WebURL,
 			create.BranchFilter,
+			create.BranchMapping,
 			create.BaseDirectory,
 			create.FilePathTemplate,
+			create.RepeatableFilePathTemplate,
 			create.SchemaPathTemplate,
 			create.SheetPathTemplate,
 			create.ExternalID,
@@ -351,8 +359,10 @@ func (s *Store) createRepositoryImpl(ctx context.Context, tx *Tx, create *api.Re
 			&repository.FullPath,
 			&repository.WebURL,
 			&repository.BranchFilter,
+			&repository.BranchMapping,
 			&repository.BaseDirectory,
 			&repository.FilePathTemplate,
+			&repository.RepeatableFilePathTemplate,
 			&repository.SchemaPathTemplate,
 			&repository.SheetPathTemplate,
 			&repository.EnableSQLReviewCI,
@@ -481,8 +491,10 @@ func (*Store) findRepositoryImpl(ctx context.Context, tx *Tx, find *api.Reposito
 			full_path,
 			web_url,
 			branch_filter,
+			branch_mapping,
 			base_directory,
 			file_path_template,
+			repeatable_file_path_template,
 			schema_path_template,
 			sheet_path_template,
 			enable_sql_review_ci,
@@ -519,8 +531,10 @@ func (*Store) findRepositoryImpl(ctx context.Context, tx *Tx, find *api.Reposito
 			&repository.FullPath,
 			&repository.WebURL,
 			&repository.BranchFilter,
+			&repository.BranchMapping,
 			&repository.BaseDirectory,
 			&repository.FilePathTemplate,
+			&repository.RepeatableFilePathTemplate,
 			&repository.SchemaPathTemplate,
 			&repository.SheetPathTemplate,
 			&repository.EnableSQLReviewCI,
@@ -552,12 +566,18 @@ func (*Store) patchRepositoryImpl(ctx context.Context, tx *Tx, patch *api.Reposi
 	if v := patch.BranchFilter; v != nil {
 		set, args = append(set, fmt.Sprintf("branch_filter = $%d", len(args)+1)), append(args, *v)
 	}
+	if v := patch.BranchMapping; v != nil {
+		set, args = append(set, fmt.Sprintf("branch_mapping = $%d", len(args)+1)), append(args, *v)
+	}
 	if v := patch.BaseDirectory; v != nil {
 		set, args = append(set, fmt.Sprintf("base_directory = $%d", len(args)+1)), append(args, *v)
 	}
 	if v := patch.FilePathTemplate; v != nil {
 		set, args = append(set, fmt.Sprintf("file_path_template = $%d", len(args)+1)), append(args, *v)
 	}
+	if v := patch.RepeatableFilePathTemplate; v != nil {
+		set, args = append(set, fmt.Sprintf("repeatable_file_path_template = $%d", len(args)+1)), append(args, *v)
+	}
 	if v := patch.SchemaPathTemplate; v != nil {
 		set, args = append(set, fmt.Sprintf("schema_path_template = $%d", len(args)+1)), append(args, *v)
 	}
@@ -593,7 +613,7 @@ func (*Store) patchRepositoryImpl(ctx context.Context, tx *Tx, patch *api.Reposi
 		UPDATE repository
 		SET `+strings.Join(set, ", ")+`
 		WHERE `+strings.Join(where, " AND ")+`
-		RETURNING id, creator_id, created_ts, updater_id, updated_ts, vcs_id, project_id, name, full_path, web_url, branch_filter, base_directory, file_path_template, schema_path_template, sheet_path_template, enable_sql_review_ci, external_id, external_webhook_id, webhook_url_host, webhook_endpoint_id, webhook_secret_token, access_token, expires_ts, refresh_token
+		RETURNING id, creator_id, created_ts, updater_id, updated_ts, vcs_id, project_id, name, full_path, web_url, branch_filter, branch_mapping, base_directory, file_path_template, repeatable_file_path_template, schema_path_template, sheet_path_template, enable_sql_review_ci, external_id, external_webhook_id, webhook_url_host, webhook_endpoint_id, webhook_secret_token, access_token, expires_ts, refresh_token
 		`,
 		args...,
 	).Scan(
@@ -608,8 +628,10 @@ func (*Store) patchRepositoryImpl(ctx context.Context, tx *Tx, patch *api.Reposi
 		&repository.FullPath,
 		&repository.WebURL,
 		&repository.BranchFilter,
+		&repository.BranchMapping,
 		&repository.BaseDirectory,
 		&repository.FilePathTemplate,
+		&repository.RepeatableFilePathTemplate,
 		&repository.SchemaPathTemplate,
 		&repository.SheetPathTemplate,
 		&repository.EnableSQLReviewCI,
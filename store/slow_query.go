@@ -0,0 +1,210 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+)
+
+// ReplaceSlowQueryList upserts the slow queries collected from an instance and
+// deletes the ones of the instance no longer reported by the engine.
+func (s *Store) ReplaceSlowQueryList(ctx context.Context, instanceID int, upsertList []*api.SlowQueryUpsert) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return FormatError(err)
+	}
+	defer tx.Rollback()
+
+	var idList []int
+	for _, upsert := range upsertList {
+		slowQuery, err := upsertSlowQueryImpl(ctx, tx, upsert)
+		if err != nil {
+			return err
+		}
+		idList = append(idList, slowQuery.ID)
+	}
+	if err := deleteStaleSlowQueryImpl(ctx, tx, instanceID, idList); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return FormatError(err)
+	}
+
+	return nil
+}
+
+// FindSlowQuery finds a list of slow queries ordered by the total latency in descending order.
+func (s *Store) FindSlowQuery(ctx context.Context, find *api.SlowQueryFind) ([]*api.SlowQuery, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	list, err := findSlowQueryImpl(ctx, tx, find)
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// upsertSlowQueryImpl upserts a slow query by the database and the fingerprint.
+func upsertSlowQueryImpl(ctx context.Context, tx *Tx, upsert *api.SlowQueryUpsert) (*api.SlowQuery, error) {
+	if upsert.Payload == "" {
+		upsert.Payload = "{}"
+	}
+	query := `
+		INSERT INTO slow_query (
+			instance_id,
+			database_id,
+			fingerprint,
+			normalized_query,
+			sample_query,
+			calls,
+			total_latency_ns,
+			max_latency_ns,
+			rows_examined,
+			rows_returned,
+			payload
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (database_id, fingerprint) DO UPDATE SET
+			normalized_query = excluded.normalized_query,
+			sample_query = excluded.sample_query,
+			calls = excluded.calls,
+			total_latency_ns = excluded.total_latency_ns,
+			max_latency_ns = excluded.max_latency_ns,
+			rows_examined = excluded.rows_examined,
+			rows_returned = excluded.rows_returned,
+			payload = excluded.payload
+		RETURNING id, created_ts, updated_ts, instance_id, database_id, fingerprint, normalized_query, sample_query, calls, total_latency_ns, max_latency_ns, rows_examined, rows_returned, payload
+	`
+	var slowQuery api.SlowQuery
+	if err := tx.QueryRowContext(ctx, query,
+		upsert.InstanceID,
+		upsert.DatabaseID,
+		upsert.Fingerprint,
+		upsert.NormalizedQuery,
+		upsert.SampleQuery,
+		upsert.Calls,
+		upsert.TotalLatencyNs,
+		upsert.MaxLatencyNs,
+		upsert.RowsExamined,
+		upsert.RowsReturned,
+		upsert.Payload,
+	).Scan(
+		&slowQuery.ID,
+		&slowQuery.CreatedTs,
+		&slowQuery.UpdatedTs,
+		&slowQuery.InstanceID,
+		&slowQuery.DatabaseID,
+		&slowQuery.Fingerprint,
+		&slowQuery.NormalizedQuery,
+		&slowQuery.SampleQuery,
+		&slowQuery.Calls,
+		&slowQuery.TotalLatencyNs,
+		&slowQuery.MaxLatencyNs,
+		&slowQuery.RowsExamined,
+		&slowQuery.RowsReturned,
+		&slowQuery.Payload,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, common.FormatDBErrorEmptyRowWithQuery(query)
+		}
+		return nil, FormatError(err)
+	}
+	return &slowQuery, nil
+}
+
+// deleteStaleSlowQueryImpl deletes the slow queries of the instance except the ones in keepIDList.
+func deleteStaleSlowQueryImpl(ctx context.Context, tx *Tx, instanceID int, keepIDList []int) error {
+	where, args := []string{"instance_id = $1"}, []interface{}{instanceID}
+	if len(keepIDList) != 0 {
+		list := []string{}
+		for _, id := range keepIDList {
+			list = append(list, fmt.Sprintf("$%d", len(args)+1))
+			args = append(args, id)
+		}
+		where = append(where, fmt.Sprintf("id NOT IN (%s)", strings.Join(list, ",")))
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM slow_query WHERE `+strings.Join(where, " AND "), args...); err != nil {
+		return FormatError(err)
+	}
+	return nil
+}
+
+func findSlowQueryImpl(ctx context.Context, tx *Tx, find *api.SlowQueryFind) ([]*api.SlowQuery, error) {
+	// Build WHERE clause.
+	where, args := []string{"1 = 1"}, []interface{}{}
+	if v := find.InstanceID; v != nil {
+		where, args = append(where, fmt.Sprintf("instance_id = $%d", len(args)+1)), append(args, *v)
+	}
+	if v := find.DatabaseID; v != nil {
+		where, args = append(where, fmt.Sprintf("database_id = $%d", len(args)+1)), append(args, *v)
+	}
+
+	query := `
+		SELECT
+			id,
+			created_ts,
+			updated_ts,
+			instance_id,
+			database_id,
+			fingerprint,
+			normalized_query,
+			sample_query,
+			calls,
+			total_latency_ns,
+			max_latency_ns,
+			rows_examined,
+			rows_returned,
+			payload
+		FROM slow_query
+		WHERE ` + strings.Join(where, " AND ") + `
+		ORDER BY total_latency_ns DESC`
+	if v := find.Limit; v != nil {
+		query += fmt.Sprintf(" LIMIT %d", *v)
+	}
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	// Iterate over result set and deserialize rows into slowQueryList.
+	var slowQueryList []*api.SlowQuery
+	for rows.Next() {
+		var slowQuery api.SlowQuery
+		if err := rows.Scan(
+			&slowQuery.ID,
+			&slowQuery.CreatedTs,
+			&slowQuery.UpdatedTs,
+			&slowQuery.InstanceID,
+			&slowQuery.DatabaseID,
+			&slowQuery.Fingerprint,
+			&slowQuery.NormalizedQuery,
+			&slowQuery.SampleQuery,
+			&slowQuery.Calls,
+			&slowQuery.TotalLatencyNs,
+			&slowQuery.MaxLatencyNs,
+			&slowQuery.RowsExamined,
+			&slowQuery.RowsReturned,
+			&slowQuery.Payload,
+		); err != nil {
+			return nil, FormatError(err)
+		}
+
+		slowQueryList = append(slowQueryList, &slowQuery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return slowQueryList, nil
+}
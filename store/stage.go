@@ -28,7 +28,8 @@ type stageRaw struct {
 	EnvironmentID int
 
 	// Domain specific fields
-	Name string
+	Name    string
+	Payload string
 }
 
 // toStage creates an instance of Stage based on the stageRaw.
@@ -48,7 +49,8 @@ func (raw *stageRaw) toStage() *api.Stage {
 		EnvironmentID: raw.EnvironmentID,
 
 		// Domain specific fields
-		Name: raw.Name,
+		Name:    raw.Name,
+		Payload: raw.Payload,
 	}
 }
 
@@ -82,6 +84,19 @@ func (s *Store) FindStage(ctx context.Context, find *api.StageFind) ([]*api.Stag
 	return stageList, nil
 }
 
+// PatchStage patches an instance of Stage.
+func (s *Store) PatchStage(ctx context.Context, patch *api.StagePatch) (*api.Stage, error) {
+	stageRaw, err := s.patchStageRaw(ctx, patch)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to patch Stage with StagePatch[%+v]", patch)
+	}
+	stage, err := s.composeStage(ctx, stageRaw)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to compose Stage with stageRaw[%+v]", stageRaw)
+	}
+	return stage, nil
+}
+
 //
 // private functions
 //
@@ -181,6 +196,26 @@ func (s *Store) findStageRaw(ctx context.Context, find *api.StageFind) ([]*stage
 	return stageRawList, nil
 }
 
+// patchStageRaw updates an existing stage by ID.
+func (s *Store) patchStageRaw(ctx context.Context, patch *api.StagePatch) (*stageRaw, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	stage, err := s.patchStageImpl(ctx, tx, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return stage, nil
+}
+
 // createStageImpl creates a new stage.
 func (*Store) createStageImpl(ctx context.Context, tx *Tx, create *api.StageCreate) (*stageRaw, error) {
 	query := `
@@ -189,11 +224,15 @@ func (*Store) createStageImpl(ctx context.Context, tx *Tx, create *api.StageCrea
 			updater_id,
 			pipeline_id,
 			environment_id,
-			name
+			name,
+			payload
 		)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, creator_id, created_ts, updater_id, updated_ts, pipeline_id, environment_id, name` + `
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, creator_id, created_ts, updater_id, updated_ts, pipeline_id, environment_id, name, payload` + `
 	`
+	if create.Payload == "" {
+		create.Payload = "{}"
+	}
 	var stageRaw stageRaw
 	if err := tx.QueryRowContext(ctx, query,
 		create.CreatorID,
@@ -201,6 +240,7 @@ func (*Store) createStageImpl(ctx context.Context, tx *Tx, create *api.StageCrea
 		create.PipelineID,
 		create.EnvironmentID,
 		create.Name,
+		create.Payload,
 	).Scan(
 		&stageRaw.ID,
 		&stageRaw.CreatorID,
@@ -210,6 +250,7 @@ func (*Store) createStageImpl(ctx context.Context, tx *Tx, create *api.StageCrea
 		&stageRaw.PipelineID,
 		&stageRaw.EnvironmentID,
 		&stageRaw.Name,
+		&stageRaw.Payload,
 	); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, common.FormatDBErrorEmptyRowWithQuery(query)
@@ -219,6 +260,43 @@ func (*Store) createStageImpl(ctx context.Context, tx *Tx, create *api.StageCrea
 	return &stageRaw, nil
 }
 
+// patchStageImpl updates a stage by ID. Returns the new state of the stage after update.
+func (*Store) patchStageImpl(ctx context.Context, tx *Tx, patch *api.StagePatch) (*stageRaw, error) {
+	// Build UPDATE clause.
+	set, args := []string{"updater_id = $1"}, []interface{}{patch.UpdaterID}
+	if v := patch.Payload; v != nil {
+		set, args = append(set, fmt.Sprintf("payload = $%d", len(args)+1)), append(args, *v)
+	}
+	args = append(args, patch.ID)
+
+	var stageRaw stageRaw
+	// Execute update query with RETURNING.
+	if err := tx.QueryRowContext(ctx, fmt.Sprintf(`
+		UPDATE stage
+		SET `+strings.Join(set, ", ")+`
+		WHERE id = $%d
+		RETURNING id, creator_id, created_ts, updater_id, updated_ts, pipeline_id, environment_id, name, payload
+	`, len(args)),
+		args...,
+	).Scan(
+		&stageRaw.ID,
+		&stageRaw.CreatorID,
+		&stageRaw.CreatedTs,
+		&stageRaw.UpdaterID,
+		&stageRaw.UpdatedTs,
+		&stageRaw.PipelineID,
+		&stageRaw.EnvironmentID,
+		&stageRaw.Name,
+		&stageRaw.Payload,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &common.Error{Code: common.NotFound, Err: errors.Errorf("stage not found with ID %d", patch.ID)}
+		}
+		return nil, FormatError(err)
+	}
+	return &stageRaw, nil
+}
+
 func (*Store) findStageImpl(ctx context.Context, tx *Tx, find *api.StageFind) ([]*stageRaw, error) {
 	// Build WHERE clause.
 	where, args := []string{"1 = 1"}, []interface{}{}
@@ -238,7 +316,8 @@ func (*Store) findStageImpl(ctx context.Context, tx *Tx, find *api.StageFind) ([
 			updated_ts,
 			pipeline_id,
 			environment_id,
-			name
+			name,
+			payload
 		FROM stage
 		WHERE `+strings.Join(where, " AND ")+` ORDER BY id ASC`,
 		args...,
@@ -261,6 +340,7 @@ func (*Store) findStageImpl(ctx context.Context, tx *Tx, find *api.StageFind) ([
 			&stageRaw.PipelineID,
 			&stageRaw.EnvironmentID,
 			&stageRaw.Name,
+			&stageRaw.Payload,
 		); err != nil {
 			return nil, FormatError(err)
 		}
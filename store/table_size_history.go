@@ -0,0 +1,202 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/plugin/db"
+)
+
+const (
+	// tableSizeHistoryHourRetention is the retention of the hourly table size history in seconds.
+	tableSizeHistoryHourRetention = 7 * 24 * 60 * 60
+	// tableSizeHistoryDayRetention is the retention of the daily table size history in seconds.
+	tableSizeHistoryDayRetention = 365 * 24 * 60 * 60
+	// tableSizeHistoryBatchSize is the number of tables inserted in one statement.
+	tableSizeHistoryBatchSize = 500
+)
+
+// tableSizeHistoryGranularityList is the list of granularities the snapshot is downsampled to.
+var tableSizeHistoryGranularityList = []api.TableSizeHistoryGranularity{
+	api.TableSizeHistoryGranularityHour,
+	api.TableSizeHistoryGranularityDay,
+}
+
+// UpsertTableSizeHistory records the table sizes of a database snapshotted at ts.
+// The snapshot replaces the previous one in the same bucket period of each granularity.
+func (s *Store) UpsertTableSizeHistory(ctx context.Context, schema *db.Schema, databaseID int, ts int64) error {
+	if len(schema.TableList) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return FormatError(err)
+	}
+	defer tx.Rollback()
+
+	for _, granularity := range tableSizeHistoryGranularityList {
+		bucketTs := ts - ts%granularity.Seconds()
+		for i := 0; i < len(schema.TableList); i += tableSizeHistoryBatchSize {
+			end := i + tableSizeHistoryBatchSize
+			if end > len(schema.TableList) {
+				end = len(schema.TableList)
+			}
+			if err := upsertTableSizeHistoryImpl(ctx, tx, databaseID, granularity, bucketTs, schema.TableList[i:end]); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return FormatError(err)
+	}
+
+	return nil
+}
+
+// FindTableSizeHistory finds the table size history of a table, or of the whole database if the table name is not specified.
+func (s *Store) FindTableSizeHistory(ctx context.Context, find *api.TableSizeHistoryFind) ([]*api.TableSizeHistory, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	list, err := findTableSizeHistoryImpl(ctx, tx, find)
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// DeleteExpiredTableSizeHistory deletes the table size history beyond the retention of each granularity.
+func (s *Store) DeleteExpiredTableSizeHistory(ctx context.Context, now int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return FormatError(err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM table_size_history
+		WHERE (granularity = $1 AND ts < $2) OR (granularity = $3 AND ts < $4)`,
+		api.TableSizeHistoryGranularityHour, now-tableSizeHistoryHourRetention,
+		api.TableSizeHistoryGranularityDay, now-tableSizeHistoryDayRetention,
+	); err != nil {
+		return FormatError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return FormatError(err)
+	}
+
+	return nil
+}
+
+func upsertTableSizeHistoryImpl(ctx context.Context, tx *Tx, databaseID int, granularity api.TableSizeHistoryGranularity, ts int64, tableList []db.Table) error {
+	var valueList []string
+	var args []interface{}
+	for _, table := range tableList {
+		valueList = append(valueList, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d)", len(args)+1, len(args)+2, len(args)+3, len(args)+4, len(args)+5, len(args)+6, len(args)+7))
+		args = append(args, databaseID, table.Name, granularity, ts, table.RowCount, table.DataSize, table.IndexSize)
+	}
+	query := `
+		INSERT INTO table_size_history (
+			database_id,
+			table_name,
+			granularity,
+			ts,
+			row_count,
+			data_size,
+			index_size
+		)
+		VALUES ` + strings.Join(valueList, ", ") + `
+		ON CONFLICT (database_id, granularity, ts, table_name) DO UPDATE SET
+			row_count = excluded.row_count,
+			data_size = excluded.data_size,
+			index_size = excluded.index_size
+	`
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return FormatError(err)
+	}
+	return nil
+}
+
+func findTableSizeHistoryImpl(ctx context.Context, tx *Tx, find *api.TableSizeHistoryFind) ([]*api.TableSizeHistory, error) {
+	// Build WHERE clause.
+	where, args := []string{"granularity = $1"}, []interface{}{find.Granularity}
+	if v := find.DatabaseID; v != nil {
+		where, args = append(where, fmt.Sprintf("database_id = $%d", len(args)+1)), append(args, *v)
+	}
+	if v := find.TableName; v != nil {
+		where, args = append(where, fmt.Sprintf("table_name = $%d", len(args)+1)), append(args, *v)
+	}
+	if v := find.SinceTs; v != nil {
+		where, args = append(where, fmt.Sprintf("ts >= $%d", len(args)+1)), append(args, *v)
+	}
+
+	// Sum up the sizes of all tables in the database if the table name is not specified.
+	query := `
+		SELECT
+			MIN(id),
+			database_id,
+			'',
+			granularity,
+			ts,
+			SUM(row_count),
+			SUM(data_size),
+			SUM(index_size)
+		FROM table_size_history
+		WHERE ` + strings.Join(where, " AND ") + `
+		GROUP BY database_id, granularity, ts
+		ORDER BY ts ASC`
+	if find.TableName != nil {
+		query = `
+			SELECT
+				id,
+				database_id,
+				table_name,
+				granularity,
+				ts,
+				row_count,
+				data_size,
+				index_size
+			FROM table_size_history
+			WHERE ` + strings.Join(where, " AND ") + `
+			ORDER BY ts ASC`
+	}
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	// Iterate over result set and deserialize rows into historyList.
+	var historyList []*api.TableSizeHistory
+	for rows.Next() {
+		var history api.TableSizeHistory
+		if err := rows.Scan(
+			&history.ID,
+			&history.DatabaseID,
+			&history.TableName,
+			&history.Granularity,
+			&history.Ts,
+			&history.RowCount,
+			&history.DataSize,
+			&history.IndexSize,
+		); err != nil {
+			return nil, FormatError(err)
+		}
+
+		historyList = append(historyList, &history)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return historyList, nil
+}
@@ -44,6 +44,8 @@ type taskRaw struct {
 	Payload           string
 	EarliestAllowedTs int64
 	BlockedBy         []string
+
+	MaintenanceWindowOverride bool
 }
 
 // toTask creates an instance of Task based on the taskRaw.
@@ -72,6 +74,8 @@ func (raw *taskRaw) toTask() *api.Task {
 		Payload:           raw.Payload,
 		EarliestAllowedTs: raw.EarliestAllowedTs,
 		BlockedBy:         raw.BlockedBy,
+
+		MaintenanceWindowOverride: raw.MaintenanceWindowOverride,
 	}
 	for _, taskRunRaw := range raw.TaskRunRawList {
 		task.TaskRunList = append(task.TaskRunList, taskRunRaw.toTaskRun())
@@ -470,7 +474,7 @@ func (*Store) createTaskImpl(ctx context.Context, tx *Tx, creates ...*api.TaskCr
 	if _, err := query.WriteString(strings.Join(queryValues, ",")); err != nil {
 		return nil, err
 	}
-	if _, err := query.WriteString(` RETURNING id, creator_id, created_ts, updater_id, updated_ts, pipeline_id, stage_id, instance_id, database_id, name, status, type, payload, earliest_allowed_ts`); err != nil {
+	if _, err := query.WriteString(` RETURNING id, creator_id, created_ts, updater_id, updated_ts, pipeline_id, stage_id, instance_id, database_id, name, status, type, payload, earliest_allowed_ts, maintenance_window_override`); err != nil {
 		return nil, err
 	}
 
@@ -498,6 +502,7 @@ func (*Store) createTaskImpl(ctx context.Context, tx *Tx, creates ...*api.TaskCr
 			&taskRaw.Type,
 			&taskRaw.Payload,
 			&taskRaw.EarliestAllowedTs,
+			&taskRaw.MaintenanceWindowOverride,
 		); err != nil {
 			return nil, FormatError(err)
 		}
@@ -563,7 +568,8 @@ func (s *Store) findTaskImpl(ctx context.Context, tx *Tx, find *api.TaskFind) ([
 			status,
 			type,
 			payload,
-			earliest_allowed_ts
+			earliest_allowed_ts,
+			maintenance_window_override
 		FROM task
 		WHERE `+strings.Join(where, " AND ")+` ORDER BY id ASC`,
 		args...,
@@ -592,6 +598,7 @@ func (s *Store) findTaskImpl(ctx context.Context, tx *Tx, find *api.TaskFind) ([
 			&taskRaw.Type,
 			&taskRaw.Payload,
 			&taskRaw.EarliestAllowedTs,
+			&taskRaw.MaintenanceWindowOverride,
 		); err != nil {
 			return nil, FormatError(err)
 		}
@@ -640,6 +647,9 @@ func (*Store) patchTaskImpl(ctx context.Context, tx *Tx, patch *api.TaskPatch) (
 	if v := patch.EarliestAllowedTs; v != nil {
 		set, args = append(set, fmt.Sprintf("earliest_allowed_ts = $%d", len(args)+1)), append(args, *v)
 	}
+	if v := patch.MaintenanceWindowOverride; v != nil {
+		set, args = append(set, fmt.Sprintf("maintenance_window_override = $%d", len(args)+1)), append(args, *v)
+	}
 	args = append(args, patch.ID)
 
 	var taskRaw taskRaw
@@ -648,7 +658,7 @@ func (*Store) patchTaskImpl(ctx context.Context, tx *Tx, patch *api.TaskPatch) (
 		UPDATE task
 		SET `+strings.Join(set, ", ")+`
 		WHERE id = $%d
-		RETURNING id, creator_id, created_ts, updater_id, updated_ts, pipeline_id, stage_id, instance_id, database_id, name, status, type, payload, earliest_allowed_ts
+		RETURNING id, creator_id, created_ts, updater_id, updated_ts, pipeline_id, stage_id, instance_id, database_id, name, status, type, payload, earliest_allowed_ts, maintenance_window_override
 	`, len(args)),
 		args...,
 	).Scan(
@@ -666,6 +676,7 @@ func (*Store) patchTaskImpl(ctx context.Context, tx *Tx, patch *api.TaskPatch) (
 		&taskRaw.Type,
 		&taskRaw.Payload,
 		&taskRaw.EarliestAllowedTs,
+		&taskRaw.MaintenanceWindowOverride,
 	); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, &common.Error{Code: common.NotFound, Err: errors.Errorf("task not found with ID %d", patch.ID)}
@@ -733,6 +744,8 @@ func (s *Store) patchTaskStatusImpl(ctx context.Context, tx *Tx, patch *api.Task
 			case api.TaskFailed:
 				taskRunStatusPatch.Status = api.TaskRunFailed
 			case api.TaskPending:
+				// The task goes back to pending when the failed run is retried automatically.
+				taskRunStatusPatch.Status = api.TaskRunFailed
 			case api.TaskPendingApproval:
 			case api.TaskCanceled:
 				taskRunStatusPatch.Status = api.TaskRunCanceled
@@ -747,6 +760,11 @@ func (s *Store) patchTaskStatusImpl(ctx context.Context, tx *Tx, patch *api.Task
 	// Build UPDATE clause.
 	set, args := []string{"updater_id = $1"}, []interface{}{patch.UpdaterID}
 	set, args = append(set, "status = $2"), append(args, patch.Status)
+	if patch.Status == api.TaskPendingApproval {
+		// The override of the maintenance windows only applies to a single attempt, so the retried task has to be
+		// overridden again.
+		set = append(set, "maintenance_window_override = FALSE")
+	}
 	var ids []string
 	for _, id := range patch.IDList {
 		ids = append(ids, strconv.Itoa(id))
@@ -757,7 +775,7 @@ func (s *Store) patchTaskStatusImpl(ctx context.Context, tx *Tx, patch *api.Task
 		UPDATE task
 		SET `+strings.Join(set, ", ")+`
 		WHERE id in (`+strings.Join(ids, ",")+`) 
-		RETURNING id, creator_id, created_ts, updater_id, updated_ts, pipeline_id, stage_id, instance_id, database_id, name, status, type, payload, earliest_allowed_ts
+		RETURNING id, creator_id, created_ts, updater_id, updated_ts, pipeline_id, stage_id, instance_id, database_id, name, status, type, payload, earliest_allowed_ts, maintenance_window_override
 	`,
 		args...,
 	)
@@ -784,6 +802,7 @@ func (s *Store) patchTaskStatusImpl(ctx context.Context, tx *Tx, patch *api.Task
 			&taskRaw.Type,
 			&taskRaw.Payload,
 			&taskRaw.EarliestAllowedTs,
+			&taskRaw.MaintenanceWindowOverride,
 		); err != nil {
 			return nil, FormatError(err)
 		}
@@ -27,13 +27,16 @@ type taskRunRaw struct {
 	TaskID int
 
 	// Domain specific fields
-	Name    string
-	Status  api.TaskRunStatus
-	Type    api.TaskType
-	Code    common.Code
-	Comment string
-	Result  string
-	Payload string
+	Name      string
+	Status    api.TaskRunStatus
+	Type      api.TaskType
+	Code      common.Code
+	Comment   string
+	Result    string
+	Payload   string
+	StartedTs int64
+	Progress  string
+	Queue     string
 }
 
 // toTaskRun creates an instance of TaskRun based on the taskRunRaw.
@@ -52,13 +55,16 @@ func (raw *taskRunRaw) toTaskRun() *api.TaskRun {
 		TaskID: raw.TaskID,
 
 		// Domain specific fields
-		Name:    raw.Name,
-		Status:  raw.Status,
-		Type:    raw.Type,
-		Code:    raw.Code,
-		Comment: raw.Comment,
-		Result:  raw.Result,
-		Payload: raw.Payload,
+		Name:      raw.Name,
+		Status:    raw.Status,
+		Type:      raw.Type,
+		Code:      raw.Code,
+		Comment:   raw.Comment,
+		Result:    raw.Result,
+		Payload:   raw.Payload,
+		StartedTs: raw.StartedTs,
+		Progress:  raw.Progress,
+		Queue:     raw.Queue,
 	}
 }
 
@@ -78,7 +84,7 @@ func (*Store) createTaskRunImpl(ctx context.Context, tx *Tx, create *api.TaskRun
 			payload
 		)
 		VALUES ($1, $2, $3, $4, 'RUNNING', $5, $6)
-		RETURNING id, creator_id, created_ts, updater_id, updated_ts, task_id, name, status, type, code, comment, result, payload
+		RETURNING id, creator_id, created_ts, updater_id, updated_ts, task_id, name, status, type, code, comment, result, payload, started_ts, progress, queue
 	`
 	var taskRunRaw taskRunRaw
 	if err := tx.QueryRowContext(ctx, query,
@@ -102,6 +108,9 @@ func (*Store) createTaskRunImpl(ctx context.Context, tx *Tx, create *api.TaskRun
 		&taskRunRaw.Comment,
 		&taskRunRaw.Result,
 		&taskRunRaw.Payload,
+		&taskRunRaw.StartedTs,
+		&taskRunRaw.Progress,
+		&taskRunRaw.Queue,
 	); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, common.FormatDBErrorEmptyRowWithQuery(query)
@@ -159,7 +168,7 @@ func (*Store) patchTaskRunStatusImpl(ctx context.Context, tx *Tx, patch *api.Tas
 		UPDATE task_run
 		SET `+strings.Join(set, ", ")+`
 		WHERE `+strings.Join(where, " AND ")+`
-		RETURNING id, creator_id, created_ts, updater_id, updated_ts, task_id, name, status, type, code, comment, result, payload
+		RETURNING id, creator_id, created_ts, updater_id, updated_ts, task_id, name, status, type, code, comment, result, payload, started_ts, progress, queue
 	`,
 		args...,
 	).Scan(
@@ -176,6 +185,9 @@ func (*Store) patchTaskRunStatusImpl(ctx context.Context, tx *Tx, patch *api.Tas
 		&taskRunRaw.Comment,
 		&taskRunRaw.Result,
 		&taskRunRaw.Payload,
+		&taskRunRaw.StartedTs,
+		&taskRunRaw.Progress,
+		&taskRunRaw.Queue,
 	); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, &common.Error{Code: common.NotFound, Err: errors.Errorf("project ID not found: %d", patch.ID)}
@@ -185,6 +197,85 @@ func (*Store) patchTaskRunStatusImpl(ctx context.Context, tx *Tx, patch *api.Tas
 	return &taskRunRaw, nil
 }
 
+// PatchTaskRunProgress updates the progress of the running task run of the task.
+func (s *Store) PatchTaskRunProgress(ctx context.Context, taskID int, progress string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return FormatError(err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE task_run
+		SET progress = $1
+		WHERE task_id = $2 AND status = $3
+	`,
+		progress,
+		taskID,
+		api.TaskRunRunning,
+	); err != nil {
+		return FormatError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return FormatError(err)
+	}
+	return nil
+}
+
+// PatchTaskRunQueue updates the queue status of the running task run of the task.
+func (s *Store) PatchTaskRunQueue(ctx context.Context, taskID int, queue string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return FormatError(err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE task_run
+		SET queue = $1
+		WHERE task_id = $2 AND status = $3
+	`,
+		queue,
+		taskID,
+		api.TaskRunRunning,
+	); err != nil {
+		return FormatError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return FormatError(err)
+	}
+	return nil
+}
+
+// MarkTaskRunStarted records the start time of the running task run of the task when the task executor starts to run it,
+// and clears its queue status.
+// The start time of a task run is recorded once, so that it's kept when the task executor reruns after transient errors.
+func (s *Store) MarkTaskRunStarted(ctx context.Context, taskID int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return FormatError(err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE task_run
+		SET started_ts = CASE WHEN started_ts = 0 THEN extract(epoch from now()) ELSE started_ts END, queue = '{}'
+		WHERE task_id = $1 AND status = $2
+	`,
+		taskID,
+		api.TaskRunRunning,
+	); err != nil {
+		return FormatError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return FormatError(err)
+	}
+	return nil
+}
+
 func (*Store) findTaskRunImpl(ctx context.Context, tx *Tx, find *api.TaskRunFind) ([]*taskRunRaw, error) {
 	// Build WHERE clause.
 	where, args := []string{"1 = 1"}, []interface{}{}
@@ -218,7 +309,10 @@ func (*Store) findTaskRunImpl(ctx context.Context, tx *Tx, find *api.TaskRunFind
 			code,
 			comment,
 			result,
-			payload
+			payload,
+			started_ts,
+			progress,
+			queue
 		FROM task_run
 		WHERE `+strings.Join(where, " AND "),
 		args...,
@@ -246,6 +340,9 @@ func (*Store) findTaskRunImpl(ctx context.Context, tx *Tx, find *api.TaskRunFind
 			&taskRunRaw.Comment,
 			&taskRunRaw.Result,
 			&taskRunRaw.Payload,
+			&taskRunRaw.StartedTs,
+			&taskRunRaw.Progress,
+			&taskRunRaw.Queue,
 		); err != nil {
 			return nil, FormatError(err)
 		}
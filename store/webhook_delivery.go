@@ -0,0 +1,283 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/common"
+)
+
+// webhookDeliveryRetention is the retention of the webhook deliveries in seconds. The payload is kept
+// for troubleshooting and replay, and the VCS providers stop redelivering long before that.
+const webhookDeliveryRetention = 30 * 24 * 60 * 60
+
+const webhookDeliveryColumns = `
+	id,
+	created_ts,
+	updated_ts,
+	webhook_endpoint_id,
+	vcs_type,
+	delivery_id,
+	event,
+	headers,
+	payload,
+	verification,
+	status,
+	response_code,
+	result,
+	duplicate_count,
+	replay_count
+`
+
+// CreateWebhookDelivery creates an inbound VCS webhook delivery in the PROCESSING status.
+// It returns the Conflict error if the delivery ID already exists for the webhook endpoint.
+func (s *Store) CreateWebhookDelivery(ctx context.Context, create *api.WebhookDeliveryCreate) (*api.WebhookDelivery, error) {
+	if create.Headers == "" {
+		create.Headers = "{}"
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO webhook_delivery (
+			webhook_endpoint_id,
+			vcs_type,
+			delivery_id,
+			event,
+			headers,
+			payload,
+			status
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + webhookDeliveryColumns
+	delivery, err := scanWebhookDelivery(tx.QueryRowContext(ctx, query,
+		create.WebhookEndpointID,
+		create.VCSType,
+		create.DeliveryID,
+		create.Event,
+		create.Headers,
+		create.Payload,
+		api.WebhookDeliveryProcessing,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, common.FormatDBErrorEmptyRowWithQuery(query)
+		}
+		return nil, FormatError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return delivery, nil
+}
+
+// GetWebhookDelivery gets a webhook delivery.
+func (s *Store) GetWebhookDelivery(ctx context.Context, find *api.WebhookDeliveryFind) (*api.WebhookDelivery, error) {
+	list, err := s.FindWebhookDelivery(ctx, find)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, nil
+	} else if len(list) > 1 {
+		return nil, &common.Error{Code: common.Conflict, Err: errors.Errorf("found %d webhook deliveries with filter %+v, expect 1", len(list), find)}
+	}
+	return list[0], nil
+}
+
+// FindWebhookDelivery finds a list of webhook deliveries ordered by the creation time in descending order.
+func (s *Store) FindWebhookDelivery(ctx context.Context, find *api.WebhookDeliveryFind) ([]*api.WebhookDelivery, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	where, args := []string{"1 = 1"}, []interface{}{}
+	if v := find.ID; v != nil {
+		where, args = append(where, fmt.Sprintf("id = $%d", len(args)+1)), append(args, *v)
+	}
+	if v := find.WebhookEndpointID; v != nil {
+		where, args = append(where, fmt.Sprintf("webhook_endpoint_id = $%d", len(args)+1)), append(args, *v)
+	}
+	if v := find.DeliveryID; v != nil {
+		where, args = append(where, fmt.Sprintf("delivery_id = $%d", len(args)+1)), append(args, *v)
+	}
+
+	query := `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_delivery
+		WHERE ` + strings.Join(where, " AND ") + `
+		ORDER BY created_ts DESC, id DESC`
+	if v := find.Limit; v != nil {
+		query += fmt.Sprintf(" LIMIT %d", *v)
+	}
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	// Iterate over result set and deserialize rows into deliveryList.
+	var deliveryList []*api.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, FormatError(err)
+		}
+		deliveryList = append(deliveryList, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return deliveryList, nil
+}
+
+// PatchWebhookDelivery patches a webhook delivery.
+func (s *Store) PatchWebhookDelivery(ctx context.Context, patch *api.WebhookDeliveryPatch) (*api.WebhookDelivery, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	// Build UPDATE clause.
+	set, args := []string{"updated_ts = extract(epoch from now())"}, []interface{}{}
+	if v := patch.Verification; v != nil {
+		set, args = append(set, fmt.Sprintf("verification = $%d", len(args)+1)), append(args, *v)
+	}
+	if v := patch.Status; v != nil {
+		set, args = append(set, fmt.Sprintf("status = $%d", len(args)+1)), append(args, *v)
+	}
+	if v := patch.ResponseCode; v != nil {
+		set, args = append(set, fmt.Sprintf("response_code = $%d", len(args)+1)), append(args, *v)
+	}
+	if v := patch.Result; v != nil {
+		set, args = append(set, fmt.Sprintf("result = $%d", len(args)+1)), append(args, *v)
+	}
+	if patch.IncreaseDuplicateCount {
+		set = append(set, "duplicate_count = duplicate_count + 1")
+	}
+	if patch.IncreaseReplayCount {
+		set = append(set, "replay_count = replay_count + 1")
+	}
+	args = append(args, patch.ID)
+
+	query := `
+		UPDATE webhook_delivery
+		SET ` + strings.Join(set, ", ") + `
+		WHERE id = ` + fmt.Sprintf("$%d", len(args)) + `
+		RETURNING ` + webhookDeliveryColumns
+	delivery, err := scanWebhookDelivery(tx.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &common.Error{Code: common.NotFound, Err: errors.Errorf("webhook delivery ID not found: %d", patch.ID)}
+		}
+		return nil, FormatError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return delivery, nil
+}
+
+// RetryWebhookDelivery moves the webhook delivery back to the PROCESSING status and counts the
+// duplicate, if the delivery failed, or it has been in the PROCESSING status without any update
+// since staleTs. The check and the update are atomic, so only one of the concurrent redeliveries
+// takes the delivery over. It returns nil if the delivery is not retryable.
+func (s *Store) RetryWebhookDelivery(ctx context.Context, id int, staleTs int64) (*api.WebhookDelivery, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE webhook_delivery
+		SET updated_ts = extract(epoch from now()), status = $1, duplicate_count = duplicate_count + 1
+		WHERE id = $2 AND (status = $3 OR (status = $4 AND updated_ts < $5))
+		RETURNING ` + webhookDeliveryColumns
+	delivery, err := scanWebhookDelivery(tx.QueryRowContext(ctx, query,
+		api.WebhookDeliveryProcessing,
+		id,
+		api.WebhookDeliveryFailed,
+		api.WebhookDeliveryProcessing,
+		staleTs,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, FormatError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return delivery, nil
+}
+
+// DeleteExpiredWebhookDelivery deletes the webhook deliveries created beyond the retention.
+func (s *Store) DeleteExpiredWebhookDelivery(ctx context.Context, now int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return FormatError(err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM webhook_delivery
+		WHERE created_ts < $1`,
+		now-webhookDeliveryRetention,
+	); err != nil {
+		return FormatError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return FormatError(err)
+	}
+
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanWebhookDelivery(row rowScanner) (*api.WebhookDelivery, error) {
+	var delivery api.WebhookDelivery
+	if err := row.Scan(
+		&delivery.ID,
+		&delivery.CreatedTs,
+		&delivery.UpdatedTs,
+		&delivery.WebhookEndpointID,
+		&delivery.VCSType,
+		&delivery.DeliveryID,
+		&delivery.Event,
+		&delivery.Headers,
+		&delivery.Payload,
+		&delivery.Verification,
+		&delivery.Status,
+		&delivery.ResponseCode,
+		&delivery.Result,
+		&delivery.DuplicateCount,
+		&delivery.ReplayCount,
+	); err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
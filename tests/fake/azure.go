@@ -0,0 +1,514 @@
+package fake
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/pkg/errors"
+
+	"github.com/bytebase/bytebase/plugin/vcs"
+	"github.com/bytebase/bytebase/plugin/vcs/azure"
+)
+
+// AzureDevOps is a fake implementation of Azure DevOps VCS provider.
+type AzureDevOps struct {
+	port int
+	echo *echo.Echo
+
+	client *http.Client
+
+	nextWebhookID int
+	webhooks      map[string]*azure.WebhookCreateOrUpdate
+	repositories  map[string]*azureRepositoryData
+}
+
+type azureRepositoryData struct {
+	// id is the GUID of the repository.
+	id string
+	// projectID is the GUID of the project of the repository.
+	projectID string
+	// files is a map that the full file path is the key and the file content is the
+	// value.
+	files map[string]string
+	// pendingChanges is the list of file changes since the last push.
+	pendingChanges []azure.Change
+	// changes is the map for the changed files of a commit.
+	// the map key is the commit ID.
+	changes map[string][]azure.Change
+	// refs is the map for repository refs.
+	// the map key is the ref name, like "refs/heads/main".
+	refs map[string]string
+	// pullRequests is the map for repository pull request.
+	// the map key is the pull request id.
+	pullRequests map[int][]azure.Change
+}
+
+// NewAzureDevOps creates a new fake implementation of Azure DevOps VCS provider.
+func NewAzureDevOps(port int) VCSProvider {
+	e := echo.New()
+	e.Use(middleware.Logger())
+	e.Use(middleware.Recover())
+
+	ad := &AzureDevOps{
+		port:          port,
+		echo:          e,
+		client:        &http.Client{},
+		nextWebhookID: 20221024,
+		webhooks:      make(map[string]*azure.WebhookCreateOrUpdate),
+		repositories:  make(map[string]*azureRepositoryData),
+	}
+
+	e.POST("/_apis/hooks/subscriptions", ad.createSubscription)
+	g := e.Group("/:project/_apis/git/repositories/:repo")
+	g.GET("", ad.getRepository)
+	g.GET("/commits/:commitID", ad.getRepositoryCommit)
+	g.GET("/items", ad.listRepositoryItems)
+	g.POST("/pushes", ad.createRepositoryPush)
+	g.GET("/refs", ad.listRepositoryRefs)
+	g.POST("/refs", ad.updateRepositoryRefs)
+	g.POST("/pullrequests", ad.createRepositoryPullRequest)
+	g.GET("/pullRequests/:prID/iterations", ad.listPullRequestIterations)
+	g.GET("/pullRequests/:prID/iterations/:iterationID/changes", ad.listPullRequestIterationChanges)
+	return ad
+}
+
+func (ad *AzureDevOps) createSubscription(c echo.Context) error {
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to read request body for creating subscription: %v", err))
+	}
+
+	var webhookCreate azure.WebhookCreateOrUpdate
+	if err = json.Unmarshal(body, &webhookCreate); err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to unmarshal request body for creating subscription: %v", err))
+	}
+
+	found := false
+	for _, r := range ad.repositories {
+		if r.id == webhookCreate.PublisherInputs.Repository && r.projectID == webhookCreate.PublisherInputs.ProjectID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return c.String(http.StatusBadRequest, fmt.Sprintf("Azure DevOps repository %q does not exist in project %q", webhookCreate.PublisherInputs.Repository, webhookCreate.PublisherInputs.ProjectID))
+	}
+
+	webhookID := fmt.Sprintf("fake-azure-subscription-%d", ad.nextWebhookID)
+	ad.webhooks[webhookID] = &webhookCreate
+	ad.nextWebhookID++
+	return ad.respond(c, http.StatusOK, azure.WebhookInfo{ID: webhookID})
+}
+
+func (ad *AzureDevOps) getRepository(c echo.Context) error {
+	r, err := ad.validRepository(c)
+	if err != nil {
+		return err
+	}
+	return ad.respond(c, http.StatusOK, ad.newRepository(c.Param("project"), c.Param("repo"), r))
+}
+
+func (ad *AzureDevOps) getRepositoryCommit(c echo.Context) error {
+	r, err := ad.validRepository(c)
+	if err != nil {
+		return err
+	}
+
+	commitID := c.Param("commitID")
+	return ad.respond(c, http.StatusOK,
+		azure.Commit{
+			CommitID: commitID,
+			Author: azure.CommitUser{
+				Name:  "fake_azure_author",
+				Email: "fake_azure_author@localhost",
+				Date:  time.Now(),
+			},
+			Comment: "Fake Azure DevOps commit message",
+			Changes: r.changes[commitID],
+		},
+	)
+}
+
+func (ad *AzureDevOps) listRepositoryItems(c echo.Context) error {
+	r, err := ad.validRepository(c)
+	if err != nil {
+		return err
+	}
+
+	if filePath := c.QueryParam("path"); filePath != "" {
+		filePath = strings.TrimPrefix(filePath, "/")
+		content, ok := r.files[filePath]
+		if !ok {
+			return c.String(http.StatusNotFound, fmt.Sprintf("file %q not found", filePath))
+		}
+		return ad.respond(c, http.StatusOK,
+			azure.Item{
+				GitObjectType: "blob",
+				CommitID:      "fake_azure_commit_id",
+				Path:          "/" + filePath,
+				Content:       content,
+			},
+		)
+	}
+
+	scopePath := strings.Trim(c.QueryParam("scopePath"), "/")
+	var items []azure.Item
+	for path := range r.files {
+		if scopePath == "" || strings.HasPrefix(path, scopePath+"/") {
+			items = append(items, azure.Item{
+				GitObjectType: "blob",
+				Path:          "/" + path,
+			})
+		}
+	}
+	if len(items) == 0 {
+		return c.String(http.StatusNotFound, fmt.Sprintf("path %q not found", scopePath))
+	}
+	return ad.respond(c, http.StatusOK, azure.ItemList{Count: len(items), Value: items})
+}
+
+func (ad *AzureDevOps) createRepositoryPush(c echo.Context) error {
+	r, err := ad.validRepository(c)
+	if err != nil {
+		return err
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to read request body for creating repository push: %v", err))
+	}
+
+	var pushCreate azure.PushCreate
+	if err = json.Unmarshal(body, &pushCreate); err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to unmarshal request body for creating repository push: %v", err))
+	}
+	for _, refUpdate := range pushCreate.RefUpdates {
+		if r.refs[refUpdate.Name] != refUpdate.OldObjectID {
+			return c.String(http.StatusConflict, fmt.Sprintf("the ref %q has been updated", refUpdate.Name))
+		}
+	}
+	for _, commit := range pushCreate.Commits {
+		for _, change := range commit.Changes {
+			r.files[strings.TrimPrefix(change.Item.Path, "/")] = change.NewContent.Content
+		}
+	}
+	return c.String(http.StatusCreated, "{}")
+}
+
+func (ad *AzureDevOps) listRepositoryRefs(c echo.Context) error {
+	r, err := ad.validRepository(c)
+	if err != nil {
+		return err
+	}
+
+	filter := "refs/" + c.QueryParam("filter")
+	if _, ok := r.refs[filter]; !ok && strings.HasPrefix(filter, "refs/heads/") {
+		// Treat any branch as existing, like the other fake VCS providers.
+		r.refs[filter] = "fake_azure_commit_id"
+	}
+	var refs []azure.Ref
+	for name, objectID := range r.refs {
+		if strings.HasPrefix(name, filter) {
+			refs = append(refs, azure.Ref{Name: name, ObjectID: objectID})
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].Name < refs[j].Name })
+	return ad.respond(c, http.StatusOK, azure.RefList{Count: len(refs), Value: refs})
+}
+
+func (ad *AzureDevOps) updateRepositoryRefs(c echo.Context) error {
+	r, err := ad.validRepository(c)
+	if err != nil {
+		return err
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to read request body for updating repository refs: %v", err))
+	}
+
+	var refUpdates []azure.RefUpdate
+	if err = json.Unmarshal(body, &refUpdates); err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to unmarshal request body for updating repository refs: %v", err))
+	}
+
+	var results []azure.RefUpdateResult
+	for _, refUpdate := range refUpdates {
+		if _, ok := r.refs[refUpdate.Name]; ok {
+			results = append(results, azure.RefUpdateResult{Name: refUpdate.Name, Success: false, UpdateStatus: "staleOldObjectId"})
+			continue
+		}
+		r.refs[refUpdate.Name] = refUpdate.NewObjectID
+		results = append(results, azure.RefUpdateResult{Name: refUpdate.Name, Success: true, UpdateStatus: "succeeded"})
+	}
+	return ad.respond(c, http.StatusOK, azure.RefUpdateResultList{Count: len(results), Value: results})
+}
+
+func (ad *AzureDevOps) createRepositoryPullRequest(c echo.Context) error {
+	r, err := ad.validRepository(c)
+	if err != nil {
+		return err
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to read request body for creating repository pull request: %v", err))
+	}
+
+	var pullRequestCreate azure.PullRequestCreate
+	if err = json.Unmarshal(body, &pullRequestCreate); err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to unmarshal request body for creating repository pull request: %v", err))
+	}
+	if _, ok := r.refs[pullRequestCreate.SourceRefName]; !ok {
+		return c.String(http.StatusBadRequest, fmt.Sprintf("the source branch not exists: %v", pullRequestCreate.SourceRefName))
+	}
+
+	prID := len(r.pullRequests) + 1
+	r.pullRequests[prID] = nil
+	return ad.respond(c, http.StatusCreated,
+		azure.PullRequest{
+			PullRequestID: prID,
+			Repository:    ad.newRepository(c.Param("project"), c.Param("repo"), r),
+		},
+	)
+}
+
+func (ad *AzureDevOps) listPullRequestIterations(c echo.Context) error {
+	r, err := ad.validRepository(c)
+	if err != nil {
+		return err
+	}
+	if _, err := ad.validPullRequest(c, r); err != nil {
+		return err
+	}
+
+	iteration := azure.PullRequestIteration{ID: 1}
+	iteration.SourceRefCommit.CommitID = "fake_azure_commit_id"
+	return ad.respond(c, http.StatusOK, azure.PullRequestIterationList{Count: 1, Value: []azure.PullRequestIteration{iteration}})
+}
+
+func (ad *AzureDevOps) listPullRequestIterationChanges(c echo.Context) error {
+	r, err := ad.validRepository(c)
+	if err != nil {
+		return err
+	}
+	changes, err := ad.validPullRequest(c, r)
+	if err != nil {
+		return err
+	}
+	return ad.respond(c, http.StatusOK, azure.PullRequestIterationChanges{ChangeEntries: changes})
+}
+
+// respond responds the value in JSON with given status code.
+func (*AzureDevOps) respond(c echo.Context, code int, value interface{}) error {
+	buf, err := json.Marshal(value)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to marshal response body: %v", err))
+	}
+	return c.String(code, string(buf))
+}
+
+func (ad *AzureDevOps) validRepository(c echo.Context) (*azureRepositoryData, error) {
+	repositoryID := fmt.Sprintf("%s/%s", c.Param("project"), c.Param("repo"))
+	r, ok := ad.repositories[repositoryID]
+	if !ok {
+		return nil, c.String(http.StatusNotFound, fmt.Sprintf("Azure DevOps repository %q does not exist", repositoryID))
+	}
+
+	return r, nil
+}
+
+func (*AzureDevOps) validPullRequest(c echo.Context, r *azureRepositoryData) ([]azure.Change, error) {
+	prID, err := strconv.Atoi(c.Param("prID"))
+	if err != nil {
+		return nil, c.String(http.StatusBadRequest, fmt.Sprintf("The pull request id is invalid: %v", c.Param("prID")))
+	}
+	changes, ok := r.pullRequests[prID]
+	if !ok {
+		return nil, c.String(http.StatusNotFound, fmt.Sprintf("Cannot found the pull request: %v", c.Param("prID")))
+	}
+	return changes, nil
+}
+
+func (ad *AzureDevOps) newRepository(project, repo string, r *azureRepositoryData) azure.Repository {
+	webURL := fmt.Sprintf("http://localhost:%d/%s/_git/%s", ad.port, project, repo)
+	return azure.Repository{
+		ID:        r.id,
+		Name:      repo,
+		RemoteURL: webURL,
+		WebURL:    webURL,
+		Project: azure.Project{
+			ID:   r.projectID,
+			Name: project,
+		},
+	}
+}
+
+// Run starts the Azure DevOps VCS provider server.
+func (ad *AzureDevOps) Run() error {
+	return ad.echo.Start(fmt.Sprintf(":%d", ad.port))
+}
+
+// Close shuts down the Azure DevOps VCS provider server.
+func (ad *AzureDevOps) Close() error {
+	return ad.echo.Close()
+}
+
+// ListenerAddr returns the Azure DevOps VCS provider server listener address.
+func (ad *AzureDevOps) ListenerAddr() net.Addr {
+	return ad.echo.ListenerAddr()
+}
+
+// APIURL returns the Azure DevOps VCS provider API URL.
+func (*AzureDevOps) APIURL(instanceURL string) string {
+	return instanceURL
+}
+
+// CreateRepository creates an Azure DevOps repository with given ID in the
+// form of "{project}/{repository}".
+func (ad *AzureDevOps) CreateRepository(id string) {
+	n := len(ad.repositories) + 1
+	ad.repositories[id] = &azureRepositoryData{
+		id:        fmt.Sprintf("fake-azure-repository-%d", n),
+		projectID: fmt.Sprintf("fake-azure-project-%d", n),
+		files:     make(map[string]string),
+		changes:   make(map[string][]azure.Change),
+		refs: map[string]string{
+			"refs/heads/main": "fake_azure_commit_id",
+		},
+		pullRequests: make(map[int][]azure.Change),
+	}
+}
+
+// SendWebhookPush sends out a webhook for a push event for the Azure DevOps
+// repository using given payload.
+//
+// Azure DevOps does not include the changed files in the payload, so the files
+// changed by AddFiles since the last push are attributed to the latest commit
+// in the payload.
+func (ad *AzureDevOps) SendWebhookPush(repositoryID string, payload []byte) error {
+	r, ok := ad.repositories[repositoryID]
+	if !ok {
+		return errors.Errorf("Azure DevOps repository %q does not exist", repositoryID)
+	}
+
+	var pushEvent azure.WebhookPushEvent
+	if err := json.Unmarshal(payload, &pushEvent); err != nil {
+		return errors.Wrap(err, "failed to unmarshal the webhook push event")
+	}
+	for i, commit := range pushEvent.Resource.Commits {
+		// The commits are in reverse chronological order.
+		if i == 0 {
+			r.changes[commit.CommitID] = r.pendingChanges
+		} else {
+			r.changes[commit.CommitID] = nil
+		}
+	}
+	r.pendingChanges = nil
+
+	// Trigger all webhooks of the repository
+	for _, webhook := range ad.webhooks {
+		if webhook.PublisherInputs.Repository != r.id {
+			continue
+		}
+
+		req, err := http.NewRequest("POST", webhook.ConsumerInputs.URL, bytes.NewReader(payload))
+		if err != nil {
+			return errors.Wrapf(err, "failed to create a new POST request to %q", webhook.ConsumerInputs.URL)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.SetBasicAuth(webhook.ConsumerInputs.BasicAuthUsername, webhook.ConsumerInputs.BasicAuthPassword)
+
+		resp, err := ad.client.Do(req)
+		if err != nil {
+			return errors.Wrapf(err, "failed to send POST request to %q", webhook.ConsumerInputs.URL)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return errors.Wrap(err, "failed to read response body")
+		}
+		if resp.StatusCode != http.StatusOK {
+			return errors.Errorf("unexpected response status code %d, body: %s", resp.StatusCode, body)
+		}
+		ad.echo.Logger.Infof("SendWebhookPush response body %s\n", body)
+	}
+	return nil
+}
+
+// AddFiles adds given files to the Azure DevOps repository.
+func (ad *AzureDevOps) AddFiles(repositoryID string, files map[string]string) error {
+	r, ok := ad.repositories[repositoryID]
+	if !ok {
+		return errors.Errorf("Azure DevOps repository %q does not exist", repositoryID)
+	}
+
+	// Save or overwrite files
+	for path, content := range files {
+		changeType := "add"
+		if _, ok := r.files[path]; ok {
+			changeType = "edit"
+		}
+		r.pendingChanges = append(r.pendingChanges, azure.Change{
+			Item: azure.Item{
+				GitObjectType: "blob",
+				Path:          "/" + path,
+			},
+			ChangeType: changeType,
+		})
+		r.files[path] = content
+	}
+	return nil
+}
+
+// GetFiles returns files with given paths from the Azure DevOps repository.
+func (ad *AzureDevOps) GetFiles(repositoryID string, filePaths ...string) (map[string]string, error) {
+	r, ok := ad.repositories[repositoryID]
+	if !ok {
+		return nil, errors.Errorf("Azure DevOps repository %q does not exist", repositoryID)
+	}
+
+	// Get files
+	files := make(map[string]string)
+	for _, path := range filePaths {
+		if content, ok := r.files[path]; ok {
+			files[path] = content
+		}
+	}
+	return files, nil
+}
+
+// AddPullRequest creates a new pull request and add changed files to it.
+func (ad *AzureDevOps) AddPullRequest(repositoryID string, prID int, files []*vcs.PullRequestFile) error {
+	r, ok := ad.repositories[repositoryID]
+	if !ok {
+		return errors.Errorf("Azure DevOps repository %q does not exist", repositoryID)
+	}
+
+	var changes []azure.Change
+	for _, file := range files {
+		changeType := "edit"
+		if file.IsDeleted {
+			changeType = "delete"
+		}
+		changes = append(changes, azure.Change{
+			Item: azure.Item{
+				GitObjectType: "blob",
+				Path:          "/" + file.Path,
+			},
+			ChangeType: changeType,
+		})
+	}
+	r.pullRequests[prID] = changes
+	return nil
+}
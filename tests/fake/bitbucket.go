@@ -0,0 +1,526 @@
+package fake
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/pkg/errors"
+
+	"github.com/bytebase/bytebase/plugin/vcs"
+	"github.com/bytebase/bytebase/plugin/vcs/bitbucket"
+)
+
+// Bitbucket is a fake implementation of Bitbucket Cloud VCS provider.
+type Bitbucket struct {
+	port int
+	echo *echo.Echo
+
+	client *http.Client
+
+	nextWebhookID int
+	repositories  map[string]*bitbucketRepositoryData
+}
+
+type bitbucketRepositoryData struct {
+	webhooks []*bitbucket.WebhookCreateOrUpdate
+	// files is a map that the full file path is the key and the file content is the
+	// value.
+	files map[string]string
+	// pendingDiffStats is the list of file changes since the last push.
+	pendingDiffStats []bitbucket.DiffStat
+	// diffStats is the map for the changed files of a commit.
+	// the map key is the commit hash.
+	diffStats map[string][]bitbucket.DiffStat
+	// branches is the map for repository branch.
+	// the map key is the branch name, like "main".
+	branches map[string]*bitbucket.Branch
+	// pullRequests is the map for repository pull request.
+	// the map key is the pull request id.
+	pullRequests map[int]struct {
+		DiffStats []bitbucket.DiffStat
+		*bitbucket.PullRequest
+	}
+}
+
+// NewBitbucket creates a new fake implementation of Bitbucket Cloud VCS provider.
+func NewBitbucket(port int) VCSProvider {
+	e := echo.New()
+	e.Use(middleware.Logger())
+	e.Use(middleware.Recover())
+
+	bb := &Bitbucket{
+		port:          port,
+		echo:          e,
+		client:        &http.Client{},
+		nextWebhookID: 20221022,
+		repositories:  make(map[string]*bitbucketRepositoryData),
+	}
+
+	g := e.Group("/2.0")
+	g.POST("/repositories/:workspace/:repo/hooks", bb.createRepositoryWebhook)
+	g.GET("/repositories/:workspace/:repo/commit/:hash", bb.getRepositoryCommit)
+	g.GET("/repositories/:workspace/:repo/diffstat/:spec", bb.getRepositoryDiffStat)
+	g.GET("/repositories/:workspace/:repo/src/:ref/*", bb.readRepositorySource)
+	g.POST("/repositories/:workspace/:repo/src", bb.createRepositoryFile)
+	g.GET("/repositories/:workspace/:repo/refs/branches/:branchName", bb.getRepositoryBranch)
+	g.POST("/repositories/:workspace/:repo/refs/branches", bb.createRepositoryBranch)
+	g.POST("/repositories/:workspace/:repo/pullrequests", bb.createRepositoryPullRequest)
+	g.GET("/repositories/:workspace/:repo/pullrequests/:prID", bb.getRepositoryPullRequest)
+	g.GET("/repositories/:workspace/:repo/pullrequests/:prID/diffstat", bb.listPullRequestDiffStat)
+	return bb
+}
+
+func (bb *Bitbucket) createRepositoryWebhook(c echo.Context) error {
+	r, err := bb.validRepository(c)
+	if err != nil {
+		return err
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to read request body for creating repository webhook: %v", err))
+	}
+
+	var webhookCreate bitbucket.WebhookCreateOrUpdate
+	if err = json.Unmarshal(body, &webhookCreate); err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to unmarshal request body for creating repository webhook: %v", err))
+	}
+	r.webhooks = append(r.webhooks, &webhookCreate)
+
+	buf, err := json.Marshal(bitbucket.WebhookInfo{UUID: fmt.Sprintf("{%d}", bb.nextWebhookID)})
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to marshal response body for creating repository webhook: %v", err))
+	}
+	bb.nextWebhookID++
+	return c.String(http.StatusCreated, string(buf))
+}
+
+func (bb *Bitbucket) getRepositoryCommit(c echo.Context) error {
+	if _, err := bb.validRepository(c); err != nil {
+		return err
+	}
+
+	buf, err := json.Marshal(
+		bitbucket.Commit{
+			Hash:    c.Param("hash"),
+			Date:    time.Now(),
+			Message: "Fake Bitbucket commit message",
+			Author: bitbucket.CommitAuthor{
+				Raw: "fake_bitbucket_author <fake_bitbucket_author@localhost>",
+			},
+		},
+	)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to marshal response body for getting repository commit: %v", err))
+	}
+	return c.String(http.StatusOK, string(buf))
+}
+
+func (bb *Bitbucket) getRepositoryDiffStat(c echo.Context) error {
+	r, err := bb.validRepository(c)
+	if err != nil {
+		return err
+	}
+	return bb.listValues(c, r.diffStats[c.Param("spec")])
+}
+
+func (bb *Bitbucket) readRepositorySource(c echo.Context) error {
+	r, err := bb.validRepository(c)
+	if err != nil {
+		return err
+	}
+
+	filePathEscaped := c.Param("*")
+	filePath, err := url.PathUnescape(filePathEscaped)
+	if err != nil {
+		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to unescape file path %q: %v", filePathEscaped, err))
+	}
+
+	content, ok := r.files[filePath]
+	if !ok {
+		// List the files under the directory.
+		var entries []bitbucket.TreeEntry
+		for path, content := range r.files {
+			if filePath == "" || strings.HasPrefix(path, filePath+"/") {
+				entries = append(entries, bitbucket.TreeEntry{
+					Type: "commit_file",
+					Path: path,
+					Size: int64(len(content)),
+				})
+			}
+		}
+		if len(entries) == 0 {
+			return c.String(http.StatusNotFound, fmt.Sprintf("file %q not found", filePath))
+		}
+		return bb.listValues(c, entries)
+	}
+
+	if c.QueryParam("format") != "meta" {
+		return c.String(http.StatusOK, content)
+	}
+	entry := bitbucket.TreeEntry{
+		Type: "commit_file",
+		Path: filePath,
+		Size: int64(len(content)),
+	}
+	entry.Commit.Hash = "fake_bitbucket_commit_hash"
+	buf, err := json.Marshal(entry)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to marshal response body for getting repository file: %v", err))
+	}
+	return c.String(http.StatusOK, string(buf))
+}
+
+func (bb *Bitbucket) createRepositoryFile(c echo.Context) error {
+	r, err := bb.validRepository(c)
+	if err != nil {
+		return err
+	}
+
+	form, err := c.FormParams()
+	if err != nil {
+		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to parse form for creating repository file: %v", err))
+	}
+	for key := range form {
+		switch key {
+		case "message", "branch", "parents", "author":
+			continue
+		}
+		r.files[key] = form.Get(key)
+	}
+	return c.String(http.StatusCreated, "")
+}
+
+func (bb *Bitbucket) getRepositoryBranch(c echo.Context) error {
+	r, err := bb.validRepository(c)
+	if err != nil {
+		return err
+	}
+
+	branchName := c.Param("branchName")
+	r.branches[branchName] = &bitbucket.Branch{
+		Name: branchName,
+		Target: bitbucket.BranchTarget{
+			Hash: "fake_bitbucket_commit_hash",
+		},
+	}
+
+	buf, err := json.Marshal(r.branches[branchName])
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to marshal response body for getting repository branch: %v", err))
+	}
+	return c.String(http.StatusOK, string(buf))
+}
+
+func (bb *Bitbucket) createRepositoryBranch(c echo.Context) error {
+	r, err := bb.validRepository(c)
+	if err != nil {
+		return err
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to read request body for creating repository branch: %v", err))
+	}
+
+	var branchCreate bitbucket.Branch
+	if err = json.Unmarshal(body, &branchCreate); err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to unmarshal request body for creating repository branch: %v", err))
+	}
+
+	if _, ok := r.branches[branchCreate.Name]; ok {
+		return c.String(http.StatusBadRequest, fmt.Sprintf("the branch already exists: %v", branchCreate.Name))
+	}
+	r.branches[branchCreate.Name] = &branchCreate
+
+	return c.String(http.StatusCreated, string(body))
+}
+
+func (bb *Bitbucket) createRepositoryPullRequest(c echo.Context) error {
+	r, err := bb.validRepository(c)
+	if err != nil {
+		return err
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to read request body for creating repository pull request: %v", err))
+	}
+
+	var pullRequestCreate bitbucket.PullRequestCreate
+	if err = json.Unmarshal(body, &pullRequestCreate); err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to unmarshal request body for creating repository pull request: %v", err))
+	}
+
+	if _, ok := r.branches[pullRequestCreate.Source.Branch.Name]; !ok {
+		return c.String(http.StatusBadRequest, fmt.Sprintf("the source branch not exists: %v", pullRequestCreate.Source.Branch.Name))
+	}
+
+	prID := len(r.pullRequests) + 1
+	pullRequest := newBitbucketPullRequest(fmt.Sprintf("%s/%s", c.Param("workspace"), c.Param("repo")), prID)
+	pullRequest.Source.Branch = pullRequestCreate.Source.Branch
+	r.pullRequests[prID] = struct {
+		DiffStats []bitbucket.DiffStat
+		*bitbucket.PullRequest
+	}{
+		PullRequest: pullRequest,
+	}
+
+	buf, err := json.Marshal(pullRequest)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to marshal response body for creating repository pull request: %v", err))
+	}
+	return c.String(http.StatusCreated, string(buf))
+}
+
+func (bb *Bitbucket) getRepositoryPullRequest(c echo.Context) error {
+	r, err := bb.validRepository(c)
+	if err != nil {
+		return err
+	}
+
+	prID, err := strconv.Atoi(c.Param("prID"))
+	if err != nil {
+		return c.String(http.StatusBadRequest, fmt.Sprintf("The pull request id is invalid: %v", c.Param("prID")))
+	}
+	pullRequest, ok := r.pullRequests[prID]
+	if !ok {
+		return c.String(http.StatusNotFound, fmt.Sprintf("Cannot found the pull request: %v", c.Param("prID")))
+	}
+
+	buf, err := json.Marshal(pullRequest.PullRequest)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to marshal response body for getting repository pull request: %v", err))
+	}
+	return c.String(http.StatusOK, string(buf))
+}
+
+func (bb *Bitbucket) listPullRequestDiffStat(c echo.Context) error {
+	r, err := bb.validRepository(c)
+	if err != nil {
+		return err
+	}
+
+	prID, err := strconv.Atoi(c.Param("prID"))
+	if err != nil {
+		return c.String(http.StatusBadRequest, fmt.Sprintf("The pull request id is invalid: %v", c.Param("prID")))
+	}
+	pullRequest, ok := r.pullRequests[prID]
+	if !ok {
+		return c.String(http.StatusNotFound, fmt.Sprintf("Cannot found the pull request: %v", c.Param("prID")))
+	}
+	return bb.listValues(c, pullRequest.DiffStats)
+}
+
+// listValues responds the values in a single page of the paginated list.
+func (*Bitbucket) listValues(c echo.Context, values interface{}) error {
+	buf, err := json.Marshal(
+		map[string]interface{}{
+			"values": values,
+		},
+	)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to marshal response body: %v", err))
+	}
+	return c.String(http.StatusOK, string(buf))
+}
+
+func (bb *Bitbucket) validRepository(c echo.Context) (*bitbucketRepositoryData, error) {
+	repositoryID := fmt.Sprintf("%s/%s", c.Param("workspace"), c.Param("repo"))
+	r, ok := bb.repositories[repositoryID]
+	if !ok {
+		return nil, c.String(http.StatusNotFound, fmt.Sprintf("Bitbucket repository %q does not exist", repositoryID))
+	}
+
+	return r, nil
+}
+
+func newBitbucketPullRequest(repositoryID string, prID int) *bitbucket.PullRequest {
+	return &bitbucket.PullRequest{
+		ID: prID,
+		Source: bitbucket.PullRequestEndpoint{
+			Commit: &bitbucket.BranchTarget{Hash: "fake_bitbucket_commit_hash"},
+		},
+		Links: bitbucket.Links{
+			HTML: bitbucket.Link{Href: fmt.Sprintf("https://bitbucket.org/%s/pull-requests/%d", repositoryID, prID)},
+		},
+	}
+}
+
+// Run starts the Bitbucket VCS provider server.
+func (bb *Bitbucket) Run() error {
+	return bb.echo.Start(fmt.Sprintf(":%d", bb.port))
+}
+
+// Close shuts down the Bitbucket VCS provider server.
+func (bb *Bitbucket) Close() error {
+	return bb.echo.Close()
+}
+
+// ListenerAddr returns the Bitbucket VCS provider server listener address.
+func (bb *Bitbucket) ListenerAddr() net.Addr {
+	return bb.echo.ListenerAddr()
+}
+
+// APIURL returns the Bitbucket VCS provider API URL.
+func (*Bitbucket) APIURL(instanceURL string) string {
+	return fmt.Sprintf("%s/2.0", instanceURL)
+}
+
+// CreateRepository creates a Bitbucket repository with given ID.
+func (bb *Bitbucket) CreateRepository(id string) {
+	bb.repositories[id] = &bitbucketRepositoryData{
+		files:     make(map[string]string),
+		diffStats: make(map[string][]bitbucket.DiffStat),
+		branches:  make(map[string]*bitbucket.Branch),
+		pullRequests: map[int]struct {
+			DiffStats []bitbucket.DiffStat
+			*bitbucket.PullRequest
+		}{},
+	}
+}
+
+// SendWebhookPush sends out a webhook for a push event for the Bitbucket
+// repository using given payload.
+//
+// Bitbucket does not include the changed files in the payload, so the files
+// changed by AddFiles since the last push are attributed to the latest commit
+// of each change in the payload.
+func (bb *Bitbucket) SendWebhookPush(repositoryID string, payload []byte) error {
+	r, ok := bb.repositories[repositoryID]
+	if !ok {
+		return errors.Errorf("Bitbucket repository %q does not exist", repositoryID)
+	}
+
+	var pushEvent bitbucket.WebhookPushEvent
+	if err := json.Unmarshal(payload, &pushEvent); err != nil {
+		return errors.Wrap(err, "failed to unmarshal the webhook push event")
+	}
+	for _, change := range pushEvent.Push.Changes {
+		for i, commit := range change.Commits {
+			// The commits are in reverse chronological order.
+			if i == 0 {
+				r.diffStats[commit.Hash] = r.pendingDiffStats
+			} else {
+				r.diffStats[commit.Hash] = nil
+			}
+		}
+	}
+	r.pendingDiffStats = nil
+
+	// Trigger all webhooks
+	for _, webhook := range r.webhooks {
+		req, err := http.NewRequest("POST", webhook.URL, bytes.NewReader(payload))
+		if err != nil {
+			return errors.Wrapf(err, "failed to create a new POST request to %q", webhook.URL)
+		}
+
+		m := hmac.New(sha256.New, []byte(webhook.Secret))
+		if _, err := m.Write(payload); err != nil {
+			return errors.Wrap(err, "failed to calculate SHA256 of the webhook secret")
+		}
+		signature := "sha256=" + hex.EncodeToString(m.Sum(nil))
+		req.Header.Set("X-Hub-Signature", signature)
+		req.Header.Set("X-Event-Key", string(bitbucket.WebhookPush))
+
+		resp, err := bb.client.Do(req)
+		if err != nil {
+			return errors.Wrapf(err, "failed to send POST request to %q", webhook.URL)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return errors.Wrap(err, "failed to read response body")
+		}
+		if resp.StatusCode != http.StatusOK {
+			return errors.Errorf("unexpected response status code %d, body: %s", resp.StatusCode, body)
+		}
+		bb.echo.Logger.Infof("SendWebhookPush response body %s\n", body)
+	}
+	return nil
+}
+
+// AddFiles adds given files to the Bitbucket repository.
+func (bb *Bitbucket) AddFiles(repositoryID string, files map[string]string) error {
+	r, ok := bb.repositories[repositoryID]
+	if !ok {
+		return errors.Errorf("Bitbucket repository %q does not exist", repositoryID)
+	}
+
+	// Save or overwrite files
+	for path, content := range files {
+		status := "added"
+		if _, ok := r.files[path]; ok {
+			status = "modified"
+		}
+		r.pendingDiffStats = append(r.pendingDiffStats, bitbucket.DiffStat{
+			Status: status,
+			New:    &bitbucket.DiffStatFile{Path: path},
+		})
+		r.files[path] = content
+	}
+	return nil
+}
+
+// GetFiles returns files with given paths from the Bitbucket repository.
+func (bb *Bitbucket) GetFiles(repositoryID string, filePaths ...string) (map[string]string, error) {
+	r, ok := bb.repositories[repositoryID]
+	if !ok {
+		return nil, errors.Errorf("Bitbucket repository %q does not exist", repositoryID)
+	}
+
+	// Get files
+	files := make(map[string]string)
+	for _, path := range filePaths {
+		if content, ok := r.files[path]; ok {
+			files[path] = content
+		}
+	}
+	return files, nil
+}
+
+// AddPullRequest creates a new pull request and add changed files to it.
+func (bb *Bitbucket) AddPullRequest(repositoryID string, prID int, files []*vcs.PullRequestFile) error {
+	r, ok := bb.repositories[repositoryID]
+	if !ok {
+		return errors.Errorf("Bitbucket repository %q does not exist", repositoryID)
+	}
+
+	var diffStats []bitbucket.DiffStat
+	for _, file := range files {
+		if file.IsDeleted {
+			diffStats = append(diffStats, bitbucket.DiffStat{
+				Status: "removed",
+				Old:    &bitbucket.DiffStatFile{Path: file.Path},
+			})
+			continue
+		}
+		diffStats = append(diffStats, bitbucket.DiffStat{
+			Status: "modified",
+			New:    &bitbucket.DiffStatFile{Path: file.Path},
+		})
+	}
+
+	r.pullRequests[prID] = struct {
+		DiffStats []bitbucket.DiffStat
+		*bitbucket.PullRequest
+	}{
+		DiffStats:   diffStats,
+		PullRequest: newBitbucketPullRequest(repositoryID, prID),
+	}
+
+	return nil
+}
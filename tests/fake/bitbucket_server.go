@@ -0,0 +1,571 @@
+package fake
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/pkg/errors"
+
+	"github.com/bytebase/bytebase/plugin/vcs"
+	"github.com/bytebase/bytebase/plugin/vcs/bitbucketserver"
+)
+
+// BitbucketServer is a fake implementation of Bitbucket Server VCS provider.
+type BitbucketServer struct {
+	port int
+	echo *echo.Echo
+
+	client *http.Client
+
+	nextWebhookID int
+	repositories  map[string]*bitbucketServerRepositoryData
+}
+
+type bitbucketServerRepositoryData struct {
+	webhooks []*bitbucketserver.WebhookCreateOrUpdate
+	// files is a map that the full file path is the key and the file content is the
+	// value.
+	files map[string]string
+	// pendingChanges is the list of file changes since the last push.
+	pendingChanges []bitbucketserver.Change
+	// commits is the map for the pushed commits.
+	// the map key is the commit ID.
+	commits map[string]*bitbucketserver.Commit
+	// changes is the map for the changed files of a commit.
+	// the map key is the commit ID.
+	changes map[string][]bitbucketserver.Change
+	// branches is the map for repository branch.
+	// the map key is the branch name, like "main".
+	branches map[string]*bitbucketserver.Branch
+	// pullRequests is the map for repository pull request.
+	// the map key is the pull request id.
+	pullRequests map[int]struct {
+		Changes []bitbucketserver.Change
+		*bitbucketserver.PullRequest
+	}
+}
+
+// NewBitbucketServer creates a new fake implementation of Bitbucket Server VCS provider.
+func NewBitbucketServer(port int) VCSProvider {
+	e := echo.New()
+	e.Use(middleware.Logger())
+	e.Use(middleware.Recover())
+
+	bbs := &BitbucketServer{
+		port:          port,
+		echo:          e,
+		client:        &http.Client{},
+		nextWebhookID: 20221101,
+		repositories:  make(map[string]*bitbucketServerRepositoryData),
+	}
+
+	g := e.Group("/rest/api/1.0/projects/:project/repos/:repo")
+	g.POST("/webhooks", bbs.createRepositoryWebhook)
+	g.GET("/commits", bbs.listRepositoryCommits)
+	g.GET("/commits/:commitID", bbs.getRepositoryCommit)
+	g.GET("/commits/:commitID/changes", bbs.listRepositoryCommitChanges)
+	g.GET("/raw/*", bbs.readRepositoryFile)
+	g.GET("/files/*", bbs.listRepositoryFiles)
+	g.PUT("/browse/*", bbs.createRepositoryFile)
+	g.GET("/branches", bbs.listRepositoryBranches)
+	g.POST("/branches", bbs.createRepositoryBranch)
+	g.POST("/pull-requests", bbs.createRepositoryPullRequest)
+	g.GET("/pull-requests/:prID", bbs.getRepositoryPullRequest)
+	g.GET("/pull-requests/:prID/changes", bbs.listPullRequestChanges)
+	return bbs
+}
+
+func (bbs *BitbucketServer) createRepositoryWebhook(c echo.Context) error {
+	r, err := bbs.validRepository(c)
+	if err != nil {
+		return err
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to read request body for creating repository webhook: %v", err))
+	}
+
+	var webhookCreate bitbucketserver.WebhookCreateOrUpdate
+	if err = json.Unmarshal(body, &webhookCreate); err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to unmarshal request body for creating repository webhook: %v", err))
+	}
+	r.webhooks = append(r.webhooks, &webhookCreate)
+
+	buf, err := json.Marshal(bitbucketserver.WebhookInfo{ID: bbs.nextWebhookID})
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to marshal response body for creating repository webhook: %v", err))
+	}
+	bbs.nextWebhookID++
+	return c.String(http.StatusCreated, string(buf))
+}
+
+func (bbs *BitbucketServer) listRepositoryCommits(c echo.Context) error {
+	r, err := bbs.validRepository(c)
+	if err != nil {
+		return err
+	}
+
+	// The latest commit changing the file is requested for the file metadata.
+	if c.QueryParam("path") != "" {
+		return bbs.listValues(c, []bitbucketserver.Commit{
+			{
+				ID:              "fake_bitbucket_server_commit_id",
+				AuthorTimestamp: time.Now().UnixMilli(),
+			},
+		})
+	}
+
+	commit, ok := r.commits[c.QueryParam("until")]
+	if !ok {
+		return c.String(http.StatusNotFound, fmt.Sprintf("commit %q not found", c.QueryParam("until")))
+	}
+	return bbs.listValues(c, []bitbucketserver.Commit{*commit})
+}
+
+func (bbs *BitbucketServer) getRepositoryCommit(c echo.Context) error {
+	r, err := bbs.validRepository(c)
+	if err != nil {
+		return err
+	}
+
+	commit, ok := r.commits[c.Param("commitID")]
+	if !ok {
+		return c.String(http.StatusNotFound, fmt.Sprintf("commit %q not found", c.Param("commitID")))
+	}
+	buf, err := json.Marshal(commit)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to marshal response body for getting repository commit: %v", err))
+	}
+	return c.String(http.StatusOK, string(buf))
+}
+
+func (bbs *BitbucketServer) listRepositoryCommitChanges(c echo.Context) error {
+	r, err := bbs.validRepository(c)
+	if err != nil {
+		return err
+	}
+	return bbs.listValues(c, r.changes[c.Param("commitID")])
+}
+
+func (bbs *BitbucketServer) readRepositoryFile(c echo.Context) error {
+	r, err := bbs.validRepository(c)
+	if err != nil {
+		return err
+	}
+
+	filePath, err := bbs.filePath(c)
+	if err != nil {
+		return err
+	}
+	content, ok := r.files[filePath]
+	if !ok {
+		return c.String(http.StatusNotFound, fmt.Sprintf("file %q not found", filePath))
+	}
+	return c.String(http.StatusOK, content)
+}
+
+func (bbs *BitbucketServer) listRepositoryFiles(c echo.Context) error {
+	r, err := bbs.validRepository(c)
+	if err != nil {
+		return err
+	}
+
+	dir, err := bbs.filePath(c)
+	if err != nil {
+		return err
+	}
+	// The file paths are relative to the directory.
+	var paths []string
+	for path := range r.files {
+		if dir == "" {
+			paths = append(paths, path)
+		} else if strings.HasPrefix(path, dir+"/") {
+			paths = append(paths, strings.TrimPrefix(path, dir+"/"))
+		}
+	}
+	return bbs.listValues(c, paths)
+}
+
+func (bbs *BitbucketServer) createRepositoryFile(c echo.Context) error {
+	r, err := bbs.validRepository(c)
+	if err != nil {
+		return err
+	}
+
+	filePath, err := bbs.filePath(c)
+	if err != nil {
+		return err
+	}
+	if err := c.Request().ParseMultipartForm(1 << 20); err != nil {
+		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to parse form for creating repository file: %v", err))
+	}
+	r.files[filePath] = c.Request().FormValue("content")
+
+	buf, err := json.Marshal(
+		bitbucketserver.Commit{
+			ID:              "fake_bitbucket_server_commit_id",
+			AuthorTimestamp: time.Now().UnixMilli(),
+			Message:         c.Request().FormValue("message"),
+		},
+	)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to marshal response body for creating repository file: %v", err))
+	}
+	return c.String(http.StatusOK, string(buf))
+}
+
+func (bbs *BitbucketServer) listRepositoryBranches(c echo.Context) error {
+	r, err := bbs.validRepository(c)
+	if err != nil {
+		return err
+	}
+
+	branchName := c.QueryParam("filterText")
+	if _, ok := r.branches[branchName]; !ok {
+		r.branches[branchName] = &bitbucketserver.Branch{
+			ID:           fmt.Sprintf("refs/heads/%s", branchName),
+			DisplayID:    branchName,
+			LatestCommit: "fake_bitbucket_server_commit_id",
+		}
+	}
+	return bbs.listValues(c, []*bitbucketserver.Branch{r.branches[branchName]})
+}
+
+func (bbs *BitbucketServer) createRepositoryBranch(c echo.Context) error {
+	r, err := bbs.validRepository(c)
+	if err != nil {
+		return err
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to read request body for creating repository branch: %v", err))
+	}
+
+	var branchCreate bitbucketserver.BranchCreate
+	if err = json.Unmarshal(body, &branchCreate); err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to unmarshal request body for creating repository branch: %v", err))
+	}
+
+	if _, ok := r.branches[branchCreate.Name]; ok {
+		return c.String(http.StatusBadRequest, fmt.Sprintf("the branch already exists: %v", branchCreate.Name))
+	}
+	branch := &bitbucketserver.Branch{
+		ID:           fmt.Sprintf("refs/heads/%s", branchCreate.Name),
+		DisplayID:    branchCreate.Name,
+		LatestCommit: branchCreate.StartPoint,
+	}
+	r.branches[branchCreate.Name] = branch
+
+	buf, err := json.Marshal(branch)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to marshal response body for creating repository branch: %v", err))
+	}
+	return c.String(http.StatusOK, string(buf))
+}
+
+func (bbs *BitbucketServer) createRepositoryPullRequest(c echo.Context) error {
+	r, err := bbs.validRepository(c)
+	if err != nil {
+		return err
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to read request body for creating repository pull request: %v", err))
+	}
+
+	var pullRequestCreate bitbucketserver.PullRequestCreate
+	if err = json.Unmarshal(body, &pullRequestCreate); err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to unmarshal request body for creating repository pull request: %v", err))
+	}
+
+	sourceBranch := strings.TrimPrefix(pullRequestCreate.FromRef.ID, "refs/heads/")
+	if _, ok := r.branches[sourceBranch]; !ok {
+		return c.String(http.StatusBadRequest, fmt.Sprintf("the source branch not exists: %v", sourceBranch))
+	}
+
+	prID := len(r.pullRequests) + 1
+	pullRequest := newBitbucketServerPullRequest(fmt.Sprintf("%s/%s", c.Param("project"), c.Param("repo")), prID)
+	pullRequest.FromRef.ID = pullRequestCreate.FromRef.ID
+	pullRequest.ToRef = pullRequestCreate.ToRef
+	r.pullRequests[prID] = struct {
+		Changes []bitbucketserver.Change
+		*bitbucketserver.PullRequest
+	}{
+		PullRequest: pullRequest,
+	}
+
+	buf, err := json.Marshal(pullRequest)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to marshal response body for creating repository pull request: %v", err))
+	}
+	return c.String(http.StatusCreated, string(buf))
+}
+
+func (bbs *BitbucketServer) getRepositoryPullRequest(c echo.Context) error {
+	r, err := bbs.validRepository(c)
+	if err != nil {
+		return err
+	}
+
+	prID, err := strconv.Atoi(c.Param("prID"))
+	if err != nil {
+		return c.String(http.StatusBadRequest, fmt.Sprintf("The pull request id is invalid: %v", c.Param("prID")))
+	}
+	pullRequest, ok := r.pullRequests[prID]
+	if !ok {
+		return c.String(http.StatusNotFound, fmt.Sprintf("Cannot found the pull request: %v", c.Param("prID")))
+	}
+
+	buf, err := json.Marshal(pullRequest.PullRequest)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to marshal response body for getting repository pull request: %v", err))
+	}
+	return c.String(http.StatusOK, string(buf))
+}
+
+func (bbs *BitbucketServer) listPullRequestChanges(c echo.Context) error {
+	r, err := bbs.validRepository(c)
+	if err != nil {
+		return err
+	}
+
+	prID, err := strconv.Atoi(c.Param("prID"))
+	if err != nil {
+		return c.String(http.StatusBadRequest, fmt.Sprintf("The pull request id is invalid: %v", c.Param("prID")))
+	}
+	pullRequest, ok := r.pullRequests[prID]
+	if !ok {
+		return c.String(http.StatusNotFound, fmt.Sprintf("Cannot found the pull request: %v", c.Param("prID")))
+	}
+	return bbs.listValues(c, pullRequest.Changes)
+}
+
+// listValues responds the values in a single page of the paged list.
+func (*BitbucketServer) listValues(c echo.Context, values interface{}) error {
+	buf, err := json.Marshal(
+		map[string]interface{}{
+			"values":     values,
+			"isLastPage": true,
+		},
+	)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to marshal response body: %v", err))
+	}
+	return c.String(http.StatusOK, string(buf))
+}
+
+// filePath returns the unescaped file path in the request path.
+func (*BitbucketServer) filePath(c echo.Context) (string, error) {
+	filePathEscaped := c.Param("*")
+	filePath, err := url.PathUnescape(filePathEscaped)
+	if err != nil {
+		return "", c.String(http.StatusBadRequest, fmt.Sprintf("failed to unescape file path %q: %v", filePathEscaped, err))
+	}
+	return filePath, nil
+}
+
+func (bbs *BitbucketServer) validRepository(c echo.Context) (*bitbucketServerRepositoryData, error) {
+	repositoryID := fmt.Sprintf("%s/%s", c.Param("project"), c.Param("repo"))
+	r, ok := bbs.repositories[repositoryID]
+	if !ok {
+		return nil, c.String(http.StatusNotFound, fmt.Sprintf("Bitbucket Server repository %q does not exist", repositoryID))
+	}
+
+	return r, nil
+}
+
+func newBitbucketServerPullRequest(repositoryID string, prID int) *bitbucketserver.PullRequest {
+	projectKey, repositorySlug, _ := strings.Cut(repositoryID, "/")
+	return &bitbucketserver.PullRequest{
+		ID: prID,
+		FromRef: bitbucketserver.PullRequestRef{
+			LatestCommit: "fake_bitbucket_server_commit_id",
+		},
+		Links: bitbucketserver.Links{
+			Self: []bitbucketserver.Link{
+				{Href: fmt.Sprintf("https://bitbucket.example.com/projects/%s/repos/%s/pull-requests/%d", projectKey, repositorySlug, prID)},
+			},
+		},
+	}
+}
+
+// Run starts the Bitbucket Server VCS provider server.
+func (bbs *BitbucketServer) Run() error {
+	return bbs.echo.Start(fmt.Sprintf(":%d", bbs.port))
+}
+
+// Close shuts down the Bitbucket Server VCS provider server.
+func (bbs *BitbucketServer) Close() error {
+	return bbs.echo.Close()
+}
+
+// ListenerAddr returns the Bitbucket Server VCS provider server listener address.
+func (bbs *BitbucketServer) ListenerAddr() net.Addr {
+	return bbs.echo.ListenerAddr()
+}
+
+// APIURL returns the Bitbucket Server VCS provider API URL.
+func (*BitbucketServer) APIURL(instanceURL string) string {
+	return fmt.Sprintf("%s/rest/api/1.0", instanceURL)
+}
+
+// CreateRepository creates a Bitbucket Server repository with given ID.
+func (bbs *BitbucketServer) CreateRepository(id string) {
+	bbs.repositories[id] = &bitbucketServerRepositoryData{
+		files:    make(map[string]string),
+		commits:  make(map[string]*bitbucketserver.Commit),
+		changes:  make(map[string][]bitbucketserver.Change),
+		branches: make(map[string]*bitbucketserver.Branch),
+		pullRequests: map[int]struct {
+			Changes []bitbucketserver.Change
+			*bitbucketserver.PullRequest
+		}{},
+	}
+}
+
+// SendWebhookPush sends out a webhook for a push event for the Bitbucket
+// Server repository using given payload.
+//
+// Bitbucket Server includes neither the commits nor the changed files in the
+// payload, so the files changed by AddFiles since the last push are attributed
+// to a single commit with the ID of the to hash of each change in the payload.
+func (bbs *BitbucketServer) SendWebhookPush(repositoryID string, payload []byte) error {
+	r, ok := bbs.repositories[repositoryID]
+	if !ok {
+		return errors.Errorf("Bitbucket Server repository %q does not exist", repositoryID)
+	}
+
+	var pushEvent bitbucketserver.WebhookPushEvent
+	if err := json.Unmarshal(payload, &pushEvent); err != nil {
+		return errors.Wrap(err, "failed to unmarshal the webhook push event")
+	}
+	for _, change := range pushEvent.Changes {
+		r.commits[change.ToHash] = &bitbucketserver.Commit{
+			ID: change.ToHash,
+			Author: bitbucketserver.User{
+				DisplayName:  "fake_bitbucket_server_author",
+				EmailAddress: "fake_bitbucket_server_author@localhost",
+			},
+			AuthorTimestamp: time.Now().UnixMilli(),
+			Message:         "Fake Bitbucket Server commit message",
+		}
+		r.changes[change.ToHash] = r.pendingChanges
+	}
+	r.pendingChanges = nil
+
+	// Trigger all webhooks
+	for _, webhook := range r.webhooks {
+		req, err := http.NewRequest("POST", webhook.URL, bytes.NewReader(payload))
+		if err != nil {
+			return errors.Wrapf(err, "failed to create a new POST request to %q", webhook.URL)
+		}
+
+		m := hmac.New(sha256.New, []byte(webhook.Configuration.Secret))
+		if _, err := m.Write(payload); err != nil {
+			return errors.Wrap(err, "failed to calculate SHA256 of the webhook secret")
+		}
+		signature := "sha256=" + hex.EncodeToString(m.Sum(nil))
+		req.Header.Set("X-Hub-Signature", signature)
+		req.Header.Set("X-Event-Key", string(bitbucketserver.WebhookPush))
+
+		resp, err := bbs.client.Do(req)
+		if err != nil {
+			return errors.Wrapf(err, "failed to send POST request to %q", webhook.URL)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return errors.Wrap(err, "failed to read response body")
+		}
+		if resp.StatusCode != http.StatusOK {
+			return errors.Errorf("unexpected response status code %d, body: %s", resp.StatusCode, body)
+		}
+		bbs.echo.Logger.Infof("SendWebhookPush response body %s\n", body)
+	}
+	return nil
+}
+
+// AddFiles adds given files to the Bitbucket Server repository.
+func (bbs *BitbucketServer) AddFiles(repositoryID string, files map[string]string) error {
+	r, ok := bbs.repositories[repositoryID]
+	if !ok {
+		return errors.Errorf("Bitbucket Server repository %q does not exist", repositoryID)
+	}
+
+	// Save or overwrite files
+	for path, content := range files {
+		changeType := "ADD"
+		if _, ok := r.files[path]; ok {
+			changeType = "MODIFY"
+		}
+		r.pendingChanges = append(r.pendingChanges, bitbucketserver.Change{
+			Path: bitbucketserver.Path{ToString: path},
+			Type: changeType,
+		})
+		r.files[path] = content
+	}
+	return nil
+}
+
+// GetFiles returns files with given paths from the Bitbucket Server repository.
+func (bbs *BitbucketServer) GetFiles(repositoryID string, filePaths ...string) (map[string]string, error) {
+	r, ok := bbs.repositories[repositoryID]
+	if !ok {
+		return nil, errors.Errorf("Bitbucket Server repository %q does not exist", repositoryID)
+	}
+
+	// Get files
+	files := make(map[string]string)
+	for _, path := range filePaths {
+		if content, ok := r.files[path]; ok {
+			files[path] = content
+		}
+	}
+	return files, nil
+}
+
+// AddPullRequest creates a new pull request and add changed files to it.
+func (bbs *BitbucketServer) AddPullRequest(repositoryID string, prID int, files []*vcs.PullRequestFile) error {
+	r, ok := bbs.repositories[repositoryID]
+	if !ok {
+		return errors.Errorf("Bitbucket Server repository %q does not exist", repositoryID)
+	}
+
+	var changes []bitbucketserver.Change
+	for _, file := range files {
+		changeType := "MODIFY"
+		if file.IsDeleted {
+			changeType = "DELETE"
+		}
+		changes = append(changes, bitbucketserver.Change{
+			Path: bitbucketserver.Path{ToString: file.Path},
+			Type: changeType,
+		})
+	}
+
+	r.pullRequests[prID] = struct {
+		Changes []bitbucketserver.Change
+		*bitbucketserver.PullRequest
+	}{
+		Changes:     changes,
+		PullRequest: newBitbucketServerPullRequest(repositoryID, prID),
+	}
+
+	return nil
+}
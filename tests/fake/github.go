@@ -32,6 +32,7 @@ type GitHub struct {
 	client *http.Client
 
 	nextWebhookID int
+	nextCommentID int64
 	repositories  map[string]*repositoryData
 }
 
@@ -54,6 +55,15 @@ type repositoryData struct {
 		Files []*github.PullRequestFile
 		*github.PullRequest
 	}
+	// statuses is the map for commit status.
+	// the map key is the commit SHA.
+	statuses map[string][]*github.CommitStatusCreate
+	// issueComments is the map for pull request conversation comments.
+	// the map key is the pull request id.
+	issueComments map[int][]*github.Comment
+	// reviewComments is the map for pull request comments on the diff.
+	// the map key is the pull request id.
+	reviewComments map[int][]*github.Comment
 }
 
 // NewGitHub creates a new fake implementation of GitHub VCS provider.
@@ -67,6 +77,7 @@ func NewGitHub(port int) VCSProvider {
 		echo:          e,
 		client:        &http.Client{},
 		nextWebhookID: 20210113,
+		nextCommentID: 20221103,
 		repositories:  make(map[string]*repositoryData),
 	}
 
@@ -85,6 +96,13 @@ func NewGitHub(port int) VCSProvider {
 	)
 	g.PUT("/repos/:owner/:repo/actions/secrets/:keyName", gh.updateRepositoryPublicKey)
 	g.GET("/repos/:owner/:repo/pulls/:prID/files", gh.listPullRequestFile)
+	g.GET("/repos/:owner/:repo/issues/:prID/comments", gh.listIssueComment)
+	g.POST("/repos/:owner/:repo/issues/:prID/comments", gh.createIssueComment)
+	g.PATCH("/repos/:owner/:repo/issues/comments/:commentID", gh.updateIssueComment)
+	g.GET("/repos/:owner/:repo/pulls/:prID/comments", gh.listReviewComment)
+	g.POST("/repos/:owner/:repo/pulls/:prID/comments", gh.createReviewComment)
+	g.DELETE("/repos/:owner/:repo/pulls/comments/:commentID", gh.deleteReviewComment)
+	g.POST("/repos/:owner/:repo/statuses/:sha", gh.createCommitStatus)
 	return gh
 }
 
@@ -391,6 +409,170 @@ func (gh *GitHub) listPullRequestFile(c echo.Context) error {
 	return c.String(http.StatusOK, string(buf))
 }
 
+func (gh *GitHub) createCommitStatus(c echo.Context) error {
+	r, err := gh.validRepository(c)
+	if err != nil {
+		return err
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to read request body for creating commit status: %v", err))
+	}
+
+	var statusCreate github.CommitStatusCreate
+	if err = json.Unmarshal(body, &statusCreate); err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to unmarshal request body for creating commit status: %v", err))
+	}
+	sha := c.Param("sha")
+	r.statuses[sha] = append(r.statuses[sha], &statusCreate)
+	return c.String(http.StatusCreated, "{}")
+}
+
+func (gh *GitHub) listIssueComment(c echo.Context) error {
+	r, err := gh.validRepository(c)
+	if err != nil {
+		return err
+	}
+	prNumber, err := validPullRequest(c, r)
+	if err != nil {
+		return c.String(http.StatusNotFound, err.Error())
+	}
+	return listComment(c, r.issueComments[prNumber])
+}
+
+func (gh *GitHub) createIssueComment(c echo.Context) error {
+	r, err := gh.validRepository(c)
+	if err != nil {
+		return err
+	}
+	prNumber, err := validPullRequest(c, r)
+	if err != nil {
+		return c.String(http.StatusNotFound, err.Error())
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to read request body for creating issue comment: %v", err))
+	}
+
+	var commentCreate github.IssueCommentCreate
+	if err = json.Unmarshal(body, &commentCreate); err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to unmarshal request body for creating issue comment: %v", err))
+	}
+	gh.nextCommentID++
+	r.issueComments[prNumber] = append(r.issueComments[prNumber], &github.Comment{ID: gh.nextCommentID, Body: commentCreate.Body})
+	return c.String(http.StatusCreated, "{}")
+}
+
+func (gh *GitHub) updateIssueComment(c echo.Context) error {
+	r, err := gh.validRepository(c)
+	if err != nil {
+		return err
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to read request body for updating issue comment: %v", err))
+	}
+
+	var commentCreate github.IssueCommentCreate
+	if err = json.Unmarshal(body, &commentCreate); err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to unmarshal request body for updating issue comment: %v", err))
+	}
+	for _, comments := range r.issueComments {
+		for _, comment := range comments {
+			if fmt.Sprintf("%d", comment.ID) == c.Param("commentID") {
+				comment.Body = commentCreate.Body
+				return c.String(http.StatusOK, "{}")
+			}
+		}
+	}
+	return c.String(http.StatusNotFound, fmt.Sprintf("Cannot found the comment: %v", c.Param("commentID")))
+}
+
+func (gh *GitHub) listReviewComment(c echo.Context) error {
+	r, err := gh.validRepository(c)
+	if err != nil {
+		return err
+	}
+	prNumber, err := validPullRequest(c, r)
+	if err != nil {
+		return c.String(http.StatusNotFound, err.Error())
+	}
+	return listComment(c, r.reviewComments[prNumber])
+}
+
+func (gh *GitHub) createReviewComment(c echo.Context) error {
+	r, err := gh.validRepository(c)
+	if err != nil {
+		return err
+	}
+	prNumber, err := validPullRequest(c, r)
+	if err != nil {
+		return c.String(http.StatusNotFound, err.Error())
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to read request body for creating review comment: %v", err))
+	}
+
+	var commentCreate github.ReviewCommentCreate
+	if err = json.Unmarshal(body, &commentCreate); err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to unmarshal request body for creating review comment: %v", err))
+	}
+	gh.nextCommentID++
+	r.reviewComments[prNumber] = append(r.reviewComments[prNumber], &github.Comment{ID: gh.nextCommentID, Body: commentCreate.Body})
+	return c.String(http.StatusCreated, "{}")
+}
+
+func (gh *GitHub) deleteReviewComment(c echo.Context) error {
+	r, err := gh.validRepository(c)
+	if err != nil {
+		return err
+	}
+
+	for prNumber, comments := range r.reviewComments {
+		for i, comment := range comments {
+			if fmt.Sprintf("%d", comment.ID) == c.Param("commentID") {
+				r.reviewComments[prNumber] = append(comments[:i], comments[i+1:]...)
+				return c.NoContent(http.StatusNoContent)
+			}
+		}
+	}
+	return c.String(http.StatusNotFound, fmt.Sprintf("Cannot found the comment: %v", c.Param("commentID")))
+}
+
+// validPullRequest returns the pull request number in the path if the pull
+// request exists in the repository.
+func validPullRequest(c echo.Context, r *repositoryData) (int, error) {
+	prNumber, err := strconv.Atoi(c.Param("prID"))
+	if err != nil {
+		return 0, errors.Errorf("The pull request id is invalid: %v", c.Param("prID"))
+	}
+	if _, ok := r.pullRequests[prNumber]; !ok {
+		return 0, errors.Errorf("Cannot found the pull request: %v", c.Param("prID"))
+	}
+	return prNumber, nil
+}
+
+// listComment responds with all comments on the first page and nothing after,
+// which is enough to terminate the pagination of the client.
+func listComment(c echo.Context, comments []*github.Comment) error {
+	if page := c.QueryParam("page"); page != "" && page != "1" {
+		return c.String(http.StatusOK, "[]")
+	}
+	if comments == nil {
+		comments = []*github.Comment{}
+	}
+	buf, err := json.Marshal(comments)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to marshal response body: %v", err))
+	}
+	return c.String(http.StatusOK, string(buf))
+}
+
 func (gh *GitHub) validRepository(c echo.Context) (*repositoryData, error) {
 	repositoryID := fmt.Sprintf("%s/%s", c.Param("owner"), c.Param("repo"))
 	r, ok := gh.repositories[repositoryID]
@@ -437,6 +619,9 @@ func (gh *GitHub) CreateRepository(id string) {
 			Files []*github.PullRequestFile
 			*github.PullRequest
 		}{},
+		statuses:       map[string][]*github.CommitStatusCreate{},
+		issueComments:  map[int][]*github.Comment{},
+		reviewComments: map[int][]*github.Comment{},
 	}
 }
 
@@ -29,6 +29,7 @@ type GitLab struct {
 	client *http.Client
 
 	nextWebhookID int
+	nextNoteID    int
 	projects      map[string]*projectData
 }
 
@@ -49,6 +50,13 @@ type projectData struct {
 		*gitlab.MergeRequestChange
 		*gitlab.MergeRequest
 	}
+	// mergeRequestDiscussions is the map for merge request discussions, a
+	// standalone note is kept as an individual discussion.
+	// the map key is the merge request id.
+	mergeRequestDiscussions map[int][]*gitlab.MergeRequestDiscussion
+	// statuses is the map for commit status.
+	// the map key is the commit SHA.
+	statuses map[string][]*gitlab.CommitStatusCreate
 }
 
 // NewGitLab creates a new fake implementation of GitLab VCS provider.
@@ -81,6 +89,13 @@ func NewGitLab(port int) VCSProvider {
 	projectGroup.POST("/projects/:id/variables", gl.createProjectEnvironmentVariable)
 	projectGroup.PUT("/projects/:id/variables/:variableKey", gl.updateProjectEnvironmentVariable)
 	projectGroup.GET("/projects/:id/merge_requests/:mrID/changes", gl.getMergeRequestChanges)
+	projectGroup.GET("/projects/:id/merge_requests/:mrID", gl.getMergeRequest)
+	projectGroup.GET("/projects/:id/merge_requests/:mrID/discussions", gl.listMergeRequestDiscussion)
+	projectGroup.POST("/projects/:id/merge_requests/:mrID/notes", gl.createMergeRequestComment)
+	projectGroup.POST("/projects/:id/merge_requests/:mrID/discussions", gl.createMergeRequestComment)
+	projectGroup.PUT("/projects/:id/merge_requests/:mrID/notes/:noteID", gl.updateMergeRequestNote)
+	projectGroup.DELETE("/projects/:id/merge_requests/:mrID/notes/:noteID", gl.deleteMergeRequestNote)
+	projectGroup.POST("/projects/:id/statuses/:sha", gl.createCommitStatus)
 
 	return gl
 }
@@ -115,6 +130,8 @@ func (gl *GitLab) CreateRepository(id string) {
 			*gitlab.MergeRequestChange
 			*gitlab.MergeRequest
 		}{},
+		mergeRequestDiscussions: map[int][]*gitlab.MergeRequestDiscussion{},
+		statuses:                map[string][]*gitlab.CommitStatusCreate{},
 	}
 }
 
@@ -447,6 +464,175 @@ func (gl *GitLab) getMergeRequestChanges(c echo.Context) error {
 	return c.String(http.StatusOK, string(buf))
 }
 
+func (gl *GitLab) createCommitStatus(c echo.Context) error {
+	pd, err := gl.validProject(c)
+	if err != nil {
+		return err
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to read request body for creating commit status: %v", err))
+	}
+
+	var statusCreate gitlab.CommitStatusCreate
+	if err = json.Unmarshal(body, &statusCreate); err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to unmarshal request body for creating commit status: %v", err))
+	}
+	sha := c.Param("sha")
+	pd.statuses[sha] = append(pd.statuses[sha], &statusCreate)
+	return c.String(http.StatusCreated, "{}")
+}
+
+func (gl *GitLab) getMergeRequest(c echo.Context) error {
+	pd, err := gl.validProject(c)
+	if err != nil {
+		return err
+	}
+
+	mrNumber, err := strconv.Atoi(c.Param("mrID"))
+	if err != nil {
+		return c.String(http.StatusBadRequest, fmt.Sprintf("The merge request id is invalid: %v", c.Param("mrID")))
+	}
+
+	mergeRequest, ok := pd.mergeRequests[mrNumber]
+	if !ok {
+		return c.String(http.StatusNotFound, fmt.Sprintf("Cannot found the merge request: %v", c.Param("mrID")))
+	}
+
+	buf, err := json.Marshal(mergeRequest.MergeRequest)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to marshal response body: %v", err))
+	}
+	return c.String(http.StatusOK, string(buf))
+}
+
+// listMergeRequestDiscussion lists the merge request discussions on the first
+// page and nothing after, which is enough to terminate the pagination of the client.
+func (gl *GitLab) listMergeRequestDiscussion(c echo.Context) error {
+	pd, err := gl.validProject(c)
+	if err != nil {
+		return err
+	}
+	mrNumber, err := validMergeRequest(c, pd)
+	if err != nil {
+		return c.String(http.StatusNotFound, err.Error())
+	}
+
+	if page := c.QueryParam("page"); page != "" && page != "1" {
+		return c.String(http.StatusOK, "[]")
+	}
+	discussions := pd.mergeRequestDiscussions[mrNumber]
+	if discussions == nil {
+		discussions = []*gitlab.MergeRequestDiscussion{}
+	}
+	buf, err := json.Marshal(discussions)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to marshal response body: %v", err))
+	}
+	return c.String(http.StatusOK, string(buf))
+}
+
+// createMergeRequestComment accepts both the merge request notes and the
+// discussions, and records them as the discussions of the merge request.
+func (gl *GitLab) createMergeRequestComment(c echo.Context) error {
+	pd, err := gl.validProject(c)
+	if err != nil {
+		return err
+	}
+	mrNumber, err := validMergeRequest(c, pd)
+	if err != nil {
+		return c.String(http.StatusNotFound, err.Error())
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to read request body for creating merge request comment: %v", err))
+	}
+
+	var discussionCreate gitlab.MergeRequestDiscussionCreate
+	if err = json.Unmarshal(body, &discussionCreate); err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to unmarshal request body for creating merge request comment: %v", err))
+	}
+	gl.nextNoteID++
+	pd.mergeRequestDiscussions[mrNumber] = append(pd.mergeRequestDiscussions[mrNumber], &gitlab.MergeRequestDiscussion{
+		ID:             fmt.Sprintf("%d", gl.nextNoteID),
+		IndividualNote: strings.HasSuffix(c.Path(), "/notes"),
+		Notes: []gitlab.MergeRequestNote{
+			{ID: gl.nextNoteID, Body: discussionCreate.Body},
+		},
+	})
+	return c.String(http.StatusCreated, "{}")
+}
+
+// updateMergeRequestNote updates the body of a merge request note.
+func (gl *GitLab) updateMergeRequestNote(c echo.Context) error {
+	pd, err := gl.validProject(c)
+	if err != nil {
+		return err
+	}
+	mrNumber, err := validMergeRequest(c, pd)
+	if err != nil {
+		return c.String(http.StatusNotFound, err.Error())
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to read request body for updating merge request note: %v", err))
+	}
+
+	var noteCreate gitlab.MergeRequestNoteCreate
+	if err = json.Unmarshal(body, &noteCreate); err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("failed to unmarshal request body for updating merge request note: %v", err))
+	}
+	for _, discussion := range pd.mergeRequestDiscussions[mrNumber] {
+		for i, note := range discussion.Notes {
+			if fmt.Sprintf("%d", note.ID) == c.Param("noteID") {
+				discussion.Notes[i].Body = noteCreate.Body
+				return c.String(http.StatusOK, "{}")
+			}
+		}
+	}
+	return c.String(http.StatusNotFound, fmt.Sprintf("Cannot found the note: %v", c.Param("noteID")))
+}
+
+// deleteMergeRequestNote deletes a merge request note along with its
+// discussion, as the fake discussions only ever have one note.
+func (gl *GitLab) deleteMergeRequestNote(c echo.Context) error {
+	pd, err := gl.validProject(c)
+	if err != nil {
+		return err
+	}
+	mrNumber, err := validMergeRequest(c, pd)
+	if err != nil {
+		return c.String(http.StatusNotFound, err.Error())
+	}
+
+	discussions := pd.mergeRequestDiscussions[mrNumber]
+	for i, discussion := range discussions {
+		for _, note := range discussion.Notes {
+			if fmt.Sprintf("%d", note.ID) == c.Param("noteID") {
+				pd.mergeRequestDiscussions[mrNumber] = append(discussions[:i], discussions[i+1:]...)
+				return c.NoContent(http.StatusNoContent)
+			}
+		}
+	}
+	return c.String(http.StatusNotFound, fmt.Sprintf("Cannot found the note: %v", c.Param("noteID")))
+}
+
+// validMergeRequest returns the merge request number in the path if the merge
+// request exists in the project.
+func validMergeRequest(c echo.Context, pd *projectData) (int, error) {
+	mrNumber, err := strconv.Atoi(c.Param("mrID"))
+	if err != nil {
+		return 0, errors.Errorf("The merge request id is invalid: %v", c.Param("mrID"))
+	}
+	if _, ok := pd.mergeRequests[mrNumber]; !ok {
+		return 0, errors.Errorf("Cannot found the merge request: %v", c.Param("mrID"))
+	}
+	return mrNumber, nil
+}
+
 // SendWebhookPush sends out a webhook for a push event for the GitLab project
 // using given payload.
 func (gl *GitLab) SendWebhookPush(projectID string, payload []byte) error {
@@ -544,6 +730,9 @@ func (gl *GitLab) AddPullRequest(projectID string, mrID int, files []*vcs.PullRe
 		MergeRequest: &gitlab.MergeRequest{
 			// TODO: the URL for merge request is invalid.
 			WebURL: fmt.Sprintf("http://gitlab.example.com/my-group/my-project/merge_requests/%d", mrID),
+			DiffRefs: gitlab.MergeRequestDiffRefs{
+				HeadSHA: files[0].LastCommitID,
+			},
 		},
 	}
 	return nil
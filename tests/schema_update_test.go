@@ -22,6 +22,10 @@ import (
 	"github.com/bytebase/bytebase/plugin/advisor"
 	"github.com/bytebase/bytebase/plugin/db"
 	"github.com/bytebase/bytebase/plugin/vcs"
+	"github.com/bytebase/bytebase/plugin/vcs/azure"
+	"github.com/bytebase/bytebase/plugin/vcs/bitbucket"
+	"github.com/bytebase/bytebase/plugin/vcs/bitbucketserver"
+	"github.com/bytebase/bytebase/plugin/vcs/gitea"
 	"github.com/bytebase/bytebase/plugin/vcs/github"
 	"github.com/bytebase/bytebase/plugin/vcs/gitlab"
 	"github.com/bytebase/bytebase/resources/postgres"
@@ -341,6 +345,171 @@ func TestVCS(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:               "Gitea",
+			vcsProviderCreator: fake.NewGitea,
+			vcsType:            vcs.Gitea,
+			externalID:         "octocat/Hello-World",
+			repositoryFullPath: "octocat/Hello-World",
+			newWebhookPushEvent: func(added [][]string, modified [][]string) interface{} {
+				var commits []gitea.WebhookCommit
+				for i := range added {
+					commits = append(commits, gitea.WebhookCommit{
+						ID:        "fake_gitea_commit_id",
+						Message:   "Fake Gitea commit message",
+						Timestamp: time.Now(),
+						URL:       "https://gitea.example.com/octocat/Hello-World/commit/fake_gitea_commit_id",
+						Author: gitea.WebhookCommitAuthor{
+							Name:  "fake_gitea_author",
+							Email: "fake_gitea_author@localhost",
+						},
+						Added:    added[i],
+						Modified: modified[i],
+					})
+				}
+				return gitea.WebhookPushEvent{
+					Ref: "refs/heads/feature/foo",
+					Repository: gitea.WebhookRepository{
+						ID:       211,
+						FullName: "octocat/Hello-World",
+						HTMLURL:  "https://gitea.example.com/octocat/Hello-World",
+					},
+					Sender: gitea.WebhookSender{
+						Login: "fake_gitea_author",
+					},
+					Commits: commits,
+				}
+			},
+		},
+		{
+			name:               "Bitbucket",
+			vcsProviderCreator: fake.NewBitbucket,
+			vcsType:            vcs.BitbucketCloud,
+			externalID:         "octocat/hello-world",
+			repositoryFullPath: "octocat/hello-world",
+			newWebhookPushEvent: func(added [][]string, modified [][]string) interface{} {
+				// Bitbucket does not include the changed files in the payload, the
+				// fake provider serves the files added since the last push instead.
+				var commits []bitbucket.Commit
+				for i := range added {
+					commits = append(commits, bitbucket.Commit{
+						Hash:    fmt.Sprintf("fake_bitbucket_commit_hash_%d", len(added)-i),
+						Date:    time.Now(),
+						Message: "Fake Bitbucket commit message",
+						Author: bitbucket.CommitAuthor{
+							Raw: "fake_bitbucket_author <fake_bitbucket_author@localhost>",
+						},
+					})
+				}
+				return bitbucket.WebhookPushEvent{
+					Push: bitbucket.WebhookPushDetail{
+						Changes: []bitbucket.WebhookPushChange{
+							{
+								New: &bitbucket.WebhookPushChangeRef{
+									Type: "branch",
+									Name: "feature/foo",
+								},
+								Commits: commits,
+							},
+						},
+					},
+					Repository: bitbucket.Repository{
+						Name:     "hello-world",
+						FullName: "octocat/hello-world",
+						Links: bitbucket.Links{
+							HTML: bitbucket.Link{Href: "https://bitbucket.org/octocat/hello-world"},
+						},
+					},
+					Actor: bitbucket.User{
+						DisplayName: "fake_bitbucket_author",
+					},
+				}
+			},
+		},
+		{
+			name:               "BitbucketServer",
+			vcsProviderCreator: fake.NewBitbucketServer,
+			vcsType:            vcs.BitbucketServer,
+			externalID:         "OCTO/hello-world",
+			repositoryFullPath: "OCTO/hello-world",
+			newWebhookPushEvent: func(added [][]string, modified [][]string) interface{} {
+				// Bitbucket Server includes neither the commits nor the changed files
+				// in the payload, the fake provider serves the files added since the
+				// last push as a single commit instead.
+				return bitbucketserver.WebhookPushEvent{
+					EventKey: bitbucketserver.WebhookPush,
+					Changes: []bitbucketserver.WebhookPushChange{
+						{
+							Ref: bitbucketserver.WebhookRef{
+								ID:        "refs/heads/feature/foo",
+								DisplayID: "feature/foo",
+								Type:      "BRANCH",
+							},
+							FromHash: "fake_bitbucket_server_commit_id_base",
+							ToHash:   fmt.Sprintf("fake_bitbucket_server_commit_id_%d", len(added)),
+							Type:     "UPDATE",
+						},
+					},
+					Repository: bitbucketserver.Repository{
+						Slug: "hello-world",
+						Name: "hello-world",
+						Project: bitbucketserver.Project{
+							Key: "OCTO",
+						},
+						Links: bitbucketserver.Links{
+							Self: []bitbucketserver.Link{
+								{Href: "https://bitbucket.example.com/projects/OCTO/repos/hello-world/browse"},
+							},
+						},
+					},
+					Actor: bitbucketserver.User{
+						DisplayName: "fake_bitbucket_server_author",
+					},
+				}
+			},
+		},
+		{
+			name:               "AzureDevOps",
+			vcsProviderCreator: fake.NewAzureDevOps,
+			vcsType:            vcs.AzureDevOps,
+			externalID:         "octocat/hello-world",
+			repositoryFullPath: "octocat/hello-world",
+			newWebhookPushEvent: func(added [][]string, modified [][]string) interface{} {
+				// Azure DevOps does not include the changed files in the payload, the
+				// fake provider serves the files added since the last push instead.
+				var commits []azure.WebhookCommit
+				for i := range added {
+					commits = append(commits, azure.WebhookCommit{
+						CommitID: fmt.Sprintf("fake_azure_commit_id_%d", len(added)-i),
+						Author: azure.CommitUser{
+							Name:  "fake_azure_author",
+							Email: "fake_azure_author@localhost",
+							Date:  time.Now(),
+						},
+						Comment: "Fake Azure DevOps commit message",
+					})
+				}
+				return azure.WebhookPushEvent{
+					EventType: azure.WebhookPush,
+					Resource: azure.WebhookPushResource{
+						Commits: commits,
+						RefUpdates: []azure.RefUpdate{
+							{Name: "refs/heads/feature/foo"},
+						},
+						Repository: azure.Repository{
+							Name:      "hello-world",
+							RemoteURL: "https://dev.azure.com/fabrikam/octocat/_git/hello-world",
+							Project: azure.Project{
+								Name: "octocat",
+							},
+						},
+						PushedBy: azure.WebhookIdentity{
+							DisplayName: "fake_azure_author",
+						},
+					},
+				}
+			},
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -689,6 +858,40 @@ func TestVCS_SDL(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:               "Gitea",
+			vcsProviderCreator: fake.NewGitea,
+			vcsType:            vcs.Gitea,
+			externalID:         "octocat/Hello-World",
+			repositoryFullPath: "octocat/Hello-World",
+			newWebhookPushEvent: func(added []string, modified []string) interface{} {
+				return gitea.WebhookPushEvent{
+					Ref: "refs/heads/feature/foo",
+					Repository: gitea.WebhookRepository{
+						ID:       211,
+						FullName: "octocat/Hello-World",
+						HTMLURL:  "https://gitea.example.com/octocat/Hello-World",
+					},
+					Sender: gitea.WebhookSender{
+						Login: "fake_gitea_author",
+					},
+					Commits: []gitea.WebhookCommit{
+						{
+							ID:        "fake_gitea_commit_id",
+							Message:   "Fake Gitea commit message",
+							Timestamp: time.Now(),
+							URL:       "https://gitea.example.com/octocat/Hello-World/commit/fake_gitea_commit_id",
+							Author: gitea.WebhookCommitAuthor{
+								Name:  "fake_gitea_author",
+								Email: "fake_gitea_author@localhost",
+							},
+							Added:    added,
+							Modified: modified,
+						},
+					},
+				}
+			},
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -1445,6 +1648,44 @@ func TestVCS_SQL_Review(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:               "Gitea",
+			vcsProviderCreator: fake.NewGitea,
+			vcsType:            vcs.Gitea,
+			externalID:         "octocat/Hello-World",
+			repositoryFullPath: "octocat/Hello-World",
+			getEmptySQLReviewResult: func(repo *api.Repository, filePath, rootURL string) *api.VCSSQLReviewResult {
+				return &api.VCSSQLReviewResult{
+					Status: advisor.Warn,
+					Content: []string{
+						fmt.Sprintf(
+							"::warning file=%s,line=1,col=1,endColumn=2,title=SQL review policy not found (2)::You can configure the SQL review policy on %s/setting/sql-review%%0ADoc: https://www.bytebase.com/docs/reference/error-code/advisor#2",
+							filePath,
+							rootURL,
+						),
+					},
+				}
+			},
+			getSQLReviewResult: func(repo *api.Repository, filePath string) *api.VCSSQLReviewResult {
+				return &api.VCSSQLReviewResult{
+					Status: advisor.Warn,
+					Content: []string{
+						fmt.Sprintf(
+							"::warning file=%s,line=1,col=1,endColumn=2,title=naming.index.pk (306)::Primary key in table \"book\" mismatches the naming convention, expect \"^pk_book_id$\" but found \"\"%%0ADoc: https://www.bytebase.com/docs/reference/error-code/advisor#306",
+							filePath,
+						),
+						fmt.Sprintf(
+							"::warning file=%s,line=1,col=1,endColumn=2,title=column.required (401)::Table \"book\" requires columns: created_ts, creator_id, updated_ts, updater_id%%0ADoc: https://www.bytebase.com/docs/reference/error-code/advisor#401",
+							filePath,
+						),
+						fmt.Sprintf(
+							"::warning file=%s,line=1,col=1,endColumn=2,title=column.no-null (402)::Column \"name\" in \"public\".\"book\" cannot have NULL value%%0ADoc: https://www.bytebase.com/docs/reference/error-code/advisor#402",
+							filePath,
+						),
+					},
+				}
+			},
+		},
 	}
 
 	for _, test := range tests {
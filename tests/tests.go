@@ -152,10 +152,16 @@ func getTestPort(testName string) int {
 		"TestSchemaAndDataUpdate",
 		"TestVCS/GitLab",
 		"TestVCS/GitHub",
+		"TestVCS/Bitbucket",
+		"TestVCS/BitbucketServer",
+		"TestVCS/Gitea",
+		"TestVCS/AzureDevOps",
 		"TestVCS_SQL_Review/GitLab",
 		"TestVCS_SQL_Review/GitHub",
+		"TestVCS_SQL_Review/Gitea",
 		"TestVCS_SDL/GitLab",
 		"TestVCS_SDL/GitHub",
+		"TestVCS_SDL/Gitea",
 		"TestWildcardInVCSFilePathTemplate/emptyBaseAndMixAsterisks",
 		"TestWildcardInVCSFilePathTemplate/singleAsterisk",
 		"TestWildcardInVCSFilePathTemplate/doubleAsterisks",